@@ -3,6 +3,7 @@ module github.com/gitpod-io/gitpod/blobserve
 go 1.18
 
 require (
+	github.com/andybalholm/brotli v1.0.4
 	github.com/containerd/containerd v1.6.2
 	github.com/docker/cli v20.10.7+incompatible
 	github.com/docker/distribution v2.8.0+incompatible
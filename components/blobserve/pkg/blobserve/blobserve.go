@@ -12,10 +12,14 @@ import (
 	"html"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
@@ -230,7 +234,8 @@ func (reg *Server) serve(w http.ResponseWriter, req *http.Request) {
 		req.URL.Path += "/"
 	}
 
-	w.Header().Set("ETag", hash)
+	// Blobs are content addressed, hence the digest is a strong validator for all files within them.
+	w.Header().Set("ETag", strconv.Quote(hash))
 	w.Header().Set("Cache-Control", "no-cache")
 
 	// http.FileServer has a special case where ServeFile redirects any request where r.URL.Path
@@ -266,9 +271,49 @@ func (reg *Server) serve(w http.ResponseWriter, req *http.Request) {
 	if workdir != "" {
 		fs = prefixingFilesystem{Prefix: workdir, FS: fs}
 	}
+	if efs, ok := fs.(encodedFileSystem); ok && serveEncoded(w, req, efs, imagePath, hash) {
+		return
+	}
 	http.StripPrefix(pathPrefix, http.FileServer(fs)).ServeHTTP(w, req)
 }
 
+// serveEncoded serves the precompressed variant of a file if the client accepts one.
+// Returns false if no suitable variant exists, in which case the caller is expected to serve the file in identity encoding.
+func serveEncoded(w http.ResponseWriter, req *http.Request, fs encodedFileSystem, name string, hash string) (served bool) {
+	if strings.HasSuffix(name, "/") {
+		return false
+	}
+	// Any file we have precompressed variants for can be served in different encodings.
+	// Caches need to know about this, irregardless of which encoding we serve in this response.
+	if _, ok := compressibleExtensions[strings.ToLower(path.Ext(name))]; ok {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	enc := negotiateEncoding(req.Header.Get("Accept-Encoding"))
+	if enc == "" {
+		return false
+	}
+	f, err := fs.OpenEncoded(name, enc)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		return false
+	}
+
+	ctype := mime.TypeByExtension(path.Ext(name))
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Encoding", string(enc))
+	w.Header().Set("ETag", strconv.Quote(hash+"-"+string(enc)))
+	http.ServeContent(w, req, name, stat.ModTime(), f)
+	return true
+}
+
 func inlineVars(req *http.Request, r io.ReadSeeker, inlineReplacements []InlineReplacement) (io.ReadSeeker, error) {
 	inlineVarsValue := req.Header.Get("X-BlobServe-InlineVars")
 	if len(inlineReplacements) == 0 || inlineVarsValue == "" {
@@ -317,3 +362,11 @@ type prefixingFilesystem struct {
 func (p prefixingFilesystem) Open(name string) (http.File, error) {
 	return p.FS.Open(filepath.Join(p.Prefix, name))
 }
+
+func (p prefixingFilesystem) OpenEncoded(name string, enc contentEncoding) (http.File, error) {
+	efs, ok := p.FS.(encodedFileSystem)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return efs.OpenEncoded(filepath.Join(p.Prefix, name), enc)
+}
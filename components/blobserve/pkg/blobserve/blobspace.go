@@ -17,6 +17,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/containerd/containerd/errdefs"
@@ -90,6 +91,10 @@ func (b *diskBlobspace) collectGarbage(interval time.Duration) {
 			if !f.IsDir() {
 				continue
 			}
+			if strings.HasSuffix(f.Name(), compressedSuffix) {
+				// precompressed variants are removed together with their blob
+				continue
+			}
 
 			blob := getGCBlob(b.Location, f)
 			if blob.Size == 0 && time.Since(blob.LastUsed) > minBlobAge {
@@ -99,6 +104,7 @@ func (b *diskBlobspace) collectGarbage(interval time.Duration) {
 				// TODO: also remove this blob if we're not aware of it being initialized at the moment
 				log.WithField("location", blob.F).Info("removing too old unready blob")
 
+				os.RemoveAll(blob.F + compressedSuffix)
 				err = os.RemoveAll(blob.F)
 				if err != nil {
 					log.WithError(err).WithField("location", blob.F).Error("cannot remove blob")
@@ -124,6 +130,7 @@ func (b *diskBlobspace) collectGarbage(interval time.Duration) {
 				os.Remove(fmt.Sprintf("%s.ready", blob.F))
 				os.Remove(fmt.Sprintf("%s.size", blob.F))
 				os.Remove(fmt.Sprintf("%s.used", blob.F))
+				os.RemoveAll(blob.F + compressedSuffix)
 				err = os.RemoveAll(blob.F)
 				if err != nil {
 					log.WithError(err).WithField("location", blob.F).Error("cannot remove blob")
@@ -181,7 +188,7 @@ func (b *diskBlobspace) Get(name string) (fs http.FileSystem, state blobstate) {
 	}
 
 	os.WriteFile(fmt.Sprintf("%s.used", fn), nil, 0644)
-	return http.Dir(fn), blobReady
+	return blobFileSystem{FileSystem: http.Dir(fn), compressed: fn + compressedSuffix}, blobReady
 }
 
 // AddFromTar adds content to this store under the given name.
@@ -220,7 +227,18 @@ func (b *diskBlobspace) AddFromTar(ctx context.Context, name string, in io.Reade
 		}
 	}
 
-	os.WriteFile(fmt.Sprintf("%s.size", fn), []byte(fmt.Sprintf("%d", cw.C)), 0644)
+	// Precompressing happens after the modifications so that the compressed variants match
+	// what we'd serve otherwise. Failing to precompress is not fatal, we just serve identity encoding.
+	size := cw.C
+	compressedSize, err := precompress(ctx, fn, fn+compressedSuffix)
+	if err != nil {
+		log.WithError(err).WithField("name", name).Warn("Blobspace::AddFromTar cannot precompress blob")
+		os.RemoveAll(fn + compressedSuffix)
+	} else {
+		size += compressedSize
+	}
+
+	os.WriteFile(fmt.Sprintf("%s.size", fn), []byte(fmt.Sprintf("%d", size)), 0644)
 	os.WriteFile(fmt.Sprintf("%s.used", fn), nil, 0644)
 	os.WriteFile(fmt.Sprintf("%s.ready", fn), nil, 0644)
 
@@ -0,0 +1,273 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package blobserve
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+)
+
+// contentEncoding is a HTTP content coding we store precompressed variants for
+type contentEncoding string
+
+const (
+	encodingBrotli contentEncoding = "br"
+	encodingGzip   contentEncoding = "gzip"
+)
+
+// precompressedEncodings lists the encodings we precompress files with in order of preference
+var precompressedEncodings = []contentEncoding{encodingBrotli, encodingGzip}
+
+const (
+	// minPrecompressSize is the minimum size of a file for it to be precompressed.
+	// Smaller files do not benefit from compression.
+	minPrecompressSize = 1024
+
+	// brotliQuality balances compression ratio and the time it takes to extract a blob
+	brotliQuality = 9
+
+	// compressedSuffix is appended to a blob's location to produce the location of its precompressed variants
+	compressedSuffix = ".compressed"
+)
+
+// compressibleExtensions lists the file extensions of text-based content worth compressing
+var compressibleExtensions = map[string]struct{}{
+	".css":  {},
+	".html": {},
+	".js":   {},
+	".json": {},
+	".map":  {},
+	".md":   {},
+	".mjs":  {},
+	".svg":  {},
+	".ttf":  {},
+	".txt":  {},
+	".wasm": {},
+	".xml":  {},
+}
+
+func (e contentEncoding) extension() string {
+	switch e {
+	case encodingBrotli:
+		return ".br"
+	case encodingGzip:
+		return ".gz"
+	default:
+		return ""
+	}
+}
+
+func (e contentEncoding) newWriter(w io.Writer) (io.WriteCloser, error) {
+	switch e {
+	case encodingBrotli:
+		return brotli.NewWriterLevel(w, brotliQuality), nil
+	case encodingGzip:
+		return gzip.NewWriterLevel(w, gzip.BestCompression)
+	default:
+		return nil, xerrors.Errorf("unsupported encoding: %s", e)
+	}
+}
+
+// precompress writes compressed variants of all compressible files in src to dst.
+// It returns the total size of the variants written.
+func precompress(ctx context.Context, src, dst string) (size int64, err error) {
+	var files []string
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if _, ok := compressibleExtensions[strings.ToLower(filepath.Ext(path))]; !ok {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() < minPrecompressSize {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return 0, xerrors.Errorf("cannot list files to precompress: %w", err)
+	}
+
+	var (
+		sizes = make([]int64, len(files))
+		work  = make(chan int)
+	)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		defer close(work)
+		for i := range files {
+			select {
+			case work <- i:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	for w := 0; w < runtime.NumCPU(); w++ {
+		eg.Go(func() error {
+			for i := range work {
+				for _, enc := range precompressedEncodings {
+					n, err := compressFile(filepath.Join(src, files[i]), filepath.Join(dst, files[i]+enc.extension()), enc)
+					if err != nil {
+						return err
+					}
+					sizes[i] += n
+				}
+			}
+			return nil
+		})
+	}
+	err = eg.Wait()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, n := range sizes {
+		size += n
+	}
+	return size, nil
+}
+
+// compressFile writes a compressed copy of src to dst. The copy is written atomically
+// so that we never serve partially written files.
+func compressFile(src, dst string, enc contentEncoding) (size int64, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return 0, err
+	}
+	out, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(out.Name())
+		}
+	}()
+
+	cw, err := enc.newWriter(out)
+	if err != nil {
+		return 0, err
+	}
+	_, err = io.Copy(cw, in)
+	if err != nil {
+		return 0, xerrors.Errorf("cannot compress %s: %w", src, err)
+	}
+	err = cw.Close()
+	if err != nil {
+		return 0, xerrors.Errorf("cannot compress %s: %w", src, err)
+	}
+	stat, err := out.Stat()
+	if err != nil {
+		return 0, err
+	}
+	err = out.Close()
+	if err != nil {
+		return 0, err
+	}
+	err = os.Chmod(out.Name(), 0644)
+	if err != nil {
+		return 0, err
+	}
+	err = os.Rename(out.Name(), dst)
+	if err != nil {
+		return 0, err
+	}
+	return stat.Size(), nil
+}
+
+// negotiateEncoding selects the precompressed encoding to serve based on a request's Accept-Encoding header.
+// If the client accepts none of our encodings, it returns an empty encoding.
+func negotiateEncoding(acceptEncoding string) contentEncoding {
+	qvalues := make(map[string]float64)
+	for _, spec := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(spec), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		if p := strings.TrimSpace(params); strings.HasPrefix(p, "q=") {
+			v, err := strconv.ParseFloat(strings.TrimPrefix(p, "q="), 64)
+			if err != nil {
+				continue
+			}
+			q = v
+		}
+		qvalues[name] = q
+	}
+
+	var (
+		res   contentEncoding
+		bestQ float64
+	)
+	for _, enc := range precompressedEncodings {
+		q, ok := qvalues[string(enc)]
+		if !ok {
+			q, ok = qvalues["*"]
+		}
+		if !ok || q <= 0 {
+			continue
+		}
+		// precompressedEncodings is ordered by preference, hence we only switch for a strictly higher q-value
+		if q > bestQ {
+			res, bestQ = enc, q
+		}
+	}
+	return res
+}
+
+// blobFileSystem serves the files of a blob and their precompressed variants
+type blobFileSystem struct {
+	http.FileSystem
+
+	// compressed is the location of the precompressed variants
+	compressed string
+}
+
+// OpenEncoded opens the precompressed variant of a file
+func (b blobFileSystem) OpenEncoded(name string, enc contentEncoding) (http.File, error) {
+	return http.Dir(b.compressed).Open(name + enc.extension())
+}
+
+// encodedFileSystem is a file system which can serve precompressed variants of its files
+type encodedFileSystem interface {
+	http.FileSystem
+	OpenEncoded(name string, enc contentEncoding) (http.File, error)
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package blobserve
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		Name           string
+		AcceptEncoding string
+		Expectation    contentEncoding
+	}{
+		{Name: "empty", AcceptEncoding: "", Expectation: ""},
+		{Name: "identity only", AcceptEncoding: "identity", Expectation: ""},
+		{Name: "gzip", AcceptEncoding: "gzip, deflate", Expectation: encodingGzip},
+		{Name: "br preferred", AcceptEncoding: "gzip, deflate, br", Expectation: encodingBrotli},
+		{Name: "q-values", AcceptEncoding: "br;q=0.5, gzip;q=0.8", Expectation: encodingGzip},
+		{Name: "excluded", AcceptEncoding: "br;q=0, gzip", Expectation: encodingGzip},
+		{Name: "wildcard", AcceptEncoding: "*", Expectation: encodingBrotli},
+		{Name: "wildcard with exclusion", AcceptEncoding: "*, br;q=0", Expectation: encodingGzip},
+		{Name: "case insensitive", AcceptEncoding: "GZIP", Expectation: encodingGzip},
+		{Name: "invalid q-value", AcceptEncoding: "br;q=foo, gzip", Expectation: encodingGzip},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := negotiateEncoding(test.AcceptEncoding)
+			if act != test.Expectation {
+				t.Errorf("negotiateEncoding(%q) = %q, expected %q", test.AcceptEncoding, act, test.Expectation)
+			}
+		})
+	}
+}
+
+func TestPrecompress(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "blob"+compressedSuffix)
+
+	largeJS := strings.Repeat("console.log('hello world');\n", 100)
+	files := map[string]string{
+		"main.js":          largeJS,
+		"sub/dir/main.css": strings.Repeat("body { color: red; }\n", 100),
+		"small.js":         "console.log('hi');",
+		"image.png":        strings.Repeat("\x89PNG", 1000),
+	}
+	for fn, content := range files {
+		err := os.MkdirAll(filepath.Join(src, filepath.Dir(fn)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(src, fn), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size, err := precompress(context.Background(), src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size == 0 {
+		t.Error("precompress() reported no size")
+	}
+
+	var produced []string
+	err = filepath.Walk(dst, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dst, path)
+		produced = append(produced, rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"main.js.br", "main.js.gz", "sub/dir/main.css.br", "sub/dir/main.css.gz"}
+	if diff := cmp.Diff(expected, produced); diff != "" {
+		t.Errorf("precompress() mismatch (-want +got):\n%s", diff)
+	}
+
+	fs := blobFileSystem{FileSystem: http.Dir(src), compressed: dst}
+	for _, enc := range precompressedEncodings {
+		f, err := fs.OpenEncoded("/main.js", enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader
+		switch enc {
+		case encodingBrotli:
+			r = brotli.NewReader(f)
+		case encodingGzip:
+			r, err = gzip.NewReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		content, err := io.ReadAll(r)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(largeJS, string(content)); diff != "" {
+			t.Errorf("decompressed %s content mismatch (-want +got):\n%s", enc, diff)
+		}
+	}
+}
+
+func TestServeEncoded(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "blob"+compressedSuffix)
+	err := os.WriteFile(filepath.Join(src, "main.js"), []byte(strings.Repeat("console.log('hello world');\n", 100)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = precompress(context.Background(), src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := blobFileSystem{FileSystem: http.Dir(src), compressed: dst}
+
+	type Expectation struct {
+		Served          bool
+		ContentEncoding string
+		ContentType     string
+		ETag            string
+		Vary            string
+	}
+	tests := []struct {
+		Name           string
+		Path           string
+		AcceptEncoding string
+		Expectation    Expectation
+	}{
+		{
+			Name:           "brotli",
+			Path:           "/main.js",
+			AcceptEncoding: "gzip, br",
+			Expectation: Expectation{
+				Served:          true,
+				ContentEncoding: "br",
+				ContentType:     "text/javascript; charset=utf-8",
+				ETag:            `"sha256:foo-br"`,
+				Vary:            "Accept-Encoding",
+			},
+		},
+		{
+			Name:           "gzip",
+			Path:           "/main.js",
+			AcceptEncoding: "gzip",
+			Expectation: Expectation{
+				Served:          true,
+				ContentEncoding: "gzip",
+				ContentType:     "text/javascript; charset=utf-8",
+				ETag:            `"sha256:foo-gzip"`,
+				Vary:            "Accept-Encoding",
+			},
+		},
+		{
+			Name:        "identity",
+			Path:        "/main.js",
+			Expectation: Expectation{Vary: "Accept-Encoding"},
+		},
+		{
+			Name:           "no variant",
+			Path:           "/missing.js",
+			AcceptEncoding: "br",
+			Expectation:    Expectation{Vary: "Accept-Encoding"},
+		},
+		{
+			Name:           "directory",
+			Path:           "/",
+			AcceptEncoding: "br",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", test.Path, nil)
+			if test.AcceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.AcceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+
+			served := serveEncoded(rec, req, fs, test.Path, "sha256:foo")
+			act := Expectation{Served: served, Vary: rec.Header().Get("Vary")}
+			if served {
+				act.ContentEncoding = rec.Header().Get("Content-Encoding")
+				act.ContentType = rec.Header().Get("Content-Type")
+				act.ETag = rec.Header().Get("ETag")
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("serveEncoded() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -16,6 +16,8 @@ var (
 		"auth_",
 		"password",
 		"token",
+		"secrets",
+		"privatekey",
 	}
 )
 
@@ -66,6 +66,16 @@ var runCmd = &cobra.Command{
 			log.WithError(err).Fatal("cannot register metrics")
 		}
 
+		if api := smith.ReviewAPI(); api != nil {
+			go func() {
+				err := http.ListenAndServe(cfg.Review.Addr, api)
+				if err != nil {
+					log.WithError(err).Error("review API server failed")
+				}
+			}()
+			log.WithField("addr", cfg.Review.Addr).Info("started review API server")
+		}
+
 		ctx := context.Background()
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
@@ -5,7 +5,10 @@
 package agent
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
 
 	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
 	"github.com/gitpod-io/gitpod/common-go/log"
@@ -23,9 +26,26 @@ import (
 
 // stopWorkspace stops a workspace
 func (agent *Smith) stopWorkspace(supervisorPID int) error {
+	if supervisorPID <= 0 {
+		// kill(2) would signal a whole process group
+		log.WithField("pid", supervisorPID).Info("workspace is not running anymore - not stopping it")
+		return nil
+	}
 	return unix.Kill(supervisorPID, unix.SIGKILL)
 }
 
+// isSupervisorRunning checks if a PID still belongs to the supervisor of a workspace instance
+func isSupervisorRunning(pid int, instanceID string) bool {
+	if pid <= 0 || instanceID == "" {
+		return false
+	}
+	env, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(env, []byte("GITPOD_INSTANCE_ID="+instanceID+"\x00"))
+}
+
 // stopWorkspaceAndBlockUser stops a workspace and blocks the user (who would have guessed?)
 func (agent *Smith) stopWorkspaceAndBlockUser(supervisorPID int, ownerID string) error {
 	err := agent.stopWorkspace(supervisorPID)
@@ -31,6 +31,9 @@ import (
 const (
 	// notificationCacheSize is the history size of notifications we don't want to get notified about again
 	notificationCacheSize = 1000
+
+	// defaultReviewWindow is the period in which confirmed offenses count towards the escalation
+	defaultReviewWindow = 7 * 24 * time.Hour
 )
 
 // Smith can perform operations within a users workspace and judge a user
@@ -46,6 +49,13 @@ type Smith struct {
 
 	detector   detector.ProcessDetector
 	classifier classifier.ProcessClassifier
+
+	review       *ReviewQueue
+	reviewWindow time.Duration
+	// offenses shares the confirmed offenses with the other agent smith instances. Nil if the offense count is per node.
+	offenses *OffenseLedger
+	// supervisorRunning checks if the supervisor of a workspace is still running before we penalize it after review
+	supervisorRunning func(pid int, instanceID string) bool
 }
 
 // NewAgentSmith creates a new agent smith
@@ -96,7 +106,7 @@ func NewAgentSmith(cfg config.Config) (*Smith, error) {
 		}
 	}
 
-	detec, err := detector.NewProcfsDetector()
+	detec, err := newDetector(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -113,6 +123,18 @@ func NewAgentSmith(cfg config.Config) (*Smith, error) {
 				config.GradeKind(config.InfringementExec, common.SeverityBarely): config.PenaltyLimitCPU,
 				config.GradeKind(config.InfringementExec, common.SeverityAudit):  config.PenaltyStopWorkspace,
 				config.GradeKind(config.InfringementExec, common.SeverityVery):   config.PenaltyStopWorkspaceAndBlockUser,
+
+				config.GradeKind(config.InfringementExcessiveCPUUse, common.SeverityBarely): config.PenaltyLimitCPU,
+				config.GradeKind(config.InfringementExcessiveCPUUse, common.SeverityAudit):  config.PenaltyLimitCPU,
+				config.GradeKind(config.InfringementExcessiveCPUUse, common.SeverityVery):   config.PenaltyStopWorkspace,
+
+				config.GradeKind(config.InfringementBlocklistedConnection, common.SeverityBarely): config.PenaltyNone,
+				config.GradeKind(config.InfringementBlocklistedConnection, common.SeverityAudit):  config.PenaltyStopWorkspace,
+				config.GradeKind(config.InfringementBlocklistedConnection, common.SeverityVery):   config.PenaltyStopWorkspaceAndBlockUser,
+
+				config.GradeKind(config.InfringementExcessiveEgress, common.SeverityBarely): config.PenaltyNone,
+				config.GradeKind(config.InfringementExcessiveEgress, common.SeverityAudit):  config.PenaltyStopWorkspace,
+				config.GradeKind(config.InfringementExcessiveEgress, common.SeverityVery):   config.PenaltyStopWorkspace,
 			},
 		},
 		Config:     cfg,
@@ -125,6 +147,7 @@ func NewAgentSmith(cfg config.Config) (*Smith, error) {
 		notifiedInfringements: lru.New(notificationCacheSize),
 		metrics:               m,
 		timeElapsedHandler:    time.Since,
+		supervisorRunning:     isSupervisorRunning,
 	}
 	if cfg.Enforcement.Default != nil {
 		if err := cfg.Enforcement.Default.Validate(); err != nil {
@@ -139,19 +162,72 @@ func NewAgentSmith(cfg config.Config) (*Smith, error) {
 		res.EnforcementRules[repo] = rules
 	}
 
+	if cfg.Review != nil {
+		if err := cfg.Review.Validate(); err != nil {
+			return nil, err
+		}
+		res.reviewWindow = defaultReviewWindow
+		if cfg.Review.WindowHours > 0 {
+			res.reviewWindow = time.Duration(cfg.Review.WindowHours) * time.Hour
+		}
+		res.review, err = NewReviewQueue(cfg.Review.StatePath, res.reviewWindow)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Review.OffensesConfigMap != "" {
+			if clientset == nil {
+				return nil, xerrors.Errorf("review: offensesConfigMap requires kubernetes to be enabled")
+			}
+			res.offenses = NewOffenseLedger(clientset, cfg.KubernetesNamespace, cfg.Review.OffensesConfigMap)
+		}
+	}
+
+	return res, nil
+}
+
+// newDetector produces the process detector, and the detectors for all configured behavioural checks
+func newDetector(cfg config.Config) (detector.ProcessDetector, error) {
+	procfs, err := detector.NewProcfsDetector()
+	if err != nil {
+		return nil, err
+	}
+	res := detector.CompositeDetector{procfs}
+
+	if cfg.ExcessiveCPUCheck != nil {
+		det, err := detector.NewCPUDetector(*cfg.ExcessiveCPUCheck)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, det)
+	}
+	if cfg.NetworkBlocklists != nil {
+		det, err := detector.NewConnectionDetector(*cfg.NetworkBlocklists)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, det)
+	}
+	if cfg.ExcessiveEgressCheck != nil {
+		det, err := detector.NewEgressDetector(*cfg.ExcessiveEgressCheck)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, det)
+	}
+
 	return res, nil
 }
 
 // InfringingWorkspace reports a user's wrongdoing in a workspace
 type InfringingWorkspace struct {
-	SupervisorPID int
-	Namespace     string
-	Pod           string
-	Owner         string
-	InstanceID    string
-	WorkspaceID   string
-	Infringements []Infringement
-	GitRemoteURL  []string
+	SupervisorPID int            `json:"supervisorPID"`
+	Namespace     string         `json:"namespace,omitempty"`
+	Pod           string         `json:"pod,omitempty"`
+	Owner         string         `json:"owner"`
+	InstanceID    string         `json:"instanceID"`
+	WorkspaceID   string         `json:"workspaceID"`
+	Infringements []Infringement `json:"infringements"`
+	GitRemoteURL  []string       `json:"gitRemoteURL,omitempty"`
 }
 
 // VID is an ID unique to this set of infringements
@@ -182,8 +258,20 @@ func (ws InfringingWorkspace) DescribeInfringements(charCount int) string {
 
 // Infringement reports a users particular wrongdoing
 type Infringement struct {
-	Description string
-	Kind        config.GradedInfringementKind
+	Description string                        `json:"description"`
+	Kind        config.GradedInfringementKind `json:"kind"`
+	Evidence    *Evidence                     `json:"evidence,omitempty"`
+}
+
+// Evidence backs an infringement up for admins to review
+type Evidence struct {
+	Path        string   `json:"path,omitempty"`
+	CommandLine []string `json:"commandLine,omitempty"`
+	// Classifier and Signature describe the blocklist entry the process matched
+	Classifier string `json:"classifier,omitempty"`
+	Signature  string `json:"signature,omitempty"`
+	// CPUHistory is the CPU use of the workspace in cores per sample, oldest first
+	CPUHistory []float64 `json:"cpuHistory,omitempty"`
 }
 
 // defaultRuleset is the name ("remote origin URL") of the default enforcement rules
@@ -225,6 +313,11 @@ func (agent *Smith) Start(ctx context.Context, callback func(InfringingWorkspace
 					workspaces[i.Workspace.PID] = i.Workspace
 				}
 				wsMutex.Unlock()
+				// processes with a finding have been judged by their detector already
+				if i.Finding != nil {
+					clo <- classifiedProcess{P: i}
+					continue
+				}
 				// perform classification of the process
 				class, err := agent.classifier.Matches(i.Path, i.CommandLine)
 				// optimisation: early out to not block on the CLO chan
@@ -270,33 +363,146 @@ func (agent *Smith) Start(ctx context.Context, callback func(InfringingWorkspace
 				log.WithError(err).WithFields(log.OWI(proc.Workspace.OwnerID, proc.Workspace.WorkspaceID, proc.Workspace.InstanceID)).WithField("path", proc.Path).Error("cannot classify process")
 				continue
 			}
-			if cl == nil || cl.Level == classifier.LevelNoMatch {
+			infringement, ok := toInfringement(proc, cl)
+			if !ok {
 				continue
 			}
 
-			_, _ = agent.Penalize(InfringingWorkspace{
+			ws := InfringingWorkspace{
 				SupervisorPID: proc.Workspace.PID,
 				Owner:         proc.Workspace.OwnerID,
+				WorkspaceID:   proc.Workspace.WorkspaceID,
 				InstanceID:    proc.Workspace.InstanceID,
 				GitRemoteURL:  []string{proc.Workspace.GitURL},
-				Infringements: []Infringement{
-					{Kind: config.GradeKind(config.InfringementExec, common.Severity(cl.Level)), Description: fmt.Sprintf("%s: %s", cl.Classifier, cl.Message)},
-				},
-			})
+				Infringements: []Infringement{infringement},
+			}
+			if agent.review != nil {
+				agent.queueForReview(ws, callback)
+				continue
+			}
+			_, _ = agent.Penalize(ws)
 		}
 	}
 }
 
+// queueForReview adds an infringing workspace to the review queue, and notifies about it once
+func (agent *Smith) queueForReview(ws InfringingWorkspace, callback func(InfringingWorkspace, []config.PenaltyKind)) {
+	owi := log.OWI(ws.Owner, ws.WorkspaceID, ws.InstanceID)
+
+	penalties := agent.penaltiesFor(ws)
+	if len(penalties) == 0 {
+		return
+	}
+	item, isNew, err := agent.review.Add(ws, penalties, time.Now())
+	if err != nil {
+		log.WithError(err).WithFields(owi).Error("cannot queue infringement for review")
+		return
+	}
+	if !isNew {
+		return
+	}
+	agent.metrics.reviewItems.WithLabelValues(string(ReviewPending)).Inc()
+	log.WithField("infringement", ws.Infringements).WithField("review", item.ID).WithFields(owi).Info("queued infringement for review")
+
+	if callback != nil {
+		callback(ws, nil)
+	}
+}
+
+// toInfringement turns the finding of a detector or the classification of a process into an infringement.
+// Returns false if the process does not infringe.
+func toInfringement(proc detector.Process, cl *classifier.Classification) (Infringement, bool) {
+	evidence := &Evidence{
+		Path:        proc.Path,
+		CommandLine: proc.CommandLine,
+	}
+	if f := proc.Finding; f != nil {
+		evidence.CPUHistory = f.CPUHistory
+		return Infringement{Kind: config.GradeKind(f.Kind, f.Severity), Description: f.Description, Evidence: evidence}, true
+	}
+	if cl == nil || cl.Level == classifier.LevelNoMatch {
+		return Infringement{}, false
+	}
+	evidence.Classifier = cl.Classifier
+	evidence.Signature = cl.Message
+	return Infringement{Kind: config.GradeKind(config.InfringementExec, common.Severity(cl.Level)), Description: fmt.Sprintf("%s: %s", cl.Classifier, cl.Message), Evidence: evidence}, true
+}
+
 // Penalize acts on infringements and e.g. stops pods
 func (agent *Smith) Penalize(ws InfringingWorkspace) ([]config.PenaltyKind, error) {
+	penalty := agent.penaltiesFor(ws)
+	return penalty, agent.applyPenalties(ws, penalty)
+}
+
+// ApproveReview confirms a queued infringement as offense and applies its penalties,
+// capped by the escalation step the owner's confirmed offenses within the review window have reached
+func (agent *Smith) ApproveReview(id, reviewer, comment string) (ReviewItem, error) {
+	if agent.review == nil {
+		return ReviewItem{}, xerrors.Errorf("review mode is not enabled")
+	}
+
+	now := time.Now()
+	item, err := agent.review.Resolve(id, ReviewApproved, reviewer, comment, now)
+	if err != nil {
+		return ReviewItem{}, err
+	}
+	agent.metrics.reviewItems.WithLabelValues(string(ReviewApproved)).Inc()
+
+	ws := item.Workspace
+	since := now.Add(-agent.reviewWindow)
+	offenses := agent.review.ConfirmedOffenses(ws.Owner, since)
+	if agent.offenses != nil {
+		shared, err := agent.offenses.Record(context.Background(), ws.Owner, id, now, since)
+		if err != nil {
+			log.WithError(err).WithField("review", id).Warn("cannot share confirmed offense - counting the offenses confirmed on this node only")
+		} else if shared > offenses {
+			offenses = shared
+		}
+	}
+	penalties := escalate(item.Penalties, agent.Config.Review.Escalation, offenses)
+	if !agent.supervisorRunning(ws.SupervisorPID, ws.InstanceID) {
+		// The workspace stopped while the infringement waited for review. Its supervisor PID
+		// may belong to another process by now, which we must not stop.
+		ws.SupervisorPID = 0
+	}
+	log.WithField("review", id).WithField("offenses", offenses).WithField("penalties", penalties).WithFields(log.OWI(ws.Owner, ws.WorkspaceID, ws.InstanceID)).Info("infringement confirmed")
+
+	penaltyErr := agent.applyPenalties(ws, penalties)
+	err = agent.review.RecordPenalties(id, penalties, penaltyErr)
+	if err != nil {
+		log.WithError(err).WithField("review", id).Warn("cannot record applied penalties")
+	}
+	return agent.review.Get(id)
+}
+
+// DismissReview marks a queued infringement as false positive. No penalty is applied.
+func (agent *Smith) DismissReview(id, reviewer, comment string) (ReviewItem, error) {
+	if agent.review == nil {
+		return ReviewItem{}, xerrors.Errorf("review mode is not enabled")
+	}
+
+	item, err := agent.review.Resolve(id, ReviewDismissed, reviewer, comment, time.Now())
+	if err != nil {
+		return ReviewItem{}, err
+	}
+	agent.metrics.reviewItems.WithLabelValues(string(ReviewDismissed)).Inc()
+	log.WithField("review", id).WithFields(log.OWI(item.Workspace.Owner, item.Workspace.WorkspaceID, item.Workspace.InstanceID)).Info("infringement dismissed")
+	return item, nil
+}
+
+// penaltiesFor returns the penalties the enforcement rules call for
+func (agent *Smith) penaltiesFor(ws InfringingWorkspace) []config.PenaltyKind {
 	var remoteURL string
 	if len(ws.GitRemoteURL) > 0 {
 		remoteURL = ws.GitRemoteURL[0]
 	}
+	return getPenalty(agent.EnforcementRules[defaultRuleset], agent.EnforcementRules[remoteURL], ws.Infringements)
+}
 
+// applyPenalties applies the first penalty to an infringing workspace
+func (agent *Smith) applyPenalties(ws InfringingWorkspace, penalty []config.PenaltyKind) error {
 	owi := log.OWI(ws.Owner, ws.WorkspaceID, ws.InstanceID)
 
-	penalty := getPenalty(agent.EnforcementRules[defaultRuleset], agent.EnforcementRules[remoteURL], ws.Infringements)
 	for _, p := range penalty {
 		switch p {
 		case config.PenaltyStopWorkspace:
@@ -307,7 +513,7 @@ func (agent *Smith) Penalize(ws InfringingWorkspace) ([]config.PenaltyKind, erro
 				log.WithError(err).WithFields(owi).Debug("failed to stop workspace")
 				agent.metrics.penaltyFailures.WithLabelValues(string(p), err.Error()).Inc()
 			}
-			return penalty, err
+			return err
 		case config.PenaltyStopWorkspaceAndBlockUser:
 			log.WithField("infringement", ws.Infringements).WithFields(owi).Info("stopping workspace and blocking user")
 			agent.metrics.penaltyAttempts.WithLabelValues(string(p)).Inc()
@@ -316,7 +522,7 @@ func (agent *Smith) Penalize(ws InfringingWorkspace) ([]config.PenaltyKind, erro
 				log.WithError(err).WithFields(owi).Debug("failed to stop workspace and block user")
 				agent.metrics.penaltyFailures.WithLabelValues(string(p), err.Error()).Inc()
 			}
-			return penalty, err
+			return err
 		case config.PenaltyLimitCPU:
 			log.WithField("infringement", ws.Infringements).WithFields(owi).Info("limiting CPU")
 			agent.metrics.penaltyAttempts.WithLabelValues(string(p)).Inc()
@@ -325,11 +531,11 @@ func (agent *Smith) Penalize(ws InfringingWorkspace) ([]config.PenaltyKind, erro
 				log.WithError(err).WithFields(owi).Debug("failed to limit CPU")
 				agent.metrics.penaltyFailures.WithLabelValues(string(p), err.Error()).Inc()
 			}
-			return penalty, err
+			return err
 		}
 	}
 
-	return penalty, nil
+	return nil
 }
 
 func findEnforcementRules(rules map[string]config.EnforcementRules, remoteURL string) config.EnforcementRules {
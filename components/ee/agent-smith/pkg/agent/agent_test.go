@@ -8,8 +8,10 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/classifier"
 	"github.com/gitpod-io/gitpod/agent-smith/pkg/common"
 	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/detector"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -33,6 +35,15 @@ func TestGetPenalty(t *testing.T) {
 			Infringement: []Infringement{{Kind: config.GradeKind(config.InfringementExec, common.SeverityAudit)}},
 			Penalties:    []config.PenaltyKind{config.PenaltyStopWorkspace},
 		},
+		{
+			Desc: "graded per kind",
+			Default: config.EnforcementRules{
+				config.GradeKind(config.InfringementExcessiveCPUUse, common.SeverityAudit):       config.PenaltyLimitCPU,
+				config.GradeKind(config.InfringementBlocklistedConnection, common.SeverityAudit): config.PenaltyStopWorkspace,
+			},
+			Infringement: []Infringement{{Kind: config.GradeKind(config.InfringementExcessiveCPUUse, common.SeverityAudit)}},
+			Penalties:    []config.PenaltyKind{config.PenaltyLimitCPU},
+		},
 		{
 			Desc:         "repo override",
 			Default:      config.EnforcementRules{config.GradeKind(config.InfringementExec, common.SeverityAudit): config.PenaltyStopWorkspace},
@@ -54,6 +65,60 @@ func TestGetPenalty(t *testing.T) {
 	}
 }
 
+func TestToInfringement(t *testing.T) {
+	type Expectation struct {
+		Infringement Infringement
+		OK           bool
+	}
+	tests := []struct {
+		Desc           string
+		Process        detector.Process
+		Classification *classifier.Classification
+		Expectation    Expectation
+	}{
+		{
+			Desc:           "no match",
+			Classification: &classifier.Classification{Level: classifier.LevelNoMatch},
+		},
+		{
+			Desc:           "classified process",
+			Process:        detector.Process{Path: "/usr/bin/miner", CommandLine: []string{"miner", "--all-cores"}},
+			Classification: &classifier.Classification{Level: classifier.LevelVery, Classifier: "sig_very", Message: "matched miner"},
+			Expectation: Expectation{
+				Infringement: Infringement{
+					Kind:        config.GradeKind(config.InfringementExec, common.SeverityVery),
+					Description: "sig_very: matched miner",
+					Evidence:    &Evidence{Path: "/usr/bin/miner", CommandLine: []string{"miner", "--all-cores"}, Classifier: "sig_very", Signature: "matched miner"},
+				},
+				OK:           true,
+			},
+		},
+		{
+			Desc: "finding",
+			Process: detector.Process{Finding: &detector.Finding{
+				Kind:        config.InfringementExcessiveEgress,
+				Severity:    common.SeverityBarely,
+				Description: "sent a lot",
+			}},
+			Expectation: Expectation{
+				Infringement: Infringement{Kind: config.GradeKind(config.InfringementExcessiveEgress, common.SeverityBarely), Description: "sent a lot", Evidence: &Evidence{}},
+				OK:           true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			var act Expectation
+			act.Infringement, act.OK = toInfringement(test.Process, test.Classification)
+
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestFindEnforcementRules(t *testing.T) {
 	ra := config.EnforcementRules{config.GradeKind(config.InfringementExec, common.SeverityAudit): config.PenaltyLimitCPU}
 	tests := []struct {
@@ -17,6 +17,7 @@ type metrics struct {
 	classificationBackpressureInCount  prometheus.GaugeFunc
 	classificationBackpressureOutCount prometheus.GaugeFunc
 	classificationBackpressureInDrop   prometheus.Counter
+	reviewItems                        *prometheus.CounterVec
 
 	mu sync.RWMutex
 	cl []prometheus.Collector
@@ -47,10 +48,17 @@ func newAgentMetrics() *metrics {
 		Name:      "classification_backpressure_in_drop_total",
 		Help:      "total count of processes that went unclassified because of backpressure",
 	})
+	m.reviewItems = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gitpod",
+		Subsystem: "agent_smith",
+		Name:      "review_items_total",
+		Help:      "total count of infringements queued for review (pending), and approved or dismissed by an admin",
+	}, []string{"status"})
 	m.cl = []prometheus.Collector{
 		m.penaltyAttempts,
 		m.penaltyFailures,
 		m.classificationBackpressureInDrop,
+		m.reviewItems,
 	}
 	return m
 }
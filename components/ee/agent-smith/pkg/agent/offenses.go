@@ -0,0 +1,114 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// OffenseLedger shares the confirmed offenses of users among all agent smith instances.
+// Every instance keeps its own review queue, but the escalation must count the offenses
+// confirmed on any node. The ledger is a config map which maps the owner to the IDs of their
+// confirmed review items and the time they were confirmed.
+type OffenseLedger struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewOffenseLedger produces a ledger backed by the config map name in namespace.
+// The config map is created on the first confirmed offense.
+func NewOffenseLedger(client kubernetes.Interface, namespace, name string) *OffenseLedger {
+	return &OffenseLedger{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// Record adds the offense of owner confirmed with review item id at the given time, and returns the number
+// of offenses of owner confirmed after since on any node, including this one. Offenses confirmed before since
+// are dropped from the ledger. Recording the same review item twice counts it once.
+func (l *OffenseLedger) Record(ctx context.Context, owner, id string, confirmed, since time.Time) (int, error) {
+	var res int
+	err := retry.OnError(retry.DefaultBackoff, func(err error) bool {
+		// another instance created or changed the config map in the meantime
+		return k8serr.IsConflict(err) || k8serr.IsAlreadyExists(err)
+	}, func() error {
+		res = 0
+		cms := l.client.CoreV1().ConfigMaps(l.namespace)
+		cm, err := cms.Get(ctx, l.name, metav1.GetOptions{})
+		create := k8serr.IsNotFound(err)
+		if create {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      l.name,
+					Namespace: l.namespace,
+				},
+			}
+		} else if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+
+		for o, fc := range cm.Data {
+			var offenses map[string]time.Time
+			err := json.Unmarshal([]byte(fc), &offenses)
+			if err != nil {
+				return xerrors.Errorf("cannot unmarshal offenses of %s: %w", o, err)
+			}
+			if o == owner {
+				offenses[id] = confirmed
+			}
+			for oid, t := range offenses {
+				if t.Before(since) {
+					delete(offenses, oid)
+				}
+			}
+			if len(offenses) == 0 {
+				delete(cm.Data, o)
+				continue
+			}
+			if o == owner {
+				res = len(offenses)
+			}
+			fc, err := json.Marshal(offenses)
+			if err != nil {
+				return err
+			}
+			cm.Data[o] = string(fc)
+		}
+		if _, exists := cm.Data[owner]; !exists && !confirmed.Before(since) {
+			fc, err := json.Marshal(map[string]time.Time{id: confirmed})
+			if err != nil {
+				return err
+			}
+			cm.Data[owner] = string(fc)
+			res = 1
+		}
+
+		if create {
+			_, err = cms.Create(ctx, cm, metav1.CreateOptions{})
+		} else {
+			_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return 0, xerrors.Errorf("cannot record offense in %s: %w", l.name, err)
+	}
+	return res, nil
+}
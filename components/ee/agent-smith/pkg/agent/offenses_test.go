@@ -0,0 +1,132 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOffenseLedger(t *testing.T) {
+	now := time.Date(2022, 5, 20, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-defaultReviewWindow)
+
+	type record struct {
+		Owner     string
+		ID        string
+		Confirmed time.Time
+	}
+	tests := []struct {
+		Desc          string
+		Records       []record
+		Expectation   int
+		ExpectedUsers int
+	}{
+		{
+			Desc:          "first offense",
+			Records:       []record{{"foo", "r1", now}},
+			Expectation:   1,
+			ExpectedUsers: 1,
+		},
+		{
+			Desc:          "offenses on different nodes",
+			Records:       []record{{"foo", "r1", now.Add(-time.Hour)}, {"foo", "r2", now}},
+			Expectation:   2,
+			ExpectedUsers: 1,
+		},
+		{
+			Desc:          "same review item twice",
+			Records:       []record{{"foo", "r1", now}, {"foo", "r1", now}},
+			Expectation:   1,
+			ExpectedUsers: 1,
+		},
+		{
+			Desc:          "other users do not count",
+			Records:       []record{{"bar", "r1", now}, {"foo", "r2", now}},
+			Expectation:   1,
+			ExpectedUsers: 2,
+		},
+		{
+			Desc:          "offenses outside the window are dropped",
+			Records:       []record{{"bar", "r1", since.Add(-time.Hour)}, {"foo", "r2", since.Add(-time.Hour)}, {"foo", "r3", now}},
+			Expectation:   1,
+			ExpectedUsers: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			ledger := NewOffenseLedger(client, "default", "agent-smith-offenses")
+
+			var (
+				act int
+				err error
+			)
+			for _, r := range test.Records {
+				// offenses outside the window were recorded back when they were within the window
+				s := since
+				if r.Confirmed.Before(s) {
+					s = r.Confirmed
+				}
+				act, err = ledger.Record(context.Background(), r.Owner, r.ID, r.Confirmed, s)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if act != test.Expectation {
+				t.Errorf("unexpected offense count: want %d, got %d", test.Expectation, act)
+			}
+
+			cm, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), "agent-smith-offenses", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cm.Data) != test.ExpectedUsers {
+				t.Errorf("unexpected users in ledger: want %d, got %v", test.ExpectedUsers, cm.Data)
+			}
+		})
+	}
+}
+
+func TestApproveReviewSharesOffenses(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	// two agent smith instances on different nodes, each with its own review queue
+	var smiths []*Smith
+	for i := 0; i < 2; i++ {
+		smith, err := newReviewTestSmith(t, &config.Review{
+			Token:      "secret",
+			Escalation: []config.PenaltyKind{config.PenaltyNone, config.PenaltyStopWorkspace},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		smith.offenses = NewOffenseLedger(client, "default", "agent-smith-offenses")
+		smiths = append(smiths, smith)
+	}
+
+	var expectedApplied = [][]config.PenaltyKind{nil, {config.PenaltyStopWorkspace}}
+	for i, smith := range smiths {
+		smith.queueForReview(infringingWorkspace("foo", "inst"), nil)
+		pending := smith.review.List(ReviewPending)
+		if len(pending) != 1 {
+			t.Fatalf("expected one pending item, got %d", len(pending))
+		}
+
+		item, err := smith.ApproveReview(pending[0].ID, "admin", "confirmed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expectedApplied[i], item.Applied); diff != "" {
+			t.Errorf("unexpected applied penalties for offense %d (-want +got):\n%s", i+1, diff)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package agent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
+	"golang.org/x/xerrors"
+)
+
+// ReviewStatus is the state of an infringement in the review queue
+type ReviewStatus string
+
+const (
+	// ReviewPending means no admin has looked at the infringement yet
+	ReviewPending ReviewStatus = "pending"
+	// ReviewApproved means an admin confirmed the infringement as offense
+	ReviewApproved ReviewStatus = "approved"
+	// ReviewDismissed means an admin found the infringement to be a false positive
+	ReviewDismissed ReviewStatus = "dismissed"
+)
+
+// ErrReviewNotFound is returned for review items which don't exist
+var ErrReviewNotFound = errors.New("review item not found")
+
+// ErrReviewResolved is returned when approving or dismissing a review item which is no longer pending
+var ErrReviewResolved = errors.New("review item is resolved already")
+
+// ReviewItem is an infringing workspace waiting for, or having had, an admin's review
+type ReviewItem struct {
+	ID        string              `json:"id"`
+	Status    ReviewStatus        `json:"status"`
+	Created   time.Time           `json:"created"`
+	Workspace InfringingWorkspace `json:"workspace"`
+	// Penalties are the penalties the enforcement rules call for
+	Penalties []config.PenaltyKind `json:"penalties,omitempty"`
+
+	Reviewed *time.Time `json:"reviewed,omitempty"`
+	Reviewer string     `json:"reviewer,omitempty"`
+	Comment  string     `json:"comment,omitempty"`
+
+	// Applied are the penalties applied after the offense was confirmed
+	Applied []config.PenaltyKind `json:"applied,omitempty"`
+	// Error is set if applying the penalties failed
+	Error string `json:"error,omitempty"`
+}
+
+// ReviewQueue holds infringements until an admin approves or dismisses them.
+// The queue is persisted to a file on every change.
+type ReviewQueue struct {
+	mu    sync.RWMutex
+	path  string
+	items map[string]*ReviewItem
+
+	// retention is how long we keep resolved items around
+	retention time.Duration
+}
+
+type reviewQueueState struct {
+	Items []*ReviewItem `json:"items"`
+}
+
+// NewReviewQueue loads the review queue from path, or creates a new one if the file does not exist.
+// Resolved items are dropped once they are older than retention.
+func NewReviewQueue(path string, retention time.Duration) (*ReviewQueue, error) {
+	q := &ReviewQueue{
+		path:      path,
+		items:     make(map[string]*ReviewItem),
+		retention: retention,
+	}
+
+	fc, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read review queue: %w", err)
+	}
+
+	var state reviewQueueState
+	err = json.Unmarshal(fc, &state)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot unmarshal review queue: %w", err)
+	}
+	for _, item := range state.Items {
+		q.items[item.ID] = item
+	}
+	return q, nil
+}
+
+// Add queues an infringing workspace for review. If the same infringements of the same workspace are pending
+// already, Add returns the pending item and false.
+func (q *ReviewQueue) Add(ws InfringingWorkspace, penalties []config.PenaltyKind, now time.Time) (ReviewItem, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.Status == ReviewPending && item.Workspace.InstanceID == ws.InstanceID && item.Workspace.VID() == ws.VID() {
+			return *item, false, nil
+		}
+	}
+
+	id, err := newReviewID()
+	if err != nil {
+		return ReviewItem{}, false, err
+	}
+	item := &ReviewItem{
+		ID:        id,
+		Status:    ReviewPending,
+		Created:   now,
+		Workspace: ws,
+		Penalties: penalties,
+	}
+	q.items[id] = item
+	q.prune(now)
+
+	err = q.persist()
+	if err != nil {
+		delete(q.items, id)
+		return ReviewItem{}, false, err
+	}
+	return *item, true, nil
+}
+
+// List returns all items with the given status, oldest first. An empty status lists all items.
+func (q *ReviewQueue) List(status ReviewStatus) []ReviewItem {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	res := make([]ReviewItem, 0, len(q.items))
+	for _, item := range q.items {
+		if status != "" && item.Status != status {
+			continue
+		}
+		res = append(res, *item)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Created.Equal(res[j].Created) {
+			return res[i].ID < res[j].ID
+		}
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res
+}
+
+// Get returns a single item
+func (q *ReviewQueue) Get(id string) (ReviewItem, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	item, ok := q.items[id]
+	if !ok {
+		return ReviewItem{}, ErrReviewNotFound
+	}
+	return *item, nil
+}
+
+// Resolve approves or dismisses a pending item
+func (q *ReviewQueue) Resolve(id string, status ReviewStatus, reviewer, comment string, now time.Time) (ReviewItem, error) {
+	if status != ReviewApproved && status != ReviewDismissed {
+		return ReviewItem{}, xerrors.Errorf("cannot resolve review item as %q", status)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok := q.items[id]
+	if !ok {
+		return ReviewItem{}, ErrReviewNotFound
+	}
+	if item.Status != ReviewPending {
+		return ReviewItem{}, ErrReviewResolved
+	}
+
+	prev := *item
+	item.Status = status
+	item.Reviewed = &now
+	item.Reviewer = reviewer
+	item.Comment = comment
+
+	err := q.persist()
+	if err != nil {
+		*item = prev
+		return ReviewItem{}, err
+	}
+	return *item, nil
+}
+
+// RecordPenalties stores the outcome of applying the penalties for an approved item
+func (q *ReviewQueue) RecordPenalties(id string, applied []config.PenaltyKind, penaltyErr error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok := q.items[id]
+	if !ok {
+		return ErrReviewNotFound
+	}
+	item.Applied = applied
+	item.Error = ""
+	if penaltyErr != nil {
+		item.Error = penaltyErr.Error()
+	}
+	return q.persist()
+}
+
+// ConfirmedOffenses counts the approved items of a user which were reviewed after since
+func (q *ReviewQueue) ConfirmedOffenses(owner string, since time.Time) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	var res int
+	for _, item := range q.items {
+		if item.Status != ReviewApproved || item.Workspace.Owner != owner || item.Reviewed == nil {
+			continue
+		}
+		if item.Reviewed.Before(since) {
+			continue
+		}
+		res++
+	}
+	return res
+}
+
+// prune drops resolved items older than the retention period. Callers must hold the lock.
+func (q *ReviewQueue) prune(now time.Time) {
+	if q.retention <= 0 {
+		return
+	}
+	for id, item := range q.items {
+		if item.Status == ReviewPending || item.Reviewed == nil {
+			continue
+		}
+		if now.Sub(*item.Reviewed) > q.retention {
+			delete(q.items, id)
+		}
+	}
+}
+
+// persist writes the queue to its file. Callers must hold the lock.
+func (q *ReviewQueue) persist() error {
+	state := reviewQueueState{Items: make([]*ReviewItem, 0, len(q.items))}
+	for _, item := range q.items {
+		state.Items = append(state.Items, item)
+	}
+	sort.Slice(state.Items, func(i, j int) bool { return state.Items[i].ID < state.Items[j].ID })
+
+	fc, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("cannot marshal review queue: %w", err)
+	}
+
+	// write to a temporary file first so that we never leave a partially written queue behind
+	tmp, err := ioutil.TempFile(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return xerrors.Errorf("cannot persist review queue: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(fc)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return xerrors.Errorf("cannot persist review queue: %w", err)
+	}
+	err = os.Rename(tmp.Name(), q.path)
+	if err != nil {
+		return xerrors.Errorf("cannot persist review queue: %w", err)
+	}
+	return nil
+}
+
+func newReviewID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", xerrors.Errorf("cannot produce review ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// escalate caps the penalties the enforcement rules call for by the escalation step of the n-th confirmed offense
+func escalate(penalties, escalation []config.PenaltyKind, offenses int) []config.PenaltyKind {
+	if len(escalation) == 0 {
+		return penalties
+	}
+	if offenses < 1 {
+		offenses = 1
+	}
+	if offenses > len(escalation) {
+		offenses = len(escalation)
+	}
+	step := escalation[offenses-1]
+
+	var (
+		res  []config.PenaltyKind
+		seen = make(map[config.PenaltyKind]struct{})
+	)
+	for _, p := range penalties {
+		if p.Severity() > step.Severity() {
+			p = step
+		}
+		if p == config.PenaltyNone {
+			continue
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		res = append(res, p)
+	}
+	return res
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/common"
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
+	"github.com/google/go-cmp/cmp"
+)
+
+func infringingWorkspace(owner, instanceID string) InfringingWorkspace {
+	return InfringingWorkspace{
+		SupervisorPID: 42,
+		Owner:         owner,
+		InstanceID:    instanceID,
+		WorkspaceID:   "ws-" + instanceID,
+		Infringements: []Infringement{
+			{
+				Kind:        config.GradeKind(config.InfringementExec, common.SeverityVery),
+				Description: "sig_very: matched miner",
+				Evidence:    &Evidence{Path: "/usr/bin/miner", CommandLine: []string{"miner"}, Classifier: "sig_very", Signature: "matched miner"},
+			},
+		},
+	}
+}
+
+func TestReviewQueue(t *testing.T) {
+	var (
+		path = filepath.Join(t.TempDir(), "review.json")
+		now  = time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	)
+	q, err := NewReviewQueue(path, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, isNew, err := q.Add(infringingWorkspace("foo", "inst1"), []config.PenaltyKind{config.PenaltyStopWorkspace}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isNew {
+		t.Error("expected first item to be new")
+	}
+	dup, isNew, err := q.Add(infringingWorkspace("foo", "inst1"), []config.PenaltyKind{config.PenaltyStopWorkspace}, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isNew || dup.ID != first.ID {
+		t.Errorf("expected pending duplicate to be merged, got %s", dup.ID)
+	}
+	second, _, err := q.Add(infringingWorkspace("foo", "inst2"), nil, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = q.Resolve(first.ID, ReviewApproved, "admin", "confirmed", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = q.Resolve(first.ID, ReviewDismissed, "admin", "", now.Add(time.Hour))
+	if err != ErrReviewResolved {
+		t.Errorf("expected ErrReviewResolved, got %v", err)
+	}
+	_, err = q.Resolve("does-not-exist", ReviewDismissed, "admin", "", now.Add(time.Hour))
+	if err != ErrReviewNotFound {
+		t.Errorf("expected ErrReviewNotFound, got %v", err)
+	}
+
+	// the queue survives a restart
+	q, err = NewReviewQueue(path, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, item := range q.List(ReviewPending) {
+		ids = append(ids, item.ID)
+	}
+	if diff := cmp.Diff([]string{second.ID}, ids); diff != "" {
+		t.Errorf("unexpected pending items (-want +got):\n%s", diff)
+	}
+	item, err := q.Get(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(infringingWorkspace("foo", "inst1"), item.Workspace); diff != "" {
+		t.Errorf("unexpected workspace (-want +got):\n%s", diff)
+	}
+
+	if n := q.ConfirmedOffenses("foo", now); n != 1 {
+		t.Errorf("expected one confirmed offense, got %d", n)
+	}
+	if n := q.ConfirmedOffenses("foo", now.Add(2*time.Hour)); n != 0 {
+		t.Errorf("expected no confirmed offense within the window, got %d", n)
+	}
+	if n := q.ConfirmedOffenses("bar", now); n != 0 {
+		t.Errorf("expected no confirmed offense of another user, got %d", n)
+	}
+
+	// resolved items are dropped after the retention period
+	_, _, err = q.Add(infringingWorkspace("bar", "inst3"), nil, now.Add(48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Get(first.ID); err != ErrReviewNotFound {
+		t.Errorf("expected resolved item to be pruned, got %v", err)
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	ladder := []config.PenaltyKind{config.PenaltyLimitCPU, config.PenaltyStopWorkspace, config.PenaltyStopWorkspaceAndBlockUser}
+	tests := []struct {
+		Desc       string
+		Penalties  []config.PenaltyKind
+		Escalation []config.PenaltyKind
+		Offenses   int
+		Expected   []config.PenaltyKind
+	}{
+		{"no escalation", []config.PenaltyKind{config.PenaltyStopWorkspaceAndBlockUser}, nil, 1, []config.PenaltyKind{config.PenaltyStopWorkspaceAndBlockUser}},
+		{"first offense", []config.PenaltyKind{config.PenaltyStopWorkspaceAndBlockUser}, ladder, 1, []config.PenaltyKind{config.PenaltyLimitCPU}},
+		{"second offense", []config.PenaltyKind{config.PenaltyStopWorkspaceAndBlockUser}, ladder, 2, []config.PenaltyKind{config.PenaltyStopWorkspace}},
+		{"beyond the ladder", []config.PenaltyKind{config.PenaltyStopWorkspaceAndBlockUser}, ladder, 5, []config.PenaltyKind{config.PenaltyStopWorkspaceAndBlockUser}},
+		{"rules are less severe", []config.PenaltyKind{config.PenaltyLimitCPU}, ladder, 3, []config.PenaltyKind{config.PenaltyLimitCPU}},
+		{"step without penalty", []config.PenaltyKind{config.PenaltyStopWorkspace}, []config.PenaltyKind{config.PenaltyNone, config.PenaltyStopWorkspace}, 1, nil},
+		{"merges capped penalties", []config.PenaltyKind{config.PenaltyStopWorkspace, config.PenaltyLimitCPU}, ladder, 1, []config.PenaltyKind{config.PenaltyLimitCPU}},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			act := escalate(test.Penalties, test.Escalation, test.Offenses)
+			if diff := cmp.Diff(test.Expected, act); diff != "" {
+				t.Errorf("unexpected penalties (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReviewAPI(t *testing.T) {
+	smith, err := newReviewTestSmith(t, &config.Review{
+		Token:      "secret",
+		Escalation: []config.PenaltyKind{config.PenaltyNone, config.PenaltyStopWorkspace},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(smith.ReviewAPI())
+	defer srv.Close()
+
+	do := func(method, path, token string, body interface{}) (int, []byte) {
+		var buf bytes.Buffer
+		if body != nil {
+			_ = json.NewEncoder(&buf).Encode(body)
+		}
+		req, err := http.NewRequest(method, srv.URL+path, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res bytes.Buffer
+		_, _ = res.ReadFrom(resp.Body)
+		return resp.StatusCode, res.Bytes()
+	}
+	decode := func(b []byte, v interface{}) {
+		err := json.Unmarshal(b, v)
+		if err != nil {
+			t.Fatalf("cannot decode %s: %v", string(b), err)
+		}
+	}
+
+	var ids []string
+	for _, inst := range []string{"inst1", "inst2", "inst3"} {
+		smith.queueForReview(infringingWorkspace("foo", inst), nil)
+	}
+
+	if code, _ := do(http.MethodGet, "/reviews", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized without token, got %d", code)
+	}
+	if code, _ := do(http.MethodGet, "/reviews", "wrong", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized with wrong token, got %d", code)
+	}
+
+	code, body := do(http.MethodGet, "/reviews?status=pending", "secret", nil)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", code, body)
+	}
+	var pending []ReviewItem
+	decode(body, &pending)
+	for _, item := range pending {
+		ids = append(ids, item.ID)
+		if diff := cmp.Diff([]config.PenaltyKind{config.PenaltyStopWorkspaceAndBlockUser}, item.Penalties); diff != "" {
+			t.Errorf("unexpected penalties (-want +got):\n%s", diff)
+		}
+	}
+	if len(ids) != 3 {
+		t.Fatalf("expected three pending items, got %d", len(ids))
+	}
+
+	if code, _ := do(http.MethodPost, "/reviews/"+ids[0]+"/approve", "secret", ReviewRequest{}); code != http.StatusBadRequest {
+		t.Errorf("expected bad request without reviewer, got %d", code)
+	}
+	if code, _ := do(http.MethodPost, "/reviews/unknown/approve", "secret", ReviewRequest{Reviewer: "admin"}); code != http.StatusNotFound {
+		t.Errorf("expected not found for unknown item, got %d", code)
+	}
+
+	// the first confirmed offense carries no penalty, the second one stops the workspace
+	var expectedApplied = [][]config.PenaltyKind{nil, {config.PenaltyStopWorkspace}}
+	for i, expected := range expectedApplied {
+		code, body := do(http.MethodPost, "/reviews/"+ids[i]+"/approve", "secret", ReviewRequest{Reviewer: "admin", Comment: "confirmed"})
+		if code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", code, body)
+		}
+		var item ReviewItem
+		decode(body, &item)
+		if item.Status != ReviewApproved || item.Reviewer != "admin" {
+			t.Errorf("unexpected review item: %+v", item)
+		}
+		if diff := cmp.Diff(expected, item.Applied); diff != "" {
+			t.Errorf("unexpected applied penalties for offense %d (-want +got):\n%s", i+1, diff)
+		}
+	}
+
+	code, body = do(http.MethodPost, "/reviews/"+ids[2]+"/dismiss", "secret", ReviewRequest{Reviewer: "admin", Comment: "false positive"})
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", code, body)
+	}
+	if code, _ := do(http.MethodPost, "/reviews/"+ids[2]+"/approve", "secret", ReviewRequest{Reviewer: "admin"}); code != http.StatusConflict {
+		t.Errorf("expected conflict for resolved item, got %d", code)
+	}
+
+	code, body = do(http.MethodGet, "/reviews/"+ids[2], "secret", nil)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", code, body)
+	}
+	var dismissed ReviewItem
+	decode(body, &dismissed)
+	if dismissed.Status != ReviewDismissed || dismissed.Comment != "false positive" || len(dismissed.Applied) != 0 {
+		t.Errorf("unexpected review item: %+v", dismissed)
+	}
+}
+
+// newReviewTestSmith produces an agent smith in review mode which never signals any process
+func newReviewTestSmith(t *testing.T, cfg *config.Review) (*Smith, error) {
+	cfg.StatePath = filepath.Join(t.TempDir(), "review.json")
+	q, err := NewReviewQueue(cfg.StatePath, defaultReviewWindow)
+	if err != nil {
+		return nil, err
+	}
+	return &Smith{
+		Config: config.Config{Review: cfg},
+		EnforcementRules: map[string]config.EnforcementRules{
+			defaultRuleset: {
+				config.GradeKind(config.InfringementExec, common.SeverityVery): config.PenaltyStopWorkspaceAndBlockUser,
+			},
+		},
+		metrics:           newAgentMetrics(),
+		review:            q,
+		reviewWindow:      defaultReviewWindow,
+		supervisorRunning: func(pid int, instanceID string) bool { return false },
+	}, nil
+}
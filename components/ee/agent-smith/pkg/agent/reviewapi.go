@@ -0,0 +1,144 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package agent
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+// ReviewRequest is the body of approve and dismiss requests
+type ReviewRequest struct {
+	Reviewer string `json:"reviewer"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// ReviewAPI serves the review queue to admins:
+//
+//	GET  /reviews?status=pending  lists review items, optionally filtered by status
+//	GET  /reviews/<id>            returns a single review item
+//	POST /reviews/<id>/approve    confirms the offense and applies the penalties
+//	POST /reviews/<id>/dismiss    dismisses the infringement as false positive
+//
+// Approve and dismiss expect a ReviewRequest as body. Returns nil if review mode is not enabled.
+func (agent *Smith) ReviewAPI() http.Handler {
+	if agent.review == nil {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reviews", agent.handleListReviews)
+	mux.HandleFunc("/reviews/", agent.handleReview)
+	return requireToken(agent.Config.Review.Token, mux)
+}
+
+func (agent *Smith) handleListReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := ReviewStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", ReviewPending, ReviewApproved, ReviewDismissed:
+	default:
+		http.Error(w, "unknown status", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, agent.review.List(status))
+}
+
+func (agent *Smith) handleReview(w http.ResponseWriter, r *http.Request) {
+	segs := strings.Split(strings.TrimPrefix(r.URL.Path, "/reviews/"), "/")
+	id := segs[0]
+	if id == "" || len(segs) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(segs) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		item, err := agent.review.Get(id)
+		if err != nil {
+			writeReviewError(w, err)
+			return
+		}
+		writeJSON(w, item)
+		return
+	}
+
+	var resolve func(id, reviewer, comment string) (ReviewItem, error)
+	switch segs[1] {
+	case "approve":
+		resolve = agent.ApproveReview
+	case "dismiss":
+		resolve = agent.DismissReview
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ReviewRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "cannot decode request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Reviewer == "" {
+		http.Error(w, "reviewer is required", http.StatusBadRequest)
+		return
+	}
+
+	item, err := resolve(id, req.Reviewer, req.Comment)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	writeJSON(w, item)
+}
+
+// requireToken rejects requests which don't carry the token as bearer token. An empty token rejects all requests.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if token == "" || !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrReviewNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrReviewResolved):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.WithError(err).Error("cannot handle review request")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.WithError(err).Warn("cannot write review API response")
+	}
+}
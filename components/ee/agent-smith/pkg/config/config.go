@@ -35,6 +35,19 @@ func GetConfig(cfgFile string) (*ServiceConfig, error) {
 		cfg.ProbePath = "/app/probe.o"
 	}
 
+	// the namespace field of ServiceConfig shadows the one of Config
+	if cfg.KubernetesNamespace == "" {
+		cfg.KubernetesNamespace = cfg.Namespace
+	}
+
+	if cfg.Review != nil && cfg.Review.TokenFile != "" {
+		token, err := ioutil.ReadFile(cfg.Review.TokenFile)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot read review token: %v", err)
+		}
+		cfg.Review.Token = strings.TrimSpace(string(token))
+	}
+
 	return &cfg, nil
 }
 
@@ -73,12 +86,6 @@ func (er EnforcementRules) Validate() error {
 		}
 	}
 
-	validPenalties := map[PenaltyKind]struct{}{
-		PenaltyLimitCPU:                  {},
-		PenaltyNone:                      {},
-		PenaltyStopWorkspace:             {},
-		PenaltyStopWorkspaceAndBlockUser: {},
-	}
 	for _, v := range er {
 		if _, ok := validPenalties[v]; !ok {
 			return xerrors.Errorf("%s: unknown penalty", v)
@@ -94,6 +101,12 @@ type InfringementKind string
 const (
 	// InfringementExec means a user executed a blocklisted executable
 	InfringementExec InfringementKind = "blocklisted executable"
+	// InfringementExcessiveCPUUse means a workspace saturated its CPU for a sustained period of time
+	InfringementExcessiveCPUUse InfringementKind = "excessive CPU use"
+	// InfringementBlocklistedConnection means a workspace connected to a blocklisted host, e.g. a mining pool
+	InfringementBlocklistedConnection InfringementKind = "blocklisted connection"
+	// InfringementExcessiveEgress means a workspace sent more traffic than allowed for a sustained period of time
+	InfringementExcessiveEgress InfringementKind = "excessive egress"
 )
 
 // PenaltyKind describes a kind of penalty for a violating workspace
@@ -110,6 +123,27 @@ const (
 	PenaltyStopWorkspaceAndBlockUser PenaltyKind = "stop workspace and block user"
 )
 
+var validPenalties = map[PenaltyKind]struct{}{
+	PenaltyLimitCPU:                  {},
+	PenaltyNone:                      {},
+	PenaltyStopWorkspace:             {},
+	PenaltyStopWorkspaceAndBlockUser: {},
+}
+
+// Severity orders penalties by how severe they are, from PenaltyNone (0) to PenaltyStopWorkspaceAndBlockUser
+func (p PenaltyKind) Severity() int {
+	switch p {
+	case PenaltyLimitCPU:
+		return 1
+	case PenaltyStopWorkspace:
+		return 2
+	case PenaltyStopWorkspaceAndBlockUser:
+		return 3
+	default:
+		return 0
+	}
+}
+
 // GradedInfringementKind is a combination of infringement kind and severity
 type GradedInfringementKind string
 
@@ -138,6 +172,9 @@ func (g GradedInfringementKind) Kind() (InfringementKind, error) {
 
 	validKinds := []InfringementKind{
 		InfringementExec,
+		InfringementExcessiveCPUUse,
+		InfringementBlocklistedConnection,
+		InfringementExcessiveEgress,
 	}
 	for _, k := range validKinds {
 		if string(k) == wopfx {
@@ -148,9 +185,131 @@ func (g GradedInfringementKind) Kind() (InfringementKind, error) {
 	return "", xerrors.Errorf("unknown kind")
 }
 
+// ExcessiveCPUCheck reports workspaces whose CPU use, averaged over a period of time, exceeds a threshold
 type ExcessiveCPUCheck struct {
+	// Threshold is the number of cores a workspace can use on average before it's reported with audit severity
 	Threshold   float32 `json:"threshold"`
 	AverageOver int     `json:"averageOverMinutes"`
+
+	// BarelyThreshold and VeryThreshold optionally grade CPU use below and above the threshold
+	BarelyThreshold float32 `json:"barelyThreshold,omitempty"`
+	VeryThreshold   float32 `json:"veryThreshold,omitempty"`
+}
+
+// Grade returns the severity of the CPU use, and false if it does not exceed any threshold
+func (c ExcessiveCPUCheck) Grade(cores float32) (common.Severity, bool) {
+	return gradeThresholds(float64(cores), float64(c.BarelyThreshold), float64(c.Threshold), float64(c.VeryThreshold))
+}
+
+// ExcessiveEgressCheck reports workspaces whose egress bandwidth, averaged over a period of time, exceeds a threshold
+type ExcessiveEgressCheck struct {
+	// Threshold is the bandwidth in bytes per second a workspace can use on average before it's reported with audit severity
+	Threshold   int64 `json:"thresholdBytesPerSecond"`
+	AverageOver int   `json:"averageOverMinutes"`
+
+	// BarelyThreshold and VeryThreshold optionally grade the bandwidth below and above the threshold
+	BarelyThreshold int64 `json:"barelyThresholdBytesPerSecond,omitempty"`
+	VeryThreshold   int64 `json:"veryThresholdBytesPerSecond,omitempty"`
+}
+
+// Grade returns the severity of the egress bandwidth, and false if it does not exceed any threshold
+func (c ExcessiveEgressCheck) Grade(bytesPerSecond float64) (common.Severity, bool) {
+	return gradeThresholds(bytesPerSecond, float64(c.BarelyThreshold), float64(c.Threshold), float64(c.VeryThreshold))
+}
+
+// gradeThresholds returns the severity of the highest threshold the value exceeds. Zero thresholds are disabled.
+func gradeThresholds(value, barely, audit, very float64) (common.Severity, bool) {
+	switch {
+	case very > 0 && value > very:
+		return common.SeverityVery, true
+	case audit > 0 && value > audit:
+		return common.SeverityAudit, true
+	case barely > 0 && value > barely:
+		return common.SeverityBarely, true
+	default:
+		return "", false
+	}
+}
+
+// NetworkBlocklists list hosts workspaces must not connect to for various levels of infringement
+type NetworkBlocklists struct {
+	Barely *PerLevelNetworkBlocklist `json:"barely,omitempty"`
+	Audit  *PerLevelNetworkBlocklist `json:"audit,omitempty"`
+	Very   *PerLevelNetworkBlocklist `json:"very,omitempty"`
+
+	// RefreshInterval is the interval in minutes in which we resolve the blocklisted hostnames. Defaults to 10 minutes.
+	RefreshInterval int `json:"refreshIntervalMinutes,omitempty"`
+}
+
+// Levels returns the blocklists by their severity
+func (b *NetworkBlocklists) Levels() map[common.Severity]*PerLevelNetworkBlocklist {
+	res := make(map[common.Severity]*PerLevelNetworkBlocklist)
+	if b.Barely != nil {
+		res[common.SeverityBarely] = b.Barely
+	}
+	if b.Audit != nil {
+		res[common.SeverityAudit] = b.Audit
+	}
+	if b.Very != nil {
+		res[common.SeverityVery] = b.Very
+	}
+	return res
+}
+
+// PerLevelNetworkBlocklist lists hosts and ports, e.g. those of mining pools, for a level of infringement
+type PerLevelNetworkBlocklist struct {
+	// Hosts are IP addresses, CIDR ranges or hostnames
+	Hosts []string `json:"hosts,omitempty"`
+	// Ports are remote ports any connection to is blocklisted, e.g. the stratum ports of mining pools
+	Ports []int `json:"ports,omitempty"`
+}
+
+// Review enables the review mode: infringements are queued until an admin confirms or dismisses them,
+// and penalties escalate with the number of confirmed offenses of a user.
+// Each agent smith instance keeps its own queue, i.e. the review API only lists the infringements found on its node.
+// Without OffensesConfigMap the offense count is per node, too.
+type Review struct {
+	// StatePath is the file the review queue is persisted in. It must survive restarts of agent smith.
+	StatePath string `json:"statePath"`
+	// Addr is the address the review API listens on
+	Addr string `json:"addr"`
+	// Token authenticates admins on the review API. Requests must carry it as bearer token.
+	Token string `json:"token,omitempty"`
+	// TokenFile is a file the token is read from, e.g. a mounted secret. It takes precedence over Token.
+	TokenFile string `json:"tokenFile,omitempty"`
+
+	// OffensesConfigMap names a config map in the namespace of agent smith in which all instances share the
+	// confirmed offenses of users, so that the escalation counts the offenses confirmed on any node.
+	// If empty, only the offenses confirmed on the same node count.
+	OffensesConfigMap string `json:"offensesConfigMap,omitempty"`
+
+	// WindowHours is the period in which confirmed offenses of a user count towards the escalation. Defaults to one week.
+	WindowHours int `json:"windowHours,omitempty"`
+	// Escalation lists the most severe penalty for the first, second, ... confirmed offense within the window.
+	// Further offenses get the last penalty. The enforcement rules still decide which penalty an infringement warrants.
+	Escalation []PenaltyKind `json:"escalation,omitempty"`
+}
+
+// Validate returns an error if the review configuration is invalid
+func (r *Review) Validate() error {
+	if r.StatePath == "" {
+		return xerrors.Errorf("review: statePath is required")
+	}
+	if r.Addr == "" {
+		return xerrors.Errorf("review: addr is required")
+	}
+	if r.Token == "" {
+		return xerrors.Errorf("review: token or tokenFile is required")
+	}
+	if r.WindowHours < 0 {
+		return xerrors.Errorf("review: windowHours must not be negative")
+	}
+	for _, p := range r.Escalation {
+		if _, ok := validPenalties[p]; !ok {
+			return xerrors.Errorf("review: %s: unknown penalty", p)
+		}
+	}
+	return nil
 }
 
 type GitpodAPI struct {
@@ -170,10 +329,13 @@ type Config struct {
 
 	Blocklists *Blocklists `json:"blocklists,omitempty"`
 
-	Enforcement       Enforcement        `json:"enforcement,omitempty"`
-	ExcessiveCPUCheck *ExcessiveCPUCheck `json:"excessiveCPUCheck,omitempty"`
-	SlackWebhooks     *SlackWebhooks     `json:"slackWebhooks,omitempty"`
-	Kubernetes        Kubernetes         `json:"kubernetes"`
+	Enforcement          Enforcement           `json:"enforcement,omitempty"`
+	ExcessiveCPUCheck    *ExcessiveCPUCheck    `json:"excessiveCPUCheck,omitempty"`
+	ExcessiveEgressCheck *ExcessiveEgressCheck `json:"excessiveEgressCheck,omitempty"`
+	NetworkBlocklists    *NetworkBlocklists    `json:"networkBlocklists,omitempty"`
+	SlackWebhooks        *SlackWebhooks        `json:"slackWebhooks,omitempty"`
+	Review               *Review               `json:"review,omitempty"`
+	Kubernetes           Kubernetes            `json:"kubernetes"`
 
 	ProbePath string `json:"probePath,omitempty"`
 }
@@ -0,0 +1,139 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/common"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGradedInfringementKind(t *testing.T) {
+	kinds := []InfringementKind{InfringementExec, InfringementExcessiveCPUUse, InfringementBlocklistedConnection, InfringementExcessiveEgress}
+	severities := []common.Severity{common.SeverityBarely, common.SeverityAudit, common.SeverityVery}
+	for _, kind := range kinds {
+		for _, severity := range severities {
+			graded := GradeKind(kind, severity)
+			t.Run(string(graded), func(t *testing.T) {
+				act, err := graded.Kind()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if act != kind {
+					t.Errorf("unexpected kind: %q", act)
+				}
+				if graded.Severity() != severity {
+					t.Errorf("unexpected severity: %q", graded.Severity())
+				}
+			})
+		}
+	}
+}
+
+func TestExcessiveCPUCheckGrade(t *testing.T) {
+	type Expectation struct {
+		Severity common.Severity
+		OK       bool
+	}
+	tests := []struct {
+		Desc        string
+		Check       ExcessiveCPUCheck
+		Cores       float32
+		Expectation Expectation
+	}{
+		{"below threshold", ExcessiveCPUCheck{Threshold: 2}, 1.5, Expectation{}},
+		{"audit", ExcessiveCPUCheck{Threshold: 2}, 2.5, Expectation{common.SeverityAudit, true}},
+		{"barely", ExcessiveCPUCheck{BarelyThreshold: 1, Threshold: 2}, 1.5, Expectation{common.SeverityBarely, true}},
+		{"very", ExcessiveCPUCheck{Threshold: 2, VeryThreshold: 4}, 4.5, Expectation{common.SeverityVery, true}},
+		{"very without audit", ExcessiveCPUCheck{VeryThreshold: 4}, 3, Expectation{}},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			var act Expectation
+			act.Severity, act.OK = test.Check.Grade(test.Cores)
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected Grade (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReviewValidate(t *testing.T) {
+	tests := []struct {
+		Desc   string
+		Review Review
+		Valid  bool
+	}{
+		{"valid", Review{StatePath: "/var/lib/agent-smith/review.json", Addr: ":8081", Token: "secret", Escalation: []PenaltyKind{PenaltyLimitCPU, PenaltyStopWorkspace}}, true},
+		{"missing state path", Review{Addr: ":8081", Token: "secret"}, false},
+		{"missing addr", Review{StatePath: "review.json", Token: "secret"}, false},
+		{"missing token", Review{StatePath: "review.json", Addr: ":8081"}, false},
+		{"token file only", Review{StatePath: "review.json", Addr: ":8081", TokenFile: "/review-token/token"}, false},
+		{"negative window", Review{StatePath: "review.json", Addr: ":8081", Token: "secret", WindowHours: -1}, false},
+		{"unknown penalty", Review{StatePath: "review.json", Addr: ":8081", Token: "secret", Escalation: []PenaltyKind{"ban"}}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			err := test.Review.Validate()
+			if test.Valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !test.Valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestGetConfigReviewTokenFile(t *testing.T) {
+	tests := []struct {
+		Desc        string
+		TokenFile   string
+		Token       string
+		ExpectToken string
+		ExpectErr   bool
+	}{
+		{Desc: "token only", Token: "inline", ExpectToken: "inline"},
+		{Desc: "token file", TokenFile: "token", ExpectToken: "from-file"},
+		{Desc: "token file takes precedence", TokenFile: "token", Token: "inline", ExpectToken: "from-file"},
+		{Desc: "missing token file", TokenFile: "does-not-exist", ExpectErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Desc, func(t *testing.T) {
+			dir := t.TempDir()
+			err := ioutil.WriteFile(filepath.Join(dir, "token"), []byte("from-file\n"), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var tokenFile string
+			if test.TokenFile != "" {
+				tokenFile = filepath.Join(dir, test.TokenFile)
+			}
+			cfg := `{"review":{"statePath":"review.json","addr":":8081","token":"` + test.Token + `","tokenFile":"` + tokenFile + `"}}`
+			cfgFile := filepath.Join(dir, "config.json")
+			err = ioutil.WriteFile(cfgFile, []byte(cfg), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := GetConfig(cfgFile)
+			if test.ExpectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Review.Token != test.ExpectToken {
+				t.Errorf("unexpected token: want %q, got %q", test.ExpectToken, res.Review.Token)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package detector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ ProcessDetector = CompositeDetector{}
+
+// CompositeDetector merges the processes discovered by several detectors
+type CompositeDetector []ProcessDetector
+
+// DiscoverProcesses starts all detectors. Must not be called more than once.
+func (cd CompositeDetector) DiscoverProcesses(ctx context.Context) (<-chan Process, error) {
+	inputs := make([]<-chan Process, 0, len(cd))
+	for _, d := range cd {
+		ps, err := d.DiscoverProcesses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, ps)
+	}
+
+	var (
+		res = make(chan Process, 100)
+		wg  sync.WaitGroup
+	)
+	for _, ps := range inputs {
+		wg.Add(1)
+		go func(ps <-chan Process) {
+			defer wg.Done()
+			for p := range ps {
+				select {
+				case res <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(ps)
+	}
+	go func() {
+		wg.Wait()
+		close(res)
+	}()
+
+	return res, nil
+}
+
+func (cd CompositeDetector) Describe(d chan<- *prometheus.Desc) {
+	for _, det := range cd {
+		det.Describe(d)
+	}
+}
+
+func (cd CompositeDetector) Collect(m chan<- prometheus.Metric) {
+	for _, det := range cd {
+		det.Collect(m)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package detector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
+	"github.com/prometheus/procfs"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// userHZ is the number of clock ticks per second used in /proc/<pid>/stat
+	userHZ = 100
+
+	cpuSampleInterval = 30 * time.Second
+)
+
+// NewCPUDetector produces a detector which reports workspaces that saturate their CPU for a sustained period of time
+func NewCPUDetector(cfg config.ExcessiveCPUCheck) (ProcessDetector, error) {
+	p, err := procfs.NewFS("/proc")
+	if err != nil {
+		return nil, err
+	}
+	s, err := newCPUSampler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newSamplingDetector("cpu", cpuSampleInterval, realProcfs(p), s), nil
+}
+
+type cpuSampler struct {
+	cfg config.ExcessiveCPUCheck
+
+	// cpuTime is the CPU time of each process at the last sample, keyed by process hash
+	cpuTime map[uint64]uint64
+	windows map[int]*slidingWindow
+}
+
+func newCPUSampler(cfg config.ExcessiveCPUCheck) (*cpuSampler, error) {
+	if cfg.AverageOver <= 0 {
+		return nil, xerrors.Errorf("excessive CPU check: averageOverMinutes must be positive")
+	}
+	if cfg.Threshold <= 0 && cfg.BarelyThreshold <= 0 && cfg.VeryThreshold <= 0 {
+		return nil, xerrors.Errorf("excessive CPU check: at least one threshold is required")
+	}
+
+	return &cpuSampler{
+		cfg:     cfg,
+		cpuTime: make(map[uint64]uint64),
+		windows: make(map[int]*slidingWindow),
+	}, nil
+}
+
+func (s *cpuSampler) Sample(now time.Time, workspaces map[int]*workspaceProcesses) []Process {
+	var (
+		res     []Process
+		cpuTime = make(map[uint64]uint64, len(s.cpuTime))
+		seen    = make(map[int]struct{}, len(workspaces))
+	)
+	for id, ws := range workspaces {
+		seen[id] = struct{}{}
+
+		var (
+			used   uint64
+			top    *process
+			topUse uint64
+		)
+		for _, p := range ws.Processes {
+			cpuTime[p.Hash] = p.CPUTime
+			// processes we haven't seen before started after the last sample
+			delta := p.CPUTime - s.cpuTime[p.Hash]
+			if p.CPUTime < s.cpuTime[p.Hash] {
+				delta = 0
+			}
+			used += delta
+			if top == nil || delta > topUse {
+				top, topUse = p, delta
+			}
+		}
+
+		window, ok := s.windows[id]
+		if !ok {
+			window = &slidingWindow{period: time.Duration(s.cfg.AverageOver) * time.Minute}
+			s.windows[id] = window
+		}
+		window.Add(now, float64(used)/userHZ)
+
+		cores, ok := window.Rate()
+		if !ok {
+			continue
+		}
+		severity, ok := s.cfg.Grade(float32(cores))
+		if !ok || top == nil {
+			continue
+		}
+
+		res = append(res, Process{
+			Path:        top.Path,
+			CommandLine: top.Cmdline,
+			Kind:        top.Kind,
+			Workspace:   ws.Workspace,
+			Finding: &Finding{
+				Kind:        config.InfringementExcessiveCPUUse,
+				Severity:    severity,
+				Description: fmt.Sprintf("used %.2f cores on average over the last %d minutes, most by %s", cores, s.cfg.AverageOver, strings.Join(top.Cmdline, " ")),
+				CPUHistory:  window.History(),
+			},
+		})
+		// a workspace needs to infringe for another full period before we report it again
+		window.Reset()
+	}
+
+	for id := range s.windows {
+		if _, ok := seen[id]; !ok {
+			delete(s.windows, id)
+		}
+	}
+	s.cpuTime = cpuTime
+
+	return res
+}
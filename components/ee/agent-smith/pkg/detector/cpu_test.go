@@ -0,0 +1,100 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package detector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/common"
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCPUSampler(t *testing.T) {
+	type Expectation struct {
+		Severity []common.Severity
+	}
+	tests := []struct {
+		Name string
+		// Cores is the number of cores the workload uses in each sample
+		Cores       float64
+		Samples     int
+		Expectation Expectation
+	}{
+		{
+			Name:        "below threshold",
+			Cores:       0.5,
+			Samples:     5,
+			Expectation: Expectation{Severity: []common.Severity{}},
+		},
+		{
+			Name:        "not enough history",
+			Cores:       2,
+			Samples:     2,
+			Expectation: Expectation{Severity: []common.Severity{}},
+		},
+		{
+			Name:        "audit",
+			Cores:       2,
+			Samples:     3,
+			Expectation: Expectation{Severity: []common.Severity{common.SeverityAudit}},
+		},
+		{
+			Name:        "very",
+			Cores:       4,
+			Samples:     3,
+			Expectation: Expectation{Severity: []common.Severity{common.SeverityVery}},
+		},
+		{
+			Name:        "reports again after another period",
+			Cores:       2,
+			Samples:     7,
+			Expectation: Expectation{Severity: []common.Severity{common.SeverityAudit, common.SeverityAudit}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			s, err := newCPUSampler(config.ExcessiveCPUCheck{Threshold: 1, VeryThreshold: 3, AverageOver: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				start      = time.Now()
+				supervisor = &process{PID: 3, Hash: 3, Cmdline: []string{"supervisor", "init"}, Kind: ProcessSupervisor}
+				workload   = &process{PID: 4, Hash: 4, Cmdline: []string{"miner", "--all-cores"}, Kind: ProcessUserWorkload}
+				wss        = map[int]*workspaceProcesses{
+					3: {Workspace: ws, Supervisor: supervisor, Processes: []*process{supervisor, workload}},
+				}
+				act = Expectation{Severity: []common.Severity{}}
+			)
+			for i := 0; i < test.Samples; i++ {
+				now := start.Add(time.Duration(i) * 30 * time.Second)
+				for _, p := range s.Sample(now, wss) {
+					if len(p.Finding.CPUHistory) == 0 {
+						t.Error("expected CPU history")
+					}
+					for _, c := range p.Finding.CPUHistory {
+						if c != test.Cores {
+							t.Errorf("unexpected CPU history: %v", p.Finding.CPUHistory)
+							break
+						}
+					}
+					if diff := cmp.Diff([]string{"miner", "--all-cores"}, p.CommandLine); diff != "" {
+						t.Errorf("unexpected top process (-want +got):\n%s", diff)
+					}
+					act.Severity = append(act.Severity, p.Finding.Severity)
+				}
+				workload.CPUTime += uint64(test.Cores * 30 * userHZ)
+			}
+
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected findings (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
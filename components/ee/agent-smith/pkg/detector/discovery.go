@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"github.com/gitpod-io/gitpod/agent-smith/pkg/common"
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -17,6 +18,21 @@ type Process struct {
 	CommandLine []string
 	Kind        ProcessKind
 	Workspace   *common.Workspace
+
+	// Finding is set by detectors which judge a workspace by its behaviour, e.g. its resource use,
+	// rather than by what it executes. Such processes are not classified.
+	Finding *Finding
+}
+
+// Finding is an infringement a detector observed itself
+type Finding struct {
+	Kind        config.InfringementKind
+	Severity    common.Severity
+	Description string
+
+	// CPUHistory is the CPU use of the workspace in cores per sample, oldest first.
+	// Only set by detectors which sample CPU use.
+	CPUHistory []float64
 }
 
 // ProcessDetector discovers processes on the node
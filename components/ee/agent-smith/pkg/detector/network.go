@@ -0,0 +1,394 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/common"
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/prometheus/procfs"
+	"golang.org/x/xerrors"
+)
+
+const (
+	networkSampleInterval = 30 * time.Second
+
+	defaultBlocklistRefreshInterval = 10 * time.Minute
+	blocklistLookupTimeout          = 10 * time.Second
+
+	// tcpEstablished and tcpSynSent are the connection states in /proc/<pid>/net/tcp we consider outbound connections
+	tcpEstablished = 1
+	tcpSynSent     = 2
+)
+
+// networkProcFS reads the state of the network namespace of a process
+type networkProcFS interface {
+	// Connections returns the remote endpoints of all open TCP connections
+	Connections(pid int) ([]connection, error)
+	// TransmittedBytes returns the number of bytes sent on all but the loopback interface
+	TransmittedBytes(pid int) (uint64, error)
+}
+
+type connection struct {
+	IP   net.IP
+	Port int
+}
+
+func (c connection) String() string {
+	return net.JoinHostPort(c.IP.String(), strconv.Itoa(c.Port))
+}
+
+var _ networkProcFS = realProcfs{}
+
+func (realProcfs) Connections(pid int) ([]connection, error) {
+	fs, err := procfs.NewFS(fmt.Sprintf("/proc/%d", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	var res []connection
+	for _, read := range []func() (procfs.NetTCP, error){fs.NetTCP, fs.NetTCP6} {
+		lines, err := read()
+		if errors.Is(err, os.ErrNotExist) {
+			// e.g. IPv6 is disabled
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lines {
+			if l.St != tcpEstablished && l.St != tcpSynSent {
+				continue
+			}
+			if l.RemAddr.IsLoopback() || l.RemAddr.IsUnspecified() {
+				continue
+			}
+			res = append(res, connection{IP: l.RemAddr, Port: int(l.RemPort)})
+		}
+	}
+	return res, nil
+}
+
+func (fs realProcfs) TransmittedBytes(pid int) (uint64, error) {
+	proc, err := procfs.FS(fs).Proc(pid)
+	if err != nil {
+		return 0, err
+	}
+	dev, err := proc.NetDev()
+	if err != nil {
+		return 0, err
+	}
+
+	var res uint64
+	for name, l := range dev {
+		if name == "lo" {
+			continue
+		}
+		res += l.TxBytes
+	}
+	return res, nil
+}
+
+// NewConnectionDetector produces a detector which reports workspaces that connect to blocklisted hosts
+func NewConnectionDetector(cfg config.NetworkBlocklists) (ProcessDetector, error) {
+	p, err := procfs.NewFS("/proc")
+	if err != nil {
+		return nil, err
+	}
+	bl, err := newNetworkBlocklist(cfg, net.DefaultResolver.LookupIPAddr)
+	if err != nil {
+		return nil, err
+	}
+	return newSamplingDetector("connection", networkSampleInterval, realProcfs(p), newConnectionSampler(realProcfs(p), bl)), nil
+}
+
+// NewEgressDetector produces a detector which reports workspaces that exceed an egress bandwidth for a sustained period of time
+func NewEgressDetector(cfg config.ExcessiveEgressCheck) (ProcessDetector, error) {
+	p, err := procfs.NewFS("/proc")
+	if err != nil {
+		return nil, err
+	}
+	s, err := newEgressSampler(cfg, realProcfs(p))
+	if err != nil {
+		return nil, err
+	}
+	return newSamplingDetector("egress", networkSampleInterval, realProcfs(p), s), nil
+}
+
+type connectionSampler struct {
+	net       networkProcFS
+	blocklist *networkBlocklist
+
+	// reported are the connections we have reported already, per workspace
+	reported map[int]map[string]struct{}
+}
+
+func newConnectionSampler(net networkProcFS, blocklist *networkBlocklist) *connectionSampler {
+	return &connectionSampler{
+		net:       net,
+		blocklist: blocklist,
+		reported:  make(map[int]map[string]struct{}),
+	}
+}
+
+func (s *connectionSampler) Sample(now time.Time, workspaces map[int]*workspaceProcesses) []Process {
+	s.blocklist.refresh(now)
+
+	var res []Process
+	for id, ws := range workspaces {
+		conns, err := s.net.Connections(id)
+		if err != nil {
+			log.WithError(err).WithFields(log.OWI(ws.Workspace.OwnerID, ws.Workspace.WorkspaceID, ws.Workspace.InstanceID)).Debug("cannot list connections of workspace")
+			continue
+		}
+
+		reported, ok := s.reported[id]
+		if !ok {
+			reported = make(map[string]struct{})
+			s.reported[id] = reported
+		}
+		for _, c := range conns {
+			severity, reason, ok := s.blocklist.Match(c)
+			if !ok {
+				continue
+			}
+			if _, ok := reported[c.String()]; ok {
+				continue
+			}
+			reported[c.String()] = struct{}{}
+
+			res = append(res, workspaceFinding(ws, &Finding{
+				Kind:        config.InfringementBlocklistedConnection,
+				Severity:    severity,
+				Description: fmt.Sprintf("connected to %s (%s)", c, reason),
+			}))
+		}
+	}
+
+	for id := range s.reported {
+		if _, ok := workspaces[id]; !ok {
+			delete(s.reported, id)
+		}
+	}
+	return res
+}
+
+type lookupIPAddrFunc func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+// networkBlocklist matches connections against blocklisted networks, hosts and ports, most severe level first
+type networkBlocklist struct {
+	levels []*networkBlocklistLevel
+
+	lookup     lookupIPAddrFunc
+	interval   time.Duration
+	resolvedAt time.Time
+}
+
+type networkBlocklistLevel struct {
+	Severity  common.Severity
+	Networks  []*net.IPNet
+	Hostnames []string
+	Ports     map[int]struct{}
+
+	// resolved maps the IP addresses of the hostnames to the hostname
+	resolved map[string]string
+}
+
+func newNetworkBlocklist(cfg config.NetworkBlocklists, lookup lookupIPAddrFunc) (*networkBlocklist, error) {
+	res := &networkBlocklist{
+		lookup:   lookup,
+		interval: time.Duration(cfg.RefreshInterval) * time.Minute,
+	}
+	if res.interval <= 0 {
+		res.interval = defaultBlocklistRefreshInterval
+	}
+
+	levels := cfg.Levels()
+	for _, severity := range []common.Severity{common.SeverityVery, common.SeverityAudit, common.SeverityBarely} {
+		bl, ok := levels[severity]
+		if !ok {
+			continue
+		}
+
+		lvl := &networkBlocklistLevel{
+			Severity: severity,
+			Ports:    make(map[int]struct{}, len(bl.Ports)),
+			resolved: make(map[string]string),
+		}
+		for _, host := range bl.Hosts {
+			if _, n, err := net.ParseCIDR(host); err == nil {
+				lvl.Networks = append(lvl.Networks, n)
+				continue
+			}
+			if ip := net.ParseIP(host); ip != nil {
+				lvl.Networks = append(lvl.Networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+				continue
+			}
+			if host == "" || strings.ContainsAny(host, "/: ") {
+				return nil, xerrors.Errorf("network blocklist: invalid host %q", host)
+			}
+			lvl.Hostnames = append(lvl.Hostnames, strings.ToLower(host))
+		}
+		for _, port := range bl.Ports {
+			if port <= 0 || port > 65535 {
+				return nil, xerrors.Errorf("network blocklist: invalid port %d", port)
+			}
+			lvl.Ports[port] = struct{}{}
+		}
+		res.levels = append(res.levels, lvl)
+	}
+
+	return res, nil
+}
+
+// refresh resolves the blocklisted hostnames if the last resolution is older than the refresh interval
+func (b *networkBlocklist) refresh(now time.Time) {
+	if !b.resolvedAt.IsZero() && now.Sub(b.resolvedAt) < b.interval {
+		return
+	}
+	b.resolvedAt = now
+
+	for _, lvl := range b.levels {
+		resolved := make(map[string]string, len(lvl.resolved))
+		for _, host := range lvl.Hostnames {
+			ctx, cancel := context.WithTimeout(context.Background(), blocklistLookupTimeout)
+			addrs, err := b.lookup(ctx, host)
+			cancel()
+			if err != nil {
+				log.WithError(err).WithField("host", host).Debug("cannot resolve blocklisted host")
+				// keep the addresses of the last successful resolution
+				for ip, h := range lvl.resolved {
+					if h == host {
+						resolved[ip] = h
+					}
+				}
+				continue
+			}
+			for _, addr := range addrs {
+				resolved[addr.IP.String()] = host
+			}
+		}
+		lvl.resolved = resolved
+	}
+}
+
+// Match returns the severity of the most severe blocklist a connection is on, alongside the reason
+func (b *networkBlocklist) Match(c connection) (severity common.Severity, reason string, ok bool) {
+	for _, lvl := range b.levels {
+		if host, ok := lvl.resolved[c.IP.String()]; ok {
+			return lvl.Severity, "blocklisted host " + host, true
+		}
+		for _, n := range lvl.Networks {
+			if n.Contains(c.IP) {
+				return lvl.Severity, "blocklisted network " + n.String(), true
+			}
+		}
+		if _, ok := lvl.Ports[c.Port]; ok {
+			return lvl.Severity, fmt.Sprintf("blocklisted port %d", c.Port), true
+		}
+	}
+	return "", "", false
+}
+
+type egressSampler struct {
+	cfg config.ExcessiveEgressCheck
+	net networkProcFS
+
+	txBytes map[int]uint64
+	windows map[int]*slidingWindow
+}
+
+func newEgressSampler(cfg config.ExcessiveEgressCheck, net networkProcFS) (*egressSampler, error) {
+	if cfg.AverageOver <= 0 {
+		return nil, xerrors.Errorf("excessive egress check: averageOverMinutes must be positive")
+	}
+	if cfg.Threshold <= 0 && cfg.BarelyThreshold <= 0 && cfg.VeryThreshold <= 0 {
+		return nil, xerrors.Errorf("excessive egress check: at least one threshold is required")
+	}
+
+	return &egressSampler{
+		cfg:     cfg,
+		net:     net,
+		txBytes: make(map[int]uint64),
+		windows: make(map[int]*slidingWindow),
+	}, nil
+}
+
+func (s *egressSampler) Sample(now time.Time, workspaces map[int]*workspaceProcesses) []Process {
+	var (
+		res     []Process
+		txBytes = make(map[int]uint64, len(workspaces))
+	)
+	for id, ws := range workspaces {
+		tx, err := s.net.TransmittedBytes(id)
+		if err != nil {
+			log.WithError(err).WithFields(log.OWI(ws.Workspace.OwnerID, ws.Workspace.WorkspaceID, ws.Workspace.InstanceID)).Debug("cannot read network statistics of workspace")
+			continue
+		}
+		txBytes[id] = tx
+
+		last, ok := s.txBytes[id]
+		if !ok || tx < last {
+			// we need two samples to compute a delta
+			last = tx
+		}
+
+		window, ok := s.windows[id]
+		if !ok {
+			window = &slidingWindow{period: time.Duration(s.cfg.AverageOver) * time.Minute}
+			s.windows[id] = window
+		}
+		window.Add(now, float64(tx-last))
+
+		bps, ok := window.Rate()
+		if !ok {
+			continue
+		}
+		severity, ok := s.cfg.Grade(bps)
+		if !ok {
+			continue
+		}
+
+		res = append(res, workspaceFinding(ws, &Finding{
+			Kind:        config.InfringementExcessiveEgress,
+			Severity:    severity,
+			Description: fmt.Sprintf("sent %.0f bytes per second on average over the last %d minutes", bps, s.cfg.AverageOver),
+		}))
+		// a workspace needs to infringe for another full period before we report it again
+		window.Reset()
+	}
+
+	for id := range s.windows {
+		if _, ok := workspaces[id]; !ok {
+			delete(s.windows, id)
+		}
+	}
+	s.txBytes = txBytes
+
+	return res
+}
+
+// workspaceFinding reports a finding which concerns the workspace as a whole, rather than a particular process
+func workspaceFinding(ws *workspaceProcesses, f *Finding) Process {
+	res := Process{
+		Kind:      ProcessSupervisor,
+		Workspace: ws.Workspace,
+		Finding:   f,
+	}
+	if ws.Supervisor != nil {
+		res.Path = ws.Supervisor.Path
+		res.CommandLine = ws.Supervisor.Cmdline
+	}
+	return res
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package detector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/common"
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
+	"github.com/google/go-cmp/cmp"
+)
+
+type memoryNetwork struct {
+	Conns   map[int][]connection
+	TxBytes map[int]uint64
+}
+
+func (m *memoryNetwork) Connections(pid int) ([]connection, error) {
+	return m.Conns[pid], nil
+}
+
+func (m *memoryNetwork) TransmittedBytes(pid int) (uint64, error) {
+	tx, ok := m.TxBytes[pid]
+	if !ok {
+		return 0, fmt.Errorf("no network namespace")
+	}
+	return tx, nil
+}
+
+func staticLookup(hosts map[string][]string) lookupIPAddrFunc {
+	return func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		ips, ok := hosts[host]
+		if !ok {
+			return nil, fmt.Errorf("no such host")
+		}
+		res := make([]net.IPAddr, len(ips))
+		for i, ip := range ips {
+			res[i] = net.IPAddr{IP: net.ParseIP(ip)}
+		}
+		return res, nil
+	}
+}
+
+func TestNetworkBlocklistMatch(t *testing.T) {
+	bl, err := newNetworkBlocklist(config.NetworkBlocklists{
+		Audit: &config.PerLevelNetworkBlocklist{
+			Hosts: []string{"10.0.0.0/8", "pool.example.com"},
+			Ports: []int{3333},
+		},
+		Very: &config.PerLevelNetworkBlocklist{
+			Hosts: []string{"10.1.2.3"},
+		},
+	}, staticLookup(map[string][]string{"pool.example.com": {"192.0.2.1", "2001:db8::1"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bl.refresh(time.Now())
+
+	type Expectation struct {
+		Severity common.Severity
+		Reason   string
+		Match    bool
+	}
+	tests := []struct {
+		Name        string
+		Conn        connection
+		Expectation Expectation
+	}{
+		{"no match", connection{IP: net.ParseIP("192.0.2.2"), Port: 443}, Expectation{}},
+		{"network", connection{IP: net.ParseIP("10.0.0.1"), Port: 443}, Expectation{common.SeverityAudit, "blocklisted network 10.0.0.0/8", true}},
+		{"most severe level", connection{IP: net.ParseIP("10.1.2.3"), Port: 443}, Expectation{common.SeverityVery, "blocklisted network 10.1.2.3/32", true}},
+		{"resolved host", connection{IP: net.ParseIP("192.0.2.1"), Port: 443}, Expectation{common.SeverityAudit, "blocklisted host pool.example.com", true}},
+		{"resolved IPv6 host", connection{IP: net.ParseIP("2001:db8::1"), Port: 443}, Expectation{common.SeverityAudit, "blocklisted host pool.example.com", true}},
+		{"port", connection{IP: net.ParseIP("192.0.2.2"), Port: 3333}, Expectation{common.SeverityAudit, "blocklisted port 3333", true}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var act Expectation
+			act.Severity, act.Reason, act.Match = bl.Match(test.Conn)
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected Match (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNetworkBlocklistInvalid(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Level config.PerLevelNetworkBlocklist
+	}{
+		{"invalid host", config.PerLevelNetworkBlocklist{Hosts: []string{"pool.example.com:3333"}}},
+		{"invalid port", config.PerLevelNetworkBlocklist{Ports: []int{70000}}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := newNetworkBlocklist(config.NetworkBlocklists{Audit: &test.Level}, staticLookup(nil))
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestConnectionSampler(t *testing.T) {
+	bl, err := newNetworkBlocklist(config.NetworkBlocklists{
+		Audit: &config.PerLevelNetworkBlocklist{Ports: []int{3333}},
+	}, staticLookup(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := &memoryNetwork{Conns: map[int][]connection{
+		3: {
+			{IP: net.ParseIP("192.0.2.1"), Port: 443},
+			{IP: net.ParseIP("192.0.2.2"), Port: 3333},
+		},
+	}}
+	supervisor := &process{PID: 3, Path: "proc/3/exe", Cmdline: []string{"supervisor", "init"}}
+	wss := map[int]*workspaceProcesses{3: {Workspace: ws, Supervisor: supervisor, Processes: []*process{supervisor}}}
+	s := newConnectionSampler(network, bl)
+
+	act := s.Sample(time.Now(), wss)
+	expectation := []Process{
+		{
+			Path:        "proc/3/exe",
+			CommandLine: []string{"supervisor", "init"},
+			Kind:        ProcessSupervisor,
+			Workspace:   ws,
+			Finding: &Finding{
+				Kind:        config.InfringementBlocklistedConnection,
+				Severity:    common.SeverityAudit,
+				Description: "connected to 192.0.2.2:3333 (blocklisted port 3333)",
+			},
+		},
+	}
+	if diff := cmp.Diff(expectation, act); diff != "" {
+		t.Errorf("unexpected findings (-want +got):\n%s", diff)
+	}
+
+	// connections are reported only once
+	act = s.Sample(time.Now(), wss)
+	if len(act) != 0 {
+		t.Errorf("expected no findings, got %v", act)
+	}
+}
+
+func TestEgressSampler(t *testing.T) {
+	s, err := newEgressSampler(config.ExcessiveEgressCheck{Threshold: 1000, BarelyThreshold: 100, AverageOver: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := &memoryNetwork{TxBytes: map[int]uint64{3: 0}}
+	s.net = network
+	wss := map[int]*workspaceProcesses{3: {Workspace: ws}}
+
+	var (
+		start = time.Now()
+		act   []common.Severity
+	)
+	for i, bps := range []uint64{0, 500, 500, 500, 500, 5000, 5000} {
+		network.TxBytes[3] += bps * 30
+		for _, p := range s.Sample(start.Add(time.Duration(i)*30*time.Second), wss) {
+			act = append(act, p.Finding.Severity)
+		}
+	}
+
+	expectation := []common.Severity{common.SeverityBarely, common.SeverityAudit}
+	if diff := cmp.Diff(expectation, act); diff != "" {
+		t.Errorf("unexpected findings (-want +got):\n%s", diff)
+	}
+}
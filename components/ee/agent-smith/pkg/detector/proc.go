@@ -78,6 +78,7 @@ func (fs realProcfs) Discover() map[int]*process {
 		proc.Parent = parent
 		proc.Kind = ProcessUnknown
 		proc.Path = path
+		proc.CPUTime = stat.CPUTime
 		parent.Children = append(parent.Children, proc)
 
 		binary.LittleEndian.PutUint64(digest[0:8], uint64(p.PID))
@@ -93,6 +94,8 @@ func (fs realProcfs) Discover() map[int]*process {
 type stat struct {
 	PPID      int
 	Starttime uint64
+	// CPUTime is the time the process spent in user and kernel mode in clock ticks
+	CPUTime uint64
 }
 
 // statProc returns a limited set of /proc/<pid>/stat content.
@@ -108,10 +111,11 @@ func statProc(pid int) (*stat, error) {
 
 func parseStat(r io.Reader) (res *stat, err error) {
 	var (
-		ppid      uint64
-		foundPPID bool
-		starttime uint64
-		i         = -1
+		ppid         uint64
+		foundPPID    bool
+		starttime    uint64
+		utime, stime uint64
+		i            = -1
 	)
 
 	scan := bufio.NewScanner(r)
@@ -129,6 +133,12 @@ func parseStat(r io.Reader) (res *stat, err error) {
 			ppid, err = strconv.ParseUint(string(text), 10, 64)
 			foundPPID = true
 		}
+		if i == 12 {
+			utime, err = strconv.ParseUint(string(text), 10, 64)
+		}
+		if i == 13 {
+			stime, err = strconv.ParseUint(string(text), 10, 64)
+		}
 		if i == 20 {
 			starttime, err = strconv.ParseUint(string(text), 10, 64)
 		}
@@ -154,6 +164,7 @@ func parseStat(r io.Reader) (res *stat, err error) {
 	return &stat{
 		PPID:      int(ppid),
 		Starttime: starttime,
+		CPUTime:   utime + stime,
 	}, nil
 }
 
@@ -336,6 +347,7 @@ type process struct {
 	Cmdline   []string
 	Workspace *common.Workspace
 	Hash      uint64
+	CPUTime   uint64
 }
 
 func (det *ProcfsDetector) run(processes chan<- Process) {
@@ -398,12 +398,12 @@ func TestParseStat(t *testing.T) {
 		{
 			Name:        "pid 1",
 			Content:     "1 (systemd) S 0 1 1 0 -1 4194560 62769 924461 98 1590 388 255 2488 1097 20 0 1 0 63 175169536 3435 18446744073709551615 94093530578944 94093531561125 140726309452800 0 0 0 671173123 4096 1260 1 0 0 17 3 0 0 32 0 0 94093531915152 94093532201000 94093562523648 140726309453736 140726309453747 140726309453747 140726309453805 0",
-			Expectation: Expectation{S: &stat{Starttime: 63}},
+			Expectation: Expectation{S: &stat{Starttime: 63, CPUTime: 643}},
 		},
 		{
 			Name:        "kthreadd",
 			Content:     "2 (kthreadd) S 0 0 0 0 -1 2129984 0 0 0 0 3 0 0 0 20 0 1 0 63 0 0 18446744073709551615 0 0 0 0 0 0 0 2147483647 0 1 0 0 0 1 0 0 0 0 0 0 0 0 0 0 0 0 0",
-			Expectation: Expectation{S: &stat{Starttime: 63}},
+			Expectation: Expectation{S: &stat{Starttime: 63, CPUTime: 3}},
 		},
 	}
 	for _, test := range tests {
@@ -0,0 +1,208 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package detector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gitpod-io/gitpod/agent-smith/pkg/common"
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// workspaceProcesses is a workspace alongside the processes running in it
+type workspaceProcesses struct {
+	Workspace  *common.Workspace
+	Supervisor *process
+	Processes  []*process
+}
+
+// discoverWorkspaces scans the process table and returns all workspaces on this node, keyed by their supervisor PID
+func discoverWorkspaces(proc discoverableProcFS) map[int]*workspaceProcesses {
+	idx := proc.Discover()
+	root, ok := idx[1]
+	if !ok {
+		log.Error("cannot find pid 1")
+		return nil
+	}
+	findWorkspaces(proc, root, 0, nil)
+
+	res := make(map[int]*workspaceProcesses)
+	for _, p := range idx {
+		if p.Workspace == nil || (p.Kind != ProcessSupervisor && p.Kind != ProcessUserWorkload) {
+			continue
+		}
+
+		ws, ok := res[p.Workspace.PID]
+		if !ok {
+			ws = &workspaceProcesses{Workspace: p.Workspace}
+			res[p.Workspace.PID] = ws
+		}
+		if p.Kind == ProcessSupervisor {
+			ws.Supervisor = p
+		}
+		ws.Processes = append(ws.Processes, p)
+	}
+	return res
+}
+
+// sampler judges the workspaces on this node from periodic samples, e.g. of their resource use
+type sampler interface {
+	// Sample is called once per interval with all workspaces on this node and returns the infringing processes
+	Sample(now time.Time, workspaces map[int]*workspaceProcesses) []Process
+}
+
+// samplingDetector detects infringing workspaces by periodically sampling them
+type samplingDetector struct {
+	mu sync.RWMutex
+	ps chan Process
+
+	name     string
+	interval time.Duration
+	proc     discoverableProcFS
+	sampler  sampler
+
+	findingsCounterVec *prometheus.CounterVec
+
+	startOnce sync.Once
+}
+
+func newSamplingDetector(name string, interval time.Duration, proc discoverableProcFS, s sampler) *samplingDetector {
+	return &samplingDetector{
+		name:     name,
+		interval: interval,
+		proc:     proc,
+		sampler:  s,
+		findingsCounterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gitpod",
+			Subsystem: fmt.Sprintf("agent_smith_%s_detector", name),
+			Name:      "findings_total",
+			Help:      "number of infringements found by this detector",
+		}, []string{"severity"}),
+	}
+}
+
+func (det *samplingDetector) Describe(d chan<- *prometheus.Desc) {
+	det.findingsCounterVec.Describe(d)
+}
+
+func (det *samplingDetector) Collect(m chan<- prometheus.Metric) {
+	det.findingsCounterVec.Collect(m)
+}
+
+// DiscoverProcesses starts sampling. Must not be called more than once.
+func (det *samplingDetector) DiscoverProcesses(ctx context.Context) (<-chan Process, error) {
+	det.mu.Lock()
+	defer det.mu.Unlock()
+
+	if det.ps != nil {
+		return nil, fmt.Errorf("already discovering processes")
+	}
+	res := make(chan Process, 100)
+	det.ps = res
+	det.startOnce.Do(func() { go det.run(ctx) })
+
+	return res, nil
+}
+
+func (det *samplingDetector) run(ctx context.Context) {
+	log.WithField("detector", det.name).Info("sampling detector started")
+
+	t := time.NewTicker(det.interval)
+	defer t.Stop()
+	for {
+		for _, p := range det.sampler.Sample(time.Now(), discoverWorkspaces(det.proc)) {
+			if p.Finding != nil {
+				det.findingsCounterVec.WithLabelValues(severityLabel(p.Finding.Severity)).Inc()
+			}
+			select {
+			case det.ps <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func severityLabel(s common.Severity) string {
+	if s == common.SeverityAudit {
+		return "audit"
+	}
+	return string(s)
+}
+
+// slidingWindow sums up measurements over a period of time
+type slidingWindow struct {
+	period  time.Duration
+	samples []windowSample
+}
+
+type windowSample struct {
+	T     time.Time
+	Value float64
+}
+
+// Add records a measurement taken at t and drops measurements older than the period
+func (w *slidingWindow) Add(t time.Time, value float64) {
+	w.samples = append(w.samples, windowSample{T: t, Value: value})
+
+	var i int
+	for i < len(w.samples) && t.Sub(w.samples[i].T) > w.period {
+		i++
+	}
+	w.samples = w.samples[i:]
+}
+
+// Rate returns the sum of all measurements divided by the time they span. Returns false until
+// the measurements span the whole period.
+func (w *slidingWindow) Rate() (float64, bool) {
+	if len(w.samples) < 2 {
+		return 0, false
+	}
+	first, last := w.samples[0], w.samples[len(w.samples)-1]
+	span := last.T.Sub(first.T)
+	// the first sample is what happened before the window starts
+	if span < w.period-w.period/10 {
+		return 0, false
+	}
+
+	var sum float64
+	for _, s := range w.samples[1:] {
+		sum += s.Value
+	}
+	return sum / span.Seconds(), true
+}
+
+// History returns the rate of each measurement but the first, oldest first
+func (w *slidingWindow) History() []float64 {
+	if len(w.samples) < 2 {
+		return nil
+	}
+
+	res := make([]float64, 0, len(w.samples)-1)
+	for i := 1; i < len(w.samples); i++ {
+		dt := w.samples[i].T.Sub(w.samples[i-1].T).Seconds()
+		if dt <= 0 {
+			res = append(res, 0)
+			continue
+		}
+		res = append(res, w.samples[i].Value/dt)
+	}
+	return res
+}
+
+// Reset drops all measurements
+func (w *slidingWindow) Reset() {
+	w.samples = nil
+}
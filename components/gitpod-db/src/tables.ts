@@ -173,6 +173,12 @@ export class GitpodTableDescriptionProvider implements TableDescriptionProvider
             primaryKeys: ["id", "userId"],
             timeColumn: "_lastModified",
         },
+        {
+            name: "d_b_user_ssh_public_key",
+            primaryKeys: ["id", "userId"],
+            timeColumn: "_lastModified",
+            deletionColumn: "deleted",
+        },
         {
             name: "d_b_email",
             primaryKeys: ["uid"],
@@ -0,0 +1,37 @@
+/**
+ * Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+ * Licensed under the GNU Affero General Public License (AGPL).
+ * See License-AGPL.txt in the project root for license information.
+ */
+
+import { PrimaryColumn, Column, Entity, Index } from "typeorm";
+import { TypeORM } from "../typeorm";
+import { UserSSHPublicKey } from "@gitpod/gitpod-protocol";
+
+@Entity({ name: "d_b_user_ssh_public_key" })
+// on DB but not Typeorm: @Index("ind_lastModified", ["_lastModified"])   // DBSync
+export class DBUserSSHPublicKey implements UserSSHPublicKey {
+    @PrimaryColumn(TypeORM.UUID_COLUMN_TYPE)
+    id: string;
+
+    // userId is part of the primary key for the same reason as in DBUserEnvVar: we use TypeORM.save
+    // and must not allow users to overwrite the keys of other users.
+    @PrimaryColumn(TypeORM.UUID_COLUMN_TYPE)
+    userId: string;
+
+    @Column()
+    name: string;
+
+    @Column("text")
+    key: string;
+
+    @Column()
+    @Index("ind_fingerprint")
+    fingerprint: string;
+
+    @Column()
+    creationTime: string;
+
+    @Column()
+    deleted?: boolean;
+}
@@ -14,6 +14,7 @@ import {
     TokenEntry,
     User,
     UserEnvVar,
+    UserSSHPublicKey,
 } from "@gitpod/gitpod-protocol";
 import { EncryptionService } from "@gitpod/gitpod-protocol/lib/encryption/encryption-service";
 import {
@@ -40,6 +41,7 @@ import { DBIdentity } from "./entity/db-identity";
 import { DBTokenEntry } from "./entity/db-token-entry";
 import { DBUser } from "./entity/db-user";
 import { DBUserEnvVar } from "./entity/db-user-env-vars";
+import { DBUserSSHPublicKey } from "./entity/db-user-ssh-public-key";
 import { DBWorkspace } from "./entity/db-workspace";
 import { TypeORM } from "./typeorm";
 import { log } from "@gitpod/gitpod-protocol/lib/util/logging";
@@ -95,6 +97,10 @@ export class TypeORMUserDBImpl implements UserDB {
         return (await this.getEntityManager()).getRepository<DBUserEnvVar>(DBUserEnvVar);
     }
 
+    protected async getSSHPublicKeyRepo(): Promise<Repository<DBUserSSHPublicKey>> {
+        return (await this.getEntityManager()).getRepository<DBUserSSHPublicKey>(DBUserSSHPublicKey);
+    }
+
     public async newUser(): Promise<User> {
         const user: User = {
             id: uuidv4(),
@@ -395,6 +401,35 @@ export class TypeORMUserDBImpl implements UserDB {
         await repo.save(envVar);
     }
 
+    public async addSSHPublicKey(key: UserSSHPublicKey): Promise<void> {
+        const repo = await this.getSSHPublicKeyRepo();
+        await repo.save(key);
+    }
+
+    public async getSSHPublicKeys(userId: string): Promise<UserSSHPublicKey[]> {
+        const repo = await this.getSSHPublicKeyRepo();
+        const keys = await repo.find({ where: { userId } });
+        return keys.filter((key) => !key.deleted);
+    }
+
+    public async deleteSSHPublicKey(key: UserSSHPublicKey): Promise<void> {
+        key.deleted = true;
+        const repo = await this.getSSHPublicKeyRepo();
+        await repo.save(key);
+    }
+
+    public async findSSHPublicKeyByFingerprint(fingerprint: string): Promise<UserSSHPublicKey | undefined> {
+        const repo = await this.getSSHPublicKeyRepo();
+        return repo
+            .createQueryBuilder("key")
+            .innerJoin(DBUser, "user", "user.id = key.userId")
+            .where("key.fingerprint = :fingerprint", { fingerprint })
+            .andWhere("key.deleted = 0")
+            .andWhere("user.blocked = 0")
+            .andWhere("user.markedDeleted = 0")
+            .getOne();
+    }
+
     public async findAllUsers(
         offset: number,
         limit: number,
@@ -218,6 +218,9 @@ export abstract class AbstractTypeORMWorkspaceDBImpl implements WorkspaceDB {
             .orderBy("wsiRunning.workspaceId", "DESC")
             .addOrderBy("GREATEST(ws.creationTime, wsi.creationTime, wsi.startedTime, wsi.stoppedTime)", "DESC")
             .limit(options.limit || 10);
+        if (options.offset) {
+            qb.offset(options.offset);
+        }
         if (options.searchString) {
             qb.andWhere("ws.description LIKE :searchString", { searchString: `%${options.searchString}%` });
         }
@@ -14,6 +14,7 @@ import {
     TokenEntry,
     User,
     UserEnvVar,
+    UserSSHPublicKey,
 } from "@gitpod/gitpod-protocol";
 import { OAuthTokenRepository, OAuthUserRepository } from "@jmondi/oauth2-server";
 import { Repository } from "typeorm";
@@ -117,6 +118,17 @@ export interface UserDB extends OAuthUserRepository, OAuthTokenRepository {
     deleteEnvVar(envVar: UserEnvVar): Promise<void>;
     getEnvVars(userId: string): Promise<UserEnvVar[]>;
 
+    addSSHPublicKey(key: UserSSHPublicKey): Promise<void>;
+    deleteSSHPublicKey(key: UserSSHPublicKey): Promise<void>;
+    getSSHPublicKeys(userId: string): Promise<UserSSHPublicKey[]>;
+
+    /**
+     * returns the key with the given SHA256 fingerprint of a user who is not blocked or marked deleted
+     *
+     * @param fingerprint the SHA256 fingerprint of the key, e.g. SHA256:...
+     */
+    findSSHPublicKeyByFingerprint(fingerprint: string): Promise<UserSSHPublicKey | undefined>;
+
     findAllUsers(
         offset: number,
         limit: number,
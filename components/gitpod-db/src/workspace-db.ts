@@ -33,6 +33,7 @@ export interface FindWorkspacesOptions {
     projectId?: string | string[];
     includeWithoutProject?: boolean;
     limit?: number;
+    offset?: number;
     searchString?: string;
     includeHeadless?: boolean;
     pinnedOnly?: boolean;
@@ -43,6 +43,9 @@ type GitpodConfig struct {
 	// The Docker image to run your workspace in.
 	Image interface{} `yaml:"image,omitempty"`
 
+	// Log the recent requests to the workspace's ports for debugging.
+	PortAccessLog bool `yaml:"portAccessLog,omitempty"`
+
 	// List of exposed ports.
 	Ports []*PortsItems `yaml:"ports,omitempty"`
 
@@ -67,6 +70,12 @@ type Image_object struct {
 
 	// Relative path to a docker file.
 	File string `yaml:"file"`
+
+	// Names of user environment variables to expose as build secrets (RUN --mount=type=secret,id=<name>).
+	Secrets []string `yaml:"secrets,omitempty"`
+
+	// Names of user environment variables holding SSH private keys to expose to the build (RUN --mount=type=ssh,id=<name>).
+	Ssh []string `yaml:"ssh,omitempty"`
 }
 
 // PortsItems
@@ -409,6 +418,28 @@ func (strct *Image_object) MarshalJSON() ([]byte, error) {
 		buf.Write(tmp)
 	}
 	comma = true
+	// Marshal the "secrets" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"secrets\": ")
+	if tmp, err := json.Marshal(strct.Secrets); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
+	// Marshal the "ssh" field
+	if comma {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\"ssh\": ")
+	if tmp, err := json.Marshal(strct.Ssh); err != nil {
+		return nil, err
+	} else {
+		buf.Write(tmp)
+	}
+	comma = true
 
 	buf.WriteString("}")
 	rv := buf.Bytes()
@@ -433,6 +464,14 @@ func (strct *Image_object) UnmarshalJSON(b []byte) error {
 				return err
 			}
 			fileReceived = true
+		case "secrets":
+			if err := json.Unmarshal([]byte(v), &strct.Secrets); err != nil {
+				return err
+			}
+		case "ssh":
+			if err := json.Unmarshal([]byte(v), &strct.Ssh); err != nil {
+				return err
+			}
 		default:
 			return xerrors.Errorf("additional property not allowed: \"" + k + "\"")
 		}
@@ -43,6 +43,7 @@ type APIInterface interface {
 	GetWorkspaceUsers(ctx context.Context, workspaceID string) (res []*WorkspaceInstanceUser, err error)
 	GetFeaturedRepositories(ctx context.Context) (res []*WhitelistedRepository, err error)
 	GetWorkspace(ctx context.Context, id string) (res *WorkspaceInfo, err error)
+	GetWorkspaceInstance(ctx context.Context, instanceID string) (res *WorkspaceInstance, err error)
 	IsWorkspaceOwner(ctx context.Context, workspaceID string) (res bool, err error)
 	CreateWorkspace(ctx context.Context, options *CreateWorkspaceOptions) (res *WorkspaceCreationResult, err error)
 	StartWorkspace(ctx context.Context, id string, options *StartWorkspaceOptions) (res *StartWorkspaceResult, err error)
@@ -80,6 +81,7 @@ type APIInterface interface {
 	TrackEvent(ctx context.Context, event *RemoteTrackMessage) (err error)
 
 	InstanceUpdates(ctx context.Context, instanceID string) (<-chan *WorkspaceInstance, error)
+	ImageBuildLogs(ctx context.Context, instanceID string) (<-chan *WorkspaceImageBuildLogs, error)
 }
 
 // FunctionName is the name of an RPC function
@@ -126,6 +128,8 @@ const (
 	FunctionGetFeaturedRepositories FunctionName = "getFeaturedRepositories"
 	// FunctionGetWorkspace is the name of the getWorkspace function
 	FunctionGetWorkspace FunctionName = "getWorkspace"
+	// FunctionGetWorkspaceInstance is the name of the getWorkspaceInstance function
+	FunctionGetWorkspaceInstance FunctionName = "getWorkspaceInstance"
 	// FunctionIsWorkspaceOwner is the name of the isWorkspaceOwner function
 	FunctionIsWorkspaceOwner FunctionName = "isWorkspaceOwner"
 	// FunctionCreateWorkspace is the name of the createWorkspace function
@@ -197,6 +201,8 @@ const (
 
 	// FunctionOnInstanceUpdate is the name of the onInstanceUpdate callback function
 	FunctionOnInstanceUpdate = "onInstanceUpdate"
+	// FunctionOnWorkspaceImageBuildLogs is the name of the onWorkspaceImageBuildLogs callback function
+	FunctionOnWorkspaceImageBuildLogs = "onWorkspaceImageBuildLogs"
 )
 
 var errNotConnected = errors.New("not connected to Gitpod server")
@@ -258,8 +264,9 @@ type APIoverJSONRPC struct {
 	C   jsonrpc2.JSONRPC2
 	log *logrus.Entry
 
-	mu   sync.RWMutex
-	subs map[string]map[chan *WorkspaceInstance]struct{}
+	mu      sync.RWMutex
+	subs    map[string]map[chan *WorkspaceInstance]struct{}
+	logSubs map[string]map[chan *WorkspaceImageBuildLogs]struct{}
 }
 
 // Close closes the connection
@@ -306,11 +313,57 @@ func (gp *APIoverJSONRPC) InstanceUpdates(ctx context.Context, instanceID string
 	return chn, nil
 }
 
+// ImageBuildLogs subscribes to the image build logs of an instance watched using WatchWorkspaceImageBuildLogs
+// until the context is canceled. An empty instanceID subscribes to the logs of all instances.
+// Dropping a chunk would corrupt the log, hence the channel is closed early if the consumer falls behind.
+func (gp *APIoverJSONRPC) ImageBuildLogs(ctx context.Context, instanceID string) (<-chan *WorkspaceImageBuildLogs, error) {
+	if gp == nil {
+		return nil, errNotConnected
+	}
+	chn := make(chan *WorkspaceImageBuildLogs, 100)
+
+	gp.mu.Lock()
+	if gp.logSubs == nil {
+		gp.logSubs = make(map[string]map[chan *WorkspaceImageBuildLogs]struct{})
+	}
+	if gp.logSubs[instanceID] == nil {
+		gp.logSubs[instanceID] = make(map[chan *WorkspaceImageBuildLogs]struct{})
+	}
+	gp.logSubs[instanceID][chn] = struct{}{}
+	gp.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		gp.mu.Lock()
+		gp.unsubscribeImageBuildLogs(instanceID, chn)
+		gp.mu.Unlock()
+	}()
+
+	return chn, nil
+}
+
+// unsubscribeImageBuildLogs removes and closes a subscription unless that happened already. gp.mu must be locked.
+func (gp *APIoverJSONRPC) unsubscribeImageBuildLogs(instanceID string, chn chan *WorkspaceImageBuildLogs) {
+	subs := gp.logSubs[instanceID]
+	if _, ok := subs[chn]; !ok {
+		return
+	}
+	delete(subs, chn)
+	if len(subs) == 0 {
+		delete(gp.logSubs, instanceID)
+	}
+	close(chn)
+}
+
 func (gp *APIoverJSONRPC) handler(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
 	if gp == nil {
 		err = errNotConnected
 		return
 	}
+	if req.Method == FunctionOnWorkspaceImageBuildLogs {
+		return gp.handleImageBuildLogs(req)
+	}
 	if req.Method != FunctionOnInstanceUpdate {
 		return
 	}
@@ -340,6 +393,52 @@ func (gp *APIoverJSONRPC) handler(ctx context.Context, conn *jsonrpc2.Conn, req
 	return
 }
 
+func (gp *APIoverJSONRPC) handleImageBuildLogs(req *jsonrpc2.Request) (result interface{}, err error) {
+	if req.Params == nil {
+		return
+	}
+	var params []json.RawMessage
+	err = json.Unmarshal(*req.Params, &params)
+	if err != nil {
+		gp.log.WithError(err).WithField("raw", string(*req.Params)).Error("cannot unmarshal image build logs")
+		return
+	}
+	var logs WorkspaceImageBuildLogs
+	if len(params) > 0 {
+		err = json.Unmarshal(params[0], &logs.Info)
+	}
+	if err == nil && len(params) > 1 {
+		err = json.Unmarshal(params[1], &logs.Content)
+	}
+	if err != nil {
+		gp.log.WithError(err).WithField("raw", string(*req.Params)).Error("cannot unmarshal image build logs")
+		return
+	}
+
+	var instanceID string
+	if logs.Content != nil {
+		instanceID = logs.Content.InstanceID
+	}
+
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+	publish := func(id string) {
+		for chn := range gp.logSubs[id] {
+			select {
+			case chn <- &logs:
+			default:
+				gp.log.WithField("instanceId", instanceID).Warn("image build log subscriber is too slow, closing its subscription")
+				gp.unsubscribeImageBuildLogs(id, chn)
+			}
+		}
+	}
+	publish(instanceID)
+	if instanceID != "" {
+		publish("")
+	}
+	return
+}
+
 func (gp *APIoverJSONRPC) GetOwnerToken(ctx context.Context, workspaceID string) (res string, err error) {
 	if gp == nil {
 		err = errNotConnected
@@ -714,6 +813,26 @@ func (gp *APIoverJSONRPC) GetWorkspace(ctx context.Context, id string) (res *Wor
 	return
 }
 
+// GetWorkspaceInstance calls getWorkspaceInstance on the server
+func (gp *APIoverJSONRPC) GetWorkspaceInstance(ctx context.Context, instanceID string) (res *WorkspaceInstance, err error) {
+	if gp == nil {
+		err = errNotConnected
+		return
+	}
+	var _params []interface{}
+
+	_params = append(_params, instanceID)
+
+	var result WorkspaceInstance
+	err = gp.C.Call(ctx, "getWorkspaceInstance", _params, &result)
+	if err != nil {
+		return
+	}
+	res = &result
+
+	return
+}
+
 // IsWorkspaceOwner calls isWorkspaceOwner on the server
 func (gp *APIoverJSONRPC) IsWorkspaceOwner(ctx context.Context, workspaceID string) (res bool, err error) {
 	if gp == nil {
@@ -1445,10 +1564,34 @@ type GetUserStorageResourceOptions struct {
 // GetWorkspacesOptions is the GetWorkspacesOptions message type
 type GetWorkspacesOptions struct {
 	Limit        float64 `json:"limit,omitempty"`
+	Offset       float64 `json:"offset,omitempty"`
 	PinnedOnly   bool    `json:"pinnedOnly,omitempty"`
 	SearchString string  `json:"searchString,omitempty"`
 }
 
+// WorkspaceImageBuildLogs is the WorkspaceImageBuildLogs message type
+type WorkspaceImageBuildLogs struct {
+	Info    *WorkspaceImageBuildStateInfo  `json:"info,omitempty"`
+	Content *WorkspaceImageBuildLogContent `json:"content,omitempty"`
+}
+
+// WorkspaceImageBuildStateInfo is the WorkspaceImageBuildStateInfo message type
+type WorkspaceImageBuildStateInfo struct {
+	Phase       string  `json:"phase,omitempty"`
+	CurrentStep float64 `json:"currentStep,omitempty"`
+	MaxSteps    float64 `json:"maxSteps,omitempty"`
+}
+
+// WorkspaceImageBuildLogContent is the WorkspaceImageBuildLogContent message type
+type WorkspaceImageBuildLogContent struct {
+	Text     string  `json:"text,omitempty"`
+	UpToLine float64 `json:"upToLine,omitempty"`
+	IsDiff   bool    `json:"isDiff,omitempty"`
+
+	// InstanceID is the instance whose image build produced the logs
+	InstanceID string `json:"instanceId,omitempty"`
+}
+
 // StartWorkspaceResult is the StartWorkspaceResult message type
 type StartWorkspaceResult struct {
 	InstanceID   string `json:"instanceID,omitempty"`
@@ -465,6 +465,21 @@ func (mr *MockAPIInterfaceMockRecorder) GetWorkspace(ctx, id interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspace", reflect.TypeOf((*MockAPIInterface)(nil).GetWorkspace), ctx, id)
 }
 
+// GetWorkspaceInstance mocks base method.
+func (m *MockAPIInterface) GetWorkspaceInstance(ctx context.Context, instanceID string) (*WorkspaceInstance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspaceInstance", ctx, instanceID)
+	ret0, _ := ret[0].(*WorkspaceInstance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspaceInstance indicates an expected call of GetWorkspaceInstance.
+func (mr *MockAPIInterfaceMockRecorder) GetWorkspaceInstance(ctx, instanceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspaceInstance", reflect.TypeOf((*MockAPIInterface)(nil).GetWorkspaceInstance), ctx, instanceID)
+}
+
 // GetWorkspaceOwner mocks base method.
 func (m *MockAPIInterface) GetWorkspaceOwner(ctx context.Context, workspaceID string) (*UserInfo, error) {
 	m.ctrl.T.Helper()
@@ -555,6 +570,21 @@ func (mr *MockAPIInterfaceMockRecorder) HasPermission(ctx, permission interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPermission", reflect.TypeOf((*MockAPIInterface)(nil).HasPermission), ctx, permission)
 }
 
+// ImageBuildLogs mocks base method.
+func (m *MockAPIInterface) ImageBuildLogs(ctx context.Context, instanceID string) (<-chan *WorkspaceImageBuildLogs, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImageBuildLogs", ctx, instanceID)
+	ret0, _ := ret[0].(<-chan *WorkspaceImageBuildLogs)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImageBuildLogs indicates an expected call of ImageBuildLogs.
+func (mr *MockAPIInterfaceMockRecorder) ImageBuildLogs(ctx, instanceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageBuildLogs", reflect.TypeOf((*MockAPIInterface)(nil).ImageBuildLogs), ctx, instanceID)
+}
+
 // InstanceUpdates mocks base method.
 func (m *MockAPIInterface) InstanceUpdates(ctx context.Context, instanceID string) (<-chan *WorkspaceInstance, error) {
 	m.ctrl.T.Helper()
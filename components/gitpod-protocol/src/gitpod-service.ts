@@ -15,6 +15,7 @@ import {
     CreateWorkspaceMode,
     Token,
     UserEnvVarValue,
+    UserSSHPublicKeyValue,
     Terms,
     Configuration,
     UserInfo,
@@ -104,6 +105,11 @@ export interface GitpodServer extends JsonRpcServer<GitpodClient>, AdminServer,
      * If you need to access an owner token use `getOwnerToken` instead.
      */
     getWorkspace(id: string): Promise<WorkspaceInfo>;
+    /**
+     * **Security:**
+     * Sensitive information like an owner token is erased, since it allows access for all team members.
+     */
+    getWorkspaceInstance(instanceId: string): Promise<WorkspaceInstance>;
     isWorkspaceOwner(workspaceId: string): Promise<boolean>;
     getOwnerToken(workspaceId: string): Promise<string>;
 
@@ -147,6 +153,11 @@ export interface GitpodServer extends JsonRpcServer<GitpodClient>, AdminServer,
     setEnvVar(variable: UserEnvVarValue): Promise<void>;
     deleteEnvVar(variable: UserEnvVarValue): Promise<void>;
 
+    // User SSH public keys
+    getSSHPublicKeys(): Promise<UserSSHPublicKeyValue[]>;
+    addSSHPublicKey(value: UserSSHPublicKeyValue): Promise<UserSSHPublicKeyValue>;
+    deleteSSHPublicKey(id: string): Promise<void>;
+
     // Teams
     getTeams(): Promise<Team[]>;
     getTeamMembers(teamId: string): Promise<TeamMemberInfo[]>;
@@ -384,6 +395,8 @@ export interface StartWorkspaceResult {
 export namespace GitpodServer {
     export interface GetWorkspacesOptions {
         limit?: number;
+        /** number of workspaces to skip, e.g. to list the workspaces page by page */
+        offset?: number;
         searchString?: string;
         pinnedOnly?: boolean;
         projectId?: string | string[];
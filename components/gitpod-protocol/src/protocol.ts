@@ -356,6 +356,57 @@ export namespace UserEnvVar {
     }
 }
 
+export interface UserSSHPublicKeyValue {
+    id?: string;
+    name: string;
+    // key is the public key in the authorized_keys format, e.g. "ssh-ed25519 AAAA... comment"
+    key: string;
+}
+export interface UserSSHPublicKey extends UserSSHPublicKeyValue {
+    id: string;
+    userId: string;
+    // fingerprint is the SHA256 fingerprint of the key, e.g. "SHA256:..."
+    fingerprint: string;
+    creationTime: string;
+    deleted?: boolean;
+}
+
+export namespace UserSSHPublicKey {
+    export const TYPES = [
+        "ssh-rsa",
+        "ssh-ed25519",
+        "ecdsa-sha2-nistp256",
+        "ecdsa-sha2-nistp384",
+        "ecdsa-sha2-nistp521",
+        "sk-ssh-ed25519@openssh.com",
+        "sk-ecdsa-sha2-nistp256@openssh.com",
+    ];
+
+    /**
+     * @param value
+     * @returns Either a string containing an error message or undefined.
+     */
+    export function validate(value: UserSSHPublicKeyValue): string | undefined {
+        if (value.name.trim() === "") {
+            return "Name must not be empty.";
+        }
+        if (value.name.length > 255) {
+            return "Name too long. Maximum name length is 255 characters.";
+        }
+        if (value.key.length > 16384) {
+            return "Key too long. Maximum key length is 16384 characters.";
+        }
+        const [type, blob] = value.key.trim().split(/\s+/);
+        if (!TYPES.includes(type)) {
+            return `Key type must be one of ${TYPES.join(", ")}.`;
+        }
+        if (!blob || !/^[A-Za-z0-9+/]+={0,2}$/.test(blob)) {
+            return "Key must use the authorized_keys format, e.g. 'ssh-ed25519 AAAA...'.";
+        }
+        return undefined;
+    }
+}
+
 export interface GitpodToken {
     /** Hash value (SHA256) of the token (primary key). */
     tokenHash: string;
@@ -662,6 +713,7 @@ export interface WorkspaceConfig {
     github?: GithubAppConfig;
     vscode?: VSCodeConfig;
     jetbrains?: JetBrainsConfig;
+    portAccessLog?: boolean;
 
     /** deprecated. Enabled by default **/
     experimentalNetwork?: boolean;
@@ -842,6 +894,8 @@ export namespace WorkspaceImageBuild {
         text: string;
         upToLine?: number;
         isDiff?: boolean;
+        /** the instance whose image build produced the logs */
+        instanceId?: string;
     }
     export type LogCallback = (info: StateInfo, content: LogContent | undefined) => void;
     export namespace LogLine {
@@ -862,6 +916,10 @@ export interface ImageConfigFile {
     file: string;
     // Path to the docker build context relative to repository root
     context?: string;
+    // Names of user environment variables to expose as build secrets
+    secrets?: string[];
+    // Names of user environment variables holding SSH private keys to expose to the build
+    ssh?: string[];
 }
 export namespace ImageConfigFile {
     export function is(config: ImageConfig | undefined): config is ImageConfigFile {
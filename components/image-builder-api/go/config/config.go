@@ -73,6 +73,14 @@ type Configuration struct {
 	// PullSecretFile points to a mount of the .dockerconfigjson file of the PullSecret.
 	PullSecretFile string `json:"pullSecretFile,omitempty"`
 
+	// BuildSecretsKeySecret names a Kubernetes secret which contains a `key` entry carrying the
+	// 32 byte key used to encrypt build secrets and SSH keys before they're passed on to bob.
+	// Builds which use secrets are rejected if this isn't configured.
+	BuildSecretsKeySecret string `json:"buildSecretsKeySecret,omitempty"`
+
+	// BuildSecretsKeyFile points to a mount of the key entry of the BuildSecretsKeySecret.
+	BuildSecretsKeyFile string `json:"buildSecretsKeyFile,omitempty"`
+
 	// BaseImageRepository configures repository where we'll push base images to.
 	BaseImageRepository string `json:"baseImageRepository"`
 
@@ -82,6 +90,24 @@ type Configuration struct {
 
 	// BuilderImage is an image ref to the workspace builder image
 	BuilderImage string `json:"builderImage"`
+
+	// MaxConcurrentBuildsPerOwner limits the number of builds a single user can have running at the same time.
+	// Builds which are coalesced with an already running build do not count towards this limit. Zero means no limit.
+	MaxConcurrentBuildsPerOwner int `json:"maxConcurrentBuildsPerOwner,omitempty"`
+
+	// Attestation configures the supply chain artifacts we produce for the workspace images we build.
+	// If this field is nil, no attestations are produced.
+	Attestation *AttestationConfig `json:"attestation,omitempty"`
+}
+
+// AttestationConfig configures the attestations produced for built workspace images.
+// Attestations are stored as OCI artifacts next to the workspace image.
+type AttestationConfig struct {
+	// SBOMFormat enables SBOM generation. Valid values are "spdx" and "cyclonedx".
+	SBOMFormat string `json:"sbomFormat,omitempty"`
+
+	// Provenance enables the generation of SLSA provenance attestations
+	Provenance bool `json:"provenance,omitempty"`
 }
 
 type TLS struct {
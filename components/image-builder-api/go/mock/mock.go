@@ -61,6 +61,26 @@ func (mr *MockImageBuilderClientMockRecorder) Build(arg0, arg1 interface{}, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockImageBuilderClient)(nil).Build), varargs...)
 }
 
+// CancelBuild mocks base method.
+func (m *MockImageBuilderClient) CancelBuild(arg0 context.Context, arg1 *api.CancelBuildRequest, arg2 ...grpc.CallOption) (*api.CancelBuildResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CancelBuild", varargs...)
+	ret0, _ := ret[0].(*api.CancelBuildResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelBuild indicates an expected call of CancelBuild.
+func (mr *MockImageBuilderClientMockRecorder) CancelBuild(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelBuild", reflect.TypeOf((*MockImageBuilderClient)(nil).CancelBuild), varargs...)
+}
+
 // ListBuilds mocks base method.
 func (m *MockImageBuilderClient) ListBuilds(arg0 context.Context, arg1 *api.ListBuildsRequest, arg2 ...grpc.CallOption) (*api.ListBuildsResponse, error) {
 	m.ctrl.T.Helper()
@@ -424,6 +444,21 @@ func (mr *MockImageBuilderServerMockRecorder) Build(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockImageBuilderServer)(nil).Build), arg0, arg1)
 }
 
+// CancelBuild mocks base method.
+func (m *MockImageBuilderServer) CancelBuild(arg0 context.Context, arg1 *api.CancelBuildRequest) (*api.CancelBuildResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelBuild", arg0, arg1)
+	ret0, _ := ret[0].(*api.CancelBuildResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelBuild indicates an expected call of CancelBuild.
+func (mr *MockImageBuilderServerMockRecorder) CancelBuild(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelBuild", reflect.TypeOf((*MockImageBuilderServer)(nil).CancelBuild), arg0, arg1)
+}
+
 // ListBuilds mocks base method.
 func (m *MockImageBuilderServer) ListBuilds(arg0 context.Context, arg1 *api.ListBuildsRequest) (*api.ListBuildsResponse, error) {
 	m.ctrl.T.Helper()
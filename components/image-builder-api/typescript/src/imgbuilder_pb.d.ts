@@ -219,6 +219,14 @@ export class BuildRequest extends jspb.Message {
     setForceRebuild(value: boolean): BuildRequest;
     getTriggeredBy(): string;
     setTriggeredBy(value: string): BuildRequest;
+    clearSecretsList(): void;
+    getSecretsList(): Array<BuildSecret>;
+    setSecretsList(value: Array<BuildSecret>): BuildRequest;
+    addSecrets(value?: BuildSecret, index?: number): BuildSecret;
+    clearSshList(): void;
+    getSshList(): Array<BuildSSHKey>;
+    setSshList(value: Array<BuildSSHKey>): BuildRequest;
+    addSsh(value?: BuildSSHKey, index?: number): BuildSSHKey;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): BuildRequest.AsObject;
@@ -236,6 +244,54 @@ export namespace BuildRequest {
         auth?: BuildRegistryAuth.AsObject,
         forceRebuild: boolean,
         triggeredBy: string,
+        secretsList: Array<BuildSecret.AsObject>,
+        sshList: Array<BuildSSHKey.AsObject>,
+    }
+}
+
+export class BuildSecret extends jspb.Message { 
+    getId(): string;
+    setId(value: string): BuildSecret;
+    getValue(): string;
+    setValue(value: string): BuildSecret;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): BuildSecret.AsObject;
+    static toObject(includeInstance: boolean, msg: BuildSecret): BuildSecret.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: BuildSecret, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): BuildSecret;
+    static deserializeBinaryFromReader(message: BuildSecret, reader: jspb.BinaryReader): BuildSecret;
+}
+
+export namespace BuildSecret {
+    export type AsObject = {
+        id: string,
+        value: string,
+    }
+}
+
+export class BuildSSHKey extends jspb.Message { 
+    getId(): string;
+    setId(value: string): BuildSSHKey;
+    getPrivateKey(): string;
+    setPrivateKey(value: string): BuildSSHKey;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): BuildSSHKey.AsObject;
+    static toObject(includeInstance: boolean, msg: BuildSSHKey): BuildSSHKey.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: BuildSSHKey, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): BuildSSHKey;
+    static deserializeBinaryFromReader(message: BuildSSHKey, reader: jspb.BinaryReader): BuildSSHKey;
+}
+
+export namespace BuildSSHKey {
+    export type AsObject = {
+        id: string,
+        privateKey: string,
     }
 }
 
@@ -30,6 +30,8 @@ goog.exportSymbol('proto.builder.BuildRegistryAuthSelective', null, global);
 goog.exportSymbol('proto.builder.BuildRegistryAuthTotal', null, global);
 goog.exportSymbol('proto.builder.BuildRequest', null, global);
 goog.exportSymbol('proto.builder.BuildResponse', null, global);
+goog.exportSymbol('proto.builder.BuildSSHKey', null, global);
+goog.exportSymbol('proto.builder.BuildSecret', null, global);
 goog.exportSymbol('proto.builder.BuildSource', null, global);
 goog.exportSymbol('proto.builder.BuildSource.FromCase', null, global);
 goog.exportSymbol('proto.builder.BuildSourceDockerfile', null, global);
@@ -202,7 +204,7 @@ if (goog.DEBUG && !COMPILED) {
  * @constructor
  */
 proto.builder.BuildRequest = function(opt_data) {
-  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+  jspb.Message.initialize(this, opt_data, 0, -1, proto.builder.BuildRequest.repeatedFields_, null);
 };
 goog.inherits(proto.builder.BuildRequest, jspb.Message);
 if (goog.DEBUG && !COMPILED) {
@@ -212,6 +214,48 @@ if (goog.DEBUG && !COMPILED) {
    */
   proto.builder.BuildRequest.displayName = 'proto.builder.BuildRequest';
 }
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.builder.BuildSecret = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.builder.BuildSecret, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.builder.BuildSecret.displayName = 'proto.builder.BuildSecret';
+}
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.builder.BuildSSHKey = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.builder.BuildSSHKey, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.builder.BuildSSHKey.displayName = 'proto.builder.BuildSSHKey';
+}
 /**
  * Generated by JsPbCodeGenerator.
  * @param {Array=} opt_data Optional initial data array, typically from a
@@ -1726,6 +1770,14 @@ proto.builder.ResolveWorkspaceImageResponse.prototype.setStatus = function(value
 
 
 
+/**
+ * List of repeated fields within this message type.
+ * @private {!Array<number>}
+ * @const
+ */
+proto.builder.BuildRequest.repeatedFields_ = [5,6];
+
+
 
 if (jspb.Message.GENERATE_TO_OBJECT) {
 /**
@@ -1759,7 +1811,11 @@ proto.builder.BuildRequest.toObject = function(includeInstance, msg) {
     source: (f = msg.getSource()) && proto.builder.BuildSource.toObject(includeInstance, f),
     auth: (f = msg.getAuth()) && proto.builder.BuildRegistryAuth.toObject(includeInstance, f),
     forceRebuild: jspb.Message.getBooleanFieldWithDefault(msg, 3, false),
-    triggeredBy: jspb.Message.getFieldWithDefault(msg, 4, "")
+    triggeredBy: jspb.Message.getFieldWithDefault(msg, 4, ""),
+    secretsList: jspb.Message.toObjectList(msg.getSecretsList(),
+    proto.builder.BuildSecret.toObject, includeInstance),
+    sshList: jspb.Message.toObjectList(msg.getSshList(),
+    proto.builder.BuildSSHKey.toObject, includeInstance)
   };
 
   if (includeInstance) {
@@ -1814,6 +1870,16 @@ proto.builder.BuildRequest.deserializeBinaryFromReader = function(msg, reader) {
       var value = /** @type {string} */ (reader.readString());
       msg.setTriggeredBy(value);
       break;
+    case 5:
+      var value = new proto.builder.BuildSecret;
+      reader.readMessage(value,proto.builder.BuildSecret.deserializeBinaryFromReader);
+      msg.addSecrets(value);
+      break;
+    case 6:
+      var value = new proto.builder.BuildSSHKey;
+      reader.readMessage(value,proto.builder.BuildSSHKey.deserializeBinaryFromReader);
+      msg.addSsh(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -1873,6 +1939,22 @@ proto.builder.BuildRequest.serializeBinaryToWriter = function(message, writer) {
       f
     );
   }
+  f = message.getSecretsList();
+  if (f.length > 0) {
+    writer.writeRepeatedMessage(
+      5,
+      f,
+      proto.builder.BuildSecret.serializeBinaryToWriter
+    );
+  }
+  f = message.getSshList();
+  if (f.length > 0) {
+    writer.writeRepeatedMessage(
+      6,
+      f,
+      proto.builder.BuildSSHKey.serializeBinaryToWriter
+    );
+  }
 };
 
 
@@ -1986,6 +2068,398 @@ proto.builder.BuildRequest.prototype.setTriggeredBy = function(value) {
 };
 
 
+/**
+ * repeated BuildSecret secrets = 5;
+ * @return {!Array<!proto.builder.BuildSecret>}
+ */
+proto.builder.BuildRequest.prototype.getSecretsList = function() {
+  return /** @type{!Array<!proto.builder.BuildSecret>} */ (
+    jspb.Message.getRepeatedWrapperField(this, proto.builder.BuildSecret, 5));
+};
+
+
+/**
+ * @param {!Array<!proto.builder.BuildSecret>} value
+ * @return {!proto.builder.BuildRequest} returns this
+*/
+proto.builder.BuildRequest.prototype.setSecretsList = function(value) {
+  return jspb.Message.setRepeatedWrapperField(this, 5, value);
+};
+
+
+/**
+ * @param {!proto.builder.BuildSecret=} opt_value
+ * @param {number=} opt_index
+ * @return {!proto.builder.BuildSecret}
+ */
+proto.builder.BuildRequest.prototype.addSecrets = function(opt_value, opt_index) {
+  return jspb.Message.addToRepeatedWrapperField(this, 5, opt_value, proto.builder.BuildSecret, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.builder.BuildRequest} returns this
+ */
+proto.builder.BuildRequest.prototype.clearSecretsList = function() {
+  return this.setSecretsList([]);
+};
+
+
+/**
+ * repeated BuildSSHKey ssh = 6;
+ * @return {!Array<!proto.builder.BuildSSHKey>}
+ */
+proto.builder.BuildRequest.prototype.getSshList = function() {
+  return /** @type{!Array<!proto.builder.BuildSSHKey>} */ (
+    jspb.Message.getRepeatedWrapperField(this, proto.builder.BuildSSHKey, 6));
+};
+
+
+/**
+ * @param {!Array<!proto.builder.BuildSSHKey>} value
+ * @return {!proto.builder.BuildRequest} returns this
+*/
+proto.builder.BuildRequest.prototype.setSshList = function(value) {
+  return jspb.Message.setRepeatedWrapperField(this, 6, value);
+};
+
+
+/**
+ * @param {!proto.builder.BuildSSHKey=} opt_value
+ * @param {number=} opt_index
+ * @return {!proto.builder.BuildSSHKey}
+ */
+proto.builder.BuildRequest.prototype.addSsh = function(opt_value, opt_index) {
+  return jspb.Message.addToRepeatedWrapperField(this, 6, opt_value, proto.builder.BuildSSHKey, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.builder.BuildRequest} returns this
+ */
+proto.builder.BuildRequest.prototype.clearSshList = function() {
+  return this.setSshList([]);
+};
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.builder.BuildSecret.prototype.toObject = function(opt_includeInstance) {
+  return proto.builder.BuildSecret.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.builder.BuildSecret} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.BuildSecret.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    id: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    value: jspb.Message.getFieldWithDefault(msg, 2, "")
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.builder.BuildSecret}
+ */
+proto.builder.BuildSecret.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.builder.BuildSecret;
+  return proto.builder.BuildSecret.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.builder.BuildSecret} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.builder.BuildSecret}
+ */
+proto.builder.BuildSecret.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setId(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setValue(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.builder.BuildSecret.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.builder.BuildSecret.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.builder.BuildSecret} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.BuildSecret.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getId();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getValue();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+};
+
+
+/**
+ * optional string id = 1;
+ * @return {string}
+ */
+proto.builder.BuildSecret.prototype.getId = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildSecret} returns this
+ */
+proto.builder.BuildSecret.prototype.setId = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * optional string value = 2;
+ * @return {string}
+ */
+proto.builder.BuildSecret.prototype.getValue = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildSecret} returns this
+ */
+proto.builder.BuildSecret.prototype.setValue = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.builder.BuildSSHKey.prototype.toObject = function(opt_includeInstance) {
+  return proto.builder.BuildSSHKey.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.builder.BuildSSHKey} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.BuildSSHKey.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    id: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    privateKey: jspb.Message.getFieldWithDefault(msg, 2, "")
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.builder.BuildSSHKey}
+ */
+proto.builder.BuildSSHKey.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.builder.BuildSSHKey;
+  return proto.builder.BuildSSHKey.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.builder.BuildSSHKey} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.builder.BuildSSHKey}
+ */
+proto.builder.BuildSSHKey.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setId(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setPrivateKey(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.builder.BuildSSHKey.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.builder.BuildSSHKey.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.builder.BuildSSHKey} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.builder.BuildSSHKey.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getId();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getPrivateKey();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+};
+
+
+/**
+ * optional string id = 1;
+ * @return {string}
+ */
+proto.builder.BuildSSHKey.prototype.getId = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildSSHKey} returns this
+ */
+proto.builder.BuildSSHKey.prototype.setId = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * optional string private_key = 2;
+ * @return {string}
+ */
+proto.builder.BuildSSHKey.prototype.getPrivateKey = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.builder.BuildSSHKey} returns this
+ */
+proto.builder.BuildSSHKey.prototype.setPrivateKey = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
 
 /**
  * Oneof group definitions for this message. Each group defines the field
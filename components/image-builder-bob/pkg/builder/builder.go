@@ -78,7 +78,64 @@ func (b *Builder) buildBaseLayer(ctx context.Context, cl *client.Client) error {
 	}
 
 	log.Info("building base image")
-	return buildImage(ctx, b.Config.ContextDir, b.Config.Dockerfile, b.Config.WorkspaceLayerAuth, b.Config.BaseRef)
+
+	mounts, cleanup, err := prepareBuildMounts(b.Config.Secrets, b.Config.SSHKeys)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return buildImage(ctx, b.Config.ContextDir, b.Config.Dockerfile, b.Config.WorkspaceLayerAuth, b.Config.BaseRef, mounts...)
+}
+
+// prepareBuildMounts writes the build secrets and SSH keys to a private temporary directory
+// and produces the buildctl arguments which make them available to the build. The files are
+// only read by buildkit during the build and never become part of the image. The returned
+// cleanup function removes them again.
+func prepareBuildMounts(secrets []BuildSecret, sshKeys []BuildSSHKey) (args []string, cleanup func(), err error) {
+	cleanup = func() {}
+	if len(secrets) == 0 && len(sshKeys) == 0 {
+		return nil, cleanup, nil
+	}
+
+	dir, err := os.MkdirTemp("", "bob-secrets-*")
+	if err != nil {
+		return nil, cleanup, xerrors.Errorf("cannot create secrets directory: %w", err)
+	}
+	cleanup = func() {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			log.WithError(err).Warn("cannot remove build secrets")
+		}
+	}
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	for i, s := range secrets {
+		fn := filepath.Join(dir, fmt.Sprintf("secret-%d", i))
+		err = ioutil.WriteFile(fn, []byte(s.Value), 0600)
+		if err != nil {
+			return nil, cleanup, xerrors.Errorf("cannot write build secret %s: %w", s.ID, err)
+		}
+		args = append(args, "--secret=id="+s.ID+",src="+fn)
+	}
+	for i, k := range sshKeys {
+		id := k.ID
+		if id == "" {
+			id = "default"
+		}
+		fn := filepath.Join(dir, fmt.Sprintf("ssh-%d", i))
+		err = ioutil.WriteFile(fn, []byte(k.PrivateKey), 0600)
+		if err != nil {
+			return nil, cleanup, xerrors.Errorf("cannot write SSH key %s: %w", id, err)
+		}
+		args = append(args, "--ssh="+id+"="+fn)
+	}
+
+	return args, cleanup, nil
 }
 
 func (b *Builder) buildWorkspaceImage(ctx context.Context, cl *client.Client) (err error) {
@@ -97,7 +154,7 @@ func (b *Builder) buildWorkspaceImage(ctx context.Context, cl *client.Client) (e
 	return buildImage(ctx, contextDir, filepath.Join(contextDir, "Dockerfile"), b.Config.WorkspaceLayerAuth, b.Config.TargetRef)
 }
 
-func buildImage(ctx context.Context, contextDir, dockerfile, authLayer, target string) (err error) {
+func buildImage(ctx context.Context, contextDir, dockerfile, authLayer, target string, extraArgs ...string) (err error) {
 	log.Info("waiting for build context")
 	waitctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
 	defer cancel()
@@ -147,6 +204,7 @@ func buildImage(ctx context.Context, contextDir, dockerfile, authLayer, target s
 		"--local=dockerfile=" + filepath.Dir(dockerfile),
 		"--opt=filename=" + filepath.Base(dockerfile),
 	}
+	buildctlArgs = append(buildctlArgs, extraArgs...)
 
 	buildctlCmd := exec.Command("buildctl", buildctlArgs...)
 
@@ -8,6 +8,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/base64"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,9 +27,23 @@ type Config struct {
 	Dockerfile         string
 	ContextDir         string
 	ExternalBuildkitd  string
+	Secrets            []BuildSecret
+	SSHKeys            []BuildSSHKey
 	localCacheImport   string
 }
 
+// BuildSecret is made available to the base layer build as BuildKit secret mount
+type BuildSecret struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+// BuildSSHKey is made available to the base layer build as BuildKit SSH mount
+type BuildSSHKey struct {
+	ID         string `json:"id"`
+	PrivateKey string `json:"privateKey"`
+}
+
 // GetConfigFromEnv extracts configuration from environment variables
 func GetConfigFromEnv() (*Config, error) {
 	cfg := &Config{
@@ -96,9 +111,46 @@ func GetConfigFromEnv() (*Config, error) {
 		}
 	}
 
+	// build secrets and SSH keys are always encrypted using BOB_AUTH_KEY
+	if secrets := os.Getenv("BOB_BUILD_SECRETS"); secrets != "" {
+		err := decryptJSON("BOB_BUILD_SECRETS", secrets, authKey, &cfg.Secrets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if sshKeys := os.Getenv("BOB_BUILD_SSH_KEYS"); sshKeys != "" {
+		err := decryptJSON("BOB_BUILD_SSH_KEYS", sshKeys, authKey, &cfg.SSHKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if (len(cfg.Secrets) > 0 || len(cfg.SSHKeys) > 0) && !cfg.BuildBase {
+		return nil, xerrors.Errorf("BOB_BUILD_SECRETS and BOB_BUILD_SSH_KEYS require BOB_BUILD_BASE")
+	}
+
 	return cfg, nil
 }
 
+// decryptJSON decrypts the value of the environment variable name and unmarshals it into dst
+func decryptJSON(name, value, key string, dst interface{}) error {
+	if key == "" {
+		return xerrors.Errorf("%s requires BOB_AUTH_KEY", name)
+	}
+	dec, err := base64.RawStdEncoding.DecodeString(value)
+	if err != nil {
+		return xerrors.Errorf("%s is not base64 encoded", name)
+	}
+	plaintext, err := decrypt(dec, key)
+	if err != nil {
+		return xerrors.Errorf("cannot decrypt %s: %w", name, err)
+	}
+	err = json.Unmarshal([]byte(plaintext), dst)
+	if err != nil {
+		return xerrors.Errorf("cannot unmarshal %s: %w", name, err)
+	}
+	return nil
+}
+
 // source: https://astaxie.gitbooks.io/build-web-application-with-golang/en/09.6.html
 func decrypt(ciphertext []byte, key string) (string, error) {
 	c, err := aes.NewCipher([]byte(key))
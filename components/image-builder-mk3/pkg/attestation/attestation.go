@@ -0,0 +1,312 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package attestation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/image-builder/api"
+)
+
+const (
+	// annotationSubject names the image digest an attestation belongs to
+	annotationSubject = "io.gitpod.attestation.subject"
+
+	// attestationConfigMediaType is the config media type of attestation artifacts
+	attestationConfigMediaType = "application/vnd.oci.image.config.v1+json"
+)
+
+// Subject is the image an attestation is about
+type Subject struct {
+	// Name is the repository of the image, e.g. registry/workspace-images
+	Name string
+	// Digest is the manifest digest of the image
+	Digest digest.Digest
+}
+
+func (s Subject) String() string {
+	return s.Name + "@" + s.Digest.String()
+}
+
+// tagFor produces the ref under which an attestation of the subject is stored. We follow the
+// convention established by sigstore/cosign, i.e. <repo>:<alg>-<digest>.<suffix>.
+func (s Subject) tagFor(suffix string) string {
+	return fmt.Sprintf("%s:%s-%s.%s", s.Name, s.Digest.Algorithm(), s.Digest.Encoded(), suffix)
+}
+
+// subjectOf produces the subject of an image identified by its absolute ref
+func subjectOf(ref string) (Subject, error) {
+	pref, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return Subject{}, xerrors.Errorf("cannot parse ref: %w", err)
+	}
+	dgst, ok := refDigest(ref)
+	if !ok {
+		return Subject{}, xerrors.Errorf("ref must be in digest form: %s", ref)
+	}
+	return Subject{Name: pref.Name(), Digest: dgst}, nil
+}
+
+func refDigest(ref string) (digest.Digest, bool) {
+	pref, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", false
+	}
+	if c, ok := pref.(reference.Canonical); ok {
+		return c.Digest(), true
+	}
+	return "", false
+}
+
+// Config configures which attestations are produced
+type Config struct {
+	// SBOMFormat enables SBOM generation if set
+	SBOMFormat SBOMFormat
+	// Provenance enables the generation of SLSA provenance attestations
+	Provenance bool
+}
+
+// Attestor produces attestations for built images and stores them as OCI artifacts
+// next to the image in its registry.
+type Attestor struct {
+	Config Config
+
+	// Resolver gives access to the registry the images are stored in
+	Resolver remotes.Resolver
+}
+
+// Attest produces the configured attestations for the image identified by its absolute ref
+func (a *Attestor) Attest(ctx context.Context, ref string, md BuildMetadata) (res []*api.BuildAttestation, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Attest")
+	defer tracing.FinishSpan(span, &err)
+	span.SetTag("ref", ref)
+
+	subject, err := subjectOf(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Config.SBOMFormat != "" {
+		mediaType, err := a.Config.SBOMFormat.MediaType()
+		if err != nil {
+			return nil, err
+		}
+		sbom, err := a.produceSBOM(ctx, ref, subject)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot produce SBOM: %w", err)
+		}
+		tag := subject.tagFor("sbom")
+		err = a.push(ctx, tag, subject, mediaType, sbom)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot push SBOM: %w", err)
+		}
+		res = append(res, &api.BuildAttestation{Kind: api.BuildAttestationKind_sbom, Ref: tag, MediaType: mediaType})
+	}
+
+	if a.Config.Provenance {
+		prov, err := EncodeProvenance(subject, md)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot produce provenance: %w", err)
+		}
+		tag := subject.tagFor("att")
+		err = a.push(ctx, tag, subject, MediaTypeInToto, prov)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot push provenance: %w", err)
+		}
+		res = append(res, &api.BuildAttestation{Kind: api.BuildAttestationKind_provenance, Ref: tag, MediaType: MediaTypeInToto})
+	}
+
+	return res, nil
+}
+
+func (a *Attestor) produceSBOM(ctx context.Context, ref string, subject Subject) ([]byte, error) {
+	name, desc, err := a.Resolver.Resolve(ctx, ref)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot resolve image: %w", err)
+	}
+	fetcher, err := a.Resolver.Fetcher(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	mfr, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot fetch manifest: %w", err)
+	}
+	defer mfr.Close()
+	var mf ociv1.Manifest
+	err = json.NewDecoder(mfr).Decode(&mf)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot decode manifest: %w", err)
+	}
+
+	scanner := NewPackageScanner()
+	for _, l := range mf.Layers {
+		err = scanLayer(ctx, fetcher, l, scanner)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return EncodeSBOM(a.Config.SBOMFormat, subject, scanner.Packages(), time.Now())
+}
+
+func scanLayer(ctx context.Context, fetcher remotes.Fetcher, desc ociv1.Descriptor, scanner *PackageScanner) error {
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return xerrors.Errorf("cannot fetch layer %s: %w", desc.Digest, err)
+	}
+	defer rc.Close()
+
+	var in io.Reader
+	switch desc.MediaType {
+	case ociv1.MediaTypeImageLayerGzip, images.MediaTypeDockerSchema2LayerGzip, ociv1.MediaTypeImageLayerNonDistributableGzip:
+		gz, err := gzip.NewReader(rc)
+		if err != nil {
+			return xerrors.Errorf("cannot decompress layer %s: %w", desc.Digest, err)
+		}
+		defer gz.Close()
+		in = gz
+	case ociv1.MediaTypeImageLayer, images.MediaTypeDockerSchema2Layer:
+		in = rc
+	default:
+		log.WithField("layer", desc.Digest).WithField("mediaType", desc.MediaType).Warn("unsupported layer media type - not scanning for packages")
+		return nil
+	}
+
+	err = scanner.ScanLayer(in)
+	if err != nil {
+		return xerrors.Errorf("cannot scan layer %s: %w", desc.Digest, err)
+	}
+	// drain the layer so that the fetcher can reuse the connection
+	_, _ = io.Copy(ioutil.Discard, in)
+	return nil
+}
+
+// Lookup returns the configured attestations which are already stored for the image identified
+// by its absolute ref. Attestations which have not been produced (yet) are omitted.
+func (a *Attestor) Lookup(ctx context.Context, ref string) (res []*api.BuildAttestation, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Lookup")
+	defer tracing.FinishSpan(span, &err)
+	span.SetTag("ref", ref)
+
+	subject, err := subjectOf(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*api.BuildAttestation
+	if a.Config.SBOMFormat != "" {
+		mediaType, err := a.Config.SBOMFormat.MediaType()
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, &api.BuildAttestation{Kind: api.BuildAttestationKind_sbom, Ref: subject.tagFor("sbom"), MediaType: mediaType})
+	}
+	if a.Config.Provenance {
+		candidates = append(candidates, &api.BuildAttestation{Kind: api.BuildAttestationKind_provenance, Ref: subject.tagFor("att"), MediaType: MediaTypeInToto})
+	}
+
+	for _, c := range candidates {
+		_, _, err := a.Resolver.Resolve(ctx, c.Ref)
+		if errdefs.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("cannot resolve %s: %w", c.Ref, err)
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// push stores an attestation as single-layer OCI artifact under the given tag
+func (a *Attestor) push(ctx context.Context, tag string, subject Subject, mediaType string, cnt []byte) error {
+	pusher, err := a.Resolver.Pusher(ctx, tag)
+	if err != nil {
+		return err
+	}
+
+	cfg := []byte("{}")
+	cfgDesc := ociv1.Descriptor{
+		MediaType: attestationConfigMediaType,
+		Digest:    digest.FromBytes(cfg),
+		Size:      int64(len(cfg)),
+	}
+	layerDesc := ociv1.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(cnt),
+		Size:      int64(len(cnt)),
+	}
+	mf, err := json.Marshal(ociv1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ociv1.MediaTypeImageManifest,
+		Config:    cfgDesc,
+		Layers:    []ociv1.Descriptor{layerDesc},
+		Annotations: map[string]string{
+			annotationSubject: subject.Digest.String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	mfDesc := ociv1.Descriptor{
+		MediaType: ociv1.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(mf),
+		Size:      int64(len(mf)),
+	}
+
+	for _, blob := range []struct {
+		Desc ociv1.Descriptor
+		Data []byte
+	}{
+		{cfgDesc, cfg},
+		{layerDesc, cnt},
+		{mfDesc, mf},
+	} {
+		err = pushBlob(ctx, pusher, blob.Desc, blob.Data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pushBlob(ctx context.Context, pusher remotes.Pusher, desc ociv1.Descriptor, data []byte) error {
+	w, err := pusher.Push(ctx, desc)
+	if errdefs.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("cannot push %s: %w", desc.Digest, err)
+	}
+	defer w.Close()
+
+	err = content.Copy(ctx, w, bytes.NewReader(data), desc.Size, desc.Digest)
+	if err != nil && !errdefs.IsAlreadyExists(err) {
+		return xerrors.Errorf("cannot push %s: %w", desc.Digest, err)
+	}
+	return nil
+}
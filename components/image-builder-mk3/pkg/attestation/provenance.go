@@ -0,0 +1,156 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package attestation
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// MediaTypeInToto is the media type of in-toto attestation statements
+	MediaTypeInToto = "application/vnd.in-toto+json"
+
+	inTotoStatementType = "https://in-toto.io/Statement/v0.1"
+	slsaPredicateType   = "https://slsa.dev/provenance/v0.2"
+
+	// BuildType identifies the way the image builder produces workspace images
+	BuildType = "https://gitpod.io/image-builder/workspace-image@v1"
+)
+
+// BuildMetadata describes how an image was built
+type BuildMetadata struct {
+	// BuilderID identifies the builder, e.g. the builder image ref
+	BuilderID string
+	BuildID   string
+
+	// Source is the repository the Dockerfile was taken from. Empty if the image was built from a ref.
+	Source *SourceMaterial
+	// DockerfilePath is the path of the Dockerfile within the source
+	DockerfilePath string
+
+	// BaseRef is the image the workspace image was built from
+	BaseRef string
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// SourceMaterial is the source repository of a build
+type SourceMaterial struct {
+	URI string
+	// Revision is the commit the source was checked out at. Empty if unknown.
+	Revision string
+}
+
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	PredicateType string          `json:"predicateType"`
+	Subject       []inTotoSubject `json:"subject"`
+	Predicate     slsaProvenance  `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenance struct {
+	Builder     slsaBuilder       `json:"builder"`
+	BuildType   string            `json:"buildType"`
+	Invocation  slsaInvocation    `json:"invocation"`
+	BuildConfig map[string]string `json:"buildConfig,omitempty"`
+	Metadata    slsaMetadata      `json:"metadata"`
+	Materials   []slsaMaterial    `json:"materials,omitempty"`
+}
+
+type slsaBuilder struct {
+	ID string `json:"id"`
+}
+
+type slsaInvocation struct {
+	ConfigSource slsaConfigSource  `json:"configSource"`
+	Parameters   map[string]string `json:"parameters,omitempty"`
+}
+
+type slsaConfigSource struct {
+	URI        string            `json:"uri,omitempty"`
+	Digest     map[string]string `json:"digest,omitempty"`
+	EntryPoint string            `json:"entryPoint,omitempty"`
+}
+
+type slsaMetadata struct {
+	BuildInvocationID string           `json:"buildInvocationId"`
+	BuildStartedOn    *time.Time       `json:"buildStartedOn,omitempty"`
+	BuildFinishedOn   *time.Time       `json:"buildFinishedOn,omitempty"`
+	Completeness      slsaCompleteness `json:"completeness"`
+	Reproducible      bool             `json:"reproducible"`
+}
+
+type slsaCompleteness struct {
+	Parameters  bool `json:"parameters"`
+	Environment bool `json:"environment"`
+	Materials   bool `json:"materials"`
+}
+
+type slsaMaterial struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// EncodeProvenance produces an in-toto statement carrying a SLSA provenance predicate for the subject
+func EncodeProvenance(subject Subject, md BuildMetadata) ([]byte, error) {
+	stmt := inTotoStatement{
+		Type:          inTotoStatementType,
+		PredicateType: slsaPredicateType,
+		Subject: []inTotoSubject{{
+			Name:   subject.Name,
+			Digest: map[string]string{subject.Digest.Algorithm().String(): subject.Digest.Encoded()},
+		}},
+		Predicate: slsaProvenance{
+			Builder:   slsaBuilder{ID: md.BuilderID},
+			BuildType: BuildType,
+			Metadata: slsaMetadata{
+				BuildInvocationID: md.BuildID,
+				Completeness: slsaCompleteness{
+					Parameters: true,
+					Materials:  md.Source == nil || md.Source.Revision != "",
+				},
+			},
+		},
+	}
+	if !md.StartedAt.IsZero() {
+		t := md.StartedAt.UTC()
+		stmt.Predicate.Metadata.BuildStartedOn = &t
+	}
+	if !md.FinishedAt.IsZero() {
+		t := md.FinishedAt.UTC()
+		stmt.Predicate.Metadata.BuildFinishedOn = &t
+	}
+
+	if md.Source != nil {
+		src := slsaConfigSource{
+			URI:        md.Source.URI,
+			EntryPoint: md.DockerfilePath,
+		}
+		mat := slsaMaterial{URI: md.Source.URI}
+		if md.Source.Revision != "" {
+			src.Digest = map[string]string{"sha1": md.Source.Revision}
+			mat.Digest = src.Digest
+		}
+		stmt.Predicate.Invocation.ConfigSource = src
+		stmt.Predicate.Materials = append(stmt.Predicate.Materials, mat)
+	}
+	if md.BaseRef != "" {
+		mat := slsaMaterial{URI: md.BaseRef}
+		if dgst, ok := refDigest(md.BaseRef); ok {
+			mat.Digest = map[string]string{dgst.Algorithm().String(): dgst.Encoded()}
+		}
+		stmt.Predicate.Materials = append(stmt.Predicate.Materials, mat)
+		stmt.Predicate.Invocation.Parameters = map[string]string{"baseRef": md.BaseRef}
+	}
+
+	return json.Marshal(stmt)
+}
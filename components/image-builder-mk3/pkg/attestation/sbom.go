@@ -0,0 +1,393 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package attestation
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// SBOMFormat determines the document format of the SBOM we produce
+type SBOMFormat string
+
+const (
+	// SBOMFormatSPDX produces SPDX 2.2 JSON documents
+	SBOMFormatSPDX SBOMFormat = "spdx"
+	// SBOMFormatCycloneDX produces CycloneDX 1.4 JSON documents
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx"
+)
+
+const (
+	// MediaTypeSPDX is the media type of SPDX JSON documents
+	MediaTypeSPDX = "application/spdx+json"
+	// MediaTypeCycloneDX is the media type of CycloneDX JSON documents
+	MediaTypeCycloneDX = "application/vnd.cyclonedx+json"
+)
+
+// MediaType returns the media type of documents in this format
+func (f SBOMFormat) MediaType() (string, error) {
+	switch f {
+	case SBOMFormatSPDX:
+		return MediaTypeSPDX, nil
+	case SBOMFormatCycloneDX:
+		return MediaTypeCycloneDX, nil
+	default:
+		return "", xerrors.Errorf("unsupported SBOM format: %s", f)
+	}
+}
+
+// Package is a software package found in an image
+type Package struct {
+	// Type is the package ecosystem, e.g. deb or apk
+	Type    string
+	Name    string
+	Version string
+	Arch    string
+	License string
+}
+
+// PURL returns the package URL of this package, see https://github.com/package-url/purl-spec
+func (p Package) PURL() string {
+	res := fmt.Sprintf("pkg:%s/%s@%s", p.Type, p.Name, p.Version)
+	if p.Arch != "" {
+		res += "?arch=" + p.Arch
+	}
+	return res
+}
+
+// packageDatabases maps the location of package databases within an image to their parser
+var packageDatabases = map[string]func(io.Reader) ([]Package, error){
+	"var/lib/dpkg/status":  parseDpkgStatus,
+	"lib/apk/db/installed": parseApkInstalled,
+}
+
+// PackageScanner collects the packages installed in an image by reading its layers
+// in order. Package databases in later layers replace those of earlier ones.
+type PackageScanner struct {
+	dbs map[string][]Package
+}
+
+// NewPackageScanner creates a new package scanner
+func NewPackageScanner() *PackageScanner {
+	return &PackageScanner{dbs: make(map[string][]Package)}
+}
+
+// ScanLayer reads the package databases from an uncompressed layer tarball
+func (s *PackageScanner) ScanLayer(layer io.Reader) error {
+	tr := tar.NewReader(layer)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("cannot read layer: %w", err)
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if dir, base := path.Split(name); strings.HasPrefix(base, ".wh.") {
+			delete(s.dbs, path.Join(dir, strings.TrimPrefix(base, ".wh.")))
+			continue
+		}
+
+		parse, ok := packageDatabases[name]
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		pkgs, err := parse(tr)
+		if err != nil {
+			return xerrors.Errorf("cannot parse %s: %w", name, err)
+		}
+		s.dbs[name] = pkgs
+	}
+}
+
+// Packages returns all packages found so far, sorted by name
+func (s *PackageScanner) Packages() []Package {
+	var res []Package
+	for _, pkgs := range s.dbs {
+		res = append(res, pkgs...)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Name == res[j].Name {
+			return res[i].Version < res[j].Version
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// parseDpkgStatus parses Debian's dpkg status file
+func parseDpkgStatus(r io.Reader) ([]Package, error) {
+	var (
+		res       []Package
+		cur       Package
+		installed bool
+	)
+	flush := func() {
+		if cur.Name != "" && installed {
+			cur.Type = "deb"
+			res = append(res, cur)
+		}
+		cur, installed = Package{}, false
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Package":
+			cur.Name = value
+		case "Version":
+			cur.Version = value
+		case "Architecture":
+			cur.Arch = value
+		case "Status":
+			installed = strings.HasSuffix(value, " installed")
+		}
+	}
+	flush()
+
+	return res, scanner.Err()
+}
+
+// parseApkInstalled parses Alpine's apk database
+func parseApkInstalled(r io.Reader) ([]Package, error) {
+	var (
+		res []Package
+		cur Package
+	)
+	flush := func() {
+		if cur.Name != "" {
+			cur.Type = "apk"
+			res = append(res, cur)
+		}
+		cur = Package{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		value := line[2:]
+		switch line[0] {
+		case 'P':
+			cur.Name = value
+		case 'V':
+			cur.Version = value
+		case 'A':
+			cur.Arch = value
+		case 'L':
+			cur.License = value
+		}
+	}
+	flush()
+
+	return res, scanner.Err()
+}
+
+// EncodeSBOM produces an SBOM document describing the packages of an image
+func EncodeSBOM(format SBOMFormat, subject Subject, pkgs []Package, now time.Time) ([]byte, error) {
+	switch format {
+	case SBOMFormatSPDX:
+		return encodeSPDX(subject, pkgs, now)
+	case SBOMFormatCycloneDX:
+		return encodeCycloneDX(subject, pkgs, now)
+	default:
+		return nil, xerrors.Errorf("unsupported SBOM format: %s", format)
+	}
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+func encodeSPDX(subject Subject, pkgs []Package, now time.Time) ([]byte, error) {
+	const (
+		noassertion = "NOASSERTION"
+		imageID     = "SPDXRef-Image"
+	)
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              subject.String(),
+		DocumentNamespace: fmt.Sprintf("https://gitpod.io/spdx/%s-%s", subject.Digest.Encoded(), uuid.NewString()),
+		CreationInfo: spdxCreationInfo{
+			Created:  now.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: gitpod-image-builder"},
+		},
+		Packages: []spdxPackage{{
+			SPDXID:           imageID,
+			Name:             subject.Name,
+			VersionInfo:      subject.Digest.String(),
+			DownloadLocation: noassertion,
+			LicenseConcluded: noassertion,
+			LicenseDeclared:  noassertion,
+			CopyrightText:    noassertion,
+		}},
+		Relationships: []spdxRelationship{{
+			Element: "SPDXRef-DOCUMENT",
+			Type:    "DESCRIBES",
+			Related: imageID,
+		}},
+	}
+	for i, p := range pkgs {
+		id := fmt.Sprintf("SPDXRef-Package-%s-%d", p.Type, i)
+		license := noassertion
+		if p.License != "" {
+			license = p.License
+		}
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:           id,
+			Name:             p.Name,
+			VersionInfo:      p.Version,
+			DownloadLocation: noassertion,
+			LicenseConcluded: noassertion,
+			LicenseDeclared:  license,
+			CopyrightText:    noassertion,
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE_MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  p.PURL(),
+			}},
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			Element: imageID,
+			Type:    "CONTAINS",
+			Related: id,
+		})
+	}
+	return json.Marshal(doc)
+}
+
+type cyclonedxDocument struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cyclonedxMetadata    `json:"metadata"`
+	Components   []cyclonedxComponent `json:"components"`
+}
+
+type cyclonedxMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []cyclonedxTool    `json:"tools"`
+	Component cyclonedxComponent `json:"component"`
+}
+
+type cyclonedxTool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+type cyclonedxComponent struct {
+	BOMRef   string             `json:"bom-ref,omitempty"`
+	Type     string             `json:"type"`
+	Name     string             `json:"name"`
+	Version  string             `json:"version,omitempty"`
+	PURL     string             `json:"purl,omitempty"`
+	Licenses []cyclonedxLicense `json:"licenses,omitempty"`
+}
+
+type cyclonedxLicense struct {
+	Expression string `json:"expression"`
+}
+
+func encodeCycloneDX(subject Subject, pkgs []Package, now time.Time) ([]byte, error) {
+	doc := cyclonedxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + uuid.NewString(),
+		Version:      1,
+		Metadata: cyclonedxMetadata{
+			Timestamp: now.UTC().Format(time.RFC3339),
+			Tools:     []cyclonedxTool{{Vendor: "Gitpod", Name: "image-builder"}},
+			Component: cyclonedxComponent{
+				Type:    "container",
+				Name:    subject.Name,
+				Version: subject.Digest.String(),
+			},
+		},
+		Components: make([]cyclonedxComponent, 0, len(pkgs)),
+	}
+	for _, p := range pkgs {
+		c := cyclonedxComponent{
+			BOMRef:  p.PURL(),
+			Type:    "library",
+			Name:    p.Name,
+			Version: p.Version,
+			PURL:    p.PURL(),
+		}
+		if p.License != "" {
+			c.Licenses = []cyclonedxLicense{{Expression: p.License}}
+		}
+		doc.Components = append(doc.Components, c)
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package attestation
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	dpkgStatus = `Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.0-6ubuntu1.1
+Description: GNU Bourne Again SHell
+ Bash is an sh-compatible command language interpreter.
+
+Package: removed
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.0
+
+Package: coreutils
+Status: install ok installed
+Architecture: amd64
+Version: 8.30-3ubuntu2
+`
+	apkInstalled = `C:Q1abc=
+P:musl
+V:1.2.3-r0
+A:x86_64
+L:MIT
+
+C:Q1def=
+P:busybox
+V:1.35.0-r13
+A:x86_64
+L:GPL-2.0-only
+`
+)
+
+func layer(t *testing.T, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, cnt := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(cnt))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(cnt))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestPackageScanner(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Layers      []map[string]string
+		Expectation []Package
+	}{
+		{
+			Name:   "dpkg",
+			Layers: []map[string]string{{"var/lib/dpkg/status": dpkgStatus}},
+			Expectation: []Package{
+				{Type: "deb", Name: "bash", Version: "5.0-6ubuntu1.1", Arch: "amd64"},
+				{Type: "deb", Name: "coreutils", Version: "8.30-3ubuntu2", Arch: "amd64"},
+			},
+		},
+		{
+			Name:   "apk",
+			Layers: []map[string]string{{"./lib/apk/db/installed": apkInstalled}},
+			Expectation: []Package{
+				{Type: "apk", Name: "busybox", Version: "1.35.0-r13", Arch: "x86_64", License: "GPL-2.0-only"},
+				{Type: "apk", Name: "musl", Version: "1.2.3-r0", Arch: "x86_64", License: "MIT"},
+			},
+		},
+		{
+			Name: "later layers win",
+			Layers: []map[string]string{
+				{"var/lib/dpkg/status": dpkgStatus},
+				{"var/lib/dpkg/status": "Package: bash\nStatus: install ok installed\nVersion: 5.1\n"},
+			},
+			Expectation: []Package{
+				{Type: "deb", Name: "bash", Version: "5.1"},
+			},
+		},
+		{
+			Name: "whiteout",
+			Layers: []map[string]string{
+				{"var/lib/dpkg/status": dpkgStatus},
+				{"var/lib/dpkg/.wh.status": ""},
+			},
+		},
+		{
+			Name:   "no package database",
+			Layers: []map[string]string{{"etc/os-release": "ID=gitpod"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			scanner := NewPackageScanner()
+			for _, l := range test.Layers {
+				err := scanner.ScanLayer(layer(t, l))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(test.Expectation, scanner.Packages()); diff != "" {
+				t.Errorf("Packages() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEncodeSBOM(t *testing.T) {
+	subject := Subject{Name: "registry/workspace", Digest: digest.FromString("image")}
+	pkgs := []Package{
+		{Type: "apk", Name: "musl", Version: "1.2.3-r0", Arch: "x86_64", License: "MIT"},
+	}
+
+	for _, format := range []SBOMFormat{SBOMFormatSPDX, SBOMFormatCycloneDX} {
+		t.Run(string(format), func(t *testing.T) {
+			res, err := EncodeSBOM(format, subject, pkgs, time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var doc map[string]interface{}
+			err = json.Unmarshal(res, &doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(res, []byte(`"pkg:apk/musl@1.2.3-r0?arch=x86_64"`)) {
+				t.Errorf("SBOM does not contain package URL: %s", string(res))
+			}
+			if !bytes.Contains(res, []byte(`"2022-05-01T00:00:00Z"`)) {
+				t.Errorf("SBOM does not contain creation time: %s", string(res))
+			}
+		})
+	}
+
+	_, err := EncodeSBOM("unknown", subject, pkgs, time.Now())
+	if err == nil {
+		t.Error("expected error for unknown SBOM format")
+	}
+}
+
+func TestEncodeProvenance(t *testing.T) {
+	var (
+		subject  = Subject{Name: "registry/workspace", Digest: digest.FromString("image")}
+		baseDgst = digest.FromString("base")
+		started  = time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	)
+	res, err := EncodeProvenance(subject, BuildMetadata{
+		BuilderID:      "builder-image",
+		BuildID:        "build-id",
+		Source:         &SourceMaterial{URI: "https://github.com/gitpod-io/gitpod.git", Revision: "abc123"},
+		DockerfilePath: ".gitpod.Dockerfile",
+		BaseRef:        "registry/base@" + baseDgst.String(),
+		StartedAt:      started,
+		FinishedAt:     started.Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var act inTotoStatement
+	err = json.Unmarshal(res, &act)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finished := started.Add(time.Minute)
+	exp := inTotoStatement{
+		Type:          inTotoStatementType,
+		PredicateType: slsaPredicateType,
+		Subject: []inTotoSubject{{
+			Name:   "registry/workspace",
+			Digest: map[string]string{"sha256": subject.Digest.Encoded()},
+		}},
+		Predicate: slsaProvenance{
+			Builder:   slsaBuilder{ID: "builder-image"},
+			BuildType: BuildType,
+			Invocation: slsaInvocation{
+				ConfigSource: slsaConfigSource{
+					URI:        "https://github.com/gitpod-io/gitpod.git",
+					Digest:     map[string]string{"sha1": "abc123"},
+					EntryPoint: ".gitpod.Dockerfile",
+				},
+				Parameters: map[string]string{"baseRef": "registry/base@" + baseDgst.String()},
+			},
+			Metadata: slsaMetadata{
+				BuildInvocationID: "build-id",
+				BuildStartedOn:    &started,
+				BuildFinishedOn:   &finished,
+				Completeness:      slsaCompleteness{Parameters: true, Materials: true},
+			},
+			Materials: []slsaMaterial{
+				{URI: "https://github.com/gitpod-io/gitpod.git", Digest: map[string]string{"sha1": "abc123"}},
+				{URI: "registry/base@" + baseDgst.String(), Digest: map[string]string{"sha256": baseDgst.Encoded()}},
+			},
+		},
+	}
+	if diff := cmp.Diff(exp, act); diff != "" {
+		t.Errorf("EncodeProvenance() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -56,8 +56,9 @@ type buildMonitor struct {
 }
 
 type runningBuild struct {
-	Info api.BuildInfo
-	Logs buildLogs
+	Info  api.BuildInfo
+	Logs  buildLogs
+	Owner string
 }
 
 type buildLogs struct {
@@ -224,6 +225,7 @@ func extractRunningBuild(status *wsmanapi.WorkspaceStatus) *runningBuild {
 			IdeURL:     status.Spec.Url,
 			OwnerToken: status.Auth.OwnerToken,
 		},
+		Owner: status.Metadata.Owner,
 	}
 }
 
@@ -261,7 +263,7 @@ func (m *buildMonitor) GetAllRunningBuilds(ctx context.Context) (res []*runningB
 	return
 }
 
-func (m *buildMonitor) RegisterNewBuild(buildID string, ref, baseRef, url, ownerToken string) {
+func (m *buildMonitor) RegisterNewBuild(buildID string, ref, baseRef, owner, url, ownerToken string) {
 	m.runningBuildsMu.Lock()
 	defer m.runningBuildsMu.Unlock()
 
@@ -277,6 +279,7 @@ func (m *buildMonitor) RegisterNewBuild(buildID string, ref, baseRef, url, owner
 			IdeURL:     url,
 			OwnerToken: ownerToken,
 		},
+		Owner: owner,
 	}
 	m.runningBuilds[buildID] = bld
 	log.WithField("build", bld).WithField("buildID", buildID).Debug("new build registered")
@@ -18,6 +18,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/containerd/containerd/remotes"
+	dockerremote "github.com/containerd/containerd/remotes/docker"
 	"github.com/docker/distribution/reference"
 	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
@@ -28,6 +30,7 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 
 	common_grpc "github.com/gitpod-io/gitpod/common-go/grpc"
 	"github.com/gitpod-io/gitpod/common-go/log"
@@ -36,6 +39,7 @@ import (
 	"github.com/gitpod-io/gitpod/image-builder/api"
 	protocol "github.com/gitpod-io/gitpod/image-builder/api"
 	"github.com/gitpod-io/gitpod/image-builder/api/config"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/attestation"
 	"github.com/gitpod-io/gitpod/image-builder/pkg/auth"
 	"github.com/gitpod-io/gitpod/image-builder/pkg/resolve"
 	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
@@ -48,6 +52,12 @@ const (
 	// maxBuildRuntime is the maximum time a build is allowed to take
 	maxBuildRuntime = 60 * time.Minute
 
+	// finishedBuildRetention is the time we remember that a build has finished
+	finishedBuildRetention = 10 * time.Minute
+
+	// maxAttestationRuntime is the maximum time producing the attestations of a built image is allowed to take
+	maxAttestationRuntime = 30 * time.Minute
+
 	// workspaceBuildProcessVersion controls how we build workspace images.
 	// Incrementing this value will trigger a rebuild of all workspace images.
 	workspaceBuildProcessVersion = 2
@@ -68,6 +78,35 @@ func NewOrchestratingBuilder(cfg config.Configuration) (res *Orchestrator, err e
 		}
 	}
 
+	var buildSecretsKey []byte
+	if cfg.BuildSecretsKeyFile != "" {
+		fn := cfg.BuildSecretsKeyFile
+		if tproot := os.Getenv("TELEPRESENCE_ROOT"); tproot != "" {
+			fn = filepath.Join(tproot, fn)
+		}
+
+		buildSecretsKey, err = os.ReadFile(fn)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot read build secrets key: %w", err)
+		}
+		if len(buildSecretsKey) != buildSecretsKeyLength {
+			return nil, xerrors.Errorf("build secrets key must be exactly %d bytes long", buildSecretsKeyLength)
+		}
+	}
+
+	var attestationCfg attestation.Config
+	if cfg.Attestation != nil {
+		attestationCfg = attestation.Config{
+			SBOMFormat: attestation.SBOMFormat(cfg.Attestation.SBOMFormat),
+			Provenance: cfg.Attestation.Provenance,
+		}
+		if attestationCfg.SBOMFormat != "" {
+			if _, err := attestationCfg.SBOMFormat.MediaType(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	var wsman wsmanapi.WorkspaceManagerClient
 	if c, ok := cfg.WorkspaceManager.Client.(wsmanapi.WorkspaceManagerClient); ok {
 		wsman = c
@@ -104,11 +143,19 @@ func NewOrchestratingBuilder(cfg config.Configuration) (res *Orchestrator, err e
 		},
 		RefResolver: &resolve.StandaloneRefResolver{},
 
-		wsman:         wsman,
-		buildListener: make(map[string]map[buildListener]struct{}),
-		logListener:   make(map[string]map[logListener]struct{}),
-		censorship:    make(map[string][]string),
-		metrics:       newMetrics(),
+		attestation:     attestationCfg,
+		buildSecretsKey: buildSecretsKey,
+		wsman:           wsman,
+		buildListener:   make(map[string]map[buildListener]struct{}),
+		logListener:     make(map[string]map[logListener]struct{}),
+		censorship:      make(map[string][]string),
+		redactions:      make(map[string][]string),
+		cancelled:       make(map[string]struct{}),
+		finished:        make(map[string]time.Time),
+		attested:        make(map[string]*attestedBuild),
+		attesting:       make(map[string]struct{}),
+		builds:          make(map[string]*reservedBuild),
+		metrics:         newMetrics(),
 	}
 	o.monitor = newBuildMonitor(o, o.wsman)
 
@@ -122,13 +169,31 @@ type Orchestrator struct {
 	AuthResolver auth.Resolver
 	RefResolver  resolve.DockerRefResolver
 
-	wsman wsmanapi.WorkspaceManagerClient
+	// AttestationResolverFactory produces the resolver used to store attestations in the registry.
+	// If nil, the Docker registry of the workspace image is used directly. This field is used for testing.
+	AttestationResolverFactory func(auth *auth.Authentication) remotes.Resolver
+
+	attestation     attestation.Config
+	buildSecretsKey []byte
+	wsman           wsmanapi.WorkspaceManagerClient
 
 	buildListener map[string]map[buildListener]struct{}
 	logListener   map[string]map[logListener]struct{}
 	censorship    map[string][]string
+	redactions    map[string][]string
+	cancelled     map[string]struct{}
+	finished      map[string]time.Time
 	mu            sync.RWMutex
 
+	// attested holds recently finished builds whose attestations were produced, indexed by build ID
+	attested map[string]*attestedBuild
+	// attesting contains the workspace image refs whose attestations are currently being produced
+	attesting map[string]struct{}
+
+	// builds maps workspace image refs to the builds this image builder started for them
+	builds   map[string]*reservedBuild
+	buildsMu sync.Mutex
+
 	monitor *buildMonitor
 
 	metrics *metrics
@@ -222,6 +287,13 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 	if req.Source == nil {
 		return status.Errorf(codes.InvalidArgument, "build source is missing")
 	}
+	err = validateBuildSecrets(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if (len(req.Secrets) > 0 || len(req.Ssh) > 0) && (len(o.buildSecretsKey) == 0 || o.Config.BuildSecretsKeySecret == "") {
+		return status.Error(codes.FailedPrecondition, "build secrets are not supported by this image builder")
+	}
 
 	// resolve build request authentication
 	reqauth := o.AuthResolver.ResolveRequestAuth(req.Auth)
@@ -256,11 +328,20 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 		}
 
 		// image has already been built - no need for us to start building
-		err = resp.Send(&protocol.BuildResponse{
+		res := &protocol.BuildResponse{
 			Status:  protocol.BuildStatus_done_success,
 			Ref:     wsrefstr,
 			BaseRef: baserefAbsolute,
-		})
+		}
+		if o.attestationEnabled() {
+			res.Info = &protocol.BuildInfo{
+				Ref:          wsrefstr,
+				BaseRef:      baserefAbsolute,
+				Status:       protocol.BuildStatus_done_success,
+				Attestations: o.lookupAttestations(ctx, wsrefstr, wsrefAuth),
+			}
+		}
+		err = resp.Send(res)
 		if err != nil {
 			return err
 		}
@@ -278,8 +359,89 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 		return
 	}
 
+	buildStart := time.Now()
+	buildID, ongoing, err := o.reserveBuild(ctx, wsrefstr, req.GetTriggeredBy(), randomUUID.String())
+	if err != nil {
+		return err
+	}
+	if ongoing {
+		log.WithField("buildID", buildID).WithField("ref", wsrefstr).Debug("coalescing build request with ongoing build")
+	} else {
+		defer o.releaseBuild(wsrefstr, buildID)
+
+		err = o.startBuild(ctx, req, buildID, reqauth, baseref, wsrefstr)
+		if err != nil {
+			o.failReservedBuild(wsrefstr, buildID, err)
+			return err
+		}
+	}
+
+	updates, cancel, ok := o.registerBuildListener(buildID)
+	if !ok {
+		return status.Error(codes.Aborted, "build finished while subscribing to it - please try again")
+	}
+	defer cancel()
+	for {
+		var update *protocol.BuildResponse
+		select {
+		case update = <-updates:
+		case <-ctx.Done():
+			return status.Error(codes.DeadlineExceeded, "image build did not finish in time")
+		}
+		if update == nil {
+			// channel was closed unexpectatly
+			return status.Error(codes.Aborted, "subscription canceled - please try again")
+		}
+
+		// The failed condition of ws-manager is not stable, hence we might wrongly report that the
+		// build was successful when in fact it wasn't. This would break workspace startup with a strange
+		// "cannot pull from reg.gitpod.io" error message. Instead the image-build should fail properly.
+		// To do this, we resolve the built image afterwards to ensure it was actually built.
+		if update.Status == protocol.BuildStatus_done_success {
+			// the update is shared with all requests coalesced with this build
+			update = proto.Clone(update).(*protocol.BuildResponse)
+
+			exists, err := o.checkImageExists(ctx, wsrefstr, wsrefAuth)
+			if err != nil {
+				update.Status = protocol.BuildStatus_done_failure
+				update.Message = fmt.Sprintf("cannot check if workspace image exists after the build: %v", err)
+			} else if !exists {
+				update.Status = protocol.BuildStatus_done_failure
+				update.Message = "image build did not produce a workspace image"
+			} else if o.attestationEnabled() {
+				if update.Info == nil {
+					update.Info = &protocol.BuildInfo{BuildId: buildID, Ref: wsrefstr, BaseRef: baseref, Status: update.Status}
+				}
+				update.Info.Attestations = o.lookupAttestations(ctx, wsrefstr, wsrefAuth)
+
+				if !ongoing {
+					// Attestations are produced in the background so that they don't delay the workspace start.
+					// Clients find them through ListBuilds or subsequent builds of the same image.
+					info := proto.Clone(update.Info).(*protocol.BuildInfo)
+					o.attestInBackground(info, wsrefAuth, getBuildMetadata(req, o.Config.BuilderImage, buildID, baseref, buildStart))
+				}
+			}
+		}
+
+		err := resp.Send(update)
+		if err != nil {
+			log.WithError(err).Error("cannot forward build update - dropping listener")
+			return status.Errorf(codes.Unknown, "cannot send update: %v", err)
+		}
+
+		if update.Status == protocol.BuildStatus_done_failure || update.Status == protocol.BuildStatus_done_success {
+			// build is done
+			o.clearListener(buildID)
+			break
+		}
+	}
+
+	return nil
+}
+
+// startBuild starts the headless workspace which builds the workspace image
+func (o *Orchestrator) startBuild(ctx context.Context, req *protocol.BuildRequest, buildID string, reqauth auth.AllowedAuthFor, baseref, wsrefstr string) (err error) {
 	var (
-		buildID        = randomUUID.String()
 		buildBase      = "false"
 		contextPath    = "."
 		dockerfilePath = "Dockerfile"
@@ -308,6 +470,7 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 		strings.Split(wsrefstr, ":")[0],
 		strings.Split(baseref, ":")[0],
 	})
+	o.redact(buildID, secretValues(req))
 
 	// push some log to the client before starting the job, just in case the build workspace takes a while to start up
 	o.PublishLog(buildID, "starting image build")
@@ -339,6 +502,10 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 			return xerrors.Errorf("cannot marshal additional auth: %w", err)
 		}
 	}
+	buildSecretEnv, err := o.getBuildSecretEnvVars(req)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot encrypt build secrets: %q", err)
+	}
 
 	var swr *wsmanapi.StartWorkspaceResponse
 	err = retry(ctx, func(ctx context.Context) (err error) {
@@ -363,7 +530,7 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 					WebRef: o.Config.BuilderImage,
 				},
 				WorkspaceLocation: contextPath,
-				Envvars: []*wsmanapi.EnvironmentVariable{
+				Envvars: append([]*wsmanapi.EnvironmentVariable{
 					{Name: "BOB_TARGET_REF", Value: "localhost:8080/target:latest"},
 					{Name: "BOB_BASE_REF", Value: bobBaseref},
 					{Name: "BOB_BUILD_BASE", Value: buildBase},
@@ -385,7 +552,7 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 						Value: string(baseRefAuth),
 					},
 					{Name: "SUPERVISOR_DEBUG_ENABLE", Value: fmt.Sprintf("%v", log.Log.Logger.IsLevelEnabled(logrus.DebugLevel))},
-				},
+				}, buildSecretEnv...),
 			},
 			Type: wsmanapi.WorkspaceType_IMAGEBUILD,
 		})
@@ -398,52 +565,19 @@ func (o *Orchestrator) Build(req *protocol.BuildRequest, resp protocol.ImageBuil
 	} else if err != nil {
 		return status.Errorf(codes.Internal, "cannot start build: %q", err)
 	} else {
-		o.monitor.RegisterNewBuild(buildID, wsrefstr, baseref, swr.Url, swr.OwnerToken)
+		o.monitor.RegisterNewBuild(buildID, wsrefstr, baseref, req.GetTriggeredBy(), swr.Url, swr.OwnerToken)
 		o.PublishLog(buildID, "starting image build ...\n")
 	}
 
-	updates, cancel := o.registerBuildListener(buildID)
-	defer cancel()
-	for {
-		update := <-updates
-		if update == nil {
-			// channel was closed unexpectatly
-			return status.Error(codes.Aborted, "subscription canceled - please try again")
-		}
-
-		// The failed condition of ws-manager is not stable, hence we might wrongly report that the
-		// build was successful when in fact it wasn't. This would break workspace startup with a strange
-		// "cannot pull from reg.gitpod.io" error message. Instead the image-build should fail properly.
-		// To do this, we resolve the built image afterwards to ensure it was actually built.
-		if update.Status == protocol.BuildStatus_done_success {
-			exists, err := o.checkImageExists(ctx, wsrefstr, wsrefAuth)
-			if err != nil {
-				update.Status = protocol.BuildStatus_done_failure
-				update.Message = fmt.Sprintf("cannot check if workspace image exists after the build: %v", err)
-			} else if !exists {
-				update.Status = protocol.BuildStatus_done_failure
-				update.Message = "image build did not produce a workspace image"
-			}
-		}
-
-		err := resp.Send(update)
-		if err != nil {
-			log.WithError(err).Error("cannot forward build update - dropping listener")
-			return status.Errorf(codes.Unknown, "cannot send update: %v", err)
-		}
-
-		if update.Status == protocol.BuildStatus_done_failure || update.Status == protocol.BuildStatus_done_success {
-			// build is done
-			o.clearListener(buildID)
-			break
-		}
-	}
-
 	return nil
 }
 
 // publishStatus broadcasts a build status update to all listeners
 func (o *Orchestrator) PublishStatus(buildID string, resp *api.BuildResponse) {
+	if resp.Status == api.BuildStatus_done_success || resp.Status == api.BuildStatus_done_failure {
+		o.markFinished(buildID, resp)
+	}
+
 	o.mu.RLock()
 	listener, ok := o.buildListener[buildID]
 	o.mu.RUnlock()
@@ -511,7 +645,7 @@ func (o *Orchestrator) Logs(req *protocol.LogsRequest, resp protocol.ImageBuilde
 	return
 }
 
-// ListBuilds returns a list of currently running builds
+// ListBuilds returns a list of currently running builds, and of recently finished builds whose attestations were produced
 func (o *Orchestrator) ListBuilds(ctx context.Context, req *protocol.ListBuildsRequest) (resp *protocol.ListBuildsResponse, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "ListBuilds")
 	defer tracing.FinishSpan(span, &err)
@@ -523,12 +657,134 @@ func (o *Orchestrator) ListBuilds(ctx context.Context, req *protocol.ListBuildsR
 
 	res := make([]*protocol.BuildInfo, 0, len(builds))
 	for _, ws := range builds {
-		res = append(res, &ws.Info)
+		info := proto.Clone(&ws.Info).(*protocol.BuildInfo)
+		info.Waiters = int32(o.countBuildListener(ws.Info.BuildId))
+		res = append(res, info)
 	}
 
+	o.mu.RLock()
+	for _, bld := range o.attested {
+		res = append(res, proto.Clone(bld.Info).(*protocol.BuildInfo))
+	}
+	o.mu.RUnlock()
+
 	return &protocol.ListBuildsResponse{Builds: res}, nil
 }
 
+// CancelBuild stops an ongoing build. All clients waiting for the build receive a failed build status.
+func (o *Orchestrator) CancelBuild(ctx context.Context, req *protocol.CancelBuildRequest) (resp *protocol.CancelBuildResponse, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "CancelBuild")
+	defer tracing.FinishSpan(span, &err)
+	tracing.LogRequestSafe(span, req)
+
+	if req.BuildId == "" {
+		return nil, status.Error(codes.InvalidArgument, "build ID is missing")
+	}
+
+	builds, err := o.monitor.GetAllRunningBuilds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var build *runningBuild
+	for _, bld := range builds {
+		if bld.Info.BuildId == req.BuildId {
+			build = bld
+			break
+		}
+	}
+	if build == nil {
+		return nil, status.Error(codes.NotFound, "build not found")
+	}
+	if !req.Admin && (req.TriggeredBy == "" || req.TriggeredBy != build.Owner) {
+		// we don't tell a user about builds they don't own
+		return nil, status.Error(codes.NotFound, "build not found")
+	}
+
+	o.mu.Lock()
+	o.cancelled[req.BuildId] = struct{}{}
+	o.mu.Unlock()
+
+	_, err = o.wsman.StopWorkspace(ctx, &wsmanapi.StopWorkspaceRequest{
+		Id:     req.BuildId,
+		Policy: wsmanapi.StopWorkspacePolicy_IMMEDIATELY,
+	})
+	if err != nil {
+		o.mu.Lock()
+		delete(o.cancelled, req.BuildId)
+		o.mu.Unlock()
+
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Error(codes.NotFound, "build not found")
+		}
+		return nil, status.Errorf(codes.Internal, "cannot stop build: %q", err)
+	}
+	o.PublishLog(req.BuildId, "image build cancelled\n")
+
+	return &protocol.CancelBuildResponse{}, nil
+}
+
+type reservedBuild struct {
+	ID    string
+	Owner string
+}
+
+// reserveBuild returns the ID of the ongoing build of the workspace image ref if there is one. Otherwise
+// it reserves ref for a new build with the given ID, provided that the owner has not exceeded their concurrent builds limit.
+func (o *Orchestrator) reserveBuild(ctx context.Context, ref, owner, newBuildID string) (buildID string, ongoing bool, err error) {
+	o.buildsMu.Lock()
+	defer o.buildsMu.Unlock()
+
+	if bld, ok := o.builds[ref]; ok {
+		return bld.ID, true, nil
+	}
+
+	running, err := o.monitor.GetAllRunningBuilds(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	ownerBuilds := make(map[string]struct{})
+	for _, bld := range running {
+		if bld.Info.Ref == ref {
+			return bld.Info.BuildId, true, nil
+		}
+		if owner != "" && bld.Owner == owner {
+			ownerBuilds[bld.Info.BuildId] = struct{}{}
+		}
+	}
+	for _, bld := range o.builds {
+		if owner != "" && bld.Owner == owner {
+			ownerBuilds[bld.ID] = struct{}{}
+		}
+	}
+	if max := o.Config.MaxConcurrentBuildsPerOwner; max > 0 && len(ownerBuilds) >= max {
+		return "", false, status.Errorf(codes.ResourceExhausted, "too many concurrent image builds: at most %d are allowed", max)
+	}
+
+	o.builds[ref] = &reservedBuild{ID: newBuildID, Owner: owner}
+	return newBuildID, false, nil
+}
+
+// failReservedBuild releases the reservation of a build which could not be started and notifies the
+// requests which were coalesced with it, so that they don't wait for a build which never runs.
+func (o *Orchestrator) failReservedBuild(ref, buildID string, err error) {
+	o.releaseBuild(ref, buildID)
+	o.PublishStatus(buildID, &protocol.BuildResponse{
+		Status:  protocol.BuildStatus_done_failure,
+		Message: fmt.Sprintf("cannot start build: %v", status.Convert(err).Message()),
+	})
+	o.clearListener(buildID)
+}
+
+// releaseBuild removes the reservation of a workspace image ref
+func (o *Orchestrator) releaseBuild(ref, buildID string) {
+	o.buildsMu.Lock()
+	defer o.buildsMu.Unlock()
+
+	if bld, ok := o.builds[ref]; ok && bld.ID == buildID {
+		delete(o.builds, ref)
+	}
+}
+
 func (o *Orchestrator) checkImageExists(ctx context.Context, ref string, authentication *auth.Authentication) (exists bool, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "checkImageExists")
 	defer tracing.FinishSpan(span, &err)
@@ -548,6 +804,130 @@ func (o *Orchestrator) checkImageExists(ctx context.Context, ref string, authent
 	return true, nil
 }
 
+// attest produces and stores the configured attestations for a built workspace image
+func (o *Orchestrator) attest(ctx context.Context, ref string, authentication *auth.Authentication, md attestation.BuildMetadata) (res []*protocol.BuildAttestation, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "attest")
+	defer tracing.FinishSpan(span, &err)
+	span.SetTag("ref", ref)
+
+	absref, err := o.RefResolver.Resolve(ctx, ref, resolve.WithAuthentication(authentication))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot resolve workspace image: %w", err)
+	}
+
+	md.FinishedAt = time.Now()
+	return o.attestor(authentication).Attest(ctx, absref, md)
+}
+
+// attestationEnabled returns true if the image builder is configured to produce attestations
+func (o *Orchestrator) attestationEnabled() bool {
+	return o.attestation.SBOMFormat != "" || o.attestation.Provenance
+}
+
+// attestor produces the attestor which stores attestations next to the workspace images
+func (o *Orchestrator) attestor(authentication *auth.Authentication) *attestation.Attestor {
+	var resolver remotes.Resolver
+	if o.AttestationResolverFactory != nil {
+		resolver = o.AttestationResolverFactory(authentication)
+	} else {
+		resolver = dockerremote.NewResolver(dockerremote.ResolverOptions{
+			Authorizer: dockerremote.NewDockerAuthorizer(dockerremote.WithAuthCreds(func(host string) (username, password string, err error) {
+				if authentication == nil {
+					return
+				}
+				return authentication.Username, authentication.Password, nil
+			})),
+		})
+	}
+	return &attestation.Attestor{
+		Config:   o.attestation,
+		Resolver: resolver,
+	}
+}
+
+// lookupAttestations returns the attestations which are already stored for a workspace image.
+// Attestations are a best effort, hence errors are logged rather than returned.
+func (o *Orchestrator) lookupAttestations(ctx context.Context, ref string, authentication *auth.Authentication) []*protocol.BuildAttestation {
+	absref, err := o.RefResolver.Resolve(ctx, ref, resolve.WithAuthentication(authentication))
+	if err != nil {
+		log.WithError(err).WithField("ref", ref).Warn("cannot resolve workspace image to look up its attestations")
+		return nil
+	}
+	res, err := o.attestor(authentication).Lookup(ctx, absref)
+	if err != nil {
+		log.WithError(err).WithField("ref", ref).Warn("cannot look up image attestations")
+		return nil
+	}
+	return res
+}
+
+type attestedBuild struct {
+	Info *protocol.BuildInfo
+	At   time.Time
+}
+
+// attestInBackground produces the attestations of a successful build without blocking the caller.
+// If the attestations of the same workspace image are already being produced, this function does nothing.
+func (o *Orchestrator) attestInBackground(info *protocol.BuildInfo, authentication *auth.Authentication, md attestation.BuildMetadata) {
+	o.mu.Lock()
+	if _, ok := o.attesting[info.Ref]; ok {
+		o.mu.Unlock()
+		return
+	}
+	o.attesting[info.Ref] = struct{}{}
+	o.mu.Unlock()
+
+	go func() {
+		defer func() {
+			o.mu.Lock()
+			delete(o.attesting, info.Ref)
+			o.mu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), maxAttestationRuntime)
+		defer cancel()
+
+		atts, err := o.attest(ctx, info.Ref, authentication, md)
+		if err != nil {
+			// attestations are a best effort - their absence must not break anything
+			log.WithError(err).WithField("buildID", info.BuildId).WithField("ref", info.Ref).Warn("cannot produce image attestations")
+			return
+		}
+		info.Attestations = atts
+		log.WithField("buildID", info.BuildId).WithField("ref", info.Ref).WithField("attestations", atts).Debug("produced image attestations")
+
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		now := time.Now()
+		o.attested[info.BuildId] = &attestedBuild{Info: info, At: now}
+		for id, bld := range o.attested {
+			if now.Sub(bld.At) > finishedBuildRetention {
+				delete(o.attested, id)
+			}
+		}
+	}()
+}
+
+// getBuildMetadata describes a build for its provenance attestation
+func getBuildMetadata(req *protocol.BuildRequest, builderImage, buildID, baseref string, startedAt time.Time) attestation.BuildMetadata {
+	md := attestation.BuildMetadata{
+		BuilderID: builderImage,
+		BuildID:   buildID,
+		BaseRef:   baseref,
+		StartedAt: startedAt,
+	}
+	if fsrc := req.Source.GetFile(); fsrc != nil {
+		md.DockerfilePath = fsrc.DockerfilePath
+		if git := fsrc.Source.GetGit(); git != nil {
+			md.Source = &attestation.SourceMaterial{URI: git.RemoteUri}
+			if git.TargetMode == csapi.CloneTargetMode_REMOTE_COMMIT {
+				md.Source.Revision = git.CloneTaget
+			}
+		}
+	}
+	return md
+}
+
 // getAbsoluteImageRef returns the "digest" form of an image, i.e. contains no mutable image tags
 func (o *Orchestrator) getAbsoluteImageRef(ctx context.Context, ref string, allowedAuth auth.AllowedAuthFor) (res string, err error) {
 	auth, err := allowedAuth.GetAuthFor(o.Auth, ref)
@@ -681,10 +1061,16 @@ type buildListener chan *api.BuildResponse
 
 type logListener chan *api.LogsResponse
 
-func (o *Orchestrator) registerBuildListener(buildID string) (c <-chan *api.BuildResponse, cancel func()) {
+// registerBuildListener registers a listener for the status updates of a build. If the build
+// has already finished, ok is false and no listener is registered.
+func (o *Orchestrator) registerBuildListener(buildID string) (c <-chan *api.BuildResponse, cancel func(), ok bool) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
+	if _, finished := o.finished[buildID]; finished {
+		return nil, nil, false
+	}
+
 	l := make(buildListener)
 	ls := o.buildListener[buildID]
 	if ls == nil {
@@ -703,7 +1089,7 @@ func (o *Orchestrator) registerBuildListener(buildID string) (c <-chan *api.Buil
 		delete(ls, l)
 		o.buildListener[buildID] = ls
 	}
-	return l, cancel
+	return l, cancel, true
 }
 
 func (o *Orchestrator) registerLogListener(buildID string) (c <-chan *api.LogsResponse, cancel func()) {
@@ -734,6 +1120,37 @@ func (o *Orchestrator) registerLogListener(buildID string) (c <-chan *api.LogsRe
 	return l, cancel
 }
 
+// markFinished records that a build has finished. Cancelled builds are reported as failed.
+func (o *Orchestrator) markFinished(buildID string, resp *api.BuildResponse) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, cancelled := o.cancelled[buildID]; cancelled {
+		resp.Status = api.BuildStatus_done_failure
+		resp.Message = "image build was cancelled"
+		if resp.Info != nil {
+			resp.Info.Status = api.BuildStatus_done_failure
+		}
+		delete(o.cancelled, buildID)
+	}
+
+	now := time.Now()
+	o.finished[buildID] = now
+	for id, t := range o.finished {
+		if now.Sub(t) > finishedBuildRetention {
+			delete(o.finished, id)
+		}
+	}
+}
+
+// countBuildListener returns the number of clients waiting for a build
+func (o *Orchestrator) countBuildListener(buildID string) int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return len(o.buildListener[buildID])
+}
+
 // clearListener removes all listener for a particular build
 func (o *Orchestrator) clearListener(buildID string) {
 	o.mu.Lock()
@@ -742,6 +1159,7 @@ func (o *Orchestrator) clearListener(buildID string) {
 	delete(o.buildListener, buildID)
 	delete(o.logListener, buildID)
 	delete(o.censorship, buildID)
+	delete(o.redactions, buildID)
 }
 
 // censor registers tokens that are censored in the log output
@@ -752,6 +1170,19 @@ func (o *Orchestrator) censor(buildID string, words []string) {
 	o.censorship[buildID] = words
 }
 
+// redact registers secret values that are replaced in the log output. Other than censored words,
+// redacted values are replaced by a marker so that users can tell where a secret was used.
+func (o *Orchestrator) redact(buildID string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.redactions[buildID] = values
+}
+
 // PublishLog broadcasts log output to all registered listener
 func (o *Orchestrator) PublishLog(buildID string, message string) {
 	o.mu.RLock()
@@ -765,7 +1196,11 @@ func (o *Orchestrator) PublishLog(buildID string, message string) {
 
 	o.mu.RLock()
 	wds := o.censorship[buildID]
+	rds := o.redactions[buildID]
 	o.mu.RUnlock()
+	for _, w := range rds {
+		message = strings.ReplaceAll(message, w, redactedMarker)
+	}
 	for _, w := range wds {
 		message = strings.ReplaceAll(message, w, "")
 	}
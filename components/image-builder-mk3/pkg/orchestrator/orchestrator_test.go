@@ -5,15 +5,25 @@
 package orchestrator
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
 	"github.com/gitpod-io/gitpod/image-builder/api"
 	"github.com/gitpod-io/gitpod/image-builder/api/config"
 	apimock "github.com/gitpod-io/gitpod/image-builder/api/mock"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/attestation"
+	"github.com/gitpod-io/gitpod/image-builder/pkg/auth"
 	"github.com/gitpod-io/gitpod/image-builder/pkg/resolve"
 	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
 	wsmock "github.com/gitpod-io/gitpod/ws-manager/api/mock"
@@ -21,6 +31,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
 )
 
 func TestBuild(t *testing.T) {
@@ -205,3 +216,335 @@ func TestResolveBaseImage(t *testing.T) {
 	}
 
 }
+
+func TestReserveBuild(t *testing.T) {
+	type reservation struct {
+		Ref     string
+		Owner   string
+		BuildID string
+	}
+	type Expectation struct {
+		BuildID string
+		Ongoing bool
+		Code    codes.Code
+	}
+	tests := []struct {
+		Name         string
+		Limit        int
+		Running      []*runningBuild
+		Reservations []reservation
+		Req          reservation
+		Expectation  Expectation
+	}{
+		{
+			Name:        "new build",
+			Req:         reservation{Ref: "ref", Owner: "owner", BuildID: "new"},
+			Expectation: Expectation{BuildID: "new"},
+		},
+		{
+			Name:         "coalesce with reserved build",
+			Reservations: []reservation{{Ref: "ref", Owner: "someone-else", BuildID: "first"}},
+			Req:          reservation{Ref: "ref", Owner: "owner", BuildID: "new"},
+			Expectation:  Expectation{BuildID: "first", Ongoing: true},
+		},
+		{
+			Name:        "coalesce with running build",
+			Running:     []*runningBuild{{Info: api.BuildInfo{BuildId: "running", Ref: "ref"}}},
+			Req:         reservation{Ref: "ref", Owner: "owner", BuildID: "new"},
+			Expectation: Expectation{BuildID: "running", Ongoing: true},
+		},
+		{
+			Name:  "owner limit exceeded",
+			Limit: 2,
+			Running: []*runningBuild{
+				{Info: api.BuildInfo{BuildId: "running", Ref: "other-ref"}, Owner: "owner"},
+			},
+			Reservations: []reservation{{Ref: "another-ref", Owner: "owner", BuildID: "reserved"}},
+			Req:          reservation{Ref: "ref", Owner: "owner", BuildID: "new"},
+			Expectation:  Expectation{Code: codes.ResourceExhausted},
+		},
+		{
+			Name:  "coalescing is not limited",
+			Limit: 1,
+			Running: []*runningBuild{
+				{Info: api.BuildInfo{BuildId: "running", Ref: "ref"}, Owner: "owner"},
+			},
+			Req:         reservation{Ref: "ref", Owner: "owner", BuildID: "new"},
+			Expectation: Expectation{BuildID: "running", Ongoing: true},
+		},
+		{
+			Name:  "other owners do not count",
+			Limit: 1,
+			Running: []*runningBuild{
+				{Info: api.BuildInfo{BuildId: "running", Ref: "other-ref"}, Owner: "someone-else"},
+			},
+			Req:         reservation{Ref: "ref", Owner: "owner", BuildID: "new"},
+			Expectation: Expectation{BuildID: "new"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			o, err := NewOrchestratingBuilder(config.Configuration{
+				WorkspaceManager: config.WorkspaceManagerConfig{
+					Client: wsmock.NewMockWorkspaceManagerClient(ctrl),
+				},
+				MaxConcurrentBuildsPerOwner: test.Limit,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, bld := range test.Running {
+				o.monitor.runningBuilds[bld.Info.BuildId] = bld
+			}
+			for _, r := range test.Reservations {
+				_, _, err := o.reserveBuild(context.Background(), r.Ref, r.Owner, r.BuildID)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			buildID, ongoing, err := o.reserveBuild(context.Background(), test.Req.Ref, test.Req.Owner, test.Req.BuildID)
+			act := Expectation{BuildID: buildID, Ongoing: ongoing, Code: status.Code(err)}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("reserveBuild() mismatch (-want +got):\n%s", diff)
+			}
+
+			if !act.Ongoing && act.Code == codes.OK {
+				o.releaseBuild(test.Req.Ref, buildID)
+				if _, ok := o.builds[test.Req.Ref]; ok {
+					t.Error("releaseBuild() did not release the reservation")
+				}
+			}
+		})
+	}
+}
+
+func TestCancelBuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wsman := wsmock.NewMockWorkspaceManagerClient(ctrl)
+	o, err := NewOrchestratingBuilder(config.Configuration{
+		WorkspaceManager: config.WorkspaceManagerConfig{
+			Client: wsman,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.monitor.runningBuilds["build-id"] = &runningBuild{Info: api.BuildInfo{BuildId: "build-id", Ref: "ref", Status: api.BuildStatus_running}, Owner: "owner"}
+
+	_, err = o.CancelBuild(context.Background(), &api.CancelBuildRequest{BuildId: "unknown", Admin: true})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for unknown build, got %v", err)
+	}
+	for _, req := range []*api.CancelBuildRequest{
+		{BuildId: "build-id"},
+		{BuildId: "build-id", TriggeredBy: "someone-else"},
+	} {
+		_, err = o.CancelBuild(context.Background(), req)
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound for build of another owner (%v), got %v", req, err)
+		}
+	}
+
+	wsman.EXPECT().StopWorkspace(gomock.Any(), &wsmanapi.StopWorkspaceRequest{
+		Id:     "build-id",
+		Policy: wsmanapi.StopWorkspacePolicy_IMMEDIATELY,
+	}).Return(&wsmanapi.StopWorkspaceResponse{}, nil)
+	_, err = o.CancelBuild(context.Background(), &api.CancelBuildRequest{BuildId: "build-id", TriggeredBy: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates, cancel, ok := o.registerBuildListener("build-id")
+	if !ok {
+		t.Fatal("cannot register build listener")
+	}
+	defer cancel()
+	go o.PublishStatus("build-id", &api.BuildResponse{Status: api.BuildStatus_done_success})
+
+	var update *api.BuildResponse
+	select {
+	case update = <-updates:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout while waiting for build status")
+	}
+	if update.Status != api.BuildStatus_done_failure {
+		t.Errorf("cancelled build reported status %v, expected %v", update.Status, api.BuildStatus_done_failure)
+	}
+
+	if _, _, ok := o.registerBuildListener("build-id"); ok {
+		t.Error("could register listener for finished build")
+	}
+}
+
+func TestFailReservedBuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	o, err := NewOrchestratingBuilder(config.Configuration{
+		WorkspaceManager: config.WorkspaceManagerConfig{
+			Client: wsmock.NewMockWorkspaceManagerClient(ctrl),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buildID, _, err := o.reserveBuild(context.Background(), "ref", "owner", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	coalesced, ongoing, err := o.reserveBuild(context.Background(), "ref", "someone-else", "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ongoing || coalesced != buildID {
+		t.Fatalf("expected request to be coalesced with %s, got %s", buildID, coalesced)
+	}
+	updates, cancel, ok := o.registerBuildListener(coalesced)
+	if !ok {
+		t.Fatal("cannot register build listener")
+	}
+	defer cancel()
+
+	go o.failReservedBuild("ref", buildID, status.Error(codes.Internal, "no workspace"))
+
+	var update *api.BuildResponse
+	select {
+	case update = <-updates:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout while waiting for build status")
+	}
+	if diff := cmp.Diff(&api.BuildResponse{Status: api.BuildStatus_done_failure, Message: "cannot start build: no workspace"}, update, protocmp.Transform()); diff != "" {
+		t.Errorf("failReservedBuild() status mismatch (-want +got):\n%s", diff)
+	}
+
+	newBuildID, ongoing, err := o.reserveBuild(context.Background(), "ref", "someone-else", "third")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ongoing || newBuildID != "third" {
+		t.Errorf("expected a new build after the failed one, got %s (ongoing: %v)", newBuildID, ongoing)
+	}
+}
+
+func TestAttestationResolverFactory(t *testing.T) {
+	const (
+		ref    = "registry.example.com/workspace:abc"
+		absref = "registry.example.com/workspace@sha256:2b1325adbf901167f47a914a62d377c98f1e32e0837dafb95ca86ca9d08ab14e"
+		tag    = "registry.example.com/workspace:sha256-2b1325adbf901167f47a914a62d377c98f1e32e0837dafb95ca86ca9d08ab14e.att"
+	)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	o, err := NewOrchestratingBuilder(config.Configuration{
+		WorkspaceManager: config.WorkspaceManagerConfig{
+			Client: wsmock.NewMockWorkspaceManagerClient(ctrl),
+		},
+		Attestation: &config.AttestationConfig{Provenance: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.RefResolver = resolve.MockRefResolver{ref: absref}
+
+	registry := &fakeRegistry{manifests: make(map[string]ociv1.Descriptor)}
+	authentication := &auth.Authentication{Username: "user", Password: "password"}
+	o.AttestationResolverFactory = func(a *auth.Authentication) remotes.Resolver {
+		if diff := cmp.Diff(authentication, a); diff != "" {
+			t.Errorf("AttestationResolverFactory() authentication mismatch (-want +got):\n%s", diff)
+		}
+		return registry
+	}
+
+	if atts := o.lookupAttestations(context.Background(), ref, authentication); len(atts) != 0 {
+		t.Fatalf("found attestations before they were produced: %v", atts)
+	}
+
+	o.attestInBackground(&api.BuildInfo{BuildId: "build-id", Ref: ref, Status: api.BuildStatus_done_success}, authentication, attestation.BuildMetadata{BuildID: "build-id"})
+
+	var builds []*api.BuildInfo
+	for start := time.Now(); len(builds) == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("timeout while waiting for attestations")
+		}
+		resp, err := o.ListBuilds(context.Background(), &api.ListBuildsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		builds = resp.Builds
+	}
+
+	exp := []*api.BuildAttestation{{Kind: api.BuildAttestationKind_provenance, Ref: tag, MediaType: attestation.MediaTypeInToto}}
+	if diff := cmp.Diff(exp, builds[0].Attestations, protocmp.Transform()); diff != "" {
+		t.Errorf("ListBuilds() attestations mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(exp, o.lookupAttestations(context.Background(), ref, authentication), protocmp.Transform()); diff != "" {
+		t.Errorf("lookupAttestations() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// fakeRegistry is an in-memory registry which stores pushed manifests under the ref they were pushed to
+type fakeRegistry struct {
+	mu        sync.Mutex
+	manifests map[string]ociv1.Descriptor
+}
+
+func (r *fakeRegistry) Resolve(ctx context.Context, ref string) (name string, desc ociv1.Descriptor, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	desc, ok := r.manifests[ref]
+	if !ok {
+		return "", ociv1.Descriptor{}, errdefs.ErrNotFound
+	}
+	return ref, desc, nil
+}
+
+func (r *fakeRegistry) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
+func (r *fakeRegistry) Pusher(ctx context.Context, ref string) (remotes.Pusher, error) {
+	return remotes.PusherFunc(func(ctx context.Context, desc ociv1.Descriptor) (content.Writer, error) {
+		return &fakeRegistryWriter{registry: r, ref: ref, desc: desc}, nil
+	}), nil
+}
+
+type fakeRegistryWriter struct {
+	bytes.Buffer
+	registry *fakeRegistry
+	ref      string
+	desc     ociv1.Descriptor
+}
+
+func (w *fakeRegistryWriter) Close() error { return nil }
+
+func (w *fakeRegistryWriter) Digest() digest.Digest { return digest.FromBytes(w.Bytes()) }
+
+func (w *fakeRegistryWriter) Commit(ctx context.Context, size int64, expected digest.Digest, opts ...content.Opt) error {
+	if expected != "" && expected != w.Digest() {
+		return errdefs.ErrFailedPrecondition
+	}
+	if w.desc.MediaType == ociv1.MediaTypeImageManifest {
+		w.registry.mu.Lock()
+		w.registry.manifests[w.ref] = w.desc
+		w.registry.mu.Unlock()
+	}
+	return nil
+}
+
+func (w *fakeRegistryWriter) Status() (content.Status, error) {
+	return content.Status{Ref: w.ref, Offset: int64(w.Len()), Total: w.desc.Size}, nil
+}
+
+func (w *fakeRegistryWriter) Truncate(size int64) error {
+	w.Buffer.Truncate(int(size))
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package orchestrator
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	protocol "github.com/gitpod-io/gitpod/image-builder/api"
+	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+const (
+	// redactedMarker replaces build secret values in the log output
+	redactedMarker = "[redacted]"
+
+	// maxBuildSecrets is the maximum number of secrets and SSH keys a single build can use
+	maxBuildSecrets = 32
+
+	// minRedactedLength is the minimum length of a secret value, or of a line of a multi-line
+	// secret, for it to be redacted. Shorter values would redact unrelated log output.
+	minRedactedLength = 8
+
+	// buildSecretsKeyLength is the length of the key used to encrypt build secrets
+	buildSecretsKeyLength = 32
+)
+
+// secretIDPattern restricts build secret IDs to what we can safely pass on to buildctl
+var secretIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// validateBuildSecrets ensures that the secrets and SSH keys of a build request are usable
+func validateBuildSecrets(req *protocol.BuildRequest) error {
+	if len(req.Secrets) == 0 && len(req.Ssh) == 0 {
+		return nil
+	}
+	if req.Source.GetFile() == nil {
+		return xerrors.Errorf("build secrets and SSH keys require a Dockerfile build source")
+	}
+	if len(req.Secrets)+len(req.Ssh) > maxBuildSecrets {
+		return xerrors.Errorf("too many build secrets: at most %d are supported", maxBuildSecrets)
+	}
+
+	ids := make(map[string]struct{}, len(req.Secrets))
+	for _, s := range req.Secrets {
+		if !secretIDPattern.MatchString(s.Id) {
+			return xerrors.Errorf("invalid build secret ID %q", s.Id)
+		}
+		if _, exists := ids[s.Id]; exists {
+			return xerrors.Errorf("duplicate build secret ID %q", s.Id)
+		}
+		ids[s.Id] = struct{}{}
+	}
+
+	ids = make(map[string]struct{}, len(req.Ssh))
+	for _, k := range req.Ssh {
+		id := k.Id
+		if id == "" {
+			id = "default"
+		}
+		if !secretIDPattern.MatchString(id) {
+			return xerrors.Errorf("invalid SSH key ID %q", id)
+		}
+		if _, exists := ids[id]; exists {
+			return xerrors.Errorf("duplicate SSH key ID %q", id)
+		}
+		if k.PrivateKey == "" {
+			return xerrors.Errorf("SSH key %q has no private key", id)
+		}
+		ids[id] = struct{}{}
+	}
+	return nil
+}
+
+// secretValues returns all values that must not show up in the build logs
+func secretValues(req *protocol.BuildRequest) []string {
+	var res []string
+	add := func(v string) {
+		v = strings.TrimSpace(v)
+		if len(v) < minRedactedLength {
+			return
+		}
+		res = append(res, v)
+
+		if !strings.Contains(v, "\n") {
+			return
+		}
+		for _, l := range strings.Split(v, "\n") {
+			l = strings.TrimSpace(l)
+			if len(l) < minRedactedLength {
+				continue
+			}
+			res = append(res, l)
+		}
+	}
+	for _, s := range req.Secrets {
+		add(s.Value)
+	}
+	for _, k := range req.Ssh {
+		add(k.PrivateKey)
+	}
+	return res
+}
+
+type bobBuildSecret struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+type bobBuildSSHKey struct {
+	ID         string `json:"id"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// getBuildSecretEnvVars produces the environment variables which pass the build secrets and
+// SSH keys on to bob. The values are encrypted using the build secrets key which bob receives
+// as BOB_AUTH_KEY from the build secrets key secret, so that they never appear in plain text
+// in the build workspace's pod spec.
+func (o *Orchestrator) getBuildSecretEnvVars(req *protocol.BuildRequest) ([]*wsmanapi.EnvironmentVariable, error) {
+	if len(req.Secrets) == 0 && len(req.Ssh) == 0 {
+		return nil, nil
+	}
+	if len(o.buildSecretsKey) == 0 || o.Config.BuildSecretsKeySecret == "" {
+		return nil, xerrors.Errorf("no build secrets key configured")
+	}
+
+	res := []*wsmanapi.EnvironmentVariable{
+		{
+			Name: "BOB_AUTH_KEY",
+			Secret: &wsmanapi.EnvironmentVariable_SecretKeyRef{
+				SecretName: o.Config.BuildSecretsKeySecret,
+				Key:        "key",
+			},
+		},
+	}
+	if len(req.Secrets) > 0 {
+		secrets := make([]bobBuildSecret, 0, len(req.Secrets))
+		for _, s := range req.Secrets {
+			secrets = append(secrets, bobBuildSecret{ID: s.Id, Value: s.Value})
+		}
+		val, err := encryptJSON(secrets, o.buildSecretsKey)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &wsmanapi.EnvironmentVariable{Name: "BOB_BUILD_SECRETS", Value: val})
+	}
+	if len(req.Ssh) > 0 {
+		keys := make([]bobBuildSSHKey, 0, len(req.Ssh))
+		for _, k := range req.Ssh {
+			keys = append(keys, bobBuildSSHKey{ID: k.Id, PrivateKey: k.PrivateKey})
+		}
+		val, err := encryptJSON(keys, o.buildSecretsKey)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &wsmanapi.EnvironmentVariable{Name: "BOB_BUILD_SSH_KEYS", Value: val})
+	}
+	return res, nil
+}
+
+// encryptJSON marshals obj and encrypts it using AES-GCM the way bob expects it, i.e. with the
+// nonce prepended to the ciphertext and base64 encoded without padding.
+func encryptJSON(obj interface{}, key []byte) (string, error) {
+	plaintext, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawStdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package orchestrator
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitpod-io/gitpod/image-builder/api"
+	"github.com/gitpod-io/gitpod/image-builder/api/config"
+)
+
+func TestValidateBuildSecrets(t *testing.T) {
+	fileSource := &api.BuildSource{From: &api.BuildSource_File{File: &api.BuildSourceDockerfile{}}}
+	refSource := &api.BuildSource{From: &api.BuildSource_Ref{Ref: &api.BuildSourceReference{Ref: "alpine:latest"}}}
+
+	tests := []struct {
+		Name        string
+		Req         *api.BuildRequest
+		ExpectError bool
+	}{
+		{
+			Name: "no secrets",
+			Req:  &api.BuildRequest{Source: refSource},
+		},
+		{
+			Name: "valid secrets",
+			Req: &api.BuildRequest{
+				Source:  fileSource,
+				Secrets: []*api.BuildSecret{{Id: "npmrc", Value: "foo"}, {Id: "deploy_key", Value: "bar"}},
+				Ssh:     []*api.BuildSSHKey{{PrivateKey: "key"}, {Id: "github", PrivateKey: "key"}},
+			},
+		},
+		{
+			Name:        "secrets with ref source",
+			Req:         &api.BuildRequest{Source: refSource, Secrets: []*api.BuildSecret{{Id: "npmrc", Value: "foo"}}},
+			ExpectError: true,
+		},
+		{
+			Name:        "invalid secret ID",
+			Req:         &api.BuildRequest{Source: fileSource, Secrets: []*api.BuildSecret{{Id: "npmrc,src=/etc/shadow", Value: "foo"}}},
+			ExpectError: true,
+		},
+		{
+			Name:        "duplicate secret ID",
+			Req:         &api.BuildRequest{Source: fileSource, Secrets: []*api.BuildSecret{{Id: "npmrc", Value: "foo"}, {Id: "npmrc", Value: "bar"}}},
+			ExpectError: true,
+		},
+		{
+			Name:        "duplicate default SSH key",
+			Req:         &api.BuildRequest{Source: fileSource, Ssh: []*api.BuildSSHKey{{PrivateKey: "key"}, {Id: "default", PrivateKey: "key"}}},
+			ExpectError: true,
+		},
+		{
+			Name:        "SSH key without private key",
+			Req:         &api.BuildRequest{Source: fileSource, Ssh: []*api.BuildSSHKey{{Id: "github"}}},
+			ExpectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := validateBuildSecrets(test.Req)
+			if test.ExpectError && err == nil {
+				t.Error("expected an error but got none")
+			}
+			if !test.ExpectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPublishLogRedactsSecrets(t *testing.T) {
+	const buildID = "build-id"
+	o := &Orchestrator{
+		logListener: make(map[string]map[logListener]struct{}),
+		censorship:  make(map[string][]string),
+		redactions:  make(map[string][]string),
+	}
+	o.censor(buildID, []string{"registry/base"})
+	o.redact(buildID, secretValues(&api.BuildRequest{
+		Secrets: []*api.BuildSecret{{Id: "npmrc", Value: "npm-super-secret"}},
+		Ssh:     []*api.BuildSSHKey{{PrivateKey: "-----BEGIN KEY-----\nc2VjcmV0LWtleS1tYXRlcmlhbA==\n-----END KEY-----\n"}},
+	}))
+
+	logs, cancel := o.registerLogListener(buildID)
+	defer cancel()
+
+	go o.PublishLog(buildID, "pulling registry/base:abc\nnpm-super-secret\nline c2VjcmV0LWtleS1tYXRlcmlhbA==\n")
+
+	var act string
+	select {
+	case l := <-logs:
+		act = string(l.Content)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout while waiting for log output")
+	}
+
+	exp := "pulling :abc\n[redacted]\nline [redacted]\n"
+	if diff := cmp.Diff(exp, act); diff != "" {
+		t.Errorf("PublishLog() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSecretValues(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Req         *api.BuildRequest
+		Expectation []string
+	}{
+		{
+			Name: "no secrets",
+			Req:  &api.BuildRequest{},
+		},
+		{
+			Name:        "long value",
+			Req:         &api.BuildRequest{Secrets: []*api.BuildSecret{{Id: "npmrc", Value: " npm-super-secret\n"}}},
+			Expectation: []string{"npm-super-secret"},
+		},
+		{
+			Name: "short value",
+			Req:  &api.BuildRequest{Secrets: []*api.BuildSecret{{Id: "flag", Value: "true"}}},
+		},
+		{
+			Name:        "multi-line value",
+			Req:         &api.BuildRequest{Ssh: []*api.BuildSSHKey{{PrivateKey: "BEGIN\nc2VjcmV0LWtleQ==\nEND"}}},
+			Expectation: []string{"BEGIN\nc2VjcmV0LWtleQ==\nEND", "c2VjcmV0LWtleQ=="},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := secretValues(test.Req)
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("secretValues() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetBuildSecretEnvVars(t *testing.T) {
+	const key = "0123456789abcdef0123456789abcdef"
+	req := &api.BuildRequest{
+		Secrets: []*api.BuildSecret{{Id: "npmrc", Value: "npm-super-secret"}},
+		Ssh:     []*api.BuildSSHKey{{Id: "github", PrivateKey: "private-key"}},
+	}
+
+	tests := []struct {
+		Name        string
+		Key         string
+		Secret      string
+		ExpectError bool
+	}{
+		{Name: "no key", Secret: "build-secrets-key", ExpectError: true},
+		{Name: "no secret", Key: key, ExpectError: true},
+		{Name: "encrypted", Key: key, Secret: "build-secrets-key"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			o := &Orchestrator{
+				Config:          config.Configuration{BuildSecretsKeySecret: test.Secret},
+				buildSecretsKey: []byte(test.Key),
+			}
+			env, err := o.getBuildSecretEnvVars(req)
+			if test.ExpectError {
+				if err == nil {
+					t.Fatal("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			act := make(map[string]interface{})
+			for _, e := range env {
+				if e.Secret != nil {
+					act[e.Name] = e.Secret.SecretName + "/" + e.Secret.Key
+					continue
+				}
+				if e.Value == "" || e.Value[0] == '[' {
+					t.Fatalf("%s is not encrypted: %q", e.Name, e.Value)
+				}
+				var dec []map[string]string
+				err := json.Unmarshal(decryptTestValue(t, e.Value, key), &dec)
+				if err != nil {
+					t.Fatalf("cannot unmarshal %s: %v", e.Name, err)
+				}
+				act[e.Name] = dec
+			}
+			exp := map[string]interface{}{
+				"BOB_AUTH_KEY":       "build-secrets-key/key",
+				"BOB_BUILD_SECRETS":  []map[string]string{{"id": "npmrc", "value": "npm-super-secret"}},
+				"BOB_BUILD_SSH_KEYS": []map[string]string{{"id": "github", "privateKey": "private-key"}},
+			}
+			if diff := cmp.Diff(exp, act); diff != "" {
+				t.Errorf("getBuildSecretEnvVars() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// decryptTestValue decrypts a value the way bob does
+func decryptTestValue(t *testing.T, value, key string) []byte {
+	dec, err := base64.RawStdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, ciphertext := dec[:gcm.NonceSize()], dec[gcm.NonceSize():]
+	res, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gitpod-io/gitpod/licensor/ee/pkg/licensor"
+)
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [license]",
+	Short: "Prints the remaining entitlements of a license - reads from stdin if no argument is provided",
+	Long: `Prints the remaining entitlements of a license - reads from stdin if no argument is provided.
+Unlike validate, inspect prints licenses which are expired or issued for another domain, too.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		e, err := newEvaluator(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		msg, valid := e.Validate()
+		lic := e.Inspect()
+		if lic.ID == "" {
+			// we could not even decode the license
+			fmt.Printf("Valid: no (%s)\n", msg)
+			return nil
+		}
+		printEntitlements(os.Stdout, lic, msg, valid, time.Now())
+		return nil
+	},
+}
+
+func printEntitlements(out io.Writer, lic licensor.LicensePayload, msg string, valid bool, now time.Time) {
+	ent := lic.Entitlements(now)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	level := fmt.Sprintf("%d", lic.Level)
+	for name, lvl := range licensor.NamedLevel {
+		if lvl == lic.Level {
+			level = name
+		}
+	}
+	fmt.Fprintf(w, "ID:\t%s\n", lic.ID)
+	fmt.Fprintf(w, "Domain:\t%s\n", orDefault(lic.Domain, "any"))
+	fmt.Fprintf(w, "Level:\t%s\n", level)
+	if valid {
+		fmt.Fprintf(w, "Valid:\tyes\n")
+	} else {
+		fmt.Fprintf(w, "Valid:\tno (%s)\n", msg)
+	}
+	if ent.ValidUntil.IsZero() {
+		fmt.Fprintf(w, "Valid until:\tnever expires\n")
+	} else {
+		fmt.Fprintf(w, "Valid until:\t%s\n", describeDeadline(ent.ValidUntil, now))
+	}
+	if ent.GraceUntil != nil {
+		grace := describeDeadline(*ent.GraceUntil, now)
+		if ent.InGracePeriod {
+			grace += ", in grace period"
+		}
+		fmt.Fprintf(w, "Grace period:\t%d days, until %s\n", lic.GracePeriodDays, grace)
+	}
+	if ent.Seats == 0 {
+		fmt.Fprintf(w, "Seats:\tunlimited\n")
+	} else {
+		fmt.Fprintf(w, "Seats:\t%d\n", ent.Seats)
+	}
+
+	fmt.Fprintf(w, "Features:\t\n")
+	for _, f := range ent.Features {
+		var desc string
+		switch {
+		case !f.Active && f.ValidFrom != nil:
+			desc = "from " + f.ValidFrom.Format(time.RFC3339)
+		case f.ValidUntil != nil:
+			desc = "until " + describeDeadline(*f.ValidUntil, now)
+		default:
+			desc = "unlimited"
+		}
+		fmt.Fprintf(w, "  %s\t%s\n", f.Feature, desc)
+	}
+
+	fmt.Fprintf(w, "Quotas:\t\n")
+	if len(ent.Quotas) == 0 {
+		fmt.Fprintf(w, "  none\t\n")
+	}
+	quotas := make([]string, 0, len(ent.Quotas))
+	for q := range ent.Quotas {
+		quotas = append(quotas, string(q))
+	}
+	sort.Strings(quotas)
+	for _, q := range quotas {
+		fmt.Fprintf(w, "  %s\t%d\n", q, ent.Quotas[licensor.Quota(q)])
+	}
+}
+
+// describeDeadline formats a point in time alongside the time left until then
+func describeDeadline(t, now time.Time) string {
+	left := t.Sub(now)
+	if left < 0 {
+		return fmt.Sprintf("%s (passed)", t.Format(time.RFC3339))
+	}
+	days := int(left.Hours() / 24)
+	if days > 0 {
+		return fmt.Sprintf("%s (%d days left)", t.Format(time.RFC3339), days)
+	}
+	return fmt.Sprintf("%s (%s left)", t.Format(time.RFC3339), left.Round(time.Minute))
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+	inspectCmd.Flags().String("domain", "", "domain to evaluate the license against")
+	inspectCmd.Flags().String("licensor", "gitpod", "licensor to use")
+}
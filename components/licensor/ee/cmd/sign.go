@@ -9,6 +9,8 @@ import (
 	"encoding/pem"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -41,11 +43,14 @@ var signCmd = &cobra.Command{
 		}
 
 		var (
-			domain, _   = cmd.Flags().GetString("domain")
-			id, _       = cmd.Flags().GetString("id")
-			level, _    = cmd.Flags().GetString("level")
-			seats, _    = cmd.Flags().GetInt("seats")
-			validFor, _ = cmd.Flags().GetDuration("valid-for")
+			domain, _    = cmd.Flags().GetString("domain")
+			id, _        = cmd.Flags().GetString("id")
+			level, _     = cmd.Flags().GetString("level")
+			seats, _     = cmd.Flags().GetInt("seats")
+			validFor, _  = cmd.Flags().GetDuration("valid-for")
+			features, _  = cmd.Flags().GetStringArray("feature")
+			quotas, _    = cmd.Flags().GetStringArray("quota")
+			graceDays, _ = cmd.Flags().GetInt("grace-period-days")
 		)
 		if domain == "" {
 			return xerrors.Errorf("--domain is mandatory")
@@ -62,18 +67,48 @@ var signCmd = &cobra.Command{
 		if validFor <= 0 {
 			return xerrors.Errorf("--valid-for must be positive")
 		}
+		if graceDays < 0 {
+			return xerrors.Errorf("--grace-period-days must be positive")
+		}
 
 		lvl, ok := licensor.NamedLevel[level]
 		if !ok {
 			return xerrors.Errorf("invalid license level: %s", level)
 		}
 
+		now := time.Now()
 		l := licensor.LicensePayload{
-			Domain:     domain,
-			ID:         id,
-			Seats:      seats,
-			Level:      lvl,
-			ValidUntil: time.Now().Add(validFor),
+			Domain:          domain,
+			ID:              id,
+			Seats:           seats,
+			Level:           lvl,
+			ValidUntil:      now.Add(validFor),
+			GracePeriodDays: graceDays,
+		}
+		for _, f := range features {
+			grant, err := parseFeatureGrant(f, now)
+			if err != nil {
+				return err
+			}
+			l.Features = append(l.Features, grant)
+		}
+		for _, q := range quotas {
+			segs := strings.SplitN(q, "=", 2)
+			if len(segs) != 2 {
+				return xerrors.Errorf("invalid quota %q: must be <quota>=<limit>", q)
+			}
+			quota, ok := licensor.NamedQuota[segs[0]]
+			if !ok {
+				return xerrors.Errorf("invalid quota: %s", segs[0])
+			}
+			limit, err := strconv.ParseInt(segs[1], 10, 64)
+			if err != nil || limit < 0 {
+				return xerrors.Errorf("invalid limit for quota %s: %s", segs[0], segs[1])
+			}
+			if l.Quotas == nil {
+				l.Quotas = make(map[licensor.Quota]int64)
+			}
+			l.Quotas[quota] = limit
 		}
 
 		res, err := licensor.Sign(l, priv)
@@ -86,6 +121,30 @@ var signCmd = &cobra.Command{
 	},
 }
 
+// parseFeatureGrant parses <feature>[:<valid-for>] into a feature grant which starts now
+func parseFeatureGrant(s string, now time.Time) (licensor.FeatureGrant, error) {
+	segs := strings.SplitN(s, ":", 2)
+	if segs[0] == "" {
+		return licensor.FeatureGrant{}, xerrors.Errorf("invalid feature %q: must be <feature>[:<valid-for>]", s)
+	}
+	feature, ok := licensor.NamedFeature[segs[0]]
+	if !ok {
+		return licensor.FeatureGrant{}, xerrors.Errorf("invalid feature: %s", segs[0])
+	}
+	res := licensor.FeatureGrant{Feature: feature}
+	if len(segs) == 1 {
+		return res, nil
+	}
+
+	validFor, err := time.ParseDuration(segs[1])
+	if err != nil || validFor <= 0 {
+		return res, xerrors.Errorf("invalid feature %q: valid-for must be a positive duration", s)
+	}
+	validUntil := now.Add(validFor)
+	res.ValidUntil = &validUntil
+	return res, nil
+}
+
 func init() {
 	rootCmd.AddCommand(signCmd)
 
@@ -95,4 +154,7 @@ func init() {
 	signCmd.Flags().Int("seats", 5, "number of seats the license is valid for")
 	signCmd.Flags().StringP("key", "k", "private_key.pem", "path to the private key to sign the license with")
 	signCmd.Flags().Duration("valid-for", 365*24*time.Hour, "time the license is valid for")
+	signCmd.Flags().StringArray("feature", nil, "feature granted on top of the license level, as <feature>[:<valid-for>], e.g. prebuild:720h")
+	signCmd.Flags().StringArray("quota", nil, "quota of a metered feature, as <quota>=<limit>, e.g. prebuild-minutes=1000")
+	signCmd.Flags().Int("grace-period-days", 0, "number of days the license remains valid after it expired")
 }
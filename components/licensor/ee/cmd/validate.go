@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
@@ -22,38 +23,51 @@ var validateCmd = &cobra.Command{
 	Short: "Validates a license - reads from stdin if no argument is provided",
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		domain, _ := cmd.Flags().GetString("domain")
-		licensorType, _ := cmd.Flags().GetString("licensor")
-
-		var e *licensor.Evaluator
-		switch licensorType {
-		case string(licensor.LicenseTypeReplicated):
-			e = licensor.NewReplicatedEvaluator(domain)
-			break
-		default:
-			var lic []byte
-			if len(args) == 0 {
-				lic, err = io.ReadAll(os.Stdin)
-				if err != nil {
-					return err
-				}
-			} else {
-				lic = []byte(args[0])
-			}
-
-			e = licensor.NewGitpodEvaluator(lic, domain)
+		e, err := newEvaluator(cmd, args)
+		if err != nil {
+			return err
 		}
 
 		if msg, valid := e.Validate(); !valid {
 			return xerrors.Errorf(msg)
 		}
 
-		b, _ := json.MarshalIndent(e.Inspect(), "", "  ")
+		ent := e.Entitlements()
+		if ent.InGracePeriod {
+			fmt.Fprintf(os.Stderr, "warning: license expired on %s, grace period ends on %s\n", ent.ValidUntil.Format(time.RFC3339), ent.GraceUntil.Format(time.RFC3339))
+		}
+
+		b, _ := json.MarshalIndent(struct {
+			licensor.LicensePayload
+			Entitlements licensor.Entitlements `json:"entitlements"`
+		}{e.Inspect(), ent}, "", "  ")
 		fmt.Println(string(b))
 		return nil
 	},
 }
 
+// newEvaluator produces an evaluator for the license passed as argument or on stdin, depending on the --licensor flag
+func newEvaluator(cmd *cobra.Command, args []string) (*licensor.Evaluator, error) {
+	domain, _ := cmd.Flags().GetString("domain")
+	licensorType, _ := cmd.Flags().GetString("licensor")
+
+	if licensorType == string(licensor.LicenseTypeReplicated) {
+		return licensor.NewReplicatedEvaluator(), nil
+	}
+
+	var lic []byte
+	if len(args) == 0 {
+		var err error
+		lic, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		lic = []byte(args[0])
+	}
+	return licensor.NewGitpodEvaluator(lic, domain), nil
+}
+
 func init() {
 	rootCmd.AddCommand(validateCmd)
 	validateCmd.Flags().String("domain", "", "domain to evaluate the license against")
@@ -0,0 +1,148 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package licensor
+
+import (
+	"sort"
+	"time"
+)
+
+// FeatureGrant enables a feature on top of those of the license level, optionally for a limited time
+type FeatureGrant struct {
+	Feature Feature `json:"feature"`
+
+	// ValidFrom and ValidUntil bound the time the feature is enabled. Unset bounds are open.
+	ValidFrom  *time.Time `json:"validFrom,omitempty"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+}
+
+// ActiveAt returns true if the grant enables its feature at the given time
+func (g FeatureGrant) ActiveAt(t time.Time) bool {
+	if g.ValidFrom != nil && t.Before(*g.ValidFrom) {
+		return false
+	}
+	if g.ValidUntil != nil && !t.Before(*g.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// Quota denotes a metered feature whose use a license can limit
+type Quota string
+
+const (
+	// QuotaPrebuildMinutes limits the prebuild minutes per month
+	QuotaPrebuildMinutes Quota = "prebuild-minutes"
+	// QuotaWorkspaceClasses limits the number of workspace classes
+	QuotaWorkspaceClasses Quota = "workspace-classes"
+)
+
+// NamedQuota maps quota names to the actual quota
+var NamedQuota = map[string]Quota{
+	string(QuotaPrebuildMinutes):  QuotaPrebuildMinutes,
+	string(QuotaWorkspaceClasses): QuotaWorkspaceClasses,
+}
+
+// Entitlements summarise what a license grants at a point in time
+type Entitlements struct {
+	Level LicenseLevel `json:"level"`
+	// Seats == 0 means there's no seat limit
+	Seats int `json:"seats"`
+
+	// ValidUntil is zero for licenses which never expire
+	ValidUntil time.Time `json:"validUntil"`
+	// GraceUntil is the end of the grace period, if the license has one
+	GraceUntil    *time.Time `json:"graceUntil,omitempty"`
+	InGracePeriod bool       `json:"inGracePeriod"`
+
+	Features []FeatureEntitlement `json:"features"`
+	// Quotas lists the limited metered features. Metered features which are not listed are unlimited.
+	Quotas map[Quota]int64 `json:"quotas,omitempty"`
+}
+
+// FeatureEntitlement is a feature a license grants
+type FeatureEntitlement struct {
+	Feature Feature `json:"feature"`
+	// Active is false for features granted from a time in the future
+	Active bool `json:"active"`
+
+	ValidFrom  *time.Time `json:"validFrom,omitempty"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+}
+
+// GraceUntil returns the end of the grace period, and false if the license has none
+func (lic LicensePayload) GraceUntil() (time.Time, bool) {
+	if lic.GracePeriodDays <= 0 || lic.ValidUntil.IsZero() {
+		return time.Time{}, false
+	}
+	return lic.ValidUntil.Add(time.Duration(lic.GracePeriodDays) * 24 * time.Hour), true
+}
+
+// expiredAt returns true if the license, including its grace period, is not valid anymore at the given time.
+// Gitpod licenses always expire: a zero ValidUntil makes them invalid, just like before grace periods existed.
+func (lic LicensePayload) expiredAt(t time.Time) bool {
+	if grace, ok := lic.GraceUntil(); ok {
+		return grace.Before(t)
+	}
+	return lic.ValidUntil.Before(t)
+}
+
+// inGracePeriodAt returns true if the license expired, but its grace period has not ended yet
+func (lic LicensePayload) inGracePeriodAt(t time.Time) bool {
+	grace, ok := lic.GraceUntil()
+	return ok && lic.ValidUntil.Before(t) && !grace.Before(t)
+}
+
+// grantsAt returns true if a feature grant of the license enables the feature at the given time
+func (lic LicensePayload) grantsAt(feature Feature, t time.Time) bool {
+	for _, g := range lic.Features {
+		if g.Feature == feature && g.ActiveAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entitlements returns what the license grants at the given time. Feature grants which ended already are omitted.
+func (lic LicensePayload) Entitlements(t time.Time) Entitlements {
+	res := Entitlements{
+		Level:         lic.Level,
+		Seats:         lic.Seats,
+		ValidUntil:    lic.ValidUntil,
+		InGracePeriod: lic.inGracePeriodAt(t),
+		Quotas:        lic.Quotas,
+	}
+	if grace, ok := lic.GraceUntil(); ok {
+		res.GraceUntil = &grace
+	}
+
+	features := make(map[Feature]FeatureEntitlement)
+	for f := range lic.Level.allowance().Features {
+		features[f] = FeatureEntitlement{Feature: f, Active: true}
+	}
+	for _, g := range lic.Features {
+		if g.ValidUntil != nil && !t.Before(*g.ValidUntil) {
+			continue
+		}
+		if existing, ok := features[g.Feature]; ok && existing.Active && (existing.ValidUntil == nil || !g.ActiveAt(t)) {
+			// the feature is granted without time bound, or by another active grant already
+			continue
+		}
+		features[g.Feature] = FeatureEntitlement{
+			Feature:    g.Feature,
+			Active:     g.ActiveAt(t),
+			ValidFrom:  g.ValidFrom,
+			ValidUntil: g.ValidUntil,
+		}
+	}
+
+	res.Features = make([]FeatureEntitlement, 0, len(features))
+	for _, f := range features {
+		res.Features = append(res.Features, f)
+	}
+	sort.Slice(res.Features, func(i, j int) bool { return res.Features[i].Feature < res.Features[j].Feature })
+
+	return res
+}
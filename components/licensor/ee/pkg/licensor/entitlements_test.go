@@ -0,0 +1,246 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package licensor
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// signForTests signs a license with a fresh key, which it installs as the only public key
+func signForTests(t *testing.T, l LicensePayload) []byte {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("cannot generate key: %q", err)
+	}
+	publicKeys = []*rsa.PublicKey{&priv.PublicKey}
+	lic, err := Sign(l, priv)
+	if err != nil {
+		t.Fatalf("cannot sign license: %q", err)
+	}
+	return lic
+}
+
+func TestBackwardsCompatibleSignature(t *testing.T) {
+	// oldLicensePayload is the payload format licenses were issued in before feature grants, quotas and grace periods
+	type oldLicensePayload struct {
+		ID         string       `json:"id"`
+		Domain     string       `json:"domain"`
+		Level      LicenseLevel `json:"level"`
+		ValidUntil time.Time    `json:"validUntil"`
+		Seats      int          `json:"seats"`
+	}
+
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("cannot generate key: %q", err)
+	}
+	publicKeys = []*rsa.PublicKey{&priv.PublicKey}
+
+	old := oldLicensePayload{ID: someID, Domain: domain, Level: LevelEnterprise, ValidUntil: time.Now().Add(time.Hour).UTC(), Seats: seats}
+	rawl, err := json.Marshal(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := sha256.Sum256(rawl)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	resl, err := json.Marshal(struct {
+		oldLicensePayload
+		Signature []byte `json:"signature"`
+	}{old, sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewGitpodEvaluator([]byte(base64.StdEncoding.EncodeToString(resl)), domain)
+	if msg, valid := e.Validate(); !valid {
+		t.Fatalf("license in the old format is invalid: %s", msg)
+	}
+	if !e.Enabled(FeaturePrebuild, seats) {
+		t.Error("license in the old format does not enable prebuilds")
+	}
+	if e.InGracePeriod() {
+		t.Error("license in the old format has a grace period")
+	}
+}
+
+func TestFeatureGrants(t *testing.T) {
+	var (
+		now      = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+		lastWeek = now.Add(-7 * 24 * time.Hour)
+		nextWeek = now.Add(7 * 24 * time.Hour)
+	)
+	tests := []struct {
+		Name    string
+		Grant   FeatureGrant
+		Enabled bool
+	}{
+		{"unbounded", FeatureGrant{Feature: FeaturePrebuild}, true},
+		{"within bounds", FeatureGrant{Feature: FeaturePrebuild, ValidFrom: &lastWeek, ValidUntil: &nextWeek}, true},
+		{"ended", FeatureGrant{Feature: FeaturePrebuild, ValidUntil: &lastWeek}, false},
+		{"not started", FeatureGrant{Feature: FeaturePrebuild, ValidFrom: &nextWeek}, false},
+		{"other feature", FeatureGrant{Feature: FeatureSnapshot}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			lic := signForTests(t, LicensePayload{
+				ID:         someID,
+				Domain:     domain,
+				Level:      LevelTeam,
+				Seats:      seats,
+				ValidUntil: time.Now().Add(time.Hour),
+				Features:   []FeatureGrant{test.Grant},
+			})
+			e := NewGitpodEvaluator(lic, domain)
+			if msg, valid := e.Validate(); !valid {
+				t.Fatalf("license is invalid: %s", msg)
+			}
+			e.now = func() time.Time { return now }
+
+			if enabled := e.Enabled(FeaturePrebuild, seats); enabled != test.Enabled {
+				t.Errorf("unexpected Enabled: expected %v, got %v", test.Enabled, enabled)
+			}
+			if e.Enabled(FeaturePrebuild, seats+1) {
+				t.Error("feature grants must not apply beyond the seat limit")
+			}
+		})
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	tests := []struct {
+		Name            string
+		ExpiredFor      time.Duration
+		GracePeriodDays int
+		Validation      string
+		InGracePeriod   bool
+	}{
+		{"not expired", -time.Hour, 7, "", false},
+		{"expired without grace period", time.Hour, 0, "not valid anymore", false},
+		{"within grace period", 24 * time.Hour, 7, "", true},
+		{"beyond grace period", 8 * 24 * time.Hour, 7, "not valid anymore", false},
+		// Gitpod licenses without ValidUntil were never valid, grace period or not
+		{"zero valid until", 0, 7, "not valid anymore", false},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			payload := LicensePayload{
+				ID:              someID,
+				Domain:          domain,
+				Level:           LevelEnterprise,
+				Seats:           seats,
+				GracePeriodDays: test.GracePeriodDays,
+			}
+			if test.ExpiredFor != 0 {
+				payload.ValidUntil = time.Now().Add(-test.ExpiredFor)
+			}
+			lic := signForTests(t, payload)
+			e := NewGitpodEvaluator(lic, domain)
+
+			msg, _ := e.Validate()
+			if msg != test.Validation {
+				t.Errorf("unexpected validation result: expected %q, got %q", test.Validation, msg)
+			}
+			if e.InGracePeriod() != test.InGracePeriod {
+				t.Errorf("unexpected InGracePeriod: expected %v, got %v", test.InGracePeriod, e.InGracePeriod())
+			}
+			if test.Validation != "" && e.Inspect().ID != someID {
+				t.Error("invalid license cannot be inspected")
+			}
+		})
+	}
+}
+
+func TestQuota(t *testing.T) {
+	lic := signForTests(t, LicensePayload{
+		ID:         someID,
+		Domain:     domain,
+		Level:      LevelEnterprise,
+		Seats:      seats,
+		ValidUntil: time.Now().Add(time.Hour),
+		Quotas:     map[Quota]int64{QuotaPrebuildMinutes: 1000},
+	})
+	e := NewGitpodEvaluator(lic, domain)
+	if msg, valid := e.Validate(); !valid {
+		t.Fatalf("license is invalid: %s", msg)
+	}
+
+	tests := []struct {
+		Name   string
+		Quota  Quota
+		Used   int64
+		Within bool
+	}{
+		{"within quota", QuotaPrebuildMinutes, 999, true},
+		{"within quota (edge)", QuotaPrebuildMinutes, 1000, true},
+		{"beyond quota", QuotaPrebuildMinutes, 1001, false},
+		{"unlimited", QuotaWorkspaceClasses, 1000000, true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if within := e.WithinQuota(test.Quota, test.Used); within != test.Within {
+				t.Errorf("unexpected WithinQuota: expected %v, got %v", test.Within, within)
+			}
+		})
+	}
+
+	invalid := &Evaluator{invalid: "not valid anymore"}
+	if invalid.WithinQuota(QuotaWorkspaceClasses, 0) {
+		t.Error("invalid licenses must not be within any quota")
+	}
+}
+
+func TestEntitlements(t *testing.T) {
+	var (
+		now        = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+		lastWeek   = now.Add(-7 * 24 * time.Hour)
+		nextWeek   = now.Add(7 * 24 * time.Hour)
+		validUntil = now.Add(-24 * time.Hour)
+		graceUntil = validUntil.Add(3 * 24 * time.Hour)
+	)
+	lic := LicensePayload{
+		Level:      LevelTeam,
+		Seats:      seats,
+		ValidUntil: validUntil,
+		Features: []FeatureGrant{
+			{Feature: FeaturePrebuild, ValidUntil: &nextWeek},
+			{Feature: FeatureSnapshot, ValidFrom: &nextWeek},
+			{Feature: FeatureSetTimeout, ValidUntil: &lastWeek},
+			{Feature: FeatureAdminDashboard, ValidUntil: &nextWeek},
+		},
+		Quotas:          map[Quota]int64{QuotaWorkspaceClasses: 3},
+		GracePeriodDays: 3,
+	}
+
+	expectation := Entitlements{
+		Level:         LevelTeam,
+		Seats:         seats,
+		ValidUntil:    validUntil,
+		GraceUntil:    &graceUntil,
+		InGracePeriod: true,
+		Features: []FeatureEntitlement{
+			{Feature: FeatureAdminDashboard, Active: true},
+			{Feature: FeaturePrebuild, Active: true, ValidUntil: &nextWeek},
+			{Feature: FeatureSnapshot, Active: false, ValidFrom: &nextWeek},
+		},
+		Quotas: map[Quota]int64{QuotaWorkspaceClasses: 3},
+	}
+	act := lic.Entitlements(now)
+	if !reflect.DeepEqual(expectation, act) {
+		exp, _ := json.MarshalIndent(expectation, "", "  ")
+		got, _ := json.MarshalIndent(act, "", "  ")
+		t.Errorf("unexpected entitlements: expected\n%s\ngot\n%s", exp, got)
+	}
+}
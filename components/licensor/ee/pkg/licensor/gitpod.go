@@ -54,12 +54,13 @@ func NewGitpodEvaluator(key []byte, domain string) (res *Evaluator) {
 		return &Evaluator{invalid: fmt.Sprintf("cannot verify key: %q", err)}
 	}
 
+	// From here on the license is genuine. Invalid evaluators keep it so that it can be inspected.
 	if !matchesDomain(lic.Domain, domain) {
-		return &Evaluator{invalid: "wrong domain"}
+		return &Evaluator{invalid: "wrong domain", lic: lic.LicensePayload}
 	}
 
-	if lic.ValidUntil.Before(time.Now()) {
-		return &Evaluator{invalid: "not valid anymore"}
+	if lic.expiredAt(time.Now()) {
+		return &Evaluator{invalid: "not valid anymore", lic: lic.LicensePayload}
 	}
 
 	return &Evaluator{
@@ -53,6 +53,16 @@ type LicensePayload struct {
 
 	// Seats == 0 means there's no seat limit
 	Seats int `json:"seats"`
+
+	// The fields below were introduced after the first licenses were issued. They must be omitted when empty,
+	// so that licenses which don't use them keep their signature.
+
+	// Features grants features on top of those of the license level, optionally for a limited time
+	Features []FeatureGrant `json:"features,omitempty"`
+	// Quotas limit the use of metered features. Metered features without quota are unlimited.
+	Quotas map[Quota]int64 `json:"quotas,omitempty"`
+	// GracePeriodDays is the number of days the license remains valid after ValidUntil
+	GracePeriodDays int `json:"gracePeriodDays,omitempty"`
 }
 
 type licensePayload struct {
@@ -95,6 +105,15 @@ const (
 	FeatureWorkspaceSharing Feature = "workspace-sharing"
 )
 
+// NamedFeature maps feature names to the actual feature
+var NamedFeature = map[string]Feature{
+	string(FeatureAdminDashboard):   FeatureAdminDashboard,
+	string(FeaturePrebuild):         FeaturePrebuild,
+	string(FeatureSetTimeout):       FeatureSetTimeout,
+	string(FeatureSnapshot):         FeatureSnapshot,
+	string(FeatureWorkspaceSharing): FeatureWorkspaceSharing,
+}
+
 type featureSet map[Feature]struct{}
 
 type allowance struct {
@@ -176,6 +195,16 @@ type Evaluator struct {
 	allowFallback bool // Paid licenses cannot fallback and prevent additional signups
 	lic           LicensePayload
 	plan          LicenseSubscriptionLevel // Specifies if it is a community/free plan or paid plan
+
+	// now returns the time against which time-bound feature grants are evaluated. Defaults to time.Now.
+	now func() time.Time
+}
+
+func (e *Evaluator) time() time.Time {
+	if e.now == nil {
+		return time.Now()
+	}
+	return e.now()
 }
 
 // Validate returns false if the license isn't valid and a message explaining why that is.
@@ -197,6 +226,9 @@ func (e *Evaluator) Enabled(feature Feature, seats int) bool {
 	if e.hasEnoughSeats(seats) {
 		// License has enough seats available - evaluate this license
 		_, ok = e.lic.Level.allowance().Features[feature]
+		if !ok {
+			ok = e.lic.grantsAt(feature, e.time())
+		}
 	} else if e.allowFallback {
 		// License has run out of seats - use the fallback license
 		_, ok = fallbackLicense.Level.allowance().Features[feature]
@@ -205,6 +237,36 @@ func (e *Evaluator) Enabled(feature Feature, seats int) bool {
 	return ok
 }
 
+// Quota returns the limit the license sets for a metered feature, and false if its use is unlimited
+func (e *Evaluator) Quota(quota Quota) (limit int64, limited bool) {
+	limit, limited = e.lic.Quotas[quota]
+	return
+}
+
+// WithinQuota returns true if the use of a metered feature does not exceed the quota of the license
+func (e *Evaluator) WithinQuota(quota Quota, used int64) bool {
+	if e.invalid != "" {
+		return false
+	}
+
+	limit, limited := e.Quota(quota)
+	return !limited || used <= limit
+}
+
+// InGracePeriod returns true if the license expired, but is still within its grace period
+func (e *Evaluator) InGracePeriod() bool {
+	if e.invalid != "" {
+		return false
+	}
+
+	return e.lic.inGracePeriodAt(e.time())
+}
+
+// Entitlements returns what the license grants right now
+func (e *Evaluator) Entitlements() Entitlements {
+	return e.lic.Entitlements(e.time())
+}
+
 // hasEnoughSeats returns true if the license supports at least the give amount of seats
 func (e *Evaluator) hasEnoughSeats(seats int) bool {
 	if e.invalid != "" {
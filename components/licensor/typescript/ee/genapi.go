@@ -68,6 +68,30 @@ func main() {
 				if m.Name == "validUntil" {
 					t.Members[i].Type.Name = "string"
 				}
+				if m.Name == "quotas" {
+					// TypeScript does not support enums as index signature
+					t.Members[i].Type = bel.TypescriptType{Name: "{ [key: string]: number }", Kind: bel.TypescriptSimpleKind}
+				}
+			}
+		}
+
+		res = append(res, t)
+	}
+
+	ts, err = bel.Extract(licensor.FeatureGrant{}, bel.WithEnumerations(handler))
+	if err != nil {
+		panic(err)
+	}
+	for _, t := range ts {
+		if t.Name == "" {
+			continue
+		}
+
+		if t.Name == "FeatureGrant" {
+			for i, m := range t.Members {
+				if m.Name == "validFrom" || m.Name == "validUntil" {
+					t.Members[i].Type.Name = "string"
+				}
 			}
 		}
 
@@ -94,6 +118,18 @@ func main() {
 		res = append(res, t)
 	}
 
+	// types referenced by several extracted types are extracted more than once
+	seen := make(map[string]struct{}, len(res))
+	unique := res[:0]
+	for _, t := range res {
+		if _, ok := seen[t.Name]; ok {
+			continue
+		}
+		seen[t.Name] = struct{}{}
+		unique = append(unique, t)
+	}
+	res = unique
+
 	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
 
 	f, err := os.Create("src/api.ts")
@@ -12,6 +12,12 @@ export enum Feature {
     FeatureSnapshot = "snapshot",
     FeatureWorkspaceSharing = "workspace-sharing",
 }
+export interface FeatureGrant {
+    feature: Feature
+    validFrom?: string
+    validUntil?: string
+}
+
 export interface LicenseData {
     type: LicenseType
     payload: LicensePayload
@@ -29,6 +35,9 @@ export interface LicensePayload {
     level: LicenseLevel
     validUntil: string
     seats: number
+    features?: FeatureGrant[]
+    quotas?: { [key: string]: number }
+    gracePeriodDays?: number
 }
 
 export enum LicenseSubscriptionLevel {
@@ -41,3 +50,7 @@ export enum LicenseType {
     LicenseTypeGitpod = "gitpod",
     LicenseTypeReplicated = "replicated",
 }
+export enum Quota {
+    QuotaPrebuildMinutes = "prebuild-minutes",
+    QuotaWorkspaceClasses = "workspace-classes",
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 	"github.com/zalando/go-keyring"
+	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
 )
 
@@ -128,6 +129,9 @@ func main() {
 						verbose:           c.Bool("verbose"),
 						authTimeout:       c.Duration("auth-timeout"),
 						localAppTimeout:   c.Duration("timeout"),
+						sshIdentity:       c.Path("ssh-identity"),
+						sshHostCA:         c.Path("ssh-host-ca"),
+						portsConfig:       c.Path("ports-config"),
 					})
 				},
 				Flags: []cli.Flag{
@@ -136,6 +140,27 @@ func main() {
 						Usage: "produce and update an OpenSSH compatible ssh_config file (defaults to $GITPOD_LCA_SSH_CONFIG)",
 						Value: sshConfig,
 					},
+					&cli.PathFlag{
+						Name:  "ssh-identity",
+						Usage: "connect through the SSH gateway using this private key and its CA-signed user certificate (<key>-cert.pub)",
+						EnvVars: []string{
+							"GITPOD_LCA_SSH_IDENTITY",
+						},
+					},
+					&cli.PathFlag{
+						Name:  "ssh-host-ca",
+						Usage: "public key of the SSH CA which signs the SSH gateway host certificates",
+						EnvVars: []string{
+							"GITPOD_LCA_SSH_HOST_CA",
+						},
+					},
+					&cli.PathFlag{
+						Name:  "ports-config",
+						Usage: "YAML file with port profiles which configure per repository or workspace which ports are tunneled to which local ports",
+						EnvVars: []string{
+							"GITPOD_LCA_PORTS_CONFIG",
+						},
+					},
 				},
 			},
 		},
@@ -162,6 +187,9 @@ type runOptions struct {
 	verbose           bool
 	authTimeout       time.Duration
 	localAppTimeout   time.Duration
+	sshIdentity       string
+	sshHostCA         string
+	portsConfig       string
 }
 
 func run(opts runOptions) error {
@@ -205,12 +233,37 @@ func run(opts runOptions) error {
 	}
 
 	s := &bastion.SSHConfigWritingCallback{Path: opts.sshConfigPath}
+	if opts.sshHostCA != "" {
+		caKey, err := os.ReadFile(opts.sshHostCA)
+		if err != nil {
+			return err
+		}
+		s.HostCAKey = strings.TrimSpace(string(caKey))
+		s.KnownHostsPath = opts.sshConfigPath + "_known_hosts"
+	}
 	if opts.sshConfigPath != "" {
 		cb = append(cb, s)
 	}
 
 	b = bastion.New(client, opts.localAppTimeout, cb)
 	b.EnableAutoTunnel = opts.autoTunnel
+	if opts.portsConfig != "" {
+		b.PortProfiles, err = bastion.LoadPortProfiles(opts.portsConfig)
+		if err != nil {
+			return err
+		}
+		logrus.WithField("ports-config", opts.portsConfig).Info("using port profiles")
+	}
+	if opts.sshIdentity != "" {
+		id := &bastion.SSHIdentity{
+			PrivateKeyFN:  opts.sshIdentity,
+			CertificateFN: opts.sshIdentity + "-cert.pub",
+		}
+		if _, err := os.Stat(id.CertificateFN); err != nil {
+			return xerrors.Errorf("cannot use SSH identity without a user certificate: %w", err)
+		}
+		b.SSHGatewayIdentity = id
+	}
 	grpcServer := grpc.NewServer()
 	appapi.RegisterLocalAppServer(grpcServer, bastion.NewLocalAppService(b, s))
 	allowOrigin := func(origin string) bool {
@@ -12,12 +12,13 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,7 +30,6 @@ import (
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
-	"google.golang.org/protobuf/proto"
 
 	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
 	app "github.com/gitpod-io/gitpod/local-app/api"
@@ -64,8 +64,34 @@ type TunnelListener struct {
 	LocalAddr  string
 	LocalPort  uint32
 	Visibility supervisor.TunnelVisiblity
+	Protocol   supervisor.TunnelProtocol
 	Ctx        context.Context
 	Cancel     func()
+
+	// SocketPath and LocalSocketPath are set for Unix domain socket tunnels
+	SocketPath      string
+	LocalSocketPath string
+	// Reverse is true if the workspace forwards connections to LocalSocketPath
+	Reverse bool
+	// clientID is the SSH client a reverse tunnel was requested on
+	clientID string
+
+	// pinned is true if the tunnel was configured by a port profile
+	pinned bool
+}
+
+// tunnelState describes a tunnel which is not established
+type tunnelState struct {
+	RemotePort uint32
+	LocalPort  uint32
+	Visibility supervisor.TunnelVisiblity
+	Protocol   supervisor.TunnelProtocol
+	State      app.TunnelState
+	Err        error
+
+	SocketPath      string
+	LocalSocketPath string
+	Reverse         bool
 }
 
 type Workspace struct {
@@ -74,18 +100,30 @@ type Workspace struct {
 	Phase       string
 	OwnerToken  string
 	URL         string
+	ContextURL  string
 
 	supervisorListener *TunnelListener
 	supervisorClient   *grpc.ClientConn
 
 	tunnelMu        sync.RWMutex
 	tunnelListeners map[uint32]*TunnelListener
+	tunnelStates    map[uint32]*tunnelState
 	tunnelEnabled   bool
-	cancelTunnel    context.CancelFunc
+	// socketListeners and socketStates are keyed by the socket path in the workspace
+	socketListeners map[string]*TunnelListener
+	socketStates    map[string]*tunnelState
+	// reverseSockets maps workspace socket paths to the local sockets we forward them to
+	reverseSockets map[string]string
+	cancelTunnel   context.CancelFunc
+	tunnelRefresh  chan struct{}
 
 	localSSHListener *TunnelListener
 	SSHPrivateFN     string
 	SSHPublicKey     string
+	// SSHCertificateFN and SSHGatewayHost are set if we connect through the SSH gateway
+	// using a CA-signed user certificate instead of a local SSH tunnel
+	SSHCertificateFN string
+	SSHGatewayHost   string
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -97,17 +135,77 @@ type Workspace struct {
 func (ws *Workspace) Status() []*app.TunnelStatus {
 	ws.tunnelMu.RLock()
 	defer ws.tunnelMu.RUnlock()
-	res := make([]*app.TunnelStatus, 0, len(ws.tunnelListeners))
+	res := make([]*app.TunnelStatus, 0, len(ws.tunnelListeners)+len(ws.tunnelStates)+len(ws.socketListeners)+len(ws.socketStates))
 	for _, listener := range ws.tunnelListeners {
-		res = append(res, &app.TunnelStatus{
-			RemotePort: listener.RemotePort,
-			LocalPort:  listener.LocalPort,
-			Visibility: listener.Visibility,
-		})
+		res = append(res, listener.status())
+	}
+	for port, state := range ws.tunnelStates {
+		if _, established := ws.tunnelListeners[port]; established {
+			continue
+		}
+		res = append(res, state.status())
+	}
+	for _, listener := range ws.socketListeners {
+		res = append(res, listener.status())
 	}
+	for socket, state := range ws.socketStates {
+		if _, established := ws.socketListeners[socket]; established {
+			continue
+		}
+		res = append(res, state.status())
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].RemotePort != res[j].RemotePort {
+			return res[i].RemotePort < res[j].RemotePort
+		}
+		return res[i].SocketPath < res[j].SocketPath
+	})
 	return res
 }
 
+func (t *TunnelListener) status() *app.TunnelStatus {
+	return &app.TunnelStatus{
+		RemotePort:      t.RemotePort,
+		LocalPort:       t.LocalPort,
+		Visibility:      t.Visibility,
+		State:           app.TunnelState_established,
+		Protocol:        t.Protocol,
+		SocketPath:      t.SocketPath,
+		LocalSocketPath: t.LocalSocketPath,
+		Reverse:         t.Reverse,
+	}
+}
+
+func (t *TunnelListener) connecting() *tunnelState {
+	return &tunnelState{
+		RemotePort:      t.RemotePort,
+		LocalPort:       t.LocalPort,
+		Visibility:      t.Visibility,
+		Protocol:        t.Protocol,
+		State:           app.TunnelState_connecting,
+		SocketPath:      t.SocketPath,
+		LocalSocketPath: t.LocalSocketPath,
+		Reverse:         t.Reverse,
+	}
+}
+
+func (s *tunnelState) status() *app.TunnelStatus {
+	status := &app.TunnelStatus{
+		RemotePort:      s.RemotePort,
+		LocalPort:       s.LocalPort,
+		Visibility:      s.Visibility,
+		State:           s.State,
+		Protocol:        s.Protocol,
+		SocketPath:      s.SocketPath,
+		LocalSocketPath: s.LocalSocketPath,
+		Reverse:         s.Reverse,
+	}
+	if s.Err != nil {
+		status.Error = s.Err.Error()
+	}
+	return status
+}
+
 type Callbacks interface {
 	InstanceUpdate(*Workspace)
 }
@@ -122,6 +220,10 @@ func (cb CompositeCallbacks) InstanceUpdate(w *Workspace) {
 
 type SSHConfigWritingCallback struct {
 	Path string
+	// HostCAKey is the installation's SSH CA public key in authorized_keys format. If set, we write
+	// @cert-authority entries for the SSH gateway hosts to KnownHostsPath.
+	HostCAKey      string
+	KnownHostsPath string
 
 	workspaces map[string]*Workspace
 }
@@ -130,13 +232,16 @@ func (s *SSHConfigWritingCallback) InstanceUpdate(w *Workspace) {
 	if s.workspaces == nil {
 		s.workspaces = make(map[string]*Workspace)
 	}
-	if w.localSSHListener == nil || w.Phase == "stopping" {
+	if (w.localSSHListener == nil && w.SSHGatewayHost == "") || w.Phase == "stopping" {
 		delete(s.workspaces, w.WorkspaceID)
 	} else if _, exists := s.workspaces[w.WorkspaceID]; !exists {
 		s.workspaces[w.WorkspaceID] = w
 	}
 
-	var cfg ssh_config.Config
+	var (
+		cfg          ssh_config.Config
+		gatewayHosts = make(map[string]struct{})
+	)
 	for _, ws := range s.workspaces {
 		p, err := ssh_config.NewPattern(ws.WorkspaceID)
 		if err != nil {
@@ -144,6 +249,25 @@ func (s *SSHConfigWritingCallback) InstanceUpdate(w *Workspace) {
 			continue
 		}
 
+		if ws.SSHGatewayHost != "" {
+			nodes := []ssh_config.Node{
+				&ssh_config.KV{Key: "HostName", Value: ws.SSHGatewayHost},
+				&ssh_config.KV{Key: "User", Value: ws.WorkspaceID},
+				&ssh_config.KV{Key: "IdentityFile", Value: ws.SSHPrivateFN},
+				&ssh_config.KV{Key: "CertificateFile", Value: ws.SSHCertificateFN},
+				&ssh_config.KV{Key: "IdentitiesOnly", Value: "yes"},
+			}
+			if s.HostCAKey != "" {
+				nodes = append(nodes, &ssh_config.KV{Key: "UserKnownHostsFile", Value: s.KnownHostsPath})
+				gatewayHosts["*"+strings.TrimPrefix(ws.SSHGatewayHost, ws.WorkspaceID)] = struct{}{}
+			}
+			cfg.Hosts = append(cfg.Hosts, &ssh_config.Host{
+				Patterns: []*ssh_config.Pattern{p},
+				Nodes:    nodes,
+			})
+			continue
+		}
+
 		host, port, _ := net.SplitHostPort(ws.localSSHListener.LocalAddr)
 		cfg.Hosts = append(cfg.Hosts, &ssh_config.Host{
 			Patterns: []*ssh_config.Pattern{p},
@@ -157,6 +281,23 @@ func (s *SSHConfigWritingCallback) InstanceUpdate(w *Workspace) {
 		})
 	}
 
+	if s.HostCAKey != "" {
+		patterns := make([]string, 0, len(gatewayHosts))
+		for p := range gatewayHosts {
+			patterns = append(patterns, p)
+		}
+		sort.Strings(patterns)
+
+		var knownHosts strings.Builder
+		for _, p := range patterns {
+			fmt.Fprintf(&knownHosts, "@cert-authority %s %s\n", p, s.HostCAKey)
+		}
+		err := ioutil.WriteFile(s.KnownHostsPath, []byte(knownHosts.String()), 0644)
+		if err != nil {
+			logrus.WithError(err).WithField("path", s.KnownHostsPath).Error("cannot write known_hosts file")
+		}
+	}
+
 	err := ioutil.WriteFile(s.Path, []byte(cfg.String()), 0644)
 	if err != nil {
 		logrus.WithError(err).WithField("path", s.Path).Error("cannot write ssh config file")
@@ -164,6 +305,26 @@ func (s *SSHConfigWritingCallback) InstanceUpdate(w *Workspace) {
 	}
 }
 
+// SSHIdentity is a private key with a short-lived user certificate signed by the installation's SSH CA.
+// The certificate is read whenever we connect, hence can be renewed while we run.
+type SSHIdentity struct {
+	PrivateKeyFN  string
+	CertificateFN string
+}
+
+// sshGatewayHost produces the SSH gateway host of a workspace, e.g. <workspaceID>.ssh.ws-eu.gitpod.io
+func sshGatewayHost(ws *Workspace) (string, error) {
+	u, err := url.Parse(ws.URL)
+	if err != nil {
+		return "", err
+	}
+	host := u.Hostname()
+	if ws.WorkspaceID == "" || !strings.HasPrefix(host, ws.WorkspaceID+".") {
+		return "", xerrors.Errorf("workspace URL %s does not start with the workspace ID", ws.URL)
+	}
+	return ws.WorkspaceID + ".ssh" + strings.TrimPrefix(host, ws.WorkspaceID), nil
+}
+
 func New(client gitpod.APIInterface, localAppTimeout time.Duration, cb Callbacks) *Bastion {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Bastion{
@@ -180,8 +341,9 @@ func New(client gitpod.APIInterface, localAppTimeout time.Duration, cb Callbacks
 }
 
 type WorkspaceUpdateRequest struct {
-	instance *gitpod.WorkspaceInstance
-	done     chan *Workspace
+	instance   *gitpod.WorkspaceInstance
+	contextURL string
+	done       chan *Workspace
 }
 
 type Bastion struct {
@@ -204,6 +366,12 @@ type Bastion struct {
 	subscriptions   map[*StatusSubscription]struct{}
 
 	EnableAutoTunnel bool
+	// PortProfiles configure which ports are tunneled to which local ports. Ports listed
+	// in a profile are tunneled even if auto tunneling is disabled.
+	PortProfiles *PortProfiles
+	// SSHGatewayIdentity makes us connect through the SSH gateway using a CA-signed user certificate
+	// instead of installing generated keys in the workspace and tunneling SSH.
+	SSHGatewayIdentity *SSHIdentity
 }
 
 func (b *Bastion) Run() error {
@@ -256,8 +424,13 @@ func (b *Bastion) FullUpdate() {
 			if ws.LatestInstance == nil {
 				continue
 			}
+			var contextURL string
+			if ws.Workspace != nil {
+				contextURL = ws.Workspace.ContextURL
+			}
 			b.updates <- &WorkspaceUpdateRequest{
-				instance: ws.LatestInstance,
+				instance:   ws.LatestInstance,
+				contextURL: contextURL,
 			}
 		}
 	}
@@ -272,10 +445,15 @@ func (b *Bastion) Update(workspaceID string) *Workspace {
 	if ws.LatestInstance == nil {
 		return nil
 	}
+	var contextURL string
+	if ws.Workspace != nil {
+		contextURL = ws.Workspace.ContextURL
+	}
 	done := make(chan *Workspace)
 	b.updates <- &WorkspaceUpdateRequest{
-		instance: ws.LatestInstance,
-		done:     done,
+		instance:   ws.LatestInstance,
+		contextURL: contextURL,
+		done:       done,
 	}
 	return <-done
 }
@@ -327,9 +505,17 @@ func (b *Bastion) handleUpdate(ur *WorkspaceUpdateRequest) {
 
 			tunnelClient:    make(chan chan *TunnelClient, 1),
 			tunnelListeners: make(map[uint32]*TunnelListener),
+			tunnelStates:    make(map[uint32]*tunnelState),
 			tunnelEnabled:   true,
+			socketListeners: make(map[string]*TunnelListener),
+			socketStates:    make(map[string]*tunnelState),
+			reverseSockets:  make(map[string]string),
+			tunnelRefresh:   make(chan struct{}, 1),
 		}
 	}
+	if ur.contextURL != "" {
+		ws.ContextURL = ur.contextURL
+	}
 	ws.Phase = u.Status.Phase
 	ws.URL = u.IdeURL
 	ws.OwnerToken = u.Status.OwnerToken
@@ -348,7 +534,7 @@ func (b *Bastion) handleUpdate(ur *WorkspaceUpdateRequest) {
 		}
 		if ws.supervisorListener == nil && ws.tunnelClientConnected {
 			var err error
-			ws.supervisorListener, err = b.establishTunnel(ws.ctx, ws, "supervisor", 22999, 0, supervisor.TunnelVisiblity_host)
+			ws.supervisorListener, err = b.establishTunnel(ws.ctx, ws, "supervisor", 22999, 0, supervisor.TunnelVisiblity_host, false)
 			if err != nil {
 				logrus.WithError(err).WithField("workspace", ws.WorkspaceID).Error("cannot establish supervisor tunnel")
 			}
@@ -367,11 +553,22 @@ func (b *Bastion) handleUpdate(ur *WorkspaceUpdateRequest) {
 			}
 		}
 
-		if ws.supervisorClient != nil && b.EnableAutoTunnel {
+		if ws.supervisorClient != nil && (b.EnableAutoTunnel || b.PortProfiles != nil) {
 			go b.tunnelPorts(ws)
 		}
 
-		if ws.localSSHListener == nil && ws.supervisorClient != nil {
+		if id := b.SSHGatewayIdentity; id != nil && ws.SSHGatewayHost == "" {
+			host, err := sshGatewayHost(ws)
+			if err != nil {
+				logrus.WithError(err).WithField("workspace", ws.WorkspaceID).Error("cannot determine SSH gateway host")
+			} else {
+				ws.SSHGatewayHost = host
+				ws.SSHPrivateFN = id.PrivateKeyFN
+				ws.SSHCertificateFN = id.CertificateFN
+			}
+		}
+
+		if ws.localSSHListener == nil && ws.supervisorClient != nil && b.SSHGatewayIdentity == nil {
 			func() {
 				var err error
 				ws.SSHPrivateFN, ws.SSHPublicKey, err = generateSSHKeys(ws.InstanceID)
@@ -508,12 +705,43 @@ func (b *Bastion) connectTunnelClient(ctx context.Context, ws *Workspace) error
 				clientCh <- client
 			case <-closed:
 				client, closed, err = newTunnelClient(ctx, ws, webSocket)
+				if err == nil {
+					// notifying subscribers must not hold up serving the tunnel client
+					go b.resetReverseSocketTunnels(ws, client.ID)
+				}
 			}
 		}
 	}()
 	return nil
 }
 
+// resetReverseSocketTunnels drops the reverse socket tunnels which were not requested on the current SSH client,
+// because the workspace forgets them once that client disconnects, and requests them again.
+func (b *Bastion) resetReverseSocketTunnels(ws *Workspace, clientID string) {
+	ws.tunnelMu.Lock()
+	var reset bool
+	for socket, t := range ws.socketListeners {
+		if !t.Reverse || t.clientID == clientID {
+			continue
+		}
+		delete(ws.socketListeners, socket)
+		delete(ws.reverseSockets, socket)
+		t.Cancel()
+		ws.socketStates[socket] = t.connecting()
+		reset = true
+	}
+	ws.tunnelMu.Unlock()
+	if !reset {
+		return
+	}
+
+	b.notify(ws)
+	select {
+	case ws.tunnelRefresh <- struct{}{}:
+	default:
+	}
+}
+
 func newTunnelClient(ctx context.Context, ws *Workspace, reconnecting *gitpod.ReconnectingWebsocket) (client *TunnelClient, closed chan struct{}, err error) {
 	logrus.WithField("workspace", ws.WorkspaceID).Info("tunnel: trying to connect ssh client...")
 	err = reconnecting.EnsureConnection(func(conn *gitpod.WebsocketConnection) (bool, error) {
@@ -537,8 +765,11 @@ func newTunnelClient(ctx context.Context, ws *Workspace, reconnecting *gitpod.Re
 		go ssh.DiscardRequests(reqs)
 		go func() {
 			for newCh := range chans {
-				// TODO(ak) reverse tunneling
-				newCh.Reject(ssh.UnknownChannelType, "tunnel: reverse is not supported yet")
+				if newCh.ChannelType() != "tunnel" {
+					_ = newCh.Reject(ssh.UnknownChannelType, "tunnel: unknown channel type")
+					continue
+				}
+				go handleReverseTunnel(ctx, ws, newCh)
 			}
 		}()
 		closed = make(chan struct{}, 1)
@@ -556,7 +787,8 @@ func newTunnelClient(ctx context.Context, ws *Workspace, reconnecting *gitpod.Re
 	return client, closed, err
 }
 
-func (b *Bastion) establishTunnel(ctx context.Context, ws *Workspace, logprefix string, remotePort int, targetPort int, visibility supervisor.TunnelVisiblity) (*TunnelListener, error) {
+// establishTunnel listens on targetPort, or on a random port if targetPort is taken and exactPort is false
+func (b *Bastion) establishTunnel(ctx context.Context, ws *Workspace, logprefix string, remotePort int, targetPort int, visibility supervisor.TunnelVisiblity, exactPort bool) (*TunnelListener, error) {
 	if !ws.tunnelClientConnected {
 		return nil, xerrors.Errorf("tunnel client is not connected")
 	}
@@ -573,6 +805,8 @@ func (b *Bastion) establishTunnel(ctx context.Context, ws *Workspace, logprefix
 	var localPort int
 	if err == nil {
 		localPort = netListener.(*net.TCPListener).Addr().(*net.TCPAddr).Port
+	} else if exactPort {
+		return nil, xerrors.Errorf("cannot listen on local port %d: %w", targetPort, err)
 	} else {
 		netListener, err = net.Listen("tcp", targetHost+":0")
 		if err != nil {
@@ -602,41 +836,20 @@ func (b *Bastion) establishTunnel(ctx context.Context, ws *Workspace, logprefix
 				defer logrus.WithField("workspace", ws.WorkspaceID).Debug(logprefix + ": connection closed")
 				defer conn.Close()
 
-				clientCh := make(chan *TunnelClient, 1)
-				select {
-				case <-listenerCtx.Done():
-					return
-				case ws.tunnelClient <- clientCh:
-				}
-				client := <-clientCh
-
-				payload, err := proto.Marshal(&supervisor.TunnelPortRequest{
-					ClientId:   client.ID,
+				sshChan, client, err := openTunnelChannel(listenerCtx, ws, &supervisor.TunnelPortRequest{
 					Port:       uint32(remotePort),
 					TargetPort: uint32(localPort),
 				})
 				if err != nil {
-					logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("id", client.ID).Error(logprefix + ": failed to marshal tunnel payload")
-					return
-				}
-				sshChan, reqs, err := client.Conn.OpenChannel("tunnel", payload)
-				if err != nil {
-					logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("id", client.ID).Warn(logprefix + ": failed to establish tunnel")
+					log := logrus.WithError(err).WithField("workspace", ws.WorkspaceID)
+					if client != nil {
+						log = log.WithField("id", client.ID)
+					}
+					log.Warn(logprefix + ": failed to establish tunnel")
 					return
 				}
 				defer sshChan.Close()
-				go ssh.DiscardRequests(reqs)
-
-				ctx, cancel := context.WithCancel(listenerCtx)
-				go func() {
-					_, _ = io.Copy(sshChan, conn)
-					cancel()
-				}()
-				go func() {
-					_, _ = io.Copy(conn, sshChan)
-					cancel()
-				}()
-				<-ctx.Done()
+				pipe(listenerCtx, conn, sshChan)
 			}()
 		}
 	}()
@@ -646,7 +859,13 @@ func (b *Bastion) establishTunnel(ctx context.Context, ws *Workspace, logprefix
 		LocalPort:  uint32(localPort),
 		Visibility: visibility,
 		Ctx:        listenerCtx,
-		Cancel:     cancel,
+		Cancel: func() {
+			cancel()
+			// we close the listener right away so that the port can be bound again immediately,
+			// e.g. when we re-establish the tunnel after a reconnect
+			netListener.Close()
+		},
+		pinned: exactPort,
 	}, nil
 }
 
@@ -659,7 +878,7 @@ func (b *Bastion) establishSSHTunnel(ws *Workspace) (listener *TunnelListener, e
 	if err != nil {
 		return nil, xerrors.Errorf("cannot install authorized key: %w", err)
 	}
-	listener, err = b.establishTunnel(ws.ctx, ws, "ssh", 23001, 0, supervisor.TunnelVisiblity_host)
+	listener, err = b.establishTunnel(ws.ctx, ws, "ssh", 23001, 0, supervisor.TunnelVisiblity_host, false)
 	return listener, err
 }
 
@@ -724,7 +943,7 @@ func installSSHAuthorizedKey(ws *Workspace, key string) error {
 
 func (b *Bastion) tunnelPorts(ws *Workspace) {
 	ws.tunnelMu.Lock()
-	if !ws.tunnelEnabled || ws.cancelTunnel != nil {
+	if (!ws.tunnelEnabled && b.PortProfiles == nil) || ws.cancelTunnel != nil {
 		ws.tunnelMu.Unlock()
 		return
 	}
@@ -737,6 +956,8 @@ func (b *Bastion) tunnelPorts(ws *Workspace) {
 		defer ws.tunnelMu.Unlock()
 
 		ws.cancelTunnel = nil
+		ws.tunnelStates = make(map[uint32]*tunnelState)
+		ws.socketStates = make(map[string]*tunnelState)
 		logrus.WithField("workspace", ws.WorkspaceID).Info("ports tunneling finished")
 	}()
 
@@ -773,56 +994,289 @@ func (b *Bastion) doTunnelPorts(ctx context.Context, ws *Workspace) error {
 		for port, t := range ws.tunnelListeners {
 			delete(ws.tunnelListeners, port)
 			t.Cancel()
+			if t.pinned {
+				// we re-establish profile tunnels on the same local port once we're reconnected
+				ws.tunnelStates[port] = t.connecting()
+			}
+		}
+		for socket, t := range ws.socketListeners {
+			delete(ws.socketListeners, socket)
+			delete(ws.reverseSockets, socket)
+			t.Cancel()
+			ws.socketStates[socket] = t.connecting()
+		}
+	}()
+
+	var (
+		updates = make(chan *supervisor.PortsStatusResponse)
+		errs    = make(chan error, 1)
+	)
+	go func() {
+		for {
+			resp, err := status.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case updates <- resp:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
+
+	var last *supervisor.PortsStatusResponse
 	for {
-		resp, err := status.Recv()
-		if err != nil {
+		select {
+		case err := <-errs:
 			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		case last = <-updates:
+		case <-ws.tunnelRefresh:
+			if last == nil {
+				continue
+			}
 		}
-		ws.tunnelMu.Lock()
-		currentTunneled := make(map[uint32]struct{})
-		for _, port := range resp.Ports {
-			visibility := supervisor.TunnelVisiblity_none
-			if port.Tunneled != nil {
-				visibility = port.Tunneled.Visibility
+
+		b.reconcileTunnels(ctx, ws, last.Ports)
+		b.notify(ws)
+	}
+}
+
+// desiredTunnel describes how we want to tunnel a workspace port
+type desiredTunnel struct {
+	LocalPort  uint32
+	Visibility supervisor.TunnelVisiblity
+	Protocol   supervisor.TunnelProtocol
+	// Pinned is true if the tunnel is configured by a port profile
+	Pinned bool
+}
+
+// desiredTunnel returns how a port should be tunneled, or nil if it should not be tunneled
+func (b *Bastion) desiredTunnel(ws *Workspace, profile *PortProfile, port *supervisor.PortsStatus) *desiredTunnel {
+	if fwd := profile.Forward(port.LocalPort); fwd != nil {
+		// we cannot observe whether a UDP port is served
+		if fwd.Skip || (fwd.protocol() == supervisor.TunnelProtocol_tcp && !port.Served && port.Tunneled == nil) {
+			return nil
+		}
+		return &desiredTunnel{
+			LocalPort:  fwd.localPort(),
+			Visibility: fwd.visibility(),
+			Protocol:   fwd.protocol(),
+			Pinned:     true,
+		}
+	}
+
+	if !b.EnableAutoTunnel || !ws.tunnelEnabled || !profile.TunnelsUnlisted() {
+		return nil
+	}
+	if port.Tunneled == nil || port.Tunneled.Visibility == supervisor.TunnelVisiblity_none {
+		return nil
+	}
+	return &desiredTunnel{
+		LocalPort:  port.Tunneled.TargetPort,
+		Visibility: port.Tunneled.Visibility,
+		Protocol:   port.Tunneled.Protocol,
+	}
+}
+
+// pendingTunnel is a port tunnel reconcileTunnels establishes without holding ws.tunnelMu
+type pendingTunnel struct {
+	Port     *supervisor.PortsStatus
+	Desired  *desiredTunnel
+	Listener *TunnelListener
+	Err      error
+}
+
+// pendingSocketTunnel is a socket tunnel reconcileTunnels establishes without holding ws.tunnelMu
+type pendingSocketTunnel struct {
+	Forward  *SocketForward
+	Listener *TunnelListener
+	Err      error
+}
+
+// reconcileTunnels establishes and closes tunnels to match the ports of a workspace. It decides what to change
+// while holding ws.tunnelMu, but talks to the workspace without it, so that a slow workspace blocks neither status
+// requests nor reverse tunnels. Callers must not hold ws.tunnelMu.
+func (b *Bastion) reconcileTunnels(ctx context.Context, ws *Workspace, ports []*supervisor.PortsStatus) {
+	profile := b.PortProfiles.Match(ws.WorkspaceID, ws.ContextURL)
+	if profile != nil {
+		// the workspace does not report UDP ports until they are tunneled
+		reported := make(map[uint32]struct{}, len(ports))
+		for _, port := range ports {
+			reported[port.LocalPort] = struct{}{}
+		}
+		for _, fwd := range profile.Ports {
+			if _, exists := reported[fwd.Port]; exists || fwd.protocol() != supervisor.TunnelProtocol_udp {
+				continue
 			}
-			listener, alreadyTunneled := ws.tunnelListeners[port.LocalPort]
-			if alreadyTunneled && listener.Visibility != visibility {
-				listener.Cancel()
-				delete(ws.tunnelListeners, port.LocalPort)
+			ports = append(ports, &supervisor.PortsStatus{LocalPort: fwd.Port})
+		}
+	}
+
+	ws.tunnelMu.Lock()
+	tunnels := b.planTunnels(ws, profile, ports)
+	sockets := b.planSocketTunnels(ws, profile)
+	ws.tunnelMu.Unlock()
+
+	for _, t := range tunnels {
+		t.Listener, t.Err = b.establishPortTunnel(ctx, ws, t.Port, t.Desired)
+	}
+	for _, t := range sockets {
+		if t.Forward.Reverse {
+			t.Listener, t.Err = b.establishReverseSocketTunnel(ctx, ws, t.Forward)
+		} else {
+			t.Listener, t.Err = b.establishSocketTunnel(ws.ctx, ws, t.Forward)
+		}
+	}
+
+	ws.tunnelMu.Lock()
+	defer ws.tunnelMu.Unlock()
+	for _, t := range tunnels {
+		port := t.Port.LocalPort
+		if t.Err != nil {
+			logrus.WithError(t.Err).WithField("workspace", ws.WorkspaceID).WithField("port", port).Error("cannot establish port tunnel")
+			ws.tunnelStates[port] = &tunnelState{
+				RemotePort: port,
+				LocalPort:  t.Desired.LocalPort,
+				Visibility: t.Desired.Visibility,
+				Protocol:   t.Desired.Protocol,
+				State:      app.TunnelState_failed,
+				Err:        t.Err,
 			}
-			if visibility == supervisor.TunnelVisiblity_none {
-				continue
+			continue
+		}
+		ws.tunnelListeners[port] = t.Listener
+		delete(ws.tunnelStates, port)
+	}
+	for _, t := range sockets {
+		socket := t.Forward.Socket
+		if t.Err != nil {
+			logrus.WithError(t.Err).WithField("workspace", ws.WorkspaceID).WithField("socket", socket).Error("cannot establish socket tunnel")
+			if t.Forward.Reverse {
+				delete(ws.reverseSockets, socket)
 			}
-			currentTunneled[port.LocalPort] = struct{}{}
-			_, alreadyTunneled = ws.tunnelListeners[port.LocalPort]
-			if alreadyTunneled {
-				continue
+			ws.socketStates[socket] = &tunnelState{
+				Protocol:        supervisor.TunnelProtocol_unix,
+				State:           app.TunnelState_failed,
+				Err:             t.Err,
+				SocketPath:      t.Forward.Socket,
+				LocalSocketPath: t.Forward.LocalSocket,
+				Reverse:         t.Forward.Reverse,
 			}
+			continue
+		}
+		ws.socketListeners[socket] = t.Listener
+		delete(ws.socketStates, socket)
+	}
+}
+
+// planTunnels closes the port tunnels which are no longer desired and returns those to establish. Callers must hold ws.tunnelMu.
+func (b *Bastion) planTunnels(ws *Workspace, profile *PortProfile, ports []*supervisor.PortsStatus) []*pendingTunnel {
+	var (
+		pending         []*pendingTunnel
+		currentTunneled = make(map[uint32]struct{})
+	)
+	for _, port := range ports {
+		desired := b.desiredTunnel(ws, profile, port)
+		listener, alreadyTunneled := ws.tunnelListeners[port.LocalPort]
+		if alreadyTunneled && (desired == nil || listener.Visibility != desired.Visibility || listener.Protocol != desired.Protocol || (desired.Pinned && listener.LocalPort != desired.LocalPort)) {
+			listener.Cancel()
+			delete(ws.tunnelListeners, port.LocalPort)
+		}
+		if desired == nil {
+			continue
+		}
+		currentTunneled[port.LocalPort] = struct{}{}
+		_, alreadyTunneled = ws.tunnelListeners[port.LocalPort]
+		if alreadyTunneled {
+			continue
+		}
+		if !desired.Pinned && port.Tunneled != nil {
 			_, alreadyTunneled = port.Tunneled.Clients[b.id]
 			if alreadyTunneled {
 				continue
 			}
+		}
+		pending = append(pending, &pendingTunnel{Port: port, Desired: desired})
+	}
+	for port, listener := range ws.tunnelListeners {
+		_, exists := currentTunneled[port]
+		if !exists {
+			delete(ws.tunnelListeners, port)
+			listener.Cancel()
+		}
+	}
+	for port := range ws.tunnelStates {
+		_, exists := currentTunneled[port]
+		if !exists {
+			delete(ws.tunnelStates, port)
+		}
+	}
+	return pending
+}
 
-			logprefix := "tunnel[" + supervisor.TunnelVisiblity_name[int32(port.Tunneled.Visibility)] + ":" + strconv.Itoa(int(port.LocalPort)) + "]"
-			listener, err := b.establishTunnel(ws.ctx, ws, logprefix, int(port.LocalPort), int(port.Tunneled.TargetPort), port.Tunneled.Visibility)
-			if err != nil {
-				logrus.WithError(err).WithField("workspace", ws.WorkspaceID).WithField("port", port.LocalPort).Error("cannot establish port tunnel")
-			} else {
-				ws.tunnelListeners[port.LocalPort] = listener
-			}
+// establishPortTunnel registers a port tunnel with the workspace if necessary and listens for local connections
+func (b *Bastion) establishPortTunnel(ctx context.Context, ws *Workspace, port *supervisor.PortsStatus, desired *desiredTunnel) (*TunnelListener, error) {
+	if port.Tunneled == nil || port.Tunneled.Protocol != desired.Protocol {
+		// the workspace accepts tunnel connections for registered ports only, using the registered protocol
+		_, err := supervisor.NewPortServiceClient(ws.supervisorClient).Tunnel(ctx, &supervisor.TunnelPortRequest{
+			Port:       port.LocalPort,
+			TargetPort: desired.LocalPort,
+			Visibility: desired.Visibility,
+			ClientId:   b.id,
+			Protocol:   desired.Protocol,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("cannot register port tunnel: %w", err)
 		}
-		for port, listener := range ws.tunnelListeners {
-			_, exists := currentTunneled[port]
-			if !exists {
-				delete(ws.tunnelListeners, port)
-				listener.Cancel()
-			}
+	}
+
+	logprefix := "tunnel[" + supervisor.TunnelVisiblity_name[int32(desired.Visibility)] + ":" + strconv.Itoa(int(port.LocalPort)) + "]"
+	if desired.Protocol == supervisor.TunnelProtocol_udp {
+		return b.establishUDPTunnel(ws.ctx, ws, logprefix, int(port.LocalPort), int(desired.LocalPort), desired.Visibility)
+	}
+	return b.establishTunnel(ws.ctx, ws, logprefix, int(port.LocalPort), int(desired.LocalPort), desired.Visibility, desired.Pinned)
+}
+
+// planSocketTunnels closes the Unix domain socket tunnels a profile no longer lists and returns those to establish.
+// Callers must hold ws.tunnelMu.
+func (b *Bastion) planSocketTunnels(ws *Workspace, profile *PortProfile) []*pendingSocketTunnel {
+	desired := make(map[string]*SocketForward)
+	if profile != nil {
+		for _, fwd := range profile.Sockets {
+			desired[fwd.Socket] = fwd
 		}
-		ws.tunnelMu.Unlock()
-		b.notify(ws)
 	}
+	for socket, listener := range ws.socketListeners {
+		fwd, exists := desired[socket]
+		if exists && listener.LocalSocketPath == fwd.LocalSocket && listener.Reverse == fwd.Reverse {
+			continue
+		}
+		delete(ws.socketListeners, socket)
+		delete(ws.reverseSockets, socket)
+		listener.Cancel()
+	}
+	for socket := range ws.socketStates {
+		if _, exists := desired[socket]; !exists {
+			delete(ws.socketStates, socket)
+		}
+	}
+
+	var pending []*pendingSocketTunnel
+	for socket, fwd := range desired {
+		if _, exists := ws.socketListeners[socket]; exists {
+			continue
+		}
+		if fwd.Reverse {
+			// the workspace may tunnel connections to us as soon as we asked it to listen
+			ws.reverseSockets[socket] = fwd.LocalSocket
+		}
+		pending = append(pending, &pendingSocketTunnel{Forward: fwd})
+	}
+	return pending
 }
 
 func (b *Bastion) notify(ws *Workspace) {
@@ -908,6 +1362,14 @@ func (b *Bastion) AutoTunnel(instanceID string, enabled bool) {
 		return
 	}
 	ws.tunnelEnabled = enabled
+	if b.PortProfiles != nil {
+		// profile tunnels keep running, we only reconsider the ports profiles don't list
+		select {
+		case ws.tunnelRefresh <- struct{}{}:
+		default:
+		}
+		return
+	}
 	if enabled {
 		if ws.cancelTunnel == nil && b.EnableAutoTunnel {
 			b.Update(ws.WorkspaceID)
@@ -0,0 +1,84 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package bastion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSSHGatewayHost(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Workspace   *Workspace
+		Expectation string
+		Error       bool
+	}{
+		{
+			Name:        "workspace URL",
+			Workspace:   &Workspace{WorkspaceID: "amber-ant-1abc2def", URL: "https://amber-ant-1abc2def.ws-eu.gitpod.io"},
+			Expectation: "amber-ant-1abc2def.ssh.ws-eu.gitpod.io",
+		},
+		{
+			Name:      "foreign URL",
+			Workspace: &Workspace{WorkspaceID: "amber-ant-1abc2def", URL: "https://gitpod.io"},
+			Error:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, err := sshGatewayHost(test.Workspace)
+			if (err != nil) != test.Error {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if act != test.Expectation {
+				t.Errorf("sshGatewayHost() mismatch: want %q, got %q", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestSSHConfigWritingCallbackGateway(t *testing.T) {
+	dir := t.TempDir()
+	cb := &SSHConfigWritingCallback{
+		Path:           filepath.Join(dir, "ssh_config"),
+		HostCAKey:      "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGitpodCA",
+		KnownHostsPath: filepath.Join(dir, "known_hosts"),
+	}
+	cb.InstanceUpdate(&Workspace{
+		WorkspaceID:      "amber-ant-1abc2def",
+		Phase:            "running",
+		SSHGatewayHost:   "amber-ant-1abc2def.ssh.ws-eu.gitpod.io",
+		SSHPrivateFN:     "/home/me/.ssh/id_ed25519",
+		SSHCertificateFN: "/home/me/.ssh/id_ed25519-cert.pub",
+	})
+
+	knownHosts, err := os.ReadFile(cb.KnownHostsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("@cert-authority *.ssh.ws-eu.gitpod.io ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGitpodCA\n", string(knownHosts)); diff != "" {
+		t.Errorf("known_hosts mismatch (-want +got):\n%s", diff)
+	}
+
+	cfg, err := os.ReadFile(cb.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"HostName amber-ant-1abc2def.ssh.ws-eu.gitpod.io",
+		"User amber-ant-1abc2def",
+		"CertificateFile /home/me/.ssh/id_ed25519-cert.pub",
+		"UserKnownHostsFile " + cb.KnownHostsPath,
+	} {
+		if !strings.Contains(string(cfg), expected) {
+			t.Errorf("ssh_config does not contain %q:\n%s", expected, cfg)
+		}
+	}
+}
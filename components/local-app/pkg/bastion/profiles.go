@@ -0,0 +1,249 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package bastion
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
+
+	supervisor "github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+// PortProfiles configure which workspace ports are tunneled to which local ports, e.g.
+//
+//	profiles:
+//	- repository: github.com/gitpod-io/gitpod
+//	  autoTunnel: false
+//	  ports:
+//	  - port: 3000
+//	    localPort: 13000
+//	    bind: lan
+//	  - port: 9229
+//	    skip: true
+//	  - port: 53
+//	    localPort: 5353
+//	    protocol: udp
+//	  sockets:
+//	  - socket: /var/run/docker.sock
+//	    localSocket: /tmp/gitpod-docker.sock
+type PortProfiles struct {
+	Profiles []*PortProfile `yaml:"profiles"`
+}
+
+// PortProfile configures the port tunnels of a workspace or all workspaces of a repository
+type PortProfile struct {
+	// Workspace is the ID of the workspace this profile applies to
+	Workspace string `yaml:"workspace,omitempty"`
+	// Repository is the repository this profile applies to, e.g. github.com/gitpod-io/gitpod
+	Repository string `yaml:"repository,omitempty"`
+	// AutoTunnel determines if ports which are not listed are tunneled as requested by the workspace.
+	// Defaults to true.
+	AutoTunnel *bool `yaml:"autoTunnel,omitempty"`
+	// Ports lists the ports of this profile
+	Ports []*PortForward `yaml:"ports"`
+	// Sockets lists the Unix domain sockets which are forwarded
+	Sockets []*SocketForward `yaml:"sockets,omitempty"`
+}
+
+// PortBind determines on which interfaces a tunnel listens
+type PortBind string
+
+const (
+	// PortBindLocalhost makes a tunnel listen on localhost only
+	PortBindLocalhost PortBind = "localhost"
+	// PortBindLAN makes a tunnel listen on all interfaces
+	PortBindLAN PortBind = "lan"
+)
+
+// PortProtocol is the protocol of a tunneled port
+type PortProtocol string
+
+const (
+	// PortProtocolTCP tunnels TCP connections
+	PortProtocolTCP PortProtocol = "tcp"
+	// PortProtocolUDP tunnels UDP datagrams
+	PortProtocolUDP PortProtocol = "udp"
+)
+
+// PortForward configures the tunnel of a single workspace port
+type PortForward struct {
+	// Port is the port in the workspace
+	Port uint32 `yaml:"port"`
+	// LocalPort is the local port we listen on. Defaults to Port.
+	LocalPort uint32 `yaml:"localPort,omitempty"`
+	// Bind determines the interfaces we listen on. Defaults to localhost.
+	Bind PortBind `yaml:"bind,omitempty"`
+	// Skip disables the tunnel for this port
+	Skip bool `yaml:"skip,omitempty"`
+	// Protocol is either tcp or udp. Defaults to tcp.
+	Protocol PortProtocol `yaml:"protocol,omitempty"`
+}
+
+// SocketForward configures the tunnel of a Unix domain socket
+type SocketForward struct {
+	// Socket is the absolute path of the socket in the workspace
+	Socket string `yaml:"socket"`
+	// LocalSocket is the absolute path of the socket on this machine
+	LocalSocket string `yaml:"localSocket"`
+	// Reverse forwards connections to Socket in the workspace to LocalSocket on this machine.
+	// By default we forward connections to LocalSocket to Socket in the workspace.
+	Reverse bool `yaml:"reverse,omitempty"`
+}
+
+// LoadPortProfiles loads port profiles from a YAML file
+func LoadPortProfiles(fn string) (*PortProfiles, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	var res PortProfiles
+	err = yaml.UnmarshalStrict(b, &res)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse port profiles %s: %w", fn, err)
+	}
+	err = res.Validate()
+	if err != nil {
+		return nil, xerrors.Errorf("invalid port profiles %s: %w", fn, err)
+	}
+	return &res, nil
+}
+
+// Validate checks the profiles for errors, e.g. local port collisions
+func (p *PortProfiles) Validate() error {
+	for i, profile := range p.Profiles {
+		if (profile.Workspace == "") == (profile.Repository == "") {
+			return xerrors.Errorf("profile %d: exactly one of workspace or repository is required", i)
+		}
+		localPorts := make(map[uint32]uint32)
+		for _, fwd := range profile.Ports {
+			if fwd.Port == 0 || fwd.Port > 0xFFFF {
+				return xerrors.Errorf("profile %d: bad port: %d", i, fwd.Port)
+			}
+			if fwd.LocalPort > 0xFFFF {
+				return xerrors.Errorf("profile %d: bad local port: %d", i, fwd.LocalPort)
+			}
+			switch fwd.Bind {
+			case "", PortBindLocalhost, PortBindLAN:
+			default:
+				return xerrors.Errorf("profile %d: port %d: bind must be %s or %s", i, fwd.Port, PortBindLocalhost, PortBindLAN)
+			}
+			switch fwd.Protocol {
+			case "", PortProtocolTCP, PortProtocolUDP:
+			default:
+				return xerrors.Errorf("profile %d: port %d: protocol must be %s or %s", i, fwd.Port, PortProtocolTCP, PortProtocolUDP)
+			}
+			if fwd.Skip {
+				continue
+			}
+			// TCP and UDP ports don't collide, but we keep things simple
+			if other, exists := localPorts[fwd.localPort()]; exists {
+				return xerrors.Errorf("profile %d: ports %d and %d both use local port %d", i, other, fwd.Port, fwd.localPort())
+			}
+			localPorts[fwd.localPort()] = fwd.Port
+		}
+		sockets := make(map[string]struct{})
+		for _, sock := range profile.Sockets {
+			if !filepath.IsAbs(sock.Socket) || !filepath.IsAbs(sock.LocalSocket) {
+				return xerrors.Errorf("profile %d: socket paths must be absolute", i)
+			}
+			if _, exists := sockets[sock.Socket]; exists {
+				return xerrors.Errorf("profile %d: socket %s is forwarded twice", i, sock.Socket)
+			}
+			sockets[sock.Socket] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// Match returns the profile for a workspace. Workspace profiles take precedence over repository profiles.
+// Returns nil if no profile matches.
+func (p *PortProfiles) Match(workspaceID, contextURL string) *PortProfile {
+	if p == nil {
+		return nil
+	}
+	for _, profile := range p.Profiles {
+		if profile.Workspace != "" && profile.Workspace == workspaceID {
+			return profile
+		}
+	}
+	repo := normalizeRepository(contextURL)
+	if repo == "" {
+		return nil
+	}
+	for _, profile := range p.Profiles {
+		if profile.Repository == "" {
+			continue
+		}
+		prefix := normalizeRepository(profile.Repository)
+		if repo == prefix || strings.HasPrefix(repo, prefix+"/") {
+			return profile
+		}
+	}
+	return nil
+}
+
+// Forward returns the configuration of a port, or nil if the profile does not list the port
+func (p *PortProfile) Forward(port uint32) *PortForward {
+	if p == nil {
+		return nil
+	}
+	for _, fwd := range p.Ports {
+		if fwd.Port == port {
+			return fwd
+		}
+	}
+	return nil
+}
+
+// TunnelsUnlisted returns true if ports the profile does not list are tunneled as requested by the workspace
+func (p *PortProfile) TunnelsUnlisted() bool {
+	return p == nil || p.AutoTunnel == nil || *p.AutoTunnel
+}
+
+func (f *PortForward) localPort() uint32 {
+	if f.LocalPort == 0 {
+		return f.Port
+	}
+	return f.LocalPort
+}
+
+func (f *PortForward) protocol() supervisor.TunnelProtocol {
+	if f.Protocol == PortProtocolUDP {
+		return supervisor.TunnelProtocol_udp
+	}
+	return supervisor.TunnelProtocol_tcp
+}
+
+func (f *PortForward) visibility() supervisor.TunnelVisiblity {
+	if f.Bind == PortBindLAN {
+		return supervisor.TunnelVisiblity_network
+	}
+	return supervisor.TunnelVisiblity_host
+}
+
+// normalizeRepository turns context URLs and repositories into host/owner/repo/... form
+func normalizeRepository(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if path == "" {
+		return strings.ToLower(u.Host)
+	}
+	return strings.ToLower(u.Host) + "/" + path
+}
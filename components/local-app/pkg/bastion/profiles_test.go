@@ -0,0 +1,186 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package bastion
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	supervisor "github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+func TestPortProfilesValidate(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Profiles PortProfiles
+		Error    bool
+	}{
+		{
+			Name: "valid",
+			Profiles: PortProfiles{Profiles: []*PortProfile{
+				{Repository: "github.com/gitpod-io/gitpod", Ports: []*PortForward{{Port: 3000, LocalPort: 13000, Bind: PortBindLAN}, {Port: 13000, Skip: true}}},
+			}},
+		},
+		{
+			Name:     "no target",
+			Profiles: PortProfiles{Profiles: []*PortProfile{{Ports: []*PortForward{{Port: 3000}}}}},
+			Error:    true,
+		},
+		{
+			Name: "local port collision",
+			Profiles: PortProfiles{Profiles: []*PortProfile{
+				{Workspace: "amber-ant-1abc2def", Ports: []*PortForward{{Port: 3000, LocalPort: 8080}, {Port: 8080}}},
+			}},
+			Error: true,
+		},
+		{
+			Name: "invalid bind",
+			Profiles: PortProfiles{Profiles: []*PortProfile{
+				{Workspace: "amber-ant-1abc2def", Ports: []*PortForward{{Port: 3000, Bind: "everywhere"}}},
+			}},
+			Error: true,
+		},
+		{
+			Name: "invalid protocol",
+			Profiles: PortProfiles{Profiles: []*PortProfile{
+				{Workspace: "amber-ant-1abc2def", Ports: []*PortForward{{Port: 53, Protocol: "sctp"}}},
+			}},
+			Error: true,
+		},
+		{
+			Name: "sockets",
+			Profiles: PortProfiles{Profiles: []*PortProfile{
+				{Workspace: "amber-ant-1abc2def", Sockets: []*SocketForward{
+					{Socket: "/var/run/docker.sock", LocalSocket: "/tmp/docker.sock"},
+					{Socket: "/tmp/ssh-agent.sock", LocalSocket: "/run/user/1000/ssh-agent.sock", Reverse: true},
+				}},
+			}},
+		},
+		{
+			Name: "relative socket",
+			Profiles: PortProfiles{Profiles: []*PortProfile{
+				{Workspace: "amber-ant-1abc2def", Sockets: []*SocketForward{{Socket: "docker.sock", LocalSocket: "/tmp/docker.sock"}}},
+			}},
+			Error: true,
+		},
+		{
+			Name: "duplicate socket",
+			Profiles: PortProfiles{Profiles: []*PortProfile{
+				{Workspace: "amber-ant-1abc2def", Sockets: []*SocketForward{
+					{Socket: "/var/run/docker.sock", LocalSocket: "/tmp/docker.sock"},
+					{Socket: "/var/run/docker.sock", LocalSocket: "/tmp/docker2.sock"},
+				}},
+			}},
+			Error: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := test.Profiles.Validate()
+			if (err != nil) != test.Error {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPortProfilesMatch(t *testing.T) {
+	var (
+		repo = &PortProfile{Repository: "github.com/gitpod-io/gitpod"}
+		ws   = &PortProfile{Workspace: "amber-ant-1abc2def"}
+	)
+	profiles := &PortProfiles{Profiles: []*PortProfile{repo, ws}}
+
+	tests := []struct {
+		Name        string
+		WorkspaceID string
+		ContextURL  string
+		Expectation *PortProfile
+	}{
+		{Name: "repository", WorkspaceID: "blue-bee-1abc2def", ContextURL: "https://github.com/gitpod-io/gitpod", Expectation: repo},
+		{Name: "pull request", WorkspaceID: "blue-bee-1abc2def", ContextURL: "https://github.com/gitpod-io/gitpod/pull/42", Expectation: repo},
+		{Name: "workspace takes precedence", WorkspaceID: "amber-ant-1abc2def", ContextURL: "https://github.com/gitpod-io/gitpod", Expectation: ws},
+		{Name: "repository prefix", WorkspaceID: "blue-bee-1abc2def", ContextURL: "https://github.com/gitpod-io/gitpod-test", Expectation: nil},
+		{Name: "no context", WorkspaceID: "blue-bee-1abc2def", Expectation: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := profiles.Match(test.WorkspaceID, test.ContextURL)
+			if act != test.Expectation {
+				t.Errorf("Match() mismatch: want %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestDesiredTunnel(t *testing.T) {
+	noAutoTunnel := false
+	profile := &PortProfile{
+		Repository: "github.com/gitpod-io/gitpod",
+		Ports: []*PortForward{
+			{Port: 3000, LocalPort: 13000, Bind: PortBindLAN},
+			{Port: 9229, Skip: true},
+			{Port: 53, LocalPort: 5353, Protocol: PortProtocolUDP},
+		},
+	}
+	strictProfile := &PortProfile{Repository: "github.com/gitpod-io/gitpod", AutoTunnel: &noAutoTunnel}
+	tunneled := &supervisor.TunneledPortInfo{TargetPort: 8080, Visibility: supervisor.TunnelVisiblity_host}
+
+	tests := []struct {
+		Name        string
+		Profile     *PortProfile
+		Port        *supervisor.PortsStatus
+		Expectation *desiredTunnel
+	}{
+		{
+			Name:        "profile port",
+			Profile:     profile,
+			Port:        &supervisor.PortsStatus{LocalPort: 3000, Served: true},
+			Expectation: &desiredTunnel{LocalPort: 13000, Visibility: supervisor.TunnelVisiblity_network, Pinned: true},
+		},
+		{
+			Name:    "profile port not served",
+			Profile: profile,
+			Port:    &supervisor.PortsStatus{LocalPort: 3000},
+		},
+		{
+			Name:        "udp profile port",
+			Profile:     profile,
+			Port:        &supervisor.PortsStatus{LocalPort: 53},
+			Expectation: &desiredTunnel{LocalPort: 5353, Visibility: supervisor.TunnelVisiblity_host, Protocol: supervisor.TunnelProtocol_udp, Pinned: true},
+		},
+		{
+			Name:    "skipped port",
+			Profile: profile,
+			Port:    &supervisor.PortsStatus{LocalPort: 9229, Served: true, Tunneled: tunneled},
+		},
+		{
+			Name:        "unlisted port",
+			Profile:     profile,
+			Port:        &supervisor.PortsStatus{LocalPort: 8080, Served: true, Tunneled: tunneled},
+			Expectation: &desiredTunnel{LocalPort: 8080, Visibility: supervisor.TunnelVisiblity_host},
+		},
+		{
+			Name:    "unlisted port without auto tunnel",
+			Profile: strictProfile,
+			Port:    &supervisor.PortsStatus{LocalPort: 8080, Served: true, Tunneled: tunneled},
+		},
+		{
+			Name:        "no profile",
+			Port:        &supervisor.PortsStatus{LocalPort: 8080, Served: true, Tunneled: tunneled},
+			Expectation: &desiredTunnel{LocalPort: 8080, Visibility: supervisor.TunnelVisiblity_host},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			b := &Bastion{EnableAutoTunnel: true}
+			act := b.desiredTunnel(&Workspace{tunnelEnabled: true}, test.Profile, test.Port)
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("desiredTunnel() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package bastion
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+	"google.golang.org/protobuf/proto"
+
+	supervisor "github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+const (
+	// maxDatagramSize is the largest datagram a length prefix can describe
+	maxDatagramSize = 0xFFFF
+	// udpSessionTimeout is the time after which we close the tunnel of an idle UDP peer
+	udpSessionTimeout = 2 * time.Minute
+
+	// reverseTunnelRequest asks the workspace to listen on a Unix domain socket and to tunnel connections to us
+	reverseTunnelRequest = "reverse-tunnel@gitpod.io"
+	// cancelReverseTunnelRequest asks the workspace to stop listening on a Unix domain socket
+	cancelReverseTunnelRequest = "cancel-reverse-tunnel@gitpod.io"
+)
+
+// currentTunnelClient returns the SSH client of the currently connected tunnel
+func currentTunnelClient(ctx context.Context, ws *Workspace) (*TunnelClient, error) {
+	clientCh := make(chan *TunnelClient, 1)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case ws.tunnelClient <- clientCh:
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case client := <-clientCh:
+		if client == nil {
+			return nil, xerrors.Errorf("tunnel client is not connected")
+		}
+		return client, nil
+	}
+}
+
+// openTunnelChannel opens a tunnel channel to the workspace
+func openTunnelChannel(ctx context.Context, ws *Workspace, req *supervisor.TunnelPortRequest) (ssh.Channel, *TunnelClient, error) {
+	client, err := currentTunnelClient(ctx, ws)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = proto.Clone(req).(*supervisor.TunnelPortRequest)
+	req.ClientId = client.ID
+	payload, err := proto.Marshal(req)
+	if err != nil {
+		return nil, client, xerrors.Errorf("failed to marshal tunnel payload: %w", err)
+	}
+	sshChan, reqs, err := client.Conn.OpenChannel("tunnel", payload)
+	if err != nil {
+		return nil, client, err
+	}
+	go ssh.DiscardRequests(reqs)
+	return sshChan, client, nil
+}
+
+// pipe copies data between both connections until either is closed
+func pipe(ctx context.Context, a io.ReadWriter, b io.ReadWriter) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		_, _ = io.Copy(a, b)
+		cancel()
+	}()
+	go func() {
+		_, _ = io.Copy(b, a)
+		cancel()
+	}()
+	<-ctx.Done()
+}
+
+// establishUDPTunnel listens for datagrams on targetPort and tunnels them to remotePort in the workspace.
+// Every local peer gets its own tunnel channel, which we close once the peer is idle.
+func (b *Bastion) establishUDPTunnel(ctx context.Context, ws *Workspace, logprefix string, remotePort int, targetPort int, visibility supervisor.TunnelVisiblity) (*TunnelListener, error) {
+	if !ws.tunnelClientConnected {
+		return nil, xerrors.Errorf("tunnel client is not connected")
+	}
+	if visibility == supervisor.TunnelVisiblity_none {
+		return nil, xerrors.Errorf("tunnel visibility is none")
+	}
+
+	targetHost := "127.0.0.1"
+	if visibility == supervisor.TunnelVisiblity_network {
+		targetHost = "0.0.0.0"
+	}
+	conn, err := net.ListenPacket("udp", targetHost+":"+strconv.Itoa(targetPort))
+	if err != nil {
+		return nil, xerrors.Errorf("cannot listen on local port %d: %w", targetPort, err)
+	}
+	localPort := conn.LocalAddr().(*net.UDPAddr).Port
+	logrus.WithField("workspace", ws.WorkspaceID).Info(logprefix + ": listening on udp " + conn.LocalAddr().String() + "...")
+
+	listenerCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-listenerCtx.Done()
+		conn.Close()
+		logrus.WithField("workspace", ws.WorkspaceID).Info(logprefix + ": closed")
+	}()
+	go serveUDPTunnel(listenerCtx, ws, logprefix, conn, &supervisor.TunnelPortRequest{
+		Port:       uint32(remotePort),
+		TargetPort: uint32(localPort),
+		Visibility: visibility,
+		Protocol:   supervisor.TunnelProtocol_udp,
+	})
+
+	return &TunnelListener{
+		RemotePort: uint32(remotePort),
+		LocalAddr:  conn.LocalAddr().String(),
+		LocalPort:  uint32(localPort),
+		Visibility: visibility,
+		Protocol:   supervisor.TunnelProtocol_udp,
+		Ctx:        listenerCtx,
+		Cancel: func() {
+			cancel()
+			conn.Close()
+		},
+		pinned: true,
+	}, nil
+}
+
+type udpSession struct {
+	ch         ssh.Channel
+	lastActive time.Time
+}
+
+func serveUDPTunnel(ctx context.Context, ws *Workspace, logprefix string, conn net.PacketConn, req *supervisor.TunnelPortRequest) {
+	var (
+		mu       sync.Mutex
+		sessions = make(map[string]*udpSession)
+	)
+	closeSession := func(peer string, sess *udpSession) {
+		mu.Lock()
+		if sessions[peer] == sess {
+			delete(sessions, peer)
+		}
+		mu.Unlock()
+		sess.ch.Close()
+	}
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for peer, sess := range sessions {
+			delete(sessions, peer)
+			sess.ch.Close()
+		}
+	}()
+
+	go func() {
+		t := time.NewTicker(udpSessionTimeout / 2)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			mu.Lock()
+			var idle []string
+			for peer, sess := range sessions {
+				if time.Since(sess.lastActive) > udpSessionTimeout {
+					idle = append(idle, peer)
+				}
+			}
+			mu.Unlock()
+			for _, peer := range idle {
+				mu.Lock()
+				sess := sessions[peer]
+				mu.Unlock()
+				if sess != nil {
+					closeSession(peer, sess)
+				}
+			}
+		}
+	}()
+
+	buf := make([]byte, 2+maxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf[2:])
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("workspace", ws.WorkspaceID).Warn(logprefix + ": failed to read datagram")
+			return
+		}
+		binary.BigEndian.PutUint16(buf, uint16(n))
+
+		peer := addr.String()
+		mu.Lock()
+		sess := sessions[peer]
+		mu.Unlock()
+		if sess == nil {
+			ch, _, err := openTunnelChannel(ctx, ws, req)
+			if err != nil {
+				logrus.WithError(err).WithField("workspace", ws.WorkspaceID).Warn(logprefix + ": failed to establish tunnel")
+				continue
+			}
+			sess = &udpSession{ch: ch}
+			mu.Lock()
+			sessions[peer] = sess
+			mu.Unlock()
+
+			go func() {
+				defer closeSession(peer, sess)
+				frame := make([]byte, maxDatagramSize)
+				for {
+					_, err := io.ReadFull(ch, frame[:2])
+					if err != nil {
+						return
+					}
+					size := int(binary.BigEndian.Uint16(frame))
+					_, err = io.ReadFull(ch, frame[:size])
+					if err != nil {
+						return
+					}
+					_, err = conn.WriteTo(frame[:size], addr)
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+
+		mu.Lock()
+		sess.lastActive = time.Now()
+		mu.Unlock()
+		_, err = sess.ch.Write(buf[:2+n])
+		if err != nil {
+			closeSession(peer, sess)
+		}
+	}
+}
+
+// establishSocketTunnel listens on a local Unix domain socket and tunnels connections to a socket in the workspace
+func (b *Bastion) establishSocketTunnel(ctx context.Context, ws *Workspace, fwd *SocketForward) (*TunnelListener, error) {
+	if !ws.tunnelClientConnected {
+		return nil, xerrors.Errorf("tunnel client is not connected")
+	}
+	logprefix := "tunnel[unix:" + fwd.Socket + "]"
+
+	removeStaleSocket(fwd.LocalSocket)
+	l, err := net.Listen("unix", fwd.LocalSocket)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot listen on %s: %w", fwd.LocalSocket, err)
+	}
+	logrus.WithField("workspace", ws.WorkspaceID).Info(logprefix + ": listening on " + fwd.LocalSocket + "...")
+
+	listenerCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-listenerCtx.Done()
+		l.Close()
+		logrus.WithField("workspace", ws.WorkspaceID).Info(logprefix + ": closed")
+	}()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if listenerCtx.Err() != nil {
+				return
+			}
+			if err != nil {
+				logrus.WithError(err).WithField("workspace", ws.WorkspaceID).Warn(logprefix + ": failed to accept connection")
+				return
+			}
+			go func() {
+				defer conn.Close()
+				ch, _, err := openTunnelChannel(listenerCtx, ws, &supervisor.TunnelPortRequest{
+					Protocol:   supervisor.TunnelProtocol_unix,
+					SocketPath: fwd.Socket,
+				})
+				if err != nil {
+					logrus.WithError(err).WithField("workspace", ws.WorkspaceID).Warn(logprefix + ": failed to establish tunnel")
+					return
+				}
+				defer ch.Close()
+				pipe(listenerCtx, conn, ch)
+			}()
+		}
+	}()
+
+	return &TunnelListener{
+		LocalAddr:       fwd.LocalSocket,
+		Protocol:        supervisor.TunnelProtocol_unix,
+		SocketPath:      fwd.Socket,
+		LocalSocketPath: fwd.LocalSocket,
+		Ctx:             listenerCtx,
+		Cancel: func() {
+			cancel()
+			l.Close()
+		},
+		pinned: true,
+	}, nil
+}
+
+// establishReverseSocketTunnel asks the workspace to listen on a Unix domain socket and to tunnel connections to
+// a socket on this machine. Callers must have added the socket to ws.reverseSockets.
+func (b *Bastion) establishReverseSocketTunnel(ctx context.Context, ws *Workspace, fwd *SocketForward) (*TunnelListener, error) {
+	if !ws.tunnelClientConnected {
+		return nil, xerrors.Errorf("tunnel client is not connected")
+	}
+	client, err := currentTunnelClient(ctx, ws)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(&supervisor.TunnelPortRequest{
+		ClientId:         client.ID,
+		Protocol:         supervisor.TunnelProtocol_unix,
+		SocketPath:       fwd.Socket,
+		TargetSocketPath: fwd.LocalSocket,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ok, _, err := client.Conn.SendRequest(reverseTunnelRequest, true, payload)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, xerrors.Errorf("workspace cannot listen on %s", fwd.Socket)
+	}
+	logrus.WithField("workspace", ws.WorkspaceID).Info("tunnel[unix:" + fwd.Socket + "]: forwarding to " + fwd.LocalSocket)
+
+	listenerCtx, cancel := context.WithCancel(ctx)
+	return &TunnelListener{
+		LocalAddr:       fwd.LocalSocket,
+		Protocol:        supervisor.TunnelProtocol_unix,
+		SocketPath:      fwd.Socket,
+		LocalSocketPath: fwd.LocalSocket,
+		Reverse:         true,
+		clientID:        client.ID,
+		Ctx:             listenerCtx,
+		Cancel: func() {
+			cancel()
+			go func() {
+				_, _, _ = client.Conn.SendRequest(cancelReverseTunnelRequest, false, payload)
+			}()
+		},
+		pinned: true,
+	}, nil
+}
+
+// handleReverseTunnel forwards a connection the workspace tunnels to us to a local Unix domain socket.
+// We only forward to sockets we asked the workspace to tunnel.
+func handleReverseTunnel(ctx context.Context, ws *Workspace, newCh ssh.NewChannel) {
+	req := &supervisor.TunnelPortRequest{}
+	err := proto.Unmarshal(newCh.ExtraData(), req)
+	if err != nil || req.Protocol != supervisor.TunnelProtocol_unix {
+		_ = newCh.Reject(ssh.Prohibited, "tunnel: unsupported reverse tunnel")
+		return
+	}
+	ws.tunnelMu.RLock()
+	localSocket, ok := ws.reverseSockets[req.SocketPath]
+	ws.tunnelMu.RUnlock()
+	if !ok {
+		_ = newCh.Reject(ssh.Prohibited, "tunnel: socket is not forwarded")
+		return
+	}
+
+	conn, err := net.Dial("unix", localSocket)
+	if err != nil {
+		_ = newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	defer conn.Close()
+	ch, reqs, err := newCh.Accept()
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+	go ssh.DiscardRequests(reqs)
+	pipe(ctx, conn, ch)
+}
+
+// removeStaleSocket removes a socket file left behind by an earlier run. We never remove other files.
+func removeStaleSocket(fn string) {
+	stat, err := os.Lstat(fn)
+	if err != nil || stat.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	_ = os.Remove(fn)
+}
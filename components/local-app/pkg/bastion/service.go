@@ -70,7 +70,7 @@ func (s *LocalAppService) ResolveSSHConnection(ctx context.Context, req *api.Res
 	if ws == nil || ws.InstanceID != req.InstanceId {
 		return nil, status.Error(codes.NotFound, "workspace not found")
 	}
-	if ws.localSSHListener == nil {
+	if ws.localSSHListener == nil && ws.SSHGatewayHost == "" {
 		return nil, status.Error(codes.NotFound, "workspace ssh tunnel not configured")
 	}
 	return &api.ResolveSSHConnectionResponse{
@@ -6,11 +6,13 @@ package cmd
 
 import (
 	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/public-api-server/pkg/proxy"
 	"github.com/gitpod-io/gitpod/public-api-server/pkg/server"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"net/url"
+	"time"
 )
 
 func init() {
@@ -19,9 +21,11 @@ func init() {
 
 func run() *cobra.Command {
 	var (
-		gitpodAPIURL string
-		grpcPort     int
-		verbose      bool
+		gitpodAPIURL   string
+		grpcPort       int
+		httpPort       int
+		verbose        bool
+		connectionPool proxy.ConnectionPoolConfig
 	)
 
 	cmd := &cobra.Command{
@@ -40,8 +44,10 @@ func run() *cobra.Command {
 			}
 
 			if err := server.Start(logger, server.Config{
-				GitpodAPI: gitpodAPI,
-				GRPCPort:  grpcPort,
+				GitpodAPI:      gitpodAPI,
+				GRPCPort:       grpcPort,
+				HTTPPort:       httpPort,
+				ConnectionPool: connectionPool,
 			}); err != nil {
 				logger.WithError(err).Fatal("Server errored.")
 			}
@@ -50,6 +56,11 @@ func run() *cobra.Command {
 
 	cmd.Flags().StringVar(&gitpodAPIURL, "gitpod-api-url", "wss://main.preview.gitpod-dev.com/api/v1", "URL for existing Gitpod Websocket API")
 	cmd.Flags().IntVar(&grpcPort, "grpc-port", 9001, "Port for serving gRPC traffic")
+	cmd.Flags().IntVar(&httpPort, "http-port", 9002, "Port for serving REST/JSON traffic")
+	cmd.Flags().IntVar(&connectionPool.MaxConnections, "max-server-connections", 1000, "Maximum number of tokens we keep a connection to server for")
+	cmd.Flags().DurationVar(&connectionPool.IdleTimeout, "server-connection-idle-timeout", 5*time.Minute, "Time after which idle connections to server are closed")
+	cmd.Flags().IntVar(&connectionPool.MaxConcurrentRequestsPerToken, "max-concurrent-requests-per-token", 10, "Maximum number of concurrent unary requests per token")
+	cmd.Flags().IntVar(&connectionPool.MaxConcurrentStreamsPerToken, "max-concurrent-streams-per-token", 10, "Maximum number of concurrent streams per token")
 	cmd.Flags().BoolVar(&verbose, "verbose", false, "Toggle verbose logging (debug level)")
 
 	return cmd
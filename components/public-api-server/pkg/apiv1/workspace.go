@@ -6,12 +6,18 @@ package apiv1
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
 	"github.com/gitpod-io/gitpod/public-api-server/pkg/proxy"
 	v1 "github.com/gitpod-io/gitpod/public-api/v1"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func NewWorkspaceService(serverConnPool proxy.ServerConnectionPool) *WorkspaceService {
@@ -29,17 +35,11 @@ type WorkspaceService struct {
 
 func (w *WorkspaceService) GetWorkspace(ctx context.Context, r *v1.GetWorkspaceRequest) (*v1.GetWorkspaceResponse, error) {
 	logger := ctxlogrus.Extract(ctx)
-	token, err := bearerTokenFromContext(ctx)
+	server, err := w.connect(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	server, err := w.connectionPool.Get(ctx, token)
-	if err != nil {
-		logger.WithError(err).Error("Failed to get connection to server.")
-		return nil, status.Error(codes.Internal, "failed to establish connection to downstream services")
-	}
-
 	workspace, err := server.GetWorkspace(ctx, r.GetWorkspaceId())
 	if err != nil {
 		logger.WithError(err).Error("Failed to get workspace.")
@@ -55,35 +55,17 @@ func (w *WorkspaceService) GetWorkspace(ctx context.Context, r *v1.GetWorkspaceR
 	}
 
 	return &v1.GetWorkspaceResponse{
-		Result: &v1.Workspace{
-			WorkspaceId: workspace.Workspace.ID,
-			OwnerId:     workspace.Workspace.OwnerID,
-			ProjectId:   "",
-			Context: &v1.WorkspaceContext{
-				ContextUrl: workspace.Workspace.ContextURL,
-				Details: &v1.WorkspaceContext_Git_{Git: &v1.WorkspaceContext_Git{
-					NormalizedContextUrl: workspace.Workspace.ContextURL,
-					Commit:               "",
-				}},
-			},
-			Description: workspace.Workspace.Description,
-		},
+		Result: convertWorkspace(workspace.Workspace),
 	}, nil
 }
 
 func (w *WorkspaceService) GetOwnerToken(ctx context.Context, r *v1.GetOwnerTokenRequest) (*v1.GetOwnerTokenResponse, error) {
 	logger := ctxlogrus.Extract(ctx)
-	token, err := bearerTokenFromContext(ctx)
+	server, err := w.connect(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	server, err := w.connectionPool.Get(ctx, token)
-	if err != nil {
-		logger.WithError(err).Error("Failed to get connection to server.")
-		return nil, status.Error(codes.Internal, "failed to establish connection to downstream services")
-	}
-
 	ownerToken, err := server.GetOwnerToken(ctx, r.GetWorkspaceId())
 
 	if err != nil {
@@ -102,6 +84,474 @@ func (w *WorkspaceService) GetOwnerToken(ctx context.Context, r *v1.GetOwnerToke
 	return &v1.GetOwnerTokenResponse{Token: ownerToken}, nil
 }
 
+func (w *WorkspaceService) ListWorkspaces(ctx context.Context, r *v1.ListWorkspacesRequest) (*v1.ListWorkspacesResponse, error) {
+	logger := ctxlogrus.Extract(ctx)
+	offset, pageSize, err := parsePagination(r.GetPagination())
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := w.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// we fetch one workspace more than requested to find out if there is a next page
+	workspaces, err := server.GetWorkspaces(ctx, &gitpod.GetWorkspacesOptions{
+		Limit:  float64(pageSize + 1),
+		Offset: float64(offset),
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to list workspaces.")
+		return nil, convertServerError(err, "list workspaces")
+	}
+
+	var nextPageToken string
+	if len(workspaces) > pageSize {
+		workspaces = workspaces[:pageSize]
+		if next := offset + pageSize; next <= maxPageOffset {
+			nextPageToken = strconv.Itoa(next)
+		}
+	}
+
+	res := make([]*v1.ListWorkspacesResponse_WorkspaceAndInstance, 0, len(workspaces))
+	for _, ws := range workspaces {
+		if ws.Workspace == nil {
+			continue
+		}
+		res = append(res, &v1.ListWorkspacesResponse_WorkspaceAndInstance{
+			Result:              convertWorkspace(ws.Workspace),
+			LastActiveInstances: convertWorkspaceInstance(ws.LatestInstance, ws.Workspace),
+		})
+	}
+	return &v1.ListWorkspacesResponse{
+		NextPageToken: nextPageToken,
+		Result:        res,
+	}, nil
+}
+
+func (w *WorkspaceService) CreateAndStartWorkspace(ctx context.Context, r *v1.CreateAndStartWorkspaceRequest) (*v1.CreateAndStartWorkspaceResponse, error) {
+	logger := ctxlogrus.Extract(ctx)
+	var contextURL string
+	switch source := r.GetSource().(type) {
+	case *v1.CreateAndStartWorkspaceRequest_ContextUrl:
+		contextURL = source.ContextUrl
+	case *v1.CreateAndStartWorkspaceRequest_PrebuildId:
+		return nil, status.Error(codes.Unimplemented, "creating workspaces from a prebuild ID is not supported yet")
+	}
+	if contextURL == "" {
+		return nil, status.Error(codes.InvalidArgument, "context_url is required")
+	}
+
+	server, err := w.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := server.CreateWorkspace(ctx, &gitpod.CreateWorkspaceOptions{
+		ContextURL: contextURL,
+		// we must not select a running workspace or wait for a running prebuild
+		Mode: createWorkspaceModeForceNew,
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to create workspace.")
+		return nil, convertServerError(err, "create workspace")
+	}
+	if result.CreatedWorkspaceID == "" {
+		logger.WithField("result", result).Error("Server did not create a workspace.")
+		return nil, status.Error(codes.Internal, "unable to create workspace")
+	}
+
+	return &v1.CreateAndStartWorkspaceResponse{WorkspaceId: result.CreatedWorkspaceID}, nil
+}
+
+func (w *WorkspaceService) StartWorkspace(ctx context.Context, r *v1.StartWorkspaceRequest) (*v1.StartWorkspaceResponse, error) {
+	logger := ctxlogrus.Extract(ctx)
+	server, err := w.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := server.StartWorkspace(ctx, r.GetWorkspaceId(), &gitpod.StartWorkspaceOptions{})
+	if err != nil {
+		logger.WithError(err).Error("Failed to start workspace.")
+		return nil, convertServerError(err, "start workspace")
+	}
+
+	return &v1.StartWorkspaceResponse{
+		InstanceId:   result.InstanceID,
+		WorkspaceUrl: result.WorkspaceURL,
+	}, nil
+}
+
+func (w *WorkspaceService) StopWorkspace(r *v1.StopWorkspaceRequest, stream v1.WorkspacesService_StopWorkspaceServer) error {
+	ctx := stream.Context()
+	logger := ctxlogrus.Extract(ctx)
+	server, err := w.connect(proxy.WithStream(ctx))
+	if err != nil {
+		return err
+	}
+
+	workspace, err := server.GetWorkspace(ctx, r.GetWorkspaceId())
+	if err != nil {
+		logger.WithError(err).Error("Failed to get workspace.")
+		return convertServerError(err, "stop workspace")
+	}
+	instance := workspace.LatestInstance
+	if instance == nil || instance.Status == nil || instance.Status.Phase == "stopping" || instance.Status.Phase == "stopped" {
+		return status.Error(codes.FailedPrecondition, "workspace has no running instance")
+	}
+
+	err = server.StopWorkspace(ctx, r.GetWorkspaceId())
+	if err != nil {
+		logger.WithError(err).Error("Failed to stop workspace.")
+		return convertServerError(err, "stop workspace")
+	}
+
+	return stream.Send(&v1.StopWorkspaceResponse{})
+}
+
+func (w *WorkspaceService) ListenToWorkspaceInstance(r *v1.ListenToWorkspaceInstanceRequest, stream v1.WorkspacesService_ListenToWorkspaceInstanceServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	logger := ctxlogrus.Extract(ctx)
+	server, err := w.connect(proxy.WithStream(ctx))
+	if err != nil {
+		return err
+	}
+
+	// we subscribe before we look up the instance so that we don't miss updates in between
+	updates, err := server.InstanceUpdates(ctx, r.GetInstanceId())
+	if err != nil {
+		logger.WithError(err).Error("Failed to listen to instance updates.")
+		return convertServerError(err, "listen to workspace instance")
+	}
+	instance, workspace, err := getWorkspaceInstance(ctx, server, r.GetInstanceId())
+	if err != nil {
+		logger.WithError(err).Error("Failed to find workspace instance.")
+		return err
+	}
+
+	for {
+		err = stream.Send(&v1.ListenToWorkspaceInstanceResponse{
+			InstanceStatus: convertWorkspaceInstance(instance, workspace).GetStatus(),
+		})
+		if err != nil {
+			return err
+		}
+		if instance.Status != nil && instance.Status.Phase == "stopped" {
+			return nil
+		}
+
+		var ok bool
+		instance, ok = <-updates
+		if !ok {
+			return ctx.Err()
+		}
+	}
+}
+
+func (w *WorkspaceService) ListenToImageBuildLogs(r *v1.ListenToImageBuildLogsRequest, stream v1.WorkspacesService_ListenToImageBuildLogsServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	logger := ctxlogrus.Extract(ctx)
+	server, err := w.connect(proxy.WithStream(ctx))
+	if err != nil {
+		return err
+	}
+
+	_, workspace, err := getWorkspaceInstance(ctx, server, r.GetInstanceId())
+	if err != nil {
+		logger.WithError(err).Error("Failed to find workspace instance.")
+		return err
+	}
+
+	logs, err := server.ImageBuildLogs(ctx, r.GetInstanceId())
+	if err != nil {
+		logger.WithError(err).Error("Failed to listen to image build logs.")
+		return convertServerError(err, "listen to image build logs")
+	}
+	// the server streams the logs while it serves the watch request
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- server.WatchWorkspaceImageBuildLogs(ctx, workspace.ID)
+	}()
+
+	var lines logLineBuffer
+	send := func(l []string) error {
+		for _, line := range l {
+			err := stream.Send(&v1.ListenToImageBuildLogsResponse{Line: line})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for {
+		select {
+		case msg, ok := <-logs:
+			if !ok {
+				return imageBuildLogsClosed(ctx)
+			}
+			if msg.Content == nil {
+				continue
+			}
+			err = send(lines.Write(msg.Content))
+			if err != nil {
+				return err
+			}
+		case err := <-watchErr:
+			if err != nil {
+				logger.WithError(err).Error("Failed to watch image build logs.")
+				return convertServerError(err, "listen to image build logs")
+			}
+			// send the logs which arrived before the watch request finished
+			var remaining []string
+		drain:
+			for {
+				select {
+				case msg, ok := <-logs:
+					if !ok {
+						return imageBuildLogsClosed(ctx)
+					}
+					if msg.Content != nil {
+						remaining = append(remaining, lines.Write(msg.Content)...)
+					}
+				default:
+					break drain
+				}
+			}
+			return send(append(remaining, lines.Flush()...))
+		}
+	}
+}
+
+// imageBuildLogsClosed explains why the image build log subscription ended before the build did
+func imageBuildLogsClosed(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// the subscription ends early if we cannot keep up with the logs, rather than silently skip some of them
+	return status.Error(codes.ResourceExhausted, "image build logs arrive faster than they can be sent")
+}
+
+// connect returns a connection to the server using the credentials of the request
+func (w *WorkspaceService) connect(ctx context.Context) (gitpod.APIInterface, error) {
+	token, err := bearerTokenFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := w.connectionPool.Get(ctx, token)
+	if status.Code(err) == codes.ResourceExhausted {
+		return nil, err
+	}
+	if err != nil {
+		ctxlogrus.Extract(ctx).WithError(err).Error("Failed to get connection to server.")
+		return nil, status.Error(codes.Internal, "failed to establish connection to downstream services")
+	}
+	return server, nil
+}
+
+// convertServerError translates errors of the server into gRPC errors for the action we attempted
+func convertServerError(err error, action string) error {
+	converted := proxy.ConvertError(err)
+	switch status.Code(converted) {
+	case codes.PermissionDenied:
+		return status.Errorf(codes.PermissionDenied, "insufficient permission to %s", action)
+	case codes.NotFound:
+		return status.Error(codes.NotFound, "workspace does not exist")
+	default:
+		return status.Errorf(codes.Internal, "unable to %s", action)
+	}
+}
+
+const (
+	defaultPageSize = 25
+	maxPageSize     = 100
+	// maxPageOffset bounds page tokens, so that offsets cannot overflow and listing stays cheap for the server
+	maxPageOffset = 10000
+
+	createWorkspaceModeForceNew = "force-new"
+)
+
+// parsePagination returns the offset and page size of a request. Page tokens are offsets into the
+// workspace list of a user, ordered by recency.
+func parsePagination(p *v1.Pagination) (offset int, pageSize int, err error) {
+	pageSize = int(p.GetPageSize())
+	if pageSize < 0 {
+		return 0, 0, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	if token := p.GetPageToken(); token != "" {
+		offset, err = strconv.Atoi(token)
+		if err != nil || offset < 0 || offset > maxPageOffset {
+			return 0, 0, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+	return offset, pageSize, nil
+}
+
+// getWorkspaceInstance looks up a workspace instance and the workspace it belongs to
+func getWorkspaceInstance(ctx context.Context, server gitpod.APIInterface, instanceID string) (*gitpod.WorkspaceInstance, *gitpod.Workspace, error) {
+	instance, err := server.GetWorkspaceInstance(ctx, instanceID)
+	if err != nil {
+		if status.Code(proxy.ConvertError(err)) == codes.NotFound {
+			return nil, nil, status.Error(codes.NotFound, "workspace instance does not exist")
+		}
+		return nil, nil, convertServerError(err, "retrieve workspace instance")
+	}
+	workspace, err := server.GetWorkspace(ctx, instance.WorkspaceID)
+	if err != nil {
+		return nil, nil, convertServerError(err, "retrieve workspace instance")
+	}
+	if workspace.Workspace == nil {
+		return nil, nil, status.Error(codes.NotFound, "workspace does not exist")
+	}
+	return instance, workspace.Workspace, nil
+}
+
+func convertWorkspace(ws *gitpod.Workspace) *v1.Workspace {
+	return &v1.Workspace{
+		WorkspaceId: ws.ID,
+		OwnerId:     ws.OwnerID,
+		ProjectId:   "",
+		Context: &v1.WorkspaceContext{
+			ContextUrl: ws.ContextURL,
+			Details: &v1.WorkspaceContext_Git_{Git: &v1.WorkspaceContext_Git{
+				NormalizedContextUrl: ws.ContextURL,
+				Commit:               "",
+			}},
+		},
+		Description: ws.Description,
+	}
+}
+
+func convertWorkspaceInstance(instance *gitpod.WorkspaceInstance, ws *gitpod.Workspace) *v1.WorkspaceInstance {
+	if instance == nil {
+		return nil
+	}
+
+	res := &v1.WorkspaceInstance{
+		InstanceId:  instance.ID,
+		WorkspaceId: instance.WorkspaceID,
+		CreatedAt:   parseTimestamp(instance.CreationTime),
+		Status: &v1.WorkspaceInstanceStatus{
+			Url:        instance.IdeURL,
+			Conditions: &v1.WorkspaceInstanceStatus_Conditions{},
+		},
+	}
+	if ws != nil {
+		if ws.Shareable {
+			res.Status.Admission = v1.AdmissionLevel_ADMISSION_LEVEL_EVERYONE
+		} else {
+			res.Status.Admission = v1.AdmissionLevel_ADMISSION_LEVEL_OWNER_ONLY
+		}
+	}
+	if instance.Status == nil {
+		return res
+	}
+
+	res.Status.Phase = convertPhase(instance.Status.Phase)
+	res.Status.Message = instance.Status.Message
+	if c := instance.Status.Conditions; c != nil {
+		res.Status.Conditions = &v1.WorkspaceInstanceStatus_Conditions{
+			Failed:            c.Failed,
+			Timeout:           c.Timeout,
+			FirstUserActivity: parseTimestamp(c.FirstUserActivity),
+		}
+	}
+	return res
+}
+
+func convertPhase(phase string) v1.WorkspaceInstanceStatus_Phase {
+	switch phase {
+	case "preparing", "pending":
+		return v1.WorkspaceInstanceStatus_PHASE_PENDING
+	case "building":
+		return v1.WorkspaceInstanceStatus_PHASE_IMAGEBUILD
+	case "creating":
+		return v1.WorkspaceInstanceStatus_PHASE_CREATING
+	case "initializing":
+		return v1.WorkspaceInstanceStatus_PHASE_INITIALIZING
+	case "running":
+		return v1.WorkspaceInstanceStatus_PHASE_RUNNING
+	case "interrupted":
+		return v1.WorkspaceInstanceStatus_PHASE_INTERRUPTED
+	case "stopping":
+		return v1.WorkspaceInstanceStatus_PHASE_STOPPING
+	case "stopped":
+		return v1.WorkspaceInstanceStatus_PHASE_STOPPED
+	default:
+		return v1.WorkspaceInstanceStatus_PHASE_UNSPECIFIED
+	}
+}
+
+func parseTimestamp(s string) *timestamppb.Timestamp {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// logLineBuffer splits the log chunks the server sends into lines
+type logLineBuffer struct {
+	partial  string
+	sent     int
+	upToLine float64
+}
+
+// Write adds a chunk and returns all lines it completed. Chunks which do not advance the log are ignored, and
+// chunks which are no diff replace the log we have seen so far.
+func (b *logLineBuffer) Write(content *gitpod.WorkspaceImageBuildLogContent) []string {
+	if content.UpToLine > 0 {
+		if content.UpToLine <= b.upToLine {
+			return nil
+		}
+		b.upToLine = content.UpToLine
+	}
+
+	text := content.Text
+	if content.IsDiff {
+		text = b.partial + text
+	}
+	lines := strings.Split(text, "\n")
+	b.partial = lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+	if !content.IsDiff {
+		// the chunk repeats the lines we have sent already
+		if len(lines) <= b.sent {
+			return nil
+		}
+		lines = lines[b.sent:]
+	}
+	b.sent += len(lines)
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
+
+// Flush returns the last line even if it is incomplete
+func (b *logLineBuffer) Flush() []string {
+	if b.partial == "" {
+		return nil
+	}
+	line := strings.TrimSuffix(b.partial, "\r")
+	b.partial = ""
+	return []string{line}
+}
+
 func bearerTokenFromContext(ctx context.Context) (string, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
@@ -116,6 +566,13 @@ func bearerTokenFromContext(ctx context.Context) (string, error) {
 		return "", status.Error(codes.Unauthenticated, "more than one authorization header specified, exactly one is required")
 	}
 
+	// REST clients send the Authorization header as is, hence we accept the token with or without scheme
 	token := values[0]
+	if parts := strings.SplitN(token, " ", 2); len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+		token = strings.TrimSpace(parts[1])
+	}
+	if token == "" {
+		return "", status.Error(codes.Unauthenticated, "empty bearer token")
+	}
 	return token, nil
 }
@@ -7,6 +7,8 @@ package apiv1
 import (
 	"context"
 	"errors"
+	"io"
+	"sort"
 	"testing"
 
 	"github.com/gitpod-io/gitpod/common-go/baseserver"
@@ -184,6 +186,337 @@ func TestWorkspaceService_GetOwnerToken(t *testing.T) {
 	}
 }
 
+func TestWorkspaceService_ListWorkspaces(t *testing.T) {
+	api := &FakeGitpodAPI{workspaces: map[string]*gitpod.WorkspaceInfo{}}
+	for _, id := range []string{"ws-1", "ws-2", "ws-3"} {
+		api.workspaces[id] = &gitpod.WorkspaceInfo{
+			Workspace: &gitpod.Workspace{ID: id, ContextURL: "https://github.com/gitpod-io/gitpod"},
+			LatestInstance: &gitpod.WorkspaceInstance{
+				ID:           "instance-" + id,
+				WorkspaceID:  id,
+				CreationTime: "2022-06-01T10:00:00.000Z",
+				Status:       &gitpod.WorkspaceInstanceStatus{Phase: "running"},
+			},
+		}
+	}
+	client, ctx := newWorkspaceServiceClient(t, api)
+
+	type Expectation struct {
+		Code          codes.Code
+		WorkspaceIDs  []string
+		NextPageToken string
+	}
+	scenarios := []struct {
+		name       string
+		Pagination *v1.Pagination
+		Expect     Expectation
+	}{
+		{
+			name:   "returns all workspaces by default",
+			Expect: Expectation{WorkspaceIDs: []string{"ws-1", "ws-2", "ws-3"}},
+		},
+		{
+			name:       "returns first page",
+			Pagination: &v1.Pagination{PageSize: 2},
+			Expect:     Expectation{WorkspaceIDs: []string{"ws-1", "ws-2"}, NextPageToken: "2"},
+		},
+		{
+			name:       "returns last page",
+			Pagination: &v1.Pagination{PageSize: 2, PageToken: "2"},
+			Expect:     Expectation{WorkspaceIDs: []string{"ws-3"}},
+		},
+		{
+			name:       "returns nothing beyond the last page",
+			Pagination: &v1.Pagination{PageSize: 2, PageToken: "4"},
+			Expect:     Expectation{},
+		},
+		{
+			name:       "invalid argument for invalid page token",
+			Pagination: &v1.Pagination{PageToken: "foo"},
+			Expect:     Expectation{Code: codes.InvalidArgument},
+		},
+		{
+			name:       "invalid argument for page token beyond the maximum offset",
+			Pagination: &v1.Pagination{PageToken: "9223372036854775800"},
+			Expect:     Expectation{Code: codes.InvalidArgument},
+		},
+		{
+			name:       "invalid argument for negative page token",
+			Pagination: &v1.Pagination{PageToken: "-1"},
+			Expect:     Expectation{Code: codes.InvalidArgument},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			resp, err := client.ListWorkspaces(ctx, &v1.ListWorkspacesRequest{Pagination: scenario.Pagination})
+			act := Expectation{Code: status.Code(err), NextPageToken: resp.GetNextPageToken()}
+			for _, ws := range resp.GetResult() {
+				act.WorkspaceIDs = append(act.WorkspaceIDs, ws.GetResult().GetWorkspaceId())
+				if ws.GetLastActiveInstances().GetStatus().GetPhase() != v1.WorkspaceInstanceStatus_PHASE_RUNNING {
+					t.Errorf("unexpected instance phase for %s: %v", ws.GetResult().GetWorkspaceId(), ws.GetLastActiveInstances().GetStatus().GetPhase())
+				}
+			}
+			if diff := cmp.Diff(scenario.Expect, act); diff != "" {
+				t.Errorf("unexpected difference:\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWorkspaceService_CreateAndStartWorkspace(t *testing.T) {
+	client, ctx := newWorkspaceServiceClient(t, &FakeGitpodAPI{})
+
+	type Expectation struct {
+		Code     codes.Code
+		Response *v1.CreateAndStartWorkspaceResponse
+	}
+	scenarios := []struct {
+		name    string
+		Request *v1.CreateAndStartWorkspaceRequest
+		Expect  Expectation
+	}{
+		{
+			name:    "creates a workspace from a context URL",
+			Request: &v1.CreateAndStartWorkspaceRequest{Source: &v1.CreateAndStartWorkspaceRequest_ContextUrl{ContextUrl: "github.com/gitpod-io/gitpod"}},
+			Expect: Expectation{
+				Code:     codes.OK,
+				Response: &v1.CreateAndStartWorkspaceResponse{WorkspaceId: "created-github.com/gitpod-io/gitpod"},
+			},
+		},
+		{
+			name:    "invalid argument without source",
+			Request: &v1.CreateAndStartWorkspaceRequest{},
+			Expect:  Expectation{Code: codes.InvalidArgument},
+		},
+		{
+			name:    "unimplemented for prebuilds",
+			Request: &v1.CreateAndStartWorkspaceRequest{Source: &v1.CreateAndStartWorkspaceRequest_PrebuildId{PrebuildId: "some-prebuild"}},
+			Expect:  Expectation{Code: codes.Unimplemented},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			resp, err := client.CreateAndStartWorkspace(ctx, scenario.Request)
+			if diff := cmp.Diff(scenario.Expect, Expectation{
+				Code:     status.Code(err),
+				Response: resp,
+			}, protocmp.Transform()); diff != "" {
+				t.Errorf("unexpected difference:\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWorkspaceService_StartWorkspace(t *testing.T) {
+	const foundWorkspaceID = "easycz-seer-xl8o1zacpyw"
+	client, ctx := newWorkspaceServiceClient(t, &FakeGitpodAPI{workspaces: map[string]*gitpod.WorkspaceInfo{
+		foundWorkspaceID: {Workspace: &gitpod.Workspace{ID: foundWorkspaceID}},
+	}})
+
+	resp, err := client.StartWorkspace(ctx, &v1.StartWorkspaceRequest{WorkspaceId: foundWorkspaceID})
+	require.NoError(t, err)
+	if diff := cmp.Diff(&v1.StartWorkspaceResponse{
+		InstanceId:   "instance-" + foundWorkspaceID,
+		WorkspaceUrl: "https://" + foundWorkspaceID + ".gitpod.io",
+	}, resp, protocmp.Transform()); diff != "" {
+		t.Errorf("unexpected difference:\n%v", diff)
+	}
+
+	_, err = client.StartWorkspace(ctx, &v1.StartWorkspaceRequest{WorkspaceId: "some-not-found-workspace-id"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestWorkspaceService_StopWorkspace(t *testing.T) {
+	api := &FakeGitpodAPI{workspaces: map[string]*gitpod.WorkspaceInfo{
+		"running-ws": {
+			Workspace:      &gitpod.Workspace{ID: "running-ws"},
+			LatestInstance: &gitpod.WorkspaceInstance{Status: &gitpod.WorkspaceInstanceStatus{Phase: "running"}},
+		},
+		"stopped-ws": {
+			Workspace:      &gitpod.Workspace{ID: "stopped-ws"},
+			LatestInstance: &gitpod.WorkspaceInstance{Status: &gitpod.WorkspaceInstanceStatus{Phase: "stopped"}},
+		},
+	}}
+	client, ctx := newWorkspaceServiceClient(t, api)
+
+	scenarios := []struct {
+		name        string
+		WorkspaceID string
+		Expect      codes.Code
+	}{
+		{name: "stops a running workspace", WorkspaceID: "running-ws", Expect: codes.OK},
+		{name: "failed precondition for stopped workspace", WorkspaceID: "stopped-ws", Expect: codes.FailedPrecondition},
+		{name: "not found when workspace is not found by ID", WorkspaceID: "some-not-found-workspace-id", Expect: codes.NotFound},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			stream, err := client.StopWorkspace(ctx, &v1.StopWorkspaceRequest{WorkspaceId: scenario.WorkspaceID})
+			require.NoError(t, err)
+			for err == nil {
+				_, err = stream.Recv()
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			require.Equal(t, scenario.Expect, status.Code(err))
+		})
+	}
+	require.Equal(t, []string{"running-ws"}, api.stopped)
+}
+
+func TestWorkspaceService_ListenToWorkspaceInstance(t *testing.T) {
+	api := &FakeGitpodAPI{
+		workspaces: map[string]*gitpod.WorkspaceInfo{
+			"some-ws": {
+				Workspace: &gitpod.Workspace{ID: "some-ws"},
+				LatestInstance: &gitpod.WorkspaceInstance{
+					ID:          "some-instance",
+					WorkspaceID: "some-ws",
+					Status:      &gitpod.WorkspaceInstanceStatus{Phase: "creating"},
+				},
+			},
+		},
+		instanceUpdates: []*gitpod.WorkspaceInstance{
+			{ID: "some-instance", IdeURL: "https://some-ws.gitpod.io", Status: &gitpod.WorkspaceInstanceStatus{Phase: "running"}},
+			{ID: "some-instance", Status: &gitpod.WorkspaceInstanceStatus{Phase: "stopped", Conditions: &gitpod.WorkspaceInstanceConditions{Timeout: "timed out"}}},
+		},
+	}
+	client, ctx := newWorkspaceServiceClient(t, api)
+
+	stream, err := client.ListenToWorkspaceInstance(ctx, &v1.ListenToWorkspaceInstanceRequest{InstanceId: "some-instance"})
+	require.NoError(t, err)
+	var act []*v1.WorkspaceInstanceStatus
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		act = append(act, resp.GetInstanceStatus())
+	}
+
+	conditions := &v1.WorkspaceInstanceStatus_Conditions{}
+	expectation := []*v1.WorkspaceInstanceStatus{
+		{Phase: v1.WorkspaceInstanceStatus_PHASE_CREATING, Admission: v1.AdmissionLevel_ADMISSION_LEVEL_OWNER_ONLY, Conditions: conditions},
+		{Phase: v1.WorkspaceInstanceStatus_PHASE_RUNNING, Admission: v1.AdmissionLevel_ADMISSION_LEVEL_OWNER_ONLY, Conditions: conditions, Url: "https://some-ws.gitpod.io"},
+		{Phase: v1.WorkspaceInstanceStatus_PHASE_STOPPED, Admission: v1.AdmissionLevel_ADMISSION_LEVEL_OWNER_ONLY, Conditions: &v1.WorkspaceInstanceStatus_Conditions{Timeout: "timed out"}},
+	}
+	if diff := cmp.Diff(expectation, act, protocmp.Transform()); diff != "" {
+		t.Errorf("unexpected difference:\n%v", diff)
+	}
+
+	stream, err = client.ListenToWorkspaceInstance(ctx, &v1.ListenToWorkspaceInstanceRequest{InstanceId: "some-not-found-instance"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestWorkspaceService_ListenToImageBuildLogs(t *testing.T) {
+	api := &FakeGitpodAPI{
+		workspaces: map[string]*gitpod.WorkspaceInfo{
+			"some-ws": {
+				Workspace:      &gitpod.Workspace{ID: "some-ws"},
+				LatestInstance: &gitpod.WorkspaceInstance{ID: "some-instance", WorkspaceID: "some-ws", Status: &gitpod.WorkspaceInstanceStatus{Phase: "building"}},
+			},
+		},
+		imageBuildLogs: []string{"Step 1/2 : FROM gitpod/workspace-full\r\nStep 2/2", " : RUN echo hello\r\n", "Successfully built"},
+	}
+	client, ctx := newWorkspaceServiceClient(t, api)
+
+	stream, err := client.ListenToImageBuildLogs(ctx, &v1.ListenToImageBuildLogsRequest{InstanceId: "some-instance"})
+	require.NoError(t, err)
+	var act []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		act = append(act, resp.GetLine())
+	}
+
+	expectation := []string{"Step 1/2 : FROM gitpod/workspace-full", "Step 2/2 : RUN echo hello", "Successfully built"}
+	if diff := cmp.Diff(expectation, act); diff != "" {
+		t.Errorf("unexpected difference:\n%v", diff)
+	}
+}
+
+func TestLogLineBuffer(t *testing.T) {
+	type Chunk = gitpod.WorkspaceImageBuildLogContent
+	tests := []struct {
+		Name        string
+		Chunks      []Chunk
+		Expectation []string
+	}{
+		{
+			Name: "diffs",
+			Chunks: []Chunk{
+				{Text: "line 1\r\nli", IsDiff: true, UpToLine: 2},
+				{Text: "ne 2\r\n", IsDiff: true, UpToLine: 3},
+			},
+			Expectation: []string{"line 1", "line 2"},
+		},
+		{
+			Name: "repeated diff",
+			Chunks: []Chunk{
+				{Text: "line 1\n", IsDiff: true, UpToLine: 2},
+				{Text: "line 1\n", IsDiff: true, UpToLine: 2},
+				{Text: "line 2\n", IsDiff: true, UpToLine: 3},
+			},
+			Expectation: []string{"line 1", "line 2"},
+		},
+		{
+			Name: "snapshots",
+			Chunks: []Chunk{
+				{Text: "line 1\nline", UpToLine: 2},
+				{Text: "line 1\nline 2\nline 3\n", UpToLine: 4},
+				{Text: "line 4\n", IsDiff: true, UpToLine: 5},
+			},
+			Expectation: []string{"line 1", "line 2", "line 3", "line 4"},
+		},
+		{
+			Name: "snapshot after diffs",
+			Chunks: []Chunk{
+				{Text: "line 1\n", IsDiff: true},
+				{Text: "line 1\nline 2\n"},
+			},
+			Expectation: []string{"line 1", "line 2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				b   logLineBuffer
+				act []string
+			)
+			for i := range test.Chunks {
+				act = append(act, b.Write(&test.Chunks[i])...)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected lines (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func newWorkspaceServiceClient(t *testing.T, api gitpod.APIInterface) (v1.WorkspacesServiceClient, context.Context) {
+	t.Helper()
+
+	srv := baseserver.NewForTests(t,
+		baseserver.WithGRPC(baseserver.MustUseRandomLocalAddress(t)),
+	)
+	v1.RegisterWorkspacesServiceServer(srv.GRPC(), NewWorkspaceService(&FakeServerConnPool{api: api}))
+	baseserver.StartServerForTests(t, srv)
+
+	conn, err := grpc.Dial(srv.GRPCAddress(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	return v1.NewWorkspacesServiceClient(conn), metadata.AppendToOutgoingContext(context.Background(), "authorization", "bearer-token-for-tests")
+}
+
 type FakeServerConnPool struct {
 	api gitpod.APIInterface
 }
@@ -195,6 +528,11 @@ func (f *FakeServerConnPool) Get(ctx context.Context, token string) (gitpod.APII
 type FakeGitpodAPI struct {
 	workspaces  map[string]*gitpod.WorkspaceInfo
 	ownertokens map[string]string
+
+	instanceUpdates []*gitpod.WorkspaceInstance
+	imageBuildLogs  []string
+	logs            chan *gitpod.WorkspaceImageBuildLogs
+	stopped         []string
 }
 
 func (f *FakeGitpodAPI) GetWorkspace(ctx context.Context, id string) (res *gitpod.WorkspaceInfo, err error) {
@@ -206,6 +544,15 @@ func (f *FakeGitpodAPI) GetWorkspace(ctx context.Context, id string) (res *gitpo
 	return w, nil
 }
 
+func (f *FakeGitpodAPI) GetWorkspaceInstance(ctx context.Context, instanceID string) (res *gitpod.WorkspaceInstance, err error) {
+	for _, w := range f.workspaces {
+		if w.LatestInstance != nil && w.LatestInstance.ID == instanceID {
+			return w.LatestInstance, nil
+		}
+	}
+	return nil, errors.New("code 404")
+}
+
 func (f *FakeGitpodAPI) GetOwnerToken(ctx context.Context, workspaceID string) (res string, err error) {
 	w, ok := f.ownertokens[workspaceID]
 	if !ok {
@@ -271,7 +618,20 @@ func (f *FakeGitpodAPI) HasPermission(ctx context.Context, permission *gitpod.Pe
 }
 
 func (f *FakeGitpodAPI) GetWorkspaces(ctx context.Context, options *gitpod.GetWorkspacesOptions) (res []*gitpod.WorkspaceInfo, err error) {
-	panic("implement me")
+	for _, ws := range f.workspaces {
+		res = append(res, ws)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Workspace.ID < res[j].Workspace.ID })
+	if options != nil && int(options.Offset) >= len(res) {
+		return nil, nil
+	}
+	if options != nil {
+		res = res[int(options.Offset):]
+	}
+	if options != nil && options.Limit > 0 && len(res) > int(options.Limit) {
+		res = res[:int(options.Limit)]
+	}
+	return res, nil
 }
 
 func (f *FakeGitpodAPI) GetWorkspaceOwner(ctx context.Context, workspaceID string) (res *gitpod.UserInfo, err error) {
@@ -291,15 +651,25 @@ func (f *FakeGitpodAPI) IsWorkspaceOwner(ctx context.Context, workspaceID string
 }
 
 func (f *FakeGitpodAPI) CreateWorkspace(ctx context.Context, options *gitpod.CreateWorkspaceOptions) (res *gitpod.WorkspaceCreationResult, err error) {
-	panic("implement me")
+	if options.Mode != "force-new" {
+		return nil, errors.New("code 400")
+	}
+	return &gitpod.WorkspaceCreationResult{CreatedWorkspaceID: "created-" + options.ContextURL}, nil
 }
 
 func (f *FakeGitpodAPI) StartWorkspace(ctx context.Context, id string, options *gitpod.StartWorkspaceOptions) (res *gitpod.StartWorkspaceResult, err error) {
-	panic("implement me")
+	if _, ok := f.workspaces[id]; !ok {
+		return nil, errors.New("code 404")
+	}
+	return &gitpod.StartWorkspaceResult{InstanceID: "instance-" + id, WorkspaceURL: "https://" + id + ".gitpod.io"}, nil
 }
 
 func (f *FakeGitpodAPI) StopWorkspace(ctx context.Context, id string) (err error) {
-	panic("implement me")
+	if _, ok := f.workspaces[id]; !ok {
+		return errors.New("code 404")
+	}
+	f.stopped = append(f.stopped, id)
+	return nil
 }
 
 func (f *FakeGitpodAPI) DeleteWorkspace(ctx context.Context, id string) (err error) {
@@ -323,7 +693,16 @@ func (f *FakeGitpodAPI) SendHeartBeat(ctx context.Context, options *gitpod.SendH
 }
 
 func (f *FakeGitpodAPI) WatchWorkspaceImageBuildLogs(ctx context.Context, workspaceID string) (err error) {
-	panic("implement me")
+	instance := f.workspaces[workspaceID].LatestInstance
+	for i, chunk := range f.imageBuildLogs {
+		f.logs <- &gitpod.WorkspaceImageBuildLogs{Content: &gitpod.WorkspaceImageBuildLogContent{
+			Text:       chunk,
+			IsDiff:     true,
+			UpToLine:   float64(i + 1),
+			InstanceID: instance.ID,
+		}}
+	}
+	return nil
 }
 
 func (f *FakeGitpodAPI) IsPrebuildDone(ctx context.Context, pwsid string) (res bool, err error) {
@@ -427,5 +806,38 @@ func (f *FakeGitpodAPI) TrackEvent(ctx context.Context, event *gitpod.RemoteTrac
 }
 
 func (f *FakeGitpodAPI) InstanceUpdates(ctx context.Context, instanceID string) (<-chan *gitpod.WorkspaceInstance, error) {
-	panic("implement me")
+	res := make(chan *gitpod.WorkspaceInstance, len(f.instanceUpdates))
+	for _, update := range f.instanceUpdates {
+		res <- update
+	}
+	return res, nil
+}
+
+func (f *FakeGitpodAPI) ImageBuildLogs(ctx context.Context, instanceID string) (<-chan *gitpod.WorkspaceImageBuildLogs, error) {
+	f.logs = make(chan *gitpod.WorkspaceImageBuildLogs, len(f.imageBuildLogs))
+	return f.logs, nil
+}
+
+func TestBearerTokenFromContext(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		md       metadata.MD
+		expected string
+		code     codes.Code
+	}{
+		{name: "raw token", md: metadata.Pairs("authorization", "some-token"), expected: "some-token"},
+		{name: "bearer scheme", md: metadata.Pairs("authorization", "Bearer some-token"), expected: "some-token"},
+		{name: "lowercase bearer scheme", md: metadata.Pairs("authorization", "bearer some-token"), expected: "some-token"},
+		{name: "no authorization header", md: metadata.MD{}, code: codes.Unauthenticated},
+		{name: "empty bearer token", md: metadata.Pairs("authorization", "Bearer "), code: codes.Unauthenticated},
+		{name: "multiple authorization headers", md: metadata.Pairs("authorization", "a", "authorization", "b"), code: codes.Unauthenticated},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			token, err := bearerTokenFromContext(metadata.NewIncomingContext(context.Background(), s.md))
+			require.Equal(t, s.code, status.Code(err))
+			require.Equal(t, s.expected, token)
+		})
+	}
 }
@@ -0,0 +1,71 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v1 "github.com/gitpod-io/gitpod/public-api/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// APIPrefix is the path prefix of all REST endpoints
+	APIPrefix = "/v1/"
+	// OpenAPIPath is the path under which we serve the OpenAPI document of the REST API
+	OpenAPIPath = "/openapi.json"
+)
+
+// Register serves the REST/JSON API of the WorkspacesService and PrebuildsService on mux.
+// Requests are transcoded to gRPC and sent to grpcEndpoint, which is expected to be our own gRPC server.
+// The Authorization header is forwarded as is, hence the REST API uses the same bearer token auth as the gRPC API.
+func Register(ctx context.Context, mux *http.ServeMux, grpcEndpoint string) error {
+	gw := NewServeMux()
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if err := v1.RegisterWorkspacesServiceHandlerFromEndpoint(ctx, gw, grpcEndpoint, opts); err != nil {
+		return fmt.Errorf("failed to register workspaces service gateway: %w", err)
+	}
+	if err := v1.RegisterPrebuildsServiceHandlerFromEndpoint(ctx, gw, grpcEndpoint, opts); err != nil {
+		return fmt.Errorf("failed to register prebuilds service gateway: %w", err)
+	}
+
+	mux.Handle(APIPrefix, gw)
+	mux.HandleFunc(OpenAPIPath, serveOpenAPIDocument)
+	return nil
+}
+
+// NewServeMux produces a gateway mux which renders JSON by default, and server-sent events
+// for clients which accept text/event-stream.
+func NewServeMux() *runtime.ServeMux {
+	jsonpb := runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{
+			EmitUnpopulated: true,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true,
+		},
+	}
+	return runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.HTTPBodyMarshaler{Marshaler: &jsonpb}),
+		runtime.WithMarshalerOption(MIMEEventStream, &SSEMarshaler{JSONPb: jsonpb}),
+	)
+}
+
+func serveOpenAPIDocument(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(v1.OpenAPIDocument)
+}
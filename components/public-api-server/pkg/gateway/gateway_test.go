@@ -0,0 +1,123 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gitpod-io/gitpod/common-go/baseserver"
+	v1 "github.com/gitpod-io/gitpod/public-api/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakePrebuildsService struct {
+	v1.UnimplementedPrebuildsServiceServer
+
+	mu            sync.Mutex
+	authorization []string
+}
+
+func (f *fakePrebuildsService) recordAuthorization(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authorization = append(f.authorization, md.Get("authorization")...)
+}
+
+func (f *fakePrebuildsService) GetPrebuild(ctx context.Context, req *v1.GetPrebuildRequest) (*v1.GetPrebuildResponse, error) {
+	f.recordAuthorization(ctx)
+	if req.PrebuildId == "unknown" {
+		return nil, status.Error(codes.NotFound, "prebuild not found")
+	}
+	return &v1.GetPrebuildResponse{Prebuild: &v1.Prebuild{PrebuildId: req.PrebuildId}}, nil
+}
+
+func (f *fakePrebuildsService) ListenToPrebuildLogs(req *v1.ListenToPrebuildLogsRequest, srv v1.PrebuildsService_ListenToPrebuildLogsServer) error {
+	f.recordAuthorization(srv.Context())
+	for _, line := range []string{"first", "second"} {
+		if err := srv.Send(&v1.ListenToPrebuildLogsResponse{Line: line}); err != nil {
+			return err
+		}
+	}
+	return status.Error(codes.Aborted, "prebuild stopped")
+}
+
+func TestGateway(t *testing.T) {
+	srv := baseserver.NewForTests(t,
+		baseserver.WithGRPC(baseserver.MustUseRandomLocalAddress(t)),
+		baseserver.WithHTTP(baseserver.MustUseRandomLocalAddress(t)),
+	)
+	prebuilds := &fakePrebuildsService{}
+	v1.RegisterPrebuildsServiceServer(srv.GRPC(), prebuilds)
+	require.NoError(t, Register(context.Background(), srv.HTTPMux(), srv.GRPCAddress()))
+	baseserver.StartServerForTests(t, srv)
+
+	get := func(t *testing.T, path string, accept string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.HTTPAddress()+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer some-token")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		resp, body := get(t, "/v1/prebuilds/123", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `"prebuildId":"123"`)
+	})
+
+	t.Run("unary error", func(t *testing.T) {
+		resp, body := get(t, "/v1/prebuilds/unknown", "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Contains(t, body, "prebuild not found")
+	})
+
+	t.Run("unimplemented", func(t *testing.T) {
+		resp, _ := get(t, "/v1/prebuilds:running?contextUrl=https://github.com/gitpod-io/gitpod", "")
+		require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	})
+
+	t.Run("server-sent events", func(t *testing.T) {
+		resp, body := get(t, "/v1/prebuilds/123/logs", MIMEEventStream)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, MIMEEventStream, resp.Header.Get("Content-Type"))
+
+		events := strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n")
+		require.Len(t, events, 3)
+		require.Equal(t, `data: {"line":"first"}`, events[0])
+		require.Equal(t, `data: {"line":"second"}`, events[1])
+		require.True(t, strings.HasPrefix(events[2], "event: error\ndata: "), "unexpected error event: %q", events[2])
+		require.Contains(t, events[2], "prebuild stopped")
+	})
+
+	t.Run("openapi document", func(t *testing.T) {
+		resp, body := get(t, OpenAPIPath, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		require.Contains(t, body, `"/v1/workspaces/{workspaceId}"`)
+	})
+
+	prebuilds.mu.Lock()
+	defer prebuilds.mu.Unlock()
+	require.NotEmpty(t, prebuilds.authorization)
+	for _, authorization := range prebuilds.authorization {
+		require.Equal(t, "Bearer some-token", authorization)
+	}
+}
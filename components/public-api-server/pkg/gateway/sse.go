@@ -0,0 +1,71 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package gateway
+
+import (
+	"bytes"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/proto"
+)
+
+// MIMEEventStream is the content type clients accept to receive streaming responses as server-sent events
+const MIMEEventStream = "text/event-stream"
+
+// SSEMarshaler renders responses as server-sent events. Every message of a stream becomes a single event,
+// errors which occur once the stream has started are sent as an `error` event.
+type SSEMarshaler struct {
+	runtime.JSONPb
+}
+
+// ContentType implements runtime.Marshaler
+func (*SSEMarshaler) ContentType(_ interface{}) string {
+	return MIMEEventStream
+}
+
+// Delimiter implements runtime.Delimited. Marshal terminates every event itself, so that unary
+// responses are complete events, too.
+func (*SSEMarshaler) Delimiter() []byte {
+	return []byte{}
+}
+
+// Marshal implements runtime.Marshaler
+func (m *SSEMarshaler) Marshal(v interface{}) ([]byte, error) {
+	var (
+		event string
+		data  = v
+	)
+	switch chunk := v.(type) {
+	case map[string]interface{}:
+		// the gateway wraps stream messages as {"result": msg}, the event itself carries that information already
+		if result, ok := chunk["result"]; ok && len(chunk) == 1 {
+			data = result
+		}
+	case map[string]proto.Message:
+		if st, ok := chunk["error"]; ok && len(chunk) == 1 {
+			event = "error"
+			data = st
+		}
+	}
+
+	payload, err := m.JSONPb.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if event != "" {
+		buf.WriteString("event: " + event + "\n")
+	}
+	// JSON can contain escaped but no raw line breaks, still every line needs its own data field
+	for _, line := range bytes.Split(payload, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteString("\n")
+	}
+	// events are terminated by a blank line
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
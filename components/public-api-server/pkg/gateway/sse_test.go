@@ -0,0 +1,54 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package gateway
+
+import (
+	"testing"
+
+	v1 "github.com/gitpod-io/gitpod/public-api/v1"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSSEMarshaler_Marshal(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Input       interface{}
+		Expectation string
+	}{
+		{
+			Name:        "unary response",
+			Input:       &v1.GetPrebuildRequest{PrebuildId: "123"},
+			Expectation: "data: {\"prebuildId\":\"123\"}\n\n",
+		},
+		{
+			Name:        "stream message",
+			Input:       map[string]interface{}{"result": &v1.ListenToPrebuildLogsResponse{Line: "hello"}},
+			Expectation: "data: {\"line\":\"hello\"}\n\n",
+		},
+		{
+			Name:        "stream error",
+			Input:       map[string]proto.Message{"error": status.New(codes.NotFound, "not found").Proto()},
+			Expectation: "event: error\ndata: {\"code\":5,\"message\":\"not found\",\"details\":[]}\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			marshaler := &SSEMarshaler{}
+			marshaler.EmitUnpopulated = true
+
+			act, err := marshaler.Marshal(test.Input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Expectation, string(act)); diff != "" {
+				t.Errorf("unexpected Marshal (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultMaxConnections                = 1000
+	defaultIdleTimeout                   = 5 * time.Minute
+	defaultMaxConcurrentRequestsPerToken = 10
+	defaultMaxConcurrentStreamsPerToken  = 10
+)
+
+// ConnectionPoolConfig configures a ConnectionPool. Zero values select the defaults.
+type ConnectionPoolConfig struct {
+	// MaxConnections is the maximum number of tokens we keep a connection for
+	MaxConnections int
+	// IdleTimeout is the time after which we close connections without requests
+	IdleTimeout time.Duration
+	// MaxConcurrentRequestsPerToken is the maximum number of unary requests a token can have in flight
+	MaxConcurrentRequestsPerToken int
+	// MaxConcurrentStreamsPerToken is the maximum number of streams a token can have open. Streams have a limit
+	// of their own, because they're long-lived and would otherwise starve the unary requests of a token.
+	MaxConcurrentStreamsPerToken int
+	// HealthCheckInterval is the interval in which we evict idle and closed connections. Defaults to half the IdleTimeout.
+	HealthCheckInterval time.Duration
+}
+
+// ConnectionPool keeps one connection to server per token. Connections reconnect through the
+// ReconnectingWebsocket, are evicted once they're idle or permanently closed, and the least recently
+// used idle connection makes room for new tokens once the pool is full.
+type ConnectionPool struct {
+	cfg  ConnectionPoolConfig
+	dial func(ctx context.Context, token string, onClose func()) (gitpod.APIInterface, error)
+
+	mu          sync.Mutex
+	connections map[string]*pooledConnection
+	lru         *list.List
+
+	stop chan struct{}
+	once sync.Once
+}
+
+type pooledConnection struct {
+	key    string
+	api    gitpod.APIInterface
+	cancel context.CancelFunc
+	closed chan struct{}
+	once   sync.Once
+
+	// guarded by ConnectionPool.mu
+	elem     *list.Element
+	inflight int
+	streams  int
+	lastUsed time.Time
+	evicted  bool
+}
+
+// NewConnectionPool creates a connection pool for the server API and starts its health checks
+func NewConnectionPool(serverAPI *url.URL, cfg ConnectionPoolConfig) *ConnectionPool {
+	pool := newConnectionPool(cfg, func(ctx context.Context, token string, onClose func()) (gitpod.APIInterface, error) {
+		return gitpod.ConnectToServer(serverAPI.String(), gitpod.ConnectToServerOpts{
+			Context:             ctx,
+			Token:               token,
+			Log:                 log.Log,
+			ReconnectionHandler: reportReconnect,
+			CloseHandler: func(err error) {
+				onClose()
+			},
+		})
+	})
+	go pool.runHealthChecks()
+	return pool
+}
+
+func newConnectionPool(cfg ConnectionPoolConfig, dial func(ctx context.Context, token string, onClose func()) (gitpod.APIInterface, error)) *ConnectionPool {
+	if cfg.MaxConnections <= 0 {
+		cfg.MaxConnections = defaultMaxConnections
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = defaultIdleTimeout
+	}
+	if cfg.MaxConcurrentRequestsPerToken <= 0 {
+		cfg.MaxConcurrentRequestsPerToken = defaultMaxConcurrentRequestsPerToken
+	}
+	if cfg.MaxConcurrentStreamsPerToken <= 0 {
+		cfg.MaxConcurrentStreamsPerToken = defaultMaxConcurrentStreamsPerToken
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = cfg.IdleTimeout / 2
+	}
+	return &ConnectionPool{
+		cfg:         cfg,
+		dial:        dial,
+		connections: make(map[string]*pooledConnection),
+		lru:         list.New(),
+		stop:        make(chan struct{}),
+	}
+}
+
+type streamContextKey struct{}
+
+// WithStream marks ctx as the context of a streaming request. Streams count towards a concurrency limit
+// of their own rather than the one of unary requests.
+func WithStream(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamContextKey{}, true)
+}
+
+func isStream(ctx context.Context) bool {
+	stream, _ := ctx.Value(streamContextKey{}).(bool)
+	return stream
+}
+
+// Get returns the connection of a token. The connection counts towards the concurrency limit of the token
+// until ctx is done, hence ctx must be scoped to a single request. Mark the contexts of streams using WithStream.
+func (p *ConnectionPool) Get(ctx context.Context, token string) (gitpod.APIInterface, error) {
+	key := hashToken(token)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn, exists := p.connections[key]
+	if exists && conn.isClosed() {
+		p.evict(conn, "closed")
+		exists = false
+	}
+	if exists {
+		reportPoolRequest("hit")
+	} else {
+		if len(p.connections) >= p.cfg.MaxConnections && !p.evictLeastRecentlyUsed() {
+			reportPoolRequest("rejected")
+			return nil, status.Error(codes.ResourceExhausted, "too many concurrent connections")
+		}
+
+		var err error
+		conn, err = p.connect(key, token)
+		if err != nil {
+			return nil, err
+		}
+		p.connections[key] = conn
+		conn.elem = p.lru.PushFront(conn)
+		reportPoolSize(len(p.connections))
+		reportPoolRequest("miss")
+	}
+
+	stream := isStream(ctx)
+	if stream {
+		if conn.streams >= p.cfg.MaxConcurrentStreamsPerToken {
+			reportPoolRequest("rejected")
+			return nil, status.Error(codes.ResourceExhausted, "too many concurrent streams")
+		}
+		conn.streams++
+	} else {
+		if conn.inflight >= p.cfg.MaxConcurrentRequestsPerToken {
+			reportPoolRequest("rejected")
+			return nil, status.Error(codes.ResourceExhausted, "too many concurrent requests")
+		}
+		conn.inflight++
+	}
+	conn.lastUsed = time.Now()
+	p.lru.MoveToFront(conn.elem)
+
+	go func() {
+		<-ctx.Done()
+		p.release(conn, stream)
+	}()
+
+	return conn.api, nil
+}
+
+// Close closes all connections and stops the health checks
+func (p *ConnectionPool) Close() {
+	p.once.Do(func() { close(p.stop) })
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.connections {
+		p.evict(conn, "shutdown")
+	}
+}
+
+func (p *ConnectionPool) connect(key, token string) (*pooledConnection, error) {
+	start := time.Now()
+	defer func() {
+		reportConnectionDuration(time.Since(start))
+	}()
+
+	// connections outlive the request which created them
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := &pooledConnection{
+		key:    key,
+		cancel: cancel,
+		closed: make(chan struct{}),
+	}
+	api, err := p.dial(ctx, token, conn.markClosed)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create new connection to server: %w", err)
+	}
+	conn.api = api
+	return conn, nil
+}
+
+func (p *ConnectionPool) release(conn *pooledConnection, stream bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if stream {
+		conn.streams--
+	} else {
+		conn.inflight--
+	}
+	conn.lastUsed = time.Now()
+	if conn.evicted && !conn.inUse() {
+		conn.close()
+	}
+}
+
+// evictLeastRecentlyUsed evicts the least recently used connection without requests in flight or open streams.
+// Returns false if all connections are in use. Callers must hold p.mu.
+func (p *ConnectionPool) evictLeastRecentlyUsed() bool {
+	for elem := p.lru.Back(); elem != nil; elem = elem.Prev() {
+		conn := elem.Value.(*pooledConnection)
+		if !conn.inUse() {
+			p.evict(conn, "capacity")
+			return true
+		}
+	}
+	return false
+}
+
+// evict removes a connection from the pool and closes it once its requests and streams are done. Callers must hold p.mu.
+func (p *ConnectionPool) evict(conn *pooledConnection, reason string) {
+	if conn.evicted {
+		return
+	}
+	conn.evicted = true
+	delete(p.connections, conn.key)
+	p.lru.Remove(conn.elem)
+	if !conn.inUse() || conn.isClosed() {
+		conn.close()
+	}
+	reportPoolSize(len(p.connections))
+	reportEviction(reason)
+}
+
+func (p *ConnectionPool) runHealthChecks() {
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.checkHealth(time.Now())
+		}
+	}
+}
+
+// checkHealth evicts connections which are permanently closed or idle for longer than the idle timeout
+func (p *ConnectionPool) checkHealth(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, conn := range p.connections {
+		switch {
+		case conn.isClosed():
+			p.evict(conn, "closed")
+		case !conn.inUse() && now.Sub(conn.lastUsed) > p.cfg.IdleTimeout:
+			p.evict(conn, "idle")
+		}
+	}
+}
+
+// inUse returns true if the connection has requests in flight or open streams. Callers must hold ConnectionPool.mu.
+func (c *pooledConnection) inUse() bool {
+	return c.inflight > 0 || c.streams > 0
+}
+
+func (c *pooledConnection) markClosed() {
+	c.once.Do(func() { close(c.closed) })
+}
+
+func (c *pooledConnection) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *pooledConnection) close() {
+	c.cancel()
+	if closer, ok := c.api.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	c.markClosed()
+}
+
+// hashToken keeps raw tokens out of the pool's memory
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
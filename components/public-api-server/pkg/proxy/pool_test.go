@@ -0,0 +1,174 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	gitpod "github.com/gitpod-io/gitpod/gitpod-protocol"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeConnection struct {
+	gitpod.APIInterface
+
+	token   string
+	onClose func()
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (f *fakeConnection) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeConnection) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func newTestPool(cfg ConnectionPoolConfig) (*ConnectionPool, *[]*fakeConnection) {
+	var dialed []*fakeConnection
+	pool := newConnectionPool(cfg, func(ctx context.Context, token string, onClose func()) (gitpod.APIInterface, error) {
+		conn := &fakeConnection{token: token, onClose: onClose}
+		dialed = append(dialed, conn)
+		return conn, nil
+	})
+	return pool, &dialed
+}
+
+func TestConnectionPool_ReusesConnectionsPerToken(t *testing.T) {
+	pool, dialed := newTestPool(ConnectionPoolConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, err := pool.Get(ctx, "token-a")
+	require.NoError(t, err)
+	second, err := pool.Get(ctx, "token-a")
+	require.NoError(t, err)
+	other, err := pool.Get(ctx, "token-b")
+	require.NoError(t, err)
+
+	require.Same(t, first, second)
+	require.NotSame(t, first, other)
+	require.Len(t, *dialed, 2)
+}
+
+func TestConnectionPool_LimitsConcurrentRequestsPerToken(t *testing.T) {
+	pool, _ := newTestPool(ConnectionPoolConfig{MaxConcurrentRequestsPerToken: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := pool.Get(ctx, "token-a")
+	require.NoError(t, err)
+
+	_, err = pool.Get(context.Background(), "token-a")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the slot is released once the request is done
+	cancel()
+	require.Eventually(t, func() bool {
+		reqCtx, reqCancel := context.WithCancel(context.Background())
+		defer reqCancel()
+		_, err := pool.Get(reqCtx, "token-a")
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestConnectionPool_EvictsLeastRecentlyUsedConnection(t *testing.T) {
+	pool, dialed := newTestPool(ConnectionPoolConfig{MaxConnections: 2})
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	_, err := pool.Get(ctxA, "token-a")
+	require.NoError(t, err)
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	_, err = pool.Get(ctxB, "token-b")
+	require.NoError(t, err)
+
+	// all connections are in use
+	_, err = pool.Get(context.Background(), "token-c")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	cancelA()
+	require.Eventually(t, func() bool {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, err := pool.Get(ctx, "token-c")
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	require.True(t, (*dialed)[0].isClosed(), "connection of token-a should be closed")
+	require.False(t, (*dialed)[1].isClosed(), "connection of token-b should be open")
+}
+
+func TestConnectionPool_HealthChecks(t *testing.T) {
+	pool, dialed := newTestPool(ConnectionPoolConfig{IdleTimeout: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := pool.Get(ctx, "token-a")
+	require.NoError(t, err)
+
+	// connections with requests in flight are never idle
+	pool.checkHealth(time.Now().Add(2 * time.Minute))
+	require.False(t, (*dialed)[0].isClosed())
+
+	cancel()
+	require.Eventually(t, func() bool {
+		pool.checkHealth(time.Now().Add(2 * time.Minute))
+		return (*dialed)[0].isClosed()
+	}, time.Second, 10*time.Millisecond)
+
+	// permanently closed connections are replaced
+	reqCtx, reqCancel := context.WithCancel(context.Background())
+	defer reqCancel()
+	_, err = pool.Get(reqCtx, "token-a")
+	require.NoError(t, err)
+	(*dialed)[1].onClose()
+	_, err = pool.Get(reqCtx, "token-a")
+	require.NoError(t, err)
+	require.Len(t, *dialed, 3)
+	require.True(t, (*dialed)[1].isClosed())
+}
+
+func TestConnectionPool_LimitsStreamsSeparately(t *testing.T) {
+	pool, dialed := newTestPool(ConnectionPoolConfig{MaxConcurrentRequestsPerToken: 1, MaxConcurrentStreamsPerToken: 1, IdleTimeout: time.Minute})
+
+	streamCtx, cancelStream := context.WithCancel(WithStream(context.Background()))
+	_, err := pool.Get(streamCtx, "token-a")
+	require.NoError(t, err)
+
+	_, err = pool.Get(WithStream(context.Background()), "token-a")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// open streams don't take the slots of unary requests
+	reqCtx, cancelReq := context.WithCancel(context.Background())
+	_, err = pool.Get(reqCtx, "token-a")
+	require.NoError(t, err)
+	cancelReq()
+
+	// connections with open streams are never idle
+	require.Eventually(t, func() bool {
+		pool.mu.Lock()
+		defer pool.mu.Unlock()
+		return pool.connections[hashToken("token-a")].inflight == 0
+	}, time.Second, 10*time.Millisecond)
+	pool.checkHealth(time.Now().Add(2 * time.Minute))
+	require.False(t, (*dialed)[0].isClosed())
+
+	cancelStream()
+	require.Eventually(t, func() bool {
+		pool.checkHealth(time.Now().Add(2 * time.Minute))
+		return (*dialed)[0].isClosed()
+	}, time.Second, 10*time.Millisecond)
+}
@@ -19,6 +19,52 @@ var proxyConnectionCreateDurationSeconds = prometheus.NewHistogram(prometheus.Hi
 	Help:      "Histogram of connection time in seconds",
 })
 
+func reportPoolRequest(result string) {
+	proxyConnectionPoolRequestsTotal.WithLabelValues(result).Inc()
+}
+
+var proxyConnectionPoolRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gitpod",
+	Name:      "public_api_proxy_connection_pool_requests_total",
+	Help:      "Total number of connection pool requests by result (hit, miss or rejected)",
+}, []string{"result"})
+
+func reportPoolSize(size int) {
+	proxyConnectionPoolSize.Set(float64(size))
+}
+
+var proxyConnectionPoolSize = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "gitpod",
+	Name:      "public_api_proxy_connection_pool_size",
+	Help:      "Number of pooled connections",
+})
+
+func reportEviction(reason string) {
+	proxyConnectionPoolEvictionsTotal.WithLabelValues(reason).Inc()
+}
+
+var proxyConnectionPoolEvictionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gitpod",
+	Name:      "public_api_proxy_connection_pool_evictions_total",
+	Help:      "Total number of evicted connections by reason (idle, closed, capacity or shutdown)",
+}, []string{"reason"})
+
+func reportReconnect() {
+	proxyConnectionReconnectsTotal.Inc()
+}
+
+var proxyConnectionReconnectsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "gitpod",
+	Name:      "public_api_proxy_connection_reconnects_total",
+	Help:      "Total number of reconnects of pooled connections",
+})
+
 func RegisterMetrics(registry *prometheus.Registry) {
-	registry.MustRegister(proxyConnectionCreateDurationSeconds)
+	registry.MustRegister(
+		proxyConnectionCreateDurationSeconds,
+		proxyConnectionPoolRequestsTotal,
+		proxyConnectionPoolSize,
+		proxyConnectionPoolEvictionsTotal,
+		proxyConnectionReconnectsTotal,
+	)
 }
@@ -4,10 +4,19 @@
 
 package server
 
-import "net/url"
+import (
+	"net/url"
+
+	"github.com/gitpod-io/gitpod/public-api-server/pkg/proxy"
+)
 
 type Config struct {
 	GitpodAPI *url.URL
 
 	GRPCPort int
+
+	// HTTPPort serves the REST/JSON gateway of the gRPC API
+	HTTPPort int
+
+	ConnectionPool proxy.ConnectionPoolConfig
 }
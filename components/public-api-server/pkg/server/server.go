@@ -5,10 +5,12 @@
 package server
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/gitpod-io/gitpod/common-go/baseserver"
 	"github.com/gitpod-io/gitpod/public-api-server/pkg/apiv1"
+	"github.com/gitpod-io/gitpod/public-api-server/pkg/gateway"
 	"github.com/gitpod-io/gitpod/public-api-server/pkg/proxy"
 	v1 "github.com/gitpod-io/gitpod/public-api/v1"
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,15 +23,19 @@ func Start(logger *logrus.Entry, cfg Config) error {
 	srv, err := baseserver.New("public_api_server",
 		baseserver.WithLogger(logger),
 		baseserver.WithGRPC(&baseserver.ServerConfiguration{Address: fmt.Sprintf(":%d", cfg.GRPCPort)}),
+		baseserver.WithHTTP(&baseserver.ServerConfiguration{Address: fmt.Sprintf(":%d", cfg.HTTPPort)}),
 		baseserver.WithMetricsRegistry(registry),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize public api server: %w", err)
 	}
 
-	if registerErr := register(srv, cfg, registry); registerErr != nil {
+	connPool, registerErr := register(srv, cfg, registry)
+	if registerErr != nil {
 		return fmt.Errorf("failed to register services: %w", registerErr)
 	}
+	// ListenAndServe stops the servers before it returns, hence no request uses the pool anymore
+	defer connPool.Close()
 
 	if listenErr := srv.ListenAndServe(); listenErr != nil {
 		return fmt.Errorf("failed to serve public api server: %w", err)
@@ -38,13 +44,19 @@ func Start(logger *logrus.Entry, cfg Config) error {
 	return nil
 }
 
-func register(srv *baseserver.Server, cfg Config, registry *prometheus.Registry) error {
+// register registers all services with the server. The caller must close the returned connection pool once the server stopped.
+func register(srv *baseserver.Server, cfg Config, registry *prometheus.Registry) (*proxy.ConnectionPool, error) {
 	proxy.RegisterMetrics(registry)
 
-	connPool := &proxy.NoConnectionPool{ServerAPI: cfg.GitpodAPI}
+	connPool := proxy.NewConnectionPool(cfg.GitpodAPI, cfg.ConnectionPool)
 
 	v1.RegisterWorkspacesServiceServer(srv.GRPC(), apiv1.NewWorkspaceService(connPool))
 	v1.RegisterPrebuildsServiceServer(srv.GRPC(), v1.UnimplementedPrebuildsServiceServer{})
 
-	return nil
+	if err := gateway.Register(context.Background(), srv.HTTPMux(), srv.GRPCAddress()); err != nil {
+		connPool.Close()
+		return nil, fmt.Errorf("failed to register REST gateway: %w", err)
+	}
+
+	return connPool, nil
 }
@@ -17,13 +17,26 @@ COMPONENTS_DIR="$ROOT_DIR"/components
 # shellcheck disable=SC1090,SC1091
 source "$ROOT_DIR"/scripts/protoc-generator.sh
 
+go_protoc_gateway() {
+    # shellcheck disable=2035
+    protoc \
+        -I/usr/lib/protoc/include -I"$COMPONENTS_DIR" -I. \
+        --grpc-gateway_out=logtostderr=true,paths=source_relative:go \
+        --openapiv2_out=allow_merge=true,merge_file_name=public-api:go/v1 \
+        gitpod/v1/*.proto
+}
+
 lint
 
 install_dependencies
+go install github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2@v2.10.0
 go_protoc "$COMPONENTS_DIR" "gitpod/v1"
+go_protoc_gateway
 mkdir -p go/v1
-mv go/gitpod/v1/*.pb.go go/v1
+mv go/gitpod/v1/*.pb.go go/gitpod/v1/*.pb.gw.go go/v1
 rm -rf go/gitpod
 typescript_protoc "$COMPONENTS_DIR" "gitpod/v1"
+# the gitpod/v1 TypeScript code imports the generated google/api annotations
+typescript_protoc "$COMPONENTS_DIR" "google/api"
 
 update_license
@@ -0,0 +1,14 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package v1
+
+import (
+	_ "embed"
+)
+
+// OpenAPIDocument is the OpenAPI (swagger 2.0) document of the REST/JSON API, generated from the gRPC service definitions.
+//
+//go:embed public-api.swagger.json
+var OpenAPIDocument []byte
@@ -13,6 +13,7 @@
 import * as grpc from "@grpc/grpc-js";
 import * as gitpod_v1_prebuilds_pb from "../../gitpod/v1/prebuilds_pb";
 import * as gitpod_v1_workspaces_pb from "../../gitpod/v1/workspaces_pb";
+import * as google_api_annotations_pb from "../../google/api/annotations_pb";
 
 interface IPrebuildsServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
     getPrebuild: IPrebuildsServiceService_IGetPrebuild;
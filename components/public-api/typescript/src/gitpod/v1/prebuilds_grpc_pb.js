@@ -10,6 +10,7 @@
 var grpc = require('@grpc/grpc-js');
 var gitpod_v1_prebuilds_pb = require('../../gitpod/v1/prebuilds_pb.js');
 var gitpod_v1_workspaces_pb = require('../../gitpod/v1/workspaces_pb.js');
+var google_api_annotations_pb = require('../../google/api/annotations_pb.js');
 
 function serialize_gitpod_v1_GetPrebuildRequest(arg) {
   if (!(arg instanceof gitpod_v1_prebuilds_pb.GetPrebuildRequest)) {
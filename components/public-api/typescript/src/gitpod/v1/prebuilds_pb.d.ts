@@ -12,6 +12,7 @@
 
 import * as jspb from "google-protobuf";
 import * as gitpod_v1_workspaces_pb from "../../gitpod/v1/workspaces_pb";
+import * as google_api_annotations_pb from "../../google/api/annotations_pb";
 
 export class GetPrebuildRequest extends jspb.Message {
     getPrebuildId(): string;
@@ -23,6 +23,8 @@ var global = (function() { return this || window || global || self || Function('
 
 var gitpod_v1_workspaces_pb = require('../../gitpod/v1/workspaces_pb.js');
 goog.object.extend(proto, gitpod_v1_workspaces_pb);
+var google_api_annotations_pb = require('../../google/api/annotations_pb.js');
+goog.object.extend(proto, google_api_annotations_pb);
 goog.exportSymbol('proto.gitpod.v1.GetPrebuildRequest', null, global);
 goog.exportSymbol('proto.gitpod.v1.GetPrebuildResponse', null, global);
 goog.exportSymbol('proto.gitpod.v1.GetRunningPrebuildRequest', null, global);
@@ -15,6 +15,7 @@ import * as gitpod_v1_workspaces_pb from "../../gitpod/v1/workspaces_pb";
 import * as google_protobuf_timestamp_pb from "google-protobuf/google/protobuf/timestamp_pb";
 import * as google_protobuf_field_mask_pb from "google-protobuf/google/protobuf/field_mask_pb";
 import * as gitpod_v1_pagination_pb from "../../gitpod/v1/pagination_pb";
+import * as google_api_annotations_pb from "../../google/api/annotations_pb";
 
 interface IWorkspacesServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
     listWorkspaces: IWorkspacesServiceService_IListWorkspaces;
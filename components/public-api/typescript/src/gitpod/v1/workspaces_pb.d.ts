@@ -14,6 +14,7 @@ import * as jspb from "google-protobuf";
 import * as google_protobuf_timestamp_pb from "google-protobuf/google/protobuf/timestamp_pb";
 import * as google_protobuf_field_mask_pb from "google-protobuf/google/protobuf/field_mask_pb";
 import * as gitpod_v1_pagination_pb from "../../gitpod/v1/pagination_pb";
+import * as google_api_annotations_pb from "../../google/api/annotations_pb";
 
 export class ListWorkspacesRequest extends jspb.Message {
 
@@ -27,6 +27,8 @@ var google_protobuf_field_mask_pb = require('google-protobuf/google/protobuf/fie
 goog.object.extend(proto, google_protobuf_field_mask_pb);
 var gitpod_v1_pagination_pb = require('../../gitpod/v1/pagination_pb.js');
 goog.object.extend(proto, gitpod_v1_pagination_pb);
+var google_api_annotations_pb = require('../../google/api/annotations_pb.js');
+goog.object.extend(proto, google_api_annotations_pb);
 goog.exportSymbol('proto.gitpod.v1.AdmissionLevel', null, global);
 goog.exportSymbol('proto.gitpod.v1.CreateAndStartWorkspaceRequest', null, global);
 goog.exportSymbol('proto.gitpod.v1.CreateAndStartWorkspaceRequest.SourceCase', null, global);
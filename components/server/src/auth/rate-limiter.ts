@@ -64,6 +64,7 @@ function getConfig(config: RateLimiterConfig): RateLimiterConfig {
         getFeaturedRepositories: { group: "default", points: 1 },
         getSuggestedContextURLs: { group: "default", points: 1 },
         getWorkspace: { group: "default", points: 1 },
+        getWorkspaceInstance: { group: "default", points: 1 },
         isWorkspaceOwner: { group: "default", points: 1 },
         getOwnerToken: { group: "default", points: 1 },
         createWorkspace: { group: "default", points: 1 },
@@ -88,6 +89,9 @@ function getConfig(config: RateLimiterConfig): RateLimiterConfig {
         getAllEnvVars: { group: "default", points: 1 },
         setEnvVar: { group: "default", points: 1 },
         deleteEnvVar: { group: "default", points: 1 },
+        getSSHPublicKeys: { group: "default", points: 1 },
+        addSSHPublicKey: { group: "default", points: 1 },
+        deleteSSHPublicKey: { group: "default", points: 1 },
         setProjectEnvironmentVariable: { group: "default", points: 1 },
         getProjectEnvironmentVariables: { group: "default", points: 1 },
         deleteProjectEnvironmentVariable: { group: "default", points: 1 },
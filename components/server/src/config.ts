@@ -20,7 +20,7 @@ import { filePathTelepresenceAware } from "@gitpod/gitpod-protocol/lib/env";
 export const Config = Symbol("Config");
 export type Config = Omit<
     ConfigSerialized,
-    "blockedRepositories" | "hostUrl" | "chargebeeProviderOptionsFile" | "licenseFile"
+    "blockedRepositories" | "hostUrl" | "chargebeeProviderOptionsFile" | "licenseFile" | "sshGatewayTokenFile"
 > & {
     hostUrl: GitpodHostUrl;
     workspaceDefaults: WorkspaceDefaults;
@@ -28,6 +28,7 @@ export type Config = Omit<
     builtinAuthProvidersConfigured: boolean;
     blockedRepositories: { urlRegExp: RegExp; blockUser: boolean }[];
     inactivityPeriodForRepos?: number;
+    sshGatewayToken?: string;
 };
 
 export interface WorkspaceDefaults {
@@ -169,6 +170,12 @@ export interface ConfigSerialized {
      * considered inactive.
      */
     inactivityPeriodForRepos?: number;
+
+    /**
+     * The SSH gateway (ws-proxy) authenticates with this token when it resolves SSH public keys to users.
+     * The endpoint is disabled if no token file is configured.
+     */
+    sshGatewayTokenFile?: string;
 }
 
 export namespace ConfigFile {
@@ -227,6 +234,10 @@ export namespace ConfigFile {
                 });
             }
         }
+        let sshGatewayToken: string | undefined;
+        if (config.sshGatewayTokenFile) {
+            sshGatewayToken = fs.readFileSync(filePathTelepresenceAware(config.sshGatewayTokenFile), "utf-8").trim();
+        }
         let inactivityPeriodForRepos: number | undefined;
         if (typeof config.inactivityPeriodForRepos === "number") {
             if (config.inactivityPeriodForRepos >= 1) {
@@ -248,6 +259,7 @@ export namespace ConfigFile {
             },
             blockedRepositories,
             inactivityPeriodForRepos,
+            sshGatewayToken,
         };
     }
 }
@@ -26,6 +26,7 @@ import { IContextParser, IPrefixContextParser } from "./workspace/context-parser
 import { ContextParser } from "./workspace/context-parser-service";
 import { SnapshotContextParser } from "./workspace/snapshot-context-parser";
 import { EnforcementController, EnforcementControllerServerFactory } from "./user/enforcement-endpoint";
+import { SSHGatewayController } from "./user/ssh-gateway-controller";
 import { MessagebusConfiguration } from "@gitpod/gitpod-messagebus/lib/config";
 import { HostContextProvider, HostContextProviderFactory } from "./auth/host-context-provider";
 import { TokenService } from "./user/token-service";
@@ -134,6 +135,7 @@ export const productionContainerModule = new ContainerModule((bind, unbind, isBo
     bind(UserController).toSelf().inSingletonScope();
     bind(EnforcementControllerServerFactory).toAutoFactory(GitpodServerImpl);
     bind(EnforcementController).toSelf().inSingletonScope();
+    bind(SSHGatewayController).toSelf().inSingletonScope();
 
     bind(InstallationAdminController).toSelf().inSingletonScope();
 
@@ -22,6 +22,7 @@ import { createWebSocketConnection } from "vscode-ws-jsonrpc/lib";
 import { MessageBusIntegration } from "./workspace/messagebus-integration";
 import { log } from "@gitpod/gitpod-protocol/lib/util/logging";
 import { EnforcementController } from "./user/enforcement-endpoint";
+import { SSHGatewayController, SSH_GATEWAY_PATH_PREFIX } from "./user/ssh-gateway-controller";
 import { AddressInfo } from "net";
 import { ConsensusLeaderQorum } from "./consensus/consensus-leader-quorum";
 import { RabbitMQConsensusLeaderMessenger } from "./consensus/rabbitmq-consensus-leader-messenger";
@@ -60,6 +61,7 @@ export class Server<C extends GitpodClient, S extends GitpodServer> {
     @inject(UserController) protected readonly userController: UserController;
     @inject(InstallationAdminController) protected readonly installationAdminController: InstallationAdminController;
     @inject(EnforcementController) protected readonly enforcementController: EnforcementController;
+    @inject(SSHGatewayController) protected readonly sshGatewayController: SSHGatewayController;
     @inject(WebsocketConnectionManager) protected websocketConnectionHandler: WebsocketConnectionManager;
     @inject(MessageBusIntegration) protected readonly messagebus: MessageBusIntegration;
     @inject(LocalMessageBroker) protected readonly localMessageBroker: LocalMessageBroker;
@@ -294,6 +296,7 @@ export class Server<C extends GitpodClient, S extends GitpodServer> {
         app.use(this.userController.apiRouter);
         app.use(this.oneTimeSecretServer.apiRouter);
         app.use("/enforcement", this.enforcementController.apiRouter);
+        app.use(SSH_GATEWAY_PATH_PREFIX, this.sshGatewayController.apiRouter);
         app.use("/workspace-download", this.workspaceDownloadService.apiRouter);
         app.use("/code-sync", this.codeSyncService.apiRouter);
         app.use(HEADLESS_LOGS_PATH_PREFIX, this.headlessLogController.headlessLogs);
@@ -0,0 +1,83 @@
+/**
+ * Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+ * Licensed under the GNU Affero General Public License (AGPL).
+ * See License-AGPL.txt in the project root for license information.
+ */
+
+import * as crypto from "crypto";
+import * as express from "express";
+import * as opentracing from "opentracing";
+import { injectable, inject } from "inversify";
+import { UserDB } from "@gitpod/gitpod-db/lib";
+import { log } from "@gitpod/gitpod-protocol/lib/util/logging";
+import { TraceContext } from "@gitpod/gitpod-protocol/lib/util/tracing";
+import { Config } from "../config";
+
+export const SSH_GATEWAY_PATH_PREFIX = "/ssh-gateway";
+
+/**
+ * sshPublicKeyFingerprint computes the SHA256 fingerprint of a public key in the authorized_keys format,
+ * in the same format as `ssh-keygen -l` and ssh.FingerprintSHA256 in Go.
+ */
+export function sshPublicKeyFingerprint(key: string): string {
+    const blob = Buffer.from(key.trim().split(/\s+/)[1] || "", "base64");
+    const hash = crypto.createHash("sha256").update(blob).digest("base64");
+    return "SHA256:" + hash.replace(/=+$/, "");
+}
+
+/**
+ * SSHGatewayController lets the SSH gateway in ws-proxy resolve the public keys users registered with their account.
+ */
+@injectable()
+export class SSHGatewayController {
+    @inject(Config) protected readonly config: Config;
+    @inject(UserDB) protected readonly userDB: UserDB;
+
+    get apiRouter(): express.Router {
+        const router = express.Router();
+        this.addRouteToResolveUser(router);
+        return router;
+    }
+
+    protected isAuthorized(req: express.Request): boolean {
+        const token = this.config.sshGatewayToken;
+        if (!token) {
+            return false;
+        }
+        const expected = Buffer.from(`Bearer ${token}`);
+        const actual = Buffer.from(req.headers.authorization || "");
+        return actual.length === expected.length && crypto.timingSafeEqual(actual, expected);
+    }
+
+    protected addRouteToResolveUser(router: express.Router) {
+        router.get("/resolve-user", async (req, res) => {
+            if (!this.isAuthorized(req)) {
+                res.sendStatus(401);
+                return;
+            }
+            const fingerprint = req.query.fingerprint;
+            if (typeof fingerprint !== "string" || !fingerprint.startsWith("SHA256:")) {
+                res.sendStatus(400);
+                return;
+            }
+
+            const spanCtx =
+                opentracing.globalTracer().extract(opentracing.FORMAT_HTTP_HEADERS, req.headers) || undefined;
+            const span = opentracing.globalTracer().startSpan("resolveSSHPublicKey", { childOf: spanCtx });
+            try {
+                const key = await this.userDB.findSSHPublicKeyByFingerprint(fingerprint);
+                if (!key) {
+                    res.sendStatus(404);
+                    return;
+                }
+                res.status(200).json({ userId: key.userId });
+            } catch (err) {
+                log.error("cannot resolve SSH public key", err);
+                TraceContext.setError({ span }, err);
+                res.sendStatus(500);
+            } finally {
+                span.finish();
+            }
+        });
+    }
+}
@@ -48,6 +48,8 @@ import {
     User,
     UserEnvVar,
     UserEnvVarValue,
+    UserSSHPublicKey,
+    UserSSHPublicKeyValue,
     UserInfo,
     WhitelistedRepository,
     Workspace,
@@ -77,6 +79,7 @@ import {
     SnapshotContext,
 } from "@gitpod/gitpod-protocol";
 import { AccountStatement } from "@gitpod/gitpod-protocol/lib/accounting-protocol";
+import { sshPublicKeyFingerprint } from "../user/ssh-gateway-controller";
 import {
     AdminBlockUserRequest,
     AdminGetListRequest,
@@ -175,6 +178,9 @@ export function censor<T>(obj: T, k: keyof T): T {
 
 export type GitpodServerWithTracing = InterfaceWithTraceContext<GitpodServer>;
 
+// MAX_SSH_PUBLIC_KEYS_PER_USER protects our database from users who register keys in a loop
+const MAX_SSH_PUBLIC_KEYS_PER_USER = 100;
+
 @injectable()
 export class GitpodServerImpl implements GitpodServerWithTracing, Disposable {
     @inject(Config) protected readonly config: Config;
@@ -576,6 +582,23 @@ export class GitpodServerImpl implements GitpodServerWithTracing, Disposable {
         };
     }
 
+    public async getWorkspaceInstance(ctx: TraceContext, instanceId: string): Promise<WorkspaceInstance> {
+        traceAPIParams(ctx, { instanceId });
+        traceWI(ctx, { instanceId });
+
+        this.checkUser("getWorkspaceInstance");
+
+        const instance = await this.workspaceDb.trace(ctx).findInstanceById(instanceId);
+        if (!instance) {
+            throw new ResponseError(ErrorCodes.NOT_FOUND, `Workspace instance ${instanceId} not found`);
+        }
+        const workspace = await this.internalGetWorkspace(instance.workspaceId, this.workspaceDb.trace(ctx));
+        const teamMembers = await this.getTeamMembersByProject(workspace.projectId);
+        await this.guardAccess({ kind: "workspaceInstance", subject: instance, workspace, teamMembers }, "get");
+
+        return this.censorInstance(instance);
+    }
+
     public async getOwnerToken(ctx: TraceContext, workspaceId: string): Promise<string> {
         traceAPIParams(ctx, { workspaceId });
         traceWI(ctx, { workspaceId });
@@ -1564,10 +1587,11 @@ export class GitpodServerImpl implements GitpodServerWithTracing, Disposable {
 
             log.warn(logCtx, "imageBuild logs: fallback!");
             ctx.span?.setTag("workspace.imageBuild.logs.fallback", true);
-            await this.deprecatedDoWatchWorkspaceImageBuildLogs(ctx, logCtx, workspace);
+            await this.deprecatedDoWatchWorkspaceImageBuildLogs(ctx, logCtx, workspace, instance.id);
             return;
         }
 
+        const instanceId = instance.id;
         const aborted = new Deferred<boolean>();
         try {
             const logEndpoint: HeadlessLogEndpoint = {
@@ -1591,6 +1615,7 @@ export class GitpodServerImpl implements GitpodServerWithTracing, Disposable {
                             text: chunk,
                             isDiff: true,
                             upToLine: lineCount,
+                            instanceId,
                         });
                     } catch (err) {
                         log.error("error while streaming imagebuild logs", err);
@@ -1614,6 +1639,7 @@ export class GitpodServerImpl implements GitpodServerWithTracing, Disposable {
         ctx: TraceContext,
         logCtx: LogContext,
         workspace: Workspace,
+        instanceId: string,
     ) {
         if (!workspace.imageNameResolved) {
             log.debug(logCtx, `No imageNameResolved set for workspaceId, cannot watch logs.`);
@@ -1638,6 +1664,7 @@ export class GitpodServerImpl implements GitpodServerWithTracing, Disposable {
                     text: data,
                     isDiff: true,
                     upToLine: lineCount,
+                    instanceId,
                 });
                 return "continue";
             });
@@ -1924,6 +1951,67 @@ export class GitpodServerImpl implements GitpodServerWithTracing, Disposable {
         await this.userDB.deleteEnvVar(envvar);
     }
 
+    async getSSHPublicKeys(ctx: TraceContext): Promise<UserSSHPublicKeyValue[]> {
+        const user = this.checkUser("getSSHPublicKeys");
+        const keys = await this.userDB.getSSHPublicKeys(user.id);
+        return keys.map(({ id, name, key }) => ({ id, name, key }));
+    }
+
+    async addSSHPublicKey(ctx: TraceContext, value: UserSSHPublicKeyValue): Promise<UserSSHPublicKeyValue> {
+        traceAPIParams(ctx, { name: value.name });
+
+        // Note: this operation is per-user only, hence needs no resource guard
+        const user = this.checkAndBlockUser("addSSHPublicKey");
+
+        const validationError = UserSSHPublicKey.validate(value);
+        if (validationError) {
+            throw new ResponseError(ErrorCodes.BAD_REQUEST, validationError);
+        }
+
+        const existingKeys = await this.userDB.getSSHPublicKeys(user.id);
+        if (existingKeys.length >= MAX_SSH_PUBLIC_KEYS_PER_USER) {
+            throw new ResponseError(
+                ErrorCodes.PERMISSION_DENIED,
+                `cannot have more than ${MAX_SSH_PUBLIC_KEYS_PER_USER} SSH public keys`,
+            );
+        }
+        const fingerprint = sshPublicKeyFingerprint(value.key);
+        if (existingKeys.some((k) => k.fingerprint === fingerprint)) {
+            throw new ResponseError(ErrorCodes.CONFLICT, "This key is registered already.");
+        }
+        // A key identifies the user when they connect through the SSH gateway, hence must belong to one user only.
+        if (await this.userDB.findSSHPublicKeyByFingerprint(fingerprint)) {
+            throw new ResponseError(ErrorCodes.CONFLICT, "This key is registered by another user.");
+        }
+
+        const key: UserSSHPublicKey = {
+            id: uuidv4(),
+            userId: user.id,
+            name: value.name.trim(),
+            key: value.key.trim(),
+            fingerprint,
+            creationTime: new Date().toISOString(),
+        };
+        await this.userDB.addSSHPublicKey(key);
+        this.analytics.track({ event: "ssh-public-key-added", userId: user.id });
+
+        return { id: key.id, name: key.name, key: key.key };
+    }
+
+    async deleteSSHPublicKey(ctx: TraceContext, id: string): Promise<void> {
+        traceAPIParams(ctx, { id });
+
+        // Note: this operation is per-user only, hence needs no resource guard
+        const user = this.checkAndBlockUser("deleteSSHPublicKey");
+
+        const key = (await this.userDB.getSSHPublicKeys(user.id)).find((k) => k.id === id);
+        if (!key) {
+            throw new ResponseError(ErrorCodes.NOT_FOUND, `SSH public key ${id} not found`);
+        }
+        await this.userDB.deleteSSHPublicKey(key);
+        this.analytics.track({ event: "ssh-public-key-deleted", userId: user.id });
+    }
+
     async setProjectEnvironmentVariable(
         ctx: TraceContext,
         projectId: string,
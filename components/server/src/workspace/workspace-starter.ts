@@ -67,9 +67,11 @@ import {
     BuildRegistryAuthTotal,
     BuildRequest,
     BuildResponse,
+    BuildSecret,
     BuildSource,
     BuildSourceDockerfile,
     BuildSourceReference,
+    BuildSSHKey,
     BuildStatus,
     ImageBuilderClientProvider,
     ResolveBaseImageRequest,
@@ -374,6 +376,7 @@ export class WorkspaceStarter {
                 workspace,
                 instance,
                 additionalAuth,
+                userEnvVars,
                 forceRebuild,
                 forceRebuild,
             );
@@ -401,6 +404,10 @@ export class WorkspaceStarter {
             const metadata = new WorkspaceMetadata();
             metadata.setOwner(workspace.ownerId);
             metadata.setMetaId(workspace.id);
+            if (workspace.config.portAccessLog) {
+                // ws-manager passes this on to ws-proxy and supervisor
+                metadata.getAnnotationsMap().set("portAccessLog", "true");
+            }
             const startRequest = new StartWorkspaceRequest();
             startRequest.setId(instance.id);
             startRequest.setMetadata(metadata);
@@ -935,6 +942,7 @@ export class WorkspaceStarter {
         workspace: Workspace,
         instance: WorkspaceInstance,
         additionalAuth: Map<string, string>,
+        userEnvVars: UserEnvVarValue[],
         ignoreBaseImageresolvedAndRebuildBase: boolean = false,
         forceRebuild: boolean = false,
     ): Promise<WorkspaceInstance> {
@@ -957,6 +965,11 @@ export class WorkspaceStarter {
             req.setAuth(auth);
             req.setForceRebuild(forceRebuild);
             req.setTriggeredBy(user.id);
+            if (src.hasFile()) {
+                const { secrets, ssh } = this.resolveBuildSecrets(workspace, userEnvVars);
+                req.setSecretsList(secrets);
+                req.setSshList(ssh);
+            }
 
             // Make sure we persist logInfo as soon as we retrieve it
             const imageBuildLogInfo = new Deferred<ImageBuildLogInfo>();
@@ -1012,7 +1025,16 @@ export class WorkspaceStarter {
                 ) {
                     // we've attempted to add the base layer for a workspace whoose base image has gone missing.
                     // Ignore the previously built (now missing) base image and force a rebuild.
-                    return this.buildWorkspaceImage(ctx, user, workspace, instance, additionalAuth, true, forceRebuild);
+                    return this.buildWorkspaceImage(
+                        ctx,
+                        user,
+                        workspace,
+                        instance,
+                        additionalAuth,
+                        userEnvVars,
+                        true,
+                        forceRebuild,
+                    );
                 } else {
                     throw err;
                 }
@@ -1073,6 +1095,48 @@ export class WorkspaceStarter {
         }
     }
 
+    /**
+     * Resolves the build secrets and SSH keys a Dockerfile build asks for in the image config
+     * from the user's environment variables which apply to the workspace's repository.
+     */
+    protected resolveBuildSecrets(
+        workspace: Workspace,
+        userEnvVars: UserEnvVarValue[],
+    ): { secrets: BuildSecret[]; ssh: BuildSSHKey[] } {
+        const image = workspace.config.image;
+        if (!ImageConfigFile.is(image) || (!image.secrets?.length && !image.ssh?.length)) {
+            return { secrets: [], ssh: [] };
+        }
+
+        const context = workspace.context;
+        const envVars = CommitContext.is(context)
+            ? UserEnvVar.filter(userEnvVars, context.repository.owner, context.repository.name)
+            : userEnvVars;
+        const lookup = (name: string) => {
+            const envVar = envVars.find((e) => e.name === name);
+            if (!envVar) {
+                throw new Error(
+                    `The image build requires the environment variable ${name}, which is not set for this repository.`,
+                );
+            }
+            return envVar.value;
+        };
+
+        const secrets = (image.secrets || []).map((name) => {
+            const secret = new BuildSecret();
+            secret.setId(name);
+            secret.setValue(lookup(name));
+            return secret;
+        });
+        const ssh = (image.ssh || []).map((name) => {
+            const key = new BuildSSHKey();
+            key.setId(name);
+            key.setPrivateKey(lookup(name));
+            return key;
+        });
+        return { secrets, ssh };
+    }
+
     protected async createSpec(
         traceCtx: TraceContext,
         user: User,
@@ -15,6 +15,10 @@ import (
 	"github.com/gitpod-io/gitpod/supervisor/api"
 )
 
+var tunnelOpts struct {
+	UDP bool
+}
+
 var tunnelCmd = &cobra.Command{
 	Use:   "tunnel <localPort> [targetPort] [visibility]",
 	Short: "opens a new tunnel",
@@ -41,10 +45,15 @@ var tunnelCmd = &cobra.Command{
 
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
+		protocol := api.TunnelProtocol_tcp
+		if tunnelOpts.UDP {
+			protocol = api.TunnelProtocol_udp
+		}
 		_, err = client.Tunnel(ctx, &api.TunnelPortRequest{
 			Port:       uint32(localPort),
 			TargetPort: uint32(targetPort),
 			Visibility: visiblity,
+			Protocol:   protocol,
 		})
 		if err != nil {
 			log.WithError(err).Fatal("cannot tunnel")
@@ -102,6 +111,7 @@ var autoTunnelCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(tunnelCmd)
+	tunnelCmd.Flags().BoolVar(&tunnelOpts.UDP, "udp", false, "tunnel UDP instead of TCP")
 	tunnelCmd.AddCommand(closeTunnelCmd)
 	tunnelCmd.AddCommand(autoTunnelCmd)
 }
@@ -0,0 +1,77 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package ports
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+)
+
+// maxDatagramSize is the largest datagram a length prefix can describe
+const maxDatagramSize = 0xFFFF
+
+// datagramConn turns a connected datagram socket into a stream of datagrams,
+// each prefixed with its length as 2-byte big endian integer. This lets us carry
+// UDP over the stream-oriented tunnel channels.
+type datagramConn struct {
+	net.Conn
+
+	rmu  sync.Mutex
+	rbuf []byte
+	rpos []byte
+
+	wmu  sync.Mutex
+	wbuf []byte
+}
+
+func newDatagramConn(conn net.Conn) *datagramConn {
+	return &datagramConn{
+		Conn: conn,
+		rbuf: make([]byte, 2+maxDatagramSize),
+	}
+}
+
+// Read reads framed datagrams
+func (c *datagramConn) Read(p []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	if len(c.rpos) == 0 {
+		n, err := c.Conn.Read(c.rbuf[2:])
+		if err != nil {
+			return 0, err
+		}
+		binary.BigEndian.PutUint16(c.rbuf, uint16(n))
+		c.rpos = c.rbuf[:2+n]
+	}
+	n := copy(p, c.rpos)
+	c.rpos = c.rpos[n:]
+	return n, nil
+}
+
+// Write writes framed datagrams. Frames may be split across writes.
+func (c *datagramConn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.wbuf = append(c.wbuf, p...)
+	for len(c.wbuf) >= 2 {
+		size := int(binary.BigEndian.Uint16(c.wbuf))
+		if len(c.wbuf) < 2+size {
+			break
+		}
+		_, err := c.Conn.Write(c.wbuf[2 : 2+size])
+		if err != nil {
+			return 0, err
+		}
+		c.wbuf = c.wbuf[2+size:]
+	}
+	if len(c.wbuf) == 0 {
+		// don't hold on to large buffers
+		c.wbuf = nil
+	}
+	return len(p), nil
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package ports
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+func frame(datagrams ...string) []byte {
+	var res []byte
+	for _, d := range datagrams {
+		size := make([]byte, 2)
+		binary.BigEndian.PutUint16(size, uint16(len(d)))
+		res = append(res, size...)
+		res = append(res, d...)
+	}
+	return res
+}
+
+func TestUDPTunneling(t *testing.T) {
+	echo, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, addr, err := echo.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = echo.WriteTo(buf[:n], addr)
+		}
+	}()
+	port := uint32(echo.LocalAddr().(*net.UDPAddr).Port)
+
+	ctx := context.Background()
+	service := NewTunneledPortsService(false)
+	_, err = service.Tunnel(ctx, &TunnelOptions{}, &PortTunnelDescription{LocalPort: port, TargetPort: port, Visibility: api.TunnelVisiblity_host, Protocol: api.TunnelProtocol_udp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.EstablishTunnel(ctx, "test", port, port, api.TunnelProtocol_tcp)
+	if err == nil {
+		t.Error("expected a TCP tunnel to a UDP port to be rejected")
+	}
+	conn, err := service.EstablishTunnel(ctx, "test", port, port, api.TunnelProtocol_udp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// frames may be split arbitrarily across writes
+	data := frame("hello", "world")
+	for _, chunk := range [][]byte{data[:1], data[1:4], data[4:9], data[9:]} {
+		_, err = conn.Write(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectation := frame("hello", "world")
+	act := make([]byte, len(expectation))
+	_, err = io.ReadFull(conn, act)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expectation, act); diff != "" {
+		t.Errorf("unexpected datagrams (-want +got):\n%s", diff)
+	}
+}
+
+func TestTunnelProtocolValidation(t *testing.T) {
+	service := NewTunneledPortsService(false)
+	_, err := service.Tunnel(context.Background(), &TunnelOptions{}, &PortTunnelDescription{LocalPort: 8080, Protocol: api.TunnelProtocol_unix})
+	if err == nil {
+		t.Error("expected unix port tunnel to be rejected")
+	}
+}
+
+func TestSocketTunneling(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(conn, conn)
+	}()
+
+	service := NewTunneledPortsService(false)
+	_, err = service.EstablishSocketTunnel(context.Background(), "test", "relative.sock")
+	if err == nil {
+		t.Error("expected relative socket path to be rejected")
+	}
+
+	conn, err := service.EstablishSocketTunnel(context.Background(), "test", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte("ping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	act := make([]byte, 4)
+	_, err = io.ReadFull(conn, act)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(act) != "ping" {
+		t.Errorf("unexpected response: %q", act)
+	}
+}
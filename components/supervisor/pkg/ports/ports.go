@@ -117,6 +117,7 @@ type managedPort struct {
 	Tunneled           bool
 	TunneledTargetPort uint32
 	TunneledVisibility api.TunnelVisiblity
+	TunneledProtocol   api.TunnelProtocol
 	TunneledClients    map[string]uint32
 }
 
@@ -344,6 +345,7 @@ func (pm *Manager) nextState(ctx context.Context) map[uint32]*managedPort {
 		mp.Tunneled = true
 		mp.TunneledTargetPort = tunneled.Desc.TargetPort
 		mp.TunneledVisibility = tunneled.Desc.Visibility
+		mp.TunneledProtocol = tunneled.Desc.Protocol
 		mp.TunneledClients = tunneled.Clients
 	}
 
@@ -671,8 +673,13 @@ func (pm *Manager) CloseTunnel(ctx context.Context, port uint32) error {
 }
 
 // EstablishTunnel actually establishes the tunnel
-func (pm *Manager) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32) (net.Conn, error) {
-	return pm.T.EstablishTunnel(ctx, clientID, localPort, targetPort)
+func (pm *Manager) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32, protocol api.TunnelProtocol) (net.Conn, error) {
+	return pm.T.EstablishTunnel(ctx, clientID, localPort, targetPort, protocol)
+}
+
+// EstablishSocketTunnel establishes a tunnel to a Unix domain socket
+func (pm *Manager) EstablishSocketTunnel(ctx context.Context, clientID string, socketPath string) (net.Conn, error) {
+	return pm.T.EstablishSocketTunnel(ctx, clientID, socketPath)
 }
 
 // AutoTunnel controls enablement of auto tunneling
@@ -754,6 +761,7 @@ func (pm *Manager) getPortStatus(port uint32) *api.PortsStatus {
 			TargetPort: mp.TunneledTargetPort,
 			Visibility: mp.TunneledVisibility,
 			Clients:    mp.TunneledClients,
+			Protocol:   mp.TunneledProtocol,
 		}
 	}
 	return ps
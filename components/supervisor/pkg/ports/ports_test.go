@@ -614,7 +614,10 @@ func (tep *testTunneledPorts) Tunnel(ctx context.Context, options *TunnelOptions
 func (tep *testTunneledPorts) CloseTunnel(ctx context.Context, localPorts ...uint32) ([]uint32, error) {
 	return nil, nil
 }
-func (tep *testTunneledPorts) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32) (net.Conn, error) {
+func (tep *testTunneledPorts) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32, protocol api.TunnelProtocol) (net.Conn, error) {
+	return nil, nil
+}
+func (tep *testTunneledPorts) EstablishSocketTunnel(ctx context.Context, clientID string, socketPath string) (net.Conn, error) {
 	return nil, nil
 }
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"sync"
@@ -22,6 +23,8 @@ type PortTunnelDescription struct {
 	LocalPort  uint32
 	TargetPort uint32
 	Visibility api.TunnelVisiblity
+	// Protocol is either TCP or UDP. Unix domain sockets are tunneled using EstablishSocketTunnel.
+	Protocol api.TunnelProtocol
 }
 
 type PortTunnelState struct {
@@ -55,7 +58,11 @@ type TunneledPortsInterface interface {
 	CloseTunnel(ctx context.Context, localPorts ...uint32) ([]uint32, error)
 
 	// EstablishTunnel actually establishes the tunnel for an incoming connection on a remote machine.
-	EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32) (net.Conn, error)
+	// The protocol must match the one the port was tunneled with.
+	EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32, protocol api.TunnelProtocol) (net.Conn, error)
+
+	// EstablishSocketTunnel establishes a tunnel for an incoming connection to a Unix domain socket in the workspace.
+	EstablishSocketTunnel(ctx context.Context, clientID string, socketPath string) (net.Conn, error)
 }
 
 // TunneledPortsService observes the tunneled ports.
@@ -129,6 +136,9 @@ func (desc *PortTunnelDescription) validate() (err error) {
 	if desc.TargetPort > 0xFFFF {
 		return xerrors.Errorf("bad target port: %d", desc.TargetPort)
 	}
+	if desc.Protocol != api.TunnelProtocol_tcp && desc.Protocol != api.TunnelProtocol_udp {
+		return xerrors.Errorf("bad protocol: %s", desc.Protocol)
+	}
 	return nil
 }
 
@@ -205,7 +215,7 @@ func (p *TunneledPortsService) CloseTunnel(ctx context.Context, localPorts ...ui
 }
 
 // EstablishTunnel actually establishes the tunnel.
-func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32) (net.Conn, error) {
+func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID string, localPort uint32, targetPort uint32, protocol api.TunnelProtocol) (net.Conn, error) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
@@ -218,11 +228,24 @@ func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID str
 	} else {
 		return nil, xerrors.Errorf("client '%s': '%d' tunnel does not exist", clientID, localPort)
 	}
+	if tunnel.State.Desc.Protocol != protocol {
+		return nil, xerrors.Errorf("client '%s': '%d' is tunneled using %s, not %s", clientID, localPort, tunnel.State.Desc.Protocol, protocol)
+	}
 
 	addr := net.JoinHostPort("localhost", strconv.FormatInt(int64(localPort), 10))
-	conn, err := net.Dial("tcp", addr)
-	if err != nil {
-		return nil, err
+	var conn net.Conn
+	if tunnel.State.Desc.Protocol == api.TunnelProtocol_udp {
+		udpConn, err := net.Dial("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		conn = newDatagramConn(udpConn)
+	} else {
+		var err error
+		conn, err = net.Dial("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
 	}
 	var result net.Conn
 	result = &tunnelConn{
@@ -250,6 +273,16 @@ func (p *TunneledPortsService) EstablishTunnel(ctx context.Context, clientID str
 	return result, nil
 }
 
+// EstablishSocketTunnel establishes a tunnel to a Unix domain socket. Unlike port tunnels, socket tunnels
+// need not be announced to clients first: clients ask for them explicitly.
+func (p *TunneledPortsService) EstablishSocketTunnel(ctx context.Context, clientID string, socketPath string) (net.Conn, error) {
+	if !filepath.IsAbs(socketPath) {
+		return nil, xerrors.Errorf("client '%s': socket path '%s' is not absolute", clientID, socketPath)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", socketPath)
+}
+
 // Snapshot writes a snapshot to w.
 func (p *TunneledPortsService) Snapshot(w io.Writer) {
 	p.mu.RLock()
@@ -267,6 +300,7 @@ func (p *TunneledPortsService) Snapshot(w io.Writer) {
 		fmt.Fprintf(w, "Target Port: %d\n", tunnel.State.Desc.TargetPort)
 		visibilty := api.TunnelVisiblity_name[int32(tunnel.State.Desc.Visibility)]
 		fmt.Fprintf(w, "Visibility: %s\n", visibilty)
+		fmt.Fprintf(w, "Protocol: %s\n", tunnel.State.Desc.Protocol)
 		for clientID, remotePort := range tunnel.State.Clients {
 			fmt.Fprintf(w, "Client: %s\n", clientID)
 			fmt.Fprintf(w, "  Remote Port: %d\n", remotePort)
@@ -119,7 +119,7 @@ func TestLocalPortTunneling(t *testing.T) {
 		}
 		defer src.Close()
 
-		dst, err := service.EstablishTunnel(ctx, "test", localPort, targetPort)
+		dst, err := service.EstablishTunnel(ctx, "test", localPort, targetPort, api.TunnelProtocol_tcp)
 		if err != nil {
 			return err
 		}
@@ -235,6 +235,9 @@ type WorkspaceConfig struct {
 	// DebugEnabled controls whether the supervisor debugging facilities (pprof, grpc tracing) should be enabled
 	DebugEnable bool `env:"SUPERVISOR_DEBUG_ENABLE"`
 
+	// PortAccessLog is true if the workspace config opted in to have ws-proxy log the requests to its ports
+	PortAccessLog bool `env:"GITPOD_PORT_ACCESS_LOG"`
+
 	// WorkspaceContext is a context for this workspace
 	WorkspaceContext string `env:"GITPOD_WORKSPACE_CONTEXT"`
 
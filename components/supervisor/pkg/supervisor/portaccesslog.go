@@ -0,0 +1,105 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+const (
+	// portAccessLogSize is the number of port access log entries we keep
+	portAccessLogSize = 500
+	// maxPortAccessLogBatchSize limits the size of the batches ws-proxy forwards to us
+	maxPortAccessLogBatchSize = 1 << 20
+)
+
+// portAccessLogEntry describes a single request to a workspace port as ws-proxy saw it
+type portAccessLogEntry struct {
+	Time       time.Time `json:"time"`
+	Port       string    `json:"port"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMs int64     `json:"durationMs"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+}
+
+// portAccessLog keeps the most recent requests to the ports of this workspace if the workspace config
+// opted in to port access logging. ws-proxy forwards the requests it proxied to us.
+//
+// The log is meant for debugging: anyone in the workspace can add entries.
+type portAccessLog struct {
+	Enabled bool
+
+	mu      sync.Mutex
+	entries []portAccessLogEntry
+	next    int
+}
+
+// Record adds entries to the access log, dropping the oldest ones once the log is full
+func (l *portAccessLog) Record(entries ...portAccessLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, entry := range entries {
+		if len(l.entries) < portAccessLogSize {
+			l.entries = append(l.entries, entry)
+			continue
+		}
+		l.entries[l.next] = entry
+		l.next = (l.next + 1) % portAccessLogSize
+	}
+}
+
+// Entries returns the access log, oldest entry first
+func (l *portAccessLog) Entries() []portAccessLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	res := make([]portAccessLogEntry, 0, len(l.entries))
+	res = append(res, l.entries[l.next:]...)
+	res = append(res, l.entries[:l.next]...)
+	return res
+}
+
+type portAccessLogResponse struct {
+	Enabled bool                 `json:"enabled"`
+	Entries []portAccessLogEntry `json:"entries"`
+}
+
+// ServeHTTP shows the access log on GET and records the entries ws-proxy forwards on POST
+func (l *portAccessLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		err := json.NewEncoder(w).Encode(portAccessLogResponse{Enabled: l.Enabled, Entries: l.Entries()})
+		if err != nil {
+			log.WithError(err).Debug("cannot serve port access log")
+		}
+	case http.MethodPost:
+		if !l.Enabled {
+			http.Error(w, "port access log is not enabled", http.StatusNotFound)
+			return
+		}
+		var entries []portAccessLogEntry
+		err := json.NewDecoder(io.LimitReader(r.Body, maxPortAccessLogBatchSize)).Decode(&entries)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		l.Record(entries...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
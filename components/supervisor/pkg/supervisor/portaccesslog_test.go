@@ -0,0 +1,110 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package supervisor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPortAccessLog(t *testing.T) {
+	type request struct {
+		Method string
+		Body   string
+	}
+	tests := []struct {
+		Name        string
+		Enabled     bool
+		Requests    []request
+		Status      int
+		Expectation *portAccessLogResponse
+	}{
+		{
+			Name:        "disabled",
+			Requests:    []request{{Method: http.MethodGet}},
+			Status:      http.StatusOK,
+			Expectation: &portAccessLogResponse{Entries: []portAccessLogEntry{}},
+		},
+		{
+			Name:     "record while disabled",
+			Requests: []request{{Method: http.MethodPost, Body: `[{"status":200}]`}},
+			Status:   http.StatusNotFound,
+		},
+		{
+			Name:    "record",
+			Enabled: true,
+			Requests: []request{
+				{Method: http.MethodPost, Body: `[{"port":"3000","status":200},{"port":"3000","status":404}]`},
+				{Method: http.MethodPost, Body: `[{"port":"8080","status":500}]`},
+				{Method: http.MethodGet},
+			},
+			Status: http.StatusOK,
+			Expectation: &portAccessLogResponse{
+				Enabled: true,
+				Entries: []portAccessLogEntry{
+					{Port: "3000", Status: 200},
+					{Port: "3000", Status: 404},
+					{Port: "8080", Status: 500},
+				},
+			},
+		},
+		{
+			Name:     "invalid entries",
+			Enabled:  true,
+			Requests: []request{{Method: http.MethodPost, Body: `{"status":200}`}},
+			Status:   http.StatusBadRequest,
+		},
+		{
+			Name:     "unsupported method",
+			Enabled:  true,
+			Requests: []request{{Method: http.MethodDelete}},
+			Status:   http.StatusMethodNotAllowed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			l := &portAccessLog{Enabled: test.Enabled}
+			var rec *httptest.ResponseRecorder
+			for _, req := range test.Requests {
+				rec = httptest.NewRecorder()
+				l.ServeHTTP(rec, httptest.NewRequest(req.Method, "/_supervisor/port-access-log", strings.NewReader(req.Body)))
+			}
+			if rec.Code != test.Status {
+				t.Fatalf("unexpected status: expected %d, got %d: %s", test.Status, rec.Code, rec.Body.String())
+			}
+			if test.Expectation == nil {
+				return
+			}
+			var act portAccessLogResponse
+			err := json.Unmarshal(rec.Body.Bytes(), &act)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expectation, &act); diff != "" {
+				t.Errorf("unexpected access log (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPortAccessLogRecord(t *testing.T) {
+	l := &portAccessLog{Enabled: true}
+	for i := 0; i < portAccessLogSize+2; i++ {
+		l.Record(portAccessLogEntry{Status: i})
+	}
+
+	entries := l.Entries()
+	if len(entries) != portAccessLogSize {
+		t.Fatalf("expected %d entries, got %d", portAccessLogSize, len(entries))
+	}
+	if first, last := entries[0].Status, entries[len(entries)-1].Status; first != 2 || last != portAccessLogSize+1 {
+		t.Errorf("expected entries 2..%d, got %d..%d", portAccessLogSize+1, first, last)
+	}
+}
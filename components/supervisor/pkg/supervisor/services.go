@@ -9,6 +9,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -838,6 +839,7 @@ func (s *portService) Tunnel(ctx context.Context, req *api.TunnelPortRequest) (*
 		LocalPort:  req.Port,
 		TargetPort: req.TargetPort,
 		Visibility: req.Visibility,
+		Protocol:   req.Protocol,
 	})
 	if err != nil {
 		return nil, err
@@ -861,11 +863,16 @@ func (s *portService) EstablishTunnel(stream api.PortService_EstablishTunnelServ
 		return status.Error(codes.Internal, err.Error())
 	}
 	desc := req.GetDesc()
-	if desc != nil {
+	if desc == nil {
 		return status.Error(codes.FailedPrecondition, "first request should be a desc")
 	}
 
-	tunnel, err := s.portsManager.EstablishTunnel(stream.Context(), desc.ClientId, desc.Port, desc.TargetPort)
+	var tunnel net.Conn
+	if desc.Protocol == api.TunnelProtocol_unix {
+		tunnel, err = s.portsManager.EstablishSocketTunnel(stream.Context(), desc.ClientId, desc.SocketPath)
+	} else {
+		tunnel, err = s.portsManager.EstablishTunnel(stream.Context(), desc.ClientId, desc.Port, desc.TargetPort, desc.Protocol)
+	}
 	if err != nil {
 		return status.Errorf(codes.Internal, "failed establish the tunnel: %v", err)
 	}
@@ -1110,6 +1110,8 @@ func startAPIEndpoint(ctx context.Context, cfg *Config, wg *sync.WaitGroup, serv
 		tunnelOverWebSocket(tunneled, conn)
 	}))
 	routes.Handle("/_supervisor/frontend", http.FileServer(http.Dir(cfg.FrontendLocation)))
+	// ws-proxy forwards the access log to this route, users look at it through the workspace URL
+	routes.Handle("/_supervisor/port-access-log", &portAccessLog{Enabled: cfg.PortAccessLog})
 	if cfg.DebugEnable {
 		routes.Handle("/_supervisor/debug/tunnels", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			rw.Header().Set("X-Content-Type-Options", "nosniff")
@@ -1147,7 +1149,7 @@ func tunnelOverWebSocket(tunneled *ports.TunneledPortsService, conn *gitpod.Webs
 		_ = conn.Wait()
 		sshConn.Close()
 	}()
-	go ssh.DiscardRequests(reqs)
+	go handleReverseTunnelRequests(conn.Ctx, sshConn, reqs)
 	go func() {
 		for ch := range chans {
 			go tunnelOverSSH(conn.Ctx, tunneled, ch)
@@ -1176,7 +1178,12 @@ func tunnelOverSSH(ctx context.Context, tunneled *ports.TunneledPortsService, ne
 		return
 	}
 
-	tunnel, err := tunneled.EstablishTunnel(ctx, tunnelReq.ClientId, tunnelReq.Port, tunnelReq.TargetPort)
+	var tunnel net.Conn
+	if tunnelReq.Protocol == api.TunnelProtocol_unix {
+		tunnel, err = tunneled.EstablishSocketTunnel(ctx, tunnelReq.ClientId, tunnelReq.SocketPath)
+	} else {
+		tunnel, err = tunneled.EstablishTunnel(ctx, tunnelReq.ClientId, tunnelReq.Port, tunnelReq.TargetPort, tunnelReq.Protocol)
+	}
 	if err != nil {
 		log.WithError(err).Error("tunnel: failed to establish")
 		_ = newCh.Reject(ssh.Prohibited, err.Error())
@@ -1205,6 +1212,101 @@ func tunnelOverSSH(ctx context.Context, tunneled *ports.TunneledPortsService, ne
 	<-ctx.Done()
 }
 
+const (
+	// reverseTunnelRequest asks us to listen on a Unix domain socket in the workspace and to tunnel
+	// connections to the client, which forwards them to a socket on its machine
+	reverseTunnelRequest = "reverse-tunnel@gitpod.io"
+	// cancelReverseTunnelRequest stops listening on a Unix domain socket
+	cancelReverseTunnelRequest = "cancel-reverse-tunnel@gitpod.io"
+)
+
+func handleReverseTunnelRequests(ctx context.Context, sshConn ssh.Conn, reqs <-chan *ssh.Request) {
+	listeners := make(map[string]net.Listener)
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+
+	reply := func(req *ssh.Request, ok bool) {
+		if req.WantReply {
+			_ = req.Reply(ok, nil)
+		}
+	}
+	for req := range reqs {
+		if req.Type != reverseTunnelRequest && req.Type != cancelReverseTunnelRequest {
+			reply(req, false)
+			continue
+		}
+		tunnelReq := &api.TunnelPortRequest{}
+		err := proto.Unmarshal(req.Payload, tunnelReq)
+		if err != nil || tunnelReq.Protocol != api.TunnelProtocol_unix || !filepath.IsAbs(tunnelReq.SocketPath) {
+			log.WithError(err).Error("tunnel: invalid reverse tunnel request")
+			reply(req, false)
+			continue
+		}
+
+		socketPath := tunnelReq.SocketPath
+		if req.Type == cancelReverseTunnelRequest {
+			if l, exists := listeners[socketPath]; exists {
+				l.Close()
+				delete(listeners, socketPath)
+			}
+			reply(req, true)
+			continue
+		}
+		if _, exists := listeners[socketPath]; exists {
+			reply(req, true)
+			continue
+		}
+
+		// we replace stale sockets, but never other files
+		if stat, err := os.Lstat(socketPath); err == nil && stat.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(socketPath)
+		}
+		l, err := net.Listen("unix", socketPath)
+		if err != nil {
+			log.WithError(err).WithField("socket", socketPath).Error("tunnel: cannot listen for reverse tunnel")
+			reply(req, false)
+			continue
+		}
+		listeners[socketPath] = l
+		go serveReverseTunnel(ctx, sshConn, l, req.Payload)
+		reply(req, true)
+	}
+}
+
+func serveReverseTunnel(ctx context.Context, sshConn ssh.Conn, l net.Listener, payload []byte) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+
+			sshChan, reqs, err := sshConn.OpenChannel("tunnel", payload)
+			if err != nil {
+				log.WithError(err).Error("tunnel: cannot open reverse tunnel channel")
+				return
+			}
+			defer sshChan.Close()
+			go ssh.DiscardRequests(reqs)
+
+			ctx, cancel := context.WithCancel(ctx)
+			go func() {
+				_, _ = io.Copy(sshChan, conn)
+				cancel()
+			}()
+			go func() {
+				_, _ = io.Copy(conn, sshChan)
+				cancel()
+			}()
+			<-ctx.Done()
+		}()
+	}
+}
+
 func stopWhenTasksAreDone(ctx context.Context, wg *sync.WaitGroup, shutdown chan ShutdownReason, successChan <-chan taskSuccess) {
 	defer wg.Done()
 	defer close(shutdown)
@@ -38,6 +38,10 @@ type WorkspaceContentServiceClient interface {
 	DisposeWorkspace(ctx context.Context, in *DisposeWorkspaceRequest, opts ...grpc.CallOption) (*DisposeWorkspaceResponse, error)
 	// BackupWorkspace creates a backup of a workspace
 	BackupWorkspace(ctx context.Context, in *BackupWorkspaceRequest, opts ...grpc.CallOption) (*BackupWorkspaceResponse, error)
+	// ListWorkspaceFiles lists a directory of the workspace content. The content is accessed read-only.
+	ListWorkspaceFiles(ctx context.Context, in *ListWorkspaceFilesRequest, opts ...grpc.CallOption) (*ListWorkspaceFilesResponse, error)
+	// ReadWorkspaceFile streams a file of the workspace content. The content is accessed read-only.
+	ReadWorkspaceFile(ctx context.Context, in *ReadWorkspaceFileRequest, opts ...grpc.CallOption) (WorkspaceContentService_ReadWorkspaceFileClient, error)
 }
 
 type workspaceContentServiceClient struct {
@@ -93,6 +97,47 @@ func (c *workspaceContentServiceClient) BackupWorkspace(ctx context.Context, in
 	return out, nil
 }
 
+func (c *workspaceContentServiceClient) ListWorkspaceFiles(ctx context.Context, in *ListWorkspaceFilesRequest, opts ...grpc.CallOption) (*ListWorkspaceFilesResponse, error) {
+	out := new(ListWorkspaceFilesResponse)
+	err := c.cc.Invoke(ctx, "/wsdaemon.WorkspaceContentService/ListWorkspaceFiles", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workspaceContentServiceClient) ReadWorkspaceFile(ctx context.Context, in *ReadWorkspaceFileRequest, opts ...grpc.CallOption) (WorkspaceContentService_ReadWorkspaceFileClient, error) {
+	stream, err := c.cc.NewStream(ctx, &WorkspaceContentService_ServiceDesc.Streams[0], "/wsdaemon.WorkspaceContentService/ReadWorkspaceFile", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &workspaceContentServiceReadWorkspaceFileClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type WorkspaceContentService_ReadWorkspaceFileClient interface {
+	Recv() (*ReadWorkspaceFileResponse, error)
+	grpc.ClientStream
+}
+
+type workspaceContentServiceReadWorkspaceFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *workspaceContentServiceReadWorkspaceFileClient) Recv() (*ReadWorkspaceFileResponse, error) {
+	m := new(ReadWorkspaceFileResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // WorkspaceContentServiceServer is the server API for WorkspaceContentService service.
 // All implementations must embed UnimplementedWorkspaceContentServiceServer
 // for forward compatibility
@@ -109,6 +154,10 @@ type WorkspaceContentServiceServer interface {
 	DisposeWorkspace(context.Context, *DisposeWorkspaceRequest) (*DisposeWorkspaceResponse, error)
 	// BackupWorkspace creates a backup of a workspace
 	BackupWorkspace(context.Context, *BackupWorkspaceRequest) (*BackupWorkspaceResponse, error)
+	// ListWorkspaceFiles lists a directory of the workspace content. The content is accessed read-only.
+	ListWorkspaceFiles(context.Context, *ListWorkspaceFilesRequest) (*ListWorkspaceFilesResponse, error)
+	// ReadWorkspaceFile streams a file of the workspace content. The content is accessed read-only.
+	ReadWorkspaceFile(*ReadWorkspaceFileRequest, WorkspaceContentService_ReadWorkspaceFileServer) error
 	mustEmbedUnimplementedWorkspaceContentServiceServer()
 }
 
@@ -131,6 +180,12 @@ func (UnimplementedWorkspaceContentServiceServer) DisposeWorkspace(context.Conte
 func (UnimplementedWorkspaceContentServiceServer) BackupWorkspace(context.Context, *BackupWorkspaceRequest) (*BackupWorkspaceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BackupWorkspace not implemented")
 }
+func (UnimplementedWorkspaceContentServiceServer) ListWorkspaceFiles(context.Context, *ListWorkspaceFilesRequest) (*ListWorkspaceFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorkspaceFiles not implemented")
+}
+func (UnimplementedWorkspaceContentServiceServer) ReadWorkspaceFile(*ReadWorkspaceFileRequest, WorkspaceContentService_ReadWorkspaceFileServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReadWorkspaceFile not implemented")
+}
 func (UnimplementedWorkspaceContentServiceServer) mustEmbedUnimplementedWorkspaceContentServiceServer() {
 }
 
@@ -235,6 +290,45 @@ func _WorkspaceContentService_BackupWorkspace_Handler(srv interface{}, ctx conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WorkspaceContentService_ListWorkspaceFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkspaceFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkspaceContentServiceServer).ListWorkspaceFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wsdaemon.WorkspaceContentService/ListWorkspaceFiles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkspaceContentServiceServer).ListWorkspaceFiles(ctx, req.(*ListWorkspaceFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorkspaceContentService_ReadWorkspaceFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadWorkspaceFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WorkspaceContentServiceServer).ReadWorkspaceFile(m, &workspaceContentServiceReadWorkspaceFileServer{stream})
+}
+
+type WorkspaceContentService_ReadWorkspaceFileServer interface {
+	Send(*ReadWorkspaceFileResponse) error
+	grpc.ServerStream
+}
+
+type workspaceContentServiceReadWorkspaceFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *workspaceContentServiceReadWorkspaceFileServer) Send(m *ReadWorkspaceFileResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // WorkspaceContentService_ServiceDesc is the grpc.ServiceDesc for WorkspaceContentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -262,7 +356,17 @@ var WorkspaceContentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BackupWorkspace",
 			Handler:    _WorkspaceContentService_BackupWorkspace_Handler,
 		},
+		{
+			MethodName: "ListWorkspaceFiles",
+			Handler:    _WorkspaceContentService_ListWorkspaceFiles_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadWorkspaceFile",
+			Handler:       _WorkspaceContentService_ReadWorkspaceFile_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "daemon.proto",
 }
@@ -100,6 +100,46 @@ func (mr *MockWorkspaceContentServiceClientMockRecorder) InitWorkspace(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitWorkspace", reflect.TypeOf((*MockWorkspaceContentServiceClient)(nil).InitWorkspace), varargs...)
 }
 
+// ListWorkspaceFiles mocks base method.
+func (m *MockWorkspaceContentServiceClient) ListWorkspaceFiles(arg0 context.Context, arg1 *api.ListWorkspaceFilesRequest, arg2 ...grpc.CallOption) (*api.ListWorkspaceFilesResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListWorkspaceFiles", varargs...)
+	ret0, _ := ret[0].(*api.ListWorkspaceFilesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWorkspaceFiles indicates an expected call of ListWorkspaceFiles.
+func (mr *MockWorkspaceContentServiceClientMockRecorder) ListWorkspaceFiles(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWorkspaceFiles", reflect.TypeOf((*MockWorkspaceContentServiceClient)(nil).ListWorkspaceFiles), varargs...)
+}
+
+// ReadWorkspaceFile mocks base method.
+func (m *MockWorkspaceContentServiceClient) ReadWorkspaceFile(arg0 context.Context, arg1 *api.ReadWorkspaceFileRequest, arg2 ...grpc.CallOption) (api.WorkspaceContentService_ReadWorkspaceFileClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ReadWorkspaceFile", varargs...)
+	ret0, _ := ret[0].(api.WorkspaceContentService_ReadWorkspaceFileClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadWorkspaceFile indicates an expected call of ReadWorkspaceFile.
+func (mr *MockWorkspaceContentServiceClientMockRecorder) ReadWorkspaceFile(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadWorkspaceFile", reflect.TypeOf((*MockWorkspaceContentServiceClient)(nil).ReadWorkspaceFile), varargs...)
+}
+
 // TakeSnapshot mocks base method.
 func (m *MockWorkspaceContentServiceClient) TakeSnapshot(arg0 context.Context, arg1 *api.TakeSnapshotRequest, arg2 ...grpc.CallOption) (*api.TakeSnapshotResponse, error) {
 	m.ctrl.T.Helper()
@@ -209,6 +249,35 @@ func (mr *MockWorkspaceContentServiceServerMockRecorder) InitWorkspace(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitWorkspace", reflect.TypeOf((*MockWorkspaceContentServiceServer)(nil).InitWorkspace), arg0, arg1)
 }
 
+// ListWorkspaceFiles mocks base method.
+func (m *MockWorkspaceContentServiceServer) ListWorkspaceFiles(arg0 context.Context, arg1 *api.ListWorkspaceFilesRequest) (*api.ListWorkspaceFilesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWorkspaceFiles", arg0, arg1)
+	ret0, _ := ret[0].(*api.ListWorkspaceFilesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWorkspaceFiles indicates an expected call of ListWorkspaceFiles.
+func (mr *MockWorkspaceContentServiceServerMockRecorder) ListWorkspaceFiles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWorkspaceFiles", reflect.TypeOf((*MockWorkspaceContentServiceServer)(nil).ListWorkspaceFiles), arg0, arg1)
+}
+
+// ReadWorkspaceFile mocks base method.
+func (m *MockWorkspaceContentServiceServer) ReadWorkspaceFile(arg0 *api.ReadWorkspaceFileRequest, arg1 api.WorkspaceContentService_ReadWorkspaceFileServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadWorkspaceFile", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReadWorkspaceFile indicates an expected call of ReadWorkspaceFile.
+func (mr *MockWorkspaceContentServiceServerMockRecorder) ReadWorkspaceFile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadWorkspaceFile", reflect.TypeOf((*MockWorkspaceContentServiceServer)(nil).ReadWorkspaceFile), arg0, arg1)
+}
+
 // TakeSnapshot mocks base method.
 func (m *MockWorkspaceContentServiceServer) TakeSnapshot(arg0 context.Context, arg1 *api.TakeSnapshotRequest) (*api.TakeSnapshotResponse, error) {
 	m.ctrl.T.Helper()
@@ -13,6 +13,7 @@
 import * as grpc from "@grpc/grpc-js";
 import * as daemon_pb from "./daemon_pb";
 import * as content_service_api_initializer_pb from "@gitpod/content-service/lib";
+import * as google_protobuf_timestamp_pb from "google-protobuf/google/protobuf/timestamp_pb";
 
 interface IWorkspaceContentServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
     initWorkspace: IWorkspaceContentServiceService_IInitWorkspace;
@@ -20,6 +21,8 @@ interface IWorkspaceContentServiceService extends grpc.ServiceDefinition<grpc.Un
     takeSnapshot: IWorkspaceContentServiceService_ITakeSnapshot;
     disposeWorkspace: IWorkspaceContentServiceService_IDisposeWorkspace;
     backupWorkspace: IWorkspaceContentServiceService_IBackupWorkspace;
+    listWorkspaceFiles: IWorkspaceContentServiceService_IListWorkspaceFiles;
+    readWorkspaceFile: IWorkspaceContentServiceService_IReadWorkspaceFile;
 }
 
 interface IWorkspaceContentServiceService_IInitWorkspace extends grpc.MethodDefinition<daemon_pb.InitWorkspaceRequest, daemon_pb.InitWorkspaceResponse> {
@@ -67,6 +70,24 @@ interface IWorkspaceContentServiceService_IBackupWorkspace extends grpc.MethodDe
     responseSerialize: grpc.serialize<daemon_pb.BackupWorkspaceResponse>;
     responseDeserialize: grpc.deserialize<daemon_pb.BackupWorkspaceResponse>;
 }
+interface IWorkspaceContentServiceService_IListWorkspaceFiles extends grpc.MethodDefinition<daemon_pb.ListWorkspaceFilesRequest, daemon_pb.ListWorkspaceFilesResponse> {
+    path: "/wsdaemon.WorkspaceContentService/ListWorkspaceFiles";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<daemon_pb.ListWorkspaceFilesRequest>;
+    requestDeserialize: grpc.deserialize<daemon_pb.ListWorkspaceFilesRequest>;
+    responseSerialize: grpc.serialize<daemon_pb.ListWorkspaceFilesResponse>;
+    responseDeserialize: grpc.deserialize<daemon_pb.ListWorkspaceFilesResponse>;
+}
+interface IWorkspaceContentServiceService_IReadWorkspaceFile extends grpc.MethodDefinition<daemon_pb.ReadWorkspaceFileRequest, daemon_pb.ReadWorkspaceFileResponse> {
+    path: "/wsdaemon.WorkspaceContentService/ReadWorkspaceFile";
+    requestStream: false;
+    responseStream: true;
+    requestSerialize: grpc.serialize<daemon_pb.ReadWorkspaceFileRequest>;
+    requestDeserialize: grpc.deserialize<daemon_pb.ReadWorkspaceFileRequest>;
+    responseSerialize: grpc.serialize<daemon_pb.ReadWorkspaceFileResponse>;
+    responseDeserialize: grpc.deserialize<daemon_pb.ReadWorkspaceFileResponse>;
+}
 
 export const WorkspaceContentServiceService: IWorkspaceContentServiceService;
 
@@ -76,6 +97,8 @@ export interface IWorkspaceContentServiceServer extends grpc.UntypedServiceImple
     takeSnapshot: grpc.handleUnaryCall<daemon_pb.TakeSnapshotRequest, daemon_pb.TakeSnapshotResponse>;
     disposeWorkspace: grpc.handleUnaryCall<daemon_pb.DisposeWorkspaceRequest, daemon_pb.DisposeWorkspaceResponse>;
     backupWorkspace: grpc.handleUnaryCall<daemon_pb.BackupWorkspaceRequest, daemon_pb.BackupWorkspaceResponse>;
+    listWorkspaceFiles: grpc.handleUnaryCall<daemon_pb.ListWorkspaceFilesRequest, daemon_pb.ListWorkspaceFilesResponse>;
+    readWorkspaceFile: grpc.handleServerStreamingCall<daemon_pb.ReadWorkspaceFileRequest, daemon_pb.ReadWorkspaceFileResponse>;
 }
 
 export interface IWorkspaceContentServiceClient {
@@ -94,6 +117,11 @@ export interface IWorkspaceContentServiceClient {
     backupWorkspace(request: daemon_pb.BackupWorkspaceRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.BackupWorkspaceResponse) => void): grpc.ClientUnaryCall;
     backupWorkspace(request: daemon_pb.BackupWorkspaceRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.BackupWorkspaceResponse) => void): grpc.ClientUnaryCall;
     backupWorkspace(request: daemon_pb.BackupWorkspaceRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.BackupWorkspaceResponse) => void): grpc.ClientUnaryCall;
+    listWorkspaceFiles(request: daemon_pb.ListWorkspaceFilesRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.ListWorkspaceFilesResponse) => void): grpc.ClientUnaryCall;
+    listWorkspaceFiles(request: daemon_pb.ListWorkspaceFilesRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.ListWorkspaceFilesResponse) => void): grpc.ClientUnaryCall;
+    listWorkspaceFiles(request: daemon_pb.ListWorkspaceFilesRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.ListWorkspaceFilesResponse) => void): grpc.ClientUnaryCall;
+    readWorkspaceFile(request: daemon_pb.ReadWorkspaceFileRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<daemon_pb.ReadWorkspaceFileResponse>;
+    readWorkspaceFile(request: daemon_pb.ReadWorkspaceFileRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<daemon_pb.ReadWorkspaceFileResponse>;
 }
 
 export class WorkspaceContentServiceClient extends grpc.Client implements IWorkspaceContentServiceClient {
@@ -113,4 +141,9 @@ export class WorkspaceContentServiceClient extends grpc.Client implements IWorks
     public backupWorkspace(request: daemon_pb.BackupWorkspaceRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.BackupWorkspaceResponse) => void): grpc.ClientUnaryCall;
     public backupWorkspace(request: daemon_pb.BackupWorkspaceRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.BackupWorkspaceResponse) => void): grpc.ClientUnaryCall;
     public backupWorkspace(request: daemon_pb.BackupWorkspaceRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.BackupWorkspaceResponse) => void): grpc.ClientUnaryCall;
+    public listWorkspaceFiles(request: daemon_pb.ListWorkspaceFilesRequest, callback: (error: grpc.ServiceError | null, response: daemon_pb.ListWorkspaceFilesResponse) => void): grpc.ClientUnaryCall;
+    public listWorkspaceFiles(request: daemon_pb.ListWorkspaceFilesRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: daemon_pb.ListWorkspaceFilesResponse) => void): grpc.ClientUnaryCall;
+    public listWorkspaceFiles(request: daemon_pb.ListWorkspaceFilesRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: daemon_pb.ListWorkspaceFilesResponse) => void): grpc.ClientUnaryCall;
+    public readWorkspaceFile(request: daemon_pb.ReadWorkspaceFileRequest, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<daemon_pb.ReadWorkspaceFileResponse>;
+    public readWorkspaceFile(request: daemon_pb.ReadWorkspaceFileRequest, metadata?: grpc.Metadata, options?: Partial<grpc.CallOptions>): grpc.ClientReadableStream<daemon_pb.ReadWorkspaceFileResponse>;
 }
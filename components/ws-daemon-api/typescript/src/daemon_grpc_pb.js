@@ -10,6 +10,7 @@
 var grpc = require('@grpc/grpc-js');
 var daemon_pb = require('./daemon_pb.js');
 var content$service$api_initializer_pb = require('@gitpod/content-service/lib');
+var google_protobuf_timestamp_pb = require('google-protobuf/google/protobuf/timestamp_pb.js');
 
 function serialize_wsdaemon_BackupWorkspaceRequest(arg) {
   if (!(arg instanceof daemon_pb.BackupWorkspaceRequest)) {
@@ -77,6 +78,50 @@ function deserialize_wsdaemon_InitWorkspaceResponse(buffer_arg) {
   return daemon_pb.InitWorkspaceResponse.deserializeBinary(new Uint8Array(buffer_arg));
 }
 
+function serialize_wsdaemon_ListWorkspaceFilesRequest(arg) {
+  if (!(arg instanceof daemon_pb.ListWorkspaceFilesRequest)) {
+    throw new Error('Expected argument of type wsdaemon.ListWorkspaceFilesRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_ListWorkspaceFilesRequest(buffer_arg) {
+  return daemon_pb.ListWorkspaceFilesRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_ListWorkspaceFilesResponse(arg) {
+  if (!(arg instanceof daemon_pb.ListWorkspaceFilesResponse)) {
+    throw new Error('Expected argument of type wsdaemon.ListWorkspaceFilesResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_ListWorkspaceFilesResponse(buffer_arg) {
+  return daemon_pb.ListWorkspaceFilesResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_ReadWorkspaceFileRequest(arg) {
+  if (!(arg instanceof daemon_pb.ReadWorkspaceFileRequest)) {
+    throw new Error('Expected argument of type wsdaemon.ReadWorkspaceFileRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_ReadWorkspaceFileRequest(buffer_arg) {
+  return daemon_pb.ReadWorkspaceFileRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_wsdaemon_ReadWorkspaceFileResponse(arg) {
+  if (!(arg instanceof daemon_pb.ReadWorkspaceFileResponse)) {
+    throw new Error('Expected argument of type wsdaemon.ReadWorkspaceFileResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_wsdaemon_ReadWorkspaceFileResponse(buffer_arg) {
+  return daemon_pb.ReadWorkspaceFileResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_wsdaemon_TakeSnapshotRequest(arg) {
   if (!(arg instanceof daemon_pb.TakeSnapshotRequest)) {
     throw new Error('Expected argument of type wsdaemon.TakeSnapshotRequest');
@@ -185,6 +230,30 @@ backupWorkspace: {
     responseSerialize: serialize_wsdaemon_BackupWorkspaceResponse,
     responseDeserialize: deserialize_wsdaemon_BackupWorkspaceResponse,
   },
+  // ListWorkspaceFiles lists a directory of the workspace content. The content is accessed read-only.
+listWorkspaceFiles: {
+    path: '/wsdaemon.WorkspaceContentService/ListWorkspaceFiles',
+    requestStream: false,
+    responseStream: false,
+    requestType: daemon_pb.ListWorkspaceFilesRequest,
+    responseType: daemon_pb.ListWorkspaceFilesResponse,
+    requestSerialize: serialize_wsdaemon_ListWorkspaceFilesRequest,
+    requestDeserialize: deserialize_wsdaemon_ListWorkspaceFilesRequest,
+    responseSerialize: serialize_wsdaemon_ListWorkspaceFilesResponse,
+    responseDeserialize: deserialize_wsdaemon_ListWorkspaceFilesResponse,
+  },
+  // ReadWorkspaceFile streams a file of the workspace content. The content is accessed read-only.
+readWorkspaceFile: {
+    path: '/wsdaemon.WorkspaceContentService/ReadWorkspaceFile',
+    requestStream: false,
+    responseStream: true,
+    requestType: daemon_pb.ReadWorkspaceFileRequest,
+    responseType: daemon_pb.ReadWorkspaceFileResponse,
+    requestSerialize: serialize_wsdaemon_ReadWorkspaceFileRequest,
+    requestDeserialize: deserialize_wsdaemon_ReadWorkspaceFileRequest,
+    responseSerialize: serialize_wsdaemon_ReadWorkspaceFileResponse,
+    responseDeserialize: deserialize_wsdaemon_ReadWorkspaceFileResponse,
+  },
 };
 
 exports.WorkspaceContentServiceClient = grpc.makeGenericClientConstructor(WorkspaceContentServiceService);
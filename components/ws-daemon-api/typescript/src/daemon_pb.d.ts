@@ -12,6 +12,7 @@
 
 import * as jspb from "google-protobuf";
 import * as content_service_api_initializer_pb from "@gitpod/content-service/lib";
+import * as google_protobuf_timestamp_pb from "google-protobuf/google/protobuf/timestamp_pb";
 
 export class InitWorkspaceRequest extends jspb.Message { 
     getId(): string;
@@ -271,6 +272,131 @@ export namespace BackupWorkspaceResponse {
     }
 }
 
+export class ListWorkspaceFilesRequest extends jspb.Message { 
+    getId(): string;
+    setId(value: string): ListWorkspaceFilesRequest;
+    getPath(): string;
+    setPath(value: string): ListWorkspaceFilesRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ListWorkspaceFilesRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: ListWorkspaceFilesRequest): ListWorkspaceFilesRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ListWorkspaceFilesRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ListWorkspaceFilesRequest;
+    static deserializeBinaryFromReader(message: ListWorkspaceFilesRequest, reader: jspb.BinaryReader): ListWorkspaceFilesRequest;
+}
+
+export namespace ListWorkspaceFilesRequest {
+    export type AsObject = {
+        id: string,
+        path: string,
+    }
+}
+
+export class ListWorkspaceFilesResponse extends jspb.Message { 
+    clearFilesList(): void;
+    getFilesList(): Array<WorkspaceFile>;
+    setFilesList(value: Array<WorkspaceFile>): ListWorkspaceFilesResponse;
+    addFiles(value?: WorkspaceFile, index?: number): WorkspaceFile;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ListWorkspaceFilesResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: ListWorkspaceFilesResponse): ListWorkspaceFilesResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ListWorkspaceFilesResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ListWorkspaceFilesResponse;
+    static deserializeBinaryFromReader(message: ListWorkspaceFilesResponse, reader: jspb.BinaryReader): ListWorkspaceFilesResponse;
+}
+
+export namespace ListWorkspaceFilesResponse {
+    export type AsObject = {
+        filesList: Array<WorkspaceFile.AsObject>,
+    }
+}
+
+export class WorkspaceFile extends jspb.Message { 
+    getName(): string;
+    setName(value: string): WorkspaceFile;
+    getSize(): number;
+    setSize(value: number): WorkspaceFile;
+    getMode(): string;
+    setMode(value: string): WorkspaceFile;
+
+    hasModified(): boolean;
+    clearModified(): void;
+    getModified(): google_protobuf_timestamp_pb.Timestamp | undefined;
+    setModified(value?: google_protobuf_timestamp_pb.Timestamp): WorkspaceFile;
+    getSymlinkTarget(): string;
+    setSymlinkTarget(value: string): WorkspaceFile;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): WorkspaceFile.AsObject;
+    static toObject(includeInstance: boolean, msg: WorkspaceFile): WorkspaceFile.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: WorkspaceFile, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): WorkspaceFile;
+    static deserializeBinaryFromReader(message: WorkspaceFile, reader: jspb.BinaryReader): WorkspaceFile;
+}
+
+export namespace WorkspaceFile {
+    export type AsObject = {
+        name: string,
+        size: number,
+        mode: string,
+        modified?: google_protobuf_timestamp_pb.Timestamp.AsObject,
+        symlinkTarget: string,
+    }
+}
+
+export class ReadWorkspaceFileRequest extends jspb.Message { 
+    getId(): string;
+    setId(value: string): ReadWorkspaceFileRequest;
+    getPath(): string;
+    setPath(value: string): ReadWorkspaceFileRequest;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ReadWorkspaceFileRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: ReadWorkspaceFileRequest): ReadWorkspaceFileRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ReadWorkspaceFileRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ReadWorkspaceFileRequest;
+    static deserializeBinaryFromReader(message: ReadWorkspaceFileRequest, reader: jspb.BinaryReader): ReadWorkspaceFileRequest;
+}
+
+export namespace ReadWorkspaceFileRequest {
+    export type AsObject = {
+        id: string,
+        path: string,
+    }
+}
+
+export class ReadWorkspaceFileResponse extends jspb.Message { 
+    getData(): Uint8Array | string;
+    getData_asU8(): Uint8Array;
+    getData_asB64(): string;
+    setData(value: Uint8Array | string): ReadWorkspaceFileResponse;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): ReadWorkspaceFileResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: ReadWorkspaceFileResponse): ReadWorkspaceFileResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: ReadWorkspaceFileResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): ReadWorkspaceFileResponse;
+    static deserializeBinaryFromReader(message: ReadWorkspaceFileResponse, reader: jspb.BinaryReader): ReadWorkspaceFileResponse;
+}
+
+export namespace ReadWorkspaceFileResponse {
+    export type AsObject = {
+        data: Uint8Array | string,
+    }
+}
+
 export enum WorkspaceContentState {
     NONE = 0,
     SETTING_UP = 1,
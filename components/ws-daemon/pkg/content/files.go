@@ -0,0 +1,220 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/common-go/tracing"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+// readFileChunkSize is the size of the chunks ReadWorkspaceFile streams
+const readFileChunkSize = 32 * 1024
+
+// ListWorkspaceFiles lists a directory of the workspace content
+func (s *WorkspaceService) ListWorkspaceFiles(ctx context.Context, req *api.ListWorkspaceFilesRequest) (resp *api.ListWorkspaceFilesResponse, err error) {
+	//nolint:ineffassign
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ListWorkspaceFiles")
+	tracing.ApplyOWI(span, log.OWI("", "", req.Id))
+	tracing.LogRequestSafe(span, req)
+	defer tracing.FinishSpan(span, &err)
+
+	root, err := s.workspaceContentRoot(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// O_PATH lets us list symlinks and files we cannot read on their own
+	f, err := openWorkspaceFile(root, req.Path, unix.O_PATH)
+	if err != nil {
+		return nil, workspaceFileError(req.Path, err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !stat.IsDir() {
+		// like ls, we list files on their own
+		return &api.ListWorkspaceFilesResponse{Files: []*api.WorkspaceFile{workspaceFile(f, stat)}}, nil
+	}
+
+	dirfd, err := unix.Openat(int(f.Fd()), ".", unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	dir := os.NewFile(uintptr(dirfd), f.Name())
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	sort.Strings(names)
+
+	resp = &api.ListWorkspaceFilesResponse{}
+	for _, name := range names {
+		fd, err := unix.Openat(dirfd, name, unix.O_PATH|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		if errors.Is(err, unix.ENOENT) {
+			// removed since we've read the directory
+			continue
+		} else if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		entry := os.NewFile(uintptr(fd), filepath.Join(f.Name(), name))
+		info, err := entry.Stat()
+		if err != nil {
+			entry.Close()
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		resp.Files = append(resp.Files, workspaceFile(entry, info))
+		entry.Close()
+	}
+	return resp, nil
+}
+
+// ReadWorkspaceFile streams a file of the workspace content
+func (s *WorkspaceService) ReadWorkspaceFile(req *api.ReadWorkspaceFileRequest, srv api.WorkspaceContentService_ReadWorkspaceFileServer) (err error) {
+	//nolint:ineffassign
+	span, _ := opentracing.StartSpanFromContext(srv.Context(), "ReadWorkspaceFile")
+	tracing.ApplyOWI(span, log.OWI("", "", req.Id))
+	tracing.LogRequestSafe(span, req)
+	defer tracing.FinishSpan(span, &err)
+
+	root, err := s.workspaceContentRoot(req.Id)
+	if err != nil {
+		return err
+	}
+
+	// O_NONBLOCK prevents opening named pipes from blocking
+	f, err := openWorkspaceFile(root, req.Path, unix.O_RDONLY|unix.O_NONBLOCK)
+	if err != nil {
+		return workspaceFileError(req.Path, err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if stat.IsDir() {
+		return status.Errorf(codes.InvalidArgument, "%s is a directory", req.Path)
+	}
+	if !stat.Mode().IsRegular() {
+		// reading named pipes or devices might block forever
+		return status.Errorf(codes.FailedPrecondition, "%s is no regular file", req.Path)
+	}
+
+	buf := make([]byte, readFileChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			serr := srv.Send(&api.ReadWorkspaceFileResponse{Data: buf[:n]})
+			if serr != nil {
+				return serr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+// workspaceContentRoot returns the location of the workspace content on this node
+func (s *WorkspaceService) workspaceContentRoot(id string) (root string, err error) {
+	if id == "" {
+		return "", status.Error(codes.InvalidArgument, "ID is required")
+	}
+
+	sess := s.store.Get(id)
+	if sess == nil {
+		return "", status.Error(codes.NotFound, "cannot find workspace")
+	}
+	if !sess.IsReady() {
+		return "", status.Error(codes.FailedPrecondition, "workspace is not ready")
+	}
+	if sess.FullWorkspaceBackup || sess.PersistentVolumeClaim {
+		// the content of those workspaces lives in the container's filesystem or volume, not our working area
+		return "", status.Error(codes.FailedPrecondition, "workspace content is not available on ws-daemon for full workspace backup or persistent volume claim workspaces")
+	}
+	return sess.Location, nil
+}
+
+// openWorkspaceFile opens a file of the workspace content relative to its root, one path component at a time.
+// None of the components may be a symlink: the workspace owns the content and could otherwise swap a directory
+// for a symlink pointing outside of the content between us resolving the path and opening the file.
+// The path is cleaned first, hence ".." never leaves the root.
+func openWorkspaceFile(root, path string, flags int) (*os.File, error) {
+	fd, err := unix.Open(root, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: root, Err: err}
+	}
+
+	path = strings.TrimPrefix(filepath.Clean("/"+path), "/")
+	if path == "" {
+		return os.NewFile(uintptr(fd), root), nil
+	}
+	components := strings.Split(path, "/")
+	for i, c := range components {
+		fl := unix.O_RDONLY | unix.O_DIRECTORY
+		if i == len(components)-1 {
+			fl = flags
+		}
+		next, err := unix.Openat(fd, c, fl|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		unix.Close(fd)
+		if err != nil {
+			return nil, &os.PathError{Op: "openat", Path: path, Err: err}
+		}
+		fd = next
+	}
+	return os.NewFile(uintptr(fd), filepath.Join(root, path)), nil
+}
+
+// workspaceFileError translates errors of openWorkspaceFile to a gRPC status
+func workspaceFileError(path string, err error) error {
+	switch {
+	case errors.Is(err, unix.ENOENT), errors.Is(err, unix.ENOTDIR):
+		return status.Errorf(codes.NotFound, "%s does not exist", path)
+	case errors.Is(err, unix.ELOOP):
+		return status.Errorf(codes.FailedPrecondition, "%s is a symlink, which we do not follow", path)
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// workspaceFile describes f, which must not be opened following symlinks
+func workspaceFile(f *os.File, info os.FileInfo) *api.WorkspaceFile {
+	res := &api.WorkspaceFile{
+		Name:     info.Name(),
+		Size:     info.Size(),
+		Mode:     info.Mode().String(),
+		Modified: timestamppb.New(info.ModTime()),
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		// an empty path reads the link f refers to itself
+		buf := make([]byte, unix.PathMax)
+		n, err := unix.Readlinkat(int(f.Fd()), "", buf)
+		if err == nil {
+			res.SymlinkTarget = string(buf[:n])
+		}
+	}
+	return res
+}
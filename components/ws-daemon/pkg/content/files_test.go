@@ -0,0 +1,194 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package content
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+	"github.com/gitpod-io/gitpod/ws-daemon/pkg/internal/session"
+)
+
+type readFileStream struct {
+	grpc.ServerStream
+	data bytes.Buffer
+}
+
+func (s *readFileStream) Context() context.Context { return context.Background() }
+
+func (s *readFileStream) Send(resp *api.ReadWorkspaceFileResponse) error {
+	s.data.Write(resp.Data)
+	return nil
+}
+
+func newFilesTestService(t *testing.T) *WorkspaceService {
+	ctx := context.Background()
+	store, err := session.NewStore(ctx, t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outside := t.TempDir()
+	err = os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ws := range []struct {
+		ID                  string
+		FullWorkspaceBackup bool
+		Ready               bool
+	}{
+		{ID: "regular", Ready: true},
+		{ID: "fwb", FullWorkspaceBackup: true, Ready: true},
+		{ID: "initializing"},
+	} {
+		ws := ws
+		location := t.TempDir()
+		sess, err := store.NewWorkspace(ctx, ws.ID, location, func(ctx context.Context, location string) (*session.Workspace, error) {
+			return &session.Workspace{
+				Location:            location,
+				InstanceID:          ws.ID,
+				FullWorkspaceBackup: ws.FullWorkspaceBackup,
+			}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ws.Ready {
+			continue
+		}
+		err = sess.MarkInitDone(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.MkdirAll(filepath.Join(location, "repo", "src"), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(location, "repo", "README.md"), bytes.Repeat([]byte("a"), 3*readFileChunkSize+1), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Symlink(filepath.Join(outside, "secret"), filepath.Join(location, "repo", "escape"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Symlink(outside, filepath.Join(location, "repo", "escapedir"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &WorkspaceService{store: store}
+}
+
+func TestListWorkspaceFiles(t *testing.T) {
+	type file struct {
+		Name          string
+		Mode          string
+		SymlinkTarget bool
+	}
+	tests := []struct {
+		Name        string
+		ID          string
+		Path        string
+		Expectation []file
+		Code        codes.Code
+	}{
+		{
+			Name: "directory",
+			ID:   "regular",
+			Path: "repo",
+			Expectation: []file{
+				{Name: "README.md", Mode: "-rw-r--r--"},
+				{Name: "escape", Mode: "Lrwxrwxrwx", SymlinkTarget: true},
+				{Name: "escapedir", Mode: "Lrwxrwxrwx", SymlinkTarget: true},
+				{Name: "src", Mode: "drwxr-xr-x"},
+			},
+		},
+		{Name: "single file", ID: "regular", Path: "/repo/README.md", Expectation: []file{{Name: "README.md", Mode: "-rw-r--r--"}}},
+		// like ls, symlinks are listed on their own rather than followed
+		{Name: "symlink", ID: "regular", Path: "repo/escapedir", Expectation: []file{{Name: "escapedir", Mode: "Lrwxrwxrwx", SymlinkTarget: true}}},
+		// symlinks are no directories to us
+		{Name: "through a symlink", ID: "regular", Path: "repo/escapedir/secret", Code: codes.NotFound},
+		// paths cannot break out of the workspace, but resolve to its root
+		{Name: "outside of the workspace", ID: "regular", Path: "../../..", Expectation: []file{{Name: "repo", Mode: "drwxr-xr-x"}}},
+		{Name: "missing", ID: "regular", Path: "repo/missing", Code: codes.NotFound},
+		{Name: "unknown workspace", ID: "unknown", Path: "repo", Code: codes.NotFound},
+		{Name: "no ID", Path: "repo", Code: codes.InvalidArgument},
+		{Name: "full workspace backup", ID: "fwb", Path: "repo", Code: codes.FailedPrecondition},
+		{Name: "not ready", ID: "initializing", Path: "repo", Code: codes.FailedPrecondition},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			srv := newFilesTestService(t)
+			resp, err := srv.ListWorkspaceFiles(context.Background(), &api.ListWorkspaceFilesRequest{Id: test.ID, Path: test.Path})
+			if code := status.Code(err); code != test.Code {
+				t.Fatalf("unexpected status code: expected %v, got %v: %v", test.Code, code, err)
+			}
+			if err != nil {
+				return
+			}
+
+			var act []file
+			for _, f := range resp.Files {
+				act = append(act, file{Name: f.Name, Mode: f.Mode, SymlinkTarget: f.SymlinkTarget != ""})
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected files (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadWorkspaceFile(t *testing.T) {
+	tests := []struct {
+		Name        string
+		ID          string
+		Path        string
+		Expectation []byte
+		Code        codes.Code
+	}{
+		{Name: "file", ID: "regular", Path: "repo/README.md", Expectation: bytes.Repeat([]byte("a"), 3*readFileChunkSize+1)},
+		{Name: "relative to the root", ID: "regular", Path: "../repo/src/../README.md", Expectation: bytes.Repeat([]byte("a"), 3*readFileChunkSize+1)},
+		{Name: "symlink", ID: "regular", Path: "repo/escape", Code: codes.FailedPrecondition},
+		// symlinks are no directories to us
+		{Name: "through a symlink", ID: "regular", Path: "repo/escapedir/secret", Code: codes.NotFound},
+		{Name: "root", ID: "regular", Path: "/", Code: codes.InvalidArgument},
+		{Name: "directory", ID: "regular", Path: "repo/src", Code: codes.InvalidArgument},
+		{Name: "missing", ID: "regular", Path: "repo/missing", Code: codes.NotFound},
+		{Name: "full workspace backup", ID: "fwb", Path: "repo/README.md", Code: codes.FailedPrecondition},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			srv := newFilesTestService(t)
+			var stream readFileStream
+			err := srv.ReadWorkspaceFile(&api.ReadWorkspaceFileRequest{Id: test.ID, Path: test.Path}, &stream)
+			if code := status.Code(err); code != test.Code {
+				t.Fatalf("unexpected status code: expected %v, got %v: %v", test.Code, code, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if !bytes.Equal(test.Expectation, stream.data.Bytes()) {
+				t.Errorf("unexpected content: expected %d bytes, got %d bytes", len(test.Expectation), stream.data.Len())
+			}
+		})
+	}
+}
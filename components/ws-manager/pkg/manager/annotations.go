@@ -82,6 +82,10 @@ const (
 	// workspaceAnnotationPrefix prefixes pod annotations that contain annotations specified during the workspaces start request
 	workspaceAnnotationPrefix = "gitpod.io/annotation."
 
+	// portAccessLogAnnotation is the start request annotation server sets if the workspace config opted in to port access logging.
+	// ws-proxy picks it up from the pod, supervisor from the environment.
+	portAccessLogAnnotation = "portAccessLog"
+
 	// stoppedByRequestAnnotation is set on a pod when it was requested to stop using a StopWorkspace call
 	stoppedByRequestAnnotation = "gitpod.io/stoppedByRequest"
 
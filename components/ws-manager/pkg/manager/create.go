@@ -769,6 +769,10 @@ func (m *Manager) createWorkspaceEnvironment(startContext *startWorkspaceContext
 		result = append(result, corev1.EnvVar{Name: "GITPOD_HEADLESS", Value: "true"})
 	}
 
+	if startContext.Request.Metadata.Annotations[portAccessLogAnnotation] == "true" {
+		result = append(result, corev1.EnvVar{Name: "GITPOD_PORT_ACCESS_LOG", Value: "true"})
+	}
+
 	// remove empty env vars
 	cleanResult := make([]corev1.EnvVar, 0)
 	for _, v := range result {
@@ -49,6 +49,10 @@ func (p ImageBuilder) ListBuilds(ctx context.Context, req *api.ListBuildsRequest
 	return p.D.ListBuilds(ctx, req)
 }
 
+func (p ImageBuilder) CancelBuild(ctx context.Context, req *api.CancelBuildRequest) (*api.CancelBuildResponse, error) {
+	return p.D.CancelBuild(ctx, req)
+}
+
 type ProtoMessage interface {
 	proto.Message
 	comparable
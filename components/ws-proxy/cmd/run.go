@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/bombsimon/logrusr/v2"
@@ -122,14 +123,18 @@ var runCmd = &cobra.Command{
 		}
 
 		// SSH Gateway
-		var signers []ssh.Signer
+		var (
+			signers     []ssh.Signer
+			hostSigners []ssh.Signer
+		)
 		flist, err := os.ReadDir("/mnt/host-key")
 		if err == nil && len(flist) > 0 {
 			for _, f := range flist {
-				if f.IsDir() {
+				if f.IsDir() || strings.HasSuffix(f.Name(), "-cert.pub") {
 					continue
 				}
-				b, err := os.ReadFile(filepath.Join("/mnt/host-key", f.Name()))
+				fn := filepath.Join("/mnt/host-key", f.Name())
+				b, err := os.ReadFile(fn)
 				if err != nil {
 					continue
 				}
@@ -138,9 +143,53 @@ var runCmd = &cobra.Command{
 					continue
 				}
 				signers = append(signers, hostSigner)
+
+				// in CA mode we present the host certificate instead of the plain host key
+				if gw := cfg.SSHGateway; gw != nil && gw.CA != nil {
+					certSigner, err := sshproxy.LoadHostCertificate(hostSigner, fn+"-cert.pub")
+					if err == nil {
+						hostSigner = certSigner
+					} else if !os.IsNotExist(err) {
+						log.WithError(err).WithField("hostKey", f.Name()).Warn("cannot load SSH host certificate")
+					}
+				}
+				hostSigners = append(hostSigners, hostSigner)
 			}
 			if len(signers) > 0 {
-				server := sshproxy.New(signers, workspaceInfoProvider, heartbeat)
+				server := sshproxy.New(hostSigners, workspaceInfoProvider, heartbeat)
+				if gw := cfg.SSHGateway; gw != nil {
+					server.Forwarding = gw.Forwarding
+					server.Audit = gw.Audit
+					if gw.RecordingStorage != nil {
+						server.RecordingStorage = &sshproxy.ContentServiceRecordingStorage{Config: *gw.RecordingStorage}
+					}
+				}
+				if gw := cfg.SSHGateway; gw != nil && gw.UserKeys != nil {
+					resolver := &sshproxy.HTTPUserKeyResolver{
+						URL:    gw.UserKeys.URL,
+						Client: &http.Client{Timeout: 10 * time.Second},
+					}
+					if gw.UserKeys.TokenFile != "" {
+						token, err := os.ReadFile(gw.UserKeys.TokenFile)
+						if err != nil {
+							log.WithError(err).Fatal("cannot read SSH gateway user keys token")
+						}
+						resolver.Token = strings.TrimSpace(string(token))
+					}
+					server.EnableUserKeyAuth(resolver)
+				}
+				if gw := cfg.SSHGateway; gw != nil && gw.CA != nil {
+					ca := sshproxy.CertificateAuthority{
+						MaxUserCertValidity: time.Duration(gw.CA.MaxUserCertValidity),
+					}
+					if gw.CA.UserCAKeysFile != "" {
+						ca.UserCAKeys, err = sshproxy.LoadCAKeys(gw.CA.UserCAKeysFile)
+						if err != nil {
+							log.WithError(err).Fatal("cannot load SSH user CA keys")
+						}
+					}
+					server.EnableCertificateAuthority(ca)
+				}
 				l, err := net.Listen("tcp", ":2200")
 				if err != nil {
 					panic(err)
@@ -10,7 +10,10 @@ import (
 
 	"golang.org/x/xerrors"
 
+	"github.com/gitpod-io/gitpod/common-go/util"
+	csconfig "github.com/gitpod-io/gitpod/content-service/api/config"
 	"github.com/gitpod-io/gitpod/ws-proxy/pkg/proxy"
+	"github.com/gitpod-io/gitpod/ws-proxy/pkg/sshproxy"
 )
 
 // Config configures this service.
@@ -22,6 +25,38 @@ type Config struct {
 	ReadinessProbeAddr string                       `json:"readinessProbeAddr"`
 	Namespace          string                       `json:"namespace"`
 	WorkspaceManager   *WorkspaceManagerConn        `json:"wsManager"`
+	SSHGateway         *SSHGatewayConfig            `json:"sshGateway,omitempty"`
+}
+
+// SSHGatewayConfig configures the SSH gateway
+type SSHGatewayConfig struct {
+	// UserKeys enables authentication using the public keys users registered with their account
+	UserKeys *UserKeysConfig `json:"userKeys,omitempty"`
+	// Forwarding determines which kinds of port and agent forwarding users may use
+	Forwarding sshproxy.ForwardingPolicy `json:"forwarding"`
+	// Audit configures audit logging and session recording
+	Audit sshproxy.AuditPolicy `json:"audit"`
+	// RecordingStorage is where session recordings are stored. Required if session recording is enabled.
+	RecordingStorage *csconfig.StorageConfig `json:"recordingStorage,omitempty"`
+	// CA enables the SSH certificate authority mode
+	CA *SSHCAConfig `json:"ca,omitempty"`
+}
+
+// SSHCAConfig configures the SSH certificate authority mode of the gateway.
+// Host certificates are picked up from the host key directory as <keyfile>-cert.pub.
+type SSHCAConfig struct {
+	// UserCAKeysFile contains the public keys (in authorized_keys format) of the CAs which sign user certificates
+	UserCAKeysFile string `json:"userCAKeysFile,omitempty"`
+	// MaxUserCertValidity is the longest validity period of user certificates we accept
+	MaxUserCertValidity util.Duration `json:"maxUserCertValidity,omitempty"`
+}
+
+// UserKeysConfig configures where the SSH gateway resolves user-registered public keys
+type UserKeysConfig struct {
+	// URL is the endpoint which resolves key fingerprints to users, e.g. http://server:3000/ssh-gateway/resolve-user
+	URL string `json:"url"`
+	// TokenFile contains the bearer token used to authenticate with the endpoint. Server reads the same token from its sshGatewayTokenFile.
+	TokenFile string `json:"tokenFile,omitempty"`
 }
 
 type WorkspaceManagerConn struct {
@@ -43,6 +78,16 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if c.SSHGateway != nil && c.SSHGateway.UserKeys != nil && c.SSHGateway.UserKeys.URL == "" {
+		return xerrors.Errorf("sshGateway.userKeys.url is required")
+	}
+	if c.SSHGateway != nil && c.SSHGateway.Audit.RecordSessions && c.SSHGateway.RecordingStorage == nil {
+		return xerrors.Errorf("sshGateway.recordingStorage is required to record sessions")
+	}
+	if c.SSHGateway != nil && c.SSHGateway.CA != nil && c.SSHGateway.CA.MaxUserCertValidity < 0 {
+		return xerrors.Errorf("sshGateway.ca.maxUserCertValidity must not be negative")
+	}
+
 	return nil
 }
 
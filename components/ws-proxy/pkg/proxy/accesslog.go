@@ -0,0 +1,139 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+const (
+	// accessLogQueueSize is the number of access log entries we queue per workspace until we forward them.
+	// If supervisor falls behind, we drop the oldest entries.
+	accessLogQueueSize = 500
+	// accessLogForwardInterval is the interval at which we forward the queued entries to supervisor
+	accessLogForwardInterval = 2 * time.Second
+	// accessLogForwardTimeout is the time we give supervisor to accept the entries we forward
+	accessLogForwardTimeout = 5 * time.Second
+	// supervisorAccessLogPath is where supervisor receives the access log entries, see supervisor's portaccesslog.go
+	supervisorAccessLogPath = "/_supervisor/port-access-log"
+)
+
+// AccessLogEntry describes a single request to a workspace port
+type AccessLogEntry struct {
+	Time       time.Time `json:"time"`
+	Port       string    `json:"port"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMs int64     `json:"durationMs"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+}
+
+// portAccessLog forwards the requests to the ports of workspaces which opted in to access logging to the
+// workspace's supervisor, which keeps the most recent ones and shows them. The log is meant for debugging
+// rather than auditing, hence we drop entries we cannot forward.
+type portAccessLog struct {
+	// SupervisorPort is the port of supervisor's API endpoint
+	SupervisorPort uint16
+	Client         *http.Client
+
+	mu      sync.Mutex
+	pending map[string]*pendingAccessLog
+	started sync.Once
+}
+
+type pendingAccessLog struct {
+	IPAddress string
+	Entries   []AccessLogEntry
+}
+
+func newPortAccessLog(supervisorPort uint16) *portAccessLog {
+	return &portAccessLog{
+		SupervisorPort: supervisorPort,
+		Client:         &http.Client{Timeout: accessLogForwardTimeout},
+		pending:        make(map[string]*pendingAccessLog),
+	}
+}
+
+// Record queues an entry for the access log of a workspace. Entries are forwarded in the background.
+func (l *portAccessLog) Record(ws *WorkspaceInfo, entry AccessLogEntry) {
+	l.started.Do(func() {
+		go func() {
+			for range time.Tick(accessLogForwardInterval) {
+				l.Forward(context.Background())
+			}
+		}()
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p, ok := l.pending[ws.WorkspaceID]
+	if !ok {
+		p = &pendingAccessLog{}
+		l.pending[ws.WorkspaceID] = p
+	}
+	p.IPAddress = ws.IPAddress
+	if len(p.Entries) >= accessLogQueueSize {
+		p.Entries = p.Entries[len(p.Entries)-accessLogQueueSize+1:]
+	}
+	p.Entries = append(p.Entries, entry)
+}
+
+// Forward sends the queued entries to the supervisors of their workspaces
+func (l *portAccessLog) Forward(ctx context.Context) {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = make(map[string]*pendingAccessLog)
+	l.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for wsID, p := range pending {
+		wg.Add(1)
+		go func(wsID string, p *pendingAccessLog) {
+			defer wg.Done()
+
+			err := l.forward(ctx, p)
+			if err != nil {
+				log.WithFields(log.OWI("", wsID, "")).WithError(err).Debug("cannot forward port access log")
+			}
+		}(wsID, p)
+	}
+	wg.Wait()
+}
+
+func (l *portAccessLog) forward(ctx context.Context, p *pendingAccessLog) error {
+	body, err := json.Marshal(p.Entries)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("http://%s:%d%s", p.IPAddress, l.SupervisorPort, supervisorAccessLogPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return xerrors.Errorf("supervisor responded with %d", resp.StatusCode)
+	}
+	return nil
+}
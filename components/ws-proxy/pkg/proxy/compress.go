@@ -0,0 +1,128 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/felixge/httpsnoop"
+)
+
+// compressHandler gzip compresses responses for clients that support it, unless the upstream
+// response is already encoded. Unlike handlers.CompressHandler it retains the Accept-Encoding header
+// of the request, so that upstream servers (e.g. blobserve) can serve precompressed content
+// which we pass through as is.
+func compressHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// always add Accept-Encoding to Vary to prevent intermediate caches corruption
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if req.Header.Get("Upgrade") != "" || !acceptsGzip(req.Header.Get("Accept-Encoding")) {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		cw := &compressResponseWriter{w: w, head: req.Method == http.MethodHead}
+		defer cw.Close()
+
+		h.ServeHTTP(httpsnoop.Wrap(w, httpsnoop.Hooks{
+			Write: func(httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+				return cw.Write
+			},
+			WriteHeader: func(httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+				return cw.WriteHeader
+			},
+			Flush: func(httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+				return cw.Flush
+			},
+			ReadFrom: func(httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+				return cw.ReadFrom
+			},
+		}), req)
+	})
+}
+
+func acceptsGzip(acceptEncoding string) bool {
+	for _, spec := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(spec, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// compressResponseWriter decides whether to compress once the response headers are known
+type compressResponseWriter struct {
+	w    http.ResponseWriter
+	head bool
+
+	wroteHeader bool
+	gz          *gzip.Writer
+}
+
+func (cw *compressResponseWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	hdr := cw.w.Header()
+	encoded := hdr.Get("Content-Encoding") != ""
+	bodyless := cw.head || code == http.StatusNoContent || code == http.StatusNotModified || code < http.StatusOK
+	if !encoded && !bodyless {
+		hdr.Set("Content-Encoding", "gzip")
+		hdr.Del("Content-Length")
+		// a strong ETag of the identity encoded response does not apply to the compressed one
+		if etag := hdr.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			hdr.Set("ETag", "W/"+etag)
+		}
+		cw.gz = gzip.NewWriter(cw.w)
+	}
+	cw.w.WriteHeader(code)
+}
+
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		hdr := cw.w.Header()
+		if hdr.Get("Content-Type") == "" {
+			hdr.Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.gz == nil {
+		return cw.w.Write(b)
+	}
+	return cw.gz.Write(b)
+}
+
+func (cw *compressResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(writerOnly{cw}, r)
+}
+
+func (cw *compressResponseWriter) Flush() {
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	if f, ok := cw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressResponseWriter) Close() error {
+	if cw.gz == nil {
+		return nil
+	}
+	return cw.gz.Close()
+}
+
+// writerOnly hides the ReadFrom method of a writer to prevent io.Copy from recursing
+type writerOnly struct {
+	io.Writer
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompressHandler(t *testing.T) {
+	const content = "hello world"
+
+	type Expectation struct {
+		ContentEncoding string
+		AcceptEncoding  string
+		Body            string
+	}
+	tests := []struct {
+		Name             string
+		AcceptEncoding   string
+		UpstreamEncoding string
+		Expectation      Expectation
+	}{
+		{
+			Name:        "no accept-encoding",
+			Expectation: Expectation{Body: content},
+		},
+		{
+			Name:           "gzip",
+			AcceptEncoding: "gzip, deflate, br",
+			Expectation:    Expectation{ContentEncoding: "gzip", AcceptEncoding: "gzip, deflate, br", Body: content},
+		},
+		{
+			Name:           "gzip excluded",
+			AcceptEncoding: "gzip;q=0, br",
+			Expectation:    Expectation{AcceptEncoding: "gzip;q=0, br", Body: content},
+		},
+		{
+			Name:             "upstream encoded",
+			AcceptEncoding:   "gzip, br",
+			UpstreamEncoding: "br",
+			Expectation:      Expectation{ContentEncoding: "br", AcceptEncoding: "gzip, br", Body: content},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var act Expectation
+			h := compressHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				act.AcceptEncoding = req.Header.Get("Accept-Encoding")
+				if test.UpstreamEncoding != "" {
+					w.Header().Set("Content-Encoding", test.UpstreamEncoding)
+				}
+				_, _ = io.WriteString(w, content)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if test.AcceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.AcceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			act.ContentEncoding = rec.Header().Get("Content-Encoding")
+			var body io.Reader = rec.Body
+			if act.ContentEncoding == "gzip" {
+				gz, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = gz
+			}
+			b, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			act.Body = string(b)
+
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("compressHandler() mismatch (-want +got):\n%s", diff)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept-Encoding" {
+				t.Errorf("expected Vary: Accept-Encoding, got %q", vary)
+			}
+		})
+	}
+}
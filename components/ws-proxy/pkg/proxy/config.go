@@ -28,6 +28,9 @@ type Config struct {
 	WorkspacePodConfig *WorkspacePodConfig `json:"workspacePodConfig"`
 
 	BuiltinPages BuiltinPagesConfig `json:"builtinPages"`
+
+	// PortLimits limits the traffic to workspace ports. If nil, ports are not limited.
+	PortLimits *PortLimitsConfig `json:"portLimits,omitempty"`
 }
 
 // Validate validates the configuration to catch issues during startup and not at runtime.
@@ -46,6 +49,12 @@ func (c *Config) Validate() error {
 			return err
 		}
 	}
+	if c.PortLimits != nil {
+		err := c.PortLimits.Validate()
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -162,6 +171,50 @@ func (c *TransportConfig) Validate() error {
 	)
 }
 
+// PortLimitsConfig configures the limits for traffic to workspace ports.
+type PortLimitsConfig struct {
+	// PerPort limits the traffic to each individual port of a workspace
+	PerPort LimitConfig `json:"perPort"`
+	// PerWorkspace limits the traffic to all ports of a workspace combined
+	PerWorkspace LimitConfig `json:"perWorkspace"`
+}
+
+// Validate validates the configuration to catch issues during startup and not at runtime.
+func (c *PortLimitsConfig) Validate() error {
+	if c == nil {
+		return xerrors.Errorf("PortLimitsConfig not configured")
+	}
+
+	err := c.PerPort.Validate()
+	if err != nil {
+		return xerrors.Errorf("invalid perPort limits: %w", err)
+	}
+	err = c.PerWorkspace.Validate()
+	if err != nil {
+		return xerrors.Errorf("invalid perWorkspace limits: %w", err)
+	}
+	return nil
+}
+
+// LimitConfig configures request rate and concurrency limits. Zero values disable the respective limit.
+type LimitConfig struct {
+	// RequestsPerSecond is the sustained request rate
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty"`
+	// Burst is the number of requests that can exceed the sustained rate. Defaults to RequestsPerSecond.
+	Burst int `json:"burst,omitempty"`
+	// MaxConnections is the maximum number of concurrent requests, including websocket connections
+	MaxConnections int `json:"maxConnections,omitempty"`
+}
+
+// Validate validates the configuration to catch issues during startup and not at runtime.
+func (c *LimitConfig) Validate() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.RequestsPerSecond, validation.Min(0.0)),
+		validation.Field(&c.Burst, validation.Min(0)),
+		validation.Field(&c.MaxConnections, validation.Min(0)),
+	)
+}
+
 // BuiltinPagesConfig configures pages served directly by ws-proxy.
 type BuiltinPagesConfig struct {
 	Location string `json:"location"`
@@ -63,6 +63,9 @@ type WorkspaceInfo struct {
 	StartedAt time.Time
 
 	OwnerUserId string
+
+	// PortAccessLog is true if the workspace opted in to have requests to its ports logged
+	PortAccessLog bool
 }
 
 // RemoteWorkspaceInfoProvider provides (cached) infos about running workspaces that it queries from ws-manager.
@@ -75,6 +78,10 @@ type RemoteWorkspaceInfoProvider struct {
 
 const (
 	workspaceIndex = "workspaceIndex"
+
+	// portAccessLogAnnotation is the workspace annotation server sets if the workspace config opts in to port access
+	// logging. ws-manager prefixes the workspace metadata annotations when adding them to the pod.
+	portAccessLogAnnotation = "gitpod.io/annotation.portAccessLog"
 )
 
 // NewRemoteWorkspaceInfoProvider creates a fresh WorkspaceInfoProvider.
@@ -162,6 +169,7 @@ func mapPodToWorkspaceInfo(pod *corev1.Pod) *WorkspaceInfo {
 		Auth:            &wsapi.WorkspaceAuthentication{Admission: admission, OwnerToken: ownerToken},
 		StartedAt:       pod.CreationTimestamp.Time,
 		OwnerUserId:     pod.Labels[kubernetes.OwnerLabel],
+		PortAccessLog:   pod.Annotations[portAccessLogAnnotation] == "true",
 	}
 }
 
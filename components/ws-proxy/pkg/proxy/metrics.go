@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "gitpod"
+	metricsSubsystem = "ws_proxy"
+)
+
+// portMetrics observes the traffic to workspace ports
+type portMetrics struct {
+	Requests         *prometheus.CounterVec
+	RequestDuration  *prometheus.HistogramVec
+	ResponseBytes    *prometheus.CounterVec
+	RejectedRequests *prometheus.CounterVec
+}
+
+func newPortMetrics() *portMetrics {
+	return &portMetrics{
+		Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "port_requests_total",
+			Help:      "Total number of requests to workspace ports",
+		}, []string{"port", "code"}),
+		RequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "port_request_duration_seconds",
+			Help:      "Duration of requests to workspace ports",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 4, 8),
+		}, []string{"port"}),
+		ResponseBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "port_response_bytes_total",
+			Help:      "Total number of bytes served from workspace ports",
+		}, []string{"port"}),
+		RejectedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "port_rejected_requests_total",
+			Help:      "Total number of requests to workspace ports rejected due to limits",
+		}, []string{"port", "reason"}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *portMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.Requests.Describe(ch)
+	m.RequestDuration.Describe(ch)
+	m.ResponseBytes.Describe(ch)
+	m.RejectedRequests.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *portMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.Requests.Collect(ch)
+	m.RequestDuration.Collect(ch)
+	m.ResponseBytes.Collect(ch)
+	m.RejectedRequests.Collect(ch)
+}
+
+// defaultPortMetrics are served alongside the controller metrics
+var defaultPortMetrics = newPortMetrics()
+
+func init() {
+	metrics.Registry.MustRegister(defaultPortMetrics)
+}
+
+// portObserverHandler records metrics for and, if the workspace opted in, logs requests to workspace ports.
+func portObserverHandler(m *portMetrics, accessLog *portAccessLog, infoProvider WorkspaceInfoProvider) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			var (
+				vars  = mux.Vars(req)
+				wsID  = vars[workspaceIDIdentifier]
+				port  = vars[workspacePortIdentifier]
+				start = time.Now()
+			)
+
+			// the request might be changed by downstream handlers, hence we take the values we need to log upfront
+			entry := AccessLogEntry{
+				Time:      start,
+				Port:      port,
+				Method:    req.Method,
+				Path:      req.URL.Path,
+				UserAgent: req.UserAgent(),
+			}
+			res := httpsnoop.CaptureMetrics(h, resp, req)
+
+			if m != nil {
+				m.Requests.WithLabelValues(port, strconv.Itoa(res.Code)).Inc()
+				m.RequestDuration.WithLabelValues(port).Observe(res.Duration.Seconds())
+				m.ResponseBytes.WithLabelValues(port).Add(float64(res.Written))
+			}
+
+			if accessLog == nil {
+				return
+			}
+			ws := infoProvider.WorkspaceInfo(wsID)
+			if ws == nil || !ws.PortAccessLog {
+				return
+			}
+			entry.Status = res.Code
+			entry.Bytes = res.Written
+			entry.DurationMs = res.Duration.Milliseconds()
+			accessLog.Record(ws, entry)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// limiterIdleTimeout is the time after which we forget about the limits of an unused port
+	limiterIdleTimeout = 10 * time.Minute
+	// limiterGCInterval is the interval in which we look for idle limiters
+	limiterGCInterval = time.Minute
+)
+
+// limitRejection describes why a request was rejected by a portLimiter
+type limitRejection string
+
+const (
+	limitRejectionNone           limitRejection = ""
+	limitRejectionPortRate       limitRejection = "port_rate"
+	limitRejectionPortConns      limitRejection = "port_connections"
+	limitRejectionWorkspaceRate  limitRejection = "workspace_rate"
+	limitRejectionWorkspaceConns limitRejection = "workspace_connections"
+)
+
+// portLimiter enforces request rate and concurrency limits on workspace ports
+type portLimiter struct {
+	cfg PortLimitsConfig
+	now func() time.Time
+
+	mu         sync.Mutex
+	workspaces map[string]*workspaceLimitState
+	lastGC     time.Time
+}
+
+type limitState struct {
+	rate     *rate.Limiter
+	conns    int
+	lastUsed time.Time
+}
+
+type workspaceLimitState struct {
+	limitState
+	ports map[string]*limitState
+}
+
+func newPortLimiter(cfg PortLimitsConfig) *portLimiter {
+	return &portLimiter{
+		cfg:        cfg,
+		now:        time.Now,
+		workspaces: make(map[string]*workspaceLimitState),
+	}
+}
+
+func newLimitState(cfg LimitConfig, now time.Time) limitState {
+	res := limitState{lastUsed: now}
+	if cfg.RequestsPerSecond > 0 {
+		burst := cfg.Burst
+		if burst == 0 {
+			burst = int(math.Ceil(cfg.RequestsPerSecond))
+		}
+		res.rate = rate.NewLimiter(rate.Limit(cfg.RequestsPerSecond), burst)
+	}
+	return res
+}
+
+// Acquire admits a request to a workspace port if it's within the configured limits.
+// If the request is admitted, callers must call release once the request is done.
+func (l *portLimiter) Acquire(workspaceID, port string) (release func(), rejection limitRejection) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.collectGarbage(now)
+
+	ws, ok := l.workspaces[workspaceID]
+	if !ok {
+		ws = &workspaceLimitState{
+			limitState: newLimitState(l.cfg.PerWorkspace, now),
+			ports:      make(map[string]*limitState),
+		}
+		l.workspaces[workspaceID] = ws
+	}
+	prt, ok := ws.ports[port]
+	if !ok {
+		s := newLimitState(l.cfg.PerPort, now)
+		prt = &s
+		ws.ports[port] = prt
+	}
+	ws.lastUsed = now
+	prt.lastUsed = now
+
+	// We check the connection limits first because they don't consume a token from the rate limiters.
+	if max := l.cfg.PerPort.MaxConnections; max > 0 && prt.conns >= max {
+		return nil, limitRejectionPortConns
+	}
+	if max := l.cfg.PerWorkspace.MaxConnections; max > 0 && ws.conns >= max {
+		return nil, limitRejectionWorkspaceConns
+	}
+	if prt.rate != nil && !prt.rate.AllowN(now, 1) {
+		return nil, limitRejectionPortRate
+	}
+	if ws.rate != nil && !ws.rate.AllowN(now, 1) {
+		return nil, limitRejectionWorkspaceRate
+	}
+
+	ws.conns++
+	prt.conns++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			now := l.now()
+			ws.conns--
+			ws.lastUsed = now
+			prt.conns--
+			prt.lastUsed = now
+		})
+	}, limitRejectionNone
+}
+
+// collectGarbage removes the state of idle ports and workspaces. Callers must hold the lock.
+func (l *portLimiter) collectGarbage(now time.Time) {
+	if now.Sub(l.lastGC) < limiterGCInterval {
+		return
+	}
+	l.lastGC = now
+
+	for wsid, ws := range l.workspaces {
+		for port, prt := range ws.ports {
+			if prt.conns == 0 && now.Sub(prt.lastUsed) > limiterIdleTimeout {
+				delete(ws.ports, port)
+			}
+		}
+		if len(ws.ports) == 0 && ws.conns == 0 && now.Sub(ws.lastUsed) > limiterIdleTimeout {
+			delete(l.workspaces, wsid)
+		}
+	}
+}
+
+// portLimitHandler rejects requests to workspace ports which exceed the configured limits.
+func portLimitHandler(limiter *portLimiter, metrics *portMetrics) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			var (
+				vars = mux.Vars(req)
+				wsID = vars[workspaceIDIdentifier]
+				port = vars[workspacePortIdentifier]
+			)
+
+			release, rejection := limiter.Acquire(wsID, port)
+			if rejection != limitRejectionNone {
+				getLog(req.Context()).WithField("reason", rejection).Debug("rejecting port request due to limits")
+				if metrics != nil {
+					metrics.RejectedRequests.WithLabelValues(port, string(rejection)).Inc()
+				}
+				resp.Header().Set("Retry-After", "1")
+				http.Error(resp, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			defer release()
+
+			h.ServeHTTP(resp, req)
+		})
+	}
+}
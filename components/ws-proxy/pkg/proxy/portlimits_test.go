@@ -0,0 +1,195 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestPortLimiter(t *testing.T) {
+	type request struct {
+		Workspace string
+		Port      string
+		// Release releases the request right away
+		Release bool
+		// Advance advances the clock before the request
+		Advance time.Duration
+	}
+	tests := []struct {
+		Name        string
+		Config      PortLimitsConfig
+		Requests    []request
+		Expectation []limitRejection
+	}{
+		{
+			Name: "no limits",
+			Requests: []request{
+				{Workspace: "ws1", Port: "8080"},
+				{Workspace: "ws1", Port: "8080"},
+				{Workspace: "ws1", Port: "8080"},
+			},
+			Expectation: []limitRejection{limitRejectionNone, limitRejectionNone, limitRejectionNone},
+		},
+		{
+			Name:   "port rate",
+			Config: PortLimitsConfig{PerPort: LimitConfig{RequestsPerSecond: 1, Burst: 2}},
+			Requests: []request{
+				{Workspace: "ws1", Port: "8080", Release: true},
+				{Workspace: "ws1", Port: "8080", Release: true},
+				{Workspace: "ws1", Port: "8080", Release: true},
+				{Workspace: "ws1", Port: "3000", Release: true},
+				{Workspace: "ws2", Port: "8080", Release: true},
+				{Workspace: "ws1", Port: "8080", Release: true, Advance: time.Second},
+			},
+			Expectation: []limitRejection{
+				limitRejectionNone,
+				limitRejectionNone,
+				limitRejectionPortRate,
+				limitRejectionNone,
+				limitRejectionNone,
+				limitRejectionNone,
+			},
+		},
+		{
+			Name:   "workspace rate",
+			Config: PortLimitsConfig{PerWorkspace: LimitConfig{RequestsPerSecond: 2}},
+			Requests: []request{
+				{Workspace: "ws1", Port: "8080", Release: true},
+				{Workspace: "ws1", Port: "3000", Release: true},
+				{Workspace: "ws1", Port: "5000", Release: true},
+				{Workspace: "ws2", Port: "8080", Release: true},
+			},
+			Expectation: []limitRejection{
+				limitRejectionNone,
+				limitRejectionNone,
+				limitRejectionWorkspaceRate,
+				limitRejectionNone,
+			},
+		},
+		{
+			Name:   "port connections",
+			Config: PortLimitsConfig{PerPort: LimitConfig{MaxConnections: 1}},
+			Requests: []request{
+				{Workspace: "ws1", Port: "8080"},
+				{Workspace: "ws1", Port: "8080"},
+				{Workspace: "ws1", Port: "3000"},
+			},
+			Expectation: []limitRejection{
+				limitRejectionNone,
+				limitRejectionPortConns,
+				limitRejectionNone,
+			},
+		},
+		{
+			Name:   "workspace connections",
+			Config: PortLimitsConfig{PerWorkspace: LimitConfig{MaxConnections: 2}},
+			Requests: []request{
+				{Workspace: "ws1", Port: "8080"},
+				{Workspace: "ws1", Port: "3000", Release: true},
+				{Workspace: "ws1", Port: "3000"},
+				{Workspace: "ws1", Port: "5000"},
+			},
+			Expectation: []limitRejection{
+				limitRejectionNone,
+				limitRejectionNone,
+				limitRejectionNone,
+				limitRejectionWorkspaceConns,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+			limiter := newPortLimiter(test.Config)
+			limiter.now = func() time.Time { return now }
+
+			var act []limitRejection
+			for _, req := range test.Requests {
+				now = now.Add(req.Advance)
+				release, rejection := limiter.Acquire(req.Workspace, req.Port)
+				if release != nil && req.Release {
+					release()
+				}
+				act = append(act, rejection)
+			}
+
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("Acquire() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPortLimiterGC(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newPortLimiter(PortLimitsConfig{PerPort: LimitConfig{MaxConnections: 1}})
+	limiter.now = func() time.Time { return now }
+
+	release, _ := limiter.Acquire("ws1", "8080")
+	_, _ = limiter.Acquire("ws2", "8080")
+	release()
+
+	now = now.Add(2 * limiterIdleTimeout)
+	_, _ = limiter.Acquire("ws3", "8080")
+
+	var act []string
+	for wsid := range limiter.workspaces {
+		act = append(act, wsid)
+	}
+	// ws1 is idle and gets collected, ws2 still has an open connection
+	if diff := cmp.Diff([]string{"ws2", "ws3"}, act, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("collectGarbage() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPortAccessLog(t *testing.T) {
+	var received [][]AccessLogEntry
+	supervisor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != supervisorAccessLogPath {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var entries []AccessLogEntry
+		err := json.NewDecoder(r.Body).Decode(&entries)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received = append(received, entries)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer supervisor.Close()
+	addr := supervisor.Listener.Addr().(*net.TCPAddr)
+
+	l := newPortAccessLog(uint16(addr.Port))
+	ws := &WorkspaceInfo{WorkspaceID: "ws1", IPAddress: addr.IP.String()}
+	for i := 0; i < accessLogQueueSize+2; i++ {
+		l.Record(ws, AccessLogEntry{Status: i})
+	}
+	l.Forward(context.Background())
+	// nothing is left to forward
+	l.Forward(context.Background())
+
+	if len(received) != 1 {
+		t.Fatalf("expected one batch, got %d", len(received))
+	}
+	entries := received[0]
+	if len(entries) != accessLogQueueSize {
+		t.Fatalf("expected %d entries, got %d", accessLogQueueSize, len(entries))
+	}
+	// the oldest entries are dropped
+	if first, last := entries[0].Status, entries[len(entries)-1].Status; first != 2 || last != accessLogQueueSize+1 {
+		t.Errorf("expected entries 2..%d, got %d..%d", accessLogQueueSize+1, first, last)
+	}
+}
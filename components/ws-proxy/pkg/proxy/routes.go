@@ -35,6 +35,11 @@ type RouteHandlerConfig struct {
 	DefaultTransport     http.RoundTripper
 	CorsHandler          mux.MiddlewareFunc
 	WorkspaceAuthHandler mux.MiddlewareFunc
+
+	// PortLimiter limits the traffic to workspace ports. If nil, port traffic is not limited.
+	PortLimiter   *portLimiter
+	PortMetrics   *portMetrics
+	PortAccessLog *portAccessLog
 }
 
 // RouteHandlerConfigOpt modifies the router handler config.
@@ -59,6 +64,11 @@ func NewRouteHandlerConfig(config *Config, opts ...RouteHandlerConfigOpt) (*Rout
 		DefaultTransport:     createDefaultTransport(config.TransportConfig),
 		CorsHandler:          corsHandler,
 		WorkspaceAuthHandler: func(h http.Handler) http.Handler { return h },
+		PortMetrics:          defaultPortMetrics,
+		PortAccessLog:        newPortAccessLog(config.WorkspacePodConfig.SupervisorPort),
+	}
+	if config.PortLimits != nil {
+		cfg.PortLimiter = newPortLimiter(*config.PortLimits)
 	}
 	for _, o := range opts {
 		o(config, cfg)
@@ -85,7 +95,7 @@ func installWorkspaceRoutes(r *mux.Router, config *RouteHandlerConfig, ip Worksp
 	// The favicon warants special handling, because we pull that from the supervisor frontend
 	// rather than the IDE.
 	faviconRouter := r.Path("/favicon.ico").Subrouter()
-	faviconRouter.Use(handlers.CompressHandler)
+	faviconRouter.Use(compressHandler)
 	faviconRouter.Use(func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 			req.URL.Path = "/_supervisor/frontend/favicon.ico"
@@ -111,7 +121,7 @@ func installWorkspaceRoutes(r *mux.Router, config *RouteHandlerConfig, ip Worksp
 
 func enableCompression(r *mux.Router) *mux.Router {
 	res := r.NewRoute().Subrouter()
-	res.Use(handlers.CompressHandler)
+	res.Use(compressHandler)
 	return res
 }
 
@@ -327,7 +337,7 @@ const imagePathSeparator = "/__files__"
 // installBlobserveRoutes  implements long-lived caching with versioned URLs, see https://web.dev/http-cache/#versioned-urls
 func installBlobserveRoutes(r *mux.Router, config *RouteHandlerConfig, infoProvider WorkspaceInfoProvider) {
 	r.Use(logHandler)
-	r.Use(handlers.CompressHandler)
+	r.Use(compressHandler)
 	r.Use(logRouteHandlerHandler("BlobserveRootHandler"))
 	r.Use(handlers.CORS(
 		// CORS headers are stored in the browser cache, we cannot be specific here to allow reuse between workspaces
@@ -365,6 +375,10 @@ func installWorkspacePortRoutes(r *mux.Router, config *RouteHandlerConfig, infoP
 	r.Use(config.WorkspaceAuthHandler)
 	// filter all session cookies
 	r.Use(sensitiveCookieHandler(config.Config.GitpodInstallation.HostName))
+	r.Use(portObserverHandler(config.PortMetrics, config.PortAccessLog, infoProvider))
+	if config.PortLimiter != nil {
+		r.Use(portLimitHandler(config.PortLimiter, config.PortMetrics))
+	}
 
 	// forward request to workspace port
 	r.NewRoute().HandlerFunc(
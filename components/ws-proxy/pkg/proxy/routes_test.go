@@ -206,13 +206,15 @@ func TestRoutes(t *testing.T) {
 		Port       *Target
 	}
 	tests := []struct {
-		Desc        string
-		Config      *Config
-		Request     *http.Request
-		Router      RouterFactory
-		Targets     *Targets
-		IgnoreBody  bool
-		Expectation Expectation
+		Desc       string
+		Config     *Config
+		Request    *http.Request
+		Router     RouterFactory
+		Targets    *Targets
+		IgnoreBody bool
+		// RequestCount is the number of times the request is sent. Only the last response is checked.
+		RequestCount int
+		Expectation  Expectation
 	}{
 		{
 			Desc: "favicon",
@@ -564,6 +566,29 @@ func TestRoutes(t *testing.T) {
 				Status: http.StatusOK,
 			},
 		},
+		{
+			Desc: "port GET rate limited",
+			Config: func() *Config {
+				cfg := config
+				cfg.PortLimits = &PortLimitsConfig{PerPort: LimitConfig{RequestsPerSecond: 0.001, Burst: 1}}
+				return &cfg
+			}(),
+			Request: modifyRequest(httptest.NewRequest("GET", workspaces[0].Ports[0].Url+"rate-limited", nil),
+				addHostHeader,
+				addOwnerToken(workspaces[0].InstanceID, workspaces[0].Auth.OwnerToken),
+			),
+			Targets:      &Targets{Port: &Target{Status: http.StatusOK}},
+			RequestCount: 2,
+			Expectation: Expectation{
+				Status: http.StatusTooManyRequests,
+				Header: http.Header{
+					"Content-Type":           {"text/plain; charset=utf-8"},
+					"Retry-After":            {"1"},
+					"X-Content-Type-Options": {"nosniff"},
+				},
+				Body: "Too Many Requests\n",
+			},
+		},
 		{
 			Desc: "blobserve route GET",
 			Request: modifyRequest(httptest.NewRequest("GET", "https://blobserve.test-domain.com/blobserve/gitpod-io/supervisor:latest/__files__/main.js", nil),
@@ -655,6 +680,9 @@ func TestRoutes(t *testing.T) {
 			}
 
 			rec := httptest.NewRecorder()
+			for i := 1; i < test.RequestCount; i++ {
+				handler.ServeHTTP(httptest.NewRecorder(), test.Request.Clone(context.Background()))
+			}
 			handler.ServeHTTP(rec, test.Request)
 			resp := rec.Result()
 
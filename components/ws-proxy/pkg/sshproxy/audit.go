@@ -0,0 +1,377 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package sshproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	csconfig "github.com/gitpod-io/gitpod/content-service/api/config"
+	"github.com/gitpod-io/gitpod/content-service/pkg/storage"
+)
+
+const (
+	// recordingUploadTimeout is the time we give a session recording upload
+	recordingUploadTimeout = 5 * time.Minute
+	// recordingContentType is the content type of asciicast recordings
+	recordingContentType = "application/x-asciicast"
+	// maxRecordingSize is the size at which we stop recording a session
+	maxRecordingSize = 64 * 1024 * 1024
+	// recordingTruncatedNotice is the last output of recordings which reached maxRecordingSize
+	recordingTruncatedNotice = "\r\n[recording truncated]\r\n"
+)
+
+// AuditPolicy configures the audit logging of the SSH gateway
+type AuditPolicy struct {
+	// Log enables structured audit log entries for every channel users open
+	Log bool `json:"log"`
+	// RecordSessions records the output of session channels in asciicast format
+	RecordSessions bool `json:"recordSessions"`
+}
+
+// RecordingStorage stores session recordings
+type RecordingStorage interface {
+	// Upload uploads a recording to the storage of a workspace instance
+	Upload(ctx context.Context, session *Session, source, name string) error
+}
+
+// ContentServiceRecordingStorage stores session recordings in the content-service storage of a workspace instance
+type ContentServiceRecordingStorage struct {
+	Config csconfig.StorageConfig
+}
+
+// Upload uploads a recording to the storage of a workspace instance
+func (s *ContentServiceRecordingStorage) Upload(ctx context.Context, session *Session, source, name string) error {
+	da, err := storage.NewDirectAccess(&s.Config)
+	if err != nil {
+		return xerrors.Errorf("cannot create storage access: %w", err)
+	}
+	err = da.Init(ctx, session.OwnerUserID, session.WorkspaceID, session.InstanceID)
+	if err != nil {
+		return xerrors.Errorf("cannot initialize storage: %w", err)
+	}
+	err = da.EnsureExists(ctx)
+	if err != nil {
+		return xerrors.Errorf("cannot ensure storage exists: %w", err)
+	}
+	_, _, err = da.UploadInstance(ctx, source, name, storage.WithContentType(recordingContentType))
+	if err != nil {
+		return xerrors.Errorf("cannot upload recording: %w", err)
+	}
+	return nil
+}
+
+// channelAudit tracks what happens on a single channel a user opened
+type channelAudit struct {
+	session     *Session
+	channelType string
+	start       time.Time
+	log         bool
+
+	bytesIn  int64
+	bytesOut int64
+
+	mu       sync.Mutex
+	command  string
+	recorder *asciicastRecorder
+}
+
+func (s *Server) startChannelAudit(session *Session, channelType string) *channelAudit {
+	res := &channelAudit{
+		session:     session,
+		channelType: channelType,
+		start:       time.Now(),
+		log:         s.Audit.Log,
+	}
+	if channelType == "session" && s.Audit.RecordSessions && s.RecordingStorage != nil {
+		rec, err := newAsciicastRecorder(res.start)
+		if err != nil {
+			log.WithFields(log.OWI("", session.WorkspaceID, session.InstanceID)).WithError(err).Warn("cannot record SSH session")
+		} else {
+			res.recorder = rec
+		}
+	}
+	if res.log {
+		res.entry().Info("ssh channel opened")
+	}
+	return res
+}
+
+func (a *channelAudit) entry() *logrus.Entry {
+	return log.WithFields(log.OWI(a.session.OwnerUserID, a.session.WorkspaceID, a.session.InstanceID)).WithFields(logrus.Fields{
+		"audit":       "ssh",
+		"userId":      a.session.UserID,
+		"remoteAddr":  a.session.Conn.RemoteAddr().String(),
+		"channelType": a.channelType,
+		"startTime":   a.start.Format(time.RFC3339Nano),
+	})
+}
+
+// Wrap returns a channel which counts and records the traffic of the user's side of the channel
+func (a *channelAudit) Wrap(c ssh.Channel) ssh.Channel {
+	return &auditedChannel{Channel: c, audit: a}
+}
+
+// ObserveRequest extracts the information we're interested in from channel requests
+func (a *channelAudit) ObserveRequest(req *ssh.Request) {
+	switch req.Type {
+	case "exec":
+		var payload struct{ Command string }
+		if ssh.Unmarshal(req.Payload, &payload) != nil {
+			return
+		}
+		a.setCommand(payload.Command)
+	case "subsystem":
+		var payload struct{ Name string }
+		if ssh.Unmarshal(req.Payload, &payload) != nil {
+			return
+		}
+		a.setCommand("subsystem:" + payload.Name)
+	case "shell":
+		a.setCommand("shell")
+	case "pty-req":
+		var payload struct {
+			Term          string
+			Columns, Rows uint32
+			Width, Height uint32
+			Modes         string
+		}
+		if ssh.Unmarshal(req.Payload, &payload) != nil {
+			return
+		}
+		if a.recorder != nil {
+			a.recorder.SetTerminal(payload.Term, int(payload.Columns), int(payload.Rows))
+		}
+	}
+}
+
+func (a *channelAudit) setCommand(cmd string) {
+	a.mu.Lock()
+	a.command = cmd
+	a.mu.Unlock()
+
+	if a.log {
+		a.entry().WithField("command", cmd).Info("ssh channel command")
+	}
+}
+
+// Finish logs the end of the channel and uploads the recording if there is one.
+// Uploading happens asynchronously.
+func (a *channelAudit) Finish(storage RecordingStorage) {
+	end := time.Now()
+	a.mu.Lock()
+	cmd := a.command
+	a.mu.Unlock()
+
+	if a.log {
+		a.entry().WithFields(logrus.Fields{
+			"command":  cmd,
+			"endTime":  end.Format(time.RFC3339Nano),
+			"bytesIn":  atomic.LoadInt64(&a.bytesIn),
+			"bytesOut": atomic.LoadInt64(&a.bytesOut),
+		}).Info("ssh channel closed")
+	}
+
+	if a.recorder == nil {
+		return
+	}
+	// we don't want to keep the channel around while uploading
+	go a.uploadRecording(storage)
+}
+
+func (a *channelAudit) uploadRecording(storage RecordingStorage) {
+	fn, err := a.recorder.Close()
+	if err != nil {
+		log.WithFields(log.OWI("", a.session.WorkspaceID, a.session.InstanceID)).WithError(err).Warn("cannot finish SSH session recording")
+		return
+	}
+	defer os.Remove(fn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), recordingUploadTimeout)
+	defer cancel()
+	name := fmt.Sprintf("ssh-recordings/%s-%s.cast", a.start.UTC().Format("20060102T150405Z"), a.session.UserID)
+	err = storage.Upload(ctx, a.session, fn, name)
+	if err != nil {
+		log.WithFields(log.OWI("", a.session.WorkspaceID, a.session.InstanceID)).WithError(err).Warn("cannot upload SSH session recording")
+	}
+}
+
+// auditedChannel counts the bytes which pass through a channel and records its output
+type auditedChannel struct {
+	ssh.Channel
+	audit *channelAudit
+}
+
+// Read reads data the user sent
+func (c *auditedChannel) Read(data []byte) (int, error) {
+	n, err := c.Channel.Read(data)
+	atomic.AddInt64(&c.audit.bytesIn, int64(n))
+	return n, err
+}
+
+// Write writes data to the user
+func (c *auditedChannel) Write(data []byte) (int, error) {
+	n, err := c.Channel.Write(data)
+	atomic.AddInt64(&c.audit.bytesOut, int64(n))
+	if c.audit.recorder != nil && n > 0 {
+		c.audit.recorder.Output(data[:n])
+	}
+	return n, err
+}
+
+// asciicastRecorder writes terminal output in asciicast v2 format, see
+// https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+type asciicastRecorder struct {
+	mu      sync.Mutex
+	f       *os.File
+	enc     *json.Encoder
+	start   time.Time
+	header  asciicastHeader
+	started bool
+	err     error
+
+	// size is the number of bytes written to f so far. Once it reaches maxSize we stop recording.
+	size      int64
+	maxSize   int64
+	truncated bool
+	// pending is the incomplete UTF-8 sequence at the end of the last output. asciicast events
+	// are JSON strings, hence we hold it back until the rest of the sequence arrives.
+	pending []byte
+}
+
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+func newAsciicastRecorder(start time.Time) (*asciicastRecorder, error) {
+	f, err := os.CreateTemp("", "ssh-recording-*.cast")
+	if err != nil {
+		return nil, err
+	}
+	res := &asciicastRecorder{
+		f:       f,
+		start:   start,
+		maxSize: maxRecordingSize,
+		header: asciicastHeader{
+			Version:   2,
+			Width:     80,
+			Height:    24,
+			Timestamp: start.Unix(),
+		},
+	}
+	res.enc = json.NewEncoder(&countingWriter{w: f, n: &res.size})
+	return res, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// SetTerminal sets the terminal dimensions. Has no effect once output was recorded.
+func (r *asciicastRecorder) SetTerminal(term string, width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		return
+	}
+	if width > 0 && height > 0 {
+		r.header.Width, r.header.Height = width, height
+	}
+	if term != "" {
+		r.header.Env = map[string]string{"TERM": term}
+	}
+}
+
+// Output records output sent to the terminal
+func (r *asciicastRecorder) Output(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data = append(r.pending, data...)
+	n := completeUTF8(data)
+	r.pending = append([]byte(nil), data[n:]...)
+	r.writeOutput(data[:n])
+}
+
+func (r *asciicastRecorder) writeOutput(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	r.writeHeader()
+	if r.err != nil || r.truncated {
+		return
+	}
+	if r.size+int64(len(data)) > r.maxSize {
+		r.truncated = true
+		data = []byte(recordingTruncatedNotice)
+	}
+	r.err = r.enc.Encode([]interface{}{time.Since(r.start).Seconds(), "o", string(data)})
+}
+
+// completeUTF8 returns the length of the prefix of data which does not end in an incomplete UTF-8 sequence
+func completeUTF8(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(data[i]) {
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			return i
+		}
+		break
+	}
+	return len(data)
+}
+
+func (r *asciicastRecorder) writeHeader() {
+	if r.started {
+		return
+	}
+	r.started = true
+	r.err = r.enc.Encode(r.header)
+}
+
+// Close finishes the recording and returns the name of the file it was written to
+func (r *asciicastRecorder) Close() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the session ended in the middle of a UTF-8 sequence
+	r.writeOutput(r.pending)
+	r.pending = nil
+	r.writeHeader()
+	err := r.f.Close()
+	if r.err != nil {
+		err = r.err
+	}
+	if err != nil {
+		os.Remove(r.f.Name())
+		return "", err
+	}
+	return r.f.Name(), nil
+}
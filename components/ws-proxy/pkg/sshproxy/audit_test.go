@@ -0,0 +1,172 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package sshproxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestAsciicastRecorder(t *testing.T) {
+	rec, err := newAsciicastRecorder(time.Unix(1650000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.SetTerminal("xterm-256color", 120, 40)
+	rec.Output([]byte("hello "))
+	// terminal changes after the first output do not affect the header
+	rec.SetTerminal("vt100", 10, 10)
+	rec.Output([]byte("world\r\n"))
+
+	header, outputs := readRecording(t, rec)
+	expectedHeader := asciicastHeader{Version: 2, Width: 120, Height: 40, Timestamp: 1650000000, Env: map[string]string{"TERM": "xterm-256color"}}
+	if diff := cmp.Diff(expectedHeader, header); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"hello ", "world\r\n"}, outputs); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAsciicastRecorderOutput(t *testing.T) {
+	euro := []byte("€")
+	tests := []struct {
+		Name        string
+		MaxSize     int64
+		Output      [][]byte
+		Expectation []string
+	}{
+		{
+			Name:        "rune split across outputs",
+			Output:      [][]byte{append([]byte("a"), euro[:1]...), euro[1:2], append(euro[2:], 'b')},
+			Expectation: []string{"a", "€b"},
+		},
+		{
+			Name:        "incomplete rune at the end",
+			Output:      [][]byte{append([]byte("a"), euro[:2]...)},
+			Expectation: []string{"a", "\ufffd\ufffd"},
+		},
+		{
+			Name:        "invalid UTF-8",
+			Output:      [][]byte{{'a', 0xff}, {'b'}},
+			Expectation: []string{"a\ufffd", "b"},
+		},
+		{
+			Name:        "truncated",
+			MaxSize:     200,
+			Output:      [][]byte{[]byte("hello"), bytes.Repeat([]byte("a"), 200), []byte("world")},
+			Expectation: []string{"hello", recordingTruncatedNotice},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			rec, err := newAsciicastRecorder(time.Unix(1650000000, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.MaxSize > 0 {
+				rec.maxSize = test.MaxSize
+			}
+			for _, o := range test.Output {
+				rec.Output(o)
+			}
+
+			_, outputs := readRecording(t, rec)
+			if diff := cmp.Diff(test.Expectation, outputs); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// readRecording closes the recorder and returns the header and output events of the recording
+func readRecording(t *testing.T, rec *asciicastRecorder) (header asciicastHeader, outputs []string) {
+	fn, err := rec.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fn)
+
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) == 0 {
+		t.Fatal("recording has no header")
+	}
+
+	err = json.Unmarshal([]byte(lines[0]), &header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range lines[1:] {
+		var evt []interface{}
+		err = json.Unmarshal([]byte(l), &evt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(evt) != 3 || evt[1] != "o" {
+			t.Errorf("unexpected event: %s", l)
+			continue
+		}
+		outputs = append(outputs, evt[2].(string))
+	}
+	return header, outputs
+}
+
+func TestChannelAuditObserveRequest(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Request     *ssh.Request
+		Expectation string
+	}{
+		{
+			Name:        "exec",
+			Request:     &ssh.Request{Type: "exec", Payload: ssh.Marshal(struct{ Command string }{"git push"})},
+			Expectation: "git push",
+		},
+		{
+			Name:        "subsystem",
+			Request:     &ssh.Request{Type: "subsystem", Payload: ssh.Marshal(struct{ Name string }{"sftp"})},
+			Expectation: "subsystem:sftp",
+		},
+		{
+			Name:        "shell",
+			Request:     &ssh.Request{Type: "shell"},
+			Expectation: "shell",
+		},
+		{
+			Name:    "invalid exec payload",
+			Request: &ssh.Request{Type: "exec", Payload: []byte{0xff}},
+		},
+		{
+			Name:    "env",
+			Request: &ssh.Request{Type: "env", Payload: ssh.Marshal(struct{ Name, Value string }{"FOO", "bar"})},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			audit := &channelAudit{session: &Session{}, channelType: "session"}
+			audit.ObserveRequest(test.Request)
+			if audit.command != test.Expectation {
+				t.Errorf("ObserveRequest() command = %q, expected %q", audit.command, test.Expectation)
+			}
+		})
+	}
+}
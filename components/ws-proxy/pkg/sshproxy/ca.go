@@ -0,0 +1,148 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package sshproxy
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	p "github.com/gitpod-io/gitpod/ws-proxy/pkg/proxy"
+)
+
+// CertificateAuthority configures the SSH certificate authority mode of the gateway
+type CertificateAuthority struct {
+	// UserCAKeys are the CAs whose user certificates we accept. The certificate principals are user IDs.
+	UserCAKeys []ssh.PublicKey
+	// MaxUserCertValidity is the longest validity period we accept for user certificates.
+	// Zero means we accept any certificate that is not valid forever.
+	MaxUserCertValidity time.Duration
+}
+
+// LoadCAKeys reads CA public keys from a file in authorized_keys format
+func LoadCAKeys(fn string) ([]ssh.PublicKey, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []ssh.PublicKey
+	for len(bytes.TrimSpace(b)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(b)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot parse CA keys in %s: %w", fn, err)
+		}
+		res = append(res, key)
+		b = rest
+	}
+	return res, nil
+}
+
+// LoadHostCertificate combines a host key with its CA-signed certificate, stored in OpenSSH format in certFN
+func LoadHostCertificate(signer ssh.Signer, certFN string) (ssh.Signer, error) {
+	b, err := os.ReadFile(certFN)
+	if err != nil {
+		return nil, err
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey(b)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse host certificate %s: %w", certFN, err)
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, xerrors.Errorf("%s is not a certificate", certFN)
+	}
+	if cert.CertType != ssh.HostCert {
+		return nil, xerrors.Errorf("%s is not a host certificate", certFN)
+	}
+	return ssh.NewCertSigner(cert, signer)
+}
+
+// EnableCertificateAuthority lets users authenticate with short-lived user certificates.
+func (s *Server) EnableCertificateAuthority(ca CertificateAuthority) {
+	s.ca = &certAuthenticator{
+		CertificateAuthority:  ca,
+		WorkspaceInfoProvider: s.workspaceInfoProvider,
+		clock:                 time.Now,
+	}
+}
+
+func (s *Server) authenticateUserCert(workspaceId string, cert *ssh.Certificate) (perm *ssh.Permissions, err error) {
+	wsInfo, userId, err := s.ca.Authenticate(workspaceId, cert)
+	defer func() {
+		s.TrackSSHConnection(wsInfo, "auth", err)
+	}()
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.Permissions{
+		Extensions: map[string]string{
+			"workspaceId": workspaceId,
+			"userId":      userId,
+		},
+	}, nil
+}
+
+// certAuthenticator authenticates workspace access using CA-signed user certificates
+type certAuthenticator struct {
+	CertificateAuthority
+	WorkspaceInfoProvider p.WorkspaceInfoProvider
+
+	clock func() time.Time
+}
+
+// Authenticate checks if the certificate is valid and names a user who may access the workspace
+func (a *certAuthenticator) Authenticate(workspaceID string, cert *ssh.Certificate) (wsInfo *p.WorkspaceInfo, userID string, err error) {
+	wsInfo = a.WorkspaceInfoProvider.WorkspaceInfo(workspaceID)
+	if wsInfo == nil {
+		return nil, "", ErrWorkspaceNotFound
+	}
+
+	err = a.checkCert(cert, ssh.UserCert, a.UserCAKeys)
+	if err != nil {
+		log.WithError(err).WithField("workspaceId", workspaceID).Debug("rejecting user certificate")
+		return wsInfo, "", ErrAuthFailed
+	}
+	if cert.ValidBefore == ssh.CertTimeInfinity ||
+		(a.MaxUserCertValidity > 0 && time.Duration(cert.ValidBefore-cert.ValidAfter)*time.Second > a.MaxUserCertValidity) {
+		log.WithField("workspaceId", workspaceID).WithField("keyId", cert.KeyId).Debug("rejecting user certificate: validity period is too long")
+		return wsInfo, "", ErrAuthFailed
+	}
+
+	checker := &ssh.CertChecker{Clock: a.clock}
+	for _, principal := range cert.ValidPrincipals {
+		if !mayAccessWorkspace(wsInfo, principal) {
+			continue
+		}
+		err = checker.CheckCert(principal, cert)
+		if err != nil {
+			log.WithError(err).WithField("workspaceId", workspaceID).Debug("rejecting user certificate")
+			return wsInfo, "", ErrAuthFailed
+		}
+		return wsInfo, principal, nil
+	}
+	return wsInfo, "", ErrAuthFailed
+}
+
+// checkCert checks the certificate type and that it was signed by one of the authorities
+func (a *certAuthenticator) checkCert(cert *ssh.Certificate, certType uint32, authorities []ssh.PublicKey) error {
+	if cert.CertType != certType {
+		return xerrors.Errorf("unexpected certificate type %d", cert.CertType)
+	}
+	if cert.SignatureKey == nil {
+		return xerrors.Errorf("certificate has no signature key")
+	}
+	signer := cert.SignatureKey.Marshal()
+	for _, ca := range authorities {
+		if bytes.Equal(ca.Marshal(), signer) {
+			return nil
+		}
+	}
+	return xerrors.Errorf("certificate %q is not signed by a trusted authority", cert.KeyId)
+}
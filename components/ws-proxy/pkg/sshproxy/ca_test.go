@@ -0,0 +1,120 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package sshproxy
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+func TestCertAuthenticator(t *testing.T) {
+	newSigner := func() ssh.Signer {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := ssh.NewSignerFromKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signer
+	}
+	var (
+		now       = time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+		userCA    = newSigner()
+		untrusted = newSigner()
+		userKey   = newSigner()
+	)
+	type certSpec struct {
+		Type       uint32
+		CA         ssh.Signer
+		Principals []string
+		ValidAfter time.Time
+		Validity   time.Duration
+		Infinite   bool
+	}
+	sign := func(spec certSpec) *ssh.Certificate {
+		if spec.Type == 0 {
+			spec.Type = ssh.UserCert
+		}
+		if spec.CA == nil {
+			spec.CA = userCA
+		}
+		if spec.ValidAfter.IsZero() {
+			spec.ValidAfter = now.Add(-time.Minute)
+		}
+		if spec.Validity == 0 {
+			spec.Validity = 5 * time.Minute
+		}
+		cert := &ssh.Certificate{
+			Key:             userKey.PublicKey(),
+			KeyId:           "test",
+			CertType:        spec.Type,
+			ValidPrincipals: spec.Principals,
+			ValidAfter:      uint64(spec.ValidAfter.Unix()),
+			ValidBefore:     uint64(spec.ValidAfter.Add(spec.Validity).Unix()),
+		}
+		if spec.Infinite {
+			cert.ValidBefore = ssh.CertTimeInfinity
+		}
+		err := cert.SignCert(rand.Reader, spec.CA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	infos := fakeInfoProvider{
+		"private": {WorkspaceID: "private", OwnerUserId: "owner", Auth: &wsmanapi.WorkspaceAuthentication{Admission: wsmanapi.AdmissionLevel_ADMIT_OWNER_ONLY}},
+		"shared":  {WorkspaceID: "shared", OwnerUserId: "owner", Auth: &wsmanapi.WorkspaceAuthentication{Admission: wsmanapi.AdmissionLevel_ADMIT_EVERYONE}},
+	}
+
+	tests := []struct {
+		Name        string
+		WorkspaceID string
+		Cert        certSpec
+		Expectation error
+		UserID      string
+	}{
+		{Name: "owner", WorkspaceID: "private", Cert: certSpec{Principals: []string{"owner"}}, UserID: "owner"},
+		{Name: "owner among principals", WorkspaceID: "private", Cert: certSpec{Principals: []string{"other", "owner"}}, UserID: "owner"},
+		{Name: "other user", WorkspaceID: "private", Cert: certSpec{Principals: []string{"other"}}, Expectation: ErrAuthFailed},
+		{Name: "other user shared", WorkspaceID: "shared", Cert: certSpec{Principals: []string{"other"}}, UserID: "other"},
+		{Name: "no principals", WorkspaceID: "private", Cert: certSpec{}, Expectation: ErrAuthFailed},
+		{Name: "untrusted CA", WorkspaceID: "private", Cert: certSpec{CA: untrusted, Principals: []string{"owner"}}, Expectation: ErrAuthFailed},
+		{Name: "host certificate", WorkspaceID: "private", Cert: certSpec{Type: ssh.HostCert, Principals: []string{"owner"}}, Expectation: ErrAuthFailed},
+		{Name: "expired", WorkspaceID: "private", Cert: certSpec{Principals: []string{"owner"}, ValidAfter: now.Add(-time.Hour)}, Expectation: ErrAuthFailed},
+		{Name: "not yet valid", WorkspaceID: "private", Cert: certSpec{Principals: []string{"owner"}, ValidAfter: now.Add(time.Minute)}, Expectation: ErrAuthFailed},
+		{Name: "validity too long", WorkspaceID: "private", Cert: certSpec{Principals: []string{"owner"}, Validity: 24 * time.Hour}, Expectation: ErrAuthFailed},
+		{Name: "valid forever", WorkspaceID: "private", Cert: certSpec{Principals: []string{"owner"}, Infinite: true}, Expectation: ErrAuthFailed},
+		{Name: "unknown workspace", WorkspaceID: "unknown", Cert: certSpec{Principals: []string{"owner"}}, Expectation: ErrWorkspaceNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			auth := &certAuthenticator{
+				CertificateAuthority: CertificateAuthority{
+					UserCAKeys:          []ssh.PublicKey{userCA.PublicKey()},
+					MaxUserCertValidity: time.Hour,
+				},
+				WorkspaceInfoProvider: infos,
+				clock:                 func() time.Time { return now },
+			}
+
+			_, userID, err := auth.Authenticate(test.WorkspaceID, sign(test.Cert))
+			if err != test.Expectation {
+				t.Errorf("unexpected error: want %v, got %v", test.Expectation, err)
+			}
+			if userID != test.UserID {
+				t.Errorf("unexpected user ID: want %q, got %q", test.UserID, userID)
+			}
+		})
+	}
+}
@@ -33,11 +33,24 @@ func (s *Server) ChannelForward(ctx context.Context, session *Session, client *s
 	}
 	defer clientChan.Close()
 
+	audit := s.startChannelAudit(session, newChannel.ChannelType())
+	defer audit.Finish(s.RecordingStorage)
+	clientChan = audit.Wrap(clientChan)
+
 	maskedReqs := make(chan *ssh.Request, 1)
 
 	go func() {
 		for req := range clientReqs {
+			audit.ObserveRequest(req)
 			switch req.Type {
+			case "auth-agent-req@openssh.com":
+				if !s.Forwarding.AllowAgentForwarding {
+					log.WithFields(log.OWI("", session.WorkspaceID, session.InstanceID)).Debug("rejecting agent forwarding request")
+					if req.WantReply {
+						req.Reply(false, nil)
+					}
+					continue
+				}
 			case "pty-req", "shell":
 				log.WithFields(log.OWI("", session.WorkspaceID, session.InstanceID)).Debugf("forwarding %s request", req.Type)
 				if req.WantReply {
@@ -0,0 +1,137 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package sshproxy
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+)
+
+const (
+	channelTypeForwardedTCPIP = "forwarded-tcpip"
+	channelTypeAuthAgent      = "auth-agent@openssh.com"
+)
+
+// ForwardingPolicy determines which kinds of forwarding the gateway proxies between users and workspaces
+type ForwardingPolicy struct {
+	// AllowRemotePortForwarding enables ssh -R, i.e. exposing services of the user's machine in the workspace
+	AllowRemotePortForwarding bool `json:"allowRemotePortForwarding"`
+	// AllowAgentForwarding enables ssh -A, i.e. using the user's SSH agent from within the workspace
+	AllowAgentForwarding bool `json:"allowAgentForwarding"`
+}
+
+// handleGlobalRequests proxies the global requests of the user's connection to the workspace
+func (s *Server) handleGlobalRequests(session *Session, client *ssh.Client, reqs <-chan *ssh.Request) {
+	for req := range reqs {
+		switch req.Type {
+		case "tcpip-forward", "cancel-tcpip-forward":
+			if !s.Forwarding.AllowRemotePortForwarding {
+				log.WithFields(log.OWI("", session.WorkspaceID, session.InstanceID)).Debug("rejecting remote port forwarding request")
+				_ = req.Reply(false, nil)
+				continue
+			}
+
+			ok, payload, err := client.SendRequest(req.Type, req.WantReply, req.Payload)
+			if err != nil {
+				log.WithFields(log.OWI("", session.WorkspaceID, session.InstanceID)).WithError(err).Warnf("cannot forward %s request", req.Type)
+				ok, payload = false, nil
+			}
+			if req.WantReply {
+				_ = req.Reply(ok, payload)
+			}
+		default:
+			if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+// routeWorkspaceChannels forwards the channels opened by the workspace which are meant for the user
+// (remote forwarded ports and agent connections) and passes on all others to the workspace client.
+func (s *Server) routeWorkspaceChannels(ctx context.Context, session *Session, chans <-chan ssh.NewChannel, workspaceChans chan<- ssh.NewChannel) {
+	defer close(workspaceChans)
+
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case channelTypeForwardedTCPIP:
+			if !s.Forwarding.AllowRemotePortForwarding {
+				newChannel.Reject(ssh.Prohibited, "remote port forwarding is disabled")
+				continue
+			}
+			go s.forwardToUser(ctx, session, newChannel)
+		case channelTypeAuthAgent:
+			if !s.Forwarding.AllowAgentForwarding {
+				newChannel.Reject(ssh.Prohibited, "agent forwarding is disabled")
+				continue
+			}
+			go s.forwardToUser(ctx, session, newChannel)
+		default:
+			workspaceChans <- newChannel
+		}
+	}
+}
+
+// forwardToUser opens a channel the workspace requested on the user's connection and proxies between the two
+func (s *Server) forwardToUser(ctx context.Context, session *Session, newChannel ssh.NewChannel) {
+	userChan, userReqs, err := session.Conn.OpenChannel(newChannel.ChannelType(), newChannel.ExtraData())
+	if err != nil {
+		log.WithFields(log.OWI("", session.WorkspaceID, session.InstanceID)).WithError(err).Debugf("cannot open %s channel to user", newChannel.ChannelType())
+		newChannel.Reject(ssh.ConnectionFailed, "cannot open channel to user")
+		return
+	}
+	defer userChan.Close()
+
+	workspaceChan, workspaceReqs, err := newChannel.Accept()
+	if err != nil {
+		log.WithFields(log.OWI("", session.WorkspaceID, session.InstanceID)).WithError(err).Error("accept new workspace channel failed")
+		return
+	}
+	defer workspaceChan.Close()
+
+	pipeChannels(ctx, userChan, userReqs, workspaceChan, workspaceReqs)
+}
+
+// pipeChannels copies data and requests between two channels until both directions are done
+func pipeChannels(ctx context.Context, a ssh.Channel, aReqs <-chan *ssh.Request, b ssh.Channel, bReqs <-chan *ssh.Request) {
+	var wg sync.WaitGroup
+	copyData := func(dst, src ssh.Channel) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+		_ = dst.CloseWrite()
+	}
+	forwardRequests := func(reqs <-chan *ssh.Request, target ssh.Channel) {
+		for {
+			select {
+			case req, ok := <-reqs:
+				if !ok {
+					return
+				}
+				res, err := target.SendRequest(req.Type, req.WantReply, req.Payload)
+				if req.WantReply {
+					_ = req.Reply(res, nil)
+				}
+				if err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	go forwardRequests(aReqs, b)
+	go forwardRequests(bReqs, a)
+
+	wg.Add(2)
+	go copyData(a, b)
+	go copyData(b, a)
+	wg.Wait()
+}
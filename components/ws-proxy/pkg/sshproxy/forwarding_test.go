@@ -0,0 +1,91 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package sshproxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh"
+)
+
+type fakeNewChannel struct {
+	Type     string
+	Rejected ssh.RejectionReason
+}
+
+func (f *fakeNewChannel) Accept() (ssh.Channel, <-chan *ssh.Request, error) {
+	return nil, nil, nil
+}
+
+func (f *fakeNewChannel) Reject(reason ssh.RejectionReason, message string) error {
+	f.Rejected = reason
+	return nil
+}
+
+func (f *fakeNewChannel) ChannelType() string { return f.Type }
+func (f *fakeNewChannel) ExtraData() []byte   { return nil }
+
+func TestRouteWorkspaceChannelsPolicy(t *testing.T) {
+	type Expectation struct {
+		Rejected []string
+		Passed   []string
+	}
+	tests := []struct {
+		Name        string
+		Policy      ForwardingPolicy
+		Channels    []string
+		Expectation Expectation
+	}{
+		{
+			Name:     "forwarding disabled",
+			Channels: []string{channelTypeForwardedTCPIP, channelTypeAuthAgent, "session"},
+			Expectation: Expectation{
+				Rejected: []string{channelTypeForwardedTCPIP, channelTypeAuthAgent},
+				Passed:   []string{"session"},
+			},
+		},
+		{
+			Name:     "unrelated channels are passed on",
+			Policy:   ForwardingPolicy{AllowRemotePortForwarding: true, AllowAgentForwarding: true},
+			Channels: []string{"session", "direct-tcpip"},
+			Expectation: Expectation{
+				Passed: []string{"session", "direct-tcpip"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				srv            = &Server{Forwarding: test.Policy}
+				chans          = make(chan ssh.NewChannel, len(test.Channels))
+				workspaceChans = make(chan ssh.NewChannel, len(test.Channels))
+				fakes          []*fakeNewChannel
+			)
+			for _, tpe := range test.Channels {
+				f := &fakeNewChannel{Type: tpe}
+				fakes = append(fakes, f)
+				chans <- f
+			}
+			close(chans)
+
+			srv.routeWorkspaceChannels(context.Background(), &Session{}, chans, workspaceChans)
+
+			var act Expectation
+			for nc := range workspaceChans {
+				act.Passed = append(act.Passed, nc.ChannelType())
+			}
+			for _, f := range fakes {
+				if f.Rejected == ssh.Prohibited {
+					act.Rejected = append(act.Rejected, f.Type)
+				}
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("routeWorkspaceChannels() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -33,16 +33,24 @@ type Session struct {
 
 	WorkspaceID string
 	InstanceID  string
+	OwnerUserID string
+	// UserID is the user who authenticated this session
+	UserID string
 
 	PublicKey           ssh.PublicKey
 	WorkspacePrivateKey ssh.Signer
 }
 
 type Server struct {
-	Heartbeater Heartbeat
+	Heartbeater      Heartbeat
+	Forwarding       ForwardingPolicy
+	Audit            AuditPolicy
+	RecordingStorage RecordingStorage
 
 	sshConfig             *ssh.ServerConfig
 	workspaceInfoProvider p.WorkspaceInfoProvider
+	userKeyAuth           *userKeyAuthenticator
+	ca                    *certAuthenticator
 }
 
 // New creates a new SSH proxy server
@@ -86,6 +94,13 @@ func New(signers []ssh.Signer, workspaceInfoProvider p.WorkspaceInfoProvider, he
 			}, nil
 		},
 		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if cert, ok := key.(*ssh.Certificate); ok && server.ca != nil && !isOwnerTokenUsername(conn.User()) {
+				return server.authenticateUserCert(conn.User(), cert)
+			}
+			if !isOwnerTokenUsername(conn.User()) {
+				return server.authenticateUserKey(conn.User(), key)
+			}
+
 			args := strings.Split(conn.User(), "#")
 			// workspaceId#ownerToken
 			if len(args) != 2 {
@@ -112,6 +127,32 @@ func New(signers []ssh.Signer, workspaceInfoProvider p.WorkspaceInfoProvider, he
 	return server
 }
 
+// EnableUserKeyAuth lets users authenticate with the public keys registered with their Gitpod account,
+// using their workspace ID as username.
+func (s *Server) EnableUserKeyAuth(resolver UserKeyResolver) {
+	s.userKeyAuth = newUserKeyAuthenticator(resolver, s.workspaceInfoProvider)
+}
+
+func (s *Server) authenticateUserKey(workspaceId string, key ssh.PublicKey) (perm *ssh.Permissions, err error) {
+	if s.userKeyAuth == nil {
+		return nil, ErrUsernameFormat
+	}
+
+	wsInfo, userId, err := s.userKeyAuth.Authenticate(workspaceId, key)
+	defer func() {
+		s.TrackSSHConnection(wsInfo, "auth", err)
+	}()
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.Permissions{
+		Extensions: map[string]string{
+			"workspaceId": workspaceId,
+			"userId":      userId,
+		},
+	}, nil
+}
+
 func (s *Server) HandleConn(c net.Conn) {
 	sshConn, chans, reqs, err := ssh.NewServerConn(c, s.sshConfig)
 	if err != nil {
@@ -120,7 +161,16 @@ func (s *Server) HandleConn(c net.Conn) {
 	}
 	defer sshConn.Close()
 
-	go ssh.DiscardRequests(reqs)
+	// Global requests are handled once we're connected to the workspace. Until then the SSH library
+	// buffers a few of them. If we never get there, we must discard them: the SSH library stops reading
+	// from the connection once nobody receives its global requests.
+	var handlingRequests bool
+	defer func() {
+		if !handlingRequests {
+			go ssh.DiscardRequests(reqs)
+		}
+	}()
+
 	if sshConn.Permissions == nil || sshConn.Permissions.Extensions == nil || sshConn.Permissions.Extensions["workspaceId"] == "" {
 		return
 	}
@@ -138,10 +188,17 @@ func (s *Server) HandleConn(c net.Conn) {
 	}
 	cancel()
 
+	// users who authenticate using the owner token are the owner
+	userId := sshConn.Permissions.Extensions["userId"]
+	if userId == "" {
+		userId = wsInfo.OwnerUserId
+	}
 	session := &Session{
 		Conn:                sshConn,
 		WorkspaceID:         workspaceId,
 		InstanceID:          wsInfo.InstanceID,
+		OwnerUserID:         wsInfo.OwnerUserId,
+		UserID:              userId,
 		WorkspacePrivateKey: key,
 	}
 	remoteAddr := wsInfo.IPAddress + ":23001"
@@ -169,9 +226,14 @@ func (s *Server) HandleConn(c net.Conn) {
 		return
 	}
 	s.Heartbeater.SendHeartbeat(wsInfo.InstanceID, false)
-	client := ssh.NewClient(clientConn, clientChans, clientReqs)
+	workspaceChans := make(chan ssh.NewChannel)
+	client := ssh.NewClient(clientConn, workspaceChans, clientReqs)
 	ctx, cancel = context.WithCancel(context.Background())
 
+	go s.routeWorkspaceChannels(ctx, session, clientChans, workspaceChans)
+	go s.handleGlobalRequests(session, client, reqs)
+	handlingRequests = true
+
 	s.TrackSSHConnection(wsInfo, "connect", nil)
 
 	go func() {
@@ -183,8 +245,6 @@ func (s *Server) HandleConn(c net.Conn) {
 		switch newChannel.ChannelType() {
 		case "session", "direct-tcpip":
 			go s.ChannelForward(ctx, session, client, newChannel)
-		case "tcpip-forward":
-			newChannel.Reject(ssh.UnknownChannelType, "Gitpod SSH Gateway cannot remote forward ports")
 		default:
 			newChannel.Reject(ssh.UnknownChannelType, fmt.Sprintf("Gitpod SSH Gateway cannot handle %s channel types", newChannel.ChannelType()))
 		}
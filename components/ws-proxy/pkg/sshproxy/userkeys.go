@@ -0,0 +1,181 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package sshproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+	"k8s.io/apimachinery/pkg/util/cache"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
+	p "github.com/gitpod-io/gitpod/ws-proxy/pkg/proxy"
+)
+
+// ErrKeyNotFound is returned by a UserKeyResolver if no user registered a key
+var ErrKeyNotFound = errors.New("public key not registered")
+
+// UserKeyResolver resolves the public keys users registered with their Gitpod account
+type UserKeyResolver interface {
+	// ResolveUser returns the ID of the user who registered the key with the given SHA256 fingerprint.
+	// Returns ErrKeyNotFound if no user registered this key.
+	ResolveUser(ctx context.Context, fingerprint string) (userID string, err error)
+}
+
+// HTTPUserKeyResolver resolves user keys using the /ssh-gateway/resolve-user endpoint of server.
+// The endpoint is called with a fingerprint query parameter and responds with {"userId": "..."},
+// or with 404 if no user registered the key.
+type HTTPUserKeyResolver struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+type resolveUserResponse struct {
+	UserID string `json:"userId"`
+}
+
+// ResolveUser resolves the user who registered a key
+func (r *HTTPUserKeyResolver) ResolveUser(ctx context.Context, fingerprint string) (userID string, err error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return "", xerrors.Errorf("invalid user key URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("fingerprint", fingerprint)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", xerrors.Errorf("cannot resolve user key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", ErrKeyNotFound
+	default:
+		return "", xerrors.Errorf("cannot resolve user key: unexpected status %d", resp.StatusCode)
+	}
+
+	var res resolveUserResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return "", xerrors.Errorf("cannot decode user key response: %w", err)
+	}
+	if res.UserID == "" {
+		return "", ErrKeyNotFound
+	}
+	return res.UserID, nil
+}
+
+const (
+	// userKeyCacheSize is the number of authentication decisions we keep
+	userKeyCacheSize = 4096
+	// userKeyAllowTTL is the time we remember a granted access. This is the maximum delay with which
+	// removing a key or un-sharing a workspace affects new connections.
+	userKeyAllowTTL = 2 * time.Minute
+	// userKeyDenyTTL is the time we remember a denied access
+	userKeyDenyTTL = 10 * time.Second
+	// userKeyResolveTimeout is the time we wait for a key to be resolved
+	userKeyResolveTimeout = 5 * time.Second
+)
+
+type userKeyDecision struct {
+	UserID string
+	Err    error
+}
+
+// userKeyAuthenticator authenticates workspace access using user-registered public keys
+type userKeyAuthenticator struct {
+	Resolver              UserKeyResolver
+	WorkspaceInfoProvider p.WorkspaceInfoProvider
+
+	decisions *cache.LRUExpireCache
+}
+
+func newUserKeyAuthenticator(resolver UserKeyResolver, infoProvider p.WorkspaceInfoProvider) *userKeyAuthenticator {
+	return &userKeyAuthenticator{
+		Resolver:              resolver,
+		WorkspaceInfoProvider: infoProvider,
+		decisions:             cache.NewLRUExpireCache(userKeyCacheSize),
+	}
+}
+
+// Authenticate checks if the user who registered the key may access the workspace
+func (a *userKeyAuthenticator) Authenticate(workspaceID string, key ssh.PublicKey) (wsInfo *p.WorkspaceInfo, userID string, err error) {
+	wsInfo = a.WorkspaceInfoProvider.WorkspaceInfo(workspaceID)
+	if wsInfo == nil {
+		return nil, "", ErrWorkspaceNotFound
+	}
+
+	fingerprint := ssh.FingerprintSHA256(key)
+	cacheKey := fmt.Sprintf("%s/%s", workspaceID, fingerprint)
+	if d, ok := a.decisions.Get(cacheKey); ok {
+		decision := d.(userKeyDecision)
+		return wsInfo, decision.UserID, decision.Err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), userKeyResolveTimeout)
+	defer cancel()
+	userID, err = a.Resolver.ResolveUser(ctx, fingerprint)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		// we don't cache transient failures
+		log.WithError(err).WithField("workspaceId", workspaceID).Warn("cannot resolve user key")
+		return wsInfo, "", ErrAuthFailed
+	}
+	if err == nil && !mayAccessWorkspace(wsInfo, userID) {
+		err = ErrAuthFailed
+	}
+	if errors.Is(err, ErrKeyNotFound) {
+		err = ErrAuthFailed
+	}
+
+	ttl := userKeyAllowTTL
+	if err != nil {
+		ttl = userKeyDenyTTL
+	}
+	a.decisions.Add(cacheKey, userKeyDecision{UserID: userID, Err: err}, ttl)
+
+	return wsInfo, userID, err
+}
+
+// mayAccessWorkspace returns true if the user owns the workspace or the workspace is shared
+func mayAccessWorkspace(wsInfo *p.WorkspaceInfo, userID string) bool {
+	if userID == "" {
+		return false
+	}
+	if wsInfo.OwnerUserId == userID {
+		return true
+	}
+	return wsInfo.Auth != nil && wsInfo.Auth.Admission == wsmanapi.AdmissionLevel_ADMIT_EVERYONE
+}
+
+// isOwnerTokenUsername returns true if the username follows the workspaceId#ownerToken format
+func isOwnerTokenUsername(username string) bool {
+	return strings.Contains(username, "#")
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package sshproxy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
+	p "github.com/gitpod-io/gitpod/ws-proxy/pkg/proxy"
+)
+
+type fakeInfoProvider map[string]*p.WorkspaceInfo
+
+func (f fakeInfoProvider) WorkspaceInfo(workspaceID string) *p.WorkspaceInfo {
+	return f[workspaceID]
+}
+
+type fakeResolver struct {
+	Users map[string]string
+	Calls int
+}
+
+func (f *fakeResolver) ResolveUser(ctx context.Context, fingerprint string) (string, error) {
+	f.Calls++
+	u, ok := f.Users[fingerprint]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return u, nil
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestUserKeyAuthenticator(t *testing.T) {
+	newKey := func() ssh.PublicKey {
+		return newTestSigner(t).PublicKey()
+	}
+	var (
+		ownerKey   = newKey()
+		otherKey   = newKey()
+		unknownKey = newKey()
+	)
+	infos := fakeInfoProvider{
+		"private": {WorkspaceID: "private", OwnerUserId: "owner", Auth: &wsmanapi.WorkspaceAuthentication{Admission: wsmanapi.AdmissionLevel_ADMIT_OWNER_ONLY}},
+		"shared":  {WorkspaceID: "shared", OwnerUserId: "owner", Auth: &wsmanapi.WorkspaceAuthentication{Admission: wsmanapi.AdmissionLevel_ADMIT_EVERYONE}},
+	}
+
+	tests := []struct {
+		Name        string
+		WorkspaceID string
+		Key         ssh.PublicKey
+		Expectation error
+	}{
+		{Name: "owner", WorkspaceID: "private", Key: ownerKey},
+		{Name: "other user", WorkspaceID: "private", Key: otherKey, Expectation: ErrAuthFailed},
+		{Name: "other user shared", WorkspaceID: "shared", Key: otherKey},
+		{Name: "unknown key", WorkspaceID: "shared", Key: unknownKey, Expectation: ErrAuthFailed},
+		{Name: "unknown workspace", WorkspaceID: "unknown", Key: ownerKey, Expectation: ErrWorkspaceNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			resolver := &fakeResolver{Users: map[string]string{
+				ssh.FingerprintSHA256(ownerKey): "owner",
+				ssh.FingerprintSHA256(otherKey): "other",
+			}}
+			auth := newUserKeyAuthenticator(resolver, infos)
+
+			for i := 0; i < 2; i++ {
+				_, _, err := auth.Authenticate(test.WorkspaceID, test.Key)
+				if err != test.Expectation {
+					t.Errorf("Authenticate() = %v, expected %v", err, test.Expectation)
+				}
+			}
+			if test.Expectation != ErrWorkspaceNotFound && resolver.Calls != 1 {
+				t.Errorf("expected decision to be cached, but resolver was called %d times", resolver.Calls)
+			}
+		})
+	}
+}
+
+func TestServerUserKeyAuth(t *testing.T) {
+	const token = "gateway-token"
+	var (
+		ownerKey   = newTestSigner(t)
+		otherKey   = newTestSigner(t)
+		unknownKey = newTestSigner(t)
+	)
+	users := map[string]string{
+		ssh.FingerprintSHA256(ownerKey.PublicKey()): "owner",
+		ssh.FingerprintSHA256(otherKey.PublicKey()): "other",
+	}
+	// keysrv behaves like server's /ssh-gateway/resolve-user endpoint
+	keysrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ssh-gateway/resolve-user" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		userID, ok := users[r.URL.Query().Get("fingerprint")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resolveUserResponse{UserID: userID})
+	}))
+	defer keysrv.Close()
+
+	infos := fakeInfoProvider{
+		"private": {WorkspaceID: "private", OwnerUserId: "owner", IPAddress: "127.0.0.1", Auth: &wsmanapi.WorkspaceAuthentication{Admission: wsmanapi.AdmissionLevel_ADMIT_OWNER_ONLY}},
+		"shared":  {WorkspaceID: "shared", OwnerUserId: "owner", IPAddress: "127.0.0.1", Auth: &wsmanapi.WorkspaceAuthentication{Admission: wsmanapi.AdmissionLevel_ADMIT_EVERYONE}},
+	}
+
+	tests := []struct {
+		Name        string
+		Token       string
+		WorkspaceID string
+		Key         ssh.Signer
+		Expectation bool
+	}{
+		{Name: "owner", Token: token, WorkspaceID: "private", Key: ownerKey, Expectation: true},
+		{Name: "other user", Token: token, WorkspaceID: "private", Key: otherKey},
+		{Name: "other user shared", Token: token, WorkspaceID: "shared", Key: otherKey, Expectation: true},
+		{Name: "unknown key", Token: token, WorkspaceID: "shared", Key: unknownKey},
+		{Name: "unknown workspace", Token: token, WorkspaceID: "unknown", Key: ownerKey},
+		{Name: "invalid token", Token: "wrong", WorkspaceID: "private", Key: ownerKey},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			server := New([]ssh.Signer{newTestSigner(t)}, infos, nil)
+			server.EnableUserKeyAuth(&HTTPUserKeyResolver{
+				URL:    keysrv.URL + "/ssh-gateway/resolve-user",
+				Token:  test.Token,
+				Client: keysrv.Client(),
+			})
+
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+			go func() { _ = server.Serve(l) }()
+
+			client, err := ssh.Dial("tcp", l.Addr().String(), &ssh.ClientConfig{
+				User:            test.WorkspaceID,
+				Auth:            []ssh.AuthMethod{ssh.PublicKeys(test.Key)},
+				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			})
+			if client != nil {
+				client.Close()
+			}
+			if test.Expectation && err != nil {
+				t.Errorf("expected to authenticate, got %v", err)
+			}
+			if !test.Expectation && (err == nil || !strings.Contains(err.Error(), "unable to authenticate")) {
+				t.Errorf("expected authentication to fail, got %v", err)
+			}
+		})
+	}
+}
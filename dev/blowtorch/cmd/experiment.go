@@ -0,0 +1,155 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/gitpod-io/gitpod/blowtorch/pkg/experiment"
+)
+
+// experimentCmd represents the experiment command
+var experimentCmd = &cobra.Command{
+	Use:   "experiment",
+	Short: "Runs declarative, time-boxed chaos experiments",
+	Long: `Runs declarative, time-boxed chaos experiments on a link between two components.
+An experiment verifies its steady state before, while and optionally after it runs, and reverts the link when it's done or aborted.
+
+Available links: ` + strings.Join(linkNames(), ", "),
+}
+
+// experimentRunCmd represents the experiment run command
+var experimentRunCmd = &cobra.Command{
+	Use:   "run <experiment.yaml>",
+	Short: "Runs an experiment and reverts the link when it's done, aborted or interrupted",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exp, link := loadExperiment(args[0])
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigChan
+			log.Info("received SIGINT - aborting experiment")
+			cancel()
+		}()
+
+		res, err := experiment.Run(ctx, exp, link, exp.Probes())
+		if res != nil {
+			writeResult(cmd, res)
+		}
+		if err != nil {
+			log.WithError(err).Fatal("experiment failed")
+		}
+
+		if res.Aborted {
+			log.WithField("reason", res.Reason).Error("experiment aborted")
+			os.Exit(1)
+		}
+		if res.Recovered != nil && !*res.Recovered {
+			log.Error("steady state did not recover")
+			os.Exit(1)
+		}
+		log.Info("🎯  steady state held throughout the experiment")
+	},
+}
+
+// experimentCheckCmd represents the experiment check command
+var experimentCheckCmd = &cobra.Command{
+	Use:   "check <experiment.yaml>",
+	Short: "Checks whether the steady state of an experiment holds, without tampering with anything",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exp, err := experiment.Load(args[0])
+		if err != nil {
+			log.WithError(err).Fatal("cannot load experiment")
+		}
+
+		var failed bool
+		for _, p := range exp.Probes() {
+			err := p.Check(context.Background())
+			if err != nil {
+				log.WithError(err).WithField("probe", p.Name()).Error("steady state does not hold")
+				failed = true
+				continue
+			}
+			log.WithField("probe", p.Name()).Info("steady state holds")
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// experimentRevertCmd represents the experiment revert command
+var experimentRevertCmd = &cobra.Command{
+	Use:   "revert <experiment.yaml>",
+	Short: "Reverts the link of an experiment, e.g. if blowtorch was killed while the experiment ran",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, link := loadExperiment(args[0])
+		err := link.Revert()
+		if err != nil {
+			log.WithError(err).Fatal("cannot revert link")
+		}
+	},
+}
+
+func loadExperiment(fn string) (*experiment.Experiment, experiment.Link) {
+	exp, err := experiment.Load(fn)
+	if err != nil {
+		log.WithError(err).Fatal("cannot load experiment")
+	}
+	cfg, ns, err := getKubeconfig()
+	if err != nil {
+		log.WithError(err).Fatal("cannot get Kubernetes client config")
+	}
+	return exp, experiment.Links[exp.Link](cfg, ns)
+}
+
+func writeResult(cmd *cobra.Command, res *experiment.Result) {
+	fn, _ := cmd.Flags().GetString("result")
+	if fn == "" {
+		return
+	}
+	fc, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		log.WithError(err).Error("cannot marshal result")
+		return
+	}
+	err = os.WriteFile(fn, fc, 0644)
+	if err != nil {
+		log.WithError(err).WithField("fn", fn).Error("cannot write result")
+	}
+}
+
+func linkNames() []string {
+	res := make([]string, 0, len(experiment.Links))
+	for n := range experiment.Links {
+		res = append(res, n)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func init() {
+	rootCmd.AddCommand(experimentCmd)
+
+	experimentRunCmd.Flags().String("result", "", "write the result of the experiment, including all steady state checks, to this JSON file")
+	experimentCmd.AddCommand(experimentRunCmd)
+	experimentCmd.AddCommand(experimentCheckCmd)
+	experimentCmd.AddCommand(experimentRevertCmd)
+}
@@ -26,6 +26,9 @@ const (
 	fmtOriginalService        = "%s-original"
 	fmtProxyDeployment        = "%s-toxiproxy"
 	renamedServiceLabelPrefix = "renamed/"
+
+	// toxiproxyImage supports the reset_peer toxic, which the shopify/toxiproxy images on Docker Hub do not
+	toxiproxyImage = "ghcr.io/shopify/toxiproxy:2.5.0"
 )
 
 type injectOptions struct {
@@ -114,7 +117,7 @@ func Inject(cfg *rest.Config, namespace, targetService string, options ...Inject
 					Containers: []corev1.Container{
 						{
 							Name:  "proxy",
-							Image: "shopify/toxiproxy",
+							Image: toxiproxyImage,
 							SecurityContext: &corev1.SecurityContext{
 								RunAsUser: &uid,
 							},
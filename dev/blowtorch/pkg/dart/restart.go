@@ -0,0 +1,61 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package dart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	restartedAtAnnotation = "blowtorch.sh/restartedAt"
+	restartRolloutTimeout = 5 * time.Minute
+)
+
+// RestartDeployment restarts all pods of a deployment like kubectl rollout restart does and waits for the rollout.
+// Clients keep connections to a service that was replaced with a toxiproxy, hence we restart them after an injection.
+func RestartDeployment(cfg *rest.Config, namespace, deployment string) error {
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`, restartedAtAnnotation, time.Now().Format(time.RFC3339))
+	_, err = client.AppsV1().Deployments(namespace).Patch(context.Background(), deployment, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	log.WithField("name", deployment).Info("deployment restarted")
+
+	err = wait.PollImmediate(2*time.Second, restartRolloutTimeout, func() (bool, error) {
+		depl, err := client.AppsV1().Deployments(namespace).Get(context.Background(), deployment, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		var replicas int32 = 1
+		if depl.Spec.Replicas != nil {
+			replicas = *depl.Spec.Replicas
+		}
+		st := depl.Status
+		return st.ObservedGeneration >= depl.Generation &&
+			st.UpdatedReplicas == replicas &&
+			st.AvailableReplicas == replicas &&
+			st.Replicas == replicas, nil
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot wait for deployment %s to roll out: %w", deployment, err)
+	}
+	return nil
+}
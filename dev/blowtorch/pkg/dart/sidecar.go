@@ -0,0 +1,155 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package dart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	sidecarContainerName  = "toxiproxy"
+	sidecarRolloutTimeout = 5 * time.Minute
+)
+
+// InjectSidecar adds a toxiproxy container to all pods of a daemon set, which forwards listenPort to upstreamPort
+// of the pod. Unlike Inject this works for connections to pod IPs, which bypass services. Clients have to connect
+// to listenPort for the toxiproxy to have an effect.
+func InjectSidecar(cfg *rest.Config, namespace, daemonSet string, listenPort, upstreamPort int) ([]*ProxiedToxiproxy, error) {
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := client.AppsV1().DaemonSets(namespace).Get(context.Background(), daemonSet, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var exists bool
+	for _, c := range ds.Spec.Template.Spec.Containers {
+		if c.Name == sidecarContainerName {
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		var uid int64 = 1000
+		ds.Spec.Template.Spec.Containers = append(ds.Spec.Template.Spec.Containers, corev1.Container{
+			Name:  sidecarContainerName,
+			Image: toxiproxyImage,
+			Ports: []corev1.ContainerPort{{
+				Name:          "toxiproxy",
+				ContainerPort: int32(listenPort),
+			}},
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser: &uid,
+			},
+		})
+		_, err = client.AppsV1().DaemonSets(namespace).Update(context.Background(), ds, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, err
+		}
+		log.WithField("name", daemonSet).Info("toxiproxy sidecar added")
+	}
+
+	err = waitForDaemonSetRollout(client, namespace, daemonSet)
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("name", daemonSet).Info("daemon set rolled out")
+
+	ds, err = client.AppsV1().DaemonSets(namespace).Get(context.Background(), daemonSet, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(ds.Spec.Selector),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*ProxiedToxiproxy
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		tpc, err := NewProxiedToxiproxy(cfg, namespace, pod.Name)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot connect to toxiproxy of %s: %w", pod.Name, err)
+		}
+		_, err = tpc.CreateProxy(daemonSet, fmt.Sprintf(":%d", listenPort), fmt.Sprintf("127.0.0.1:%d", upstreamPort))
+		if err != nil {
+			return nil, xerrors.Errorf("cannot proxy port %d -> %d of %s: %w", listenPort, upstreamPort, pod.Name, err)
+		}
+		log.WithField("pod", pod.Name).Infof("toxiproxy for port %d -> %d set up", listenPort, upstreamPort)
+		res = append(res, tpc)
+	}
+	return res, nil
+}
+
+// RemoveSidecar reverts the changes made by InjectSidecar
+func RemoveSidecar(cfg *rest.Config, namespace, daemonSet string) error {
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	ds, err := client.AppsV1().DaemonSets(namespace).Get(context.Background(), daemonSet, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	var (
+		containers = ds.Spec.Template.Spec.Containers[:0]
+		found      bool
+	)
+	for _, c := range ds.Spec.Template.Spec.Containers {
+		if c.Name == sidecarContainerName {
+			found = true
+			continue
+		}
+		containers = append(containers, c)
+	}
+	if !found {
+		log.WithField("name", daemonSet).Info("daemon set has no toxiproxy sidecar")
+		return nil
+	}
+	ds.Spec.Template.Spec.Containers = containers
+	_, err = client.AppsV1().DaemonSets(namespace).Update(context.Background(), ds, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	log.WithField("name", daemonSet).Info("toxiproxy sidecar removed")
+
+	return waitForDaemonSetRollout(client, namespace, daemonSet)
+}
+
+func waitForDaemonSetRollout(client kubernetes.Interface, namespace, daemonSet string) error {
+	err := wait.PollImmediate(2*time.Second, sidecarRolloutTimeout, func() (bool, error) {
+		ds, err := client.AppsV1().DaemonSets(namespace).Get(context.Background(), daemonSet, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		st := ds.Status
+		return st.ObservedGeneration >= ds.Generation &&
+			st.UpdatedNumberScheduled == st.DesiredNumberScheduled &&
+			st.NumberAvailable == st.DesiredNumberScheduled, nil
+	})
+	if err != nil {
+		return xerrors.Errorf("cannot wait for daemon set %s to roll out: %w", daemonSet, err)
+	}
+	return nil
+}
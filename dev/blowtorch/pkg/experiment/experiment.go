@@ -0,0 +1,263 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package experiment
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	toxiproxy "github.com/Shopify/toxiproxy/client"
+	"golang.org/x/xerrors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultInterval = 10 * time.Second
+	defaultTimeout  = 5 * time.Second
+)
+
+// Experiment is a time-boxed chaos experiment on a link between two components
+type Experiment struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Link is the connection the experiment tampers with, see Links
+	Link string `json:"link"`
+	// Duration is how long the toxics are active
+	Duration Duration `json:"duration"`
+	// Toxics are the failures the experiment injects into the link
+	Toxics []Toxic `json:"toxics"`
+
+	// SteadyState describes how the system behaves when it's healthy
+	SteadyState SteadyState `json:"steadyState"`
+}
+
+// ToxicType is a kind of failure
+type ToxicType string
+
+const (
+	// ToxicLatency delays all data by Latency +/- Jitter
+	ToxicLatency ToxicType = "latency"
+	// ToxicBandwidth limits the bandwidth to Rate KB/s
+	ToxicBandwidth ToxicType = "bandwidth"
+	// ToxicResetPeer resets connections after Timeout
+	ToxicResetPeer ToxicType = "reset_peer"
+	// ToxicTimeout stops all data and closes connections after Timeout, or never if Timeout is zero
+	ToxicTimeout ToxicType = "timeout"
+)
+
+// ToxicStream is the direction of traffic a toxic applies to
+type ToxicStream string
+
+const (
+	// StreamUpstream is traffic from the client to the server
+	StreamUpstream ToxicStream = "upstream"
+	// StreamDownstream is traffic from the server to the client
+	StreamDownstream ToxicStream = "downstream"
+)
+
+// Toxic is a failure injected into a link
+type Toxic struct {
+	Type ToxicType `json:"type"`
+	// Stream defaults to downstream
+	Stream ToxicStream `json:"stream,omitempty"`
+	// Toxicity is the probability of the toxic to apply to a connection. Defaults to 1.
+	Toxicity *float32 `json:"toxicity,omitempty"`
+
+	Latency Duration `json:"latency,omitempty"`
+	Jitter  Duration `json:"jitter,omitempty"`
+	Rate    int      `json:"rate,omitempty"`
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// Attributes produces the toxiproxy attributes of the toxic
+func (t Toxic) Attributes() toxiproxy.Attributes {
+	ms := func(d Duration) int64 { return time.Duration(d).Milliseconds() }
+	switch t.Type {
+	case ToxicLatency:
+		return toxiproxy.Attributes{"latency": ms(t.Latency), "jitter": ms(t.Jitter)}
+	case ToxicBandwidth:
+		return toxiproxy.Attributes{"rate": t.Rate}
+	case ToxicResetPeer, ToxicTimeout:
+		return toxiproxy.Attributes{"timeout": ms(t.Timeout)}
+	}
+	return nil
+}
+
+func (t Toxic) stream() string {
+	if t.Stream == "" {
+		return string(StreamDownstream)
+	}
+	return string(t.Stream)
+}
+
+func (t Toxic) toxicity() float32 {
+	if t.Toxicity == nil {
+		return 1
+	}
+	return *t.Toxicity
+}
+
+// SteadyState describes how the system behaves when it's healthy. The steady state must hold before
+// the experiment starts and while it runs, otherwise the experiment is aborted.
+type SteadyState struct {
+	// Interval is the time between checks while the experiment runs. Defaults to 10s.
+	Interval Duration `json:"interval,omitempty"`
+	// Recovery is the time the steady state may take to hold again once the link is reverted.
+	// If zero, recovery is not checked.
+	Recovery Duration `json:"recovery,omitempty"`
+
+	Probes []ProbeSpec `json:"probes"`
+}
+
+func (s SteadyState) interval() time.Duration {
+	if s.Interval == 0 {
+		return defaultInterval
+	}
+	return time.Duration(s.Interval)
+}
+
+// ProbeSpec configures a probe of the steady state. Exactly one of PromQL or GRPCHealth must be set.
+type ProbeSpec struct {
+	Name       string           `json:"name"`
+	PromQL     *PromQLProbe     `json:"promql,omitempty"`
+	GRPCHealth *GRPCHealthProbe `json:"grpcHealth,omitempty"`
+}
+
+// Load reads an experiment from a YAML file
+func Load(fn string) (*Experiment, error) {
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var res Experiment
+	err = yaml.UnmarshalStrict(fc, &res)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot unmarshal %s: %w", fn, err)
+	}
+	err = res.Validate()
+	if err != nil {
+		return nil, xerrors.Errorf("invalid experiment %s: %w", fn, err)
+	}
+	return &res, nil
+}
+
+// Validate checks the experiment for errors
+func (e *Experiment) Validate() error {
+	if e.Name == "" {
+		return xerrors.Errorf("name is required")
+	}
+	if _, ok := Links[e.Link]; !ok {
+		return xerrors.Errorf("unknown link %q", e.Link)
+	}
+	if e.Duration <= 0 {
+		return xerrors.Errorf("duration must be positive")
+	}
+	if len(e.Toxics) == 0 {
+		return xerrors.Errorf("at least one toxic is required")
+	}
+	for i, t := range e.Toxics {
+		err := t.validate()
+		if err != nil {
+			return xerrors.Errorf("toxic %d (%s): %w", i, t.Type, err)
+		}
+	}
+
+	if len(e.SteadyState.Probes) == 0 {
+		return xerrors.Errorf("steady state requires at least one probe")
+	}
+	if e.SteadyState.Interval < 0 || e.SteadyState.Recovery < 0 {
+		return xerrors.Errorf("steady state interval and recovery must not be negative")
+	}
+	names := make(map[string]struct{}, len(e.SteadyState.Probes))
+	for i, p := range e.SteadyState.Probes {
+		err := p.validate()
+		if err != nil {
+			return xerrors.Errorf("probe %d (%s): %w", i, p.Name, err)
+		}
+		if _, exists := names[p.Name]; exists {
+			return xerrors.Errorf("probe %d (%s): name is not unique", i, p.Name)
+		}
+		names[p.Name] = struct{}{}
+	}
+	return nil
+}
+
+func (t Toxic) validate() error {
+	switch t.Type {
+	case ToxicLatency:
+		if t.Latency <= 0 {
+			return xerrors.Errorf("latency must be positive")
+		}
+		if t.Jitter < 0 {
+			return xerrors.Errorf("jitter must not be negative")
+		}
+	case ToxicBandwidth:
+		if t.Rate < 0 {
+			return xerrors.Errorf("rate must not be negative")
+		}
+	case ToxicResetPeer, ToxicTimeout:
+		if t.Timeout < 0 {
+			return xerrors.Errorf("timeout must not be negative")
+		}
+	default:
+		return xerrors.Errorf("unknown toxic type")
+	}
+
+	switch t.Stream {
+	case "", StreamUpstream, StreamDownstream:
+	default:
+		return xerrors.Errorf("unknown stream %q", t.Stream)
+	}
+	if t.Toxicity != nil && (*t.Toxicity < 0 || *t.Toxicity > 1) {
+		return xerrors.Errorf("toxicity must be between 0 and 1")
+	}
+	return nil
+}
+
+func (p ProbeSpec) validate() error {
+	if p.Name == "" {
+		return xerrors.Errorf("name is required")
+	}
+	switch {
+	case p.PromQL != nil && p.GRPCHealth != nil:
+		return xerrors.Errorf("only one of promql or grpcHealth may be set")
+	case p.PromQL != nil:
+		return p.PromQL.validate()
+	case p.GRPCHealth != nil:
+		return p.GRPCHealth.validate()
+	}
+	return xerrors.Errorf("one of promql or grpcHealth is required")
+}
+
+// Duration is a time.Duration which unmarshals from strings like "30s"
+type Duration time.Duration
+
+// UnmarshalJSON parses the duration
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON produces the duration as string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// String produces the duration as string
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package experiment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadExamples(t *testing.T) {
+	fns, err := filepath.Glob("../../experiments/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fns) == 0 {
+		t.Fatal("found no example experiments")
+	}
+	for _, fn := range fns {
+		t.Run(filepath.Base(fn), func(t *testing.T) {
+			_, err := Load(fn)
+			if err != nil {
+				t.Errorf("cannot load example: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	max := 1.0
+	valid := func() Experiment {
+		return Experiment{
+			Name:     "test",
+			Link:     "server-to-ws-manager",
+			Duration: Duration(time.Minute),
+			Toxics:   []Toxic{{Type: ToxicLatency, Latency: Duration(time.Second)}},
+			SteadyState: SteadyState{
+				Probes: []ProbeSpec{{Name: "p", PromQL: &PromQLProbe{URL: "http://localhost:9090", Query: "up", Max: &max}}},
+			},
+		}
+	}
+
+	tests := []struct {
+		Name        string
+		Modify      func(e *Experiment)
+		Expectation string
+	}{
+		{Name: "valid", Modify: func(e *Experiment) {}},
+		{Name: "unknown link", Modify: func(e *Experiment) { e.Link = "foo" }, Expectation: `unknown link "foo"`},
+		{Name: "no duration", Modify: func(e *Experiment) { e.Duration = 0 }, Expectation: "duration must be positive"},
+		{Name: "no toxics", Modify: func(e *Experiment) { e.Toxics = nil }, Expectation: "at least one toxic is required"},
+		{
+			Name:        "unknown toxic",
+			Modify:      func(e *Experiment) { e.Toxics[0].Type = "slicer" },
+			Expectation: "toxic 0 (slicer): unknown toxic type",
+		},
+		{
+			Name:        "latency without latency",
+			Modify:      func(e *Experiment) { e.Toxics[0].Latency = 0 },
+			Expectation: "toxic 0 (latency): latency must be positive",
+		},
+		{
+			Name: "invalid toxicity",
+			Modify: func(e *Experiment) {
+				tx := float32(1.5)
+				e.Toxics[0].Toxicity = &tx
+			},
+			Expectation: "toxic 0 (latency): toxicity must be between 0 and 1",
+		},
+		{
+			Name:        "unknown stream",
+			Modify:      func(e *Experiment) { e.Toxics[0].Stream = "sideways" },
+			Expectation: `toxic 0 (latency): unknown stream "sideways"`,
+		},
+		{Name: "no probes", Modify: func(e *Experiment) { e.SteadyState.Probes = nil }, Expectation: "steady state requires at least one probe"},
+		{
+			Name:        "probe without kind",
+			Modify:      func(e *Experiment) { e.SteadyState.Probes[0].PromQL = nil },
+			Expectation: "probe 0 (p): one of promql or grpcHealth is required",
+		},
+		{
+			Name:        "probe with two kinds",
+			Modify:      func(e *Experiment) { e.SteadyState.Probes[0].GRPCHealth = &GRPCHealthProbe{Address: "localhost:8080"} },
+			Expectation: "probe 0 (p): only one of promql or grpcHealth may be set",
+		},
+		{
+			Name:        "promql without bounds",
+			Modify:      func(e *Experiment) { e.SteadyState.Probes[0].PromQL.Max = nil },
+			Expectation: "probe 0 (p): promql probe requires min or max",
+		},
+		{
+			Name: "duplicate probe",
+			Modify: func(e *Experiment) {
+				e.SteadyState.Probes = append(e.SteadyState.Probes, ProbeSpec{Name: "p", GRPCHealth: &GRPCHealthProbe{Address: "localhost:8080"}})
+			},
+			Expectation: "probe 1 (p): name is not unique",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			exp := valid()
+			test.Modify(&exp)
+
+			var act string
+			if err := exp.Validate(); err != nil {
+				act = err.Error()
+			}
+			if act != test.Expectation {
+				t.Errorf("unexpected error: want %q, got %q", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestPromQLProbe(t *testing.T) {
+	min, max := 1.0, 10.0
+	tests := []struct {
+		Name        string
+		Response    string
+		Expectation string
+	}{
+		{
+			Name:     "vector within bounds",
+			Response: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1650000000,"1"]},{"metric":{"job":"b"},"value":[1650000000,"10"]}]}}`,
+		},
+		{
+			Name:     "scalar within bounds",
+			Response: `{"status":"success","data":{"resultType":"scalar","result":[1650000000,"5"]}}`,
+		},
+		{
+			Name:        "above max",
+			Response:    `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up","job":"a"},"value":[1650000000,"11"]}]}}`,
+			Expectation: `up{job="a"} is 11, above 10`,
+		},
+		{
+			Name:        "below min",
+			Response:    `{"status":"success","data":{"resultType":"scalar","result":[1650000000,"0.5"]}}`,
+			Expectation: "scalar is 0.5, below 1",
+		},
+		{
+			Name:        "no samples",
+			Response:    `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			Expectation: "query returned no samples",
+		},
+		{
+			Name:        "range query",
+			Response:    `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			Expectation: `unsupported result type "matrix" - the query must produce a vector or scalar`,
+		},
+		{
+			Name:        "query error",
+			Response:    `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			Expectation: "query failed: parse error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/query" || r.URL.Query().Get("query") != "up" {
+					http.Error(w, "unexpected request", http.StatusBadRequest)
+					return
+				}
+				_, _ = w.Write([]byte(test.Response))
+			}))
+			defer srv.Close()
+
+			p := &promQLProbe{name: "test", spec: PromQLProbe{URL: srv.URL + "/", Query: "up", Min: &min, Max: &max}}
+			var act string
+			if err := p.Check(context.Background()); err != nil {
+				act = err.Error()
+			}
+			if !strings.HasPrefix(act, test.Expectation) || (test.Expectation == "" && act != "") {
+				t.Errorf("unexpected error: want %q, got %q", test.Expectation, act)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	toxiproxy "github.com/Shopify/toxiproxy/client"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/gitpod-io/gitpod/blowtorch/pkg/dart"
+)
+
+// Link is a connection between two components which experiments tamper with
+type Link interface {
+	// Inject routes the link through toxiproxy and returns the proxies which carry its traffic
+	Inject() ([]Proxy, error)
+
+	// Revert restores the link. Revert must succeed if the link was not injected or only partially.
+	Revert() error
+}
+
+// Proxy carries the traffic of a link, e.g. a *toxiproxy.Proxy
+type Proxy interface {
+	AddToxic(name, typeName, stream string, toxicity float32, attrs toxiproxy.Attributes) (*toxiproxy.Toxic, error)
+	RemoveToxic(name string) error
+}
+
+// Links are the links experiments can target
+var Links = map[string]func(cfg *rest.Config, namespace string) Link{
+	// server and ws-manager-bridge connect to ws-manager through its service
+	"server-to-ws-manager": func(cfg *rest.Config, namespace string) Link {
+		return &serviceLink{
+			Config:    cfg,
+			Namespace: namespace,
+			Service:   "ws-manager",
+			Clients:   []string{"server", "ws-manager-bridge"},
+		}
+	},
+	// ws-manager connects to the ws-daemon pod on the node of a workspace directly, bypassing the service
+	"ws-manager-to-ws-daemon": func(cfg *rest.Config, namespace string) Link {
+		return &wsdaemonLink{
+			Config:    cfg,
+			Namespace: namespace,
+		}
+	},
+}
+
+// serviceLink replaces a service with a toxiproxy and restarts the clients of the service, so that they reconnect
+type serviceLink struct {
+	Config    *rest.Config
+	Namespace string
+	Service   string
+	Clients   []string
+
+	tpc *dart.ProxiedToxiproxy
+}
+
+func (l *serviceLink) Inject() ([]Proxy, error) {
+	tpc, err := dart.Inject(l.Config, l.Namespace, l.Service)
+	if err != nil {
+		return nil, err
+	}
+	l.tpc = tpc
+
+	err = l.restartClients()
+	if err != nil {
+		return nil, err
+	}
+
+	proxies, err := tpc.Proxies()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Proxy, 0, len(proxies))
+	for _, p := range proxies {
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+func (l *serviceLink) Revert() error {
+	if l.tpc != nil {
+		l.tpc.Close()
+		l.tpc = nil
+	}
+
+	err := dart.Remove(l.Config, l.Namespace, l.Service)
+	if apierrors.IsNotFound(err) {
+		log.WithField("service", l.Service).Info("service is not proxied - nothing to remove")
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return l.restartClients()
+}
+
+func (l *serviceLink) restartClients() error {
+	for _, c := range l.Clients {
+		err := dart.RestartDeployment(l.Config, l.Namespace, c)
+		if err != nil {
+			return xerrors.Errorf("cannot restart %s: %w", c, err)
+		}
+	}
+	return nil
+}
+
+const (
+	wsdaemonDaemonSet   = "ws-daemon"
+	wsdaemonPort        = 8080
+	wsdaemonProxyPort   = 18080
+	wsmanagerDeployment = "ws-manager"
+	wsmanagerConfigMap  = "ws-manager"
+	wsmanagerConfigKey  = "config.json"
+
+	// wsdaemonNetworkPolicy admits ws-manager to ws-daemon, but only on wsdaemonPort
+	wsdaemonNetworkPolicy = "ws-daemon"
+	// proxyNetworkPolicy temporarily admits the same clients to the toxiproxy sidecar
+	proxyNetworkPolicy = "ws-daemon-toxiproxy"
+
+	// originalConfigAnnotation keeps the original ws-manager config, so that we can restore it verbatim
+	originalConfigAnnotation = "blowtorch.sh/original-config"
+)
+
+// wsdaemonLink adds a toxiproxy sidecar to all ws-daemon pods, admits traffic to its port and points ws-manager to it
+type wsdaemonLink struct {
+	Config    *rest.Config
+	Namespace string
+
+	tpcs []*dart.ProxiedToxiproxy
+}
+
+func (l *wsdaemonLink) Inject() ([]Proxy, error) {
+	tpcs, err := dart.InjectSidecar(l.Config, l.Namespace, wsdaemonDaemonSet, wsdaemonProxyPort, wsdaemonPort)
+	l.tpcs = tpcs
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.allowProxyIngress()
+	if err != nil {
+		return nil, err
+	}
+	err = l.setWSManagerDaemonPort(wsdaemonProxyPort)
+	if err != nil {
+		return nil, err
+	}
+	err = dart.RestartDeployment(l.Config, l.Namespace, wsmanagerDeployment)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Proxy
+	for _, tpc := range tpcs {
+		p, err := tpc.Proxy(wsdaemonDaemonSet)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+func (l *wsdaemonLink) Revert() error {
+	for _, tpc := range l.tpcs {
+		tpc.Close()
+	}
+	l.tpcs = nil
+
+	// ws-manager must not talk to the sidecar anymore before we remove it
+	restored, err := l.restoreWSManagerConfig()
+	if err != nil {
+		return err
+	}
+	if restored {
+		err = dart.RestartDeployment(l.Config, l.Namespace, wsmanagerDeployment)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = dart.RemoveSidecar(l.Config, l.Namespace, wsdaemonDaemonSet)
+	if err != nil {
+		return err
+	}
+	return l.removeProxyIngress()
+}
+
+// allowProxyIngress admits the clients ws-daemon's network policy admits on wsdaemonPort to wsdaemonProxyPort, too.
+// Network policies are additive, hence we add our own instead of changing the one the installer manages.
+func (l *wsdaemonLink) allowProxyIngress() error {
+	client, err := kubernetes.NewForConfig(l.Config)
+	if err != nil {
+		return err
+	}
+	policies := client.NetworkingV1().NetworkPolicies(l.Namespace)
+	np, err := policies.Get(context.Background(), wsdaemonNetworkPolicy, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		log.WithField("name", wsdaemonNetworkPolicy).Info("no network policy - nothing to admit")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var (
+		tcp  = corev1.ProtocolTCP
+		from []networkingv1.NetworkPolicyPeer
+	)
+	for _, rule := range np.Spec.Ingress {
+		for _, p := range rule.Ports {
+			if p.Port != nil && p.Port.IntValue() == wsdaemonPort {
+				from = append(from, rule.From...)
+				break
+			}
+		}
+	}
+	proxy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      proxyNetworkPolicy,
+			Namespace: l.Namespace,
+			Labels:    np.Labels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: np.Spec.PodSelector,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{
+						{
+							Protocol: &tcp,
+							Port:     &intstr.IntOrString{IntVal: wsdaemonProxyPort},
+						},
+					},
+					From: from,
+				},
+			},
+		},
+	}
+	_, err = policies.Create(context.Background(), proxy, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// a previous experiment was not reverted
+		err = nil
+	}
+	if err != nil {
+		return xerrors.Errorf("cannot admit traffic to toxiproxy: %w", err)
+	}
+	log.WithField("port", wsdaemonProxyPort).Infof("network policy admits traffic to the toxiproxy of %s", wsdaemonDaemonSet)
+	return nil
+}
+
+// removeProxyIngress removes the network policy added by allowProxyIngress
+func (l *wsdaemonLink) removeProxyIngress() error {
+	client, err := kubernetes.NewForConfig(l.Config)
+	if err != nil {
+		return err
+	}
+	err = client.NetworkingV1().NetworkPolicies(l.Namespace).Delete(context.Background(), proxyNetworkPolicy, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.WithField("name", proxyNetworkPolicy).Info("network policy removed")
+	return nil
+}
+
+// setWSManagerDaemonPort changes the port ws-manager connects to ws-daemon on and keeps the original config
+func (l *wsdaemonLink) setWSManagerDaemonPort(port int) error {
+	client, err := kubernetes.NewForConfig(l.Config)
+	if err != nil {
+		return err
+	}
+	cm, err := client.CoreV1().ConfigMaps(l.Namespace).Get(context.Background(), wsmanagerConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	original := cm.Data[wsmanagerConfigKey]
+	if o, ok := cm.Annotations[originalConfigAnnotation]; ok {
+		// a previous experiment was not reverted - keep its original config
+		original = o
+	}
+	// numbers must survive the round trip unchanged
+	var cfg map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(original))
+	dec.UseNumber()
+	err = dec.Decode(&cfg)
+	if err != nil {
+		return xerrors.Errorf("cannot unmarshal ws-manager config: %w", err)
+	}
+	manager, ok := cfg["manager"].(map[string]interface{})
+	if !ok {
+		return xerrors.Errorf("ws-manager config has no manager section")
+	}
+	wsdaemon, ok := manager["wsdaemon"].(map[string]interface{})
+	if !ok {
+		return xerrors.Errorf("ws-manager config has no wsdaemon section")
+	}
+	wsdaemon["port"] = port
+	fc, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+	cm.Annotations[originalConfigAnnotation] = original
+	cm.Data[wsmanagerConfigKey] = string(fc)
+	_, err = client.CoreV1().ConfigMaps(l.Namespace).Update(context.Background(), cm, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	log.WithField("port", port).Infof("ws-manager connects to %s through toxiproxy", wsdaemonDaemonSet)
+	return nil
+}
+
+// restoreWSManagerConfig restores the original ws-manager config if it was changed
+func (l *wsdaemonLink) restoreWSManagerConfig() (restored bool, err error) {
+	client, err := kubernetes.NewForConfig(l.Config)
+	if err != nil {
+		return false, err
+	}
+	cm, err := client.CoreV1().ConfigMaps(l.Namespace).Get(context.Background(), wsmanagerConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	original, ok := cm.Annotations[originalConfigAnnotation]
+	if !ok {
+		return false, nil
+	}
+
+	cm.Data[wsmanagerConfigKey] = original
+	delete(cm.Annotations, originalConfigAnnotation)
+	_, err = client.CoreV1().ConfigMaps(l.Namespace).Update(context.Background(), cm, metav1.UpdateOptions{})
+	if err != nil {
+		return false, err
+	}
+	log.Info("ws-manager config restored")
+	return true, nil
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package experiment
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Probe checks whether a part of the steady state holds
+type Probe interface {
+	Name() string
+
+	// Check returns an error if the steady state does not hold
+	Check(ctx context.Context) error
+}
+
+// Probes produces the probes of the experiment's steady state
+func (e *Experiment) Probes() []Probe {
+	res := make([]Probe, 0, len(e.SteadyState.Probes))
+	for _, p := range e.SteadyState.Probes {
+		switch {
+		case p.PromQL != nil:
+			res = append(res, &promQLProbe{name: p.Name, spec: *p.PromQL})
+		case p.GRPCHealth != nil:
+			res = append(res, &grpcHealthProbe{name: p.Name, spec: *p.GRPCHealth})
+		}
+	}
+	return res
+}
+
+// PromQLProbe asserts that all samples of a PromQL query are within bounds.
+// Queries which return no samples fail - use "or vector(0)" for metrics which may not exist yet.
+type PromQLProbe struct {
+	// URL is the base URL of the Prometheus API, e.g. http://localhost:9090
+	URL   string `json:"url"`
+	Query string `json:"query"`
+
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Timeout defaults to 5s
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+func (p *PromQLProbe) validate() error {
+	if p.URL == "" || p.Query == "" {
+		return xerrors.Errorf("promql probe requires url and query")
+	}
+	if _, err := url.Parse(p.URL); err != nil {
+		return xerrors.Errorf("invalid url: %w", err)
+	}
+	if p.Min == nil && p.Max == nil {
+		return xerrors.Errorf("promql probe requires min or max")
+	}
+	if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+		return xerrors.Errorf("min must not be greater than max")
+	}
+	return nil
+}
+
+type promQLProbe struct {
+	name string
+	spec PromQLProbe
+}
+
+func (p *promQLProbe) Name() string { return p.name }
+
+func (p *promQLProbe) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, timeoutOrDefault(p.spec.Timeout))
+	defer cancel()
+
+	u := strings.TrimSuffix(p.spec.URL, "/") + "/api/v1/query?" + url.Values{"query": []string{p.spec.Query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("cannot query Prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res promQueryResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return xerrors.Errorf("cannot decode Prometheus response (%s): %w", resp.Status, err)
+	}
+	if res.Status != "success" {
+		return xerrors.Errorf("query failed: %s", res.Error)
+	}
+	samples, err := res.Data.samples()
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return xerrors.Errorf("query returned no samples")
+	}
+
+	for _, s := range samples {
+		if p.spec.Min != nil && s.Value < *p.spec.Min {
+			return xerrors.Errorf("%s is %v, below %v", s.Metric, s.Value, *p.spec.Min)
+		}
+		if p.spec.Max != nil && s.Value > *p.spec.Max {
+			return xerrors.Errorf("%s is %v, above %v", s.Metric, s.Value, *p.spec.Max)
+		}
+	}
+	return nil
+}
+
+type promQueryResponse struct {
+	Status string        `json:"status"`
+	Error  string        `json:"error"`
+	Data   promQueryData `json:"data"`
+}
+
+type promQueryData struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+type promSample struct {
+	Metric string
+	Value  float64
+}
+
+// samples decodes the result of an instant query, which is either a vector or a scalar
+func (d promQueryData) samples() ([]promSample, error) {
+	// values are [<unix time>, "<value>"]
+	parseValue := func(v []interface{}) (float64, error) {
+		if len(v) != 2 {
+			return 0, xerrors.Errorf("invalid sample %v", v)
+		}
+		s, ok := v[1].(string)
+		if !ok {
+			return 0, xerrors.Errorf("invalid sample value %v", v[1])
+		}
+		return strconv.ParseFloat(s, 64)
+	}
+
+	switch d.ResultType {
+	case "scalar":
+		var v []interface{}
+		err := json.Unmarshal(d.Result, &v)
+		if err != nil {
+			return nil, err
+		}
+		val, err := parseValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return []promSample{{Metric: "scalar", Value: val}}, nil
+	case "vector":
+		var vs []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		}
+		err := json.Unmarshal(d.Result, &vs)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]promSample, 0, len(vs))
+		for _, v := range vs {
+			val, err := parseValue(v.Value)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, promSample{Metric: formatMetric(v.Metric), Value: val})
+		}
+		return res, nil
+	}
+	return nil, xerrors.Errorf("unsupported result type %q - the query must produce a vector or scalar", d.ResultType)
+}
+
+func formatMetric(labels map[string]string) string {
+	name := labels["__name__"]
+	var lbls []string
+	for k, v := range labels {
+		if k == "__name__" {
+			continue
+		}
+		lbls = append(lbls, fmt.Sprintf("%s=%q", k, v))
+	}
+	sort.Strings(lbls)
+	return name + "{" + strings.Join(lbls, ",") + "}"
+}
+
+// GRPCHealthProbe asserts that a gRPC service reports SERVING using the gRPC health checking protocol
+type GRPCHealthProbe struct {
+	// Address is the host:port of the server, e.g. of a kubectl port-forward
+	Address string `json:"address"`
+	// Service is the service to check. If empty, the health of the server is checked.
+	Service string `json:"service,omitempty"`
+	// TLS configures mutual TLS. If nil, the connection is insecure.
+	TLS *ProbeTLS `json:"tls,omitempty"`
+	// Timeout defaults to 5s
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// ProbeTLS configures mutual TLS for a probe
+type ProbeTLS struct {
+	// Path contains ca.crt, tls.crt and tls.key, like the TLS secrets of our components
+	Path       string `json:"path"`
+	ServerName string `json:"serverName"`
+}
+
+func (p *GRPCHealthProbe) validate() error {
+	if p.Address == "" {
+		return xerrors.Errorf("grpcHealth probe requires address")
+	}
+	if p.TLS != nil && p.TLS.Path == "" {
+		return xerrors.Errorf("tls requires path")
+	}
+	return nil
+}
+
+type grpcHealthProbe struct {
+	name string
+	spec GRPCHealthProbe
+}
+
+func (p *grpcHealthProbe) Name() string { return p.name }
+
+func (p *grpcHealthProbe) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, timeoutOrDefault(p.spec.Timeout))
+	defer cancel()
+
+	creds := insecure.NewCredentials()
+	if p.spec.TLS != nil {
+		var err error
+		creds, err = loadTLSCredentials(*p.spec.TLS)
+		if err != nil {
+			return err
+		}
+	}
+	conn, err := grpc.DialContext(ctx, p.spec.Address, grpc.WithTransportCredentials(creds), grpc.WithBlock())
+	if err != nil {
+		return xerrors.Errorf("cannot connect to %s: %w", p.spec.Address, err)
+	}
+	defer conn.Close()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: p.spec.Service})
+	if err != nil {
+		return xerrors.Errorf("health check failed: %w", err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return xerrors.Errorf("service is %s", resp.Status)
+	}
+	return nil
+}
+
+func loadTLSCredentials(cfg ProbeTLS) (credentials.TransportCredentials, error) {
+	ca, err := os.ReadFile(filepath.Join(cfg.Path, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	capool := x509.NewCertPool()
+	capool.AppendCertsFromPEM(ca)
+	cert, err := tls.LoadX509KeyPair(filepath.Join(cfg.Path, "tls.crt"), filepath.Join(cfg.Path, "tls.key"))
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      capool,
+		ServerName:   cfg.ServerName,
+	}), nil
+}
+
+func timeoutOrDefault(d Duration) time.Duration {
+	if d <= 0 {
+		return defaultTimeout
+	}
+	return time.Duration(d)
+}
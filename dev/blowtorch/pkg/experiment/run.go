@@ -0,0 +1,188 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// Phase is the part of an experiment a steady state check belongs to
+type Phase string
+
+const (
+	// PhaseBefore checks happen before the link is tampered with
+	PhaseBefore Phase = "before"
+	// PhaseDuring checks happen while the toxics are active
+	PhaseDuring Phase = "during"
+	// PhaseRecovery checks happen after the link was reverted
+	PhaseRecovery Phase = "recovery"
+)
+
+// Result is the outcome of an experiment
+type Result struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Aborted is true if the toxics were not active for the full duration of the experiment
+	Aborted bool   `json:"aborted"`
+	Reason  string `json:"reason,omitempty"`
+	// Recovered is set if the experiment checks the recovery of the steady state
+	Recovered *bool `json:"recovered,omitempty"`
+
+	Checks []Check `json:"checks"`
+}
+
+// Check is a single probe of the steady state
+type Check struct {
+	Time  time.Time `json:"time"`
+	Phase Phase     `json:"phase"`
+	Probe string    `json:"probe"`
+	Error string    `json:"error,omitempty"`
+}
+
+// Run runs an experiment:
+//  1. it verifies that the steady state holds before tampering with anything,
+//  2. injects toxiproxy into the link and adds the toxics,
+//  3. verifies the steady state every interval until the duration is over,
+//  4. and reverts the link - also if the steady state does not hold anymore or ctx is canceled.
+//
+// A steady state which does not hold is reported in the result. Run returns an error if it cannot
+// tamper with the link or revert it.
+func Run(ctx context.Context, exp *Experiment, link Link, probes []Probe) (res *Result, err error) {
+	res = &Result{
+		Name:  exp.Name,
+		Start: time.Now(),
+	}
+	defer func() {
+		res.End = time.Now()
+	}()
+
+	if !res.check(ctx, PhaseBefore, probes) {
+		res.Aborted = true
+		res.Reason = "steady state does not hold before the experiment"
+		return res, nil
+	}
+
+	defer func() {
+		log.WithField("link", exp.Link).Info("reverting link")
+		rerr := link.Revert()
+		if rerr != nil {
+			rerr = xerrors.Errorf("cannot revert link %s - revert it manually: %w", exp.Link, rerr)
+			if err == nil {
+				err = rerr
+			} else {
+				log.WithError(rerr).Error("cannot revert link")
+			}
+			return
+		}
+		if err != nil || exp.SteadyState.Recovery == 0 {
+			return
+		}
+
+		recovered := res.waitForRecovery(probes, time.Duration(exp.SteadyState.Recovery), exp.SteadyState.interval())
+		res.Recovered = &recovered
+	}()
+
+	log.WithField("link", exp.Link).Info("injecting toxiproxy")
+	proxies, err := link.Inject()
+	if err != nil {
+		return res, xerrors.Errorf("cannot inject toxiproxy into link %s: %w", exp.Link, err)
+	}
+	var names []string
+	defer func() {
+		// removing toxics is quick, unlike reverting the link, and ends the chaos right away
+		for _, p := range proxies {
+			for _, n := range names {
+				rerr := p.RemoveToxic(n)
+				if rerr != nil {
+					log.WithError(rerr).WithField("toxic", n).Warn("cannot remove toxic")
+				}
+			}
+		}
+	}()
+	for i, t := range exp.Toxics {
+		name := fmt.Sprintf("blowtorch-%d-%s", i, t.Type)
+		for _, p := range proxies {
+			_, err = p.AddToxic(name, string(t.Type), t.stream(), t.toxicity(), t.Attributes())
+			if err != nil {
+				return res, xerrors.Errorf("cannot add toxic %s: %w", name, err)
+			}
+		}
+		names = append(names, name)
+		log.WithField("toxic", name).WithField("attributes", t.Attributes()).WithField("proxies", len(proxies)).Info("toxic added")
+	}
+
+	log.WithField("duration", exp.Duration).Info("experiment running")
+	var (
+		done   = time.After(time.Duration(exp.Duration))
+		ticker = time.NewTicker(exp.SteadyState.interval())
+	)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			log.Info("experiment done")
+			return res, nil
+		case <-ctx.Done():
+			res.Aborted = true
+			res.Reason = "experiment was canceled"
+			return res, nil
+		case <-ticker.C:
+			if !res.check(ctx, PhaseDuring, probes) {
+				res.Aborted = true
+				res.Reason = "steady state does not hold anymore"
+				return res, nil
+			}
+		}
+	}
+}
+
+// check runs all probes and records their results. Returns true if the steady state holds.
+func (res *Result) check(ctx context.Context, phase Phase, probes []Probe) (ok bool) {
+	ok = true
+	for _, p := range probes {
+		c := Check{
+			Time:  time.Now(),
+			Phase: phase,
+			Probe: p.Name(),
+		}
+		err := p.Check(ctx)
+		if err != nil {
+			c.Error = err.Error()
+			ok = false
+			log.WithError(err).WithField("probe", p.Name()).WithField("phase", phase).Warn("steady state does not hold")
+		}
+		res.Checks = append(res.Checks, c)
+	}
+	return ok
+}
+
+// waitForRecovery checks the steady state every interval until it holds or the timeout is over
+func (res *Result) waitForRecovery(probes []Probe, timeout, interval time.Duration) bool {
+	// the experiment's context may be canceled already, but recovery is worth knowing nonetheless
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if res.check(ctx, PhaseRecovery, probes) {
+			log.Info("steady state recovered")
+			return true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
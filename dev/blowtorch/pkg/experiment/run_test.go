@@ -0,0 +1,237 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	toxiproxy "github.com/Shopify/toxiproxy/client"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeLink struct {
+	mu        sync.Mutex
+	Events    []string
+	InjectErr error
+	Proxies   int
+}
+
+func (l *fakeLink) record(evt string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Events = append(l.Events, evt)
+}
+
+func (l *fakeLink) Inject() ([]Proxy, error) {
+	l.record("inject")
+	if l.InjectErr != nil {
+		return nil, l.InjectErr
+	}
+	var res []Proxy
+	for i := 0; i < l.Proxies; i++ {
+		res = append(res, &fakeProxy{Link: l, Name: fmt.Sprintf("p%d", i)})
+	}
+	return res, nil
+}
+
+func (l *fakeLink) Revert() error {
+	l.record("revert")
+	return nil
+}
+
+type fakeProxy struct {
+	Link *fakeLink
+	Name string
+}
+
+func (p *fakeProxy) AddToxic(name, typeName, stream string, toxicity float32, attrs toxiproxy.Attributes) (*toxiproxy.Toxic, error) {
+	p.Link.record(fmt.Sprintf("%s: add %s %s %v %v", p.Name, name, stream, toxicity, attrs))
+	return &toxiproxy.Toxic{Name: name}, nil
+}
+
+func (p *fakeProxy) RemoveToxic(name string) error {
+	p.Link.record(fmt.Sprintf("%s: remove %s", p.Name, name))
+	return nil
+}
+
+// fakeProbe fails the checks for which Fail returns true
+type fakeProbe struct {
+	Fail  func(n int) bool
+	calls int
+}
+
+func (p *fakeProbe) Name() string { return "fake" }
+
+func (p *fakeProbe) Check(ctx context.Context) error {
+	n := p.calls
+	p.calls++
+	if p.Fail != nil && p.Fail(n) {
+		return fmt.Errorf("check %d failed", n)
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	var (
+		toxicity = float32(0.5)
+		injected = []string{
+			"inject",
+			"p0: add blowtorch-0-latency downstream 1 map[jitter:0 latency:100]",
+			"p1: add blowtorch-0-latency downstream 1 map[jitter:0 latency:100]",
+			"p0: add blowtorch-1-reset_peer upstream 0.5 map[timeout:0]",
+			"p1: add blowtorch-1-reset_peer upstream 0.5 map[timeout:0]",
+		}
+		removed = []string{
+			"p0: remove blowtorch-0-latency",
+			"p0: remove blowtorch-1-reset_peer",
+			"p1: remove blowtorch-0-latency",
+			"p1: remove blowtorch-1-reset_peer",
+			"revert",
+		}
+		recovered    = true
+		notRecovered = false
+	)
+
+	type Expectation struct {
+		Events    []string
+		Aborted   bool
+		Reason    string
+		Recovered *bool
+		Error     string
+	}
+	tests := []struct {
+		Name        string
+		Duration    time.Duration
+		Interval    time.Duration
+		Recovery    time.Duration
+		InjectErr   error
+		Fail        func(n int) bool
+		Cancel      bool
+		Expectation Expectation
+	}{
+		{
+			Name:     "steady state holds",
+			Duration: 50 * time.Millisecond,
+			Expectation: Expectation{
+				Events: append(append([]string{}, injected...), removed...),
+			},
+		},
+		{
+			Name:     "steady state does not hold before",
+			Duration: 50 * time.Millisecond,
+			Fail:     func(n int) bool { return n == 0 },
+			Expectation: Expectation{
+				Aborted: true,
+				Reason:  "steady state does not hold before the experiment",
+			},
+		},
+		{
+			Name:     "steady state breaks",
+			Duration: time.Hour,
+			Fail:     func(n int) bool { return n == 2 },
+			Expectation: Expectation{
+				Events:  append(append([]string{}, injected...), removed...),
+				Aborted: true,
+				Reason:  "steady state does not hold anymore",
+			},
+		},
+		{
+			Name:     "canceled",
+			Duration: time.Hour,
+			Cancel:   true,
+			Expectation: Expectation{
+				Events:  append(append([]string{}, injected...), removed...),
+				Aborted: true,
+				Reason:  "experiment was canceled",
+			},
+		},
+		{
+			Name:      "inject fails",
+			Duration:  50 * time.Millisecond,
+			InjectErr: errors.New("no such service"),
+			Expectation: Expectation{
+				Events: []string{"inject", "revert"},
+				Error:  "cannot inject toxiproxy into link server-to-ws-manager: no such service",
+			},
+		},
+		{
+			Name:     "recovers",
+			Duration: time.Hour,
+			Recovery: time.Second,
+			// breaks during the experiment and holds again on the second recovery check
+			Fail: func(n int) bool { return n == 1 || n == 2 },
+			Expectation: Expectation{
+				Events:    append(append([]string{}, injected...), removed...),
+				Aborted:   true,
+				Reason:    "steady state does not hold anymore",
+				Recovered: &recovered,
+			},
+		},
+		{
+			Name: "does not recover",
+			// the experiment ends before the first check during it
+			Duration: 20 * time.Millisecond,
+			Interval: 50 * time.Millisecond,
+			Recovery: 100 * time.Millisecond,
+			Fail:     func(n int) bool { return n > 0 },
+			Expectation: Expectation{
+				Events:    append(append([]string{}, injected...), removed...),
+				Recovered: &notRecovered,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			interval := test.Interval
+			if interval == 0 {
+				interval = 10 * time.Millisecond
+			}
+			exp := &Experiment{
+				Name:     "test",
+				Link:     "server-to-ws-manager",
+				Duration: Duration(test.Duration),
+				Toxics: []Toxic{
+					{Type: ToxicLatency, Latency: Duration(100 * time.Millisecond)},
+					{Type: ToxicResetPeer, Stream: StreamUpstream, Toxicity: &toxicity},
+				},
+				SteadyState: SteadyState{
+					Interval: Duration(interval),
+					Recovery: Duration(test.Recovery),
+				},
+			}
+			link := &fakeLink{InjectErr: test.InjectErr, Proxies: 2}
+			probe := &fakeProbe{Fail: test.Fail}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.Cancel {
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+
+			res, err := Run(ctx, exp, link, []Probe{probe})
+
+			var act Expectation
+			act.Events = link.Events
+			if err != nil {
+				act.Error = err.Error()
+			}
+			act.Aborted = res.Aborted
+			act.Reason = res.Reason
+			act.Recovered = res.Recovered
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+			if res.End.Before(res.Start) {
+				t.Errorf("result ends before it starts")
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	builder "github.com/gitpod-io/gitpod/image-builder/api"
+)
+
+var imagebuildsCancelCmd = &cobra.Command{
+	Use:   "cancel <build-id>",
+	Short: "Cancels an ongoing build",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		conn, client, err := getImagebuildsClient(ctx)
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect")
+		}
+		defer conn.Close()
+
+		_, err = client.CancelBuild(ctx, &builder.CancelBuildRequest{
+			BuildId: args[0],
+		})
+		if err != nil {
+			log.WithError(err).Fatal("cannot cancel build")
+		}
+		log.WithField("buildID", args[0]).Info("build cancelled")
+	},
+}
+
+func init() {
+	imagebuildsCmd.AddCommand(imagebuildsCancelCmd)
+}
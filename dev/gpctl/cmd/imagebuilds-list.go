@@ -34,9 +34,9 @@ var imagebuildsListCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		tpl := `REF	STATUS	STARTED AT
+		tpl := `ID	REF	STATUS	STARTED AT	WAITERS
 {{- range .Builds }}
-{{ .Ref }}	{{ .Status }}	{{ .StartedAt }}
+{{ .BuildId }}	{{ .Ref }}	{{ .Status }}	{{ .StartedAt }}	{{ .Waiters }}
 {{ end }}
 `
 		getOutputFormat(tpl, "{..ref}").Print(resp)
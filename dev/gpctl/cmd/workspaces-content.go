@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/ws-daemon/api"
+)
+
+// workspacesContentCmd represents the content command
+var workspacesContentCmd = &cobra.Command{
+	Use:   "content",
+	Short: "inspects the content of a workspace read-only through ws-daemon",
+	Long: `Inspects the content of a workspace read-only through the ws-daemon on the workspace's node.
+Paths are relative to the workspace content, i.e. /workspace in the workspace.
+Workspaces with full workspace backup or persistent volume claims are not supported.`,
+	Args: cobra.ExactArgs(1),
+}
+
+// workspacesContentLsCmd represents the content ls command
+var workspacesContentLsCmd = &cobra.Command{
+	Use:   "ls <instanceID> [path]",
+	Short: "lists a directory of the workspace content",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		instanceID := args[0]
+		var path string
+		if len(args) > 1 {
+			path = args[1]
+		}
+
+		conn, client, err := getWorkspaceContentClient(ctx, instanceID)
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect")
+		}
+		defer conn.Close()
+
+		resp, err := client.ListWorkspaceFiles(ctx, &api.ListWorkspaceFilesRequest{
+			Id:   instanceID,
+			Path: path,
+		})
+		if err != nil {
+			log.WithError(err).Fatal("error during RPC call")
+		}
+
+		tpl := `MODE	SIZE	MODIFIED	NAME
+{{- range .Files }}
+{{ .Mode }}	{{ .Size }}	{{ .Modified.AsTime.Format "2006-01-02 15:04:05" }}	{{ .Name }}{{ if .SymlinkTarget }} (symlink to {{ .SymlinkTarget }}){{ end -}}
+{{ end }}
+`
+		err = getOutputFormat(tpl, "{..name}").Print(resp)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// workspacesContentGetCmd represents the content get command
+var workspacesContentGetCmd = &cobra.Command{
+	Use:   "get <instanceID> <path>",
+	Short: "downloads a file of the workspace content",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		instanceID := args[0]
+		conn, client, err := getWorkspaceContentClient(ctx, instanceID)
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect")
+		}
+		defer conn.Close()
+
+		out := io.Writer(os.Stdout)
+		if fn, _ := cmd.Flags().GetString("file"); fn != "" {
+			f, err := os.Create(fn)
+			if err != nil {
+				log.WithError(err).Fatal("cannot create output file")
+			}
+			defer f.Close()
+			out = f
+		}
+
+		stream, err := client.ReadWorkspaceFile(ctx, &api.ReadWorkspaceFileRequest{
+			Id:   instanceID,
+			Path: args[1],
+		})
+		if err != nil {
+			log.WithError(err).Fatal("error during RPC call")
+		}
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				log.WithError(err).Fatal("cannot read file")
+			}
+			_, err = out.Write(resp.Data)
+			if err != nil {
+				log.WithError(err).Fatal("cannot write file")
+			}
+		}
+	},
+}
+
+func init() {
+	workspacesContentCmd.AddCommand(workspacesContentLsCmd)
+
+	workspacesContentGetCmd.Flags().String("file", "", "write the file here rather than to stdout")
+	workspacesContentCmd.AddCommand(workspacesContentGetCmd)
+
+	workspacesCmd.AddCommand(workspacesContentCmd)
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+// workspaceEvent is a Kubernetes event or status transition of a workspace
+type workspaceEvent struct {
+	Time time.Time `json:"time"`
+	// Source is one of event, pod, condition, container or ws-manager
+	Source  string `json:"source"`
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+const workspaceEventTpl = `{{ .Time.Format "15:04:05" }}	{{ .Source }}	{{ .Type }}	{{ .Reason }}	{{ .Message }}`
+
+// workspacesEventsCmd represents the events command
+var workspacesEventsCmd = &cobra.Command{
+	Use:   "events <instanceID>",
+	Short: "prints the pod events and status transitions of a workspace in chronological order",
+	Long: `Prints the Kubernetes events of the workspace pod, joined with the transitions of its conditions and containers,
+in chronological order. Events are kept for a limited time by Kubernetes, status transitions for as long as the pod exists.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		instanceID := args[0]
+		cfg, namespace, err := getKubeconfig()
+		if err != nil {
+			log.WithError(err).Fatal("cannot get kubeconfig")
+		}
+		clientSet, err := kubernetes.NewForConfig(cfg)
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect to Kubernetes")
+		}
+
+		evts, err := getWorkspaceEvents(ctx, clientSet, namespace, instanceID)
+		if err != nil {
+			log.WithError(err).Fatal("cannot get events")
+		}
+
+		tpl := `TIME	SOURCE	TYPE	REASON	MESSAGE
+{{- range . }}
+` + workspaceEventTpl + `
+{{- end }}
+`
+		err = getOutputFormat(tpl, "{..reason}").Print(evts)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); !watch {
+			return
+		}
+
+		conn, client, err := getWorkspacesClient(ctx)
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect")
+		}
+		defer conn.Close()
+
+		sub, err := client.Subscribe(ctx, &api.SubscribeRequest{})
+		if err != nil {
+			log.WithError(err).Fatal("error during RPC call")
+		}
+		defer sub.CloseSend()
+
+		var phase api.WorkspacePhase = -1
+		for {
+			resp, err := sub.Recv()
+			if err != nil {
+				log.WithError(err).Error()
+				return
+			}
+			status := resp.GetStatus()
+			if status == nil || status.Id != instanceID || status.Phase == phase {
+				continue
+			}
+			phase = status.Phase
+
+			evt := workspaceEvent{
+				Time:    time.Now(),
+				Source:  "ws-manager",
+				Type:    "Phase",
+				Reason:  status.Phase.String(),
+				Message: status.Message,
+			}
+			if status.Conditions.GetFailed() != "" {
+				evt.Message = status.Conditions.Failed
+			}
+			err = getOutputFormat(workspaceEventTpl, "{.reason}").Print(evt)
+			if err != nil {
+				log.WithError(err).Error()
+				return
+			}
+			fmt.Println()
+
+			if phase == api.WorkspacePhase_STOPPED {
+				return
+			}
+		}
+	},
+}
+
+// getWorkspaceEvents returns the Kubernetes events of the workspace pod joined with its status transitions,
+// sorted by time. If the pod is gone, only the events remain.
+func getWorkspaceEvents(ctx context.Context, clientSet kubernetes.Interface, namespace, instanceID string) ([]workspaceEvent, error) {
+	var (
+		res     []workspaceEvent
+		podName string
+	)
+	// the events of stopped workspaces outlive their pod
+	pod, err := getWorkspacePod(ctx, clientSet, namespace, instanceID)
+	if err == nil {
+		podName = pod.Name
+		res = append(res, podEvents(pod)...)
+	}
+
+	// the pod name depends on the workspace type, hence we need to match the suffix if the pod is gone
+	selector := "involvedObject.kind=Pod"
+	if podName != "" {
+		selector += ",involvedObject.name=" + podName
+	}
+	events, err := clientSet.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events.Items {
+		if podName == "" && !strings.HasSuffix(e.InvolvedObject.Name, "-"+instanceID) {
+			continue
+		}
+
+		t := e.EventTime.Time
+		if !e.LastTimestamp.IsZero() {
+			t = e.LastTimestamp.Time
+		} else if t.IsZero() {
+			t = e.FirstTimestamp.Time
+		}
+		msg := e.Message
+		if e.Count > 1 {
+			msg += fmt.Sprintf(" (x%d)", e.Count)
+		}
+		res = append(res, workspaceEvent{
+			Time:    t,
+			Source:  "event",
+			Type:    e.Type,
+			Reason:  e.Reason,
+			Message: msg,
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Time.Before(res[j].Time) })
+	return res, nil
+}
+
+// podEvents turns the timestamps of a pod's status into events
+func podEvents(pod *corev1.Pod) []workspaceEvent {
+	res := []workspaceEvent{{
+		Time:    pod.CreationTimestamp.Time,
+		Source:  "pod",
+		Type:    "Normal",
+		Reason:  "Created",
+		Message: pod.Name,
+	}}
+	if pod.DeletionTimestamp != nil {
+		res = append(res, workspaceEvent{
+			Time:    pod.DeletionTimestamp.Time,
+			Source:  "pod",
+			Type:    "Normal",
+			Reason:  "Deleting",
+			Message: pod.Name,
+		})
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.LastTransitionTime.IsZero() {
+			continue
+		}
+		tpe := "Normal"
+		if c.Status != corev1.ConditionTrue {
+			tpe = "Warning"
+		}
+		msg := c.Reason
+		if c.Message != "" {
+			msg = strings.TrimSpace(msg + " " + c.Message)
+		}
+		res = append(res, workspaceEvent{
+			Time:    c.LastTransitionTime.Time,
+			Source:  "condition",
+			Type:    tpe,
+			Reason:  fmt.Sprintf("%s=%s", c.Type, c.Status),
+			Message: msg,
+		})
+	}
+
+	containers := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, c := range containers {
+		for _, state := range []corev1.ContainerState{c.LastTerminationState, c.State} {
+			if s := state.Running; s != nil {
+				res = append(res, workspaceEvent{Time: s.StartedAt.Time, Source: "container", Type: "Normal", Reason: "Started", Message: c.Name})
+			}
+			if s := state.Terminated; s != nil {
+				tpe := "Normal"
+				if s.ExitCode != 0 {
+					tpe = "Warning"
+				}
+				msg := fmt.Sprintf("%s exited with %d", c.Name, s.ExitCode)
+				if s.Reason != "" {
+					msg += ": " + s.Reason
+				}
+				if s.Message != "" {
+					msg += " " + s.Message
+				}
+				if !s.StartedAt.IsZero() {
+					res = append(res, workspaceEvent{Time: s.StartedAt.Time, Source: "container", Type: "Normal", Reason: "Started", Message: c.Name})
+				}
+				res = append(res, workspaceEvent{Time: s.FinishedAt.Time, Source: "container", Type: tpe, Reason: "Terminated", Message: msg})
+			}
+		}
+	}
+
+	return res
+}
+
+func init() {
+	workspacesCmd.AddCommand(workspacesEventsCmd)
+
+	workspacesEventsCmd.Flags().BoolP("watch", "w", false, "print the phase transitions reported by ws-manager until the workspace stops")
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	supervisor "github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+// workspacesExecCmd represents the exec command
+var workspacesExecCmd = &cobra.Command{
+	Use:   "exec <instanceID> -- <command> [args...]",
+	Short: "runs a command in a running workspace through the supervisor terminal API",
+	Long: `Runs a command in a running workspace through the supervisor terminal API.
+The command runs in a terminal, hence stdout and stderr are combined. gpctl exits with the exit code of the command.
+The workspace image must provide /bin/sh which starts the command once gpctl listens to its output.`,
+	Example: `  gpctl workspaces exec 2b2e4c8f-5e47-4c8a-9f4b-3b0a5e6e2a11 -- ls -la /workspace`,
+	Args:    cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		instanceID := args[0]
+		conn, err := getSupervisorConn(ctx, instanceID)
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect")
+		}
+		defer conn.Close()
+		client := supervisor.NewTerminalServiceClient(conn)
+
+		// Fast commands would exit before we listen to their output, hence the shell waits for a line
+		// on its stdin, which we write once we listen, before it runs the command. The line isn't echoed.
+		workdir, _ := cmd.Flags().GetString("workdir")
+		term, err := client.Open(ctx, &supervisor.OpenTerminalRequest{
+			Workdir:   workdir,
+			Shell:     "/bin/sh",
+			ShellArgs: append([]string{"-c", `stty -echo 2>/dev/null; read -r _; stty echo 2>/dev/null; exec "$0" "$@"`}, args[1:]...),
+		})
+		if err != nil {
+			log.WithError(err).Fatal("cannot open terminal")
+		}
+		alias := term.Terminal.Alias
+		shutdown := func() {
+			// the terminal is gone already if the command has exited
+			_, _ = client.Shutdown(context.Background(), &supervisor.ShutdownTerminalRequest{Alias: alias})
+		}
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-sigChan
+			shutdown()
+			cancel()
+		}()
+
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		start := func() error {
+			_, err := client.Write(ctx, &supervisor.WriteTerminalRequest{Alias: alias, Stdin: []byte("\n")})
+			if err != nil {
+				return xerrors.Errorf("cannot start command: %w", err)
+			}
+			if interactive {
+				go forwardStdin(ctx, client, alias)
+			}
+			return nil
+		}
+
+		exitCode, err := listenTerminal(ctx, client, alias, os.Stdout, 0, start)
+		if err != nil {
+			shutdown()
+			log.WithError(err).Fatal("cannot listen to terminal")
+		}
+		if exitCode == nil {
+			shutdown()
+			os.Exit(1)
+		}
+		os.Exit(int(*exitCode))
+	},
+}
+
+// listenTerminal copies the output of a supervisor terminal to out, starting with the terminal's backlog.
+// Returns the exit code once the terminal's process has exited. If idle is not zero, listenTerminal returns
+// without exit code when the terminal produces no output for that long. If onListen is not nil, it is called
+// once supervisor has started to forward the terminal's output to us.
+func listenTerminal(ctx context.Context, client supervisor.TerminalServiceClient, alias string, out io.Writer, idle time.Duration, onListen func() error) (exitCode *int32, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	listen, err := client.Listen(ctx, &supervisor.ListenTerminalRequest{Alias: alias})
+	if err != nil {
+		return nil, err
+	}
+
+	type recv struct {
+		resp *supervisor.ListenTerminalResponse
+		err  error
+	}
+	msgs := make(chan recv)
+	go func() {
+		for {
+			resp, err := listen.Recv()
+			select {
+			case msgs <- recv{resp, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var timeout <-chan time.Time
+	for {
+		if idle > 0 {
+			timeout = time.After(idle)
+		}
+
+		var msg recv
+		select {
+		case msg = <-msgs:
+		case <-timeout:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if msg.err == io.EOF {
+			return nil, nil
+		}
+		if msg.err != nil {
+			return nil, msg.err
+		}
+		if onListen != nil {
+			// supervisor listens to the terminal before it sends its first message
+			err = onListen()
+			if err != nil {
+				return nil, err
+			}
+			onListen = nil
+		}
+
+		switch output := msg.resp.Output.(type) {
+		case *supervisor.ListenTerminalResponse_Data:
+			_, err = out.Write(output.Data)
+			if err != nil {
+				return nil, err
+			}
+		case *supervisor.ListenTerminalResponse_ExitCode:
+			return &output.ExitCode, nil
+		}
+	}
+}
+
+// forwardStdin writes our stdin to the terminal until stdin is closed
+func forwardStdin(ctx context.Context, client supervisor.TerminalServiceClient, alias string) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n > 0 {
+			_, werr := client.Write(ctx, &supervisor.WriteTerminalRequest{Alias: alias, Stdin: buf[:n]})
+			if werr != nil {
+				log.WithError(werr).Warn("cannot write to terminal")
+				return
+			}
+		}
+		if err == io.EOF {
+			// the terminal has no stdin to close - EOT ends the input of most programs
+			_, _ = client.Write(ctx, &supervisor.WriteTerminalRequest{Alias: alias, Stdin: []byte{4}})
+			return
+		}
+		if err != nil {
+			log.WithError(xerrors.Errorf("cannot read stdin: %w", err)).Warn("stopped forwarding stdin")
+			return
+		}
+	}
+}
+
+func init() {
+	workspacesCmd.AddCommand(workspacesExecCmd)
+
+	workspacesExecCmd.Flags().String("workdir", "", "working directory of the command - defaults to the workspace's checkout location")
+	workspacesExecCmd.Flags().BoolP("interactive", "i", false, "forward stdin to the command")
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gitpod-io/gitpod/common-go/log"
+	supervisor "github.com/gitpod-io/gitpod/supervisor/api"
+)
+
+// taskLogIdleTimeout is how long we wait for more output of a task terminal before we consider its backlog printed
+const taskLogIdleTimeout = 500 * time.Millisecond
+
+// workspacesLogsCmd represents the logs command
+var workspacesLogsCmd = &cobra.Command{
+	Use:   "logs <instanceID>",
+	Short: "prints the supervisor and task logs of a workspace",
+	Long: `Prints the supervisor log, i.e. the log of the workspace container, followed by the output of each task.
+Task output is the backlog of the task's terminal, which holds the most recent output only.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var (
+			instanceID        = args[0]
+			follow, _         = cmd.Flags().GetBool("follow")
+			task, _           = cmd.Flags().GetString("task")
+			supervisorOnly, _ = cmd.Flags().GetBool("supervisor")
+		)
+		if task != "" && supervisorOnly {
+			log.Fatal("--task and --supervisor are mutually exclusive")
+		}
+		if follow && task == "" && !supervisorOnly {
+			log.Fatal("--follow requires either --task or --supervisor")
+		}
+
+		if task == "" {
+			err := printSupervisorLogs(ctx, instanceID, follow, os.Stdout)
+			if err != nil {
+				log.WithError(err).Fatal("cannot get supervisor logs")
+			}
+			if supervisorOnly {
+				return
+			}
+		}
+
+		conn, err := getSupervisorConn(ctx, instanceID)
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect")
+		}
+		defer conn.Close()
+
+		tasksStatus, err := supervisor.NewStatusServiceClient(conn).TasksStatus(ctx, &supervisor.TasksStatusRequest{})
+		if err != nil {
+			log.WithError(err).Fatal("cannot get tasks")
+		}
+		tasks, err := tasksStatus.Recv()
+		if err != nil {
+			log.WithError(err).Fatal("cannot get tasks")
+		}
+
+		terminals := supervisor.NewTerminalServiceClient(conn)
+		var found bool
+		for _, t := range tasks.Tasks {
+			if task != "" && t.Id != task && t.GetPresentation().GetName() != task {
+				continue
+			}
+			found = true
+
+			if task == "" {
+				fmt.Printf("\n==> task %s (%s): %s <==\n", t.Id, t.State, t.GetPresentation().GetName())
+			}
+			if t.Terminal == "" {
+				continue
+			}
+
+			idle := taskLogIdleTimeout
+			if follow {
+				idle = 0
+			}
+			_, err = listenTerminal(ctx, terminals, t.Terminal, os.Stdout, idle, nil)
+			if status.Code(err) == codes.NotFound {
+				// supervisor removes the terminals of closed tasks
+				fmt.Println("task terminal is gone")
+				continue
+			}
+			if err != nil {
+				log.WithError(err).WithField("task", t.Id).Fatal("cannot get task logs")
+			}
+		}
+		if task != "" && !found {
+			log.WithField("task", task).Fatal("workspace has no such task")
+		}
+	},
+}
+
+// printSupervisorLogs copies the log of the workspace container, which is the log of supervisor, to out
+func printSupervisorLogs(ctx context.Context, instanceID string, follow bool, out io.Writer) error {
+	cfg, namespace, err := getKubeconfig()
+	if err != nil {
+		return err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	pod, err := getWorkspacePod(ctx, clientSet, namespace, instanceID)
+	if err != nil {
+		return err
+	}
+	logs, err := clientSet.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: "workspace",
+		Follow:    follow,
+	}).Stream(ctx)
+	if err != nil {
+		return xerrors.Errorf("cannot get logs of pod %s: %w", pod.Name, err)
+	}
+	defer logs.Close()
+
+	_, err = io.Copy(out, logs)
+	return err
+}
+
+func init() {
+	workspacesCmd.AddCommand(workspacesLogsCmd)
+
+	workspacesLogsCmd.Flags().BoolP("follow", "f", false, "stream new output - requires --task or --supervisor")
+	workspacesLogsCmd.Flags().String("task", "", "print the output of this task only - accepts the task's ID or name")
+	workspacesLogsCmd.Flags().Bool("supervisor", false, "print the supervisor log only")
+}
@@ -17,12 +17,27 @@ import (
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
+	wsk8s "github.com/gitpod-io/gitpod/common-go/kubernetes"
 	"github.com/gitpod-io/gitpod/gpctl/pkg/util"
+	wsdaemon "github.com/gitpod-io/gitpod/ws-daemon/api"
 	"github.com/gitpod-io/gitpod/ws-manager/api"
 )
 
+const (
+	// local ports of the port forwardings to the workspace pod and its ws-daemon.
+	// They differ from the one of ws-manager so that commands can talk to both at the same time.
+	supervisorLocalPort = 20203
+	wsdaemonLocalPort   = 20204
+
+	supervisorPort = 22999
+	wsdaemonPort   = 8080
+)
+
 // workspacesCmd represents the client command
 var workspacesCmd = &cobra.Command{
 	Use:   "workspaces",
@@ -154,3 +169,111 @@ func getStatusByURL(ctx context.Context, client api.WorkspaceManagerClient, url
 
 	return nil, xerrors.Errorf("no workspace with URL \"%s\" found", url)
 }
+
+// getWorkspacePod returns the pod of a workspace instance
+func getWorkspacePod(ctx context.Context, clientSet kubernetes.Interface, namespace, instanceID string) (*corev1.Pod, error) {
+	pods, err := clientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", wsk8s.WorkspaceIDLabel, instanceID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, xerrors.Errorf("no pod for workspace instance %s in %s", instanceID, namespace)
+	}
+	return &pods.Items[0], nil
+}
+
+// forwardPort forwards the local port to the port of the pod and waits until the forwarding is ready
+func forwardPort(ctx context.Context, cfg *rest.Config, namespace, podName string, localPort, remotePort int) error {
+	readychan, errchan := util.ForwardPort(ctx, cfg, namespace, podName, fmt.Sprintf("%d:%d", localPort, remotePort))
+	select {
+	case <-readychan:
+		return nil
+	case err := <-errchan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getSupervisorConn connects to the supervisor API of a running workspace
+func getSupervisorConn(ctx context.Context, instanceID string) (*grpc.ClientConn, error) {
+	cfg, namespace, err := getKubeconfig()
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pod, err := getWorkspacePod(ctx, clientSet, namespace, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, xerrors.Errorf("workspace pod %s is %s, not running", pod.Name, pod.Status.Phase)
+	}
+	err = forwardPort(ctx, cfg, namespace, pod.Name, supervisorLocalPort, supervisorPort)
+	if err != nil {
+		return nil, err
+	}
+
+	// supervisor's API is not secured - it expects to be reached from within the workspace only
+	return grpc.Dial(fmt.Sprintf("localhost:%d", supervisorLocalPort), grpc.WithInsecure())
+}
+
+// getWorkspaceContentClient connects to the ws-daemon on the node of a workspace
+func getWorkspaceContentClient(ctx context.Context, instanceID string) (*grpc.ClientConn, wsdaemon.WorkspaceContentServiceClient, error) {
+	cfg, namespace, err := getKubeconfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pod, err := getWorkspacePod(ctx, clientSet, namespace, instanceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pod.Spec.NodeName == "" {
+		return nil, nil, xerrors.Errorf("workspace pod %s is not scheduled yet", pod.Name)
+	}
+	daemons, err := clientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "component=ws-daemon",
+		FieldSelector: "spec.nodeName=" + pod.Spec.NodeName,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(daemons.Items) == 0 {
+		return nil, nil, xerrors.Errorf("no ws-daemon on node %s", pod.Spec.NodeName)
+	}
+	err = forwardPort(ctx, cfg, namespace, daemons.Items[0].Name, wsdaemonLocalPort, wsdaemonPort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPool, err := util.CertPoolFromSecret(clientSet, namespace, "ws-daemon-tls", []string{"ca.crt"})
+	if err != nil {
+		return nil, nil, xerrors.Errorf("could not load ca cert: %w", err)
+	}
+	cert, err := util.CertFromSecret(clientSet, namespace, "ws-daemon-tls", "tls.crt", "tls.key")
+	if err != nil {
+		return nil, nil, xerrors.Errorf("could not load tls cert: %w", err)
+	}
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      certPool,
+		ServerName:   "wsdaemon",
+	})
+
+	conn, err := grpc.Dial(fmt.Sprintf("localhost:%d", wsdaemonLocalPort), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, wsdaemon.NewWorkspaceContentServiceClient(conn), nil
+}
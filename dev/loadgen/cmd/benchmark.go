@@ -32,6 +32,7 @@ import (
 var benchmarkOpts struct {
 	TLSPath string
 	Host    string
+	Fake    bool
 }
 
 // benchmarkCommand represents the run command
@@ -82,34 +83,18 @@ var benchmarkCommand = &cobra.Command{
 			Type: api.WorkspaceType_REGULAR,
 		}
 
-		var opts []grpc.DialOption
-		if benchmarkOpts.TLSPath != "" {
-			ca, err := ioutil.ReadFile(filepath.Join(benchmarkOpts.TLSPath, "ca.crt"))
-			if err != nil {
-				log.Fatal(err)
-			}
-			capool := x509.NewCertPool()
-			capool.AppendCertsFromPEM(ca)
-			cert, err := tls.LoadX509KeyPair(filepath.Join(benchmarkOpts.TLSPath, "tls.crt"), filepath.Join(benchmarkOpts.TLSPath, "tls.key"))
+		var executor loadgen.Executor
+		if benchmarkOpts.Fake {
+			executor = loadgen.NewFakeExecutor()
+		} else {
+			conn, err := dialWsManager()
 			if err != nil {
 				log.Fatal(err)
 			}
-			creds := credentials.NewTLS(&tls.Config{
-				Certificates: []tls.Certificate{cert},
-				RootCAs:      capool,
-				ServerName:   "ws-manager",
-			})
-			opts = append(opts, grpc.WithTransportCredentials(creds))
-		} else {
-			opts = append(opts, grpc.WithInsecure())
+			defer conn.Close()
+			executor = &loadgen.WsmanExecutor{C: api.NewWorkspaceManagerClient(conn)}
 		}
 
-		conn, err := grpc.Dial(benchmarkOpts.Host, opts...)
-		if err != nil {
-			log.Fatal(err)
-		}
-		defer conn.Close()
-
 		d, err := time.ParseDuration(scenario.RunningTimeout)
 		if err != nil {
 			log.Fatal(err)
@@ -118,9 +103,8 @@ var benchmarkCommand = &cobra.Command{
 		success := observer.NewSuccessObserver(scenario.SuccessRate)
 
 		session := &loadgen.Session{
-			Executor: &loadgen.WsmanExecutor{C: api.NewWorkspaceManagerClient(conn)},
-			// Executor: loadgen.NewFakeExecutor(),
-			Load: load,
+			Executor: executor,
+			Load:     load,
 			Specs: &loadgen.MultiWorkspaceGenerator{
 				Template: template,
 				Repos:    scenario.Repos,
@@ -155,6 +139,12 @@ var benchmarkCommand = &cobra.Command{
 			},
 		}
 
+		if scenario.Session != nil {
+			// Run waits for all user sessions to finish, and workspaces needn't be running at the end of one
+			session.Scenario = scenario.Session
+			session.PostLoadWait = nil
+		}
+
 		sctx, scancel := context.WithCancel(context.Background())
 
 		go func() {
@@ -184,6 +174,33 @@ func init() {
 
 	benchmarkCommand.Flags().StringVar(&benchmarkOpts.TLSPath, "tls", "", "path to ws-manager's TLS certificates")
 	benchmarkCommand.Flags().StringVar(&benchmarkOpts.Host, "host", "localhost:8080", "ws-manager host to talk to")
+	benchmarkCommand.Flags().BoolVar(&benchmarkOpts.Fake, "fake", false, "use fake workspaces rather than talking to ws-manager - useful for trying scenarios")
+}
+
+func dialWsManager() (*grpc.ClientConn, error) {
+	var opts []grpc.DialOption
+	if benchmarkOpts.TLSPath != "" {
+		ca, err := ioutil.ReadFile(filepath.Join(benchmarkOpts.TLSPath, "ca.crt"))
+		if err != nil {
+			return nil, err
+		}
+		capool := x509.NewCertPool()
+		capool.AppendCertsFromPEM(ca)
+		cert, err := tls.LoadX509KeyPair(filepath.Join(benchmarkOpts.TLSPath, "tls.crt"), filepath.Join(benchmarkOpts.TLSPath, "tls.key"))
+		if err != nil {
+			return nil, err
+		}
+		creds := credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      capool,
+			ServerName:   "ws-manager",
+		})
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	return grpc.Dial(benchmarkOpts.Host, opts...)
 }
 
 type BenchmarkScenario struct {
@@ -194,6 +211,8 @@ type BenchmarkScenario struct {
 	RunningTimeout  string                     `json:"waitForRunning"`
 	StoppingTimeout string                     `json:"waitForStopping"`
 	SuccessRate     float32                    `json:"successRate"`
+	// Session simulates a user session on each workspace rather than just starting it
+	Session *loadgen.Scenario `json:"session,omitempty"`
 }
 
 func handleWorkspaceDeletion(timeout string, executor loadgen.Executor) error {
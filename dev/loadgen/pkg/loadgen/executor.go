@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"net/http"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -46,33 +48,95 @@ type WorkspaceUpdate struct {
 // NewFakeExecutor creates a new fake executor
 func NewFakeExecutor() *FakeExecutor {
 	return &FakeExecutor{
-		updates: make(chan WorkspaceUpdate),
-		stop:    make(chan struct{}),
+		MaxDelay:   2 * time.Second,
+		updates:    make(chan WorkspaceUpdate),
+		stop:       make(chan struct{}),
+		workspaces: make(map[string]*fakeWorkspace),
 	}
 }
 
-// FakeExecutor creates fake workspaces
+// FakeExecutor creates fake workspaces. Fake workspaces run until they are stopped or time out,
+// which makes the executor suitable for running scenarios offline.
 type FakeExecutor struct {
-	updates chan WorkspaceUpdate
-	stop    chan struct{}
+	// MaxDelay is the maximum time a fake workspace spends in a phase or an action takes
+	MaxDelay time.Duration
+
+	updates   chan WorkspaceUpdate
+	stop      chan struct{}
+	stopOnce  sync.Once
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	mu         sync.Mutex
+	workspaces map[string]*fakeWorkspace
+}
+
+type fakeWorkspace struct {
+	Phase    api.WorkspacePhase
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
+var _ ScenarioExecutor = &FakeExecutor{}
+
 // StartWorkspace starts a new workspace
 func (fe *FakeExecutor) StartWorkspace(spec *StartWorkspaceSpec) (callDuration time.Duration, err error) {
 	log.WithField("spec", spec).Info("StartWorkspace")
-	go fe.produceUpdates(spec)
-	callDuration = time.Duration(rand.Uint32()%5000) * time.Millisecond
+
+	var timeout time.Duration
+	if t := spec.Spec.GetTimeout(); t != "" {
+		timeout, err = time.ParseDuration(t)
+		if err != nil {
+			return 0, status.Errorf(codes.InvalidArgument, "invalid timeout: %v", err)
+		}
+	}
+
+	ws := &fakeWorkspace{
+		Phase: api.WorkspacePhase_PENDING,
+		stop:  make(chan struct{}),
+	}
+	fe.mu.Lock()
+	if _, exists := fe.workspaces[spec.Id]; exists {
+		fe.mu.Unlock()
+		return 0, status.Errorf(codes.AlreadyExists, "workspace %s exists already", spec.Id)
+	}
+	fe.workspaces[spec.Id] = ws
+	fe.mu.Unlock()
+
+	fe.wg.Add(1)
+	go fe.produceUpdates(spec, ws, timeout)
+	callDuration = fe.delay()
 	return
 }
 
-func (fe *FakeExecutor) produceUpdates(spec *StartWorkspaceSpec) {
+func (fe *FakeExecutor) delay() time.Duration {
+	if fe.MaxDelay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(fe.MaxDelay)))
+}
+
+func (fe *FakeExecutor) produceUpdates(spec *StartWorkspaceSpec, ws *fakeWorkspace, timeout time.Duration) {
+	defer fe.wg.Done()
+
 	u := WorkspaceUpdate{
 		InstanceID:  spec.Id,
 		WorkspaceID: spec.Metadata.MetaId,
 		OwnerID:     spec.Metadata.Owner,
-		Phase:       api.WorkspacePhase_PENDING,
 	}
-	fe.updates <- u
+	send := func(p api.WorkspacePhase) bool {
+		fe.mu.Lock()
+		ws.Phase = p
+		fe.mu.Unlock()
+
+		u.Phase = p
+		select {
+		case fe.updates <- u:
+			return true
+		case <-fe.stop:
+			return false
+		}
+	}
 
 	for _, p := range []api.WorkspacePhase{
 		api.WorkspacePhase_PENDING,
@@ -80,24 +144,119 @@ func (fe *FakeExecutor) produceUpdates(spec *StartWorkspaceSpec) {
 		api.WorkspacePhase_INITIALIZING,
 		api.WorkspacePhase_RUNNING,
 	} {
-		time.Sleep(time.Duration(rand.Uint32()%2000) * time.Millisecond)
-		u.Phase = p
 		select {
-		case fe.updates <- u:
+		case <-time.After(fe.delay()):
+		case <-ws.stop:
+		case <-fe.stop:
+			return
+		}
+		if !send(p) {
+			return
+		}
+	}
+
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timedOut = time.After(timeout)
+	}
+	select {
+	case <-ws.stop:
+	case <-timedOut:
+	case <-fe.stop:
+		return
+	}
+
+	for _, p := range []api.WorkspacePhase{
+		api.WorkspacePhase_STOPPING,
+		api.WorkspacePhase_STOPPED,
+	} {
+		select {
+		case <-time.After(fe.delay()):
 		case <-fe.stop:
 			return
 		}
+		if !send(p) {
+			return
+		}
 	}
 }
 
+func (fe *FakeExecutor) running(instanceID string) error {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+
+	ws, ok := fe.workspaces[instanceID]
+	if !ok {
+		return status.Errorf(codes.NotFound, "workspace %s does not exist", instanceID)
+	}
+	if ws.Phase != api.WorkspacePhase_RUNNING {
+		return status.Errorf(codes.FailedPrecondition, "workspace %s is %s", instanceID, ws.Phase)
+	}
+	return nil
+}
+
+// act simulates an action in a running workspace
+func (fe *FakeExecutor) act(ctx context.Context, instanceID string) error {
+	err := fe.running(instanceID)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-time.After(fe.delay()):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StopWorkspace stops a workspace
+func (fe *FakeExecutor) StopWorkspace(ctx context.Context, instanceID string) error {
+	fe.mu.Lock()
+	ws, ok := fe.workspaces[instanceID]
+	fe.mu.Unlock()
+	if !ok {
+		return status.Errorf(codes.NotFound, "workspace %s does not exist", instanceID)
+	}
+
+	ws.stopOnce.Do(func() { close(ws.stop) })
+	return nil
+}
+
+// OpenIDE pretends to open the IDE of a running workspace
+func (fe *FakeExecutor) OpenIDE(ctx context.Context, instanceID string) error {
+	return fe.act(ctx, instanceID)
+}
+
+// RunCommand pretends to run a command in a running workspace. The command always succeeds.
+func (fe *FakeExecutor) RunCommand(ctx context.Context, instanceID, command string) (exitCode int, err error) {
+	return 0, fe.act(ctx, instanceID)
+}
+
 // Observe observes all workspaces started by the excecutor
 func (fe *FakeExecutor) Observe() (<-chan WorkspaceUpdate, error) {
 	return fe.updates, nil
 }
 
 // StopAll stops all workspaces started by the executor
-func (fe *FakeExecutor) StopAll() error {
-	close(fe.stop)
+func (fe *FakeExecutor) StopAll(ctx context.Context) error {
+	fe.stopOnce.Do(func() {
+		close(fe.stop)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		fe.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("not all workspaces could be stopped")
+	}
+
+	fe.closeOnce.Do(func() {
+		close(fe.updates)
+	})
 	return nil
 }
 
@@ -105,11 +264,17 @@ const loadgenAnnotation = "loadgen"
 
 // WsmanExecutor talks to a ws manager
 type WsmanExecutor struct {
-	C          api.WorkspaceManagerClient
-	Sub        []context.CancelFunc
+	C   api.WorkspaceManagerClient
+	Sub []context.CancelFunc
+	// HTTP talks to the workspaces when running scenarios. Defaults to http.DefaultClient.
+	HTTP *http.Client
+
+	mu         sync.Mutex
 	workspaces []string
 }
 
+var _ ScenarioExecutor = &WsmanExecutor{}
+
 // StartWorkspace starts a new workspace
 func (w *WsmanExecutor) StartWorkspace(spec *StartWorkspaceSpec) (callDuration time.Duration, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
@@ -125,10 +290,59 @@ func (w *WsmanExecutor) StartWorkspace(spec *StartWorkspaceSpec) (callDuration t
 		return 0, err
 	}
 
+	w.mu.Lock()
 	w.workspaces = append(w.workspaces, ss.Id)
+	w.mu.Unlock()
 	return time.Since(t0), nil
 }
 
+// StopWorkspace stops a workspace
+func (w *WsmanExecutor) StopWorkspace(ctx context.Context, instanceID string) error {
+	_, err := w.C.StopWorkspace(ctx, &api.StopWorkspaceRequest{
+		Id:     instanceID,
+		Policy: api.StopWorkspacePolicy_NORMALLY,
+	})
+	return err
+}
+
+// OpenIDE requests the IDE of a running workspace
+func (w *WsmanExecutor) OpenIDE(ctx context.Context, instanceID string) error {
+	sc, err := w.supervisor(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	return sc.OpenIDE(ctx)
+}
+
+// RunCommand runs a shell command in a running workspace through supervisor
+func (w *WsmanExecutor) RunCommand(ctx context.Context, instanceID, command string) (exitCode int, err error) {
+	sc, err := w.supervisor(ctx, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	return sc.RunCommand(ctx, command)
+}
+
+func (w *WsmanExecutor) supervisor(ctx context.Context, instanceID string) (*supervisorClient, error) {
+	desc, err := w.C.DescribeWorkspace(ctx, &api.DescribeWorkspaceRequest{Id: instanceID})
+	if err != nil {
+		return nil, err
+	}
+	if desc.Status.Phase != api.WorkspacePhase_RUNNING {
+		return nil, fmt.Errorf("workspace %s is %s", instanceID, desc.Status.Phase)
+	}
+
+	client := w.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &supervisorClient{
+		HTTP:       client,
+		URL:        desc.Status.Spec.Url,
+		OwnerToken: desc.Status.Auth.GetOwnerToken(),
+	}, nil
+}
+
 // Observe observes all workspaces started by the excecutor
 func (w *WsmanExecutor) Observe() (<-chan WorkspaceUpdate, error) {
 	res := make(chan WorkspaceUpdate)
@@ -174,6 +388,8 @@ func (w *WsmanExecutor) StopAll(ctx context.Context) error {
 	}
 
 	log.Info("stopping workspaces")
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	for _, id := range w.workspaces {
 		stopReq := api.StopWorkspaceRequest{
 			Id:     id,
@@ -7,6 +7,7 @@ package loadgen
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -22,6 +23,9 @@ type Session struct {
 	PostLoadWait func()
 	Termination  func(executor Executor) error
 
+	// Scenario, if set, simulates a user session for each workspace started. Requires a ScenarioExecutor.
+	Scenario *Scenario
+
 	Worker int
 }
 
@@ -32,6 +36,7 @@ type SessionEvent struct {
 	Error           error
 	WorkspaceStart  *SessionEventWorkspaceStart
 	WorkspaceUpdate *SessionEventWorkspaceUpdate
+	ScenarioStep    *SessionEventScenarioStep
 }
 
 // SessionEventKind describes the type of session event
@@ -46,6 +51,8 @@ const (
 	SessionWorkspaceStart
 	// SessionWorkspaceUpdate indicates a workspace update. Expect the WorkspaceUpdate field to be non-nil
 	SessionWorkspaceUpdate
+	// SessionScenarioStep indicates a simulated user finished a scenario step. Expect the ScenarioStep field to be non-nil
+	SessionScenarioStep
 	// SessionDone indicates the session is done. Expect no more updates.
 	SessionDone
 )
@@ -63,8 +70,31 @@ type SessionEventWorkspaceUpdate struct {
 	Update WorkspaceUpdate
 }
 
+// SessionEventScenarioStep describes a finished scenario step
+type SessionEventScenarioStep struct {
+	Time       time.Time
+	InstanceID string
+	Action     ScenarioAction
+	Duration   time.Duration
+	Error      error
+}
+
 // Run starts the load testing
 func (s *Session) Run(ctx context.Context) error {
+	var scenarioExecutor ScenarioExecutor
+	if s.Scenario != nil {
+		err := s.Scenario.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid scenario: %w", err)
+		}
+		var ok bool
+		scenarioExecutor, ok = s.Executor.(ScenarioExecutor)
+		if !ok {
+			return fmt.Errorf("executor cannot run scenarios")
+		}
+	}
+	tracker := newPhaseTracker()
+
 	load := s.Load.Generate()
 
 	var infraWG sync.WaitGroup
@@ -83,6 +113,7 @@ func (s *Session) Run(ctx context.Context) error {
 
 		<-start
 		for u := range obs {
+			tracker.Update(u)
 			updates <- &SessionEvent{
 				Kind: SessionWorkspaceUpdate,
 				WorkspaceUpdate: &SessionEventWorkspaceUpdate{
@@ -93,7 +124,10 @@ func (s *Session) Run(ctx context.Context) error {
 		}
 	}()
 
-	var loadWG sync.WaitGroup
+	var (
+		loadWG     sync.WaitGroup
+		scenarioWG sync.WaitGroup
+	)
 	loadWG.Add(s.Worker)
 	for i := 0; i < s.Worker; i++ {
 		go func(idx int) {
@@ -112,6 +146,15 @@ func (s *Session) Run(ctx context.Context) error {
 					break
 				}
 
+				if scenarioExecutor != nil {
+					scenarioWG.Add(1)
+					go func() {
+						defer scenarioWG.Done()
+						s.runScenario(ctx, scenarioExecutor, tracker, spec, updates)
+					}()
+					continue
+				}
+
 				dur, err := s.Executor.StartWorkspace(spec)
 				if err != nil {
 					updates <- &SessionEvent{Kind: SessionError, Error: err}
@@ -134,6 +177,7 @@ func (s *Session) Run(ctx context.Context) error {
 	close(start)
 
 	loadWG.Wait()
+	scenarioWG.Wait()
 	if s.PostLoadWait != nil && ctx.Err() == nil {
 		s.PostLoadWait()
 	}
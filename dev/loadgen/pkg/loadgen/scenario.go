@@ -0,0 +1,426 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	csapi "github.com/gitpod-io/gitpod/content-service/api"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+// ScenarioExecutor can simulate user sessions in addition to starting workspaces
+type ScenarioExecutor interface {
+	Executor
+
+	// StopWorkspace stops a workspace like a user would
+	StopWorkspace(ctx context.Context, instanceID string) error
+
+	// OpenIDE requests the IDE of a running workspace like a browser would
+	OpenIDE(ctx context.Context, instanceID string) error
+
+	// RunCommand runs a shell command in a running workspace through supervisor and waits until it's done
+	RunCommand(ctx context.Context, instanceID, command string) (exitCode int, err error)
+}
+
+// ScenarioAction is something a simulated user does
+type ScenarioAction string
+
+const (
+	// ScenarioStart starts the workspace and waits until it's running
+	ScenarioStart ScenarioAction = "start"
+	// ScenarioOpenIDE opens the IDE of the workspace
+	ScenarioOpenIDE ScenarioAction = "openIDE"
+	// ScenarioWriteFile writes a file of random content into the workspace
+	ScenarioWriteFile ScenarioAction = "writeFile"
+	// ScenarioRunTask runs a command in the workspace and waits until it's done
+	ScenarioRunTask ScenarioAction = "runTask"
+	// ScenarioIdle does nothing for the duration of the step
+	ScenarioIdle ScenarioAction = "idle"
+	// ScenarioTimeout leaves the workspace alone until it times out and is stopped
+	ScenarioTimeout ScenarioAction = "timeout"
+	// ScenarioStop stops the workspace and waits until it's stopped
+	ScenarioStop ScenarioAction = "stop"
+	// ScenarioRestart starts a new instance of the stopped workspace from its backup and waits until it's running
+	ScenarioRestart ScenarioAction = "restart"
+)
+
+const (
+	defaultStepTimeout    = 10 * time.Minute
+	defaultTimeoutTimeout = time.Hour
+)
+
+// Scenario describes the session of a simulated user, from starting a workspace to stopping it
+type Scenario struct {
+	// WorkspaceTimeout overrides the timeout of the workspaces, e.g. to have the timeout action finish quickly
+	WorkspaceTimeout string `json:"workspaceTimeout,omitempty"`
+
+	Steps []ScenarioStep `json:"steps"`
+}
+
+// ScenarioStep is a single action of a simulated user
+type ScenarioStep struct {
+	Action ScenarioAction `json:"action"`
+
+	// Think is the time the user takes before the step
+	Think *Distribution `json:"think,omitempty"`
+	// Timeout limits how long the step may take. Defaults to 10m, or 1h for the timeout action.
+	Timeout util.Duration `json:"timeout,omitempty"`
+
+	// Duration is the idle time of the idle action
+	Duration *Distribution `json:"duration,omitempty"`
+	// Path is the file the writeFile action writes, relative to the working directory of the workspace's terminal
+	Path string `json:"path,omitempty"`
+	// Size is the number of bytes the writeFile action writes
+	Size int `json:"size,omitempty"`
+	// Command is the shell command the runTask action runs
+	Command string `json:"command,omitempty"`
+}
+
+func (s ScenarioStep) timeout() time.Duration {
+	if s.Timeout != 0 {
+		return time.Duration(s.Timeout)
+	}
+	if s.Action == ScenarioTimeout {
+		return defaultTimeoutTimeout
+	}
+	return defaultStepTimeout
+}
+
+// Validate checks that the steps are possible in their order, e.g. that the workspace runs when a file is written
+func (s *Scenario) Validate() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("scenario has no steps")
+	}
+	if s.WorkspaceTimeout != "" {
+		if _, err := time.ParseDuration(s.WorkspaceTimeout); err != nil {
+			return fmt.Errorf("invalid workspaceTimeout: %w", err)
+		}
+	}
+
+	var started, running bool
+	for i, step := range s.Steps {
+		var err error
+		switch step.Action {
+		case ScenarioStart:
+			if started {
+				err = fmt.Errorf("workspace was started already - use restart")
+			}
+			started, running = true, true
+		case ScenarioRestart:
+			if !started || running {
+				err = fmt.Errorf("workspace must be stopped")
+			}
+			running = true
+		case ScenarioOpenIDE, ScenarioWriteFile, ScenarioRunTask:
+			if !running {
+				err = fmt.Errorf("workspace must be running")
+			}
+			if step.Action == ScenarioWriteFile && step.Path == "" {
+				err = fmt.Errorf("path is required")
+			}
+			if step.Action == ScenarioRunTask && step.Command == "" {
+				err = fmt.Errorf("command is required")
+			}
+		case ScenarioTimeout, ScenarioStop:
+			if !running {
+				err = fmt.Errorf("workspace must be running")
+			}
+			running = false
+		case ScenarioIdle:
+			if step.Duration == nil {
+				err = fmt.Errorf("duration is required")
+			}
+		default:
+			err = fmt.Errorf("unknown action")
+		}
+		if err == nil {
+			for _, d := range []*Distribution{step.Think, step.Duration} {
+				if d == nil {
+					continue
+				}
+				if err = d.Validate(); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("step %d (%s): %w", i, step.Action, err)
+		}
+	}
+	if s.Steps[0].Action != ScenarioStart && s.Steps[0].Action != ScenarioIdle {
+		return fmt.Errorf("scenario must start the workspace first")
+	}
+	if !started {
+		return fmt.Errorf("scenario never starts the workspace")
+	}
+	return nil
+}
+
+// DistributionType determines how a distribution samples durations
+type DistributionType string
+
+const (
+	// DistributionConstant always samples Value
+	DistributionConstant DistributionType = "constant"
+	// DistributionUniform samples uniformly between Min and Max
+	DistributionUniform DistributionType = "uniform"
+	// DistributionNormal samples normally distributed around Value with StdDev
+	DistributionNormal DistributionType = "normal"
+	// DistributionExponential samples exponentially distributed with mean Value, e.g. the time between requests
+	DistributionExponential DistributionType = "exponential"
+)
+
+// Distribution produces random durations, e.g. the think time of users. Samples are clamped to Min and Max if set.
+type Distribution struct {
+	Type   DistributionType `json:"type"`
+	Value  util.Duration    `json:"value,omitempty"`
+	StdDev util.Duration    `json:"stddev,omitempty"`
+	Min    util.Duration    `json:"min,omitempty"`
+	Max    util.Duration    `json:"max,omitempty"`
+}
+
+// Validate checks that the distribution has the parameters its type requires
+func (d *Distribution) Validate() error {
+	switch d.Type {
+	case DistributionConstant, DistributionExponential:
+		if d.Value <= 0 {
+			return fmt.Errorf("%s distribution requires a positive value", d.Type)
+		}
+	case DistributionNormal:
+		if d.Value <= 0 || d.StdDev <= 0 {
+			return fmt.Errorf("%s distribution requires a positive value and stddev", d.Type)
+		}
+	case DistributionUniform:
+		if d.Max <= d.Min {
+			return fmt.Errorf("%s distribution requires max to be greater than min", d.Type)
+		}
+	default:
+		return fmt.Errorf("unknown distribution type %q", d.Type)
+	}
+	if d.Max != 0 && d.Max < d.Min {
+		return fmt.Errorf("max must not be less than min")
+	}
+	return nil
+}
+
+// Sample produces a random duration. A nil distribution always samples zero.
+func (d *Distribution) Sample() time.Duration {
+	if d == nil {
+		return 0
+	}
+
+	var res float64
+	switch d.Type {
+	case DistributionConstant:
+		res = float64(d.Value)
+	case DistributionUniform:
+		res = float64(d.Min) + rand.Float64()*float64(d.Max-d.Min)
+	case DistributionNormal:
+		res = float64(d.Value) + rand.NormFloat64()*float64(d.StdDev)
+	case DistributionExponential:
+		res = rand.ExpFloat64() * float64(d.Value)
+	}
+
+	res = math.Max(res, float64(d.Min))
+	if d.Max > 0 {
+		res = math.Min(res, float64(d.Max))
+	}
+	return time.Duration(res)
+}
+
+// runScenario simulates the session of a single user. The session ends at the first failing step.
+func (s *Session) runScenario(ctx context.Context, executor ScenarioExecutor, tracker *phaseTracker, spec *StartWorkspaceSpec, updates chan<- *SessionEvent) {
+	if s.Scenario.WorkspaceTimeout != "" {
+		spec.Spec.Timeout = s.Scenario.WorkspaceTimeout
+	}
+
+	for _, step := range s.Scenario.Steps {
+		select {
+		case <-time.After(step.Think.Sample()):
+		case <-ctx.Done():
+			return
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, step.timeout())
+		t0 := time.Now()
+		next, err := s.runScenarioStep(stepCtx, executor, tracker, spec, step, updates)
+		cancel()
+		if step.Action == ScenarioIdle {
+			continue
+		}
+
+		updates <- &SessionEvent{
+			Kind: SessionScenarioStep,
+			ScenarioStep: &SessionEventScenarioStep{
+				Time:       time.Now(),
+				InstanceID: spec.Id,
+				Action:     step.Action,
+				Duration:   time.Since(t0),
+				Error:      err,
+			},
+		}
+		if err != nil {
+			return
+		}
+		spec = next
+	}
+}
+
+// runScenarioStep runs a single step and returns the spec of the workspace instance after the step
+func (s *Session) runScenarioStep(ctx context.Context, executor ScenarioExecutor, tracker *phaseTracker, spec *StartWorkspaceSpec, step ScenarioStep, updates chan<- *SessionEvent) (*StartWorkspaceSpec, error) {
+	switch step.Action {
+	case ScenarioStart, ScenarioRestart:
+		if step.Action == ScenarioRestart {
+			spec = restartSpec(spec)
+		}
+		dur, err := executor.StartWorkspace(spec)
+		if err != nil {
+			return spec, err
+		}
+		updates <- &SessionEvent{
+			Kind: SessionWorkspaceStart,
+			WorkspaceStart: &SessionEventWorkspaceStart{
+				Time:         time.Now(),
+				CallDuration: dur,
+				Spec:         spec,
+			},
+		}
+		return spec, tracker.Wait(ctx, spec.Id, func(u WorkspaceUpdate) (bool, error) {
+			switch {
+			case u.Failed:
+				return false, fmt.Errorf("workspace failed in phase %s", u.Phase)
+			case u.Phase == api.WorkspacePhase_RUNNING:
+				return true, nil
+			case u.Phase == api.WorkspacePhase_STOPPING || u.Phase == api.WorkspacePhase_STOPPED:
+				return false, fmt.Errorf("workspace stopped before it was running")
+			}
+			return false, nil
+		})
+
+	case ScenarioOpenIDE:
+		return spec, executor.OpenIDE(ctx, spec.Id)
+
+	case ScenarioWriteFile:
+		cmd := fmt.Sprintf("mkdir -p \"$(dirname %s)\" && head -c %d /dev/urandom > %s", shellQuote(step.Path), step.Size, shellQuote(step.Path))
+		return spec, runCommand(ctx, executor, spec.Id, cmd)
+
+	case ScenarioRunTask:
+		return spec, runCommand(ctx, executor, spec.Id, step.Command)
+
+	case ScenarioIdle:
+		select {
+		case <-time.After(step.Duration.Sample()):
+			return spec, nil
+		case <-ctx.Done():
+			return spec, ctx.Err()
+		}
+
+	case ScenarioTimeout, ScenarioStop:
+		if step.Action == ScenarioStop {
+			err := executor.StopWorkspace(ctx, spec.Id)
+			if err != nil {
+				return spec, err
+			}
+		}
+		return spec, tracker.Wait(ctx, spec.Id, func(u WorkspaceUpdate) (bool, error) {
+			return u.Phase == api.WorkspacePhase_STOPPED, nil
+		})
+	}
+
+	return spec, fmt.Errorf("unknown action %s", step.Action)
+}
+
+func runCommand(ctx context.Context, executor ScenarioExecutor, instanceID, command string) error {
+	exitCode, err := executor.RunCommand(ctx, instanceID, command)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("command exited with %d", exitCode)
+	}
+	return nil
+}
+
+// restartSpec produces the spec of a new instance of the workspace which restores the content of the previous one
+func restartSpec(spec *StartWorkspaceSpec) *StartWorkspaceSpec {
+	out := proto.Clone((*api.StartWorkspaceRequest)(spec)).(*api.StartWorkspaceRequest)
+	out.Id = uuid.New().String()
+
+	checkoutLocation := spec.Spec.GetInitializer().GetGit().GetCheckoutLocation()
+	if checkoutLocation == "" {
+		checkoutLocation = spec.Spec.WorkspaceLocation
+	}
+	out.Spec.Initializer = &csapi.WorkspaceInitializer{
+		Spec: &csapi.WorkspaceInitializer_Backup{
+			Backup: &csapi.FromBackupInitializer{CheckoutLocation: checkoutLocation},
+		},
+	}
+	return (*StartWorkspaceSpec)(out)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// phaseTracker keeps the latest update of each workspace, so that simulated users can wait for phases
+type phaseTracker struct {
+	mu      sync.Mutex
+	status  map[string]WorkspaceUpdate
+	changed chan struct{}
+}
+
+func newPhaseTracker() *phaseTracker {
+	return &phaseTracker{
+		status:  make(map[string]WorkspaceUpdate),
+		changed: make(chan struct{}),
+	}
+}
+
+// Update records a workspace update and wakes all waiters
+func (t *phaseTracker) Update(u WorkspaceUpdate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status[u.InstanceID] = u
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// Wait calls done with the latest update of the workspace whenever it changes, until done returns true or an error
+func (t *phaseTracker) Wait(ctx context.Context, instanceID string, done func(u WorkspaceUpdate) (bool, error)) error {
+	for {
+		t.mu.Lock()
+		u, ok := t.status[instanceID]
+		changed := t.changed
+		t.mu.Unlock()
+
+		if ok {
+			fin, err := done(u)
+			if err != nil {
+				return err
+			}
+			if fin {
+				return nil
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("workspace is %s: %w", u.Phase, ctx.Err())
+		}
+	}
+}
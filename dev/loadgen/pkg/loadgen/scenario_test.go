@@ -0,0 +1,286 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package loadgen
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/common-go/util"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+func TestScenarioValidate(t *testing.T) {
+	think := &Distribution{Type: DistributionConstant, Value: util.Duration(time.Second)}
+	tests := []struct {
+		Name        string
+		Scenario    Scenario
+		Expectation string
+	}{
+		{
+			Name: "full session",
+			Scenario: Scenario{
+				WorkspaceTimeout: "5m",
+				Steps: []ScenarioStep{
+					{Action: ScenarioIdle, Duration: think},
+					{Action: ScenarioStart},
+					{Action: ScenarioOpenIDE, Think: think},
+					{Action: ScenarioWriteFile, Path: "foo", Size: 10},
+					{Action: ScenarioRunTask, Command: "true"},
+					{Action: ScenarioTimeout},
+					{Action: ScenarioRestart},
+					{Action: ScenarioStop},
+				},
+			},
+		},
+		{
+			Name:        "no steps",
+			Expectation: "scenario has no steps",
+		},
+		{
+			Name:        "invalid timeout",
+			Scenario:    Scenario{WorkspaceTimeout: "forever", Steps: []ScenarioStep{{Action: ScenarioStart}}},
+			Expectation: "invalid workspaceTimeout",
+		},
+		{
+			Name:        "not started",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioOpenIDE}}},
+			Expectation: "step 0 (openIDE): workspace must be running",
+		},
+		{
+			Name:        "never started",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioIdle, Duration: think}}},
+			Expectation: "scenario never starts the workspace",
+		},
+		{
+			Name:        "started twice",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioStart}, {Action: ScenarioStop}, {Action: ScenarioStart}}},
+			Expectation: "step 2 (start): workspace was started already - use restart",
+		},
+		{
+			Name:        "restart running",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioStart}, {Action: ScenarioRestart}}},
+			Expectation: "step 1 (restart): workspace must be stopped",
+		},
+		{
+			Name:        "write to stopped",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioStart}, {Action: ScenarioTimeout}, {Action: ScenarioWriteFile, Path: "foo"}}},
+			Expectation: "step 2 (writeFile): workspace must be running",
+		},
+		{
+			Name:        "missing command",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioStart}, {Action: ScenarioRunTask}}},
+			Expectation: "step 1 (runTask): command is required",
+		},
+		{
+			Name:        "missing idle duration",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioStart}, {Action: ScenarioIdle}}},
+			Expectation: "step 1 (idle): duration is required",
+		},
+		{
+			Name:        "invalid think time",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioStart, Think: &Distribution{Type: DistributionUniform}}}},
+			Expectation: "step 0 (start): uniform distribution requires max to be greater than min",
+		},
+		{
+			Name:        "unknown action",
+			Scenario:    Scenario{Steps: []ScenarioStep{{Action: ScenarioStart}, {Action: "dance"}}},
+			Expectation: "step 1 (dance): unknown action",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var act string
+			if err := test.Scenario.Validate(); err != nil {
+				act = err.Error()
+			}
+			if !strings.HasPrefix(act, test.Expectation) || (test.Expectation == "" && act != "") {
+				t.Errorf("unexpected error: want %q, got %q", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestDistributionSample(t *testing.T) {
+	sec := func(s int) util.Duration { return util.Duration(time.Duration(s) * time.Second) }
+	tests := []struct {
+		Name     string
+		Dist     *Distribution
+		Min, Max time.Duration
+	}{
+		{Name: "nil", Dist: nil, Min: 0, Max: 0},
+		{Name: "constant", Dist: &Distribution{Type: DistributionConstant, Value: sec(5)}, Min: 5 * time.Second, Max: 5 * time.Second},
+		{Name: "uniform", Dist: &Distribution{Type: DistributionUniform, Min: sec(1), Max: sec(3)}, Min: 1 * time.Second, Max: 3 * time.Second},
+		{Name: "clamped normal", Dist: &Distribution{Type: DistributionNormal, Value: sec(10), StdDev: sec(10), Min: sec(5), Max: sec(15)}, Min: 5 * time.Second, Max: 15 * time.Second},
+		{Name: "clamped exponential", Dist: &Distribution{Type: DistributionExponential, Value: sec(10), Max: sec(12)}, Min: 0, Max: 12 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				s := test.Dist.Sample()
+				if s < test.Min || s > test.Max {
+					t.Fatalf("sample %v is not within [%v, %v]", s, test.Min, test.Max)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionScenario(t *testing.T) {
+	const workspaces = 3
+	ms := func(v int) *Distribution {
+		return &Distribution{Type: DistributionConstant, Value: util.Duration(time.Duration(v) * time.Millisecond)}
+	}
+
+	tests := []struct {
+		Name          string
+		Scenario      Scenario
+		Expectation   map[ScenarioAction]int
+		ExpectFailure ScenarioAction
+		ExpectStarts  int
+	}{
+		{
+			Name: "full session",
+			Scenario: Scenario{
+				WorkspaceTimeout: "50ms",
+				Steps: []ScenarioStep{
+					{Action: ScenarioStart},
+					{Action: ScenarioOpenIDE, Think: ms(5)},
+					{Action: ScenarioWriteFile, Path: "foo/bar", Size: 10},
+					{Action: ScenarioRunTask, Command: "true", Think: ms(5)},
+					{Action: ScenarioIdle, Duration: ms(10)},
+					{Action: ScenarioTimeout},
+					{Action: ScenarioRestart},
+					{Action: ScenarioStop},
+				},
+			},
+			Expectation: map[ScenarioAction]int{
+				ScenarioStart:     workspaces,
+				ScenarioOpenIDE:   workspaces,
+				ScenarioWriteFile: workspaces,
+				ScenarioRunTask:   workspaces,
+				ScenarioTimeout:   workspaces,
+				ScenarioRestart:   workspaces,
+				ScenarioStop:      workspaces,
+			},
+			ExpectStarts: 2 * workspaces,
+		},
+		{
+			Name: "failing step ends session",
+			Scenario: Scenario{
+				Steps: []ScenarioStep{
+					{Action: ScenarioStart},
+					// the workspace never times out
+					{Action: ScenarioTimeout, Timeout: util.Duration(50 * time.Millisecond)},
+					{Action: ScenarioRestart},
+				},
+			},
+			Expectation: map[ScenarioAction]int{
+				ScenarioStart:   workspaces,
+				ScenarioTimeout: workspaces,
+			},
+			ExpectFailure: ScenarioTimeout,
+			ExpectStarts:  workspaces,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			executor := NewFakeExecutor()
+			executor.MaxDelay = 10 * time.Millisecond
+
+			obs := make(chan *SessionEvent)
+			var (
+				steps  = make(map[ScenarioAction]int)
+				starts int
+				done   = make(chan struct{})
+			)
+			go func() {
+				defer close(done)
+				for evt := range obs {
+					switch evt.Kind {
+					case SessionWorkspaceStart:
+						starts++
+					case SessionScenarioStep:
+						step := evt.ScenarioStep
+						steps[step.Action]++
+						if step.Error != nil && step.Action != test.ExpectFailure {
+							t.Errorf("step %s failed: %v", step.Action, step.Error)
+						}
+						if step.Error == nil && step.Action == test.ExpectFailure {
+							t.Errorf("step %s did not fail", step.Action)
+						}
+					case SessionError:
+						t.Errorf("session error: %v", evt.Error)
+					}
+				}
+			}()
+
+			scenario := test.Scenario
+			session := &Session{
+				Executor: executor,
+				Load:     NewWorkspaceCountLimitingGenerator(NewFixedLoadGenerator(time.Millisecond, time.Millisecond), workspaces),
+				Specs: &FixedWorkspaceGenerator{Template: &api.StartWorkspaceRequest{
+					Metadata: &api.WorkspaceMetadata{Owner: "foobar"},
+					Spec:     &api.StartWorkspaceSpec{WorkspaceLocation: "foobar"},
+				}},
+				Observer: []chan<- *SessionEvent{obs},
+				Scenario: &scenario,
+				Termination: func(executor Executor) error {
+					ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+					defer cancel()
+					return executor.StopAll(ctx)
+				},
+				Worker: 1,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			err := session.Run(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			<-done
+
+			for action, cnt := range test.Expectation {
+				if steps[action] != cnt {
+					t.Errorf("unexpected number of %s steps: want %d, got %d", action, cnt, steps[action])
+				}
+			}
+			if len(steps) != len(test.Expectation) {
+				t.Errorf("unexpected steps: %v", steps)
+			}
+			if starts != test.ExpectStarts {
+				t.Errorf("unexpected number of workspace starts: want %d, got %d", test.ExpectStarts, starts)
+			}
+		})
+	}
+}
+
+func TestRestartSpec(t *testing.T) {
+	spec := &StartWorkspaceSpec{
+		Id:       "instance",
+		Metadata: &api.WorkspaceMetadata{MetaId: "workspace", Owner: "owner"},
+		Spec:     &api.StartWorkspaceSpec{WorkspaceLocation: "repo"},
+	}
+
+	act := restartSpec(spec)
+	if act.Id == spec.Id {
+		t.Errorf("restart must have a new instance ID")
+	}
+	if act.Metadata.MetaId != spec.Metadata.MetaId {
+		t.Errorf("restart must keep the workspace ID: want %s, got %s", spec.Metadata.MetaId, act.Metadata.MetaId)
+	}
+	if loc := act.Spec.Initializer.GetBackup().GetCheckoutLocation(); loc != "repo" {
+		t.Errorf("restart must restore the backup into the checkout location: want repo, got %q", loc)
+	}
+	if spec.Spec.Initializer != nil {
+		t.Errorf("restart must not modify the original spec")
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package loadgen
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const ownerTokenHeader = "x-gitpod-owner-token"
+
+// supervisorClient talks to supervisor through the REST gateway ws-proxy exposes on the workspace URL
+type supervisorClient struct {
+	HTTP       *http.Client
+	URL        string
+	OwnerToken string
+}
+
+// OpenIDE requests the workspace URL like a browser opening the IDE would
+func (c *supervisorClient) OpenIDE(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// RunCommand runs a command in the first terminal of the workspace and waits until it's done.
+// The REST gateway cannot open terminals, hence the command shares the terminal with whatever runs in it.
+func (c *supervisorClient) RunCommand(ctx context.Context, command string) (exitCode int, err error) {
+	var terminals struct {
+		Terminals []struct {
+			Alias string `json:"alias"`
+		} `json:"terminals"`
+	}
+	err = c.getJSON(ctx, "terminal/list", &terminals)
+	if err != nil {
+		return 0, err
+	}
+	if len(terminals.Terminals) == 0 {
+		return 0, fmt.Errorf("workspace has no terminal")
+	}
+	alias := terminals.Terminals[0].Alias
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	listen, err := c.do(ctx, http.MethodGet, c.api("terminal/listen/"+url.PathEscape(alias)), nil)
+	if err != nil {
+		return 0, err
+	}
+	defer listen.Body.Close()
+
+	// The terminal echoes the command, which contains the marker followed by $?, rather than digits.
+	// The marker is unique so that the backlog of the terminal cannot contain it.
+	marker := "loadgen-" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	exit := regexp.MustCompile(marker + `(\d+)\r?\n`)
+	stdin := fmt.Sprintf("(%s); echo %s$?\n", command, marker)
+	q := url.Values{"stdin": []string{base64.StdEncoding.EncodeToString([]byte(stdin))}}
+	write, err := c.do(ctx, http.MethodPost, c.api("terminal/write/"+url.PathEscape(alias))+"?"+q.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	write.Body.Close()
+
+	var (
+		output []byte
+		dec    = json.NewDecoder(bufio.NewReader(listen.Body))
+	)
+	for {
+		var msg struct {
+			Result *struct {
+				Data     []byte `json:"data"`
+				ExitCode *int   `json:"exitCode"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		err = dec.Decode(&msg)
+		if err == io.EOF {
+			return 0, fmt.Errorf("terminal closed before the command was done")
+		}
+		if err != nil {
+			return 0, err
+		}
+		if msg.Error != nil {
+			return 0, fmt.Errorf("cannot listen to terminal: %s", msg.Error.Message)
+		}
+		if msg.Result == nil {
+			continue
+		}
+		if msg.Result.ExitCode != nil {
+			return 0, fmt.Errorf("terminal exited with %d before the command was done", *msg.Result.ExitCode)
+		}
+
+		output = append(output, msg.Result.Data...)
+		if m := exit.FindSubmatch(output); m != nil {
+			return strconv.Atoi(string(m[1]))
+		}
+		// keep enough output to find a marker that spans messages
+		if keep := len(marker) + 8; len(output) > keep {
+			output = output[len(output)-keep:]
+		}
+	}
+}
+
+func (c *supervisorClient) api(path string) string {
+	return strings.TrimSuffix(c.URL, "/") + "/_supervisor/v1/" + path
+}
+
+func (c *supervisorClient) getJSON(ctx context.Context, path string, res interface{}) error {
+	resp, err := c.do(ctx, http.MethodGet, c.api(path), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+func (c *supervisorClient) do(ctx context.Context, method, u string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(ownerTokenHeader, c.OwnerToken)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", method, u, resp.Status)
+	}
+	return resp, nil
+}
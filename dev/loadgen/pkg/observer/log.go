@@ -15,7 +15,8 @@ func NewLogObserver(errorOnly bool) chan<- *loadgen.SessionEvent {
 	res := make(chan *loadgen.SessionEvent, defaultCapacity)
 	go func() {
 		for evt := range res {
-			if errorOnly && evt.Kind != loadgen.SessionError {
+			failedStep := evt.Kind == loadgen.SessionScenarioStep && evt.ScenarioStep.Error != nil
+			if errorOnly && evt.Kind != loadgen.SessionError && !failedStep {
 				continue
 			}
 
@@ -29,6 +30,14 @@ func NewLogObserver(errorOnly bool) chan<- *loadgen.SessionEvent {
 			case loadgen.SessionWorkspaceUpdate:
 				up := evt.WorkspaceUpdate.Update
 				log.WithField("instanceID", up.InstanceID).WithField("failed", up.Failed).WithField("phase", up.Phase).Info("workspace update")
+			case loadgen.SessionScenarioStep:
+				step := evt.ScenarioStep
+				entry := log.WithField("instanceID", step.InstanceID).WithField("action", step.Action).WithField("duration", step.Duration)
+				if failedStep {
+					entry.WithError(step.Error).Error("scenario step failed")
+				} else {
+					entry.Info("scenario step done")
+				}
 			case loadgen.SessionDone:
 				log.Info("session done")
 			}
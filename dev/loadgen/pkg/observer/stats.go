@@ -5,6 +5,7 @@
 package observer
 
 import (
+	"sort"
 	"time"
 
 	"github.com/gitpod-io/gitpod/loadgen/pkg/loadgen"
@@ -21,6 +22,79 @@ type Stats struct {
 	Failed  int           `json:"failed"`
 	Running int           `json:"running"`
 	Samples []StatsSample `json:"samples"`
+
+	// Phases are the latencies of workspace phases and scenario steps. Workspace phases are keyed
+	// workspace.<PHASE> for the time spent in a phase, and workspace.startToRunning for the time from
+	// starting a workspace until it runs. Scenario steps are keyed step.<action>.
+	Phases map[string]PhaseStats `json:"phases,omitempty"`
+}
+
+// PhaseStats are the latency percentiles of a phase
+type PhaseStats struct {
+	Count  int           `json:"count"`
+	Failed int           `json:"failed"`
+	P50    time.Duration `json:"p50"`
+	P90    time.Duration `json:"p90"`
+	P95    time.Duration `json:"p95"`
+	P99    time.Duration `json:"p99"`
+	Max    time.Duration `json:"max"`
+}
+
+// phaseSamples collects the latencies of phases
+type phaseSamples struct {
+	Durations map[string][]time.Duration
+	Failed    map[string]int
+}
+
+func newPhaseSamples() *phaseSamples {
+	return &phaseSamples{
+		Durations: make(map[string][]time.Duration),
+		Failed:    make(map[string]int),
+	}
+}
+
+func (p *phaseSamples) Add(phase string, d time.Duration, failed bool) {
+	if failed {
+		p.Failed[phase]++
+		return
+	}
+	p.Durations[phase] = append(p.Durations[phase], d)
+}
+
+func (p *phaseSamples) Stats() map[string]PhaseStats {
+	res := make(map[string]PhaseStats, len(p.Durations))
+	for phase, failed := range p.Failed {
+		res[phase] = PhaseStats{Count: failed, Failed: failed}
+	}
+	for phase, ds := range p.Durations {
+		sorted := make([]time.Duration, len(ds))
+		copy(sorted, ds)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		failed := p.Failed[phase]
+		res[phase] = PhaseStats{
+			Count:  len(sorted) + failed,
+			Failed: failed,
+			P50:    percentile(sorted, 50),
+			P90:    percentile(sorted, 90),
+			P95:    percentile(sorted, 95),
+			P99:    percentile(sorted, 99),
+			Max:    sorted[len(sorted)-1],
+		}
+	}
+	return res
+}
+
+// percentile computes the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
 }
 
 // StatsSample is a single workspace sample
@@ -36,8 +110,14 @@ type StatsSample struct {
 func NewStatsObserver(cb func(*Stats)) chan<- *loadgen.SessionEvent {
 	res := make(chan *loadgen.SessionEvent, defaultCapacity)
 
-	publishStats := func(status map[string]*StatsSample) {
+	var (
+		status     = make(map[string]*StatsSample)
+		phaseSince = make(map[string]time.Time)
+		phases     = newPhaseSamples()
+	)
+	publishStats := func() {
 		var s Stats
+		s.Phases = phases.Stats()
 		s.Samples = make([]StatsSample, 0, len(status))
 		for _, ws := range status {
 			s.Total++
@@ -54,12 +134,14 @@ func NewStatsObserver(cb func(*Stats)) chan<- *loadgen.SessionEvent {
 	}
 
 	go func() {
-		status := make(map[string]*StatsSample)
 		for evt := range res {
 			switch evt.Kind {
 			case loadgen.SessionStart:
 				status = make(map[string]*StatsSample)
+				phaseSince = make(map[string]time.Time)
+				phases = newPhaseSamples()
 			case loadgen.SessionWorkspaceStart:
+				phaseSince[evt.WorkspaceStart.Spec.Id] = evt.WorkspaceStart.Time
 				status[evt.WorkspaceStart.Spec.Id] = &StatsSample{
 					InstanceID:    evt.WorkspaceStart.Spec.Id,
 					Start:         evt.WorkspaceStart.Time,
@@ -73,12 +155,29 @@ func NewStatsObserver(cb func(*Stats)) chan<- *loadgen.SessionEvent {
 				if !ok {
 					continue
 				}
+				if up.Phase != ws.Phase {
+					t := evt.WorkspaceUpdate.Time
+					if ws.Phase != api.WorkspacePhase_UNKNOWN {
+						phases.Add("workspace."+ws.Phase.String(), t.Sub(phaseSince[up.InstanceID]), false)
+					}
+					if up.Phase == api.WorkspacePhase_RUNNING && ws.Phase != api.WorkspacePhase_RUNNING {
+						phases.Add("workspace.startToRunning", t.Sub(ws.Start), false)
+					}
+					phaseSince[up.InstanceID] = t
+				}
+				if up.Failed && !ws.Failed {
+					phases.Add("workspace."+up.Phase.String(), 0, true)
+				}
 				ws.Phase = up.Phase
 				ws.Failed = up.Failed
 				ws.Running = evt.WorkspaceUpdate.Time
-				publishStats(status)
+				publishStats()
+			case loadgen.SessionScenarioStep:
+				step := evt.ScenarioStep
+				phases.Add("step."+string(step.Action), step.Duration, step.Error != nil)
+				publishStats()
 			case loadgen.SessionDone:
-				publishStats(status)
+				publishStats()
 			}
 		}
 	}()
@@ -0,0 +1,105 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package observer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gitpod-io/gitpod/loadgen/pkg/loadgen"
+	"github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+func TestPercentile(t *testing.T) {
+	var hundred []time.Duration
+	for i := 1; i <= 100; i++ {
+		hundred = append(hundred, time.Duration(i))
+	}
+
+	tests := []struct {
+		Name        string
+		Sorted      []time.Duration
+		P           int
+		Expectation time.Duration
+	}{
+		{Name: "empty", Sorted: nil, P: 50, Expectation: 0},
+		{Name: "single", Sorted: []time.Duration{7}, P: 99, Expectation: 7},
+		{Name: "p50 of 100", Sorted: hundred, P: 50, Expectation: 50},
+		{Name: "p99 of 100", Sorted: hundred, P: 99, Expectation: 99},
+		{Name: "p90 of 4", Sorted: []time.Duration{1, 2, 3, 4}, P: 90, Expectation: 4},
+		{Name: "p50 of 4", Sorted: []time.Duration{1, 2, 3, 4}, P: 50, Expectation: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := percentile(test.Sorted, test.P)
+			if act != test.Expectation {
+				t.Errorf("unexpected percentile: want %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestStatsPhases(t *testing.T) {
+	t0 := time.Now()
+	spec := &loadgen.StartWorkspaceSpec{Id: "ws1"}
+	update := func(offset time.Duration, phase api.WorkspacePhase) *loadgen.SessionEvent {
+		return &loadgen.SessionEvent{
+			Kind: loadgen.SessionWorkspaceUpdate,
+			WorkspaceUpdate: &loadgen.SessionEventWorkspaceUpdate{
+				Time:   t0.Add(offset),
+				Update: loadgen.WorkspaceUpdate{InstanceID: spec.Id, Phase: phase},
+			},
+		}
+	}
+	step := func(action loadgen.ScenarioAction, d time.Duration, err error) *loadgen.SessionEvent {
+		return &loadgen.SessionEvent{
+			Kind:         loadgen.SessionScenarioStep,
+			ScenarioStep: &loadgen.SessionEventScenarioStep{InstanceID: spec.Id, Action: action, Duration: d, Error: err},
+		}
+	}
+
+	stats := make(chan *Stats)
+	obs := NewStatsObserver(func(s *Stats) {
+		stats <- s
+	})
+	go func() {
+		for _, evt := range []*loadgen.SessionEvent{
+			{Kind: loadgen.SessionStart},
+			{Kind: loadgen.SessionWorkspaceStart, WorkspaceStart: &loadgen.SessionEventWorkspaceStart{Time: t0, Spec: spec}},
+			update(1*time.Second, api.WorkspacePhase_PENDING),
+			update(3*time.Second, api.WorkspacePhase_CREATING),
+			update(4*time.Second, api.WorkspacePhase_CREATING),
+			update(7*time.Second, api.WorkspacePhase_RUNNING),
+			step(loadgen.ScenarioOpenIDE, 2*time.Second, nil),
+			step(loadgen.ScenarioRunTask, time.Second, errors.New("exit code 1")),
+			{Kind: loadgen.SessionDone},
+		} {
+			obs <- evt
+		}
+		close(obs)
+	}()
+
+	var last *Stats
+	for i := 0; i < 7; i++ {
+		last = <-stats
+	}
+
+	expectation := map[string]PhaseStats{
+		"workspace.PENDING":        {Count: 1, P50: 2 * time.Second, P90: 2 * time.Second, P95: 2 * time.Second, P99: 2 * time.Second, Max: 2 * time.Second},
+		"workspace.CREATING":       {Count: 1, P50: 4 * time.Second, P90: 4 * time.Second, P95: 4 * time.Second, P99: 4 * time.Second, Max: 4 * time.Second},
+		"workspace.startToRunning": {Count: 1, P50: 7 * time.Second, P90: 7 * time.Second, P95: 7 * time.Second, P99: 7 * time.Second, Max: 7 * time.Second},
+		"step.openIDE":             {Count: 1, P50: 2 * time.Second, P90: 2 * time.Second, P95: 2 * time.Second, P99: 2 * time.Second, Max: 2 * time.Second},
+		"step.runTask":             {Count: 1, Failed: 1},
+	}
+	if !reflect.DeepEqual(last.Phases, expectation) {
+		t.Errorf("unexpected phases: want %v, got %v", expectation, last.Phases)
+	}
+	if last.Running != 1 {
+		t.Errorf("unexpected number of running workspaces: want 1, got %d", last.Running)
+	}
+}
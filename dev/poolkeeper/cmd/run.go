@@ -5,10 +5,14 @@
 package cmd
 
 import (
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 
 	"github.com/gitpod-io/gitpod/common-go/log"
@@ -33,7 +37,29 @@ var runCmd = &cobra.Command{
 			log.Info("poolkeeper stopped.")
 		}()
 
-		poolKeeper := poolkeeper.NewPoolKeeper(clientSet, &config.Poolkeeper)
+		reg := prometheus.NewRegistry()
+		poolKeeper, err := poolkeeper.NewPoolKeeper(clientSet, &config.Poolkeeper, prometheus.WrapRegistererWithPrefix("gitpod_poolkeeper_", reg))
+		if err != nil {
+			log.WithError(err).Fatal("cannot create poolkeeper")
+		}
+		if addr := config.Poolkeeper.PrometheusAddr; addr != "" {
+			reg.MustRegister(
+				collectors.NewGoCollector(),
+				collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+			)
+
+			handler := http.NewServeMux()
+			handler.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+
+			go func() {
+				err := http.ListenAndServe(addr, handler)
+				if err != nil {
+					log.WithError(err).Error("Prometheus metrics server failed")
+				}
+			}()
+			log.WithField("addr", addr).Info("started Prometheus metrics server")
+		}
+
 		go poolKeeper.Start()
 		defer poolKeeper.Stop()
 
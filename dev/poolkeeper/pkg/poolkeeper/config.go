@@ -10,6 +10,9 @@ import "github.com/gitpod-io/gitpod/common-go/util"
 type Config struct {
 	// Tasks contains all tasks poolkeeper should execute on the NodePools
 	Tasks []*Task `json:"tasks"`
+
+	// PrometheusAddr is the address the metrics are served on, e.g. :9500. Metrics are disabled if empty.
+	PrometheusAddr string `json:"prometheusAddr,omitempty"`
 }
 
 // Task is an action that PoolKeeper should perform regularly
@@ -25,6 +28,9 @@ type Task struct {
 
 	// KeepNodeAlive blocks downscaling for a specified node
 	KeepNodeAlive *KeepNodeAlive `json:"keepNodeAlive,omitempty"`
+
+	// ScaleAhead keeps capacity for the workspace starts expected in the near future
+	ScaleAhead *ScaleAhead `json:"scaleAhead,omitempty"`
 }
 
 // TODO Needs a lease: https://carlosbecker.com/posts/k8s-leader-election
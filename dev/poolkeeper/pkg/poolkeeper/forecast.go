@@ -0,0 +1,145 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package poolkeeper
+
+import (
+	"sort"
+	"time"
+)
+
+const week = 7 * 24 * time.Hour
+
+// startHistory counts the workspace starts per slot of the week, e.g. Tuesdays 09:15-09:30, over the last weeks
+type startHistory struct {
+	// SlotSize is the granularity of the history
+	SlotSize time.Duration `json:"slotSize"`
+
+	// Weeks are sorted by their start, the last one usually is the current week
+	Weeks []*historyWeek `json:"weeks"`
+
+	// Observed is the time until which all workspace starts were counted
+	Observed time.Time `json:"observed"`
+}
+
+type historyWeek struct {
+	// Start is Monday 00:00 UTC of that week
+	Start time.Time `json:"start"`
+
+	// Starts counts the workspace starts per slot. A slot is -1 if we did not observe it, e.g. because
+	// poolkeeper was not running or could not connect to ws-manager.
+	Starts []int `json:"starts"`
+}
+
+func newStartHistory(slotSize time.Duration) *startHistory {
+	return &startHistory{SlotSize: slotSize}
+}
+
+// weekStart returns Monday 00:00 UTC of the week t is in
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	monday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-monday, 0, 0, 0, 0, time.UTC)
+}
+
+func (h *startHistory) slot(t time.Time) int {
+	return int(t.Sub(weekStart(t)) / h.SlotSize)
+}
+
+// week returns the week t is in. If create is true and the week is missing, week adds it.
+func (h *startHistory) week(t time.Time, create bool) *historyWeek {
+	start := weekStart(t)
+	for _, w := range h.Weeks {
+		if w.Start.Equal(start) {
+			return w
+		}
+	}
+	if !create {
+		return nil
+	}
+
+	w := &historyWeek{
+		Start:  start,
+		Starts: make([]int, int(week/h.SlotSize)),
+	}
+	for i := range w.Starts {
+		w.Starts[i] = -1
+	}
+	h.Weeks = append(h.Weeks, w)
+	sort.Slice(h.Weeks, func(i, j int) bool { return h.Weeks[i].Start.Before(h.Weeks[j].Start) })
+	return w
+}
+
+// Record counts a workspace start at t
+func (h *startHistory) Record(t time.Time) {
+	w := h.week(t, true)
+	s := h.slot(t)
+	if w.Starts[s] < 0 {
+		w.Starts[s] = 0
+	}
+	w.Starts[s]++
+}
+
+// Observe marks all slots since the last observation until t as observed, i.e. all workspace starts
+// in that period have been counted.
+func (h *startHistory) Observe(t time.Time) {
+	start := h.Observed
+	if start.IsZero() || start.After(t) {
+		start = t
+	}
+
+	// stepping by the slot size cannot skip a slot
+	for c := start; c.Before(t); c = c.Add(h.SlotSize) {
+		h.observeSlot(c)
+	}
+	h.observeSlot(t)
+	h.Observed = t
+}
+
+func (h *startHistory) observeSlot(t time.Time) {
+	w := h.week(t, true)
+	s := h.slot(t)
+	if w.Starts[s] < 0 {
+		w.Starts[s] = 0
+	}
+}
+
+// Prune drops all but the keep weeks before the week t is in
+func (h *startHistory) Prune(t time.Time, keep int) {
+	oldest := weekStart(t).Add(-time.Duration(keep) * week)
+	var res []*historyWeek
+	for _, w := range h.Weeks {
+		if w.Start.Before(oldest) {
+			continue
+		}
+		res = append(res, w)
+	}
+	h.Weeks = res
+}
+
+// Forecast predicts the number of workspace starts between t and t+d as the sum of the mean starts per slot
+// in the previous weeks. ok is false if no previous week observed any of those slots.
+func (h *startHistory) Forecast(t time.Time, d time.Duration) (starts float64, ok bool) {
+	end := t.Add(d)
+	for c := weekStart(t).Add(time.Duration(h.slot(t)) * h.SlotSize); c.Before(end); c = c.Add(h.SlotSize) {
+		var (
+			s       = h.slot(c)
+			current = weekStart(c)
+			sum, n  int
+		)
+		for _, w := range h.Weeks {
+			if !w.Start.Before(current) || len(w.Starts) <= s || w.Starts[s] < 0 {
+				continue
+			}
+			sum += w.Starts[s]
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		starts += float64(sum) / float64(n)
+		ok = true
+	}
+	return starts, ok
+}
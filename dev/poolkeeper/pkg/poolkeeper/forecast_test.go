@@ -0,0 +1,322 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package poolkeeper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// slots describes a history as the observed slots per week, keyed by the date of the week's Monday
+type slots map[string]map[int]int
+
+func newTestHistory(t *testing.T, s slots) *startHistory {
+	h := newStartHistory(15 * time.Minute)
+	for week, starts := range s {
+		start, err := time.Parse("2006-01-02", week)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := h.week(start, true)
+		for slot, n := range starts {
+			w.Starts[slot] = n
+		}
+	}
+	return h
+}
+
+func observedSlots(h *startHistory) slots {
+	res := make(slots)
+	for _, w := range h.Weeks {
+		starts := make(map[int]int)
+		for slot, n := range w.Starts {
+			if n < 0 {
+				continue
+			}
+			starts[slot] = n
+		}
+		res[w.Start.Format("2006-01-02")] = starts
+	}
+	return res
+}
+
+// monday is Monday 00:00 UTC
+var monday = time.Date(2022, 5, 9, 0, 0, 0, 0, time.UTC)
+
+func TestStartHistoryRecord(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Starts      []time.Time
+		Expectation slots
+	}{
+		{
+			Name:        "single start",
+			Starts:      []time.Time{monday.Add(5 * time.Minute)},
+			Expectation: slots{"2022-05-09": {0: 1}},
+		},
+		{
+			Name:        "same slot",
+			Starts:      []time.Time{monday.Add(5 * time.Minute), monday.Add(14 * time.Minute)},
+			Expectation: slots{"2022-05-09": {0: 2}},
+		},
+		{
+			Name:        "end of the week",
+			Starts:      []time.Time{monday.Add(week - time.Minute)},
+			Expectation: slots{"2022-05-09": {671: 1}},
+		},
+		{
+			Name:   "different weeks",
+			Starts: []time.Time{monday.Add(time.Hour), monday.Add(-week + 20*time.Minute)},
+			Expectation: slots{
+				"2022-05-02": {1: 1},
+				"2022-05-09": {4: 1},
+			},
+		},
+		{
+			Name:        "non-UTC time",
+			Starts:      []time.Time{monday.In(time.FixedZone("CEST", 2*60*60))},
+			Expectation: slots{"2022-05-09": {0: 1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			h := newStartHistory(15 * time.Minute)
+			for _, s := range test.Starts {
+				h.Record(s)
+			}
+
+			if diff := cmp.Diff(test.Expectation, observedSlots(h)); diff != "" {
+				t.Errorf("unexpected history (-want +got):\n%s", diff)
+			}
+			for i := 1; i < len(h.Weeks); i++ {
+				if !h.Weeks[i-1].Start.Before(h.Weeks[i].Start) {
+					t.Errorf("weeks are not sorted: %v before %v", h.Weeks[i-1].Start, h.Weeks[i].Start)
+				}
+			}
+		})
+	}
+}
+
+func TestStartHistoryObserve(t *testing.T) {
+	tests := []struct {
+		Name        string
+		History     slots
+		Observed    time.Time
+		Observe     time.Time
+		Expectation slots
+	}{
+		{
+			Name:        "first observation",
+			Observe:     monday.Add(20 * time.Minute),
+			Expectation: slots{"2022-05-09": {1: 0}},
+		},
+		{
+			Name:        "since last observation",
+			Observed:    monday.Add(5 * time.Minute),
+			Observe:     monday.Add(50 * time.Minute),
+			Expectation: slots{"2022-05-09": {0: 0, 1: 0, 2: 0, 3: 0}},
+		},
+		{
+			Name:        "keeps recorded starts",
+			History:     slots{"2022-05-09": {1: 3}},
+			Observed:    monday,
+			Observe:     monday.Add(30 * time.Minute),
+			Expectation: slots{"2022-05-09": {0: 0, 1: 3, 2: 0}},
+		},
+		{
+			Name:     "across weeks",
+			Observed: monday.Add(-10 * time.Minute),
+			Observe:  monday.Add(10 * time.Minute),
+			Expectation: slots{
+				"2022-05-02": {671: 0},
+				"2022-05-09": {0: 0},
+			},
+		},
+		{
+			Name:        "last observation in the future",
+			Observed:    monday.Add(time.Hour),
+			Observe:     monday,
+			Expectation: slots{"2022-05-09": {0: 0}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			h := newTestHistory(t, test.History)
+			h.Observed = test.Observed
+			h.Observe(test.Observe)
+
+			if diff := cmp.Diff(test.Expectation, observedSlots(h)); diff != "" {
+				t.Errorf("unexpected history (-want +got):\n%s", diff)
+			}
+			if !h.Observed.Equal(test.Observe) {
+				t.Errorf("unexpected observation time: expected %v, got %v", test.Observe, h.Observed)
+			}
+		})
+	}
+}
+
+func TestStartHistoryPrune(t *testing.T) {
+	history := slots{
+		"2022-04-18": {0: 1},
+		"2022-04-25": {0: 2},
+		"2022-05-02": {0: 3},
+		"2022-05-09": {0: 4},
+	}
+	tests := []struct {
+		Name        string
+		History     slots
+		Keep        int
+		Expectation slots
+	}{
+		{
+			Name:    "keep two weeks",
+			History: history,
+			Keep:    2,
+			Expectation: slots{
+				"2022-04-25": {0: 2},
+				"2022-05-02": {0: 3},
+				"2022-05-09": {0: 4},
+			},
+		},
+		{
+			Name:        "keep the current week only",
+			History:     history,
+			Keep:        0,
+			Expectation: slots{"2022-05-09": {0: 4}},
+		},
+		{
+			Name:        "history shorter than kept",
+			History:     history,
+			Keep:        10,
+			Expectation: history,
+		},
+		{
+			Name:        "empty history",
+			Keep:        2,
+			Expectation: slots{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			h := newTestHistory(t, test.History)
+			h.Prune(monday.Add(36*time.Hour), test.Keep)
+
+			if diff := cmp.Diff(test.Expectation, observedSlots(h)); diff != "" {
+				t.Errorf("unexpected history (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStartHistoryForecast(t *testing.T) {
+	tests := []struct {
+		Name      string
+		History   slots
+		Time      time.Time
+		Lookahead time.Duration
+		Starts    float64
+		OK        bool
+	}{
+		{
+			Name:      "no history",
+			Time:      monday,
+			Lookahead: 15 * time.Minute,
+		},
+		{
+			Name: "unobserved slots only",
+			History: slots{
+				"2022-05-02": {},
+			},
+			Time:      monday,
+			Lookahead: 15 * time.Minute,
+		},
+		{
+			Name: "mean of the previous weeks",
+			History: slots{
+				"2022-04-25": {0: 4},
+				"2022-05-02": {0: 2},
+			},
+			Time:      monday,
+			Lookahead: 15 * time.Minute,
+			Starts:    3,
+			OK:        true,
+		},
+		{
+			Name: "ignores unobserved slots",
+			History: slots{
+				"2022-04-25": {0: 4},
+				"2022-05-02": {1: 2},
+			},
+			Time:      monday,
+			Lookahead: 15 * time.Minute,
+			Starts:    4,
+			OK:        true,
+		},
+		{
+			Name: "ignores the current week",
+			History: slots{
+				"2022-05-02": {0: 2},
+				"2022-05-09": {0: 10},
+			},
+			Time:      monday,
+			Lookahead: 15 * time.Minute,
+			Starts:    2,
+			OK:        true,
+		},
+		{
+			Name: "sums the slots within the lookahead",
+			History: slots{
+				"2022-05-02": {0: 1, 1: 2, 2: 4},
+			},
+			Time:      monday,
+			Lookahead: 30 * time.Minute,
+			Starts:    3,
+			OK:        true,
+		},
+		{
+			Name: "includes the current slot",
+			History: slots{
+				"2022-05-02": {0: 1, 1: 2, 2: 4},
+			},
+			Time:      monday.Add(10 * time.Minute),
+			Lookahead: 15 * time.Minute,
+			Starts:    3,
+			OK:        true,
+		},
+		{
+			Name: "history shorter than the lookahead",
+			History: slots{
+				"2022-05-02": {0: 2},
+			},
+			Time:      monday,
+			Lookahead: time.Hour,
+			Starts:    2,
+			OK:        true,
+		},
+		{
+			Name: "lookahead into the next week",
+			History: slots{
+				"2022-05-02": {671: 1},
+				"2022-05-09": {0: 5},
+			},
+			Time:      monday.Add(week - 10*time.Minute),
+			Lookahead: 20 * time.Minute,
+			Starts:    6,
+			OK:        true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			h := newTestHistory(t, test.History)
+			starts, ok := h.Forecast(test.Time, test.Lookahead)
+
+			if starts != test.Starts || ok != test.OK {
+				t.Errorf("unexpected forecast: expected %v (ok %v), got %v (ok %v)", test.Starts, test.OK, starts, ok)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 )
@@ -18,19 +19,29 @@ type PoolKeeper struct {
 	Clientset *kubernetes.Clientset
 	Config    *Config
 
+	scaleAheadMetrics *scaleAheadMetrics
+
 	stop chan struct{}
 	done chan struct{}
 }
 
 // NewPoolKeeper creates a new PoolKeeper instance
-func NewPoolKeeper(clientset *kubernetes.Clientset, config *Config) *PoolKeeper {
+func NewPoolKeeper(clientset *kubernetes.Clientset, config *Config, reg prometheus.Registerer) (*PoolKeeper, error) {
+	metrics := newScaleAheadMetrics()
+	err := reg.Register(metrics)
+	if err != nil {
+		return nil, err
+	}
+
 	return &PoolKeeper{
 		Clientset: clientset,
 		Config:    config,
 
+		scaleAheadMetrics: metrics,
+
 		stop: make(chan struct{}, 1),
 		done: make(chan struct{}, 1),
-	}
+	}, nil
 }
 
 // Start starts the PoolKeeper and is meant to be run in a goroutine
@@ -41,6 +52,14 @@ func (pk *PoolKeeper) Start() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	for _, task := range pk.Config.Tasks {
+		if task.ScaleAhead != nil {
+			err := task.ScaleAhead.start(ctx, pk.Clientset, task.Name, pk.scaleAheadMetrics)
+			if err != nil {
+				log.WithError(err).WithField("task", task.Name).Error("cannot start task")
+				continue
+			}
+		}
+
 		go func(ctx context.Context, task *Task) {
 			ticker := time.NewTicker(time.Duration(task.Interval))
 			for {
@@ -49,6 +68,8 @@ func (pk *PoolKeeper) Start() {
 					task.PatchDeploymentAffinity.run(pk.Clientset)
 				} else if task.KeepNodeAlive != nil {
 					task.KeepNodeAlive.run(pk.Clientset, time.Now())
+				} else if task.ScaleAhead != nil {
+					task.ScaleAhead.run(pk.Clientset, time.Now())
 				}
 				log.WithField("task", task.Name).Infof("task done.")
 
@@ -0,0 +1,566 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package poolkeeper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	common_grpc "github.com/gitpod-io/gitpod/common-go/grpc"
+	"github.com/gitpod-io/gitpod/common-go/util"
+	wsmanapi "github.com/gitpod-io/gitpod/ws-manager/api"
+)
+
+const (
+	scaleAheadStateConfigMap    = "poolkeeper-scale-ahead"
+	scaleAheadMarkerLabel       = "poolkeeper/scaleAhead"
+	scaleAheadConfigAnnotation  = "poolkeeper/placeholderConfig"
+	scaleAheadDefaultDeployment = "poolkeeper-scale-ahead"
+
+	// placeholders only reserve resources, they need not do anything
+	placeholderImage = "k8s.gcr.io/pause:3.6"
+)
+
+// ScaleAhead keeps capacity for the workspaces it expects to start soon. It learns the weekly pattern of
+// workspace starts from ws-manager and keeps placeholder pods with a low priority, which workspaces preempt.
+// Preempted placeholders become pending, which makes the cluster autoscaler add nodes before they're needed.
+type ScaleAhead struct {
+	// WorkspaceManager is the ws-manager we learn the workspace starts from
+	WorkspaceManager WorkspaceManagerConn `json:"wsManager"`
+
+	// Namespace to keep the placeholders and the learned history in
+	Namespace string `json:"namespace,omitempty"`
+
+	// SlotSize is the granularity of the weekly pattern. Must divide a day. Defaults to 15m.
+	SlotSize util.Duration `json:"slotSize,omitempty"`
+
+	// History is the number of past weeks the forecast is based on. Defaults to 4.
+	History int `json:"history,omitempty"`
+
+	// Lookahead is how far ahead we keep capacity, i.e. roughly the time it takes to add a node. Defaults to 15m.
+	Lookahead util.Duration `json:"lookahead,omitempty"`
+
+	// Headroom is added to the forecast, e.g. 0.2 keeps capacity for 20% more workspaces than expected
+	Headroom float64 `json:"headroom,omitempty"`
+
+	// Placeholder configures the pods that keep capacity free
+	Placeholder Placeholder `json:"placeholder"`
+
+	// DryRun learns and exports the forecast, but does not touch the placeholders
+	DryRun bool `json:"dryRun,omitempty"`
+
+	name      string
+	metrics   *scaleAheadMetrics
+	mu        sync.Mutex
+	history   *startHistory
+	known     map[string]struct{}
+	connected bool
+}
+
+// WorkspaceManagerConn configures the connection to ws-manager
+type WorkspaceManagerConn struct {
+	Addr string `json:"addr"`
+	TLS  struct {
+		CA   string `json:"ca"`
+		Cert string `json:"crt"`
+		Key  string `json:"key"`
+	} `json:"tls"`
+}
+
+// Placeholder configures the placeholder pods of a ScaleAhead task
+type Placeholder struct {
+	// Name of the placeholder deployment. Defaults to poolkeeper-scale-ahead.
+	Name string `json:"name,omitempty"`
+
+	// Workspaces is the number of workspaces a placeholder keeps capacity for. Placeholders which request
+	// the allocatable resources of a node keep whole nodes warm. Defaults to 1.
+	Workspaces int `json:"workspaces,omitempty"`
+
+	// Resources are the resources of a placeholder, e.g. those of Workspaces workspaces
+	Resources corev1.ResourceRequirements `json:"resources"`
+
+	// NodeSelector and Tolerations must match the nodes workspaces run on
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// PriorityClassName names a priority class lower than that of workspaces, so that workspaces preempt placeholders
+	PriorityClassName string `json:"priorityClassName"`
+
+	// Min and Max bound the number of placeholders. Max 0 means unbounded.
+	Min int `json:"min,omitempty"`
+	Max int `json:"max,omitempty"`
+}
+
+type scaleAheadMetrics struct {
+	ForecastStarts      *prometheus.GaugeVec
+	ForecastKnown       *prometheus.GaugeVec
+	PlaceholdersDesired *prometheus.GaugeVec
+	PlaceholdersReady   *prometheus.GaugeVec
+	WorkspaceStarts     *prometheus.CounterVec
+	Connected           *prometheus.GaugeVec
+}
+
+func newScaleAheadMetrics() *scaleAheadMetrics {
+	return &scaleAheadMetrics{
+		ForecastStarts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "scale_ahead_forecast_starts",
+			Help: "Workspace starts expected within the lookahead of a scale-ahead task, without headroom",
+		}, []string{"task"}),
+		ForecastKnown: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "scale_ahead_forecast_known",
+			Help: "1 if the history of a scale-ahead task covers its lookahead, 0 otherwise",
+		}, []string{"task"}),
+		PlaceholdersDesired: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "scale_ahead_placeholders_desired",
+			Help: "Placeholders a scale-ahead task wants to keep, also in dry-run mode",
+		}, []string{"task"}),
+		PlaceholdersReady: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "scale_ahead_placeholders_ready",
+			Help: "Placeholders which are running, i.e. capacity that is available right away",
+		}, []string{"task"}),
+		WorkspaceStarts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "scale_ahead_workspace_starts_total",
+			Help: "Workspace starts a scale-ahead task learned from",
+		}, []string{"task"}),
+		Connected: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "scale_ahead_connected",
+			Help: "1 if a scale-ahead task is subscribed to ws-manager, 0 otherwise",
+		}, []string{"task"}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *scaleAheadMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.ForecastStarts.Describe(ch)
+	m.ForecastKnown.Describe(ch)
+	m.PlaceholdersDesired.Describe(ch)
+	m.PlaceholdersReady.Describe(ch)
+	m.WorkspaceStarts.Describe(ch)
+	m.Connected.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *scaleAheadMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.ForecastStarts.Collect(ch)
+	m.ForecastKnown.Collect(ch)
+	m.PlaceholdersDesired.Collect(ch)
+	m.PlaceholdersReady.Collect(ch)
+	m.WorkspaceStarts.Collect(ch)
+	m.Connected.Collect(ch)
+}
+
+func (sa *ScaleAhead) applyDefaults() {
+	if sa.Namespace == "" {
+		sa.Namespace = "default"
+	}
+	if sa.SlotSize == 0 {
+		sa.SlotSize = util.Duration(15 * time.Minute)
+	}
+	if sa.History == 0 {
+		sa.History = 4
+	}
+	if sa.Lookahead == 0 {
+		sa.Lookahead = util.Duration(15 * time.Minute)
+	}
+	if sa.Placeholder.Name == "" {
+		sa.Placeholder.Name = scaleAheadDefaultDeployment
+	}
+	if sa.Placeholder.Workspaces == 0 {
+		sa.Placeholder.Workspaces = 1
+	}
+}
+
+func (sa *ScaleAhead) validate() error {
+	slotSize := time.Duration(sa.SlotSize)
+	if slotSize <= 0 || (24*time.Hour)%slotSize != 0 {
+		return fmt.Errorf("slotSize must divide a day")
+	}
+	if sa.History < 0 {
+		return fmt.Errorf("history must not be negative")
+	}
+	if sa.Lookahead < 0 {
+		return fmt.Errorf("lookahead must not be negative")
+	}
+	if sa.Headroom < 0 {
+		return fmt.Errorf("headroom must not be negative")
+	}
+	if sa.Placeholder.Workspaces < 0 {
+		return fmt.Errorf("placeholder.workspaces must not be negative")
+	}
+	if sa.Placeholder.Max > 0 && sa.Placeholder.Max < sa.Placeholder.Min {
+		return fmt.Errorf("placeholder.max must not be less than placeholder.min")
+	}
+	if sa.Placeholder.PriorityClassName == "" {
+		return fmt.Errorf("placeholder.priorityClassName is required - otherwise workspaces cannot preempt placeholders")
+	}
+	if sa.WorkspaceManager.Addr == "" {
+		return fmt.Errorf("wsManager.addr is required")
+	}
+	return nil
+}
+
+// start loads the learned history and starts learning from ws-manager until ctx is canceled
+func (sa *ScaleAhead) start(ctx context.Context, clientset kubernetes.Interface, name string, metrics *scaleAheadMetrics) error {
+	sa.applyDefaults()
+	err := sa.validate()
+	if err != nil {
+		return err
+	}
+	sa.name = name
+	sa.metrics = metrics
+	sa.known = make(map[string]struct{})
+
+	sa.history, err = sa.loadHistory(clientset)
+	if err != nil {
+		return err
+	}
+
+	grpcOpts := common_grpc.DefaultClientOptions()
+	if tls := sa.WorkspaceManager.TLS; tls.CA != "" && tls.Cert != "" && tls.Key != "" {
+		tlsConfig, err := common_grpc.ClientAuthTLSConfig(
+			tls.CA, tls.Cert, tls.Key,
+			common_grpc.WithSetRootCAs(true),
+			common_grpc.WithServerName("ws-manager"),
+		)
+		if err != nil {
+			return fmt.Errorf("cannot load ws-manager certs: %w", err)
+		}
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		grpcOpts = append(grpcOpts, grpc.WithInsecure())
+	}
+	conn, err := grpc.Dial(sa.WorkspaceManager.Addr, grpcOpts...)
+	if err != nil {
+		return fmt.Errorf("cannot connect to ws-manager: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	go sa.learn(ctx, wsmanapi.NewWorkspaceManagerClient(conn))
+	return nil
+}
+
+// learn counts workspace starts until ctx is canceled and reconnects to ws-manager if necessary
+func (sa *ScaleAhead) learn(ctx context.Context, client wsmanapi.WorkspaceManagerClient) {
+	for {
+		err := sa.subscribe(ctx, client)
+		sa.setConnected(false)
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).WithField("task", sa.name).Warn("lost ws-manager subscription - reconnecting")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (sa *ScaleAhead) subscribe(ctx context.Context, client wsmanapi.WorkspaceManagerClient) error {
+	sub, err := client.Subscribe(ctx, &wsmanapi.SubscribeRequest{})
+	if err != nil {
+		return err
+	}
+
+	// Workspaces which started after the last observation, but before we subscribed, are still running
+	// and count as well. Earlier ones have been counted already, possibly by a previous poolkeeper.
+	sa.mu.Lock()
+	since := sa.history.Observed
+	sa.mu.Unlock()
+	wss, err := client.GetWorkspaces(ctx, &wsmanapi.GetWorkspacesRequest{})
+	if err != nil {
+		return err
+	}
+	for _, s := range wss.Status {
+		sa.seen(s, since)
+	}
+
+	// We missed the workspaces which started and stopped while we were not subscribed. The slots of that
+	// period stay unobserved, unless they contain one of the workspaces above.
+	sa.mu.Lock()
+	sa.history.Observed = time.Now()
+	sa.mu.Unlock()
+	sa.setConnected(true)
+	log.WithField("task", sa.name).WithField("workspaces", len(wss.Status)).Info("subscribed to ws-manager")
+
+	for {
+		resp, err := sub.Recv()
+		if err != nil {
+			return err
+		}
+		if s := resp.GetStatus(); s != nil {
+			sa.seen(s, since)
+		}
+	}
+}
+
+// seen records the start of a workspace the first time we see it
+func (sa *ScaleAhead) seen(s *wsmanapi.WorkspaceStatus, since time.Time) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	if s.Phase == wsmanapi.WorkspacePhase_STOPPED {
+		delete(sa.known, s.Id)
+		return
+	}
+	if _, ok := sa.known[s.Id]; ok {
+		return
+	}
+	sa.known[s.Id] = struct{}{}
+
+	started := time.Now()
+	if ts := s.GetMetadata().GetStartedAt(); ts != nil {
+		started = ts.AsTime()
+	}
+	if !started.After(since) {
+		return
+	}
+	sa.history.Record(started)
+	sa.metrics.WorkspaceStarts.WithLabelValues(sa.name).Inc()
+}
+
+func (sa *ScaleAhead) setConnected(connected bool) {
+	sa.mu.Lock()
+	sa.connected = connected
+	sa.mu.Unlock()
+
+	var v float64
+	if connected {
+		v = 1
+	}
+	sa.metrics.Connected.WithLabelValues(sa.name).Set(v)
+}
+
+func (sa *ScaleAhead) run(clientset kubernetes.Interface, t time.Time) {
+	sa.mu.Lock()
+	if sa.connected {
+		sa.history.Observe(t)
+	}
+	sa.history.Prune(t, sa.History)
+	state, err := json.Marshal(sa.history)
+	forecast, ok := sa.history.Forecast(t, time.Duration(sa.Lookahead))
+	sa.mu.Unlock()
+
+	if err != nil {
+		log.WithError(err).Error("cannot marshal scale-ahead history")
+	} else {
+		err = sa.saveHistory(clientset, state)
+		if err != nil {
+			log.WithError(err).Error("cannot save scale-ahead history")
+		}
+	}
+
+	desired := sa.placeholders(forecast, ok)
+	var known float64
+	if ok {
+		known = 1
+	}
+	sa.metrics.ForecastStarts.WithLabelValues(sa.name).Set(forecast)
+	sa.metrics.ForecastKnown.WithLabelValues(sa.name).Set(known)
+	sa.metrics.PlaceholdersDesired.WithLabelValues(sa.name).Set(float64(desired))
+	log.WithField("forecast", forecast).WithField("known", ok).WithField("placeholders", desired).WithField("lookahead", sa.Lookahead).Info("forecast workspace starts")
+
+	if sa.DryRun {
+		log.WithField("placeholders", desired).Info("dry run - not scaling placeholders")
+		return
+	}
+	err = sa.scalePlaceholders(clientset, desired)
+	if err != nil {
+		log.WithError(err).Error("cannot scale placeholders")
+	}
+}
+
+// placeholders computes the number of placeholders which keep enough capacity for the forecast
+func (sa *ScaleAhead) placeholders(forecast float64, ok bool) int {
+	var res int
+	if ok {
+		res = int(math.Ceil(forecast * (1 + sa.Headroom) / float64(sa.Placeholder.Workspaces)))
+	}
+	if res < sa.Placeholder.Min {
+		res = sa.Placeholder.Min
+	}
+	if sa.Placeholder.Max > 0 && res > sa.Placeholder.Max {
+		res = sa.Placeholder.Max
+	}
+	return res
+}
+
+func (sa *ScaleAhead) stateKey() string {
+	return sa.name + ".json"
+}
+
+func (sa *ScaleAhead) loadHistory(clientset kubernetes.Interface) (*startHistory, error) {
+	slotSize := time.Duration(sa.SlotSize)
+	cm, err := clientset.CoreV1().ConfigMaps(sa.Namespace).Get(context.Background(), scaleAheadStateConfigMap, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return newStartHistory(slotSize), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot load scale-ahead history: %w", err)
+	}
+	state, ok := cm.Data[sa.stateKey()]
+	if !ok {
+		return newStartHistory(slotSize), nil
+	}
+
+	var res startHistory
+	err = json.Unmarshal([]byte(state), &res)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal scale-ahead history: %w", err)
+	}
+	if res.SlotSize != slotSize {
+		log.WithField("task", sa.name).WithField("slotSize", sa.SlotSize).Warn("slot size changed - discarding the learned history")
+		return newStartHistory(slotSize), nil
+	}
+	return &res, nil
+}
+
+func (sa *ScaleAhead) saveHistory(clientset kubernetes.Interface, state []byte) error {
+	configMaps := clientset.CoreV1().ConfigMaps(sa.Namespace)
+	cm, err := configMaps.Get(context.Background(), scaleAheadStateConfigMap, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(context.Background(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: scaleAheadStateConfigMap,
+				Labels: map[string]string{
+					scaleAheadMarkerLabel: "true",
+				},
+			},
+			Data: map[string]string{
+				sa.stateKey(): string(state),
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[sa.stateKey()] = string(state)
+	_, err = configMaps.Update(context.Background(), cm, metav1.UpdateOptions{})
+	return err
+}
+
+// scalePlaceholders creates the placeholder deployment or scales it to the desired replicas
+func (sa *ScaleAhead) scalePlaceholders(clientset kubernetes.Interface, replicas int) error {
+	desired, err := sa.placeholderDeployment(int32(replicas))
+	if err != nil {
+		return err
+	}
+
+	deployments := clientset.AppsV1().Deployments(sa.Namespace)
+	current, err := deployments.Get(context.Background(), desired.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = deployments.Create(context.Background(), desired, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		log.WithField("deployment", desired.Name).WithField("replicas", replicas).Info("created placeholders")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sa.metrics.PlaceholdersReady.WithLabelValues(sa.name).Set(float64(current.Status.ReadyReplicas))
+
+	if current.Annotations[scaleAheadConfigAnnotation] != desired.Annotations[scaleAheadConfigAnnotation] {
+		if current.Annotations == nil {
+			current.Annotations = make(map[string]string)
+		}
+		current.Annotations[scaleAheadConfigAnnotation] = desired.Annotations[scaleAheadConfigAnnotation]
+		current.Spec.Replicas = desired.Spec.Replicas
+		current.Spec.Template = desired.Spec.Template
+		_, err = deployments.Update(context.Background(), current, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		log.WithField("deployment", desired.Name).WithField("replicas", replicas).Info("updated placeholders")
+		return nil
+	}
+
+	if current.Spec.Replicas != nil && *current.Spec.Replicas == int32(replicas) {
+		return nil
+	}
+	patch := fmt.Sprintf(`{"spec": {"replicas": %d}}`, replicas)
+	_, err = deployments.Patch(context.Background(), desired.Name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	log.WithField("deployment", desired.Name).WithField("replicas", replicas).Info("scaled placeholders")
+	return nil
+}
+
+func (sa *ScaleAhead) placeholderDeployment(replicas int32) (*appsv1.Deployment, error) {
+	// the annotation tells us whether we need to update the deployment after a config change
+	cfg, err := json.Marshal(sa.Placeholder)
+	if err != nil {
+		return nil, err
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(cfg))
+
+	var (
+		uid65534 = int64(65534)
+		labels   = map[string]string{
+			scaleAheadMarkerLabel: "true",
+			"app":                 sa.Placeholder.Name,
+		}
+	)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   sa.Placeholder.Name,
+			Labels: labels,
+			Annotations: map[string]string{
+				scaleAheadConfigAnnotation: hash,
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:      "placeholder",
+							Image:     placeholderImage,
+							Resources: sa.Placeholder.Resources,
+							SecurityContext: &corev1.SecurityContext{
+								RunAsUser: &uid65534,
+							},
+						},
+					},
+					NodeSelector:                  sa.Placeholder.NodeSelector,
+					Tolerations:                   sa.Placeholder.Tolerations,
+					PriorityClassName:             sa.Placeholder.PriorityClassName,
+					TerminationGracePeriodSeconds: new(int64),
+					EnableServiceLinks:            new(bool),
+				},
+			},
+		},
+	}, nil
+}
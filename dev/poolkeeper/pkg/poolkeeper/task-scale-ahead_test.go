@@ -0,0 +1,169 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the Gitpod Enterprise Source Code License,
+// See License.enterprise.txt in the project root folder.
+
+package poolkeeper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestScaleAheadPlaceholders(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Headroom    float64
+		Placeholder Placeholder
+		Forecast    float64
+		OK          bool
+		Expectation int
+	}{
+		{
+			Name:        "unknown forecast",
+			Placeholder: Placeholder{Workspaces: 1},
+			Forecast:    10,
+			Expectation: 0,
+		},
+		{
+			Name:        "unknown forecast with min",
+			Placeholder: Placeholder{Workspaces: 1, Min: 2},
+			Expectation: 2,
+		},
+		{
+			Name:        "rounds up",
+			Placeholder: Placeholder{Workspaces: 1},
+			Forecast:    2.1,
+			OK:          true,
+			Expectation: 3,
+		},
+		{
+			Name:        "headroom",
+			Headroom:    0.5,
+			Placeholder: Placeholder{Workspaces: 1},
+			Forecast:    10,
+			OK:          true,
+			Expectation: 15,
+		},
+		{
+			Name:        "workspaces per placeholder",
+			Placeholder: Placeholder{Workspaces: 4},
+			Forecast:    10,
+			OK:          true,
+			Expectation: 3,
+		},
+		{
+			Name:        "headroom and workspaces per placeholder",
+			Headroom:    0.5,
+			Placeholder: Placeholder{Workspaces: 4},
+			Forecast:    10,
+			OK:          true,
+			Expectation: 4,
+		},
+		{
+			Name:        "below min",
+			Placeholder: Placeholder{Workspaces: 1, Min: 3},
+			Forecast:    1,
+			OK:          true,
+			Expectation: 3,
+		},
+		{
+			Name:        "above max",
+			Headroom:    0.5,
+			Placeholder: Placeholder{Workspaces: 1, Min: 1, Max: 5},
+			Forecast:    10,
+			OK:          true,
+			Expectation: 5,
+		},
+		{
+			Name:        "unbounded",
+			Placeholder: Placeholder{Workspaces: 1, Min: 1},
+			Forecast:    100,
+			OK:          true,
+			Expectation: 100,
+		},
+		{
+			Name:        "min equals max",
+			Placeholder: Placeholder{Workspaces: 1, Min: 2, Max: 2},
+			Forecast:    10,
+			OK:          true,
+			Expectation: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sa := &ScaleAhead{Headroom: test.Headroom, Placeholder: test.Placeholder}
+			act := sa.placeholders(test.Forecast, test.OK)
+
+			if act != test.Expectation {
+				t.Errorf("unexpected placeholders: expected %d, got %d", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestScaleAheadRun(t *testing.T) {
+	tests := []struct {
+		Name   string
+		DryRun bool
+	}{
+		{Name: "scales placeholders"},
+		{Name: "dry run", DryRun: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sa := &ScaleAhead{
+				WorkspaceManager: WorkspaceManagerConn{Addr: "ws-manager:8080"},
+				Placeholder: Placeholder{
+					PriorityClassName: "placeholder",
+					Min:               2,
+				},
+				DryRun: test.DryRun,
+			}
+			sa.applyDefaults()
+			err := sa.validate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sa.name = "test"
+			sa.metrics = newScaleAheadMetrics()
+			sa.history = newStartHistory(time.Duration(sa.SlotSize))
+
+			clientset := fake.NewSimpleClientset()
+			sa.run(clientset, monday)
+
+			if desired := testutil.ToFloat64(sa.metrics.PlaceholdersDesired.WithLabelValues(sa.name)); desired != 2 {
+				t.Errorf("unexpected desired placeholders: expected 2, got %v", desired)
+			}
+
+			cm, err := clientset.CoreV1().ConfigMaps(sa.Namespace).Get(context.Background(), scaleAheadStateConfigMap, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("history was not saved: %v", err)
+			}
+			if _, ok := cm.Data[sa.stateKey()]; !ok {
+				t.Errorf("history was not saved under %s", sa.stateKey())
+			}
+
+			deployments, err := clientset.AppsV1().Deployments(sa.Namespace).List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.DryRun {
+				if len(deployments.Items) != 0 {
+					t.Errorf("dry run created %d placeholder deployments", len(deployments.Items))
+				}
+				return
+			}
+			if len(deployments.Items) != 1 {
+				t.Fatalf("expected one placeholder deployment, got %d", len(deployments.Items))
+			}
+			if replicas := deployments.Items[0].Spec.Replicas; replicas == nil || *replicas != 2 {
+				t.Errorf("unexpected placeholder replicas: expected 2, got %v", replicas)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var configMigrateOpts struct {
+	Config  string
+	Version string
+	Write   bool
+}
+
+// configMigrateCmd represents the config migrate command
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrates a config file to a newer version",
+	Long: `Migrates a config file to a newer version
+
+Rewrites the config file to the target version, moving deprecated fields to
+their replacement along the way. Comments are preserved where possible.
+The migrated config is printed to stdout, unless --write is set. Deprecated
+fields which could not be migrated are reported on stderr.`,
+	Example: `  # Print the migrated config
+  gitpod-installer config migrate --config gitpod.config.yaml
+
+  # Migrate the config file in place
+  gitpod-installer config migrate --config gitpod.config.yaml --write`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var (
+			in  []byte
+			err error
+		)
+		switch configMigrateOpts.Config {
+		case "":
+			return fmt.Errorf("missing --config")
+		case "-":
+			if configMigrateOpts.Write {
+				return fmt.Errorf("cannot write config read from stdin")
+			}
+			in, err = io.ReadAll(os.Stdin)
+		default:
+			in, err = ioutil.ReadFile(configMigrateOpts.Config)
+		}
+		if err != nil {
+			return err
+		}
+
+		out, report, err := config.Migrate(in, configMigrateOpts.Version)
+		if err != nil {
+			return err
+		}
+
+		// the migrated config must still load, otherwise we'd leave users with a broken config
+		_, _, err = config.Load(string(out))
+		if err != nil {
+			return fmt.Errorf("migrated config is invalid: %w", err)
+		}
+
+		printMigrationReport(os.Stderr, report)
+
+		if configMigrateOpts.Write {
+			fi, err := os.Stat(configMigrateOpts.Config)
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(configMigrateOpts.Config, out, fi.Mode().Perm())
+		}
+		_, err = os.Stdout.Write(out)
+		return err
+	},
+}
+
+func printMigrationReport(out io.Writer, report *config.MigrationReport) {
+	if report.From != report.To {
+		fmt.Fprintf(out, "migrated config from %s to %s\n", report.From, report.To)
+	}
+	for _, d := range report.Deprecated {
+		if d.Migrated {
+			fmt.Fprintf(out, "%s: %s is deprecated and was moved to %s: %s\n", d.Version, d.Path, d.Replacement, d.Message)
+			continue
+		}
+		fmt.Fprintf(out, "%s: %s is deprecated: %s\n", d.Version, d.Path, d.Message)
+	}
+	for _, n := range report.Notes {
+		fmt.Fprintf(out, "note: %s\n", n)
+	}
+}
+
+func init() {
+	configCmd.AddCommand(configMigrateCmd)
+
+	configMigrateCmd.Flags().StringVarP(&configMigrateOpts.Config, "config", "c", os.Getenv("GITPOD_INSTALLER_CONFIG"), "path to the config file, use - for stdin")
+	configMigrateCmd.Flags().StringVar(&configMigrateOpts.Version, "version", config.CurrentVersion, "config version to migrate to")
+	configMigrateCmd.Flags().BoolVarP(&configMigrateOpts.Write, "write", "w", false, "write the migrated config back to the config file instead of stdout")
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/diff"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var diffOpts struct {
+	Kube              kubeConfig
+	ConfigFN          string
+	Output            string
+	ShowUnchanged     bool
+	FailOnDestructive bool
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compares the rendered Kubernetes manifests to the live objects in the cluster",
+	Long: `Compares the rendered Kubernetes manifests to the live objects in the cluster
+
+Renders the objects like the render command does and fetches their live
+versions from the cluster. Fields the API server manages or defaults are
+ignored. Objects of a previous installation which are not rendered anymore
+are reported as deletions.
+
+Changes which might lose data or which the API server would reject, e.g.
+updates of immutable fields, are flagged as destructive.`,
+	Example: `  # Compare the config to the cluster of the current kubeconfig context
+  gitpod-installer diff --config config.yaml --namespace gitpod
+
+  # Fail if applying the config would be destructive, e.g. in CI
+  gitpod-installer diff --config config.yaml --fail-on-destructive`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkKubeConfig(&diffOpts.Kube); err != nil {
+			return err
+		}
+
+		_, cfgVersion, cfg, err := loadConfig(diffOpts.ConfigFN)
+		if err != nil {
+			return err
+		}
+		handleExperimentalConfig(cfg)
+
+		rendered, err := renderKubernetesObjects(cfgVersion, cfg)
+		if err != nil {
+			return err
+		}
+		desired, err := diff.ParseManifests(rendered)
+		if err != nil {
+			return err
+		}
+
+		differ, err := newDiffer(diffOpts.Kube.Config, renderOpts.Namespace)
+		if err != nil {
+			return err
+		}
+		diffs, err := differ.Diff(context.Background(), desired)
+		if err != nil {
+			return err
+		}
+
+		switch diffOpts.Output {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(diffs)
+		case "text":
+			err = printDiffs(os.Stdout, diffs, diffOpts.ShowUnchanged)
+		default:
+			err = fmt.Errorf("unknown output format %q, must be text or json", diffOpts.Output)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, destructive := diff.Summary(diffs); destructive > 0 && diffOpts.FailOnDestructive {
+			return fmt.Errorf("found %d destructive changes", destructive)
+		}
+		return nil
+	},
+}
+
+func newDiffer(kubeconfig, namespace string) (*diff.Differ, error) {
+	clientcfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{},
+	)
+	restConfig, err := clientcfg.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &diff.Differ{
+		Client:    client,
+		Mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+		Namespace: namespace,
+	}, nil
+}
+
+var diffActionSymbol = map[diff.Action]string{
+	diff.ActionCreate:    "+",
+	diff.ActionUpdate:    "~",
+	diff.ActionDelete:    "-",
+	diff.ActionUnchanged: "=",
+}
+
+func printDiffs(out io.Writer, diffs []diff.ObjectDiff, showUnchanged bool) error {
+	for _, d := range diffs {
+		if d.Action == diff.ActionUnchanged && !showUnchanged {
+			continue
+		}
+
+		fmt.Fprintf(out, "%s %s (%s)\n", diffActionSymbol[d.Action], d.ID(), d.Action)
+		if d.Note != "" {
+			fmt.Fprintf(out, "    note: %s\n", d.Note)
+		}
+		for _, c := range d.Changes {
+			printFieldChange(out, c)
+		}
+		for _, r := range d.Destructive {
+			fmt.Fprintf(out, "    DESTRUCTIVE: %s\n", r)
+		}
+	}
+
+	actions, destructive := diff.Summary(diffs)
+	_, err := fmt.Fprintf(out, "\n%d to create, %d to update, %d to delete, %d unchanged - %d destructive\n",
+		actions[diff.ActionCreate], actions[diff.ActionUpdate], actions[diff.ActionDelete], actions[diff.ActionUnchanged], destructive)
+	return err
+}
+
+func printFieldChange(out io.Writer, c diff.FieldChange) {
+	live, liveMultiline := c.Live.(string)
+	desired, desiredMultiline := c.Desired.(string)
+	if liveMultiline && desiredMultiline && (strings.Contains(live, "\n") || strings.Contains(desired, "\n")) {
+		// e.g. config files in config maps - a line diff is way more useful than two walls of text
+		fmt.Fprintf(out, "    %s:\n", c.Path)
+		lines := cmp.Diff(strings.Split(live, "\n"), strings.Split(desired, "\n"))
+		for _, l := range strings.Split(strings.TrimRight(lines, "\n"), "\n") {
+			fmt.Fprintf(out, "      %s\n", l)
+		}
+		return
+	}
+
+	switch {
+	case c.Live == nil:
+		fmt.Fprintf(out, "    + %s: %s\n", c.Path, formatDiffValue(c.Desired))
+	case c.Desired == nil:
+		fmt.Fprintf(out, "    - %s: %s\n", c.Path, formatDiffValue(c.Live))
+	default:
+		fmt.Fprintf(out, "    ~ %s: %s -> %s\n", c.Path, formatDiffValue(c.Live), formatDiffValue(c.Desired))
+	}
+}
+
+func formatDiffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffOpts.Kube.Config, "kubeconfig", "", "path to the kubeconfig file")
+	diffCmd.Flags().StringVarP(&diffOpts.ConfigFN, "config", "c", os.Getenv("GITPOD_INSTALLER_CONFIG"), "path to the config file, use - for stdin")
+	diffCmd.Flags().StringVarP(&renderOpts.Namespace, "namespace", "n", "default", "namespace Gitpod is deployed to")
+	diffCmd.Flags().BoolVar(&renderOpts.UseExperimentalConfig, "use-experimental-config", false, "enable the use of experimental config that is prone to be changed")
+	diffCmd.Flags().StringVarP(&diffOpts.Output, "output", "o", "text", "output format: text or json")
+	diffCmd.Flags().BoolVar(&diffOpts.ShowUnchanged, "show-unchanged", false, "list unchanged objects, too")
+	diffCmd.Flags().BoolVar(&diffOpts.FailOnDestructive, "fail-on-destructive", false, "exit with an error if any change is destructive")
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/containerd/containerd/platforms"
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"github.com/gitpod-io/gitpod/installer/pkg/mirror"
+	"github.com/spf13/cobra"
+)
+
+var mirrorBundleOpts struct {
+	ConfigFN          string
+	Output            string
+	Platform          string
+	ExcludeThirdParty bool
+	PlainHTTP         bool
+}
+
+// mirrorBundleCmd represents the mirror bundle command
+var mirrorBundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Writes all images required to install Gitpod into a single tarball for air-gapped installations",
+	Long: `Writes all images required to install Gitpod into a single tarball for air-gapped installations
+
+Pulls every image mirror list prints, including the IDE images, and writes them
+into a single OCI image layout tarball. Copy the tarball into the air-gapped
+network and push the images into your registry with mirror push.
+
+Registries are accessed with the credentials of "docker login".`,
+	Example: `  gitpod-installer mirror bundle --config config.yaml --output gitpod-images.tar`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if mirrorBundleOpts.Output == "" {
+			return fmt.Errorf("output is a required flag")
+		}
+		platform, err := platforms.Parse(mirrorBundleOpts.Platform)
+		if err != nil {
+			return err
+		}
+
+		_, cfgVersion, cfg, err := loadConfig(mirrorBundleOpts.ConfigFN)
+		if err != nil {
+			return err
+		}
+		images, err := listMirrorImages(cfgVersion, cfg)
+		if err != nil {
+			return err
+		}
+		if mirrorBundleOpts.ExcludeThirdParty {
+			gitpodImages := make([]string, 0, len(images))
+			for _, img := range images {
+				if strings.Contains(img, common.GitpodContainerRegistry) {
+					gitpodImages = append(gitpodImages, img)
+				}
+			}
+			images = gitpodImages
+		}
+
+		resolver, err := mirror.NewResolver(mirrorBundleOpts.PlainHTTP)
+		if err != nil {
+			return err
+		}
+		workdir, err := os.MkdirTemp("", "gitpod-mirror-bundle")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(workdir)
+
+		out, err := os.Create(mirrorBundleOpts.Output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		err = mirror.Bundle(context.Background(), resolver, images, platform, workdir, out, os.Stderr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "wrote %d images to %s\n", len(images), mirrorBundleOpts.Output)
+
+		return out.Close()
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorBundleCmd)
+
+	mirrorBundleCmd.Flags().StringVarP(&mirrorBundleOpts.ConfigFN, "config", "c", os.Getenv("GITPOD_INSTALLER_CONFIG"), "path to the config file")
+	mirrorBundleCmd.Flags().StringVarP(&mirrorBundleOpts.Output, "output", "o", "", "path of the bundle to write")
+	mirrorBundleCmd.Flags().StringVar(&mirrorBundleOpts.Platform, "platform", "linux/amd64", "platform to bundle multi-platform images for")
+	mirrorBundleCmd.Flags().BoolVar(&mirrorBundleOpts.ExcludeThirdParty, "exclude-third-party", false, "exclude non-Gitpod images")
+	mirrorBundleCmd.Flags().BoolVar(&mirrorBundleOpts.PlainHTTP, "plain-http", false, "access registries without TLS")
+}
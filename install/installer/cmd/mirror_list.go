@@ -5,6 +5,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
@@ -13,9 +14,12 @@ import (
 
 	"github.com/docker/distribution/reference"
 	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"github.com/gitpod-io/gitpod/installer/pkg/components/server/ide"
 	configv1 "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/yaml"
 )
 
 type mirrorListRepo struct {
@@ -206,6 +210,30 @@ func generateMirrorList(cfgVersion string, cfg *configv1.Config) ([]mirrorListRe
 	// Get the target repository from the config
 	targetRepo := strings.TrimRight(cfg.Repository, "/")
 
+	rawImages, err := listMirrorImages(cfgVersion, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]mirrorListRepo, 0)
+	for _, img := range rawImages {
+		target, ok := mirrorTarget(img, targetRepo, mirrorListOpts.ExcludeThirdParty)
+		if !ok {
+			continue
+		}
+
+		images = append(images, mirrorListRepo{
+			Original: img,
+			Target:   target,
+		})
+	}
+
+	return images, nil
+}
+
+// listMirrorImages renders the objects for all dependency setups, and lists the images they use sorted by name.
+// The images are pulled from the default Gitpod registry, regardless of the repository in the config.
+func listMirrorImages(cfgVersion string, cfg *configv1.Config) ([]string, error) {
 	// Use the default Gitpod registry to pull from
 	cfg.Repository = common.GitpodContainerRegistry
 
@@ -214,16 +242,17 @@ func generateMirrorList(cfgVersion string, cfg *configv1.Config) ([]mirrorListRe
 		return nil, err
 	}
 
-	// Map of images used for deduping
-	allImages := make(map[string]bool)
-
 	rawImages := make([]string, 0)
 	for _, item := range k8s {
 		rawImages = append(rawImages, getPodImages(item)...)
 		rawImages = append(rawImages, getGenericImages(item)...)
+		rawImages = append(rawImages, getIDEImages(item)...)
 	}
 
-	images := make([]mirrorListRepo, 0)
+	// Map of images used for deduping
+	allImages := make(map[string]bool)
+
+	images := make([]string, 0)
 	for _, img := range rawImages {
 		// Ignore if the image equals the container registry
 		if img == common.GitpodContainerRegistry {
@@ -235,35 +264,66 @@ func generateMirrorList(cfgVersion string, cfg *configv1.Config) ([]mirrorListRe
 		}
 		allImages[img] = true
 
-		// Convert target
-		target := img
-		if strings.Contains(img, cfg.Repository) {
-			// This is the Gitpod registry
-			target = strings.Replace(target, cfg.Repository, targetRepo, 1)
-		} else if !mirrorListOpts.ExcludeThirdParty {
-			// Amend third-party images - remove the first part
-			thirdPartyImg := strings.Join(strings.Split(img, "/")[1:], "/")
-			target = fmt.Sprintf("%s/%s", targetRepo, thirdPartyImg)
-		} else {
-			// Excluding third-party images - just skip this one
-			continue
-		}
+		images = append(images, img)
+	}
 
-		images = append(images, mirrorListRepo{
-			Original: img,
-			Target:   target,
-		})
+	sort.Strings(images)
+
+	return images, nil
+}
+
+// mirrorTarget converts the name of an image into its name in the target repository. Returns false if the image
+// is a third-party image which should be excluded.
+func mirrorTarget(img string, targetRepo string, excludeThirdParty bool) (string, bool) {
+	if strings.Contains(img, common.GitpodContainerRegistry) {
+		// This is the Gitpod registry
+		return strings.Replace(img, common.GitpodContainerRegistry, targetRepo, 1), true
+	}
+	if excludeThirdParty {
+		// Excluding third-party images - just skip this one
+		return "", false
 	}
 
-	// Sort it by the Original
-	sort.Slice(images, func(i, j int) bool {
-		scoreI := images[i].Original
-		scoreJ := images[j].Original
+	// Amend third-party images - remove the first part
+	thirdPartyImg := strings.Join(strings.Split(img, "/")[1:], "/")
+	return fmt.Sprintf("%s/%s", targetRepo, thirdPartyImg), true
+}
+
+// getIDEImages these are the images of the IDEs users can choose from, which are configured in
+// the IDE config of server
+func getIDEImages(k8sObj string) []string {
+	objs, err := common.YamlToRuntimeObject([]string{k8sObj})
+	if err != nil || len(objs) == 0 {
+		return nil
+	}
+	obj := objs[0]
+	if obj.Kind != "ConfigMap" || obj.Metadata.Name != fmt.Sprintf("%s-ide-config", ide.Component) {
+		return nil
+	}
 
-		return scoreI < scoreJ
-	})
+	var cfgMap corev1.ConfigMap
+	err = yaml.Unmarshal([]byte(obj.Content), &cfgMap)
+	if err != nil {
+		return nil
+	}
+	var ideConfig ide.IDEConfig
+	err = json.Unmarshal([]byte(cfgMap.Data["config.json"]), &ideConfig)
+	if err != nil {
+		return nil
+	}
 
-	return images, nil
+	var images []string
+	if ideConfig.SupervisorImage != "" {
+		images = append(images, ideConfig.SupervisorImage)
+	}
+	for _, opt := range ideConfig.IDEOptions.Options {
+		for _, img := range []string{opt.Image, opt.LatestImage} {
+			if img != "" {
+				images = append(images, img)
+			}
+		}
+	}
+	return images
 }
 
 // getGenericImages this is a bit brute force - anything starting "docker.io" or with Gitpod repo is found
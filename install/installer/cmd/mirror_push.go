@@ -0,0 +1,114 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"github.com/gitpod-io/gitpod/installer/pkg/config"
+	"github.com/gitpod-io/gitpod/installer/pkg/mirror"
+	"github.com/spf13/cobra"
+)
+
+var mirrorPushOpts struct {
+	Bundle     string
+	Repository string
+	ConfigFN   string
+	PlainHTTP  bool
+}
+
+// mirrorPushCmd represents the mirror push command
+var mirrorPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Pushes the images of a bundle into a registry",
+	Long: `Pushes the images of a bundle into a registry
+
+Loads a bundle written by mirror bundle and pushes its images into the
+repository, using the same names as mirror list. If a config file is given
+with --config, its "repository" field is set to the repository, so that Gitpod
+is installed from the pushed images. GITPOD_INSTALLER_CONFIG is ignored, i.e.
+the config file is only rewritten if it is named explicitly.
+
+The registry is accessed with the credentials of "docker login".`,
+	Example: `  gitpod-installer mirror push --bundle gitpod-images.tar --repository registry.example.com/gitpod --config config.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if mirrorPushOpts.Bundle == "" {
+			return fmt.Errorf("bundle is a required flag")
+		}
+		targetRepo := strings.TrimRight(mirrorPushOpts.Repository, "/")
+		if targetRepo == "" {
+			return fmt.Errorf("repository is a required flag")
+		}
+		if targetRepo == common.GitpodContainerRegistry {
+			return fmt.Errorf("cannot mirror images to repository %s", common.GitpodContainerRegistry)
+		}
+
+		workdir, err := os.MkdirTemp("", "gitpod-mirror-push")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(workdir)
+
+		in, err := os.Open(mirrorPushOpts.Bundle)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		pushed, err := mirror.Push(context.Background(), func() (remotes.Resolver, error) {
+			return mirror.NewResolver(mirrorPushOpts.PlainHTTP)
+		}, in, func(original string) (string, bool) {
+			return mirrorTarget(original, targetRepo, false)
+		}, workdir, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		fc, err := common.ToJSONString(pushed)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(fc))
+
+		if mirrorPushOpts.ConfigFN == "" {
+			return nil
+		}
+		fi, err := os.Stat(mirrorPushOpts.ConfigFN)
+		if err != nil {
+			return err
+		}
+		cfg, err := ioutil.ReadFile(mirrorPushOpts.ConfigFN)
+		if err != nil {
+			return err
+		}
+		cfg, err = config.SetValue(cfg, "repository", targetRepo)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(mirrorPushOpts.ConfigFN, cfg, fi.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "set repository of %s to %s\n", mirrorPushOpts.ConfigFN, targetRepo)
+
+		return nil
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorPushCmd)
+
+	mirrorPushCmd.Flags().StringVarP(&mirrorPushOpts.Bundle, "bundle", "b", "", "path of the bundle written by mirror bundle")
+	mirrorPushCmd.Flags().StringVarP(&mirrorPushOpts.Repository, "repository", "r", "", "repository to push the images to")
+	// unlike other commands, we don't default to GITPOD_INSTALLER_CONFIG: push must only rewrite configs users name explicitly
+	mirrorPushCmd.Flags().StringVarP(&mirrorPushOpts.ConfigFN, "config", "c", "", "path to the config file whose repository to rewrite")
+	mirrorPushCmd.Flags().BoolVar(&mirrorPushOpts.PlainHTTP, "plain-http", false, "access the registry without TLS")
+}
@@ -47,14 +47,7 @@ A config file is required which can be generated with the init command.`,
 			return err
 		}
 
-		if cfg.Experimental != nil {
-			if renderOpts.UseExperimentalConfig {
-				fmt.Fprintf(os.Stderr, "rendering using experimental config\n")
-			} else {
-				fmt.Fprintf(os.Stderr, "ignoring experimental config. Use `--use-experimental-config` to include the experimental section in config\n")
-				cfg.Experimental = nil
-			}
-		}
+		handleExperimentalConfig(cfg)
 
 		yaml, err := renderKubernetesObjects(cfgVersion, cfg)
 		if err != nil {
@@ -77,6 +70,19 @@ A config file is required which can be generated with the init command.`,
 	},
 }
 
+// handleExperimentalConfig drops the experimental config section unless --use-experimental-config is set
+func handleExperimentalConfig(cfg *configv1.Config) {
+	if cfg.Experimental == nil {
+		return
+	}
+	if renderOpts.UseExperimentalConfig {
+		fmt.Fprintf(os.Stderr, "rendering using experimental config\n")
+	} else {
+		fmt.Fprintf(os.Stderr, "ignoring experimental config. Use `--use-experimental-config` to include the experimental section in config\n")
+		cfg.Experimental = nil
+	}
+}
+
 func saveYamlToFiles(dir string, yaml []string) error {
 	for i, mf := range yaml {
 		objs, err := common.YamlToRuntimeObject([]string{mf})
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/gitpod-io/gitpod/installer/pkg/cluster"
 	"github.com/gitpod-io/gitpod/installer/pkg/common"
@@ -18,15 +19,33 @@ import (
 )
 
 var validateClusterOpts struct {
-	Kube      kubeConfig
-	Namespace string
-	Config    string
+	Kube         kubeConfig
+	Namespace    string
+	Config       string
+	ProbeNodes   bool
+	ProbeImage   string
+	ProbeTimeout time.Duration
 }
 
 // validateClusterCmd represents the cluster command
 var validateClusterCmd = &cobra.Command{
 	Use:   "cluster",
 	Short: "Validate the cluster setup",
+	Long: `Validate the cluster setup
+
+Checks the cluster, and the services the config refers to, e.g. the object
+storage, container registry and DNS records. The latter are checked from the
+machine the installer runs on.
+
+Some facts of the workspace nodes, like the cgroup version or whether they
+support shiftfs or fuse, are not exposed by Kubernetes. With --probe-nodes,
+a privileged DaemonSet gathers them. It runs on the workspace nodes, mounts
+their root filesystem read-only and is deleted after the validation.`,
+	Example: `  # Validate the cluster and the config
+  gitpod-installer validate cluster --kubeconfig ~/.kube/config --config config.yaml
+
+  # Validate the workspace nodes, too
+  gitpod-installer validate cluster --kubeconfig ~/.kube/config --config config.yaml --probe-nodes`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := checkKubeConfig(&validateClusterOpts.Kube); err != nil {
 			return err
@@ -41,13 +60,25 @@ var validateClusterCmd = &cobra.Command{
 			return err
 		}
 
-		result, err := cluster.ClusterChecks.Validate(context.Background(), res, validateClusterOpts.Namespace)
+		ctx := context.Background()
+		if validateClusterOpts.ProbeNodes {
+			facts, err := cluster.ProbeNodes(ctx, res, validateClusterOpts.Namespace, cluster.ProbeOpts{
+				Image:   validateClusterOpts.ProbeImage,
+				Timeout: validateClusterOpts.ProbeTimeout,
+			})
+			if err != nil {
+				return err
+			}
+			ctx = cluster.WithNodeFacts(ctx, facts)
+		}
+
+		result, err := cluster.ClusterChecks.Validate(ctx, res, validateClusterOpts.Namespace)
 		if err != nil {
 			return err
 		}
 
 		if validateClusterOpts.Config != "" {
-			res, err := runClusterConfigValidation(context.Background(), res, validateClusterOpts.Namespace)
+			res, err := runClusterConfigValidation(ctx, res, validateClusterOpts.Namespace)
 			if err != nil {
 				return err
 			}
@@ -105,4 +136,7 @@ func init() {
 	validateClusterCmd.PersistentFlags().StringVar(&validateClusterOpts.Kube.Config, "kubeconfig", "", "path to the kubeconfig file")
 	validateClusterCmd.PersistentFlags().StringVarP(&validateClusterOpts.Config, "config", "c", os.Getenv("GITPOD_INSTALLER_CONFIG"), "path to the config file")
 	validateClusterCmd.PersistentFlags().StringVarP(&validateClusterOpts.Namespace, "namespace", "n", "default", "namespace to deploy to")
+	validateClusterCmd.PersistentFlags().BoolVar(&validateClusterOpts.ProbeNodes, "probe-nodes", false, "run a privileged DaemonSet to gather the facts of the workspace nodes")
+	validateClusterCmd.PersistentFlags().StringVar(&validateClusterOpts.ProbeImage, "probe-image", cluster.DefaultProbeImage, "image of the node probe, which needs sh, awk, df and find")
+	validateClusterCmd.PersistentFlags().DurationVar(&validateClusterOpts.ProbeTimeout, "probe-timeout", 2*time.Minute, "time to wait for the node probe")
 }
@@ -13,6 +13,7 @@ import (
 	certmanager "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 )
@@ -22,6 +23,10 @@ const (
 	// additional information, which get interpreted as pre-release (eg, 1.2.3-rc4)
 	kernelVersionConstraint     = ">= 5.4.0-0"
 	kubernetesVersionConstraint = ">= 1.21.0-0"
+
+	// Workspace nodes keep the workspace images and content on their disk
+	workspaceNodeDiskConstraint     = "50Gi"
+	workspaceNodeFreeDiskConstraint = "20Gi"
 )
 
 // checkAffinityLabels validates that the nodes have all the required affinity labels applied
@@ -325,3 +330,148 @@ func checkNamespaceExists(ctx context.Context, config *rest.Config, namespace st
 
 	return nil, nil
 }
+
+// isWorkspaceNode returns true if workspaces are scheduled to the node
+func isWorkspaceNode(node corev1.Node) bool {
+	labels := node.GetLabels()
+	return labels[AffinityLabelWorkspacesRegular] == "true" || labels[AffinityLabelWorkspacesHeadless] == "true"
+}
+
+// checkWorkspaceNodeDisk checks the workspace nodes have enough disk. The free disk is checked, too, if the nodes
+// were probed.
+func checkWorkspaceNodeDisk(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+	nodes, err := listNodesFromContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		minDisk     = resource.MustParse(workspaceNodeDiskConstraint)
+		minFreeDisk = resource.MustParse(workspaceNodeFreeDiskConstraint)
+		facts       = nodeFactsFromContext(ctx)
+		res         []ValidationError
+	)
+	for _, node := range nodes {
+		if !isWorkspaceNode(node) {
+			continue
+		}
+
+		capacity, ok := node.Status.Capacity[corev1.ResourceEphemeralStorage]
+		if !ok {
+			res = append(res, ValidationError{
+				Message: "node " + node.Name + " does not report its ephemeral-storage capacity",
+				Type:    ValidationStatusWarning,
+			})
+		} else if capacity.Cmp(minDisk) < 0 {
+			res = append(res, ValidationError{
+				Message: fmt.Sprintf("node %s has %s of disk, which is less than %s", node.Name, capacity.String(), workspaceNodeDiskConstraint),
+				Type:    ValidationStatusError,
+			})
+		}
+
+		f, ok := facts[node.Name]
+		if !ok || f.DiskAvailable == nil {
+			continue
+		}
+		if f.DiskAvailable.Cmp(minFreeDisk) < 0 {
+			res = append(res, ValidationError{
+				Message: fmt.Sprintf("node %s has %s of free disk in /var/lib, which is less than %s", node.Name, f.DiskAvailable.String(), workspaceNodeFreeDiskConstraint),
+				Type:    ValidationStatusWarning,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// checkNodeProbe checks the probe gathered the facts of all workspace nodes
+func checkNodeProbe(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+	var res []ValidationError
+	for _, f := range sortedNodeFacts(ctx) {
+		if f.Error == "" {
+			continue
+		}
+		res = append(res, ValidationError{
+			Message: "cannot gather the facts of node " + f.Node + ": " + f.Error,
+			Type:    ValidationStatusWarning,
+		})
+	}
+	return res, nil
+}
+
+// checkCgroupVersion checks the workspace nodes use cgroup v2. Workspaces run on cgroup v1, too,
+// but cannot manage their own cgroups then.
+func checkCgroupVersion(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+	var (
+		res      []ValidationError
+		versions = make(map[int]struct{})
+	)
+	for _, f := range sortedNodeFacts(ctx) {
+		if f.Error != "" {
+			continue
+		}
+
+		switch f.CgroupVersion {
+		case 2:
+		case 1:
+			res = append(res, ValidationError{
+				Message: "node " + f.Node + " uses cgroup v1 - workspaces cannot manage their own cgroups",
+				Type:    ValidationStatusWarning,
+			})
+		default:
+			res = append(res, ValidationError{
+				Message: "cannot determine the cgroup version of node " + f.Node,
+				Type:    ValidationStatusError,
+			})
+			continue
+		}
+		versions[f.CgroupVersion] = struct{}{}
+	}
+
+	if len(versions) > 1 {
+		res = append(res, ValidationError{
+			Message: "workspace nodes use different cgroup versions - workspaces behave differently depending on their node",
+			Type:    ValidationStatusWarning,
+		})
+	}
+
+	return res, nil
+}
+
+// CheckFSShiftMethod produces a new check that the workspace nodes support the method to shift the UIDs of
+// workspace filesystems, i.e. shiftfs or fuse
+func CheckFSShiftMethod(method string) ValidationCheck {
+	return ValidationCheck{
+		Name:              "filesystem shift method " + method,
+		Description:       "all workspace nodes support " + method,
+		RequiresNodeFacts: true,
+		Check: func(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+			var res []ValidationError
+			for _, f := range sortedNodeFacts(ctx) {
+				if f.Error != "" {
+					continue
+				}
+
+				switch method {
+				case "shiftfs":
+					if !f.SupportsFilesystem("shiftfs") && !f.ShiftfsModule {
+						res = append(res, ValidationError{
+							Message: "shiftfs is not available on node " + f.Node + " - install the shiftfs kernel module or use fuse",
+							Type:    ValidationStatusError,
+						})
+					}
+				case "fuse":
+					if !f.SupportsFilesystem("fuse") && !f.FuseDevice {
+						res = append(res, ValidationError{
+							Message: "fuse is not available on node " + f.Node + " - load the fuse kernel module",
+							Type:    ValidationStatusError,
+						})
+					}
+				default:
+					return nil, fmt.Errorf("unknown filesystem shift method %s", method)
+				}
+			}
+			return res, nil
+		},
+	}
+}
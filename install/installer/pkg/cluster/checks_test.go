@@ -0,0 +1,472 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/configuration"
+	"github.com/docker/distribution/registry/handlers"
+	_ "github.com/docker/distribution/registry/storage/driver/inmemory"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func quantity(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+	return &q
+}
+
+func TestParseNodeFacts(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Logs        string
+		Expectation NodeFacts
+		Done        bool
+		Error       string
+	}{
+		{
+			Name: "complete",
+			Logs: "cgroupVersion=2\nfilesystems=sysfs,tmpfs,shiftfs,fuse,\nshiftfsModule=true\nfuseDevice=true\ndiskCapacity=102400Ki\ndiskAvailable=51200Ki\n---done---\n",
+			Expectation: NodeFacts{
+				CgroupVersion: 2,
+				Filesystems:   []string{"fuse", "shiftfs", "sysfs", "tmpfs"},
+				ShiftfsModule: true,
+				FuseDevice:    true,
+				DiskCapacity:  quantity("102400Ki"),
+				DiskAvailable: quantity("51200Ki"),
+			},
+			Done: true,
+		},
+		{
+			Name:        "unknown cgroup setup",
+			Logs:        "filesystems=ext4,\n---done---\n",
+			Expectation: NodeFacts{Filesystems: []string{"ext4"}},
+			Done:        true,
+		},
+		{
+			Name:        "not done",
+			Logs:        "cgroupVersion=1\n",
+			Expectation: NodeFacts{CgroupVersion: 1},
+		},
+		{
+			Name:  "invalid disk",
+			Logs:  "diskCapacity=lots\n---done---\n",
+			Error: "cannot parse diskCapacity: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, done, err := parseNodeFacts([]byte(test.Logs))
+			if test.Error != "" {
+				if err == nil || err.Error() != test.Error {
+					t.Fatalf("expected error %q, got %v", test.Error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if done != test.Done {
+				t.Errorf("expected done to be %v, got %v", test.Done, done)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected node facts (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNodeFactChecks(t *testing.T) {
+	facts := map[string]NodeFacts{
+		"ws-1":   {Node: "ws-1", CgroupVersion: 2, Filesystems: []string{"fuse", "shiftfs"}},
+		"ws-2":   {Node: "ws-2", CgroupVersion: 1, ShiftfsModule: true},
+		"ws-3":   {Node: "ws-3", FuseDevice: true},
+		"broken": {Node: "broken", Error: "probe did not finish within 2m0s: pod probe-x is ErrImagePull"},
+	}
+
+	tests := []struct {
+		Name        string
+		Check       ValidationCheckFunc
+		Expectation []ValidationError
+	}{
+		{
+			Name:  "node probe",
+			Check: checkNodeProbe,
+			Expectation: []ValidationError{
+				{Message: "cannot gather the facts of node broken: probe did not finish within 2m0s: pod probe-x is ErrImagePull", Type: ValidationStatusWarning},
+			},
+		},
+		{
+			Name:  "cgroup version",
+			Check: checkCgroupVersion,
+			Expectation: []ValidationError{
+				{Message: "node ws-2 uses cgroup v1 - workspaces cannot manage their own cgroups", Type: ValidationStatusWarning},
+				{Message: "cannot determine the cgroup version of node ws-3", Type: ValidationStatusError},
+				{Message: "workspace nodes use different cgroup versions - workspaces behave differently depending on their node", Type: ValidationStatusWarning},
+			},
+		},
+		{
+			Name:  "shiftfs",
+			Check: CheckFSShiftMethod("shiftfs").Check,
+			Expectation: []ValidationError{
+				{Message: "shiftfs is not available on node ws-3 - install the shiftfs kernel module or use fuse", Type: ValidationStatusError},
+			},
+		},
+		{
+			Name:  "fuse",
+			Check: CheckFSShiftMethod("fuse").Check,
+			Expectation: []ValidationError{
+				{Message: "fuse is not available on node ws-2 - load the fuse kernel module", Type: ValidationStatusError},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, err := test.Check(WithNodeFacts(context.Background(), facts), nil, "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func workspaceNode(name, disk string) corev1.Node {
+	res := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{AffinityLabelWorkspacesRegular: "true"},
+		},
+	}
+	if disk != "" {
+		res.Status.Capacity = corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse(disk)}
+	}
+	return res
+}
+
+func TestCheckWorkspaceNodeDisk(t *testing.T) {
+	nodes := []corev1.Node{
+		workspaceNode("ws-1", "100Gi"),
+		workspaceNode("ws-2", "30Gi"),
+		workspaceNode("ws-3", ""),
+		{ObjectMeta: metav1.ObjectMeta{Name: "meta-1"}},
+	}
+
+	tests := []struct {
+		Name        string
+		Facts       map[string]NodeFacts
+		Expectation []ValidationError
+	}{
+		{
+			Name: "without node facts",
+			Expectation: []ValidationError{
+				{Message: "node ws-2 has 30Gi of disk, which is less than 50Gi", Type: ValidationStatusError},
+				{Message: "node ws-3 does not report its ephemeral-storage capacity", Type: ValidationStatusWarning},
+			},
+		},
+		{
+			Name: "with node facts",
+			Facts: map[string]NodeFacts{
+				"ws-1": {Node: "ws-1", DiskAvailable: quantity("10Gi")},
+				"ws-3": {Node: "ws-3", DiskAvailable: quantity("40Gi")},
+			},
+			Expectation: []ValidationError{
+				{Message: "node ws-1 has 10Gi of free disk in /var/lib, which is less than 20Gi", Type: ValidationStatusWarning},
+				{Message: "node ws-2 has 30Gi of disk, which is less than 50Gi", Type: ValidationStatusError},
+				{Message: "node ws-3 does not report its ephemeral-storage capacity", Type: ValidationStatusWarning},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), keyClientset, fake.NewSimpleClientset())
+			ctx = context.WithValue(ctx, keyNodeList, nodes)
+			if test.Facts != nil {
+				ctx = WithNodeFacts(ctx, test.Facts)
+			}
+
+			act, err := checkWorkspaceNodeDisk(ctx, nil, "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateSkipsChecksRequiringNodeFacts(t *testing.T) {
+	ok := func(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+		return nil, nil
+	}
+	checks := ValidationChecks{
+		{Name: "always", Check: ok},
+		{Name: "node facts", Check: ok, RequiresNodeFacts: true},
+	}
+
+	for _, probed := range []bool{false, true} {
+		ctx := context.WithValue(context.Background(), keyClientset, fake.NewSimpleClientset())
+		if probed {
+			ctx = WithNodeFacts(ctx, nil)
+		}
+
+		res, err := checks.Validate(ctx, &rest.Config{}, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var act []string
+		for _, item := range res.Items {
+			act = append(act, item.Name)
+		}
+
+		expectation := []string{"always"}
+		if probed {
+			expectation = append(expectation, "node facts")
+		}
+		if diff := cmp.Diff(expectation, act); diff != "" {
+			t.Errorf("unexpected checks with probed=%v (-want +got):\n%s", probed, diff)
+		}
+	}
+}
+
+func TestCheckDNS(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Records     map[string][]string
+		Expectation []ValidationError
+	}{
+		{
+			Name: "valid",
+			Records: map[string][]string{
+				"gitpod.example.com":      {"10.0.0.2", "10.0.0.1"},
+				"*.gitpod.example.com":    {"10.0.0.1", "10.0.0.2"},
+				"*.ws.gitpod.example.com": {"10.0.0.1", "10.0.0.2"},
+			},
+		},
+		{
+			Name: "missing wildcard",
+			Records: map[string][]string{
+				"gitpod.example.com":   {"10.0.0.1"},
+				"*.gitpod.example.com": {"10.0.0.1"},
+			},
+			Expectation: []ValidationError{
+				{Message: "cannot resolve LABEL.ws.gitpod.example.com: no such host - is there a DNS record for *.ws.gitpod.example.com?", Type: ValidationStatusError},
+			},
+		},
+		{
+			Name: "different addresses",
+			Records: map[string][]string{
+				"gitpod.example.com":      {"10.0.0.1"},
+				"*.gitpod.example.com":    {"10.0.0.1"},
+				"*.ws.gitpod.example.com": {"10.0.0.3"},
+			},
+			Expectation: []ValidationError{
+				{Message: "*.ws.gitpod.example.com resolves to 10.0.0.3, but gitpod.example.com to 10.0.0.1", Type: ValidationStatusWarning},
+			},
+		},
+	}
+
+	defer func(orig func(context.Context, string) ([]string, error)) { lookupHost = orig }(lookupHost)
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var label string
+			lookupHost = func(ctx context.Context, host string) ([]string, error) {
+				if addrs, ok := test.Records[host]; ok {
+					return addrs, nil
+				}
+				segs := strings.SplitN(host, ".", 2)
+				if addrs, ok := test.Records["*."+segs[1]]; ok {
+					label = segs[0]
+					return addrs, nil
+				}
+				return nil, fmt.Errorf("no such host")
+			}
+
+			act, err := CheckDNS("gitpod.example.com").Check(context.Background(), nil, "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range act {
+				act[i].Message = strings.ReplaceAll(act[i].Message, label, "LABEL")
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckRegistryPushAccess(t *testing.T) {
+	logrus.SetOutput(ioutil.Discard)
+
+	newRegistry := func(readOnly bool) string {
+		cfg := &configuration.Configuration{
+			Storage: configuration.Storage{
+				"inmemory": configuration.Parameters{},
+				"maintenance": configuration.Parameters{
+					"readonly": map[interface{}]interface{}{"enabled": readOnly},
+				},
+			},
+		}
+		cfg.HTTP.Secret = "secret"
+		srv := httptest.NewServer(handlers.NewApp(context.Background(), cfg))
+		t.Cleanup(srv.Close)
+
+		u, err := url.Parse(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// plain HTTP is used for localhost only
+		return strings.Replace(u.Host, "127.0.0.1", "localhost", 1)
+	}
+
+	tests := []struct {
+		Name        string
+		ReadOnly    bool
+		Secret      *corev1.Secret
+		Expectation []string
+	}{
+		{
+			Name: "push access",
+		},
+		{
+			Name:        "read-only",
+			ReadOnly:    true,
+			Expectation: []string{"cannot push to"},
+		},
+		{
+			Name:        "invalid secret",
+			Secret:      &corev1.Secret{Data: map[string][]byte{".dockerconfigjson": []byte("nope")}},
+			Expectation: []string{"secret registry-auth contains no valid .dockerconfigjson"},
+		},
+		{
+			Name:   "missing secret",
+			Secret: &corev1.Secret{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			host := newRegistry(test.ReadOnly)
+
+			secret := test.Secret
+			if secret == nil {
+				auth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+				secret = &corev1.Secret{Data: map[string][]byte{
+					".dockerconfigjson": []byte(fmt.Sprintf(`{"auths":{"%s":{"auth":"%s"}}}`, host, auth)),
+				}}
+			}
+			var objs []runtime.Object
+			if secret.Data != nil {
+				secret.Name, secret.Namespace = "registry-auth", "default"
+				objs = append(objs, secret)
+			}
+			ctx := context.WithValue(context.Background(), keyClientset, fake.NewSimpleClientset(objs...))
+
+			res, err := CheckRegistryPushAccess(host+"/gitpod", "registry-auth").Check(ctx, nil, "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(test.Expectation) {
+				t.Fatalf("expected %d errors, got %v", len(test.Expectation), res)
+			}
+			for i, r := range res {
+				if !strings.HasPrefix(r.Message, test.Expectation[i]) || r.Type != ValidationStatusError {
+					t.Errorf("expected error %q, got %v", test.Expectation[i], r)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckObjectStorageS3(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Status      int
+		Secret      *corev1.Secret
+		Expectation []string
+	}{
+		{
+			Name:   "valid credentials",
+			Status: http.StatusNotFound,
+		},
+		{
+			Name:        "invalid credentials",
+			Status:      http.StatusForbidden,
+			Expectation: []string{"cannot access S3 object storage"},
+		},
+		{
+			Name:   "missing secret",
+			Secret: &corev1.Secret{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var requests []string
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				w.WriteHeader(test.Status)
+			}))
+			defer srv.Close()
+			objectStorageTransport = srv.Client().Transport
+			defer func() { objectStorageTransport = nil }()
+
+			secret := test.Secret
+			if secret == nil {
+				secret = &corev1.Secret{Data: map[string][]byte{
+					"accessKeyId":     []byte("key"),
+					"secretAccessKey": []byte("secret"),
+				}}
+			}
+			var objs []runtime.Object
+			if secret.Data != nil {
+				secret.Name, secret.Namespace = "storage", "default"
+				objs = append(objs, secret)
+			}
+			ctx := context.WithValue(context.Background(), keyClientset, fake.NewSimpleClientset(objs...))
+
+			res, err := CheckObjectStorageS3(strings.TrimPrefix(srv.URL, "https://"), "local", "storage").Check(ctx, nil, "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(test.Expectation) {
+				t.Fatalf("expected %d errors, got %v", len(test.Expectation), res)
+			}
+			for i, r := range res {
+				if !strings.HasPrefix(r.Message, test.Expectation[i]) || r.Type != ValidationStatusError {
+					t.Errorf("expected error %q, got %v", test.Expectation[i], r)
+				}
+			}
+			// we must not depend on the permission to list all buckets
+			for _, req := range requests {
+				if req != "HEAD /" && !strings.HasPrefix(req, "HEAD /gitpod-user-") {
+					t.Errorf("unexpected request %s", req)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/containerd/containerd/reference/docker"
+	dockerremote "github.com/containerd/containerd/remotes/docker"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// The checks in this file connect to services outside the cluster. They run where the installer runs,
+// which might have a different network access than the cluster.
+
+const connectivityTimeout = 10 * time.Second
+
+// lookupHost resolves host names - tests replace it to not depend on the DNS
+var lookupHost = net.DefaultResolver.LookupHost
+
+// objectStorageTransport is used to connect to S3 object storage - tests replace it to trust their server
+var objectStorageTransport http.RoundTripper
+
+// CheckDNS produces a new check that the domain, and the wildcard domains of Gitpod and the workspaces
+// resolve to the same addresses
+func CheckDNS(domain string) ValidationCheck {
+	return ValidationCheck{
+		Name:        "DNS records",
+		Description: "the DNS records for " + domain + ", *." + domain + " and *.ws." + domain + " exist",
+		Check: func(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+			ctx, cancel := context.WithTimeout(ctx, connectivityTimeout)
+			defer cancel()
+
+			var res []ValidationError
+			base, err := resolve(ctx, domain)
+			if err != nil {
+				res = append(res, ValidationError{
+					Message: fmt.Sprintf("cannot resolve %s: %v", domain, err),
+					Type:    ValidationStatusError,
+				})
+			}
+
+			// a random label never has a record of its own, hence is resolved by the wildcard record
+			label := "installer-check-" + randomHex(4)
+			for _, wildcard := range []string{domain, "ws." + domain} {
+				host := label + "." + wildcard
+				addrs, err := resolve(ctx, host)
+				if err != nil {
+					res = append(res, ValidationError{
+						Message: fmt.Sprintf("cannot resolve %s: %v - is there a DNS record for *.%s?", host, err, wildcard),
+						Type:    ValidationStatusError,
+					})
+					continue
+				}
+				if base != nil && strings.Join(base, ",") != strings.Join(addrs, ",") {
+					res = append(res, ValidationError{
+						Message: fmt.Sprintf("*.%s resolves to %s, but %s to %s", wildcard, strings.Join(addrs, ", "), domain, strings.Join(base, ", ")),
+						Type:    ValidationStatusWarning,
+					})
+				}
+			}
+
+			return res, nil
+		},
+	}
+}
+
+func resolve(ctx context.Context, host string) ([]string, error) {
+	addrs, err := lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+// CheckObjectStorageS3 produces a new check that the S3 endpoint accepts the credentials of the secret.
+// content-service creates a bucket per user, hence there is no bucket we could check. Instead, we ask
+// for a bucket which does not exist: S3 tells invalid credentials apart from missing buckets, and unlike
+// listing all buckets, this does not require permissions content-service does not need.
+func CheckObjectStorageS3(endpoint, region, secretName string) ValidationCheck {
+	return ValidationCheck{
+		Name:        "S3 object storage reachable",
+		Description: "the S3 object storage at " + endpoint + " accepts the credentials of " + secretName,
+		Check: func(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+			data, err := secretData(ctx, config, namespace, secretName)
+			if err != nil || data == nil {
+				// missing secrets are reported by CheckSecret
+				return nil, err
+			}
+
+			client, err := minio.New(endpoint, &minio.Options{
+				Creds:     credentials.NewStaticV4(string(data["accessKeyId"]), string(data["secretAccessKey"]), ""),
+				Secure:    true,
+				Region:    region,
+				Transport: objectStorageTransport,
+			})
+			if err != nil {
+				return []ValidationError{{
+					Message: fmt.Sprintf("invalid S3 endpoint %s: %v", endpoint, err),
+					Type:    ValidationStatusError,
+				}}, nil
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, connectivityTimeout)
+			defer cancel()
+			_, err = client.BucketExists(ctx, "gitpod-user-installer-check-"+randomHex(8))
+			if err != nil {
+				return []ValidationError{{
+					Message: fmt.Sprintf("cannot access S3 object storage at %s: %v", endpoint, err),
+					Type:    ValidationStatusError,
+				}}, nil
+			}
+
+			return nil, nil
+		},
+	}
+}
+
+// CheckObjectStorageCloudStorage produces a new check that the service account of the secret may access
+// the Cloud Storage buckets of the project. content-service creates a bucket per user, hence we check
+// the project's buckets rather than a single bucket.
+func CheckObjectStorageCloudStorage(project, secretName string) ValidationCheck {
+	return ValidationCheck{
+		Name:        "Cloud Storage object storage reachable",
+		Description: "the service account of " + secretName + " may access the Cloud Storage buckets of project " + project,
+		Check: func(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+			data, err := secretData(ctx, config, namespace, secretName)
+			if err != nil || data == nil {
+				return nil, err
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, connectivityTimeout)
+			defer cancel()
+			client, err := storage.NewClient(ctx, option.WithCredentialsJSON(data["service-account.json"]))
+			if err != nil {
+				return []ValidationError{{
+					Message: fmt.Sprintf("secret %s contains no valid service account: %v", secretName, err),
+					Type:    ValidationStatusError,
+				}}, nil
+			}
+			defer client.Close()
+
+			_, err = client.Buckets(ctx, project).Next()
+			if err != nil && err != iterator.Done {
+				return []ValidationError{{
+					Message: fmt.Sprintf("cannot access the Cloud Storage buckets of project %s: %v", project, err),
+					Type:    ValidationStatusError,
+				}}, nil
+			}
+
+			return nil, nil
+		},
+	}
+}
+
+// CheckObjectStorageAzure produces a new check that the Azure storage account of the secret is reachable
+func CheckObjectStorageAzure(secretName string) ValidationCheck {
+	return ValidationCheck{
+		Name:        "Azure object storage reachable",
+		Description: "the Azure storage account of " + secretName + " is reachable",
+		Check: func(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+			data, err := secretData(ctx, config, namespace, secretName)
+			if err != nil || data == nil {
+				return nil, err
+			}
+			return checkReachable(ctx, fmt.Sprintf("https://%s.blob.core.windows.net", data["accountName"]))
+		},
+	}
+}
+
+// checkReachable checks that the URL responds to HTTP requests - regardless of the status
+func checkReachable(ctx context.Context, url string) ([]ValidationError, error) {
+	ctx, cancel := context.WithTimeout(ctx, connectivityTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return []ValidationError{{
+			Message: fmt.Sprintf("cannot reach %s: %v", url, err),
+			Type:    ValidationStatusError,
+		}}, nil
+	}
+	resp.Body.Close()
+
+	return nil, nil
+}
+
+// CheckRegistryPushAccess produces a new check that the credentials of the pull secret may push images into the
+// registry. The check starts a blob upload, but never completes it.
+func CheckRegistryPushAccess(url, secretName string) ValidationCheck {
+	return ValidationCheck{
+		Name:        "container registry push access",
+		Description: "the credentials of " + secretName + " may push images to " + url,
+		Check: func(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error) {
+			data, err := secretData(ctx, config, namespace, secretName)
+			if err != nil || data == nil {
+				return nil, err
+			}
+
+			var dockerConfig struct {
+				Auths map[string]struct {
+					Username string `json:"username"`
+					Password string `json:"password"`
+					Auth     string `json:"auth"`
+				} `json:"auths"`
+			}
+			err = json.Unmarshal(data[".dockerconfigjson"], &dockerConfig)
+			if err != nil {
+				return []ValidationError{{
+					Message: fmt.Sprintf("secret %s contains no valid .dockerconfigjson: %v", secretName, err),
+					Type:    ValidationStatusError,
+				}}, nil
+			}
+			creds := func(host string) (string, string, error) {
+				if host == "registry-1.docker.io" {
+					host = "index.docker.io"
+				}
+				for k, auth := range dockerConfig.Auths {
+					k = strings.TrimPrefix(strings.TrimPrefix(k, "https://"), "http://")
+					if strings.SplitN(k, "/", 2)[0] != host {
+						continue
+					}
+					if auth.Username == "" && auth.Auth != "" {
+						userpass, err := base64.StdEncoding.DecodeString(auth.Auth)
+						if err != nil {
+							return "", "", err
+						}
+						segs := strings.SplitN(string(userpass), ":", 2)
+						if len(segs) == 2 {
+							return segs[0], segs[1], nil
+						}
+					}
+					return auth.Username, auth.Password, nil
+				}
+				return "", "", nil
+			}
+
+			// image-builder pushes into this repository
+			ref, err := docker.ParseDockerRef(strings.TrimSuffix(url, "/") + "/base-images:installer-check")
+			if err != nil {
+				return []ValidationError{{
+					Message: fmt.Sprintf("invalid registry URL %s: %v", url, err),
+					Type:    ValidationStatusError,
+				}}, nil
+			}
+			resolver := dockerremote.NewResolver(dockerremote.ResolverOptions{
+				Hosts: dockerremote.ConfigureDefaultRegistries(
+					dockerremote.WithAuthorizer(dockerremote.NewDockerAuthorizer(dockerremote.WithAuthCreds(creds))),
+					dockerremote.WithPlainHTTP(dockerremote.MatchLocalhost),
+				),
+			})
+
+			ctx, cancel := context.WithTimeout(ctx, connectivityTimeout)
+			defer cancel()
+			pusher, err := resolver.Pusher(ctx, ref.String())
+			if err != nil {
+				return nil, err
+			}
+			// a random blob never exists already, hence the registry has to accept an upload
+			blob := []byte(randomHex(16))
+			w, err := pusher.Push(ctx, ocispec.Descriptor{
+				MediaType: ocispec.MediaTypeImageLayer,
+				Digest:    digest.FromBytes(blob),
+				Size:      int64(len(blob)),
+			})
+			if err != nil {
+				return []ValidationError{{
+					Message: fmt.Sprintf("cannot push to %s: %v", ref.Name(), err),
+					Type:    ValidationStatusError,
+				}}, nil
+			}
+			w.Close()
+
+			return nil, nil
+		},
+	}
+}
+
+// secretData returns the data of the secret, or nil if there's no such secret
+func secretData(ctx context.Context, config *rest.Config, namespace, name string) (map[string][]byte, error) {
+	client, err := clientsetFromContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if secret.Data == nil {
+		return map[string][]byte{}, nil
+	}
+	return secret.Data, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,335 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// ProbeName is the name of the DaemonSet gathering the node facts
+	ProbeName = "installer-node-probe"
+
+	// DefaultProbeImage is the image the probe runs. It needs sh, awk, df and find.
+	DefaultProbeImage = "docker.io/library/busybox:1.35"
+
+	probeDoneMarker = "---done---"
+)
+
+// probeScript prints the node facts as key=value lines. The host's root is mounted at /host.
+// The pod must stay running, otherwise the DaemonSet restarts it.
+const probeScript = `
+if [ -f /host/sys/fs/cgroup/cgroup.controllers ]; then
+  echo "cgroupVersion=2"
+elif [ -d /host/sys/fs/cgroup/cpu ] || [ -d /host/sys/fs/cgroup/cpu,cpuacct ]; then
+  echo "cgroupVersion=1"
+fi
+echo "filesystems=$(awk '{print $NF}' /proc/filesystems | tr '\n' ',')"
+if [ -n "$(find /host/lib/modules/$(uname -r) -name 'shiftfs.ko*' 2>/dev/null | head -n 1)" ]; then
+  echo "shiftfsModule=true"
+fi
+if [ -c /host/dev/fuse ]; then
+  echo "fuseDevice=true"
+fi
+df -Pk /host/var/lib | awk 'NR == 2 { print "diskCapacity=" $2 "Ki"; print "diskAvailable=" $4 "Ki" }'
+echo "` + probeDoneMarker + `"
+exec sleep 86400
+`
+
+// NodeFacts are facts about a workspace node which the Kubernetes API does not expose.
+// They are gathered by a privileged probe running on the node.
+type NodeFacts struct {
+	Node string `json:"node"`
+	// CgroupVersion is 1 for cgroup v1 or hybrid setups, 2 for unified setups and 0 if unknown
+	CgroupVersion int `json:"cgroupVersion"`
+	// Filesystems lists the filesystems the kernel supports, i.e. whose module is loaded
+	Filesystems []string `json:"filesystems"`
+	// ShiftfsModule is true if the shiftfs kernel module is installed, albeit not necessarily loaded
+	ShiftfsModule bool `json:"shiftfsModule"`
+	// FuseDevice is true if /dev/fuse exists
+	FuseDevice bool `json:"fuseDevice"`
+	// DiskCapacity and DiskAvailable describe the disk of /var/lib, where containerd and the kubelet keep their data
+	DiskCapacity  *resource.Quantity `json:"diskCapacity,omitempty"`
+	DiskAvailable *resource.Quantity `json:"diskAvailable,omitempty"`
+	// Error is set if the probe did not gather the facts of the node
+	Error string `json:"error,omitempty"`
+}
+
+// SupportsFilesystem returns true if the kernel of the node supports the filesystem
+func (f NodeFacts) SupportsFilesystem(fs string) bool {
+	for _, s := range f.Filesystems {
+		if s == fs {
+			return true
+		}
+	}
+	return false
+}
+
+// ProbeOpts configures the probe
+type ProbeOpts struct {
+	Image   string
+	Timeout time.Duration
+}
+
+// ProbeNodes runs a privileged DaemonSet on the workspace nodes to gather their facts, and deletes it afterwards.
+// Nodes whose probe does not finish within the timeout get facts with an error.
+func ProbeNodes(ctx context.Context, config *rest.Config, namespace string, opts ProbeOpts) (map[string]NodeFacts, error) {
+	if opts.Image == "" {
+		opts.Image = DefaultProbeImage
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 2 * time.Minute
+	}
+
+	client, err := clientsetFromContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := client.AppsV1().DaemonSets(namespace).Create(ctx, probeDaemonSet(namespace, opts.Image), metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("DaemonSet %s/%s exists already - is another validation running? Delete it otherwise", namespace, ProbeName)
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot create node probe: %w", err)
+	}
+	defer func() {
+		// ctx might be done already
+		_ = client.AppsV1().DaemonSets(namespace).Delete(context.Background(), ProbeName, metav1.DeleteOptions{
+			PropagationPolicy: func() *metav1.DeletionPropagation { p := metav1.DeletePropagationBackground; return &p }(),
+		})
+	}()
+
+	var (
+		facts    = make(map[string]NodeFacts)
+		deadline = time.Now().Add(opts.Timeout)
+		selector = metav1.FormatLabelSelector(ds.Spec.Selector)
+	)
+	for {
+		ds, err = client.AppsV1().DaemonSets(namespace).Get(ctx, ProbeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+
+		var pending []corev1.Pod
+		for _, pod := range pods.Items {
+			if pod.Spec.NodeName == "" {
+				continue
+			}
+			if _, done := facts[pod.Spec.NodeName]; done {
+				continue
+			}
+			if pod.Status.Phase != corev1.PodRunning {
+				pending = append(pending, pod)
+				continue
+			}
+
+			logs, err := client.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+			if err != nil {
+				pending = append(pending, pod)
+				continue
+			}
+			f, done, err := parseNodeFacts(logs)
+			if err != nil {
+				f.Error = err.Error()
+				done = true
+			}
+			if !done {
+				pending = append(pending, pod)
+				continue
+			}
+			f.Node = pod.Spec.NodeName
+			facts[f.Node] = f
+		}
+
+		scheduled := ds.Status.ObservedGeneration >= ds.Generation
+		if scheduled && len(facts) >= int(ds.Status.DesiredNumberScheduled) {
+			return facts, nil
+		}
+		if time.Now().After(deadline) {
+			for _, pod := range pending {
+				facts[pod.Spec.NodeName] = NodeFacts{
+					Node:  pod.Spec.NodeName,
+					Error: fmt.Sprintf("probe did not finish within %s: %s", opts.Timeout, podState(pod)),
+				}
+			}
+			return facts, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// podState describes why a pod is not running yet
+func podState(pod corev1.Pod) string {
+	for _, c := range pod.Status.ContainerStatuses {
+		if c.State.Waiting != nil && c.State.Waiting.Reason != "" {
+			return fmt.Sprintf("pod %s is %s %s", pod.Name, c.State.Waiting.Reason, c.State.Waiting.Message)
+		}
+	}
+	return fmt.Sprintf("pod %s is %s", pod.Name, pod.Status.Phase)
+}
+
+// parseNodeFacts parses the output of the probe script. Returns false if the script has not finished yet.
+func parseNodeFacts(logs []byte) (res NodeFacts, done bool, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == probeDoneMarker {
+			done = true
+			break
+		}
+
+		segs := strings.SplitN(line, "=", 2)
+		if len(segs) != 2 {
+			continue
+		}
+		key, value := segs[0], segs[1]
+		switch key {
+		case "cgroupVersion":
+			switch value {
+			case "1":
+				res.CgroupVersion = 1
+			case "2":
+				res.CgroupVersion = 2
+			}
+		case "filesystems":
+			for _, fs := range strings.Split(value, ",") {
+				if fs != "" {
+					res.Filesystems = append(res.Filesystems, fs)
+				}
+			}
+			sort.Strings(res.Filesystems)
+		case "shiftfsModule":
+			res.ShiftfsModule = value == "true"
+		case "fuseDevice":
+			res.FuseDevice = value == "true"
+		case "diskCapacity", "diskAvailable":
+			q, err := resource.ParseQuantity(value)
+			if err != nil {
+				return res, false, fmt.Errorf("cannot parse %s: %w", key, err)
+			}
+			if key == "diskCapacity" {
+				res.DiskCapacity = &q
+			} else {
+				res.DiskAvailable = &q
+			}
+		}
+	}
+	return res, done, scanner.Err()
+}
+
+func probeDaemonSet(namespace, image string) *appsv1.DaemonSet {
+	labels := map[string]string{
+		"app":       "gitpod",
+		"component": ProbeName,
+	}
+
+	var workspaceNodes []corev1.NodeSelectorTerm
+	for _, label := range []string{AffinityLabelWorkspacesRegular, AffinityLabelWorkspacesHeadless} {
+		workspaceNodes = append(workspaceNodes, corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      label,
+				Operator: corev1.NodeSelectorOpExists,
+			}},
+		})
+	}
+
+	return &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "DaemonSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ProbeName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Affinity: &corev1.Affinity{
+						NodeAffinity: &corev1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+								NodeSelectorTerms: workspaceNodes,
+							},
+						},
+					},
+					// workspace nodes are often tainted
+					Tolerations:                   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					TerminationGracePeriodSeconds: pointer.Int64(1),
+					Containers: []corev1.Container{{
+						Name:    "probe",
+						Image:   image,
+						Command: []string{"sh", "-c", probeScript},
+						SecurityContext: &corev1.SecurityContext{
+							Privileged: pointer.Bool(true),
+						},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "host",
+							MountPath: "/host",
+							ReadOnly:  true,
+						}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "host",
+						VolumeSource: corev1.VolumeSource{
+							HostPath: &corev1.HostPathVolumeSource{Path: "/"},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// WithNodeFacts adds the facts gathered by ProbeNodes to the context. Checks which require them are skipped otherwise.
+func WithNodeFacts(ctx context.Context, facts map[string]NodeFacts) context.Context {
+	if facts == nil {
+		facts = make(map[string]NodeFacts)
+	}
+	return context.WithValue(ctx, keyNodeFacts, facts)
+}
+
+func nodeFactsFromContext(ctx context.Context) map[string]NodeFacts {
+	res, _ := ctx.Value(keyNodeFacts).(map[string]NodeFacts)
+	return res
+}
+
+// sortedNodeFacts returns the node facts of the context sorted by node name
+func sortedNodeFacts(ctx context.Context) []NodeFacts {
+	facts := nodeFactsFromContext(ctx)
+	res := make([]NodeFacts, 0, len(facts))
+	for _, f := range facts {
+		res = append(res, f)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Node < res[j].Node })
+	return res
+}
@@ -33,6 +33,8 @@ type ValidationCheck struct {
 	Name        string              `json:"name"`
 	Description string              `json:"description"`
 	Check       ValidationCheckFunc `json:"-"`
+	// RequiresNodeFacts skips the check unless the context holds the node facts gathered by ProbeNodes
+	RequiresNodeFacts bool `json:"-"`
 }
 
 type ValidationCheckFunc func(ctx context.Context, config *rest.Config, namespace string) ([]ValidationError, error)
@@ -80,6 +82,23 @@ var ClusterChecks = ValidationChecks{
 		Description: "ensure that the target namespace exists",
 		Check:       checkNamespaceExists,
 	},
+	{
+		Name:        "workspace node disk",
+		Description: "all workspace nodes have at least " + workspaceNodeDiskConstraint + " of disk",
+		Check:       checkWorkspaceNodeDisk,
+	},
+	{
+		Name:              "node probe",
+		Description:       "the facts of all workspace nodes were gathered",
+		Check:             checkNodeProbe,
+		RequiresNodeFacts: true,
+	},
+	{
+		Name:              "cgroup version",
+		Description:       "all workspace nodes use cgroup v2",
+		Check:             checkCgroupVersion,
+		RequiresNodeFacts: true,
+	},
 }
 
 // ValidationChecks are a group of validations
@@ -94,7 +113,7 @@ func (checks ValidationChecks) Validate(ctx context.Context, config *rest.Config
 		Items:  []ValidationItem{},
 	}
 
-	client, err := kubernetes.NewForConfig(config)
+	client, err := clientsetFromContext(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +126,10 @@ func (checks ValidationChecks) Validate(ctx context.Context, config *rest.Config
 	ctx = context.WithValue(ctx, keyNodeList, list)
 
 	for _, check := range checks {
+		if check.RequiresNodeFacts && nodeFactsFromContext(ctx) == nil {
+			continue
+		}
+
 		result := ValidationItem{
 			ValidationCheck: check,
 			Status:          ValidationStatusOk,
@@ -145,6 +168,7 @@ func (checks ValidationChecks) Validate(ctx context.Context, config *rest.Config
 const (
 	keyNodeList  = "nodeListKey"
 	keyClientset = "clientset"
+	keyNodeFacts = "nodeFacts"
 )
 
 func listNodesFromContext(ctx context.Context, config *rest.Config) ([]corev1.Node, error) {
@@ -11,6 +11,7 @@ import (
 	"github.com/gitpod-io/gitpod/agent-smith/pkg/classifier"
 	"github.com/gitpod-io/gitpod/agent-smith/pkg/config"
 	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,6 +20,7 @@ import (
 
 func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 	ascfg := config.ServiceConfig{
+		Namespace:      ctx.Namespace,
 		PProfAddr:      fmt.Sprintf("localhost:%d", PProfPort),
 		PrometheusAddr: fmt.Sprintf("localhost:%d", PrometheusPort),
 		Config: config.Config{
@@ -37,6 +39,7 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 			GitpodAPI: config.GitpodAPI{
 				HostURL: fmt.Sprintf("https://%s", ctx.Config.Domain),
 			},
+			Review: reviewConfig(ctx),
 		},
 	}
 
@@ -59,3 +62,29 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 		},
 	}, nil
 }
+
+// reviewConfig returns the review mode configuration of agent smith, or nil if review mode is disabled.
+// The review API only listens on localhost and is meant to be reached via port-forwarding.
+func reviewConfig(ctx *common.RenderContext) *config.Review {
+	var res *config.Review
+	_ = ctx.WithExperimental(func(ucfg *experimental.Config) error {
+		if ucfg.Workspace == nil || ucfg.Workspace.AgentSmithReview == nil {
+			return nil
+		}
+		review := ucfg.Workspace.AgentSmithReview
+
+		// the token itself lives in the review token secret
+		res = &config.Review{
+			StatePath:         reviewStateDir + "/review.json",
+			Addr:              fmt.Sprintf("localhost:%d", ReviewPort),
+			TokenFile:         reviewTokenDir + "/" + reviewTokenKey,
+			OffensesConfigMap: OffensesConfigMap,
+			WindowHours:       review.WindowHours,
+		}
+		for _, p := range review.Escalation {
+			res.Escalation = append(res.Escalation, config.PenaltyKind(p))
+		}
+		return nil
+	})
+	return res
+}
@@ -8,4 +8,17 @@ const (
 	Component      = "agent-smith"
 	PrometheusPort = 9500
 	PProfPort      = 6060
+	ReviewPort     = 8081
+
+	// ReviewTokenSecret holds the token of the review API
+	ReviewTokenSecret = "agent-smith-review"
+	// OffensesConfigMap is where the agent smith instances share the confirmed offenses of users.
+	// Agent smith creates it on the first confirmed offense.
+	OffensesConfigMap = "agent-smith-offenses"
+
+	// reviewStateDir holds the review queue on the node so that it survives restarts of agent smith
+	reviewStateDir = "/var/lib/gitpod/agent-smith"
+	// reviewTokenDir is where the review token secret is mounted
+	reviewTokenDir = "/review-token"
+	reviewTokenKey = "token"
 )
@@ -19,11 +19,60 @@ import (
 func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 	labels := common.DefaultLabels(Component)
 
-	configHash, err := common.ObjectHash(configmap(ctx))
+	var hashObj []runtime.Object
+	if objs, err := configmap(ctx); err != nil {
+		return nil, err
+	} else {
+		hashObj = append(hashObj, objs...)
+	}
+
+	// we read the review token on startup only
+	if objs, err := reviewTokenSecret(ctx); err != nil {
+		return nil, err
+	} else {
+		hashObj = append(hashObj, objs...)
+	}
+
+	configHash, err := common.ObjectHash(hashObj, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	volumes := []corev1.Volume{{
+		Name: "config",
+		VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: Component},
+		}},
+	}}
+	volumeMounts := []corev1.VolumeMount{{
+		Name:      "config",
+		MountPath: "/config",
+	}}
+	if reviewConfig(ctx) != nil {
+		// the review queue is kept per node
+		volumes = append(volumes, corev1.Volume{
+			Name: "review-state",
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{
+				Path: reviewStateDir,
+				Type: func() *corev1.HostPathType { r := corev1.HostPathDirectoryOrCreate; return &r }(),
+			}},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "review-state",
+			MountPath: reviewStateDir,
+		}, corev1.VolumeMount{
+			Name:      "review-token",
+			MountPath: reviewTokenDir,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "review-token",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: ReviewTokenSecret,
+			}},
+		})
+	}
+
 	return []runtime.Object{&appsv1.DaemonSet{
 		TypeMeta: common.TypeMetaDaemonset,
 		ObjectMeta: metav1.ObjectMeta{
@@ -60,10 +109,7 @@ func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 								"memory": resource.MustParse("32Mi"),
 							},
 						}),
-						VolumeMounts: []corev1.VolumeMount{{
-							Name:      "config",
-							MountPath: "/config",
-						}},
+						VolumeMounts: volumeMounts,
 						Env: common.MergeEnv(
 							common.DefaultEnv(&ctx.Config),
 							common.WorkspaceTracingEnv(ctx),
@@ -79,12 +125,7 @@ func daemonset(ctx *common.RenderContext) ([]runtime.Object, error) {
 							ProcMount:  func() *corev1.ProcMountType { r := corev1.DefaultProcMount; return &r }(),
 						},
 					}, *common.KubeRBACProxyContainer(ctx)},
-					Volumes: []corev1.Volume{{
-						Name: "config",
-						VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
-							LocalObjectReference: corev1.LocalObjectReference{Name: Component},
-						}},
-					}},
+					Volumes: volumes,
 				},
 			},
 		},
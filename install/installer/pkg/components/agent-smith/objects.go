@@ -10,6 +10,7 @@ var Objects = common.CompositeRenderFunc(
 	configmap,
 	daemonset,
 	networkpolicy,
+	reviewTokenSecret,
 	role,
 	rolebinding,
 	common.DefaultServiceAccount(Component),
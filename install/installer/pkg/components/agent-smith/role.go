@@ -15,6 +15,32 @@ import (
 )
 
 func role(ctx *common.RenderContext) ([]runtime.Object, error) {
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{"policy"},
+			Resources:     []string{"podsecuritypolicies"},
+			Verbs:         []string{"use"},
+			ResourceNames: []string{fmt.Sprintf("%s-ns-privileged-unconfined", ctx.Namespace)},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"get", "update"},
+		},
+	}
+	if reviewConfig(ctx) != nil {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"create"},
+		}, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			Verbs:         []string{"get", "update"},
+			ResourceNames: []string{OffensesConfigMap},
+		})
+	}
+
 	return []runtime.Object{&rbacv1.Role{
 		TypeMeta: common.TypeMetaRole,
 		ObjectMeta: metav1.ObjectMeta{
@@ -22,18 +48,6 @@ func role(ctx *common.RenderContext) ([]runtime.Object, error) {
 			Namespace: ctx.Namespace,
 			Labels:    common.DefaultLabels(Component),
 		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups:     []string{"policy"},
-				Resources:     []string{"podsecuritypolicies"},
-				Verbs:         []string{"use"},
-				ResourceNames: []string{fmt.Sprintf("%s-ns-privileged-unconfined", ctx.Namespace)},
-			},
-			{
-				APIGroups: []string{""},
-				Resources: []string{"pods"},
-				Verbs:     []string{"get", "update"},
-			},
-		},
+		Rules: rules,
 	}}, nil
 }
@@ -0,0 +1,41 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package agentsmith
+
+import (
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// reviewTokenSecret holds the token admins authenticate with on the review API,
+// so that it does not end up in the config map of agent smith
+func reviewTokenSecret(ctx *common.RenderContext) ([]runtime.Object, error) {
+	var token string
+	_ = ctx.WithExperimental(func(ucfg *experimental.Config) error {
+		if ucfg.Workspace != nil && ucfg.Workspace.AgentSmithReview != nil {
+			token = ucfg.Workspace.AgentSmithReview.Token
+		}
+		return nil
+	})
+	if token == "" {
+		return nil, nil
+	}
+
+	return []runtime.Object{&corev1.Secret{
+		TypeMeta: common.TypeMetaSecret,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ReviewTokenSecret,
+			Namespace: ctx.Namespace,
+			Labels:    common.DefaultLabels(Component),
+		},
+		Data: map[string][]byte{
+			reviewTokenKey: []byte(token),
+		},
+	}}, nil
+}
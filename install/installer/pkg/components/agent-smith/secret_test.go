@@ -0,0 +1,51 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package agentsmith
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/versions"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func renderContextWithReview(t *testing.T, review *experimental.AgentSmithReview) *common.RenderContext {
+	ctx, err := common.NewRenderContext(config.Config{
+		Experimental: &experimental.Config{
+			Workspace: &experimental.WorkspaceConfig{
+				AgentSmithReview: review,
+			},
+		},
+	}, versions.Manifest{}, "test-namespace")
+	require.NoError(t, err)
+	return ctx
+}
+
+func TestReviewTokenSecret(t *testing.T) {
+	ctx := renderContextWithReview(t, &experimental.AgentSmithReview{Token: "review-secret"})
+
+	objs, err := reviewTokenSecret(ctx)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	require.Equal(t, "review-secret", string(objs[0].(*corev1.Secret).Data[reviewTokenKey]))
+
+	objs, err = configmap(ctx)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	cfg := objs[0].(*corev1.ConfigMap).Data["config.json"]
+	require.False(t, strings.Contains(cfg, "review-secret"), "the config map must not contain the review token")
+	require.Contains(t, cfg, reviewTokenDir+"/"+reviewTokenKey)
+}
+
+func TestReviewTokenSecret_NotConfigured(t *testing.T) {
+	objs, err := reviewTokenSecret(renderContextWithReview(t, nil))
+	require.NoError(t, err)
+	require.Empty(t, objs, "no review token secret should be rendered unless review mode is enabled")
+}
@@ -53,6 +53,11 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 		WorkspaceImageRepository: fmt.Sprintf("%s/workspace-images", registryName),
 	}
 
+	if buildSecretsKey(ctx) != "" {
+		orchestrator.BuildSecretsKeySecret = BuildSecretsKeySecret
+		orchestrator.BuildSecretsKeyFile = BuildSecretsKeyDir + "/key"
+	}
+
 	imgcfg := config.ServiceConfig{
 		Orchestrator: orchestrator,
 		RefCache: config.RefCacheConfig{
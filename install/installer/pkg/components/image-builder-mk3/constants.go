@@ -7,11 +7,13 @@ package image_builder_mk3
 import "github.com/gitpod-io/gitpod/installer/pkg/common"
 
 const (
-	PullSecretFile = "/config/pull-secret.json"
-	BuilderImage   = "image-builder-mk3/bob"
-	Component      = common.ImageBuilderComponent
-	RPCPort        = common.ImageBuilderRPCPort
-	RPCPortName    = "service"
-	PProfPort      = 6060
-	PrometheusPort = 9500
+	PullSecretFile        = "/config/pull-secret.json"
+	BuildSecretsKeySecret = "image-builder-build-secrets-key"
+	BuildSecretsKeyDir    = "/config/build-secrets-key"
+	BuilderImage          = "image-builder-mk3/bob"
+	Component             = common.ImageBuilderComponent
+	RPCPort               = common.ImageBuilderRPCPort
+	RPCPortName           = "service"
+	PProfPort             = 6060
+	PrometheusPort        = 9500
 )
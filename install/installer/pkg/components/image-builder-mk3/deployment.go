@@ -54,6 +54,13 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 		hashObj = append(hashObj, objs...)
 	}
 
+	// we read the build secrets key on startup only
+	if objs, err := buildSecretsKeySecret(ctx); err != nil {
+		return nil, err
+	} else {
+		hashObj = append(hashObj, objs...)
+	}
+
 	configHash, err := common.ObjectHash(hashObj, nil)
 	if err != nil {
 		return nil, err
@@ -104,6 +111,21 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 			SubPath:   ".dockerconfigjson",
 		},
 	}
+	if buildSecretsKey(ctx) != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: "build-secrets-key",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: BuildSecretsKeySecret,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "build-secrets-key",
+			MountPath: BuildSecretsKeyDir,
+			ReadOnly:  true,
+		})
+	}
 	if vol, mnt, _, ok := common.CustomCACertVolume(ctx); ok {
 		volumes = append(volumes, *vol)
 		volumeMounts = append(volumeMounts, *mnt)
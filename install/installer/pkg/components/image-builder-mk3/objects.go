@@ -7,6 +7,7 @@ package image_builder_mk3
 import "github.com/gitpod-io/gitpod/installer/pkg/common"
 
 var Objects = common.CompositeRenderFunc(
+	buildSecretsKeySecret,
 	clusterrole,
 	configmap,
 	deployment,
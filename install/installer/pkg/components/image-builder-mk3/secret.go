@@ -0,0 +1,57 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package image_builder_mk3
+
+import (
+	"fmt"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// buildSecretsKeyLength is the length of the key image-builder-mk3 expects
+const buildSecretsKeyLength = 32
+
+// buildSecretsKey returns the configured build secrets key, or an empty string if build secrets are disabled.
+// The key comes from the config rather than being generated, because image-builder-mk3 and the build
+// workspaces must agree on it across renders.
+func buildSecretsKey(ctx *common.RenderContext) string {
+	var key string
+	_ = ctx.WithExperimental(func(cfg *experimental.Config) error {
+		if cfg.Workspace != nil {
+			key = cfg.Workspace.BuildSecretsKey
+		}
+		return nil
+	})
+	return key
+}
+
+// buildSecretsKeySecret holds the key image-builder-mk3 uses to encrypt build secrets
+// before it passes them on to bob
+func buildSecretsKeySecret(ctx *common.RenderContext) ([]runtime.Object, error) {
+	key := buildSecretsKey(ctx)
+	if key == "" {
+		return nil, nil
+	}
+	if len(key) != buildSecretsKeyLength {
+		return nil, fmt.Errorf("%s: experimental.workspace.buildSecretsKey must be exactly %d characters long", Component, buildSecretsKeyLength)
+	}
+
+	return []runtime.Object{&corev1.Secret{
+		TypeMeta: common.TypeMetaSecret,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BuildSecretsKeySecret,
+			Namespace: ctx.Namespace,
+			Labels:    common.DefaultLabels(Component),
+		},
+		Data: map[string][]byte{
+			"key": []byte(key),
+		},
+	}}, nil
+}
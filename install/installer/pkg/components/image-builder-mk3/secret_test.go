@@ -0,0 +1,54 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package image_builder_mk3
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	config "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/v1/experimental"
+	"github.com/gitpod-io/gitpod/installer/pkg/config/versions"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func renderContextWithBuildSecretsKey(t *testing.T, key string) *common.RenderContext {
+	ctx, err := common.NewRenderContext(config.Config{
+		Experimental: &experimental.Config{
+			Workspace: &experimental.WorkspaceConfig{
+				BuildSecretsKey: key,
+			},
+		},
+	}, versions.Manifest{}, "test-namespace")
+	require.NoError(t, err)
+	return ctx
+}
+
+func TestBuildSecretsKeySecret(t *testing.T) {
+	key := strings.Repeat("k", buildSecretsKeyLength)
+
+	var keys []string
+	for i := 0; i < 2; i++ {
+		objs, err := buildSecretsKeySecret(renderContextWithBuildSecretsKey(t, key))
+		require.NoError(t, err)
+		require.Len(t, objs, 1)
+		keys = append(keys, string(objs[0].(*corev1.Secret).Data["key"]))
+	}
+	require.Equal(t, key, keys[0])
+	require.Equal(t, keys[0], keys[1], "renders must not rotate the build secrets key")
+}
+
+func TestBuildSecretsKeySecret_NotConfigured(t *testing.T) {
+	objs, err := buildSecretsKeySecret(renderContextWithBuildSecretsKey(t, ""))
+	require.NoError(t, err)
+	require.Empty(t, objs, "no build secrets key should be rendered unless configured")
+}
+
+func TestBuildSecretsKeySecret_InvalidLength(t *testing.T) {
+	_, err := buildSecretsKeySecret(renderContextWithBuildSecretsKey(t, "too-short"))
+	require.Error(t, err)
+}
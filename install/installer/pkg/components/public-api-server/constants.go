@@ -10,4 +10,8 @@ const (
 	GRPCPortName      = "grpc"
 	GRPCContainerPort = 9001
 	GRPCServicePort   = 9001
+
+	HTTPPortName      = "http"
+	HTTPContainerPort = 9002
+	HTTPServicePort   = 9002
 )
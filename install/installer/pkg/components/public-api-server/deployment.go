@@ -51,6 +51,7 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 							Args: []string{
 								"run",
 								fmt.Sprintf("--grpc-port=%d", GRPCContainerPort),
+								fmt.Sprintf("--http-port=%d", HTTPContainerPort),
 								fmt.Sprintf("--gitpod-api-url=wss://%s/api/v1", ctx.Config.Domain),
 							},
 							ImagePullPolicy: corev1.PullIfNotPresent,
@@ -65,6 +66,10 @@ func deployment(ctx *common.RenderContext) ([]runtime.Object, error) {
 									ContainerPort: GRPCContainerPort,
 									Name:          GRPCPortName,
 								},
+								{
+									ContainerPort: HTTPContainerPort,
+									Name:          HTTPPortName,
+								},
 							},
 							SecurityContext: &corev1.SecurityContext{
 								Privileged: pointer.Bool(false),
@@ -38,6 +38,7 @@ func TestDeployment_ServerArguments(t *testing.T) {
 	require.EqualValues(t, []string{
 		"run",
 		"--grpc-port=9001",
+		"--http-port=9002",
 		`--gitpod-api-url=wss://test.domain.everything.awesome.is/api/v1`,
 	}, apiContainer.Args)
 }
@@ -14,5 +14,9 @@ func service(ctx *common.RenderContext) ([]runtime.Object, error) {
 			ContainerPort: GRPCContainerPort,
 			ServicePort:   GRPCServicePort,
 		},
+		HTTPPortName: {
+			ContainerPort: HTTPContainerPort,
+			ServicePort:   HTTPServicePort,
+		},
 	})(ctx)
 }
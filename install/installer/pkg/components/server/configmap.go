@@ -76,7 +76,9 @@ func configmap(ctx *common.RenderContext) ([]runtime.Object, error) {
 	defaultBaseImageRegistryWhitelist := []string{}
 	_ = ctx.WithExperimental(func(cfg *experimental.Config) error {
 		if cfg.WebApp != nil && cfg.WebApp.Server != nil {
-			if cfg.WebApp.Server.DefaultBaseImageRegistryWhiteList != nil {
+			if cfg.WebApp.Server.DefaultBaseImageRegistryAllowList != nil {
+				defaultBaseImageRegistryWhitelist = cfg.WebApp.Server.DefaultBaseImageRegistryAllowList
+			} else if cfg.WebApp.Server.DefaultBaseImageRegistryWhiteList != nil {
 				defaultBaseImageRegistryWhitelist = cfg.WebApp.Server.DefaultBaseImageRegistryWhiteList
 			}
 		}
@@ -160,3 +160,40 @@ func TestInvalidBlockedRepositoryRegularExpressions(t *testing.T) {
 
 	require.Error(t, err, "expected to fail when rendering configmap with invalid blocked repo regexp %q", invalidRegexp)
 }
+
+func TestDefaultBaseImageRegistryAllowList(t *testing.T) {
+	tests := []struct {
+		Name        string
+		AllowList   []string
+		WhiteList   []string
+		Expectation []string
+	}{
+		{Name: "none", Expectation: []string{}},
+		{Name: "allowlist", AllowList: []string{"allowed-registry"}, Expectation: []string{"allowed-registry"}},
+		{Name: "deprecated whitelist", WhiteList: []string{"whitelisted-registry"}, Expectation: []string{"whitelisted-registry"}},
+		{Name: "allowlist takes precedence", AllowList: []string{"allowed-registry"}, WhiteList: []string{"whitelisted-registry"}, Expectation: []string{"allowed-registry"}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx, err := common.NewRenderContext(config.Config{
+				Experimental: &experimental.Config{
+					WebApp: &experimental.WebAppConfig{
+						Server: &experimental.ServerConfig{
+							DefaultBaseImageRegistryAllowList: test.AllowList,
+							DefaultBaseImageRegistryWhiteList: test.WhiteList,
+						},
+					},
+				},
+			}, versions.Manifest{}, "test_namespace")
+			require.NoError(t, err)
+
+			objs, err := configmap(ctx)
+			require.NoError(t, err)
+
+			var cfg ConfigSerialized
+			err = json.Unmarshal([]byte(objs[0].(*corev1.ConfigMap).Data["config.json"]), &cfg)
+			require.NoError(t, err)
+			require.Equal(t, test.Expectation, cfg.DefaultBaseImageRegistryWhitelist)
+		})
+	}
+}
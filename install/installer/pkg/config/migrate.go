@@ -0,0 +1,315 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Deprecation describes a config field which is superseded by another one, or removed altogether
+type Deprecation struct {
+	// Path is the dot-separated path of the deprecated field, e.g. experimental.webapp.server.defaultBaseImageRegistryWhitelist
+	Path string
+	// Replacement is the path of the field superseding the deprecated one. An empty replacement means the field
+	// has no successor: migrations report, but keep it.
+	Replacement string
+	// Message explains the deprecation to users
+	Message string
+}
+
+// MigrationFunc rewrites a config document into the next version. doc is the mapping at the root of the document,
+// without the apiVersion field. It returns notes on changes users need to review.
+type MigrationFunc func(doc *yaml.Node) (notes []string, err error)
+
+// MigratableVersion is implemented by config versions which deprecate fields or have a successor.
+// Migrate walks the chain of versions and applies their hooks.
+type MigratableVersion interface {
+	ConfigVersion
+
+	// Deprecations lists the deprecated fields of this version
+	Deprecations() []Deprecation
+
+	// Next returns the version superseding this one, alongside the function which migrates a document into it.
+	// Returns an empty version if this version has no successor.
+	Next() (version string, migrate MigrationFunc)
+}
+
+// MigrationReport describes what a migration changed
+type MigrationReport struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Deprecated lists the deprecated fields the config used
+	Deprecated []DeprecationNotice `json:"deprecated,omitempty"`
+	// Notes list changes users need to review
+	Notes []string `json:"notes,omitempty"`
+}
+
+// DeprecationNotice reports a deprecated field the config used
+type DeprecationNotice struct {
+	Version     string `json:"version"`
+	Path        string `json:"path"`
+	Replacement string `json:"replacement,omitempty"`
+	Message     string `json:"message"`
+	// Migrated is true if the value was moved to its replacement
+	Migrated bool `json:"migrated"`
+}
+
+// Migrate rewrites a config document to the target version, or to CurrentVersion if target is empty.
+// Deprecated fields are moved to their replacement in every version along the way. Comments are preserved
+// where possible.
+func Migrate(in []byte, target string) (out []byte, report *MigrationReport, err error) {
+	if target == "" {
+		target = CurrentVersion
+	}
+	if _, err := LoadConfigVersion(target); err != nil {
+		return nil, nil, err
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(in, &doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("config must be a YAML mapping")
+	}
+
+	// like Load, we assume the current version if there's no apiVersion
+	version := CurrentVersion
+	apiVersion := removeKey(root, "apiVersion")
+	if apiVersion != nil && apiVersion.value.Value != "" {
+		version = apiVersion.value.Value
+	}
+	report = &MigrationReport{From: version}
+
+	for steps := 0; ; steps++ {
+		if steps > len(versions) {
+			return nil, nil, fmt.Errorf("cannot migrate from %s to %s: versions form a cycle", report.From, target)
+		}
+
+		v, err := LoadConfigVersion(version)
+		if err != nil {
+			return nil, nil, err
+		}
+		mv, ok := v.(MigratableVersion)
+		if !ok {
+			if version == target {
+				break
+			}
+			return nil, nil, fmt.Errorf("cannot migrate from %s to %s: %s has no successor", report.From, target, version)
+		}
+
+		for _, d := range mv.Deprecations() {
+			notice, found := applyDeprecation(root, d)
+			if !found {
+				continue
+			}
+			notice.Version = version
+			report.Deprecated = append(report.Deprecated, notice)
+		}
+
+		if version == target {
+			break
+		}
+		next, migrate := mv.Next()
+		if next == "" {
+			return nil, nil, fmt.Errorf("cannot migrate from %s to %s: %s has no successor", report.From, target, version)
+		}
+		notes, err := migrate(root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot migrate from %s to %s: %w", version, next, err)
+		}
+		report.Notes = append(report.Notes, notes...)
+		version = next
+	}
+	report.To = version
+
+	// apiVersion always comes first
+	if apiVersion == nil {
+		apiVersion = &keyValue{
+			key:   &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "apiVersion"},
+			value: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"},
+		}
+		if len(root.Content) > 0 {
+			// the head comment of the first field likely describes the whole document, hence belongs at the top
+			apiVersion.key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+	}
+	apiVersion.value.Value = version
+	root.Content = append([]*yaml.Node{apiVersion.key, apiVersion.value}, root.Content...)
+
+	out, err = encodeDocument(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, report, nil
+}
+
+// SetValue sets the string field at the dot-separated path of a config document, e.g. "repository".
+// Comments are preserved where possible.
+func SetValue(in []byte, path string, value string) ([]byte, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(in, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse config: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config must be a YAML mapping")
+	}
+
+	segs := strings.Split(path, ".")
+	parent := ensurePath(doc.Content[0], segs[:len(segs)-1])
+	if parent == nil {
+		return nil, fmt.Errorf("cannot set %s: %s is not a mapping", path, strings.Join(segs[:len(segs)-1], "."))
+	}
+	if i := findKey(parent, segs[len(segs)-1]); i >= 0 {
+		node := parent.Content[i+1]
+		node.Kind, node.Tag, node.Style, node.Value, node.Content = yaml.ScalarNode, "!!str", 0, value, nil
+	} else {
+		parent.Content = append(parent.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segs[len(segs)-1]},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+		)
+	}
+
+	return encodeDocument(&doc)
+}
+
+func encodeDocument(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// applyDeprecation moves a deprecated field to its replacement. Returns false if the document does not use the field.
+func applyDeprecation(root *yaml.Node, d Deprecation) (notice DeprecationNotice, found bool) {
+	notice = DeprecationNotice{
+		Path:        d.Path,
+		Replacement: d.Replacement,
+		Message:     d.Message,
+	}
+
+	segs := strings.Split(d.Path, ".")
+	parent := lookupPath(root, segs[:len(segs)-1])
+	if parent == nil || findKey(parent, segs[len(segs)-1]) < 0 {
+		return notice, false
+	}
+	if d.Replacement == "" {
+		return notice, true
+	}
+
+	rsegs := strings.Split(d.Replacement, ".")
+	if lookupPath(root, rsegs) != nil {
+		// we must not overwrite what users configured already - they need to resolve this themselves
+		notice.Message += fmt.Sprintf(" - not migrated because %s is set already", d.Replacement)
+		return notice, true
+	}
+	target := ensurePath(root, rsegs[:len(rsegs)-1])
+	if target == nil {
+		notice.Message += fmt.Sprintf(" - not migrated because %s is not a mapping", strings.Join(rsegs[:len(rsegs)-1], "."))
+		return notice, true
+	}
+
+	kv := removeKey(parent, segs[len(segs)-1])
+	kv.key.Value = rsegs[len(rsegs)-1]
+	target.Content = append(target.Content, kv.key, kv.value)
+	pruneEmpty(root, segs[:len(segs)-1])
+
+	notice.Migrated = true
+	return notice, true
+}
+
+type keyValue struct {
+	key, value *yaml.Node
+}
+
+// findKey returns the index of the key in a mapping node, or -1 if the mapping has no such key
+func findKey(mapping *yaml.Node, key string) int {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeKey removes a key from a mapping node and returns it alongside its value
+func removeKey(mapping *yaml.Node, key string) *keyValue {
+	i := findKey(mapping, key)
+	if i < 0 {
+		return nil
+	}
+	res := &keyValue{key: mapping.Content[i], value: mapping.Content[i+1]}
+	mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+	return res
+}
+
+// lookupPath returns the node at path, or nil if there's no such node
+func lookupPath(node *yaml.Node, path []string) *yaml.Node {
+	for _, seg := range path {
+		i := findKey(node, seg)
+		if i < 0 {
+			return nil
+		}
+		node = node.Content[i+1]
+	}
+	return node
+}
+
+// ensurePath returns the mapping at path, creating it if necessary. Returns nil if a node along the path is not a mapping.
+func ensurePath(node *yaml.Node, path []string) *yaml.Node {
+	for _, seg := range path {
+		i := findKey(node, seg)
+		if i < 0 {
+			child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}, child)
+			node = child
+			continue
+		}
+
+		child := node.Content[i+1]
+		if child.Tag == "!!null" {
+			// e.g. `experimental:` without value
+			*child = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		if child.Kind != yaml.MappingNode {
+			return nil
+		}
+		node = child
+	}
+	return node
+}
+
+// pruneEmpty removes the mappings along path which became empty, deepest first
+func pruneEmpty(root *yaml.Node, path []string) {
+	for l := len(path); l > 0; l-- {
+		parent := lookupPath(root, path[:l-1])
+		node := lookupPath(root, path[:l])
+		if node == nil || node.Kind != yaml.MappingNode || len(node.Content) > 0 {
+			return
+		}
+		removeKey(parent, path[l-1])
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package config_test
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/cluster"
+	"github.com/gitpod-io/gitpod/installer/pkg/config"
+	_ "github.com/gitpod-io/gitpod/installer/pkg/config/v1"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the migration tests")
+
+// TestMigrateGolden migrates every config in testdata/migrate to the current version. The migrated config is
+// compared to the .golden file, the migration report to the .report.json file. Run with -update to update them.
+func TestMigrateGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/migrate/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no test fixtures found")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(input, filepath.Ext(input))
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			in, err := ioutil.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, report, err := config.Migrate(in, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := config.Load(string(out)); err != nil {
+				t.Fatalf("migrated config does not load: %v", err)
+			}
+			rep, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rep = append(rep, '\n')
+
+			golden := map[string][]byte{
+				name + ".golden":      out,
+				name + ".report.json": rep,
+			}
+			for fn, act := range golden {
+				if *update {
+					err = ioutil.WriteFile(fn, act, 0644)
+					if err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+
+				exp, err := ioutil.ReadFile(fn)
+				if err != nil {
+					t.Fatalf("cannot read golden file, run with -update to create it: %v", err)
+				}
+				if diff := cmp.Diff(string(exp), string(act)); diff != "" {
+					t.Errorf("unexpected %s (-want +got):\n%s", filepath.Base(fn), diff)
+				}
+			}
+		})
+	}
+}
+
+// testVersion is a config version which only exists to test migration chains
+type testVersion struct {
+	deprecations []config.Deprecation
+	next         string
+	migrate      config.MigrationFunc
+}
+
+func (v testVersion) Factory() interface{}                                   { return &map[string]interface{}{} }
+func (v testVersion) Defaults(obj interface{}) error                         { return nil }
+func (v testVersion) LoadValidationFuncs(*validator.Validate) error          { return nil }
+func (v testVersion) ClusterValidation(interface{}) cluster.ValidationChecks { return nil }
+func (v testVersion) Deprecations() []config.Deprecation                     { return v.deprecations }
+func (v testVersion) Next() (string, config.MigrationFunc)                   { return v.next, v.migrate }
+
+func init() {
+	config.AddVersion("test-v1", testVersion{
+		deprecations: []config.Deprecation{
+			{Path: "legacy.value", Replacement: "modern.value", Message: "legacy is gone"},
+			{Path: "unused", Message: "unused is unused"},
+		},
+		next: "test-v2",
+		migrate: func(doc *yaml.Node) ([]string, error) {
+			// test-v2 renames modern to current
+			for i := 0; i < len(doc.Content); i += 2 {
+				if doc.Content[i].Value == "modern" {
+					doc.Content[i].Value = "current"
+				}
+			}
+			return []string{"modern was renamed to current"}, nil
+		},
+	})
+	config.AddVersion("test-v2", testVersion{
+		deprecations: []config.Deprecation{
+			{Path: "keep", Message: "keep has no replacement"},
+		},
+		next: "test-v3",
+		migrate: func(doc *yaml.Node) ([]string, error) {
+			return nil, nil
+		},
+	})
+	config.AddVersion("test-v3", testVersion{})
+	config.AddVersion("test-cycle", testVersion{
+		next:    "test-cycle",
+		migrate: func(doc *yaml.Node) ([]string, error) { return nil, nil },
+	})
+}
+
+func TestMigrateChain(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Input    string
+		Target   string
+		Expected string
+		Report   *config.MigrationReport
+		Error    string
+	}{
+		{
+			Name:     "full chain",
+			Input:    "apiVersion: test-v1\n# legacy things\nlegacy:\n  value: 42 # the answer\nkeep: true\n",
+			Target:   "test-v3",
+			Expected: "apiVersion: test-v3\nkeep: true\ncurrent:\n  value: 42 # the answer\n",
+			Report: &config.MigrationReport{
+				From: "test-v1",
+				To:   "test-v3",
+				Deprecated: []config.DeprecationNotice{
+					{Version: "test-v1", Path: "legacy.value", Replacement: "modern.value", Message: "legacy is gone", Migrated: true},
+					{Version: "test-v2", Path: "keep", Message: "keep has no replacement"},
+				},
+				Notes: []string{"modern was renamed to current"},
+			},
+		},
+		{
+			Name:     "deprecated field with comments",
+			Input:    "apiVersion: test-v1\nlegacy:\n  other: true\n  # the answer\n  value: 42 # really\n",
+			Target:   "test-v1",
+			Expected: "apiVersion: test-v1\nlegacy:\n  other: true\nmodern:\n  # the answer\n  value: 42 # really\n",
+			Report: &config.MigrationReport{
+				From: "test-v1",
+				To:   "test-v1",
+				Deprecated: []config.DeprecationNotice{
+					{Version: "test-v1", Path: "legacy.value", Replacement: "modern.value", Message: "legacy is gone", Migrated: true},
+				},
+			},
+		},
+		{
+			Name:     "replacement set already",
+			Input:    "apiVersion: test-v1\nlegacy:\n  value: 42\nmodern:\n  value: 23\nunused: true\n",
+			Target:   "test-v1",
+			Expected: "apiVersion: test-v1\nlegacy:\n  value: 42\nmodern:\n  value: 23\nunused: true\n",
+			Report: &config.MigrationReport{
+				From: "test-v1",
+				To:   "test-v1",
+				Deprecated: []config.DeprecationNotice{
+					{Version: "test-v1", Path: "legacy.value", Replacement: "modern.value", Message: "legacy is gone - not migrated because modern.value is set already"},
+					{Version: "test-v1", Path: "unused", Message: "unused is unused"},
+				},
+			},
+		},
+		{
+			Name:     "replacement parent not a mapping",
+			Input:    "apiVersion: test-v1\nlegacy:\n  value: 42\nmodern: true\n",
+			Target:   "test-v1",
+			Expected: "apiVersion: test-v1\nlegacy:\n  value: 42\nmodern: true\n",
+			Report: &config.MigrationReport{
+				From: "test-v1",
+				To:   "test-v1",
+				Deprecated: []config.DeprecationNotice{
+					{Version: "test-v1", Path: "legacy.value", Replacement: "modern.value", Message: "legacy is gone - not migrated because modern is not a mapping"},
+				},
+			},
+		},
+		{
+			Name:     "partial chain",
+			Input:    "apiVersion: test-v1\nother: true\n",
+			Target:   "test-v2",
+			Expected: "apiVersion: test-v2\nother: true\n",
+			Report: &config.MigrationReport{
+				From:  "test-v1",
+				To:    "test-v2",
+				Notes: []string{"modern was renamed to current"},
+			},
+		},
+		{
+			Name:   "downgrade",
+			Input:  "apiVersion: test-v3\n",
+			Target: "test-v1",
+			Error:  "cannot migrate from test-v3 to test-v1: test-v3 has no successor",
+		},
+		{
+			Name:   "unknown target",
+			Input:  "apiVersion: test-v1\n",
+			Target: "test-v42",
+			Error:  "unsupprted API version: test-v42",
+		},
+		{
+			Name:   "cycle",
+			Input:  "apiVersion: test-cycle\n",
+			Target: "test-v3",
+			Error:  "cannot migrate from test-cycle to test-v3: versions form a cycle",
+		},
+		{
+			Name:   "not a mapping",
+			Input:  "- apiVersion: test-v1\n",
+			Target: "test-v3",
+			Error:  "config must be a YAML mapping",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			out, report, err := config.Migrate([]byte(test.Input), test.Target)
+			if test.Error != "" {
+				if err == nil || err.Error() != test.Error {
+					t.Fatalf("expected error %q, got %v", test.Error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expected, string(out)); diff != "" {
+				t.Errorf("unexpected config (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.Report, report); diff != "" {
+				t.Errorf("unexpected report (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		Name        string
+		In          string
+		Path        string
+		Value       string
+		Expectation string
+		Error       string
+	}{
+		{
+			Name:        "replace",
+			In:          "apiVersion: v1\n# where images are pulled from\nrepository: eu.gcr.io/gitpod-core-dev/build # default\ndomain: gitpod.example.com\n",
+			Path:        "repository",
+			Value:       "registry.example.com/gitpod",
+			Expectation: "apiVersion: v1\n# where images are pulled from\nrepository: registry.example.com/gitpod # default\ndomain: gitpod.example.com\n",
+		},
+		{
+			Name:        "add",
+			In:          "apiVersion: v1\ndomain: gitpod.example.com\n",
+			Path:        "repository",
+			Value:       "registry.example.com/gitpod",
+			Expectation: "apiVersion: v1\ndomain: gitpod.example.com\nrepository: registry.example.com/gitpod\n",
+		},
+		{
+			Name:        "nested",
+			In:          "apiVersion: v1\ncontainerRegistry:\n  inCluster: false\n",
+			Path:        "containerRegistry.external.url",
+			Value:       "registry.example.com",
+			Expectation: "apiVersion: v1\ncontainerRegistry:\n  inCluster: false\n  external:\n    url: registry.example.com\n",
+		},
+		{
+			Name:  "not a mapping",
+			In:    "apiVersion: v1\ncontainerRegistry: true\n",
+			Path:  "containerRegistry.inCluster",
+			Value: "false",
+			Error: "cannot set containerRegistry.inCluster: containerRegistry is not a mapping",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			out, err := config.SetValue([]byte(test.In), test.Path, test.Value)
+			if test.Error != "" {
+				if err == nil || err.Error() != test.Error {
+					t.Fatalf("expected error %q, got %v", test.Error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.Expectation, string(out)); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -65,6 +65,22 @@ type WorkspaceConfig struct {
 	} `json:"registryFacade"`
 
 	WorkspaceClasses map[string]WorkspaceClass `json:"classes,omitempty"`
+
+	AgentSmithReview *AgentSmithReview `json:"agentSmithReview,omitempty"`
+
+	// BuildSecretsKey encrypts the build secrets image-builder-mk3 passes on to bob. It must be 32 characters long
+	// and must not change between deployments. Image builds cannot use build secrets without it.
+	BuildSecretsKey string `json:"buildSecretsKey,omitempty"`
+}
+
+// AgentSmithReview puts agent smith into review mode: infringements are penalized only once an admin approved them
+type AgentSmithReview struct {
+	// Token authenticates admins on the review API of each agent smith pod
+	Token string `json:"token" validate:"required"`
+	// WindowHours is the period in which confirmed offenses of a user count towards the escalation
+	WindowHours int `json:"windowHours,omitempty"`
+	// Escalation lists the most severe penalty for the first, second, ... confirmed offense
+	Escalation []string `json:"escalation,omitempty"`
 }
 
 type PersistentVolumeClaim struct {
@@ -141,7 +157,8 @@ type ServerConfig struct {
 	DisableDynamicAuthProviderLogin   bool                `json:"disableDynamicAuthProviderLogin"`
 	EnableLocalApp                    *bool               `json:"enableLocalApp"`
 	RunDbDeleter                      *bool               `json:"runDbDeleter"`
-	DefaultBaseImageRegistryWhiteList []string            `json:"defaultBaseImageRegistryWhitelist"`
+	DefaultBaseImageRegistryAllowList []string            `json:"defaultBaseImageRegistryAllowlist,omitempty"`
+	DefaultBaseImageRegistryWhiteList []string            `json:"defaultBaseImageRegistryWhitelist,omitempty"` // deprecated: use defaultBaseImageRegistryAllowlist
 	DisableWorkspaceGarbageCollection bool                `json:"disableWorkspaceGarbageCollection"`
 	BlockedRepositories               []BlockedRepository `json:"blockedRepositories,omitempty"`
 }
@@ -0,0 +1,25 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package config
+
+import (
+	"github.com/gitpod-io/gitpod/installer/pkg/config"
+)
+
+// Deprecations lists the fields of this version which are superseded
+func (v version) Deprecations() []config.Deprecation {
+	return []config.Deprecation{
+		{
+			Path:        "experimental.webapp.server.defaultBaseImageRegistryWhitelist",
+			Replacement: "experimental.webapp.server.defaultBaseImageRegistryAllowlist",
+			Message:     "the base image registry whitelist was renamed to allowlist",
+		},
+	}
+}
+
+// Next returns the successor of this version. v1 is the current version, hence has no successor yet.
+func (v version) Next() (string, config.MigrationFunc) {
+	return "", nil
+}
@@ -87,25 +87,34 @@ func (v version) ClusterValidation(rcfg interface{}) cluster.ValidationChecks {
 
 	var res cluster.ValidationChecks
 	res = append(res, cluster.CheckSecret(cfg.Certificate.Name, cluster.CheckSecretRequiredData("tls.crt", "tls.key")))
+	res = append(res, cluster.CheckDNS(cfg.Domain))
+
+	if cfg.Kind != InstallationMeta {
+		res = append(res, cluster.CheckFSShiftMethod(string(cfg.Workspace.Runtime.FSShiftMethod)))
+	}
 
 	if cfg.ObjectStorage.CloudStorage != nil {
 		secretName := cfg.ObjectStorage.CloudStorage.ServiceAccount.Name
 		res = append(res, cluster.CheckSecret(secretName, cluster.CheckSecretRequiredData("service-account.json")))
+		res = append(res, cluster.CheckObjectStorageCloudStorage(cfg.ObjectStorage.CloudStorage.Project, secretName))
 	}
 
 	if cfg.ObjectStorage.Azure != nil {
 		secretName := cfg.ObjectStorage.Azure.Credentials.Name
 		res = append(res, cluster.CheckSecret(secretName, cluster.CheckSecretRequiredData("accountName", "accountKey")))
+		res = append(res, cluster.CheckObjectStorageAzure(secretName))
 	}
 
 	if cfg.ObjectStorage.S3 != nil {
 		secretName := cfg.ObjectStorage.S3.Credentials.Name
 		res = append(res, cluster.CheckSecret(secretName, cluster.CheckSecretRequiredData("accessKeyId", "secretAccessKey")))
+		res = append(res, cluster.CheckObjectStorageS3(cfg.ObjectStorage.S3.Endpoint, cfg.Metadata.Region, secretName))
 	}
 
 	if cfg.ContainerRegistry.External != nil {
 		secretName := cfg.ContainerRegistry.External.Certificate.Name
 		res = append(res, cluster.CheckSecret(secretName, cluster.CheckSecretRequiredData(".dockerconfigjson")))
+		res = append(res, cluster.CheckRegistryPushAccess(cfg.ContainerRegistry.External.URL, secretName))
 	}
 
 	if cfg.ContainerRegistry.S3Storage != nil {
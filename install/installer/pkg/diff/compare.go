@@ -0,0 +1,309 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package diff
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// FieldChange is the change of a single field. Live is nil for fields which are added, Desired is nil for fields
+// which are removed.
+type FieldChange struct {
+	Path    string      `json:"path"`
+	Live    interface{} `json:"live,omitempty"`
+	Desired interface{} `json:"desired,omitempty"`
+}
+
+// serverManagedMetadata are the metadata fields the API server maintains
+var serverManagedMetadata = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"managedFields",
+	"selfLink",
+}
+
+// serverManagedAnnotations are the annotations the API server and kubectl maintain
+var serverManagedAnnotations = []string{
+	lastAppliedAnnotation,
+	"deployment.kubernetes.io/revision",
+}
+
+// normalize removes the server-managed fields from an object, and turns the stringData of secrets into data
+func normalize(obj map[string]interface{}) map[string]interface{} {
+	if obj == nil {
+		return nil
+	}
+	res := deepCopy(obj).(map[string]interface{})
+	delete(res, "status")
+
+	if md, ok := res["metadata"].(map[string]interface{}); ok {
+		for _, f := range serverManagedMetadata {
+			delete(md, f)
+		}
+		if annotations, ok := md["annotations"].(map[string]interface{}); ok {
+			for _, a := range serverManagedAnnotations {
+				delete(annotations, a)
+			}
+			if len(annotations) == 0 {
+				delete(md, "annotations")
+			}
+		}
+	}
+
+	if res["kind"] == "Secret" {
+		if sd, ok := res["stringData"].(map[string]interface{}); ok {
+			data, _ := res["data"].(map[string]interface{})
+			if data == nil {
+				data = make(map[string]interface{}, len(sd))
+			}
+			for k, v := range sd {
+				if s, ok := v.(string); ok {
+					data[k] = base64.StdEncoding.EncodeToString([]byte(s))
+				}
+			}
+			res["data"] = data
+			delete(res, "stringData")
+		}
+	}
+
+	return res
+}
+
+// compare lists the changes from live to desired. Fields which are set in the live object only are considered
+// defaults of the API server, unless the last applied configuration shows they were rendered before.
+func compare(path string, desired, live, applied interface{}) []FieldChange {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return []FieldChange{{Path: path, Live: live, Desired: desired}}
+		}
+		a, _ := applied.(map[string]interface{})
+
+		var res []FieldChange
+		for _, k := range sortedKeys(d) {
+			lv, exists := l[k]
+			if !exists {
+				if !isEmpty(d[k]) {
+					res = append(res, FieldChange{Path: joinPath(path, k), Desired: d[k]})
+				}
+				continue
+			}
+			res = append(res, compare(joinPath(path, k), d[k], lv, a[k])...)
+		}
+		for _, k := range sortedKeys(a) {
+			if _, rendered := d[k]; rendered {
+				continue
+			}
+			if lv, exists := l[k]; exists {
+				res = append(res, FieldChange{Path: joinPath(path, k), Live: lv})
+			}
+		}
+		return res
+
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return []FieldChange{{Path: path, Live: live, Desired: desired}}
+		}
+		a, _ := applied.([]interface{})
+
+		var res []FieldChange
+		for i := range d {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if i >= len(l) {
+				res = append(res, FieldChange{Path: p, Desired: d[i]})
+				continue
+			}
+			var av interface{}
+			if i < len(a) {
+				av = a[i]
+			}
+			res = append(res, compare(p, d[i], l[i], av)...)
+		}
+		for i := len(d); i < len(l); i++ {
+			res = append(res, FieldChange{Path: fmt.Sprintf("%s[%d]", path, i), Live: l[i]})
+		}
+		return res
+
+	default:
+		if scalarEqual(desired, live) {
+			return nil
+		}
+		return []FieldChange{{Path: path, Live: live, Desired: desired}}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// hasPathPrefix returns true if path is prefix, or a field within prefix
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[")
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// scalarEqual compares scalars, treating numbers of different types as equal if their value is
+func scalarEqual(a, b interface{}) bool {
+	fa, aok := toFloat(a)
+	fb, bok := toFloat(b)
+	if aok && bok {
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			res[k] = deepCopy(e)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, e := range v {
+			res[i] = deepCopy(e)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+const sensitiveValue = "(sensitive value)"
+
+// maskSecrets hides the values of secret data in changes
+func maskSecrets(changes []FieldChange) {
+	for i, c := range changes {
+		if !hasPathPrefix(c.Path, "data") {
+			continue
+		}
+		if c.Live != nil {
+			changes[i].Live = sensitiveValue
+		}
+		if c.Desired != nil {
+			changes[i].Desired = sensitiveValue
+		}
+	}
+}
+
+// immutableFields lists the fields which cannot be changed once an object exists, by kind
+var immutableFields = map[string][]string{
+	"Deployment":         {"spec.selector"},
+	"DaemonSet":          {"spec.selector"},
+	"ReplicaSet":         {"spec.selector"},
+	"Job":                {"spec.selector", "spec.template", "spec.completions"},
+	"Service":            {"spec.clusterIP", "spec.clusterIPs", "spec.ipFamilies"},
+	"RoleBinding":        {"roleRef"},
+	"ClusterRoleBinding": {"roleRef"},
+	"StorageClass":       {"provisioner", "parameters", "reclaimPolicy", "volumeBindingMode"},
+}
+
+// mutableStatefulSetFields are the only fields of a StatefulSet spec which may be updated
+var mutableStatefulSetFields = []string{
+	"spec.replicas",
+	"spec.template",
+	"spec.updateStrategy",
+	"spec.persistentVolumeClaimRetentionPolicy",
+	"spec.minReadySeconds",
+}
+
+// destructiveReasons explains why applying a change might lose data or be rejected by the API server
+func destructiveReasons(kind string, action Action, changes []FieldChange, live map[string]interface{}) []string {
+	var res []string
+	switch action {
+	case ActionDelete:
+		res = append(res, "the object is not rendered anymore and will be deleted")
+		switch kind {
+		case "PersistentVolumeClaim":
+			res = append(res, "deleting a PersistentVolumeClaim may delete the data of its volume")
+		case "StatefulSet":
+			res = append(res, "deleting a StatefulSet leaves its PersistentVolumeClaims behind")
+		}
+		return res
+	case ActionUpdate:
+	default:
+		return nil
+	}
+
+	for _, c := range changes {
+		for _, f := range immutableFields[kind] {
+			if hasPathPrefix(c.Path, f) {
+				res = append(res, fmt.Sprintf("%s is immutable: the object must be deleted and recreated", c.Path))
+				break
+			}
+		}
+	}
+
+	switch kind {
+	case "PersistentVolumeClaim":
+		res = append(res, "PersistentVolumeClaims are mostly immutable, changing them may require recreating the volume and losing its data")
+	case "StatefulSet":
+		for _, c := range changes {
+			if !hasPathPrefix(c.Path, "spec") {
+				continue
+			}
+			var mutable bool
+			for _, f := range mutableStatefulSetFields {
+				if hasPathPrefix(c.Path, f) {
+					mutable = true
+					break
+				}
+			}
+			if !mutable {
+				res = append(res, fmt.Sprintf("%s is immutable: the StatefulSet must be deleted and recreated", c.Path))
+			}
+		}
+		res = append(res, "changes to StatefulSets restart their pods one at a time")
+	case "Secret", "ConfigMap":
+		if immutable, _ := live["immutable"].(bool); immutable {
+			res = append(res, fmt.Sprintf("the %s is immutable: it must be deleted and recreated", kind))
+		}
+	}
+
+	return res
+}
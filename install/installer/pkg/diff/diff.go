@@ -0,0 +1,276 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gitpod-io/gitpod/installer/pkg/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// Action describes what applying the rendered objects would do to an object
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionDelete    Action = "delete"
+	ActionUnchanged Action = "unchanged"
+)
+
+// InventoryName is the name of the config map listing all objects of an installation, see common.GenerateInstallationConfigMap
+const InventoryName = "gitpod-app"
+
+// ObjectDiff describes the difference between a rendered object and its live version
+type ObjectDiff struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+
+	Action  Action        `json:"action"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	// Destructive lists the reasons why applying the change might lose data or fail
+	Destructive []string `json:"destructive,omitempty"`
+	// Note explains special circumstances, e.g. if the cluster does not know the kind of the object
+	Note string `json:"note,omitempty"`
+}
+
+// ID returns a human readable identifier of the object
+func (d ObjectDiff) ID() string {
+	if d.Namespace == "" {
+		return fmt.Sprintf("%s/%s %s", d.APIVersion, d.Kind, d.Name)
+	}
+	return fmt.Sprintf("%s/%s %s/%s", d.APIVersion, d.Kind, d.Namespace, d.Name)
+}
+
+// Differ compares rendered objects to their live versions in a cluster
+type Differ struct {
+	Client dynamic.Interface
+	Mapper meta.RESTMapper
+	// Namespace is the namespace Gitpod is installed into. Namespaced objects which don't specify one end up there.
+	Namespace string
+}
+
+// Diff compares the rendered objects to their live versions. Objects which are listed in the live installation
+// inventory, but were not rendered, will be deleted. The result is in the order of the rendered objects, followed
+// by the deletions.
+func (d *Differ) Diff(ctx context.Context, desired []*unstructured.Unstructured) ([]ObjectDiff, error) {
+	var (
+		res      []ObjectDiff
+		rendered = make(map[string]struct{}, len(desired))
+	)
+	for _, obj := range desired {
+		mapping, err := d.Mapper.RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+		if meta.IsNoMatchError(err) {
+			res = append(res, ObjectDiff{
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+				Action:     ActionCreate,
+				Note:       "the cluster does not know this kind yet, e.g. because the CRD is not installed",
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		obj = obj.DeepCopy()
+		d.defaultNamespace(obj, mapping)
+		rendered[objectKey(obj.GroupVersionKind().GroupKind(), obj.GetNamespace(), obj.GetName())] = struct{}{}
+
+		live, err := d.Client.Resource(mapping.Resource).Namespace(obj.GetNamespace()).Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			live = nil
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot get %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+
+		res = append(res, Compare(obj, live))
+	}
+
+	deletions, err := d.deletions(ctx, rendered)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, deletions...)
+
+	return res, nil
+}
+
+// deletions lists the objects of the live installation inventory which were not rendered
+func (d *Differ) deletions(ctx context.Context, rendered map[string]struct{}) ([]ObjectDiff, error) {
+	inventory, err := d.Client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Namespace(d.Namespace).Get(ctx, InventoryName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// Gitpod is not installed yet
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get installation inventory: %w", err)
+	}
+	app, _, _ := unstructured.NestedString(inventory.Object, "data", "app.yaml")
+	installed, err := common.YamlToRuntimeObject([]string{app})
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse installation inventory: %w", err)
+	}
+
+	var res []ObjectDiff
+	for _, o := range installed {
+		gv, err := schema.ParseGroupVersion(o.APIVersion)
+		if err != nil || o.Kind == "" {
+			continue
+		}
+		mapping, err := d.Mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: o.Kind}, gv.Version)
+		if meta.IsNoMatchError(err) {
+			// the kind is gone from the cluster, e.g. because its CRD was removed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var obj unstructured.Unstructured
+		obj.SetAPIVersion(o.APIVersion)
+		obj.SetKind(o.Kind)
+		obj.SetNamespace(o.Metadata.Namespace)
+		obj.SetName(o.Metadata.Name)
+		d.defaultNamespace(&obj, mapping)
+		if _, ok := rendered[objectKey(mapping.GroupVersionKind.GroupKind(), obj.GetNamespace(), obj.GetName())]; ok {
+			continue
+		}
+
+		live, err := d.Client.Resource(mapping.Resource).Namespace(obj.GetNamespace()).Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot get %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		res = append(res, Compare(nil, live))
+	}
+	return res, nil
+}
+
+func (d *Differ) defaultNamespace(obj *unstructured.Unstructured, mapping *meta.RESTMapping) {
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		obj.SetNamespace("")
+		return
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(d.Namespace)
+	}
+}
+
+func objectKey(gk schema.GroupKind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", gk.String(), namespace, name)
+}
+
+// Compare compares a rendered object to its live version. Either may be nil if the object does not exist
+// on that side.
+func Compare(desired, live *unstructured.Unstructured) ObjectDiff {
+	ref := desired
+	if ref == nil {
+		ref = live
+	}
+	res := ObjectDiff{
+		APIVersion: ref.GetAPIVersion(),
+		Kind:       ref.GetKind(),
+		Namespace:  ref.GetNamespace(),
+		Name:       ref.GetName(),
+	}
+
+	switch {
+	case desired == nil:
+		res.Action = ActionDelete
+	case live == nil:
+		res.Action = ActionCreate
+	default:
+		var applied map[string]interface{}
+		if a, ok := live.GetAnnotations()[lastAppliedAnnotation]; ok {
+			// we only need this to find fields which were removed from the rendered objects - if it's broken, we don't
+			_ = json.Unmarshal([]byte(a), &applied)
+			applied = normalize(applied)
+		}
+		res.Changes = compare("", normalize(desired.Object), normalize(live.Object), applied)
+		res.Action = ActionUnchanged
+		if len(res.Changes) > 0 {
+			res.Action = ActionUpdate
+		}
+	}
+
+	if res.Kind == "Secret" {
+		maskSecrets(res.Changes)
+	}
+	var liveObj map[string]interface{}
+	if live != nil {
+		liveObj = live.Object
+	}
+	res.Destructive = destructiveReasons(res.Kind, res.Action, res.Changes, liveObj)
+
+	return res
+}
+
+var documentSeparator = regexp.MustCompile("(^|\n)---")
+
+// ParseManifests parses rendered YAML documents into objects, skipping empty documents
+func ParseManifests(docs []string) ([]*unstructured.Unstructured, error) {
+	var res []*unstructured.Unstructured
+	for _, doc := range docs {
+		for _, part := range documentSeparator.Split(doc, -1) {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			js, err := yaml.YAMLToJSON([]byte(part))
+			if err != nil {
+				return nil, err
+			}
+			if string(js) == "null" {
+				// a document which contains comments only
+				continue
+			}
+			var obj unstructured.Unstructured
+			err = obj.UnmarshalJSON(js)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, &obj)
+		}
+	}
+	return res, nil
+}
+
+// Summary counts the diffs by action, and the number of destructive ones
+func Summary(diffs []ObjectDiff) (actions map[Action]int, destructive int) {
+	actions = make(map[Action]int)
+	for _, d := range diffs {
+		actions[d.Action]++
+		if len(d.Destructive) > 0 {
+			destructive++
+		}
+	}
+	return
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
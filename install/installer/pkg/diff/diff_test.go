@@ -0,0 +1,367 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func parse(t *testing.T, manifest string) *unstructured.Unstructured {
+	objs, err := ParseManifests([]string{manifest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expected one object, got %d", len(objs))
+	}
+	return objs[0]
+}
+
+// withLastApplied sets the last applied configuration of a live object, as kubectl apply does
+func withLastApplied(t *testing.T, live *unstructured.Unstructured, applied *unstructured.Unstructured) *unstructured.Unstructured {
+	b, err := json.Marshal(applied.Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := live.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[lastAppliedAnnotation] = string(b)
+	live.SetAnnotations(annotations)
+	return live
+}
+
+const renderedDeployment = `
+# apps/v1/Deployment server
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: server
+  namespace: default
+  creationTimestamp: null
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      component: server
+  strategy: {}
+  template:
+    metadata:
+      labels:
+        component: server
+    spec:
+      containers:
+        - name: server
+          image: server:v2
+          resources: {}
+status: {}
+`
+
+const liveDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: server
+  namespace: default
+  uid: 6f1c8c3e-0000-0000-0000-000000000000
+  resourceVersion: "1234"
+  generation: 3
+  creationTimestamp: "2022-05-01T12:00:00Z"
+  annotations:
+    deployment.kubernetes.io/revision: "3"
+spec:
+  replicas: 1
+  progressDeadlineSeconds: 600
+  selector:
+    matchLabels:
+      component: server
+  strategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        component: server
+    spec:
+      dnsPolicy: ClusterFirst
+      containers:
+        - name: server
+          image: server:v1
+          imagePullPolicy: IfNotPresent
+status:
+  replicas: 1
+`
+
+func TestCompare(t *testing.T) {
+	type Expectation struct {
+		Action      Action
+		Changes     []FieldChange
+		Destructive []string
+	}
+	tests := []struct {
+		Name        string
+		Desired     func(t *testing.T) *unstructured.Unstructured
+		Live        func(t *testing.T) *unstructured.Unstructured
+		Expectation Expectation
+	}{
+		{
+			Name:    "ignores server-managed and defaulted fields",
+			Desired: func(t *testing.T) *unstructured.Unstructured { return parse(t, renderedDeployment) },
+			Live: func(t *testing.T) *unstructured.Unstructured {
+				live := parse(t, liveDeployment)
+				containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+				containers[0].(map[string]interface{})["image"] = "server:v2"
+				_ = unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers")
+				return live
+			},
+			Expectation: Expectation{Action: ActionUnchanged},
+		},
+		{
+			Name:    "image update",
+			Desired: func(t *testing.T) *unstructured.Unstructured { return parse(t, renderedDeployment) },
+			Live:    func(t *testing.T) *unstructured.Unstructured { return parse(t, liveDeployment) },
+			Expectation: Expectation{
+				Action:  ActionUpdate,
+				Changes: []FieldChange{{Path: "spec.template.spec.containers[0].image", Live: "server:v1", Desired: "server:v2"}},
+			},
+		},
+		{
+			Name: "field removed since last apply",
+			Desired: func(t *testing.T) *unstructured.Unstructured {
+				return parse(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  a: foo\n")
+			},
+			Live: func(t *testing.T) *unstructured.Unstructured {
+				previous := parse(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  a: foo\n  b: bar\n")
+				return withLastApplied(t, previous.DeepCopy(), previous)
+			},
+			Expectation: Expectation{
+				Action:  ActionUpdate,
+				Changes: []FieldChange{{Path: "data.b", Live: "bar"}},
+			},
+		},
+		{
+			Name: "numbers of different types",
+			Desired: func(t *testing.T) *unstructured.Unstructured {
+				return parse(t, "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\nspec:\n  ports:\n    - port: 8080\n")
+			},
+			Live: func(t *testing.T) *unstructured.Unstructured {
+				live := parse(t, "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\nspec:\n  clusterIP: 10.0.0.1\n  ports:\n    - port: 8080\n      protocol: TCP\n")
+				_ = unstructured.SetNestedSlice(live.Object, []interface{}{map[string]interface{}{"port": float64(8080)}}, "spec", "ports")
+				return live
+			},
+			Expectation: Expectation{Action: ActionUnchanged},
+		},
+		{
+			Name: "secret string data",
+			Desired: func(t *testing.T) *unstructured.Unstructured {
+				return parse(t, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\nstringData:\n  user: admin\n  password: new\n")
+			},
+			Live: func(t *testing.T) *unstructured.Unstructured {
+				// admin and old
+				return parse(t, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\ndata:\n  user: YWRtaW4=\n  password: b2xk\n")
+			},
+			Expectation: Expectation{
+				Action:  ActionUpdate,
+				Changes: []FieldChange{{Path: "data.password", Live: sensitiveValue, Desired: sensitiveValue}},
+			},
+		},
+		{
+			Name: "immutable selector",
+			Desired: func(t *testing.T) *unstructured.Unstructured {
+				desired := parse(t, renderedDeployment)
+				_ = unstructured.SetNestedField(desired.Object, "gitpod-server", "spec", "selector", "matchLabels", "component")
+				return desired
+			},
+			Live: func(t *testing.T) *unstructured.Unstructured {
+				live := parse(t, liveDeployment)
+				containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+				containers[0].(map[string]interface{})["image"] = "server:v2"
+				_ = unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers")
+				return live
+			},
+			Expectation: Expectation{
+				Action:      ActionUpdate,
+				Changes:     []FieldChange{{Path: "spec.selector.matchLabels.component", Live: "server", Desired: "gitpod-server"}},
+				Destructive: []string{"spec.selector.matchLabels.component is immutable: the object must be deleted and recreated"},
+			},
+		},
+		{
+			Name: "stateful set volume claim templates",
+			Desired: func(t *testing.T) *unstructured.Unstructured {
+				return parse(t, "apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: db\nspec:\n  volumeClaimTemplates:\n    - spec:\n        resources:\n          requests:\n            storage: 20Gi\n")
+			},
+			Live: func(t *testing.T) *unstructured.Unstructured {
+				return parse(t, "apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: db\nspec:\n  volumeClaimTemplates:\n    - spec:\n        resources:\n          requests:\n            storage: 10Gi\n")
+			},
+			Expectation: Expectation{
+				Action:  ActionUpdate,
+				Changes: []FieldChange{{Path: "spec.volumeClaimTemplates[0].spec.resources.requests.storage", Live: "10Gi", Desired: "20Gi"}},
+				Destructive: []string{
+					"spec.volumeClaimTemplates[0].spec.resources.requests.storage is immutable: the StatefulSet must be deleted and recreated",
+					"changes to StatefulSets restart their pods one at a time",
+				},
+			},
+		},
+		{
+			Name: "immutable config map",
+			Desired: func(t *testing.T) *unstructured.Unstructured {
+				return parse(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\nimmutable: true\ndata:\n  a: bar\n")
+			},
+			Live: func(t *testing.T) *unstructured.Unstructured {
+				return parse(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\nimmutable: true\ndata:\n  a: foo\n")
+			},
+			Expectation: Expectation{
+				Action:      ActionUpdate,
+				Changes:     []FieldChange{{Path: "data.a", Live: "foo", Desired: "bar"}},
+				Destructive: []string{"the ConfigMap is immutable: it must be deleted and recreated"},
+			},
+		},
+		{
+			Name:    "create",
+			Desired: func(t *testing.T) *unstructured.Unstructured { return parse(t, renderedDeployment) },
+			Live:    func(t *testing.T) *unstructured.Unstructured { return nil },
+			Expectation: Expectation{
+				Action: ActionCreate,
+			},
+		},
+		{
+			Name:    "delete persistent volume claim",
+			Desired: func(t *testing.T) *unstructured.Unstructured { return nil },
+			Live: func(t *testing.T) *unstructured.Unstructured {
+				return parse(t, "apiVersion: v1\nkind: PersistentVolumeClaim\nmetadata:\n  name: data\n")
+			},
+			Expectation: Expectation{
+				Action: ActionDelete,
+				Destructive: []string{
+					"the object is not rendered anymore and will be deleted",
+					"deleting a PersistentVolumeClaim may delete the data of its volume",
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			d := Compare(test.Desired(t), test.Live(t))
+			act := Expectation{
+				Action:      d.Action,
+				Changes:     d.Changes,
+				Destructive: d.Destructive,
+			}
+			if diff := cmp.Diff(test.Expectation, act); diff != "" {
+				t.Errorf("unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffer(t *testing.T) {
+	var (
+		deployments  = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+		configMaps   = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+		services     = schema.GroupVersionKind{Version: "v1", Kind: "Service"}
+		pvcs         = schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}
+		clusterRoles = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{deployments, configMaps, services, pvcs} {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	mapper.Add(clusterRoles, meta.RESTScopeRoot)
+
+	inventory := parse(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: gitpod-app
+  namespace: gitpod
+data:
+  app.yaml: |
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: server
+      namespace: gitpod
+    ---
+    apiVersion: v1
+    kind: Service
+    metadata:
+      name: old-service
+      namespace: gitpod
+    ---
+    apiVersion: v1
+    kind: PersistentVolumeClaim
+    metadata:
+      name: already-gone
+      namespace: gitpod
+    ---
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: gitpod-app
+      namespace: gitpod
+`)
+	live := parse(t, liveDeployment)
+	live.SetNamespace("gitpod")
+	oldService := parse(t, "apiVersion: v1\nkind: Service\nmetadata:\n  name: old-service\n  namespace: gitpod\n")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), live, oldService, inventory)
+
+	desired, err := ParseManifests([]string{
+		// rendered objects have no namespace, they should end up in the one of the differ
+		"---\n" + renderedDeployment + "---\n" + "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: gitpod-app\n  namespace: gitpod\n" +
+			"---\n# comments only\n" +
+			"---\napiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: gitpod\n  namespace: gitpod\n" +
+			"---\napiVersion: cert-manager.io/v1\nkind: Certificate\nmetadata:\n  name: https-certificates\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desired[0].SetNamespace("")
+
+	differ := &Differ{Client: client, Mapper: mapper, Namespace: "gitpod"}
+	diffs, err := differ.Diff(context.Background(), desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Expectation struct {
+		ID     string
+		Action Action
+	}
+	var act []Expectation
+	for _, d := range diffs {
+		act = append(act, Expectation{ID: d.ID(), Action: d.Action})
+	}
+	expected := []Expectation{
+		{ID: "apps/v1/Deployment gitpod/server", Action: ActionUpdate},
+		// the inventory's data is only set live, and there's no last applied configuration showing it was rendered
+		{ID: "v1/ConfigMap gitpod/gitpod-app", Action: ActionUnchanged},
+		{ID: "rbac.authorization.k8s.io/v1/ClusterRole gitpod", Action: ActionCreate},
+		{ID: "cert-manager.io/v1/Certificate https-certificates", Action: ActionCreate},
+		{ID: "v1/Service gitpod/old-service", Action: ActionDelete},
+	}
+	if diff := cmp.Diff(expected, act); diff != "" {
+		t.Errorf("unexpected diffs (-want +got):\n%s", diff)
+	}
+	if diffs[3].Note == "" {
+		t.Error("expected a note for the kind unknown to the cluster")
+	}
+
+	actions, destructive := Summary(diffs)
+	if diff := cmp.Diff(map[Action]int{ActionCreate: 2, ActionUpdate: 1, ActionDelete: 1, ActionUnchanged: 1}, actions); diff != "" {
+		t.Errorf("unexpected summary (-want +got):\n%s", diff)
+	}
+	if destructive != 1 {
+		t.Errorf("expected one destructive change, got %d", destructive)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/images/archive"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/reference/docker"
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Bundle pulls the images and writes them into a single OCI image layout tarball. Multi-platform images are
+// bundled for the given platform only. The blobs are downloaded to workdir first.
+func Bundle(ctx context.Context, resolver remotes.Resolver, refs []string, platform ocispec.Platform, workdir string, out io.Writer, progress io.Writer) error {
+	store, err := local.NewStore(workdir)
+	if err != nil {
+		return err
+	}
+
+	var opts []archive.ExportOpt
+	for _, ref := range refs {
+		fmt.Fprintf(progress, "pulling %s\n", ref)
+
+		named, err := docker.ParseDockerRef(ref)
+		if err != nil {
+			return fmt.Errorf("invalid image %s: %w", ref, err)
+		}
+		name, desc, err := resolver.Resolve(ctx, named.String())
+		if err != nil {
+			return fmt.Errorf("cannot resolve %s: %w", ref, err)
+		}
+		fetcher, err := resolver.Fetcher(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		desc, err = resolveManifest(ctx, store, fetcher, desc, platform)
+		if err != nil {
+			return fmt.Errorf("cannot resolve %s: %w", ref, err)
+		}
+		err = images.Dispatch(ctx, images.Handlers(remotes.FetchHandler(store, fetcher), images.ChildrenHandler(store)), nil, desc)
+		if err != nil {
+			return fmt.Errorf("cannot pull %s: %w", ref, err)
+		}
+
+		// we keep the name as listed, so that the bundle can be pushed using the same naming as mirror list
+		opts = append(opts, archive.WithManifest(desc, ref))
+	}
+
+	return archive.Export(ctx, store, out, opts...)
+}
+
+// resolveManifest returns the image manifest for the platform if desc points to an index
+func resolveManifest(ctx context.Context, store content.Store, fetcher remotes.Fetcher, desc ocispec.Descriptor, platform ocispec.Platform) (ocispec.Descriptor, error) {
+	switch desc.MediaType {
+	case images.MediaTypeDockerSchema2Manifest, ocispec.MediaTypeImageManifest:
+		return desc, nil
+	case images.MediaTypeDockerSchema2ManifestList, ocispec.MediaTypeImageIndex:
+	default:
+		return desc, fmt.Errorf("unsupported media type %s", desc.MediaType)
+	}
+
+	_, err := remotes.FetchHandler(store, fetcher)(ctx, desc)
+	if err != nil {
+		return desc, err
+	}
+	p, err := content.ReadBlob(ctx, store, desc)
+	if err != nil {
+		return desc, err
+	}
+	var idx ocispec.Index
+	err = json.Unmarshal(p, &idx)
+	if err != nil {
+		return desc, err
+	}
+
+	var (
+		matcher    = platforms.Only(platform)
+		candidates []ocispec.Descriptor
+	)
+	for _, m := range idx.Manifests {
+		if m.Platform != nil && matcher.Match(*m.Platform) {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		return desc, fmt.Errorf("no manifest for platform %s", platforms.Format(platform))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return matcher.Less(*candidates[i].Platform, *candidates[j].Platform) })
+
+	return candidates[0], nil
+}
+
+// Pushed describes an image pushed from a bundle
+type Pushed struct {
+	Original string        `json:"original"`
+	Target   string        `json:"target"`
+	Digest   digest.Digest `json:"digest"`
+}
+
+// Push loads a bundle written by Bundle and pushes its images. target converts the name an image was bundled
+// with into the name it is pushed as, or returns false to skip the image. The blobs are imported to workdir first.
+//
+// Resolvers track pushed content by digest, regardless of the repository. As images share blobs and every
+// repository needs its own copy, Push uses a new resolver for every image.
+func Push(ctx context.Context, newResolver func() (remotes.Resolver, error), in io.Reader, target func(original string) (string, bool), workdir string, progress io.Writer) ([]Pushed, error) {
+	store, err := local.NewStore(workdir)
+	if err != nil {
+		return nil, err
+	}
+
+	idxDesc, err := archive.ImportIndex(ctx, store, in)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load bundle: %w", err)
+	}
+	p, err := content.ReadBlob(ctx, store, idxDesc)
+	if err != nil {
+		return nil, err
+	}
+	var idx ocispec.Index
+	err = json.Unmarshal(p, &idx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load bundle: %w", err)
+	}
+
+	var res []Pushed
+	for _, desc := range idx.Manifests {
+		original := desc.Annotations[images.AnnotationImageName]
+		if original == "" {
+			return nil, fmt.Errorf("bundle contains image %s without name", desc.Digest)
+		}
+		tgt, ok := target(original)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(progress, "pushing %s to %s\n", original, tgt)
+
+		named, err := docker.ParseDockerRef(tgt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %s: %w", tgt, err)
+		}
+		resolver, err := newResolver()
+		if err != nil {
+			return nil, err
+		}
+		pusher, err := resolver.Pusher(ctx, named.String())
+		if err != nil {
+			return nil, err
+		}
+
+		// the name annotations only make sense within the bundle
+		desc.Annotations = nil
+		err = remotes.PushContent(ctx, pusher, desc, store, nil, platforms.All, nil)
+		if err != nil {
+			return nil, fmt.Errorf("cannot push %s: %w", tgt, err)
+		}
+		res = append(res, Pushed{Original: original, Target: tgt, Digest: desc.Digest})
+	}
+
+	return res, nil
+}
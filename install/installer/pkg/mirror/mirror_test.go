@@ -0,0 +1,221 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/docker/distribution/configuration"
+	"github.com/docker/distribution/registry/handlers"
+	_ "github.com/docker/distribution/registry/storage/driver/inmemory"
+	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestRegistry starts an in-memory registry and returns its host
+func newTestRegistry(t *testing.T) string {
+	logrus.SetOutput(ioutil.Discard)
+
+	cfg := &configuration.Configuration{
+		Storage: configuration.Storage{"inmemory": configuration.Parameters{}},
+	}
+	cfg.HTTP.Secret = "secret"
+	srv := httptest.NewServer(handlers.NewApp(context.Background(), cfg))
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
+
+func newTestResolver() remotes.Resolver {
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(docker.WithPlainHTTP(docker.MatchAllHosts)),
+	})
+}
+
+func writeBlob(t *testing.T, store content.Store, mediaType string, data []byte) ocispec.Descriptor {
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(data), Size: int64(len(data))}
+	err := content.WriteBlob(context.Background(), store, desc.Digest.String(), bytes.NewReader(data), desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+func writeJSONBlob(t *testing.T, store content.Store, mediaType string, v interface{}) ocispec.Descriptor {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeBlob(t, store, mediaType, data)
+}
+
+// pushTestImage pushes a single-layer image for each platform to ref. Images with more than one platform are
+// pushed as index.
+func pushTestImage(t *testing.T, ref string, plats ...string) {
+	store, err := local.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifests []ocispec.Descriptor
+	for _, p := range plats {
+		platform := platforms.MustParse(p)
+		// images share their layers, as real ones do with base layers
+		layer := writeBlob(t, store, ocispec.MediaTypeImageLayer, []byte(p))
+		cfg := writeJSONBlob(t, store, ocispec.MediaTypeImageConfig, ocispec.Image{
+			Architecture: platform.Architecture,
+			OS:           platform.OS,
+			RootFS:       ocispec.RootFS{Type: "layers", DiffIDs: []digest.Digest{layer.Digest}},
+		})
+		mf := writeJSONBlob(t, store, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Config:    cfg,
+			Layers:    []ocispec.Descriptor{layer},
+		})
+		mf.Platform = &platform
+		manifests = append(manifests, mf)
+	}
+
+	desc := manifests[0]
+	if len(manifests) > 1 {
+		desc = writeJSONBlob(t, store, ocispec.MediaTypeImageIndex, ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Manifests: manifests,
+		})
+	}
+	desc.Platform = nil
+
+	pusher, err := newTestResolver().Pusher(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = remotes.PushContent(context.Background(), pusher, desc, store, nil, platforms.All, nil)
+	if err != nil {
+		t.Fatalf("cannot push test image %s: %v", ref, err)
+	}
+}
+
+func TestBundleAndPush(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		source = newTestRegistry(t)
+		target = newTestRegistry(t)
+		refs   = []string{
+			source + "/gitpod/server:commit-1",
+			source + "/gitpod/ide/code:commit-2",
+			source + "/third-party/mysql:5.7",
+		}
+	)
+	pushTestImage(t, refs[0], "linux/amd64")
+	pushTestImage(t, refs[1], "linux/amd64", "linux/arm64")
+	pushTestImage(t, refs[2], "linux/arm64", "linux/amd64")
+	// the same image under another name, which must be pushed into its own repository
+	pushTestImage(t, source+"/gitpod/server-copy:commit-1", "linux/amd64")
+	refs = append(refs, source+"/gitpod/server-copy:commit-1")
+
+	var bundle bytes.Buffer
+	err := Bundle(ctx, newTestResolver(), refs, platforms.MustParse("linux/amd64"), t.TempDir(), &bundle, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushed, err := Push(ctx, func() (remotes.Resolver, error) { return newTestResolver(), nil }, bytes.NewReader(bundle.Bytes()), func(original string) (string, bool) {
+		if strings.Contains(original, "third-party") {
+			return "", false
+		}
+		return strings.Replace(original, source, target+"/mirror", 1), true
+	}, t.TempDir(), ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		act      []string
+		resolver = newTestResolver()
+	)
+	for _, p := range pushed {
+		act = append(act, p.Original+" -> "+p.Target)
+
+		// the pushed image must be the amd64 one and complete
+		_, desc, err := resolver.Resolve(ctx, p.Target)
+		if err != nil {
+			t.Fatalf("cannot resolve pushed image %s: %v", p.Target, err)
+		}
+		if desc.Digest != p.Digest {
+			t.Errorf("unexpected digest of %s: expected %s, got %s", p.Target, p.Digest, desc.Digest)
+		}
+		if desc.MediaType != ocispec.MediaTypeImageManifest {
+			t.Errorf("expected %s to be a single-platform manifest, got %s", p.Target, desc.MediaType)
+		}
+
+		store, err := local.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fetcher, err := resolver.Fetcher(ctx, p.Target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = images.Dispatch(ctx, images.Handlers(remotes.FetchHandler(store, fetcher), images.ChildrenHandler(store)), nil, desc)
+		if err != nil {
+			t.Fatalf("cannot pull pushed image %s: %v", p.Target, err)
+		}
+		cfgDesc, err := images.Config(ctx, store, desc, platforms.All)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cfg ocispec.Image
+		rawCfg, err := content.ReadBlob(ctx, store, cfgDesc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = json.Unmarshal(rawCfg, &cfg)
+		if cfg.Architecture != "amd64" {
+			t.Errorf("expected %s to be the amd64 image, got %s", p.Target, cfg.Architecture)
+		}
+	}
+
+	expected := []string{
+		refs[0] + " -> " + target + "/mirror/gitpod/server:commit-1",
+		refs[1] + " -> " + target + "/mirror/gitpod/ide/code:commit-2",
+		refs[3] + " -> " + target + "/mirror/gitpod/server-copy:commit-1",
+	}
+	if diff := cmp.Diff(expected, act); diff != "" {
+		t.Errorf("unexpected pushed images (-want +got):\n%s", diff)
+	}
+}
+
+func TestBundleMissingPlatform(t *testing.T) {
+	var (
+		source = newTestRegistry(t)
+		ref    = source + "/gitpod/arm-only:v1"
+	)
+	pushTestImage(t, ref, "linux/arm64", "linux/arm/v7")
+
+	err := Bundle(context.Background(), newTestResolver(), []string{ref}, platforms.MustParse("linux/amd64"), t.TempDir(), ioutil.Discard, ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "no manifest for platform linux/amd64") {
+		t.Errorf("expected missing platform error, got %v", err)
+	}
+}
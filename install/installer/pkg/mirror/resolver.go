@@ -0,0 +1,51 @@
+// Copyright (c) 2022 Gitpod GmbH. All rights reserved.
+// Licensed under the GNU Affero General Public License (AGPL).
+// See License-AGPL.txt in the project root for license information.
+
+package mirror
+
+import (
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+)
+
+// dockerHubAuthHost is the host Docker stores the credentials for Docker Hub under
+const dockerHubAuthHost = "https://index.docker.io/v1/"
+
+// NewResolver returns a resolver which authenticates with the credentials of the Docker config,
+// i.e. those of `docker login`. If plainHTTP is true, registries are accessed without TLS.
+func NewResolver(plainHTTP bool) (remotes.Resolver, error) {
+	cfg, err := config.Load(config.Dir())
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []docker.RegistryOpt{
+		docker.WithAuthorizer(docker.NewDockerAuthorizer(docker.WithAuthCreds(dockerConfigCreds(cfg)))),
+	}
+	if plainHTTP {
+		opts = append(opts, docker.WithPlainHTTP(docker.MatchAllHosts))
+	}
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(opts...),
+	}), nil
+}
+
+func dockerConfigCreds(cfg *configfile.ConfigFile) func(host string) (string, string, error) {
+	return func(host string) (user, pass string, err error) {
+		if host == "registry-1.docker.io" {
+			host = dockerHubAuthHost
+		}
+		auth, err := cfg.GetAuthConfig(host)
+		if err != nil {
+			return
+		}
+		if auth.IdentityToken != "" {
+			// as containerd does: an empty user name makes the token the refresh token
+			return "", auth.IdentityToken, nil
+		}
+		return auth.Username, auth.Password, nil
+	}
+}